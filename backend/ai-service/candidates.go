@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"ai-service/recommend"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 参与排序的候选简历数，取最近更新的已发布简历
+const maxCandidateResumes = 1000
+
+// ErrJobNotFound 职位不存在或已删除
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrNotJobMember 调用者不是职位所属企业的有效成员
+var ErrNotJobMember = errors.New("caller is not a member of the job's company")
+
+// 企业对候选人的处理动作
+const (
+	FeedbackApprove = "approve"
+	FeedbackReject  = "reject"
+)
+
+// CandidateRecommendation 候选人推荐结果
+type CandidateRecommendation struct {
+	UserID         uint               `json:"user_id"`
+	ResumeID       uint               `json:"resume_id"`
+	ResumeTitle    string             `json:"resume_title"`
+	Username       string             `json:"username"`
+	Nickname       string             `json:"nickname"`
+	Location       string             `json:"location"`
+	WorkExperience int                `json:"work_experience"`
+	EducationLevel string             `json:"education_level"`
+	Score          float64            `json:"score"`
+	SkillMatch     int                `json:"skill_match"`
+	MatchedSkills  []string           `json:"matched_skills"`
+	Factors        []recommend.Factor `json:"factors"`
+	Reason         string             `json:"reason"`
+	Feedback       string             `json:"feedback,omitempty"` // 已处理过的候选人的处理结果
+}
+
+// AuthorizeJob 校验用户是职位所属企业的有效成员，成员关系由企业服务维护在company_members表中
+func (rs *RecommendationService) AuthorizeJob(jobID, userID uint) error {
+	job, err := rs.loadJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.CompanyID == nil || userID == 0 {
+		return ErrNotJobMember
+	}
+	var members int64
+	if err := rs.dbManager.MySQL.Table("company_members").
+		Where("company_id = ? AND user_id = ? AND status = ?", *job.CompanyID, userID, "active").
+		Count(&members).Error; err != nil {
+		return err
+	}
+	if members == 0 {
+		return ErrNotJobMember
+	}
+	return nil
+}
+
+// GetCandidateRecommendations 为职位推荐候选人：只考虑公开的已发布简历，排除屏蔽了该企业的求职者，
+// 已处理过的候选人默认不再返回，其处理结果用于调整其他候选人的排序
+func (rs *RecommendationService) GetCandidateRecommendations(jobID uint, limit int, includeReviewed bool) ([]CandidateRecommendation, error) {
+	mysql := rs.dbManager.MySQL
+
+	job, err := rs.loadJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := mysql.Where("status = ? AND deleted_at IS NULL", "published").
+		Where("id NOT IN (?)", mysql.Model(&ResumeAuth{}).Select("resume_id").Where("is_public = ?", false))
+	if job.CompanyID != nil {
+		query = query.Where("user_id NOT IN (?)", mysql.Model(&ResumeBlacklist{}).Select("user_id").Where("company_id = ?", *job.CompanyID))
+	}
+	var resumes []Resume
+	if err := query.Order("updated_at DESC").Limit(maxCandidateResumes).Find(&resumes).Error; err != nil {
+		return nil, err
+	}
+
+	// 每个求职者只取一份简历：优先默认简历，否则取最近更新的
+	byUser := make(map[uint]Resume)
+	var userIDs []uint
+	for _, r := range resumes {
+		existing, ok := byUser[r.UserID]
+		if !ok {
+			userIDs = append(userIDs, r.UserID)
+		}
+		if !ok || (r.IsDefault && !existing.IsDefault) {
+			byUser[r.UserID] = r
+		}
+	}
+
+	var feedbacks []CandidateFeedback
+	if err := mysql.Where("job_id = ?", jobID).Find(&feedbacks).Error; err != nil {
+		return nil, err
+	}
+	var feedback recommend.Feedback
+	reviewed := make(map[uint]string, len(feedbacks))
+	for _, f := range feedbacks {
+		reviewed[f.UserID] = f.Action
+		skills := recommend.SkillNames(recommend.ParseSkills(f.Skills))
+		switch f.Action {
+		case FeedbackApprove:
+			feedback.Approved = append(feedback.Approved, skills)
+		case FeedbackReject:
+			feedback.Rejected = append(feedback.Rejected, skills)
+		}
+	}
+	if !includeReviewed {
+		kept := userIDs[:0]
+		for _, id := range userIDs {
+			if _, ok := reviewed[id]; !ok {
+				kept = append(kept, id)
+			}
+		}
+		userIDs = kept
+	}
+	if len(userIDs) == 0 {
+		return []CandidateRecommendation{}, nil
+	}
+
+	var users []User
+	if err := mysql.Select("id, username, nickname, location").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	usersByID := make(map[uint]User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	var profiles []UserProfile
+	if err := mysql.Where("user_id IN ?", userIDs).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	profilesByUser := make(map[uint]UserProfile, len(profiles))
+	for _, p := range profiles {
+		profilesByUser[p.UserID] = p
+	}
+
+	candidates := make([]recommend.Candidate, 0, len(userIDs))
+	for _, id := range userIDs {
+		user, profile, resume := usersByID[id], profilesByUser[id], byUser[id]
+		candidates = append(candidates, recommend.Candidate{
+			UserID:    id,
+			ResumeID:  resume.ID,
+			Name:      user.Username,
+			Profile:   toProfile(user, profile),
+			UpdatedAt: resume.UpdatedAt,
+		})
+	}
+
+	results := recommend.NewScorer().RankCandidates(toRecommendJob(job), candidates, feedback, limit)
+	recommendations := make([]CandidateRecommendation, len(results))
+	for i, r := range results {
+		user := usersByID[r.Candidate.UserID]
+		recommendations[i] = CandidateRecommendation{
+			UserID:         r.Candidate.UserID,
+			ResumeID:       r.Candidate.ResumeID,
+			ResumeTitle:    byUser[r.Candidate.UserID].Title,
+			Username:       user.Username,
+			Nickname:       user.Nickname,
+			Location:       user.Location,
+			WorkExperience: r.Candidate.Profile.WorkExperience,
+			EducationLevel: r.Candidate.Profile.EducationLevel,
+			Score:          r.Score,
+			SkillMatch:     len(r.MatchedSkills),
+			MatchedSkills:  r.MatchedSkills,
+			Factors:        r.Factors,
+			Reason:         r.Reason(),
+			Feedback:       reviewed[r.Candidate.UserID],
+		}
+	}
+	return recommendations, nil
+}
+
+// RecordCandidateFeedback 记录企业对候选人的处理结果，并保存候选人当时的技能用于后续排序
+func (rs *RecommendationService) RecordCandidateFeedback(feedback *CandidateFeedback) error {
+	mysql := rs.dbManager.MySQL
+
+	var count int64
+	if err := mysql.Model(&Job{}).Where("id = ? AND deleted_at IS NULL", feedback.JobID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrJobNotFound
+	}
+
+	var profile UserProfile
+	if err := mysql.Select("skills").Where("user_id = ?", feedback.UserID).First(&profile).Error; err != nil &&
+		!errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	skills, _ := json.Marshal(recommend.SkillNames(recommend.ParseSkills(profile.Skills)))
+	feedback.Skills = string(skills)
+	feedback.Action = strings.ToLower(feedback.Action)
+
+	return mysql.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"resume_id", "action", "operator_id", "skills", "note", "updated_at"}),
+	}).Create(feedback).Error
+}
@@ -0,0 +1,134 @@
+// skillbackfill 把已有职位和用户资料中的技能字段按技能词典标准化后写回
+//
+//	skillbackfill -dsn 'user:pass@tcp(host:3306)/jobfirst?parseTime=true' [-tables jobs,user_profiles] [-dry-run]
+//
+// 新写入的职位由用户服务Job模型的BeforeSave钩子标准化，本工具在词典更新或首次上线时执行；
+// user_profiles目前没有服务写入，需要靠本工具标准化。
+// 不是字符串数组或技能对象数组的值计为无法解析，保持原样，需人工处理。
+// 执行成功退出码为0，出错为2。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"resume-centre/common/skills"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 允许回填的表，都有id主键和JSON类型的skills列
+var backfillTables = map[string]bool{"jobs": true, "user_profiles": true}
+
+type row struct {
+	ID     uint
+	Skills string
+}
+
+type result struct {
+	scanned, changed, failed int
+}
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("SKILL_BACKFILL_DSN"), "MySQL连接串，默认读取SKILL_BACKFILL_DSN")
+	tables := flag.String("tables", "jobs,user_profiles", "逗号分隔的待回填表")
+	batchSize := flag.Int("batch", 500, "每批读取的行数")
+	dryRun := flag.Bool("dry-run", false, "只统计需要修改的行，不写回")
+	verbose := flag.Bool("v", false, "输出每一行的修改前后内容")
+	flag.Parse()
+
+	if *dsn == "" {
+		fail("-dsn is required")
+	}
+	if *batchSize <= 0 {
+		fail("-batch must be positive")
+	}
+	db, err := gorm.Open(mysql.Open(*dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		fail("connect database: %v", err)
+	}
+
+	for _, table := range strings.Split(*tables, ",") {
+		table = strings.TrimSpace(table)
+		if !backfillTables[table] {
+			fail("unsupported table %q", table)
+		}
+		res, err := backfill(db, table, *batchSize, *dryRun, *verbose)
+		if err != nil {
+			fail("%s: %v", table, err)
+		}
+		action := "updated"
+		if *dryRun {
+			action = "would update"
+		}
+		fmt.Printf("%s: scanned %d, %s %d, unparseable %d\n", table, res.scanned, action, res.changed, res.failed)
+	}
+}
+
+// backfill 按主键分批读取，只写回标准化后内容有变化的行
+func backfill(db *gorm.DB, table string, batchSize int, dryRun, verbose bool) (result, error) {
+	var res result
+	dict := skills.Default()
+	var lastID uint
+	for {
+		var rows []row
+		if err := db.Table(table).Select("id, skills").
+			Where("id > ? AND skills IS NOT NULL", lastID).
+			Order("id").Limit(batchSize).Scan(&rows).Error; err != nil {
+			return res, err
+		}
+		if len(rows) == 0 {
+			return res, nil
+		}
+		lastID = rows[len(rows)-1].ID
+
+		for _, r := range rows {
+			res.scanned++
+			if !parseable(r.Skills) {
+				res.failed++
+				fmt.Fprintf(os.Stderr, "%s #%d: unparseable skills %s\n", table, r.ID, r.Skills)
+				continue
+			}
+			normalized, err := dict.NormalizeJSON(r.Skills)
+			if err != nil {
+				res.failed++
+				fmt.Fprintf(os.Stderr, "%s #%d: %v\n", table, r.ID, err)
+				continue
+			}
+			if normalized == r.Skills {
+				continue
+			}
+			res.changed++
+			if verbose {
+				fmt.Printf("%s #%d: %s -> %s\n", table, r.ID, r.Skills, normalized)
+			}
+			if dryRun {
+				continue
+			}
+			// 用UpdateColumn跳过updated_at，回填不算用户修改
+			if err := db.Table(table).Where("id = ?", r.ID).UpdateColumn("skills", normalized).Error; err != nil {
+				return res, fmt.Errorf("update #%d: %w", r.ID, err)
+			}
+		}
+	}
+}
+
+// parseable 只接受字符串数组或技能对象数组，其他内容交给Parse会被当成分隔文本拆开
+func parseable(raw string) bool {
+	var names []string
+	if json.Unmarshal([]byte(raw), &names) == nil {
+		return true
+	}
+	var reqs []skills.Requirement
+	return json.Unmarshal([]byte(raw), &reqs) == nil
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "skillbackfill: "+format+"\n", args...)
+	os.Exit(2)
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"ai-service/recommend"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	cfModelKey     = "recommend:cf:item_model"
+	cfTrainLockKey = "recommend:cf:train_lock"
+	cfAnalysisType = "collaborative_filtering"
+	cfModelReload  = 5 * time.Minute // 多实例部署时，从Redis重新加载其它实例训练的模型
+	cfHoldout      = 0.2             // 离线评估的测试集比例
+	cfEvalK        = 10
+)
+
+// ErrTrainingInProgress 其它实例正在训练协同过滤模型
+var ErrTrainingInProgress = errors.New("collaborative filtering training already in progress")
+
+// 行为对应的隐式反馈强度，只统计target_type为job的行为
+var behaviorWeights = map[string]float64{
+	"view":         1,
+	"job_view":     1,
+	"favorite":     3,
+	"job_favorite": 3,
+	"apply":        5,
+	"job_apply":    5,
+}
+
+var applyActions = []string{"apply", "job_apply"}
+
+// CollaborativeTrainingResult 一次训练的结果
+type CollaborativeTrainingResult struct {
+	TrainedAt    time.Time         `json:"trained_at"`
+	Users        int               `json:"users"`
+	Items        int               `json:"items"`
+	Interactions int               `json:"interactions"`
+	Metrics      recommend.Metrics `json:"metrics"`
+	Duration     string            `json:"duration"`
+}
+
+// GetCollaborativeRecommendations 获取协同过滤推荐：按用户行为历史推荐相似职位，
+// 排除已投递和已下线的职位；没有行为或模型结果不足时用内容匹配结果补足
+func (rs *RecommendationService) GetCollaborativeRecommendations(ctx context.Context, userID uint, limit int) ([]JobRecommendation, error) {
+	applied, err := rs.appliedJobs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations, err := rs.collaborativeCandidates(ctx, userID, applied, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(recommendations) >= limit {
+		return recommendations, nil
+	}
+
+	// 冷启动：用内容匹配补足
+	profile, err := rs.loadProfile(userID)
+	if err != nil && !errors.Is(err, ErrProfileNotFound) {
+		return nil, err
+	}
+	exclude := make(map[uint]bool, len(applied)+len(recommendations))
+	for id := range applied {
+		exclude[id] = true
+	}
+	for _, r := range recommendations {
+		exclude[r.JobID] = true
+	}
+	fallback, err := rs.rankJobs(profile, limit+len(exclude))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range fallback {
+		if len(recommendations) >= limit {
+			break
+		}
+		if !exclude[r.JobID] {
+			recommendations = append(recommendations, r)
+		}
+	}
+	return recommendations, nil
+}
+
+// collaborativeCandidates 只用协同过滤模型推荐，没有模型或用户没有行为历史时返回空
+func (rs *RecommendationService) collaborativeCandidates(ctx context.Context, userID uint, applied map[uint]bool, limit int) ([]JobRecommendation, error) {
+	model := rs.collaborativeModel(ctx)
+	if model == nil {
+		return nil, nil
+	}
+	interactions, err := rs.loadInteractions(time.Now().AddDate(0, 0, -cfHistoryDays()), userID)
+	if err != nil {
+		return nil, err
+	}
+	history := recommend.Preferences(interactions)[userID]
+	if len(history) == 0 {
+		return nil, nil
+	}
+	return rs.recommendFromModel(model, history, applied, limit)
+}
+
+// recommendFromModel 用模型推荐，只保留仍在招的职位
+func (rs *RecommendationService) recommendFromModel(model *recommend.ItemModel, history map[uint]float64, applied map[uint]bool, limit int) ([]JobRecommendation, error) {
+	candidates := model.Candidates(history)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	var jobs []Job
+	if err := rs.dbManager.MySQL.Where("id IN ? AND status = ? AND deleted_at IS NULL", candidates, "active").
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	active := make(map[uint]Job, len(jobs))
+	for _, job := range jobs {
+		active[job.ID] = job
+	}
+
+	recs := model.Recommend(history, limit, func(id uint) bool {
+		_, ok := active[id]
+		return !ok || applied[id]
+	})
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	// 推荐理由引用的历史职位可能已下线，单独查标题
+	becauseIDs := make([]uint, 0, len(recs))
+	for _, r := range recs {
+		becauseIDs = append(becauseIDs, r.Because)
+	}
+	var becauseJobs []Job
+	if err := rs.dbManager.MySQL.Select("id, title").Where("id IN ?", becauseIDs).Find(&becauseJobs).Error; err != nil {
+		return nil, err
+	}
+	titles := make(map[uint]string, len(becauseJobs))
+	for _, job := range becauseJobs {
+		titles[job.ID] = job.Title
+	}
+
+	recommendations := make([]JobRecommendation, len(recs))
+	for i, r := range recs {
+		job := active[r.JobID]
+		reason := "与你关注过的职位相似的用户也关注了这个职位"
+		if title := titles[r.Because]; title != "" {
+			reason = fmt.Sprintf("关注过「%s」的用户也关注了这个职位", title)
+		}
+		recommendations[i] = JobRecommendation{
+			JobID:     job.ID,
+			Title:     job.Title,
+			Company:   job.CompanyName,
+			Location:  job.Location,
+			SalaryMin: job.SalaryMin,
+			SalaryMax: job.SalaryMax,
+			Score:     r.Score,
+			Reason:    reason,
+			Algorithm: "item_cf",
+		}
+	}
+	return recommendations, nil
+}
+
+// appliedJobs 用户已投递的职位
+func (rs *RecommendationService) appliedJobs(userID uint) (map[uint]bool, error) {
+	var ids []uint
+	if err := rs.dbManager.MySQL.Model(&UserBehavior{}).
+		Where("user_id = ? AND target_type = ? AND action IN ? AND target_id IS NOT NULL", userID, "job", applyActions).
+		Distinct().Pluck("target_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		applied[id] = true
+	}
+	return applied, nil
+}
+
+// loadInteractions 读取since之后对职位的行为，userID为0时读取全部用户
+func (rs *RecommendationService) loadInteractions(since time.Time, userID uint) ([]recommend.Interaction, error) {
+	actions := make([]string, 0, len(behaviorWeights))
+	for action := range behaviorWeights {
+		actions = append(actions, action)
+	}
+	query := rs.dbManager.MySQL.Model(&UserBehavior{}).
+		Select("id, user_id, action, target_id, created_at").
+		Where("target_type = ? AND action IN ? AND target_id IS NOT NULL AND created_at >= ?", "job", actions, since)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var interactions []recommend.Interaction
+	var batch []UserBehavior
+	err := query.FindInBatches(&batch, 5000, func(*gorm.DB, int) error {
+		for _, b := range batch {
+			interactions = append(interactions, recommend.Interaction{
+				UserID: b.UserID,
+				JobID:  *b.TargetID,
+				Weight: behaviorWeights[b.Action],
+				At:     b.CreatedAt,
+			})
+		}
+		return nil
+	}).Error
+	return interactions, err
+}
+
+// collaborativeModel 返回当前模型，超过cfModelReload未刷新时从Redis重新加载；没有训练过的模型时返回nil
+func (rs *RecommendationService) collaborativeModel(ctx context.Context) *recommend.ItemModel {
+	rs.cfMu.RLock()
+	model, loadedAt := rs.cfModel, rs.cfLoadedAt
+	rs.cfMu.RUnlock()
+	if model != nil && time.Since(loadedAt) < cfModelReload {
+		return model
+	}
+
+	data, err := rs.dbManager.Redis.Get(ctx, cfModelKey).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Failed to load collaborative filtering model: %v", err)
+		}
+		return model
+	}
+	var loaded recommend.ItemModel
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		log.Printf("Failed to decode collaborative filtering model: %v", err)
+		return model
+	}
+	rs.setCollaborativeModel(&loaded)
+	return &loaded
+}
+
+func (rs *RecommendationService) setCollaborativeModel(model *recommend.ItemModel) {
+	rs.cfMu.Lock()
+	rs.cfModel, rs.cfLoadedAt = model, time.Now()
+	rs.cfMu.Unlock()
+}
+
+// TrainCollaborativeModel 训练协同过滤模型：先在按时间切分的训练集上评估，再用全部数据训练并保存到Redis，
+// 训练记录写入advanced_analytics
+func (rs *RecommendationService) TrainCollaborativeModel(ctx context.Context) (*CollaborativeTrainingResult, error) {
+	// 多实例时只允许一个实例训练
+	acquired, err := rs.dbManager.Redis.SetNX(ctx, cfTrainLockKey, os.Getpid(), time.Hour).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrTrainingInProgress
+	}
+	defer rs.dbManager.Redis.Del(context.Background(), cfTrainLockKey)
+
+	started := time.Now()
+	params, _ := json.Marshal(map[string]interface{}{
+		"history_days": cfHistoryDays(),
+		"holdout":      cfHoldout,
+		"k":            cfEvalK,
+		"options":      recommend.DefaultTrainOptions,
+	})
+	record := AdvancedAnalytics{AnalysisType: cfAnalysisType, Data: string(params), Status: "running", CreatedAt: started}
+	if err := rs.dbManager.PostgreSQL.Create(&record).Error; err != nil {
+		log.Printf("Failed to record collaborative filtering training: %v", err)
+	}
+	finish := func(status string, result interface{}) {
+		if record.ID == 0 {
+			return
+		}
+		data, _ := json.Marshal(result)
+		now := time.Now()
+		rs.dbManager.PostgreSQL.Model(&record).Updates(AdvancedAnalytics{Status: status, Result: string(data), CompletedAt: &now})
+	}
+
+	interactions, err := rs.loadInteractions(started.AddDate(0, 0, -cfHistoryDays()), 0)
+	if err != nil {
+		finish("failed", map[string]string{"error": err.Error()})
+		return nil, err
+	}
+
+	train, test := recommend.SplitHoldout(interactions, cfHoldout)
+	metrics := recommend.Evaluate(recommend.TrainItemModel(train, recommend.DefaultTrainOptions), train, test, cfEvalK)
+
+	model := recommend.TrainItemModel(interactions, recommend.DefaultTrainOptions)
+	data, err := json.Marshal(model)
+	if err == nil {
+		err = rs.dbManager.Redis.Set(ctx, cfModelKey, data, 0).Err()
+	}
+	if err != nil {
+		finish("failed", map[string]string{"error": err.Error()})
+		return nil, err
+	}
+	rs.setCollaborativeModel(model)
+
+	result := &CollaborativeTrainingResult{
+		TrainedAt:    model.TrainedAt,
+		Users:        model.Users,
+		Items:        model.Items,
+		Interactions: model.Interactions,
+		Metrics:      metrics,
+		Duration:     time.Since(started).Round(time.Millisecond).String(),
+	}
+	finish("completed", result)
+	log.Printf("Collaborative filtering model trained: %d users, %d jobs, %d interactions, precision@%d=%.4f recall@%d=%.4f",
+		result.Users, result.Items, result.Interactions, cfEvalK, metrics.PrecisionAtK, cfEvalK, metrics.RecallAtK)
+	return result, nil
+}
+
+// LatestCollaborativeTraining 最近一次完成的训练记录
+func (rs *RecommendationService) LatestCollaborativeTraining() (*AdvancedAnalytics, error) {
+	var record AdvancedAnalytics
+	err := rs.dbManager.PostgreSQL.Where("analysis_type = ? AND status = ?", cfAnalysisType, "completed").
+		Order("completed_at DESC").First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// startCollaborativeTraining 定期训练协同过滤模型，Redis中还没有模型时立即训练一次
+func startCollaborativeTraining(ctx context.Context, rs *RecommendationService) {
+	interval := envDuration("CF_TRAIN_INTERVAL", 6*time.Hour)
+	train := func() {
+		if _, err := rs.TrainCollaborativeModel(ctx); err != nil && !errors.Is(err, ErrTrainingInProgress) {
+			log.Printf("Failed to train collaborative filtering model: %v", err)
+		}
+	}
+
+	go func() {
+		if rs.collaborativeModel(ctx) == nil {
+			train()
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				train()
+			}
+		}
+	}()
+}
+
+// cfHistoryDays 参与训练和推荐的行为时间窗口（天）
+func cfHistoryDays() int {
+	if days, err := strconv.Atoi(os.Getenv("CF_HISTORY_DAYS")); err == nil && days > 0 {
+		return days
+	}
+	return 180
+}
+
+// envDuration 读取时长类型的环境变量，如6h、30m
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return defaultValue
+}
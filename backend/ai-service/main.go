@@ -1,11 +1,20 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
+
+	"ai-service/serving"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,16 +36,23 @@ func NewAIHandler(dbManager *DatabaseManager) *AIHandler {
 
 // GetJobRecommendations 获取职位推荐
 func (h *AIHandler) GetJobRecommendations(c *gin.Context) {
-	userIDStr := c.Param("userID")
-	limit := 5 // 默认推荐数量
-
-	// 解析用户ID
-	var userID uint
-	fmt.Sscanf(userIDStr, "%d", &userID)
+	userID, ok := requireSelf(c)
+	if !ok {
+		return
+	}
+	limit := parseLimit(c, 5) // 默认推荐数量
 
 	// 使用推荐服务获取推荐
-	recommendations, err := h.recommendationService.GetJobRecommendations(userID, limit)
+	served, err := h.recommendationService.GetJobRecommendations(c.Request.Context(), userID, limit)
+	if errors.Is(err, ErrProfileNotFound) {
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "User profile not found",
+		})
+		return
+	}
 	if err != nil {
+		log.Printf("Failed to get job recommendations for user %d: %v", userID, err)
 		c.JSON(500, gin.H{
 			"success": false,
 			"error":   "Failed to get recommendations",
@@ -47,33 +63,214 @@ func (h *AIHandler) GetJobRecommendations(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"success": true,
 		"data": gin.H{
-			"recommendations": recommendations,
-			"total":           len(recommendations),
+			"recommendations": served.Recommendations,
+			"total":           len(served.Recommendations),
 			"user_id":         userID,
+			"request_id":      served.RequestID,
+			"experiment":      served.Experiment,
+			"variant":         served.Variant,
 		},
 	})
 }
 
-// GetSkillRecommendations 获取技能推荐
-func (h *AIHandler) GetSkillRecommendations(c *gin.Context) {
-	userID := c.Param("userID")
+// RecordRecommendationEvent 上报推荐结果的点击或投递，request_id为获取推荐时返回的请求ID
+func (h *AIHandler) RecordRecommendationEvent(c *gin.Context) {
+	userID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	var request struct {
+		RequestID string `json:"request_id" binding:"required"`
+		JobID     uint   `json:"job_id" binding:"required"`
+		Action    string `json:"action" binding:"required,oneof=click apply"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+		return
+	}
 
-	// 模拟技能推荐数据
-	recommendations := []map[string]interface{}{
-		{
-			"skill_name": "Spring Boot",
-			"category":   "framework",
-			"score":      0.92,
-			"demand":     85,
-			"reason":     "与Java技能高度相关，市场需求大",
+	err := h.recommendationService.RecordRecommendationEvent(c.Request.Context(), userID, request.RequestID, request.JobID, request.Action)
+	if errors.Is(err, ErrUnknownRecommendation) {
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Recommendation request not found or expired",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to record recommendation %s for request %s: %v", request.Action, request.RequestID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to record recommendation event",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"request_id": request.RequestID,
+			"job_id":     request.JobID,
+			"action":     request.Action,
 		},
-		{
-			"skill_name": "Docker",
-			"category":   "devops",
-			"score":      0.78,
-			"demand":     72,
-			"reason":     "现代化部署必备技能",
+	})
+}
+
+// GetExperimentReport 推荐实验各分组的点击率和投递率，experiment默认为当前实验，days为统计天数，仅管理员可调用
+func (h *AIHandler) GetExperimentReport(c *gin.Context) {
+	experiment := c.DefaultQuery("experiment", h.recommendationService.servingConfig.Experiment.Name)
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days <= 0 || days > 90 {
+		c.JSON(400, gin.H{
+			"success": false,
+			"error":   "days must be between 1 and 90",
+		})
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	report, err := h.recommendationService.ExperimentReport(c.Request.Context(), experiment, since)
+	if err != nil {
+		log.Printf("Failed to build report for experiment %s: %v", experiment, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to build experiment report",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"experiment": experiment,
+			"since":      since,
+			"variants":   report,
 		},
+	})
+}
+
+// requireCaller 网关认证后通过X-User-ID传递调用者，缺失时返回401
+func requireCaller(c *gin.Context) (uint, bool) {
+	userID, _ := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 64)
+	if userID == 0 {
+		c.JSON(401, gin.H{
+			"success": false,
+			"error":   "Authentication required",
+		})
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// requireSelf 路径中的:userID必须是调用者本人，推荐结果包含用户的期望薪资、城市和工作年限
+func requireSelf(c *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("userID"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(400, gin.H{
+			"success": false,
+			"error":   "Invalid user ID",
+		})
+		return 0, false
+	}
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return 0, false
+	}
+	if uint(userID) != callerID {
+		c.JSON(403, gin.H{
+			"success": false,
+			"error":   "Cannot access another user's recommendations",
+		})
+		return 0, false
+	}
+	return callerID, true
+}
+
+// adminAuthMiddleware 管理操作需要X-Admin-Token与AI_ADMIN_TOKEN一致，未配置时拒绝所有请求
+func adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := os.Getenv("AI_ADMIN_TOKEN")
+		token := c.GetHeader("X-Admin-Token")
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			c.JSON(403, gin.H{
+				"success": false,
+				"error":   "Admin access required",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeJob 校验调用者是职位所属企业的成员，失败时已写入响应
+func (h *AIHandler) authorizeJob(c *gin.Context, jobID uint) (uint, bool) {
+	userID, ok := requireCaller(c)
+	if !ok {
+		return 0, false
+	}
+	err := h.recommendationService.AuthorizeJob(jobID, userID)
+	switch {
+	case err == nil:
+		return userID, true
+	case errors.Is(err, ErrJobNotFound):
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Job not found",
+		})
+	case errors.Is(err, ErrNotJobMember):
+		c.JSON(403, gin.H{
+			"success": false,
+			"error":   "Job does not belong to your company",
+		})
+	default:
+		log.Printf("Failed to authorize user %d for job %d: %v", userID, jobID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to authorize job access",
+		})
+	}
+	return 0, false
+}
+
+// parseLimit 解析limit参数，限制在1-50之间
+func parseLimit(c *gin.Context, defaultLimit int) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultLimit
+	}
+	if limit > 50 {
+		return 50
+	}
+	return limit
+}
+
+// GetSkillRecommendations 获取技能推荐，job_ids指定目标职位（逗号分隔），不指定时以推荐职位为目标
+func (h *AIHandler) GetSkillRecommendations(c *gin.Context) {
+	userID, ok := requireSelf(c)
+	if !ok {
+		return
+	}
+	limit := parseLimit(c, 5)
+
+	var jobIDs []uint
+	for _, s := range strings.Split(c.Query("job_ids"), ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64); err == nil && id > 0 {
+			jobIDs = append(jobIDs, uint(id))
+		}
+	}
+
+	recommendations, err := h.recommendationService.GetSkillRecommendations(c.Request.Context(), userID, jobIDs, limit)
+	if err != nil {
+		log.Printf("Failed to get skill recommendations for user %d: %v", userID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to get skill recommendations",
+		})
+		return
 	}
 
 	c.JSON(200, gin.H{
@@ -86,9 +283,95 @@ func (h *AIHandler) GetSkillRecommendations(c *gin.Context) {
 	})
 }
 
+// ResolveSkill 把技能名或别名解析为标准技能
+func (h *AIHandler) ResolveSkill(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(400, gin.H{
+			"error": "name is required",
+		})
+		return
+	}
+
+	taxonomy, err := h.recommendationService.skillGraph.Taxonomy(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to load skill taxonomy: %v", err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to load skill taxonomy",
+		})
+		return
+	}
+	skill, ok := taxonomy.Resolve(name)
+	if !ok {
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Unknown skill",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    skill,
+	})
+}
+
+// GetRelatedSkills 获取与指定技能在职位中常一起出现的技能
+func (h *AIHandler) GetRelatedSkills(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(400, gin.H{
+			"error": "name is required",
+		})
+		return
+	}
+
+	related, err := h.recommendationService.skillGraph.Related(c.Request.Context(), name, parseLimit(c, 10))
+	if err != nil {
+		log.Printf("Failed to get related skills for %s: %v", name, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to get related skills",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"skill":   name,
+			"related": related,
+			"total":   len(related),
+		},
+	})
+}
+
+// RebuildSkillGraph 立即重建技能图谱，仅管理员可调用
+func (h *AIHandler) RebuildSkillGraph(c *gin.Context) {
+	summary, err := h.recommendationService.RebuildSkillGraph(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to rebuild skill graph: %v", err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to rebuild skill graph",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    summary,
+	})
+}
+
 // GetPersonalizedRecommendations 获取个性化推荐
 func (h *AIHandler) GetPersonalizedRecommendations(c *gin.Context) {
-	userID := c.Param("userID")
+	userID, ok := requireSelf(c)
+	if !ok {
+		return
+	}
+	limit := parseLimit(c, 10)
 
 	// 从请求体获取用户技能
 	var request struct {
@@ -102,30 +385,14 @@ func (h *AIHandler) GetPersonalizedRecommendations(c *gin.Context) {
 		return
 	}
 
-	// 模拟个性化推荐数据
-	recommendations := []map[string]interface{}{
-		{
-			"job_id":      1,
-			"title":       "Java开发工程师",
-			"company":     "腾讯科技",
-			"location":    "深圳",
-			"salary_min":  15000,
-			"salary_max":  25000,
-			"score":       0.85,
-			"skill_match": 3,
-			"reason":      "技能匹配度高",
-		},
-		{
-			"job_id":      2,
-			"title":       "前端开发工程师",
-			"company":     "阿里巴巴",
-			"location":    "杭州",
-			"salary_min":  12000,
-			"salary_max":  20000,
-			"score":       0.72,
-			"skill_match": 2,
-			"reason":      "技能部分匹配",
-		},
+	recommendations, err := h.recommendationService.GetPersonalizedRecommendations(userID, request.Skills, limit)
+	if err != nil {
+		log.Printf("Failed to get personalized recommendations for user %d: %v", userID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to get personalized recommendations",
+		})
+		return
 	}
 
 	c.JSON(200, gin.H{
@@ -141,32 +408,20 @@ func (h *AIHandler) GetPersonalizedRecommendations(c *gin.Context) {
 
 // GetCollaborativeRecommendations 获取协同过滤推荐
 func (h *AIHandler) GetCollaborativeRecommendations(c *gin.Context) {
-	userID := c.Param("userID")
+	userID, ok := requireSelf(c)
+	if !ok {
+		return
+	}
+	limit := parseLimit(c, 10)
 
-	// 模拟协同过滤推荐数据
-	recommendations := []map[string]interface{}{
-		{
-			"job_id":      4,
-			"title":       "DevOps工程师",
-			"company":     "美团",
-			"location":    "北京",
-			"salary_min":  18000,
-			"salary_max":  30000,
-			"score":       0.82,
-			"skill_match": 2,
-			"reason":      "相似用户也喜欢这个职位",
-		},
-		{
-			"job_id":      5,
-			"title":       "数据工程师",
-			"company":     "滴滴",
-			"location":    "北京",
-			"salary_min":  16000,
-			"salary_max":  28000,
-			"score":       0.76,
-			"skill_match": 1,
-			"reason":      "基于用户行为推荐",
-		},
+	recommendations, err := h.recommendationService.GetCollaborativeRecommendations(c.Request.Context(), userID, limit)
+	if err != nil {
+		log.Printf("Failed to get collaborative recommendations for user %d: %v", userID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to get collaborative recommendations",
+		})
+		return
 	}
 
 	c.JSON(200, gin.H{
@@ -179,7 +434,193 @@ func (h *AIHandler) GetCollaborativeRecommendations(c *gin.Context) {
 	})
 }
 
-// CalculateSimilarity 计算相似度
+// TrainCollaborativeModel 立即训练协同过滤模型并返回离线评估指标
+func (h *AIHandler) TrainCollaborativeModel(c *gin.Context) {
+	result, err := h.recommendationService.TrainCollaborativeModel(c.Request.Context())
+	if errors.Is(err, ErrTrainingInProgress) {
+		c.JSON(409, gin.H{
+			"success": false,
+			"error":   "Training already in progress",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to train collaborative filtering model: %v", err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to train model",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetCollaborativeMetrics 获取最近一次训练的离线评估指标
+func (h *AIHandler) GetCollaborativeMetrics(c *gin.Context) {
+	record, err := h.recommendationService.LatestCollaborativeTraining()
+	if err != nil {
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "No trained model",
+		})
+		return
+	}
+
+	var result CollaborativeTrainingResult
+	json.Unmarshal([]byte(record.Result), &result)
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetCandidateRecommendations 为职位推荐候选人
+func (h *AIHandler) GetCandidateRecommendations(c *gin.Context) {
+	var jobID uint
+	fmt.Sscanf(c.Param("jobID"), "%d", &jobID)
+	if _, ok := h.authorizeJob(c, jobID); !ok {
+		return
+	}
+	limit := parseLimit(c, 20)
+	includeReviewed := c.Query("include_reviewed") == "true"
+
+	candidates, err := h.recommendationService.GetCandidateRecommendations(jobID, limit, includeReviewed)
+	if errors.Is(err, ErrJobNotFound) {
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Job not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get candidate recommendations for job %d: %v", jobID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to get candidate recommendations",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"candidates": candidates,
+			"total":      len(candidates),
+			"job_id":     jobID,
+		},
+	})
+}
+
+// SubmitCandidateFeedback 记录企业对候选人的通过/淘汰，影响该职位后续的候选人排序，操作人为当前用户
+func (h *AIHandler) SubmitCandidateFeedback(c *gin.Context) {
+	var jobID uint
+	fmt.Sscanf(c.Param("jobID"), "%d", &jobID)
+	operatorID, ok := h.authorizeJob(c, jobID)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		UserID   uint   `json:"user_id" binding:"required"`
+		ResumeID uint   `json:"resume_id"`
+		Action   string `json:"action" binding:"required,oneof=approve reject"`
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	feedback := CandidateFeedback{
+		JobID:      jobID,
+		UserID:     request.UserID,
+		ResumeID:   request.ResumeID,
+		Action:     request.Action,
+		OperatorID: operatorID,
+		Note:       request.Note,
+	}
+	err := h.recommendationService.RecordCandidateFeedback(&feedback)
+	if errors.Is(err, ErrJobNotFound) {
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Job not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to record candidate feedback for job %d: %v", jobID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to record feedback",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// GetJobApplications 职位的投递列表及匹配度，仅职位所属企业的成员可查看；
+// sort=score时按匹配度从高到低，默认按投递时间倒序
+func (h *AIHandler) GetJobApplications(c *gin.Context) {
+	var jobID uint
+	fmt.Sscanf(c.Param("jobID"), "%d", &jobID)
+	if _, ok := h.authorizeJob(c, jobID); !ok {
+		return
+	}
+	sortBy := c.DefaultQuery("sort", SortByAppliedAt)
+	if sortBy != SortByAppliedAt && sortBy != SortByScore {
+		c.JSON(400, gin.H{
+			"success": false,
+			"error":   "sort must be applied_at or score",
+		})
+		return
+	}
+	page, _ := strconv.Atoi(c.Query("page"))
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := parseLimit(c, 20)
+
+	applications, total, err := h.recommendationService.GetJobApplications(jobID, sortBy, (page-1)*pageSize, pageSize)
+	if errors.Is(err, ErrJobNotFound) {
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Job not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get applications for job %d: %v", jobID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to get applications",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"applications": applications,
+			"total":        total,
+			"page":         page,
+			"limit":        pageSize,
+			"sort":         sortBy,
+			"job_id":       jobID,
+		},
+	})
+}
+
+// CalculateSimilarity 计算相似度，技能按标准ID比较
 func (h *AIHandler) CalculateSimilarity(c *gin.Context) {
 	var request struct {
 		Skills1 []string `json:"skills1"`
@@ -193,28 +634,7 @@ func (h *AIHandler) CalculateSimilarity(c *gin.Context) {
 		return
 	}
 
-	// 计算Jaccard相似度
-	similarity := 0.0
-	if len(request.Skills1) > 0 && len(request.Skills2) > 0 {
-		// 计算交集
-		intersection := make(map[string]bool)
-		for _, skill := range request.Skills1 {
-			intersection[skill] = true
-		}
-
-		commonSkills := 0
-		for _, skill := range request.Skills2 {
-			if intersection[skill] {
-				commonSkills++
-			}
-		}
-
-		// 计算Jaccard相似度
-		union := len(request.Skills1) + len(request.Skills2) - commonSkills
-		if union > 0 {
-			similarity = float64(commonSkills) / float64(union)
-		}
-	}
+	similarity := h.recommendationService.CalculateSimilarity(request.Skills1, request.Skills2)
 
 	c.JSON(200, gin.H{
 		"success": true,
@@ -226,11 +646,12 @@ func (h *AIHandler) CalculateSimilarity(c *gin.Context) {
 	})
 }
 
-// CalculateSkillMatch 计算技能匹配度
+// CalculateSkillMatch 计算技能匹配度：必备技能权重为加分项的两倍，技能按标准ID比较
 func (h *AIHandler) CalculateSkillMatch(c *gin.Context) {
 	var request struct {
-		RequiredSkills []string `json:"required_skills"`
-		UserSkills     []string `json:"user_skills"`
+		RequiredSkills   []string `json:"required_skills"`
+		NiceToHaveSkills []string `json:"nice_to_have_skills"`
+		UserSkills       []string `json:"user_skills"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -240,33 +661,70 @@ func (h *AIHandler) CalculateSkillMatch(c *gin.Context) {
 		return
 	}
 
-	// 计算匹配的技能数量
-	skillMatch := 0
-	matchRate := 0.0
-
-	if len(request.RequiredSkills) > 0 {
-		for _, requiredSkill := range request.RequiredSkills {
-			for _, userSkill := range request.UserSkills {
-				if requiredSkill == userSkill {
-					skillMatch++
-					break
-				}
-			}
-		}
-		matchRate = float64(skillMatch) / float64(len(request.RequiredSkills))
-	}
+	result := h.recommendationService.CalculateSkillMatch(request.RequiredSkills, request.NiceToHaveSkills, request.UserSkills)
 
 	c.JSON(200, gin.H{
 		"success": true,
 		"data": gin.H{
-			"skill_match":     skillMatch,
-			"match_rate":      matchRate,
-			"required_skills": request.RequiredSkills,
-			"user_skills":     request.UserSkills,
+			"skill_match":         len(result.Matched),
+			"match_rate":          result.Score,
+			"matched_skills":      result.Matched,
+			"missing_required":    result.MissingRequired,
+			"missing_optional":    result.MissingOptional,
+			"required_skills":     request.RequiredSkills,
+			"nice_to_have_skills": request.NiceToHaveSkills,
+			"user_skills":         request.UserSkills,
 		},
 	})
 }
 
+// MatchResume 简历与职位的匹配评估：总分、各维度得分和简历改进建议，只能评估本人的简历
+func (h *AIHandler) MatchResume(c *gin.Context) {
+	userID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	var request struct {
+		ResumeID uint `json:"resume_id" binding:"required"`
+		JobID    uint `json:"job_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+		return
+	}
+
+	match, err := h.recommendationService.MatchResume(userID, request.ResumeID, request.JobID)
+	switch {
+	case errors.Is(err, ErrResumeNotFound):
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Resume not found",
+		})
+		return
+	case errors.Is(err, ErrJobNotFound):
+		c.JSON(404, gin.H{
+			"success": false,
+			"error":   "Job not found",
+		})
+		return
+	case err != nil:
+		log.Printf("Failed to match resume %d with job %d: %v", request.ResumeID, request.JobID, err)
+		c.JSON(500, gin.H{
+			"success": false,
+			"error":   "Failed to calculate resume match",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    match,
+	})
+}
+
 // HealthCheck 健康检查
 func (h *AIHandler) HealthCheck(c *gin.Context) {
 	// 检查数据库连接状态
@@ -307,6 +765,10 @@ func main() {
 	}
 	defer dbManager.Close()
 
+	if err := dbManager.MySQL.AutoMigrate(&CandidateFeedback{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
@@ -316,9 +778,22 @@ func main() {
 	// 创建AI处理器
 	aiHandler := NewAIHandler(dbManager)
 
+	// 推荐实验配置
+	servingConfig, err := serving.LoadConfig(os.Getenv("RECOMMEND_EXPERIMENTS_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load recommendation experiments: %v", err)
+	}
+	aiHandler.recommendationService.SetServingConfig(servingConfig)
+
 	// 设置路由
 	setupRoutes(r, aiHandler)
 
+	// 定期训练协同过滤模型
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startCollaborativeTraining(ctx, aiHandler.recommendationService)
+	startSkillGraphSync(ctx, aiHandler.recommendationService)
+
 	// 启动服务器
 	port := getPort()
 	log.Printf("AI service starting on port %s", port)
@@ -360,6 +835,16 @@ func setupRoutes(r *gin.Engine, handler *AIHandler) {
 
 			// 协同过滤推荐
 			recommendations.GET("/collaborative/:userID", handler.GetCollaborativeRecommendations)
+
+			// 推荐结果的点击/投递上报
+			recommendations.POST("/events", handler.RecordRecommendationEvent)
+
+			// 职位候选人推荐及企业反馈
+			recommendations.GET("/candidates/:jobID", handler.GetCandidateRecommendations)
+			recommendations.POST("/candidates/:jobID/feedback", handler.SubmitCandidateFeedback)
+
+			// 职位投递列表，可按简历匹配度排序
+			recommendations.GET("/applications/:jobID", handler.GetJobApplications)
 		}
 
 		// 算法相关路由
@@ -370,6 +855,29 @@ func setupRoutes(r *gin.Engine, handler *AIHandler) {
 
 			// 计算技能匹配度
 			algorithms.POST("/skill-match", handler.CalculateSkillMatch)
+
+			// 简历与职位匹配评估
+			algorithms.POST("/resume-match", handler.MatchResume)
+
+			// 协同过滤模型训练与离线评估
+			algorithms.POST("/collaborative/train", adminAuthMiddleware(), handler.TrainCollaborativeModel)
+			algorithms.GET("/collaborative/metrics", handler.GetCollaborativeMetrics)
+
+			// 技能图谱重建
+			algorithms.POST("/skill-graph/rebuild", adminAuthMiddleware(), handler.RebuildSkillGraph)
+
+			// 推荐实验报告
+			algorithms.GET("/experiments/report", adminAuthMiddleware(), handler.GetExperimentReport)
+		}
+
+		// 技能体系相关路由
+		skillGroup := api.Group("/skills")
+		{
+			// 技能名/别名解析
+			skillGroup.GET("/resolve", handler.ResolveSkill)
+
+			// 共现技能
+			skillGroup.GET("/related", handler.GetRelatedSkills)
 		}
 	}
 
@@ -211,3 +211,33 @@ type AdvancedAnalytics struct {
 	CreatedAt         time.Time `json:"created_at"`
 	CompletedAt       *time.Time `json:"completed_at"`
 }
+
+// ResumeAuth 简历授权设置（简历服务维护），没有记录时按公开处理
+type ResumeAuth struct {
+	ResumeID          uint      `json:"resume_id" gorm:"primaryKey"`
+	UserID            uint      `json:"user_id"`
+	IsPublic          bool      `json:"is_public"`
+	AllowView         bool      `json:"allow_view"`
+	AllowDownload     bool      `json:"allow_download"`
+}
+
+// ResumeBlacklist 求职者屏蔽的企业（简历服务维护）
+type ResumeBlacklist struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	UserID            uint      `json:"user_id"`
+	CompanyID         uint      `json:"company_id"`
+}
+
+// CandidateFeedback 企业对职位推荐候选人的处理结果，同一职位同一候选人只保留最新一次
+type CandidateFeedback struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	JobID             uint      `json:"job_id" gorm:"not null;uniqueIndex:idx_candidate_feedback_job_user"`
+	UserID            uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_candidate_feedback_job_user"`
+	ResumeID          uint      `json:"resume_id"`
+	Action            string    `json:"action" gorm:"type:varchar(20);not null"` // approve、reject
+	OperatorID        uint      `json:"operator_id"`
+	Skills            string    `json:"skills" gorm:"type:json"` // 处理时候选人的技能快照
+	Note              string    `json:"note"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
@@ -0,0 +1,147 @@
+package recommend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// 有企业反馈时反馈因素的权重
+const feedbackWeight = 0.15
+
+// Candidate 候选人：一份已发布的简历及其求职资料
+type Candidate struct {
+	UserID    uint
+	ResumeID  uint
+	Name      string
+	Profile   Profile
+	UpdatedAt time.Time // 简历最近更新时间
+}
+
+// CandidateResult 候选人推荐结果
+type CandidateResult struct {
+	Candidate     Candidate
+	Score         float64
+	MatchedSkills []string
+	Factors       []Factor
+}
+
+// Reason 按贡献从高到低拼接各因素的解释
+func (r CandidateResult) Reason() string {
+	return explain(r.Factors)
+}
+
+// Feedback 企业对同一职位已处理候选人的技能，用于调整后续排序
+type Feedback struct {
+	Approved [][]string
+	Rejected [][]string
+}
+
+// RankCandidates 为职位的候选人打分并按得分降序返回前limit个（limit<=0时返回全部）。
+// 因素与职位推荐相同，发布时间换成简历活跃度；有反馈时加入与已通过/已淘汰候选人的技能相似度
+func (s *Scorer) RankCandidates(job Job, candidates []Candidate, feedback Feedback, limit int) []CandidateResult {
+	approved, rejected := normalizeSkillSets(feedback.Approved), normalizeSkillSets(feedback.Rejected)
+
+	results := make([]CandidateResult, 0, len(candidates))
+	for _, c := range candidates {
+		skills := make(map[string]bool, len(c.Profile.Skills))
+		for _, name := range c.Profile.Skills {
+			skills[NormalizeSkill(name)] = true
+		}
+		r := s.score(c.Profile, skills, job)
+
+		factors := r.Factors
+		// 最后一个因素是职位发布时间，对候选人换成简历更新时间
+		factors[len(factors)-1] = s.activityFactor(c.UpdatedAt)
+		factors[len(factors)-1].Weight = s.Weights.Recency
+		if len(approved)+len(rejected) > 0 {
+			f := feedbackFactor(skills, approved, rejected)
+			f.Weight = feedbackWeight
+			factors = append(factors, f)
+		}
+		results = append(results, CandidateResult{
+			Candidate:     c,
+			Score:         combine(factors),
+			MatchedSkills: r.MatchedSkills,
+			Factors:       factors,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if !results[i].Candidate.UpdatedAt.Equal(results[j].Candidate.UpdatedAt) {
+			return results[i].Candidate.UpdatedAt.After(results[j].Candidate.UpdatedAt)
+		}
+		return results[i].Candidate.ResumeID < results[j].Candidate.ResumeID
+	})
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results
+}
+
+// 简历活跃度：按更新时间的半衰期衰减
+func (s *Scorer) activityFactor(updatedAt time.Time) Factor {
+	f := s.recencyFactor(updatedAt)
+	f.Name = "activity"
+	if updatedAt.IsZero() || s.RecencyHalfLife <= 0 {
+		return f
+	}
+	days := int(s.Now().Sub(updatedAt).Hours() / 24)
+	if days <= 3 {
+		f.Reason = "简历近期更新"
+	} else {
+		f.Reason = fmt.Sprintf("简历%d天前更新", days)
+	}
+	return f
+}
+
+// 反馈：与已通过候选人的平均技能相似度高于已淘汰候选人时加分，反之减分，0.5为中性
+func feedbackFactor(skills map[string]bool, approved, rejected []map[string]bool) Factor {
+	f := Factor{Name: "feedback"}
+	simApproved, simRejected := meanJaccard(skills, approved), meanJaccard(skills, rejected)
+	f.Score = math.Max(0, math.Min(1, 0.5+(simApproved-simRejected)/2))
+	switch {
+	case simApproved-simRejected >= 0.1:
+		f.Reason = "与已通过的候选人技能相近"
+	case simRejected-simApproved >= 0.1:
+		f.Reason = "与已淘汰的候选人技能相近"
+	}
+	return f
+}
+
+func normalizeSkillSets(lists [][]string) []map[string]bool {
+	sets := make([]map[string]bool, 0, len(lists))
+	for _, list := range lists {
+		set := make(map[string]bool, len(list))
+		for _, name := range list {
+			if name = NormalizeSkill(name); name != "" {
+				set[name] = true
+			}
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+func meanJaccard(skills map[string]bool, sets []map[string]bool) float64 {
+	if len(sets) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, set := range sets {
+		inter := 0
+		for name := range set {
+			if skills[name] {
+				inter++
+			}
+		}
+		if union := len(skills) + len(set) - inter; union > 0 {
+			sum += float64(inter) / float64(union)
+		}
+	}
+	return sum / float64(len(sets))
+}
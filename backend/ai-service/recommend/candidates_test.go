@@ -0,0 +1,66 @@
+package recommend
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRankCandidates(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	scorer := NewScorer()
+	scorer.Now = func() time.Time { return now }
+
+	job := Job{
+		ID:              1,
+		Location:        "上海",
+		Skills:          ParseSkills(`["Go", "MySQL", "Redis", "Kubernetes"]`),
+		SalaryMin:       20000,
+		SalaryMax:       30000,
+		ExperienceLevel: "3-5年",
+		EducationLevel:  "本科",
+	}
+	candidates := []Candidate{
+		{ResumeID: 1, Profile: Profile{Skills: []string{"golang", "mysql", "redis"}, Location: "上海", WorkExperience: 4, EducationLevel: "本科", ExpectedSalaryMin: 22000}, UpdatedAt: now.AddDate(0, 0, -1)},
+		{ResumeID: 2, Profile: Profile{Skills: []string{"go", "k8s", "mysql"}, Location: "上海", WorkExperience: 4, EducationLevel: "本科", ExpectedSalaryMin: 22000}, UpdatedAt: now.AddDate(0, 0, -1)},
+		{ResumeID: 3, Profile: Profile{Skills: []string{"Java", "Spring"}, Location: "北京", WorkExperience: 1, EducationLevel: "大专"}, UpdatedAt: now.AddDate(0, 0, -90)},
+	}
+
+	results := scorer.RankCandidates(job, candidates, Feedback{}, 0)
+	if len(results) != 3 || results[2].Candidate.ResumeID != 3 {
+		t.Fatalf("unrelated candidate should rank last, got %+v", resumeIDs(results))
+	}
+	// 两个候选人技能覆盖度相同，同分时按简历ID
+	if results[0].Score != results[1].Score {
+		t.Fatalf("candidates 1 and 2 should tie without feedback: %v vs %v", results[0].Score, results[1].Score)
+	}
+	if reason := results[2].Reason(); !strings.Contains(reason, "简历90天前更新") {
+		t.Errorf("reason %q should mention resume activity", reason)
+	}
+	for _, f := range results[0].Factors {
+		if f.Name == "recency" || f.Name == "feedback" {
+			t.Errorf("unexpected factor %q without feedback", f.Name)
+		}
+	}
+
+	// 企业通过了会Kubernetes的候选人、淘汰了不会的，候选人2应排到前面
+	feedback := Feedback{
+		Approved: [][]string{{"Go", "Kubernetes", "MySQL"}},
+		Rejected: [][]string{{"Go", "MySQL", "Redis", "PHP"}},
+	}
+	results = scorer.RankCandidates(job, candidates, feedback, 2)
+	if got := resumeIDs(results); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("order with feedback = %v, want [2 1]", got)
+	}
+	if reason := results[0].Reason(); !strings.Contains(reason, "与已通过的候选人技能相近") {
+		t.Errorf("reason %q should explain the feedback", reason)
+	}
+}
+
+func resumeIDs(results []CandidateResult) []uint {
+	ids := make([]uint, len(results))
+	for i, r := range results {
+		ids[i] = r.Candidate.ResumeID
+	}
+	return ids
+}
@@ -0,0 +1,197 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Interaction 用户对职位的一次隐式反馈（浏览、收藏、投递），Weight越大偏好越强
+type Interaction struct {
+	UserID uint
+	JobID  uint
+	Weight float64
+	At     time.Time
+}
+
+// Neighbor 相似职位
+type Neighbor struct {
+	JobID      uint    `json:"job_id"`
+	Similarity float64 `json:"similarity"`
+}
+
+// ItemModel 基于物品的协同过滤模型，保存每个职位最相似的若干职位
+type ItemModel struct {
+	Neighbors    map[uint][]Neighbor `json:"neighbors"`
+	TrainedAt    time.Time           `json:"trained_at"`
+	Users        int                 `json:"users"`
+	Items        int                 `json:"items"`
+	Interactions int                 `json:"interactions"`
+}
+
+// TrainOptions 训练参数
+type TrainOptions struct {
+	Neighbors    int     // 每个职位保留的相似职位数
+	Shrinkage    float64 // 共现次数少时压低相似度，避免偶然共现
+	MaxUserItems int     // 单个用户参与计算的职位数上限，限制共现对数量
+}
+
+// DefaultTrainOptions 默认训练参数
+var DefaultTrainOptions = TrainOptions{Neighbors: 50, Shrinkage: 10, MaxUserItems: 200}
+
+// Recommendation 协同过滤推荐结果，Because为贡献最大的历史职位
+type Recommendation struct {
+	JobID   uint
+	Score   float64
+	Because uint
+}
+
+type itemPair struct{ a, b uint }
+
+// Preferences 汇总用户对职位的偏好，同一职位取最强的一次反馈
+func Preferences(interactions []Interaction) map[uint]map[uint]float64 {
+	prefs := make(map[uint]map[uint]float64)
+	for _, in := range interactions {
+		if in.Weight <= 0 {
+			continue
+		}
+		items := prefs[in.UserID]
+		if items == nil {
+			items = make(map[uint]float64)
+			prefs[in.UserID] = items
+		}
+		items[in.JobID] = math.Max(items[in.JobID], in.Weight)
+	}
+	return prefs
+}
+
+// TrainItemModel 用带收缩的余弦相似度计算职位两两相似度，每个职位保留最相似的opts.Neighbors个
+func TrainItemModel(interactions []Interaction, opts TrainOptions) *ItemModel {
+	prefs := Preferences(interactions)
+	norms := make(map[uint]float64)
+	dots := make(map[itemPair]float64)
+	counts := make(map[itemPair]int)
+
+	for _, items := range prefs {
+		ids := topItems(items, opts.MaxUserItems)
+		for _, id := range ids {
+			norms[id] += items[id] * items[id]
+		}
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				p := itemPair{ids[i], ids[j]}
+				if p.a > p.b {
+					p.a, p.b = p.b, p.a
+				}
+				dots[p] += items[ids[i]] * items[ids[j]]
+				counts[p]++
+			}
+		}
+	}
+
+	neighbors := make(map[uint][]Neighbor)
+	for p, dot := range dots {
+		n := float64(counts[p])
+		sim := dot / math.Sqrt(norms[p.a]*norms[p.b]) * n / (n + opts.Shrinkage)
+		neighbors[p.a] = append(neighbors[p.a], Neighbor{JobID: p.b, Similarity: sim})
+		neighbors[p.b] = append(neighbors[p.b], Neighbor{JobID: p.a, Similarity: sim})
+	}
+	for id, list := range neighbors {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Similarity != list[j].Similarity {
+				return list[i].Similarity > list[j].Similarity
+			}
+			return list[i].JobID < list[j].JobID
+		})
+		if opts.Neighbors > 0 && len(list) > opts.Neighbors {
+			list = list[:opts.Neighbors]
+		}
+		neighbors[id] = list
+	}
+
+	return &ItemModel{
+		Neighbors:    neighbors,
+		TrainedAt:    time.Now(),
+		Users:        len(prefs),
+		Items:        len(norms),
+		Interactions: len(interactions),
+	}
+}
+
+// topItems 按偏好从强到弱取前limit个职位，limit<=0时全部返回
+func topItems(items map[uint]float64, limit int) []uint {
+	ids := make([]uint, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if items[ids[i]] != items[ids[j]] {
+			return items[ids[i]] > items[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+// Recommend 根据用户的历史偏好推荐n个职位，得分为历史职位相似度按偏好加权的平均值（0~1）。
+// 历史中的职位和exclude返回true的职位不会被推荐
+func (m *ItemModel) Recommend(history map[uint]float64, n int, exclude func(uint) bool) []Recommendation {
+	var total float64
+	scores := make(map[uint]float64)
+	best := make(map[uint]float64)
+	because := make(map[uint]uint)
+	for _, h := range topItems(history, 0) {
+		w := history[h]
+		total += w
+		for _, nb := range m.Neighbors[h] {
+			if _, seen := history[nb.JobID]; seen {
+				continue
+			}
+			contribution := w * nb.Similarity
+			scores[nb.JobID] += contribution
+			if contribution > best[nb.JobID] {
+				best[nb.JobID] = contribution
+				because[nb.JobID] = h
+			}
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	results := make([]Recommendation, 0, len(scores))
+	for id, score := range scores {
+		if exclude != nil && exclude(id) {
+			continue
+		}
+		results = append(results, Recommendation{JobID: id, Score: score / total, Because: because[id]})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].JobID < results[j].JobID
+	})
+	if n > 0 && len(results) > n {
+		results = results[:n]
+	}
+	return results
+}
+
+// Candidates 历史职位的全部相似职位（去掉历史本身），用于批量查询职位状态
+func (m *ItemModel) Candidates(history map[uint]float64) []uint {
+	seen := make(map[uint]bool)
+	var ids []uint
+	for h := range history {
+		for _, nb := range m.Neighbors[h] {
+			if _, inHistory := history[nb.JobID]; !inHistory && !seen[nb.JobID] {
+				seen[nb.JobID] = true
+				ids = append(ids, nb.JobID)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,124 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// clusteredInteractions 两组兴趣不同的用户：后端用户关注职位1-5，前端用户关注职位11-15，
+// 每个用户按时间顺序浏览本组的全部职位，并投递最后一个
+func clusteredInteractions() []Interaction {
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var interactions []Interaction
+	for u := uint(1); u <= 20; u++ {
+		first := uint(1)
+		if u > 10 {
+			first = 11
+		}
+		for i := uint(0); i < 5; i++ {
+			job := first + (u+i)%5
+			at := base.Add(time.Duration(u)*time.Hour + time.Duration(i)*time.Minute)
+			interactions = append(interactions, Interaction{UserID: u, JobID: job, Weight: 1, At: at})
+			if i == 4 {
+				interactions = append(interactions, Interaction{UserID: u, JobID: job, Weight: 5, At: at.Add(time.Second)})
+			}
+		}
+	}
+	return interactions
+}
+
+func TestTrainItemModel(t *testing.T) {
+	model := TrainItemModel(clusteredInteractions(), DefaultTrainOptions)
+	if model.Users != 20 || model.Items != 10 {
+		t.Fatalf("users/items = %d/%d, want 20/10", model.Users, model.Items)
+	}
+	for id, neighbors := range model.Neighbors {
+		for _, nb := range neighbors {
+			if (id <= 5) != (nb.JobID <= 5) {
+				t.Errorf("job %d has neighbor %d from the other cluster", id, nb.JobID)
+			}
+			if nb.Similarity <= 0 || nb.Similarity > 1 {
+				t.Errorf("similarity(%d,%d) = %v, want (0,1]", id, nb.JobID, nb.Similarity)
+			}
+		}
+	}
+
+	limited := TrainItemModel(clusteredInteractions(), TrainOptions{Neighbors: 2, Shrinkage: 10})
+	for id, neighbors := range limited.Neighbors {
+		if len(neighbors) > 2 {
+			t.Errorf("job %d kept %d neighbors, want at most 2", id, len(neighbors))
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	model := TrainItemModel(clusteredInteractions(), DefaultTrainOptions)
+	history := map[uint]float64{1: 1, 2: 5}
+
+	recs := model.Recommend(history, 10, func(id uint) bool { return id == 3 })
+	if len(recs) != 2 {
+		t.Fatalf("got %d recommendations, want 2 (jobs 4 and 5): %+v", len(recs), recs)
+	}
+	for _, r := range recs {
+		if r.JobID != 4 && r.JobID != 5 {
+			t.Errorf("recommended job %d, want only 4 or 5", r.JobID)
+		}
+		if r.Because != 2 {
+			t.Errorf("job %d explained by %d, want the applied job 2", r.JobID, r.Because)
+		}
+		if r.Score <= 0 || r.Score > 1 {
+			t.Errorf("score %v out of (0,1]", r.Score)
+		}
+	}
+
+	if got := model.Recommend(map[uint]float64{99: 1}, 5, nil); len(got) != 0 {
+		t.Errorf("unknown history should give no recommendations, got %+v", got)
+	}
+	if got := len(model.Candidates(history)); got != 3 {
+		t.Errorf("Candidates returned %d jobs, want 3", got)
+	}
+}
+
+func TestSplitHoldout(t *testing.T) {
+	interactions := append(clusteredInteractions(), Interaction{UserID: 99, JobID: 1, Weight: 1})
+	train, test := SplitHoldout(interactions, 0.2)
+	if len(train)+len(test) != len(interactions) {
+		t.Fatalf("split lost interactions: %d+%d != %d", len(train), len(test), len(interactions))
+	}
+
+	heldOut := Preferences(test)
+	if _, ok := heldOut[99]; ok {
+		t.Error("user with a single job should stay in the training set")
+	}
+	for u := uint(1); u <= 20; u++ {
+		if len(heldOut[u]) != 1 {
+			t.Fatalf("user %d held out %d jobs, want 1", u, len(heldOut[u]))
+		}
+		// 最后投递的职位是时间上最新的
+		for job, w := range heldOut[u] {
+			if w != 5 {
+				t.Errorf("user %d held out job %d with weight %v, want the latest (applied) job", u, job, w)
+			}
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	train, test := SplitHoldout(clusteredInteractions(), 0.2)
+	model := TrainItemModel(train, DefaultTrainOptions)
+
+	metrics := Evaluate(model, train, test, 1)
+	if metrics.Users != 20 || metrics.Coverage != 1 {
+		t.Fatalf("evaluated %d users with coverage %v, want 20 and 1", metrics.Users, metrics.Coverage)
+	}
+	// 每个用户只剩一个本组职位没看过，推荐1个必然命中
+	if metrics.PrecisionAtK != 1 || metrics.RecallAtK != 1 {
+		t.Errorf("precision@1=%v recall@1=%v, want 1 and 1", metrics.PrecisionAtK, metrics.RecallAtK)
+	}
+
+	metrics = Evaluate(model, train, test, 5)
+	if math.Abs(metrics.PrecisionAtK-0.2) > 1e-9 || metrics.RecallAtK != 1 {
+		t.Errorf("precision@5=%v recall@5=%v, want 0.2 and 1", metrics.PrecisionAtK, metrics.RecallAtK)
+	}
+}
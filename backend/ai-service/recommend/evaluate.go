@@ -0,0 +1,98 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+)
+
+// Metrics 离线评估指标
+type Metrics struct {
+	K            int     `json:"k"`
+	PrecisionAtK float64 `json:"precision_at_k"`
+	RecallAtK    float64 `json:"recall_at_k"`
+	Users        int     `json:"users"`    // 参与评估的用户数
+	Coverage     float64 `json:"coverage"` // 有推荐结果的评估用户占比
+}
+
+// SplitHoldout 按时间切分：每个用户最近的fraction比例的职位（至少1个）作为测试集，
+// 只有一个职位的用户全部留在训练集
+func SplitHoldout(interactions []Interaction, fraction float64) (train, test []Interaction) {
+	byUser := make(map[uint][]Interaction)
+	for _, in := range interactions {
+		byUser[in.UserID] = append(byUser[in.UserID], in)
+	}
+
+	for _, list := range byUser {
+		// 每个职位以最后一次反馈的时间排序
+		last := make(map[uint]Interaction)
+		for _, in := range list {
+			if prev, ok := last[in.JobID]; !ok || in.At.After(prev.At) {
+				last[in.JobID] = in
+			}
+		}
+		if len(last) < 2 {
+			train = append(train, list...)
+			continue
+		}
+		jobs := make([]Interaction, 0, len(last))
+		for _, in := range last {
+			jobs = append(jobs, in)
+		}
+		sort.Slice(jobs, func(i, j int) bool {
+			if !jobs[i].At.Equal(jobs[j].At) {
+				return jobs[i].At.Before(jobs[j].At)
+			}
+			return jobs[i].JobID < jobs[j].JobID
+		})
+		holdout := int(math.Round(float64(len(jobs)) * fraction))
+		holdout = min(max(holdout, 1), len(jobs)-1)
+		held := make(map[uint]bool, holdout)
+		for _, in := range jobs[len(jobs)-holdout:] {
+			held[in.JobID] = true
+		}
+
+		for _, in := range list {
+			if held[in.JobID] {
+				test = append(test, in)
+			} else {
+				train = append(train, in)
+			}
+		}
+	}
+	return train, test
+}
+
+// Evaluate 用训练集的历史为测试集中的用户推荐k个职位，计算平均precision@k和recall@k
+func Evaluate(m *ItemModel, train, test []Interaction, k int) Metrics {
+	history := Preferences(train)
+	expected := Preferences(test)
+	metrics := Metrics{K: k}
+
+	var precision, recall float64
+	covered := 0
+	for userID, relevant := range expected {
+		h := history[userID]
+		if len(h) == 0 {
+			continue
+		}
+		metrics.Users++
+		recs := m.Recommend(h, k, nil)
+		if len(recs) > 0 {
+			covered++
+		}
+		hits := 0
+		for _, r := range recs {
+			if _, ok := relevant[r.JobID]; ok {
+				hits++
+			}
+		}
+		precision += float64(hits) / float64(k)
+		recall += float64(hits) / float64(len(relevant))
+	}
+	if metrics.Users > 0 {
+		metrics.PrecisionAtK = precision / float64(metrics.Users)
+		metrics.RecallAtK = recall / float64(metrics.Users)
+		metrics.Coverage = float64(covered) / float64(metrics.Users)
+	}
+	return metrics
+}
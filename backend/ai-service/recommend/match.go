@@ -0,0 +1,189 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"resume-centre/common/skills"
+)
+
+// 匹配等级
+const (
+	LevelExcellent = "excellent"
+	LevelGood      = "good"
+	LevelFair      = "fair"
+	LevelLow       = "low"
+)
+
+// 建议优先级
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// Match 简历与职位的匹配评估：总分与技能、经验、学历、地点、薪资各维度的得分和改进建议
+type Match struct {
+	Score       float64       `json:"score"`
+	Level       string        `json:"level"`
+	Dimensions  []Factor      `json:"dimensions"`
+	Skills      SkillGap      `json:"skills"`
+	Experience  ExperienceGap `json:"experience"`
+	Suggestions []Suggestion  `json:"suggestions"`
+}
+
+// SkillGap 技能匹配明细，NotInResume为求职资料中有、简历正文未提到的职位技能
+type SkillGap struct {
+	Matched         []string `json:"matched"`
+	MissingRequired []string `json:"missing_required"`
+	MissingOptional []string `json:"missing_optional"`
+	NotInResume     []string `json:"not_in_resume,omitempty"`
+}
+
+// ExperienceGap 工作年限与职位要求的差距，Gap为还差的年数，RequiredMax<0表示无上限
+type ExperienceGap struct {
+	Years       int  `json:"years"`
+	RequiredMin int  `json:"required_min"`
+	RequiredMax int  `json:"required_max"`
+	Required    bool `json:"required"`
+	Gap         int  `json:"gap"`
+}
+
+// Suggestion 简历改进建议
+type Suggestion struct {
+	Dimension string `json:"dimension"`
+	Priority  string `json:"priority"`
+	Text      string `json:"text"`
+}
+
+// Match 评估求职者与职位的匹配度。维度与职位推荐相同但不含发布时间；
+// resumeSkills为简历正文中提到的技能，为nil时不检查简历是否写明了已掌握的技能
+func (s *Scorer) Match(profile Profile, resumeSkills []string, job Job) Match {
+	dict := skills.Default()
+	userSkills := make(map[string]bool, len(profile.Skills))
+	for _, name := range profile.Skills {
+		userSkills[NormalizeSkill(name)] = true
+	}
+
+	skill, _ := skillFactor(userSkills, job.Skills)
+	factors := []Factor{
+		skill,
+		experienceFactor(profile.WorkExperience, job.ExperienceLevel),
+		educationFactor(profile.EducationLevel, job.EducationLevel),
+		locationFactor(profile.Location, job.Location),
+		salaryFactor(profile, job),
+	}
+	weights := []float64{s.Weights.Skills, s.Weights.Experience, s.Weights.Education, s.Weights.Location, s.Weights.Salary}
+	for i := range factors {
+		factors[i].Weight = weights[i]
+	}
+
+	m := Match{Score: combine(factors), Dimensions: factors}
+	m.Level = matchLevel(m.Score)
+
+	result := dict.MatchRequirements(job.Skills, profile.Skills)
+	m.Skills = SkillGap{
+		Matched:         nonNil(result.Matched),
+		MissingRequired: nonNil(result.MissingRequired),
+		MissingOptional: nonNil(result.MissingOptional),
+	}
+	if resumeSkills != nil {
+		mentioned := dict.IDs(resumeSkills)
+		for _, name := range result.Matched {
+			if !mentioned[dict.ID(name)] {
+				m.Skills.NotInResume = append(m.Skills.NotInResume, name)
+			}
+		}
+	}
+
+	lo, hi, ok := experienceRange(job.ExperienceLevel)
+	m.Experience = ExperienceGap{Years: profile.WorkExperience, RequiredMin: lo, RequiredMax: hi, Required: ok}
+	if ok && profile.WorkExperience < lo {
+		m.Experience.Gap = lo - profile.WorkExperience
+	}
+
+	m.Suggestions = suggest(profile, job, m)
+	return m
+}
+
+// Reason 按贡献从高到低拼接各维度的解释
+func (m Match) Reason() string {
+	return explain(m.Dimensions)
+}
+
+func matchLevel(score float64) string {
+	switch {
+	case score >= 0.8:
+		return LevelExcellent
+	case score >= 0.6:
+		return LevelGood
+	case score >= 0.4:
+		return LevelFair
+	}
+	return LevelLow
+}
+
+// suggest 按各维度的差距给出简历改进建议，高优先级在前
+func suggest(profile Profile, job Job, m Match) []Suggestion {
+	var list []Suggestion
+	add := func(dimension, priority, format string, args ...interface{}) {
+		list = append(list, Suggestion{Dimension: dimension, Priority: priority, Text: fmt.Sprintf(format, args...)})
+	}
+
+	if len(m.Skills.MissingRequired) > 0 {
+		add("skills", PriorityHigh, "职位必备技能%s未体现，如已掌握请补充到技能列表和项目经历中，否则建议优先学习",
+			strings.Join(m.Skills.MissingRequired, "、"))
+	}
+	if len(m.Skills.NotInResume) > 0 {
+		add("skills", PriorityMedium, "简历正文没有提到%s，建议在项目经历中写明使用场景和成果",
+			strings.Join(m.Skills.NotInResume, "、"))
+	}
+	if len(m.Skills.MissingOptional) > 0 {
+		add("skills", PriorityLow, "加分技能%s可作为后续学习方向，掌握后在简历中注明", strings.Join(m.Skills.MissingOptional, "、"))
+	}
+
+	if gap := m.Experience.Gap; gap > 0 {
+		priority := PriorityMedium
+		if gap >= 2 {
+			priority = PriorityHigh
+		}
+		add("experience", priority, "职位要求%s经验，当前%d年，建议突出与职位相关的项目、实习和兼职经历以弥补年限差距",
+			job.ExperienceLevel, profile.WorkExperience)
+	}
+
+	required, has := educationRank(job.EducationLevel), educationRank(profile.EducationLevel)
+	switch {
+	case required > 0 && has == 0:
+		add("education", PriorityMedium, "职位要求%s学历，请在简历中补充学历信息", job.EducationLevel)
+	case required > 0 && has < required:
+		add("education", PriorityMedium, "学历低于职位要求的%s，可补充相关证书、培训或代表性项目体现专业能力", job.EducationLevel)
+	}
+
+	for _, f := range m.Dimensions {
+		switch {
+		case f.Name == "location" && f.Score == 0:
+			add("location", PriorityLow, "职位工作地点在%s，如接受异地工作或可以到岗，请在简历中注明", job.Location)
+		case f.Name == "location" && profile.Location == "" && job.Location != "":
+			add("location", PriorityLow, "补充所在城市，便于企业判断到岗情况")
+		case f.Name == "salary" && f.Score > 0 && f.Score < 1:
+			add("salary", PriorityLow, "期望薪资高于职位薪资范围，可考虑调整期望或在沟通中说明薪资弹性")
+		}
+	}
+
+	priorities := map[string]int{PriorityHigh: 0, PriorityMedium: 1, PriorityLow: 2}
+	sort.SliceStable(list, func(i, j int) bool {
+		return priorities[list[i].Priority] < priorities[list[j].Priority]
+	})
+	if list == nil {
+		list = []Suggestion{}
+	}
+	return list
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,58 @@
+package recommend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	job := Job{
+		Location:        "上海",
+		Skills:          ParseSkills(`[{"name":"Go","required":true},{"name":"MySQL"},{"name":"Kubernetes","required":false}]`),
+		SalaryMin:       20000,
+		SalaryMax:       30000,
+		ExperienceLevel: "3-5年",
+		EducationLevel:  "本科",
+	}
+
+	strong := Profile{Skills: []string{"golang", "mysql", "k8s"}, Location: "上海", WorkExperience: 4, EducationLevel: "硕士", ExpectedSalaryMin: 25000}
+	m := NewScorer().Match(strong, []string{"Go", "MySQL", "Kubernetes"}, job)
+	if m.Score != 1 || m.Level != LevelExcellent || len(m.Suggestions) != 0 {
+		t.Fatalf("full match = %+v", m)
+	}
+	if len(m.Dimensions) != 5 {
+		t.Errorf("dimensions = %+v, want skills, experience, education, location, salary", m.Dimensions)
+	}
+
+	weak := Profile{Skills: []string{"MySQL", "PHP"}, Location: "北京", WorkExperience: 1, EducationLevel: "大专", ExpectedSalaryMin: 35000}
+	m = NewScorer().Match(weak, []string{"PHP"}, job)
+	if m.Level != LevelFair {
+		t.Errorf("weak match level = %s (score %v)", m.Level, m.Score)
+	}
+	if !reflect.DeepEqual(m.Skills.MissingRequired, []string{"Go"}) ||
+		!reflect.DeepEqual(m.Skills.MissingOptional, []string{"Kubernetes"}) ||
+		!reflect.DeepEqual(m.Skills.NotInResume, []string{"MySQL"}) {
+		t.Errorf("skills = %+v", m.Skills)
+	}
+	if m.Experience.Gap != 2 || m.Experience.RequiredMin != 3 {
+		t.Errorf("experience = %+v", m.Experience)
+	}
+
+	dimensions := make([]string, len(m.Suggestions))
+	for i, s := range m.Suggestions {
+		dimensions[i] = s.Dimension + ":" + s.Priority
+	}
+	want := []string{"skills:high", "experience:high", "skills:medium", "education:medium", "skills:low", "location:low", "salary:low"}
+	if !reflect.DeepEqual(dimensions, want) {
+		t.Errorf("suggestions = %v, want %v", dimensions, want)
+	}
+	if !strings.Contains(m.Suggestions[0].Text, "Go") {
+		t.Errorf("first suggestion %q should name the missing skill", m.Suggestions[0].Text)
+	}
+
+	// 没有简历正文时不检查技能是否写进简历
+	if m := NewScorer().Match(weak, nil, job); m.Skills.NotInResume != nil {
+		t.Errorf("NotInResume = %v without resume text", m.Skills.NotInResume)
+	}
+}
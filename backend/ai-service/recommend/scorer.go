@@ -0,0 +1,391 @@
+// Package recommend 职位推荐：基于内容的打分（技能、薪资、地点、经验、学历和发布时间）与基于用户行为的物品协同过滤
+package recommend
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Profile 求职者画像，薪资为月薪
+type Profile struct {
+	Skills            []string
+	ExpectedSalaryMin int
+	ExpectedSalaryMax int
+	Location          string
+	WorkExperience    int // 工作年限
+	EducationLevel    string
+}
+
+// Job 候选职位
+type Job struct {
+	ID              uint
+	Title           string
+	Company         string
+	Location        string
+	Skills          []Skill
+	SalaryMin       int
+	SalaryMax       int
+	SalaryType      string // monthly、yearly、daily、hourly
+	ExperienceLevel string
+	EducationLevel  string
+	PostedAt        time.Time
+}
+
+// Weights 各因素权重，总和不必为1，得分按权重归一
+type Weights struct {
+	Skills     float64
+	Salary     float64
+	Location   float64
+	Experience float64
+	Education  float64
+	Recency    float64
+}
+
+// DefaultWeights 默认权重，技能占主导
+var DefaultWeights = Weights{Skills: 0.4, Salary: 0.2, Location: 0.15, Experience: 0.1, Education: 0.1, Recency: 0.05}
+
+// Factor 单个因素的得分与解释
+type Factor struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Weight float64 `json:"weight"`
+	Reason string  `json:"reason"`
+}
+
+// Result 一个职位的推荐结果
+type Result struct {
+	Job           Job
+	Score         float64
+	MatchedSkills []string
+	Factors       []Factor
+}
+
+// Reason 按贡献从高到低拼接各因素的解释
+func (r Result) Reason() string {
+	return explain(r.Factors)
+}
+
+func explain(factors []Factor) string {
+	factors = append([]Factor(nil), factors...)
+	sort.SliceStable(factors, func(i, j int) bool {
+		return factors[i].Score*factors[i].Weight > factors[j].Score*factors[j].Weight
+	})
+	reasons := make([]string, 0, len(factors))
+	for _, f := range factors {
+		if f.Reason != "" {
+			reasons = append(reasons, f.Reason)
+		}
+	}
+	return strings.Join(reasons, "；")
+}
+
+// Scorer 内容推荐打分器
+type Scorer struct {
+	Weights Weights
+	// 发布时间衰减的半衰期
+	RecencyHalfLife time.Duration
+	Now             func() time.Time
+}
+
+// NewScorer 使用默认权重和14天半衰期
+func NewScorer() *Scorer {
+	return &Scorer{Weights: DefaultWeights, RecencyHalfLife: 14 * 24 * time.Hour, Now: time.Now}
+}
+
+// Rank 为所有职位打分并按得分降序返回前limit个（limit<=0时返回全部），
+// 同分时较新的职位在前
+func (s *Scorer) Rank(profile Profile, jobs []Job, limit int) []Result {
+	userSkills := make(map[string]bool, len(profile.Skills))
+	for _, name := range profile.Skills {
+		userSkills[NormalizeSkill(name)] = true
+	}
+
+	results := make([]Result, 0, len(jobs))
+	for _, job := range jobs {
+		results = append(results, s.score(profile, userSkills, job))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if !results[i].Job.PostedAt.Equal(results[j].Job.PostedAt) {
+			return results[i].Job.PostedAt.After(results[j].Job.PostedAt)
+		}
+		return results[i].Job.ID < results[j].Job.ID
+	})
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (s *Scorer) score(profile Profile, userSkills map[string]bool, job Job) Result {
+	skill, matched := skillFactor(userSkills, job.Skills)
+	factors := []Factor{
+		skill,
+		salaryFactor(profile, job),
+		locationFactor(profile.Location, job.Location),
+		experienceFactor(profile.WorkExperience, job.ExperienceLevel),
+		educationFactor(profile.EducationLevel, job.EducationLevel),
+		s.recencyFactor(job.PostedAt),
+	}
+	weights := []float64{s.Weights.Skills, s.Weights.Salary, s.Weights.Location, s.Weights.Experience, s.Weights.Education, s.Weights.Recency}
+	for i := range factors {
+		factors[i].Weight = weights[i]
+	}
+	return Result{Job: job, Score: combine(factors), MatchedSkills: matched, Factors: factors}
+}
+
+// combine 按权重归一的加权平均分，保留三位小数
+func combine(factors []Factor) float64 {
+	var total, weightSum float64
+	for _, f := range factors {
+		total += f.Score * f.Weight
+		weightSum += f.Weight
+	}
+	if weightSum <= 0 {
+		return 0
+	}
+	return math.Round(total/weightSum*1000) / 1000
+}
+
+// 技能：命中的职位技能权重之和占全部权重的比例
+func skillFactor(userSkills map[string]bool, jobSkills []Skill) (Factor, []string) {
+	f := Factor{Name: "skills"}
+	if len(jobSkills) == 0 {
+		f.Score = 0.5
+		f.Reason = "职位未列出技能要求"
+		return f, nil
+	}
+
+	var matchedWeight, totalWeight float64
+	var matched, missingRequired []string
+	seen := map[string]bool{}
+	for _, skill := range jobSkills {
+		id := NormalizeSkill(skill.Name)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		w := skill.EffectiveWeight()
+		totalWeight += w
+		if userSkills[id] {
+			matchedWeight += w
+			matched = append(matched, skill.Name)
+		} else if skill.IsRequired() {
+			missingRequired = append(missingRequired, skill.Name)
+		}
+	}
+	if totalWeight > 0 {
+		f.Score = matchedWeight / totalWeight
+	}
+
+	switch {
+	case len(matched) == 0:
+		f.Reason = "技能与职位要求不匹配"
+	case len(missingRequired) == 0:
+		f.Reason = fmt.Sprintf("掌握全部要求技能（%s）", strings.Join(matched, "、"))
+	default:
+		f.Reason = fmt.Sprintf("匹配技能%s，缺少%s", strings.Join(matched, "、"), strings.Join(missingRequired, "、"))
+	}
+	return f, matched
+}
+
+// 薪资：职位上限达到期望下限即为满足，否则按差距平方衰减
+func salaryFactor(profile Profile, job Job) Factor {
+	f := Factor{Name: "salary"}
+	jobMin, jobMax := monthlySalary(job.SalaryMin, job.SalaryType), monthlySalary(job.SalaryMax, job.SalaryType)
+	if jobMax == 0 {
+		jobMax = jobMin
+	}
+	expectedMin, expectedMax := profile.ExpectedSalaryMin, profile.ExpectedSalaryMax
+	if expectedMin == 0 {
+		expectedMin = expectedMax
+	}
+
+	switch {
+	case jobMax == 0:
+		f.Score = 0.5
+		f.Reason = "薪资面议"
+	case expectedMin == 0:
+		f.Score = 0.5
+		f.Reason = fmt.Sprintf("薪资%s，未填写期望薪资", salaryRange(jobMin, jobMax))
+	case jobMax >= expectedMin:
+		f.Score = 1
+		f.Reason = fmt.Sprintf("薪资%s符合期望%s", salaryRange(jobMin, jobMax), salaryRange(expectedMin, expectedMax))
+	default:
+		ratio := float64(jobMax) / float64(expectedMin)
+		f.Score = ratio * ratio
+		f.Reason = fmt.Sprintf("薪资%s低于期望%s", salaryRange(jobMin, jobMax), salaryRange(expectedMin, expectedMax))
+	}
+	return f
+}
+
+// 按每月21.75个工作日、每天8小时折算为月薪
+func monthlySalary(amount int, salaryType string) int {
+	switch strings.ToLower(salaryType) {
+	case "yearly", "annual":
+		return amount / 12
+	case "daily":
+		return int(float64(amount) * 21.75)
+	case "hourly":
+		return int(float64(amount) * 21.75 * 8)
+	}
+	return amount
+}
+
+func salaryRange(min, max int) string {
+	k := func(v int) string { return strconv.FormatFloat(float64(v)/1000, 'f', -1, 64) + "k" }
+	if max == 0 || max == min {
+		return k(min)
+	}
+	return k(min) + "-" + k(max)
+}
+
+// 地点：同城或远程为满分，一方未填写时中性
+func locationFactor(userLocation, jobLocation string) Factor {
+	f := Factor{Name: "location"}
+	user, job := normalizeCity(userLocation), normalizeCity(jobLocation)
+	switch {
+	case job == "远程" || job == "remote":
+		f.Score = 1
+		f.Reason = "支持远程办公"
+	case user == "" || job == "":
+		f.Score = 0.5
+	case user == job || strings.Contains(job, user) || strings.Contains(user, job):
+		f.Score = 1
+		f.Reason = "工作地点在" + jobLocation
+	default:
+		f.Reason = fmt.Sprintf("工作地点%s与所在地%s不同", jobLocation, userLocation)
+	}
+	return f
+}
+
+func normalizeCity(location string) string {
+	s := strings.ToLower(strings.TrimSpace(location))
+	s = strings.TrimSuffix(s, "市")
+	return s
+}
+
+var (
+	yearsRangePattern = regexp.MustCompile(`(\d+)\s*[-~至]\s*(\d+)`)
+	yearsMinPattern   = regexp.MustCompile(`(\d+)\s*(年以上|\+|年及以上)`)
+	yearsPattern      = regexp.MustCompile(`(\d+)`)
+)
+
+// 经验要求解析为年限区间，max<0表示无上限；ok为false表示没有要求
+func experienceRange(level string) (min, max int, ok bool) {
+	s := strings.ToLower(strings.TrimSpace(level))
+	switch s {
+	case "", "不限", "any", "unlimited":
+		return 0, -1, false
+	case "intern", "实习", "entry", "应届", "应届生", "graduate":
+		return 0, 1, true
+	case "junior", "初级":
+		return 1, 3, true
+	case "mid", "middle", "intermediate", "中级":
+		return 3, 5, true
+	case "senior", "高级":
+		return 5, 10, true
+	case "lead", "expert", "principal", "专家", "资深":
+		return 8, -1, true
+	}
+	if m := yearsRangePattern.FindStringSubmatch(s); m != nil {
+		lo, _ := strconv.Atoi(m[1])
+		hi, _ := strconv.Atoi(m[2])
+		return lo, hi, true
+	}
+	if m := yearsMinPattern.FindStringSubmatch(s); m != nil {
+		lo, _ := strconv.Atoi(m[1])
+		return lo, -1, true
+	}
+	if m := yearsPattern.FindStringSubmatch(s); m != nil {
+		lo, _ := strconv.Atoi(m[1])
+		return lo, -1, true
+	}
+	return 0, -1, false
+}
+
+// 经验：区间内满分，每差一年扣0.25，超出上限较多时略微扣分
+func experienceFactor(years int, level string) Factor {
+	f := Factor{Name: "experience"}
+	lo, hi, ok := experienceRange(level)
+	switch {
+	case !ok:
+		f.Score = 1
+		f.Reason = "经验不限"
+	case years < lo:
+		f.Score = math.Max(0, 1-0.25*float64(lo-years))
+		f.Reason = fmt.Sprintf("要求%s经验，当前%d年", level, years)
+	case hi >= 0 && years > hi+2:
+		f.Score = math.Max(0.5, 1-0.1*float64(years-hi))
+		f.Reason = fmt.Sprintf("%d年经验高于职位要求", years)
+	default:
+		f.Score = 1
+		f.Reason = fmt.Sprintf("%d年经验符合要求", years)
+	}
+	return f
+}
+
+var educationRanks = map[string]int{
+	"高中": 1, "中专": 1, "high_school": 1,
+	"大专": 2, "专科": 2, "associate": 2, "college": 2,
+	"本科": 3, "学士": 3, "bachelor": 3,
+	"硕士": 4, "研究生": 4, "master": 4,
+	"博士": 5, "phd": 5, "doctor": 5, "doctorate": 5,
+}
+
+func educationRank(level string) int {
+	s := strings.ToLower(strings.TrimSpace(level))
+	s = strings.TrimSuffix(s, "及以上")
+	s = strings.TrimSuffix(s, "以上")
+	return educationRanks[s]
+}
+
+// 学历：达到要求满分，低一级0.5分，低两级及以上0分
+func educationFactor(userLevel, jobLevel string) Factor {
+	f := Factor{Name: "education"}
+	required, has := educationRank(jobLevel), educationRank(userLevel)
+	switch {
+	case required == 0:
+		f.Score = 1
+		f.Reason = "学历不限"
+	case has == 0:
+		f.Score = 0.5
+	case has >= required:
+		f.Score = 1
+		f.Reason = "学历满足" + jobLevel + "要求"
+	case has == required-1:
+		f.Score = 0.5
+		f.Reason = "学历略低于" + jobLevel + "要求"
+	default:
+		f.Reason = "学历低于" + jobLevel + "要求"
+	}
+	return f
+}
+
+// 发布时间：按半衰期指数衰减
+func (s *Scorer) recencyFactor(postedAt time.Time) Factor {
+	f := Factor{Name: "recency"}
+	if postedAt.IsZero() || s.RecencyHalfLife <= 0 {
+		f.Score = 0.5
+		return f
+	}
+	age := s.Now().Sub(postedAt)
+	if age < 0 {
+		age = 0
+	}
+	f.Score = math.Pow(0.5, float64(age)/float64(s.RecencyHalfLife))
+	days := int(age.Hours() / 24)
+	if days <= 3 {
+		f.Reason = "最新发布"
+	} else {
+		f.Reason = fmt.Sprintf("%d天前发布", days)
+	}
+	return f
+}
@@ -0,0 +1,171 @@
+package recommend
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixture struct {
+	Now     time.Time `json:"now"`
+	Profile struct {
+		Skills            []string `json:"skills"`
+		ExpectedSalaryMin int      `json:"expected_salary_min"`
+		ExpectedSalaryMax int      `json:"expected_salary_max"`
+		Location          string   `json:"location"`
+		WorkExperience    int      `json:"work_experience"`
+		EducationLevel    string   `json:"education_level"`
+	} `json:"profile"`
+	Jobs []struct {
+		ID              uint   `json:"id"`
+		Title           string `json:"title"`
+		Location        string `json:"location"`
+		Skills          string `json:"skills"`
+		SalaryMin       int    `json:"salary_min"`
+		SalaryMax       int    `json:"salary_max"`
+		SalaryType      string `json:"salary_type"`
+		ExperienceLevel string `json:"experience_level"`
+		EducationLevel  string `json:"education_level"`
+		PostedDaysAgo   int    `json:"posted_days_ago"`
+	} `json:"jobs"`
+	Expect struct {
+		Order   []uint              `json:"order"`
+		Reasons map[string][]string `json:"reasons"`
+	} `json:"expect"`
+}
+
+func TestRankFixtures(t *testing.T) {
+	paths, _ := filepath.Glob("testdata/*.json")
+	if len(paths) == 0 {
+		t.Fatal("no fixtures")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fx fixture
+			if err := json.Unmarshal(data, &fx); err != nil {
+				t.Fatal(err)
+			}
+
+			profile := Profile{
+				Skills:            fx.Profile.Skills,
+				ExpectedSalaryMin: fx.Profile.ExpectedSalaryMin,
+				ExpectedSalaryMax: fx.Profile.ExpectedSalaryMax,
+				Location:          fx.Profile.Location,
+				WorkExperience:    fx.Profile.WorkExperience,
+				EducationLevel:    fx.Profile.EducationLevel,
+			}
+			var jobs []Job
+			for _, j := range fx.Jobs {
+				jobs = append(jobs, Job{
+					ID:              j.ID,
+					Title:           j.Title,
+					Location:        j.Location,
+					Skills:          ParseSkills(j.Skills),
+					SalaryMin:       j.SalaryMin,
+					SalaryMax:       j.SalaryMax,
+					SalaryType:      j.SalaryType,
+					ExperienceLevel: j.ExperienceLevel,
+					EducationLevel:  j.EducationLevel,
+					PostedAt:        fx.Now.AddDate(0, 0, -j.PostedDaysAgo),
+				})
+			}
+
+			scorer := NewScorer()
+			scorer.Now = func() time.Time { return fx.Now }
+			results := scorer.Rank(profile, jobs, 0)
+
+			var order []uint
+			byID := map[uint]Result{}
+			for _, r := range results {
+				order = append(order, r.Job.ID)
+				byID[r.Job.ID] = r
+				if r.Score < 0 || r.Score > 1 {
+					t.Errorf("job %d score %v out of range", r.Job.ID, r.Score)
+				}
+			}
+			if !equalIDs(order, fx.Expect.Order) {
+				for _, r := range results {
+					t.Logf("job %d score %.3f: %s", r.Job.ID, r.Score, r.Reason())
+				}
+				t.Errorf("order = %v, want %v", order, fx.Expect.Order)
+			}
+			for id, wants := range fx.Expect.Reasons {
+				n, _ := strconv.Atoi(id)
+				reason := byID[uint(n)].Reason()
+				for _, want := range wants {
+					if !strings.Contains(reason, want) {
+						t.Errorf("job %s reason %q missing %q", id, reason, want)
+					}
+				}
+			}
+
+			if top := scorer.Rank(profile, jobs, 2); len(top) != 2 || top[0].Job.ID != fx.Expect.Order[0] {
+				t.Errorf("limited rank = %v", top)
+			}
+		})
+	}
+}
+
+func TestParseSkills(t *testing.T) {
+	cases := map[string][]string{
+		`["Go", " MySQL "]`:             {"Go", "MySQL"},
+		`[{"name":"Go","weight":2}]`:    {"Go"},
+		"Java，Spring Boot、Redis; Kafka": {"Java", "Spring Boot", "Redis", "Kafka"},
+		"":                              nil,
+		"null":                          nil,
+	}
+	for raw, want := range cases {
+		got := SkillNames(ParseSkills(raw))
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("ParseSkills(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestNormalizeSkill(t *testing.T) {
+	for in, want := range map[string]string{"Golang": "go", " Vue ": "vue", "机器学习": "machine-learning", "jiqixuexi": "machine-learning", "Spring  Boot": "spring-boot", "Kubernets": "kubernetes", "Rust": "rust", "自研框架": "自研框架"} {
+		if got := NormalizeSkill(in); got != want {
+			t.Errorf("NormalizeSkill(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExperienceRange(t *testing.T) {
+	cases := []struct {
+		level  string
+		lo, hi int
+		ok     bool
+	}{
+		{"3-5年", 3, 5, true},
+		{"5年以上", 5, -1, true},
+		{"senior", 5, 10, true},
+		{"不限", 0, -1, false},
+		{"2", 2, -1, true},
+	}
+	for _, c := range cases {
+		lo, hi, ok := experienceRange(c.level)
+		if lo != c.lo || hi != c.hi || ok != c.ok {
+			t.Errorf("experienceRange(%q) = %d,%d,%v", c.level, lo, hi, ok)
+		}
+	}
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package recommend
+
+import (
+	"resume-centre/common/skills"
+)
+
+// Skill 职位要求的技能，Weight为相对重要程度，可选技能权重减半
+type Skill = skills.Requirement
+
+// NormalizeSkill 技能的标准ID，别名、拼音和拼写错误都归到同一个ID
+func NormalizeSkill(name string) string {
+	return skills.Default().ID(name)
+}
+
+// ParseSkills 解析JSON技能字段，兼容字符串数组、对象数组和逗号分隔的纯文本，名称统一为标准展示名
+func ParseSkills(raw string) []Skill {
+	return skills.Default().Parse(raw)
+}
+
+// SkillNames 技能名称列表
+func SkillNames(list []Skill) []string {
+	return skills.Names(list)
+}
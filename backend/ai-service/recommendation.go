@@ -3,21 +3,49 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
-	"strings"
+	"sync"
 	"time"
+
+	"ai-service/recommend"
+	"ai-service/serving"
+	"ai-service/skillgraph"
+
+	"gorm.io/gorm"
+
+	"resume-centre/common/skills"
 )
 
+// ErrProfileNotFound 用户尚未填写求职资料
+var ErrProfileNotFound = errors.New("user profile not found")
+
+// 参与排序的候选职位数，取最新发布的职位
+const maxCandidateJobs = 500
+
 // RecommendationService 推荐服务
 type RecommendationService struct {
-	dbManager *DatabaseManager
+	dbManager  *DatabaseManager
+	skillGraph *skillgraph.Recommender
+
+	// 协同过滤模型的内存副本
+	cfMu       sync.RWMutex
+	cfModel    *recommend.ItemModel
+	cfLoadedAt time.Time
+
+	// 推荐实验配置和职位表指纹（用于缓存失效）
+	servingConfig serving.Config
+	jobsMu        sync.Mutex
+	jobsVersion   string
+	jobsCheckedAt time.Time
 }
 
 // NewRecommendationService 创建推荐服务
 func NewRecommendationService(dbManager *DatabaseManager) *RecommendationService {
 	return &RecommendationService{
-		dbManager: dbManager,
+		dbManager:     dbManager,
+		skillGraph:    skillgraph.NewRecommender(skillgraph.NewNeo4jGraph(dbManager.Neo4j)),
+		servingConfig: serving.DefaultConfig,
 	}
 }
 
@@ -37,112 +65,129 @@ type JobRecommendation struct {
 
 // SkillRecommendation 技能推荐结果
 type SkillRecommendation struct {
-	SkillName string  `json:"skill_name"`
-	Category  string  `json:"category"`
-	Score     float64 `json:"score"`
-	Demand    int     `json:"demand"`
-	Reason    string  `json:"reason"`
-}
-
-// GetJobRecommendations 获取职位推荐
-func (rs *RecommendationService) GetJobRecommendations(userID uint, limit int) ([]JobRecommendation, error) {
-	// 从缓存获取推荐结果
-	cacheKey := fmt.Sprintf("job_recommendations:%d", userID)
-	cached, err := rs.getCachedRecommendations(cacheKey)
-	if err == nil && len(cached) > 0 {
-		return cached, nil
-	}
-
-	// 获取模拟推荐数据
-	recommendations := rs.getMockJobRecommendations(userID, limit)
-
-	// 缓存结果
-	rs.cacheRecommendations(cacheKey, recommendations, 30*time.Minute)
-
-	return recommendations, nil
+	SkillName     string   `json:"skill_name"`
+	Category      string   `json:"category"`
+	Score         float64  `json:"score"`
+	Demand        int      `json:"demand"`
+	Reason        string   `json:"reason"`
+	RelatedSkills []string `json:"related_skills,omitempty"`
 }
 
-// GetSkillRecommendations 获取技能推荐
-func (rs *RecommendationService) GetSkillRecommendations(userID uint, limit int) ([]SkillRecommendation, error) {
-	return rs.getMockSkillRecommendations(userID, limit), nil
-}
-
-// GetPersonalizedRecommendations 获取个性化推荐
+// GetPersonalizedRecommendations 获取个性化推荐：以请求中的技能代替资料中的技能，没有资料时只按技能等因素匹配
 func (rs *RecommendationService) GetPersonalizedRecommendations(userID uint, skills []string, limit int) ([]JobRecommendation, error) {
-	// 基于技能进行推荐
-	recommendations := rs.getMockJobRecommendations(userID, limit)
-
-	// 根据技能调整评分
-	for i := range recommendations {
-		recommendations[i].Score += 0.1 // 增加个性化评分
-		recommendations[i].Reason = fmt.Sprintf("基于技能 %v 的个性化推荐", skills)
+	profile, err := rs.loadProfile(userID)
+	if err != nil && !errors.Is(err, ErrProfileNotFound) {
+		return nil, err
 	}
-
-	return recommendations, nil
+	if len(skills) > 0 {
+		profile.Skills = skills
+	}
+	return rs.rankJobs(profile, limit)
 }
 
-// CalculateSimilarity 计算相似度
-func (rs *RecommendationService) CalculateSimilarity(skills1, skills2 []string) float64 {
-	if len(skills1) == 0 || len(skills2) == 0 {
-		return 0.0
+// loadProfile 读取用户求职资料和所在地
+func (rs *RecommendationService) loadProfile(userID uint) (recommend.Profile, error) {
+	var user User
+	if err := rs.dbManager.MySQL.Select("id, location").Where("id = ?", userID).First(&user).Error; err != nil &&
+		!errors.Is(err, gorm.ErrRecordNotFound) {
+		return recommend.Profile{}, err
 	}
+	profile := recommend.Profile{Location: user.Location}
 
-	// 计算Jaccard相似度
-	set1 := make(map[string]bool)
-	for _, skill := range skills1 {
-		set1[strings.ToLower(skill)] = true
-	}
+	var userProfile UserProfile
+	if err := rs.dbManager.MySQL.Where("user_id = ?", userID).First(&userProfile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return profile, ErrProfileNotFound
+		}
+		return profile, err
+	}
+	profile.Skills = recommend.SkillNames(recommend.ParseSkills(userProfile.Skills))
+	profile.ExpectedSalaryMin = userProfile.ExpectedSalaryMin
+	profile.ExpectedSalaryMax = userProfile.ExpectedSalaryMax
+	profile.WorkExperience = userProfile.WorkExperience
+	profile.EducationLevel = userProfile.EducationLevel
+	return profile, nil
+}
 
-	set2 := make(map[string]bool)
-	for _, skill := range skills2 {
-		set2[strings.ToLower(skill)] = true
+// rankJobs 对最新的在招职位打分排序
+func (rs *RecommendationService) rankJobs(profile recommend.Profile, limit int) ([]JobRecommendation, error) {
+	results, err := rs.rankJobResults(profile, limit)
+	if err != nil {
+		return nil, err
 	}
-
-	// 计算交集
-	intersection := 0
-	for skill := range set1 {
-		if set2[skill] {
-			intersection++
+	recommendations := make([]JobRecommendation, len(results))
+	for i, r := range results {
+		recommendations[i] = JobRecommendation{
+			JobID:      r.Job.ID,
+			Title:      r.Job.Title,
+			Company:    r.Job.Company,
+			Location:   r.Job.Location,
+			SalaryMin:  r.Job.SalaryMin,
+			SalaryMax:  r.Job.SalaryMax,
+			Score:      r.Score,
+			SkillMatch: len(r.MatchedSkills),
+			Reason:     r.Reason(),
+			Algorithm:  "content_based",
 		}
 	}
+	return recommendations, nil
+}
 
-	// 计算并集
-	union := len(set1) + len(set2) - intersection
+// rankJobResults 内容推荐的打分结果
+func (rs *RecommendationService) rankJobResults(profile recommend.Profile, limit int) ([]recommend.Result, error) {
+	jobs, err := rs.activeJobs()
+	if err != nil {
+		return nil, err
+	}
 
-	if union == 0 {
-		return 0.0
+	candidates := make([]recommend.Job, len(jobs))
+	for i, job := range jobs {
+		candidates[i] = toRecommendJob(job)
 	}
+	return recommend.NewScorer().Rank(profile, candidates, limit), nil
+}
 
-	return float64(intersection) / float64(union)
+// activeJobs 最新发布的在招职位，作为各推荐算法的候选集
+func (rs *RecommendationService) activeJobs() ([]Job, error) {
+	var jobs []Job
+	err := rs.dbManager.MySQL.Where("status = ? AND deleted_at IS NULL", "active").
+		Order("created_at DESC").Limit(maxCandidateJobs).Find(&jobs).Error
+	return jobs, err
 }
 
-// CalculateSkillMatch 计算技能匹配度
-func (rs *RecommendationService) CalculateSkillMatch(requiredSkills, userSkills []string) (int, float64) {
-	if len(requiredSkills) == 0 {
-		return 0, 0.0
+// toRecommendJob 转换为打分用的职位
+func toRecommendJob(job Job) recommend.Job {
+	return recommend.Job{
+		ID:              job.ID,
+		Title:           job.Title,
+		Company:         job.CompanyName,
+		Location:        job.Location,
+		Skills:          recommend.ParseSkills(job.Skills),
+		SalaryMin:       job.SalaryMin,
+		SalaryMax:       job.SalaryMax,
+		SalaryType:      job.SalaryType,
+		ExperienceLevel: job.ExperienceLevel,
+		EducationLevel:  job.EducationLevel,
+		PostedAt:        job.CreatedAt,
 	}
+}
 
-	// 转换为小写进行比较
-	requiredSet := make(map[string]bool)
-	for _, skill := range requiredSkills {
-		requiredSet[strings.ToLower(skill)] = true
-	}
+// CalculateSimilarity 两组技能按标准技能ID计算的Jaccard相似度，golang与Go、k8s与Kubernetes视为同一技能
+func (rs *RecommendationService) CalculateSimilarity(skills1, skills2 []string) float64 {
+	return skills.Default().Similarity(skills1, skills2)
+}
 
-	userSet := make(map[string]bool)
-	for _, skill := range userSkills {
-		userSet[strings.ToLower(skill)] = true
+// CalculateSkillMatch 计算技能匹配度：必备技能权重为加分项的两倍，技能按标准ID比较
+func (rs *RecommendationService) CalculateSkillMatch(requiredSkills, niceToHaveSkills, userSkills []string) skills.MatchResult {
+	optional := false
+	reqs := make([]skills.Requirement, 0, len(requiredSkills)+len(niceToHaveSkills))
+	for _, name := range requiredSkills {
+		reqs = append(reqs, skills.Requirement{Name: name})
 	}
-
-	// 计算匹配的技能数量
-	matched := 0
-	for skill := range requiredSet {
-		if userSet[skill] {
-			matched++
-		}
+	for _, name := range niceToHaveSkills {
+		reqs = append(reqs, skills.Requirement{Name: name, Required: &optional})
 	}
-
-	matchRate := float64(matched) / float64(len(requiredSkills))
-	return matched, matchRate
+	return skills.Default().MatchRequirements(reqs, userSkills)
 }
 
 // getCachedRecommendations 从缓存获取推荐结果
@@ -169,72 +214,3 @@ func (rs *RecommendationService) cacheRecommendations(cacheKey string, recommend
 
 	rs.dbManager.Redis.Set(ctx, cacheKey, data, duration)
 }
-
-// getMockJobRecommendations 获取模拟职位推荐
-func (rs *RecommendationService) getMockJobRecommendations(userID uint, limit int) []JobRecommendation {
-	return []JobRecommendation{
-		{
-			JobID:      1,
-			Title:      "Java开发工程师",
-			Company:    "腾讯科技",
-			Location:   "深圳",
-			SalaryMin:  15000,
-			SalaryMax:  25000,
-			Score:      0.85,
-			SkillMatch: 3,
-			Reason:     "技能匹配度高",
-			Algorithm:  "content_based",
-		},
-		{
-			JobID:      2,
-			Title:      "前端开发工程师",
-			Company:    "阿里巴巴",
-			Location:   "杭州",
-			SalaryMin:  12000,
-			SalaryMax:  20000,
-			Score:      0.72,
-			SkillMatch: 2,
-			Reason:     "技能部分匹配",
-			Algorithm:  "collaborative",
-		},
-		{
-			JobID:      3,
-			Title:      "DevOps工程师",
-			Company:    "美团",
-			Location:   "北京",
-			SalaryMin:  18000,
-			SalaryMax:  30000,
-			Score:      0.82,
-			SkillMatch: 2,
-			Reason:     "基于技能关系图推荐",
-			Algorithm:  "graph_based",
-		},
-	}
-}
-
-// getMockSkillRecommendations 获取模拟技能推荐
-func (rs *RecommendationService) getMockSkillRecommendations(userID uint, limit int) []SkillRecommendation {
-	return []SkillRecommendation{
-		{
-			SkillName: "Spring Boot",
-			Category:  "framework",
-			Score:     0.92,
-			Demand:    85,
-			Reason:    "与Java技能高度相关，市场需求大",
-		},
-		{
-			SkillName: "Docker",
-			Category:  "devops",
-			Score:     0.78,
-			Demand:    72,
-			Reason:    "现代化部署必备技能",
-		},
-		{
-			SkillName: "Kubernetes",
-			Category:  "devops",
-			Score:     0.75,
-			Demand:    68,
-			Reason:    "容器编排必备技能",
-		},
-	}
-}
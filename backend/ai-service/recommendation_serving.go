@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"ai-service/recommend"
+	"ai-service/serving"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"resume-centre/common/skills"
+)
+
+const (
+	recommendCacheTTL   = 30 * time.Minute
+	recommendRequestTTL = 24 * time.Hour // 曝光后可以上报点击/投递的时间
+	recommendRequestKey = "recommend:request:%s"
+	// 职位表指纹的检查间隔，职位变更最迟在这之后使推荐缓存失效
+	jobsVersionTTL = 30 * time.Second
+
+	statisticsStream = "stream:statistics.events"
+	statisticsTopic  = "statistics.events"
+)
+
+// ErrUnknownRecommendation 推荐请求已过期、不属于上报用户或职位不在该次推荐中
+var ErrUnknownRecommendation = errors.New("unknown recommendation request or job")
+
+// 点击/投递行为对应的统计事件
+var recommendationActions = map[string]string{
+	"click": serving.EventClick,
+	"apply": serving.EventApply,
+}
+
+// ServedRecommendations 一次推荐请求的结果，RequestID用于上报点击和投递
+type ServedRecommendations struct {
+	RequestID       string              `json:"request_id"`
+	Experiment      string              `json:"experiment"`
+	Variant         string              `json:"variant"`
+	Recommendations []JobRecommendation `json:"recommendations"`
+}
+
+// servedRequest 保存在Redis中的推荐请求，用于把点击和投递归因到实验分组
+type servedRequest struct {
+	UserID     uint                  `json:"user_id"`
+	Experiment string                `json:"experiment"`
+	Variant    string                `json:"variant"`
+	Jobs       map[uint]servedJobRef `json:"jobs"`
+}
+
+type servedJobRef struct {
+	Position  int    `json:"position"`
+	Algorithm string `json:"algorithm"`
+}
+
+// SetServingConfig 替换推荐实验配置
+func (rs *RecommendationService) SetServingConfig(cfg serving.Config) {
+	rs.servingConfig = cfg
+}
+
+// GetJobRecommendations 获取职位推荐：按实验分组的权重融合内容匹配、协同过滤和技能图谱的候选，
+// 结果按用户资料和职位表的版本缓存，资料或职位变更后自动失效；每次返回都记录曝光事件
+func (rs *RecommendationService) GetJobRecommendations(ctx context.Context, userID uint, limit int) (*ServedRecommendations, error) {
+	cfg := rs.servingConfig
+	variant := cfg.Experiment.Assign(userID)
+
+	version, err := rs.cacheVersion(userID)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := fmt.Sprintf("job_recommendations:%d:%s:%s:%d:%s", userID, cfg.Experiment.Name, variant.Name, limit, version)
+	recommendations, err := rs.getCachedRecommendations(cacheKey)
+	if err != nil || len(recommendations) == 0 {
+		recommendations, err = rs.blendRecommendations(ctx, userID, variant, limit, limit*cfg.CandidatesPerGenerator)
+		if err != nil {
+			return nil, err
+		}
+		rs.cacheRecommendations(cacheKey, recommendations, recommendCacheTTL)
+	}
+
+	served := &ServedRecommendations{
+		RequestID:       newRequestID(),
+		Experiment:      cfg.Experiment.Name,
+		Variant:         variant.Name,
+		Recommendations: recommendations,
+	}
+	if len(recommendations) > 0 {
+		rs.logImpressions(userID, served)
+	}
+	return served, nil
+}
+
+// blendRecommendations 运行各候选生成器并融合排序
+func (rs *RecommendationService) blendRecommendations(ctx context.Context, userID uint, variant serving.Variant, limit, perGenerator int) ([]JobRecommendation, error) {
+	profile, err := rs.loadProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 内容匹配的技能命中数在融合后仍展示给用户
+	skillMatch := make(map[uint]int)
+	generators := []serving.Generator{
+		serving.GeneratorFunc("content", func(ctx context.Context, req serving.Request) ([]serving.Candidate, error) {
+			results, err := rs.rankJobs(profile, req.Limit)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]serving.Candidate, len(results))
+			for i, r := range results {
+				skillMatch[r.JobID] = r.SkillMatch
+				candidates[i] = serving.Candidate{JobID: r.JobID, Score: r.Score, Reason: r.Reason}
+			}
+			return candidates, nil
+		}),
+		serving.GeneratorFunc("collaborative", func(ctx context.Context, req serving.Request) ([]serving.Candidate, error) {
+			applied, err := rs.appliedJobs(req.UserID)
+			if err != nil {
+				return nil, err
+			}
+			results, err := rs.collaborativeCandidates(ctx, req.UserID, applied, req.Limit)
+			if err != nil {
+				return nil, err
+			}
+			candidates := make([]serving.Candidate, len(results))
+			for i, r := range results {
+				candidates[i] = serving.Candidate{JobID: r.JobID, Score: r.Score, Reason: r.Reason}
+			}
+			return candidates, nil
+		}),
+		serving.GeneratorFunc("graph", func(ctx context.Context, req serving.Request) ([]serving.Candidate, error) {
+			return rs.graphCandidates(ctx, profile, req.Limit)
+		}),
+	}
+
+	items, errs, err := serving.Run(ctx, generators, variant, serving.Request{UserID: userID, Limit: perGenerator}, limit)
+	for name, genErr := range errs {
+		log.Printf("Recommendation generator %s failed for user %d: %v", name, userID, genErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rs.hydrateRecommendations(items, skillMatch)
+}
+
+// hydrateRecommendations 补全职位信息，跳过融合期间下线的职位
+func (rs *RecommendationService) hydrateRecommendations(items []serving.Item, skillMatch map[uint]int) ([]JobRecommendation, error) {
+	if len(items) == 0 {
+		return []JobRecommendation{}, nil
+	}
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.JobID
+	}
+	var jobs []Job
+	if err := rs.dbManager.MySQL.Where("id IN ? AND status = ? AND deleted_at IS NULL", ids, "active").
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Job, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+
+	recommendations := make([]JobRecommendation, 0, len(items))
+	for _, item := range items {
+		job, ok := byID[item.JobID]
+		if !ok {
+			continue
+		}
+		recommendations = append(recommendations, JobRecommendation{
+			JobID:      job.ID,
+			Title:      job.Title,
+			Company:    job.CompanyName,
+			Location:   job.Location,
+			SalaryMin:  job.SalaryMin,
+			SalaryMax:  job.SalaryMax,
+			Score:      item.Score,
+			SkillMatch: skillMatch[job.ID],
+			Reason:     item.Reason,
+			Algorithm:  item.Source,
+		})
+	}
+	return recommendations, nil
+}
+
+// graphCandidates 技能图谱推荐：用户技能在图谱中的相邻技能视为可迁移技能，
+// 按职位技能被已掌握或相邻技能覆盖的程度打分，只返回至少要求一项相邻技能的职位
+func (rs *RecommendationService) graphCandidates(ctx context.Context, profile recommend.Profile, limit int) ([]serving.Candidate, error) {
+	dict := skills.Default()
+	owned := make(map[string]bool, len(profile.Skills))
+	for _, name := range profile.Skills {
+		owned[dict.ID(name)] = true
+	}
+	if len(owned) == 0 {
+		return nil, nil
+	}
+
+	type adjacent struct {
+		score float64
+		from  string
+	}
+	neighbors := make(map[string]adjacent)
+	for i, name := range profile.Skills {
+		if i >= 10 {
+			break
+		}
+		related, err := rs.skillGraph.Related(ctx, name, 10)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range related {
+			id := dict.ID(r.Skill)
+			if owned[id] || r.Score <= neighbors[id].score {
+				continue
+			}
+			neighbors[id] = adjacent{score: r.Score, from: name}
+		}
+	}
+	if len(neighbors) == 0 {
+		return nil, nil
+	}
+
+	jobs, err := rs.activeJobs()
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]serving.Candidate, 0)
+	for _, job := range jobs {
+		reqs := recommend.ParseSkills(job.Skills)
+		if len(reqs) == 0 {
+			continue
+		}
+		covered, best := 0.0, adjacent{}
+		bestName := ""
+		for _, req := range reqs {
+			if owned[req.ID] {
+				covered++
+				continue
+			}
+			if n, ok := neighbors[req.ID]; ok {
+				covered += n.score
+				if n.score > best.score {
+					best, bestName = n, req.Name
+				}
+			}
+		}
+		if bestName == "" {
+			continue
+		}
+		candidates = append(candidates, serving.Candidate{
+			JobID:  job.ID,
+			Score:  covered / float64(len(reqs)),
+			Reason: fmt.Sprintf("职位要求的%s常与你掌握的%s一起出现", bestName, best.from),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].JobID < candidates[j].JobID
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// cacheVersion 推荐缓存的版本：用户资料的更新时间加职位表指纹，任一变化即换用新的缓存键
+func (rs *RecommendationService) cacheVersion(userID uint) (string, error) {
+	jobsVersion, err := rs.jobsFingerprint()
+	if err != nil {
+		return "", err
+	}
+	var profile UserProfile
+	err = rs.dbManager.MySQL.Select("updated_at").Where("user_id = ?", userID).First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%s", profile.UpdatedAt.Unix(), jobsVersion), nil
+}
+
+// jobsFingerprint 在招职位数和职位表最后更新时间，在内存中缓存jobsVersionTTL
+func (rs *RecommendationService) jobsFingerprint() (string, error) {
+	rs.jobsMu.Lock()
+	defer rs.jobsMu.Unlock()
+	if rs.jobsVersion != "" && time.Since(rs.jobsCheckedAt) < jobsVersionTTL {
+		return rs.jobsVersion, nil
+	}
+
+	var row struct {
+		Active  int64
+		Updated *time.Time
+	}
+	if err := rs.dbManager.MySQL.Model(&Job{}).
+		Select("SUM(CASE WHEN status = 'active' AND deleted_at IS NULL THEN 1 ELSE 0 END) AS active, MAX(updated_at) AS updated").
+		Scan(&row).Error; err != nil {
+		return "", err
+	}
+	updated := int64(0)
+	if row.Updated != nil {
+		updated = row.Updated.Unix()
+	}
+	rs.jobsVersion = fmt.Sprintf("%d.%d", row.Active, updated)
+	rs.jobsCheckedAt = time.Now()
+	return rs.jobsVersion, nil
+}
+
+// logImpressions 保存请求上下文并异步写入曝光事件，失败只记录日志
+func (rs *RecommendationService) logImpressions(userID uint, served *ServedRecommendations) {
+	req := servedRequest{
+		UserID:     userID,
+		Experiment: served.Experiment,
+		Variant:    served.Variant,
+		Jobs:       make(map[uint]servedJobRef, len(served.Recommendations)),
+	}
+	events := make([]recommendationEvent, len(served.Recommendations))
+	for i, r := range served.Recommendations {
+		ref := servedJobRef{Position: i + 1, Algorithm: r.Algorithm}
+		req.Jobs[r.JobID] = ref
+		events[i] = newRecommendationEvent(fmt.Sprintf("%s:%d", served.RequestID, ref.Position),
+			serving.EventImpression, served.RequestID, r.JobID, req, ref)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		data, err := json.Marshal(req)
+		if err == nil {
+			err = rs.dbManager.Redis.Set(ctx, fmt.Sprintf(recommendRequestKey, served.RequestID), data, recommendRequestTTL).Err()
+		}
+		if err != nil {
+			log.Printf("Failed to save recommendation request %s: %v", served.RequestID, err)
+		}
+		if err := rs.publishEvents(ctx, events); err != nil {
+			log.Printf("Failed to log recommendation impressions for request %s: %v", served.RequestID, err)
+		}
+	}()
+}
+
+// RecordRecommendationEvent 记录推荐结果的点击或投递，归因到曝光时的实验分组。
+// 只接受推荐请求所属用户的上报，其他用户的请求按不存在处理
+func (rs *RecommendationService) RecordRecommendationEvent(ctx context.Context, userID uint, requestID string, jobID uint, action string) error {
+	eventType, ok := recommendationActions[action]
+	if !ok {
+		return fmt.Errorf("unsupported action %q", action)
+	}
+	data, err := rs.dbManager.Redis.Get(ctx, fmt.Sprintf(recommendRequestKey, requestID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrUnknownRecommendation
+	}
+	if err != nil {
+		return err
+	}
+	var req servedRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	if req.UserID != userID {
+		return ErrUnknownRecommendation
+	}
+	ref, ok := req.Jobs[jobID]
+	if !ok {
+		return ErrUnknownRecommendation
+	}
+	// 同一请求同一职位的重复上报按事件ID去重
+	event := newRecommendationEvent(fmt.Sprintf("%s:%d:%s", requestID, jobID, action), eventType, requestID, jobID, req, ref)
+	return rs.publishEvents(ctx, []recommendationEvent{event})
+}
+
+// recommendationEvent 写入统计服务的事件，格式与统计服务的事件接入一致
+type recommendationEvent struct {
+	ID          string         `json:"id"`
+	Type        string         `json:"type"`
+	Value       int64          `json:"value"`
+	UserID      *uint          `json:"user_id,omitempty"`
+	ReferenceID string         `json:"reference_id"`
+	Metadata    map[string]any `json:"metadata"`
+	Timestamp   time.Time      `json:"timestamp"`
+}
+
+func newRecommendationEvent(id, eventType, requestID string, jobID uint, req servedRequest, ref servedJobRef) recommendationEvent {
+	userID := req.UserID
+	return recommendationEvent{
+		ID:          id,
+		Type:        eventType,
+		Value:       1,
+		UserID:      &userID,
+		ReferenceID: strconv.FormatUint(uint64(jobID), 10),
+		Metadata: map[string]any{
+			"request_id": requestID,
+			"experiment": req.Experiment,
+			"variant":    req.Variant,
+			"position":   ref.Position,
+			"algorithm":  ref.Algorithm,
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+// publishEvents 把事件写入统计服务消费的Redis Stream
+func (rs *RecommendationService) publishEvents(ctx context.Context, events []recommendationEvent) error {
+	pipe := rs.dbManager.Redis.Pipeline()
+	for _, event := range events {
+		data, err := json.Marshal(map[string]any{
+			"id":        event.ID,
+			"topic":     statisticsTopic,
+			"data":      event,
+			"timestamp": event.Timestamp,
+		})
+		if err != nil {
+			return err
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: statisticsStream,
+			Values: []interface{}{
+				"data", string(data),
+				"topic", statisticsTopic,
+				"timestamp", event.Timestamp.Unix(),
+			},
+		})
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ExperimentReport 实验各分组的曝光、点击、投递和点击率/投递率，基于统计服务保存的原始事件
+func (rs *RecommendationService) ExperimentReport(ctx context.Context, experiment string, since time.Time) ([]serving.VariantStats, error) {
+	// metadata为文本列，先校验是JSON再取字段，避免其它事件的元数据导致查询失败
+	const variantExpr = "CASE WHEN JSON_VALID(metadata) THEN JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.variant')) END"
+	const experimentExpr = "CASE WHEN JSON_VALID(metadata) THEN JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.experiment')) END"
+
+	var rows []struct {
+		Variant string
+		Type    string
+		Events  int64
+		Users   int64
+	}
+	if err := rs.dbManager.MySQL.WithContext(ctx).Table("statistics_events").
+		Select(variantExpr+" AS variant, type, COUNT(*) AS events, COUNT(DISTINCT user_id) AS users").
+		Where("type IN ? AND occurred_at >= ?", []string{serving.EventImpression, serving.EventClick, serving.EventApply}, since).
+		Where(experimentExpr+" = ?", experiment).
+		Group("variant, type").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*serving.VariantStats)
+	var order []string
+	get := func(name string) *serving.VariantStats {
+		if s, ok := stats[name]; ok {
+			return s
+		}
+		stats[name] = &serving.VariantStats{Variant: name}
+		order = append(order, name)
+		return stats[name]
+	}
+	// 当前实验的分组即使还没有事件也列出
+	if experiment == rs.servingConfig.Experiment.Name {
+		for _, v := range rs.servingConfig.Experiment.Variants {
+			get(v.Name)
+		}
+	}
+	for _, row := range rows {
+		s := get(row.Variant)
+		switch row.Type {
+		case serving.EventImpression:
+			s.Impressions, s.Users = row.Events, row.Users
+		case serving.EventClick:
+			s.Clicks = row.Events
+		case serving.EventApply:
+			s.Applies = row.Events
+		}
+	}
+
+	report := make([]serving.VariantStats, 0, len(order))
+	for _, name := range order {
+		stats[name].ComputeRates()
+		report = append(report, *stats[name])
+	}
+	return report, nil
+}
+
+// newRequestID 随机的推荐请求ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"ai-service/recommend"
+
+	"gorm.io/gorm"
+
+	"resume-centre/common/skills"
+)
+
+// 一个职位参与评分的投递数上限，取最近的投递
+const maxJobApplications = 2000
+
+// ErrResumeNotFound 简历不存在或已删除，或不属于调用者
+var ErrResumeNotFound = errors.New("resume not found")
+
+// 投递列表排序方式
+const (
+	SortByAppliedAt = "applied_at"
+	SortByScore     = "score"
+)
+
+// ResumeMatch 简历与职位的匹配评估
+type ResumeMatch struct {
+	ResumeID uint   `json:"resume_id"`
+	JobID    uint   `json:"job_id"`
+	UserID   uint   `json:"user_id"`
+	JobTitle string `json:"job_title"`
+	recommend.Match
+}
+
+// ApplicationMatch 职位的一份投递及其匹配度
+type ApplicationMatch struct {
+	UserID          uint      `json:"user_id"`
+	Username        string    `json:"username"`
+	Nickname        string    `json:"nickname"`
+	ResumeID        uint      `json:"resume_id"`
+	ResumeTitle     string    `json:"resume_title"`
+	AppliedAt       time.Time `json:"applied_at"`
+	Score           float64   `json:"score"`
+	Level           string    `json:"level"`
+	MatchedSkills   []string  `json:"matched_skills"`
+	MissingRequired []string  `json:"missing_required"`
+	Reason          string    `json:"reason"`
+	Feedback        string    `json:"feedback,omitempty"` // 企业的处理结果
+}
+
+// MatchResume 评估简历与职位的匹配度：求职资料中的技能与简历正文提到的技能合并参与匹配，
+// 并检查简历是否写明了职位要求的技能。只能评估userID本人的简历
+func (rs *RecommendationService) MatchResume(userID, resumeID, jobID uint) (*ResumeMatch, error) {
+	mysql := rs.dbManager.MySQL
+
+	var resume Resume
+	if err := mysql.Where("id = ? AND user_id = ? AND deleted_at IS NULL", resumeID, userID).First(&resume).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResumeNotFound
+		}
+		return nil, err
+	}
+	job, err := rs.loadJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := rs.loadProfile(resume.UserID)
+	if err != nil && !errors.Is(err, ErrProfileNotFound) {
+		return nil, err
+	}
+	return &ResumeMatch{
+		ResumeID: resume.ID,
+		JobID:    job.ID,
+		UserID:   resume.UserID,
+		JobTitle: job.Title,
+		Match:    matchResume(profile, resume, job),
+	}, nil
+}
+
+// GetJobApplications 职位的投递列表及每份投递的匹配度。投递来自用户行为中的投递记录，
+// 同一求职者多次投递只保留最近一次；行为元数据中没有简历ID时使用求职者的默认简历
+func (rs *RecommendationService) GetJobApplications(jobID uint, sortBy string, offset, limit int) ([]ApplicationMatch, int, error) {
+	mysql := rs.dbManager.MySQL
+	job, err := rs.loadJob(jobID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var behaviors []UserBehavior
+	if err := mysql.Where("target_type = ? AND target_id = ? AND action IN ?", "job", jobID, applyActions).
+		Order("created_at DESC").Limit(maxJobApplications).Find(&behaviors).Error; err != nil {
+		return nil, 0, err
+	}
+	type application struct {
+		userID, resumeID uint
+		appliedAt        time.Time
+	}
+	var applications []application
+	seen := make(map[uint]bool)
+	for _, b := range behaviors {
+		if seen[b.UserID] {
+			continue
+		}
+		seen[b.UserID] = true
+		var meta struct {
+			ResumeID uint `json:"resume_id"`
+		}
+		// 元数据缺失或格式不符时使用默认简历
+		_ = json.Unmarshal([]byte(b.Metadata), &meta)
+		applications = append(applications, application{userID: b.UserID, resumeID: meta.ResumeID, appliedAt: b.CreatedAt})
+	}
+	if len(applications) == 0 {
+		return []ApplicationMatch{}, 0, nil
+	}
+
+	userIDs := make([]uint, len(applications))
+	for i, a := range applications {
+		userIDs[i] = a.userID
+	}
+	var resumes []Resume
+	if err := mysql.Where("user_id IN ? AND deleted_at IS NULL", userIDs).Order("updated_at DESC").Find(&resumes).Error; err != nil {
+		return nil, 0, err
+	}
+	resumesByID := make(map[uint]Resume, len(resumes))
+	defaultResume := make(map[uint]Resume, len(userIDs))
+	for _, r := range resumes {
+		resumesByID[r.ID] = r
+		if existing, ok := defaultResume[r.UserID]; !ok || (r.IsDefault && !existing.IsDefault) {
+			defaultResume[r.UserID] = r
+		}
+	}
+	var users []User
+	if err := mysql.Select("id, username, nickname, location").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	usersByID := make(map[uint]User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	var profiles []UserProfile
+	if err := mysql.Where("user_id IN ?", userIDs).Find(&profiles).Error; err != nil {
+		return nil, 0, err
+	}
+	profilesByUser := make(map[uint]UserProfile, len(profiles))
+	for _, p := range profiles {
+		profilesByUser[p.UserID] = p
+	}
+
+	var feedbacks []CandidateFeedback
+	if err := mysql.Select("user_id, action").Where("job_id = ?", jobID).Find(&feedbacks).Error; err != nil {
+		return nil, 0, err
+	}
+	reviewed := make(map[uint]string, len(feedbacks))
+	for _, f := range feedbacks {
+		reviewed[f.UserID] = f.Action
+	}
+
+	results := make([]ApplicationMatch, 0, len(applications))
+	for _, a := range applications {
+		resume, ok := resumesByID[a.resumeID]
+		if !ok || resume.UserID != a.userID {
+			resume = defaultResume[a.userID]
+		}
+		user := usersByID[a.userID]
+		m := matchResume(toProfile(user, profilesByUser[a.userID]), resume, job)
+		results = append(results, ApplicationMatch{
+			UserID:          a.userID,
+			Username:        user.Username,
+			Nickname:        user.Nickname,
+			ResumeID:        resume.ID,
+			ResumeTitle:     resume.Title,
+			AppliedAt:       a.appliedAt,
+			Score:           m.Score,
+			Level:           m.Level,
+			MatchedSkills:   m.Skills.Matched,
+			MissingRequired: m.Skills.MissingRequired,
+			Reason:          m.Reason(),
+			Feedback:        reviewed[a.userID],
+		})
+	}
+	// 投递记录已按时间倒序，按匹配度排序时同分保持投递时间倒序
+	if sortBy == SortByScore {
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	}
+
+	total := len(results)
+	if offset >= total {
+		return []ApplicationMatch{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return results[offset:end], total, nil
+}
+
+// loadJob 读取未删除的职位
+func (rs *RecommendationService) loadJob(jobID uint) (Job, error) {
+	var job Job
+	if err := rs.dbManager.MySQL.Where("id = ? AND deleted_at IS NULL", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return job, ErrJobNotFound
+		}
+		return job, err
+	}
+	return job, nil
+}
+
+// toProfile 由用户和求职资料组成打分用的画像
+func toProfile(user User, profile UserProfile) recommend.Profile {
+	return recommend.Profile{
+		Skills:            recommend.SkillNames(recommend.ParseSkills(profile.Skills)),
+		ExpectedSalaryMin: profile.ExpectedSalaryMin,
+		ExpectedSalaryMax: profile.ExpectedSalaryMax,
+		Location:          user.Location,
+		WorkExperience:    profile.WorkExperience,
+		EducationLevel:    profile.EducationLevel,
+	}
+}
+
+// matchResume 简历正文提到的技能并入求职资料的技能后评估匹配度；没有简历时只按求职资料
+func matchResume(profile recommend.Profile, resume Resume, job Job) recommend.Match {
+	var mentioned []string
+	if resume.ID != 0 {
+		mentioned = skills.Default().Extract(resume.Content)
+		profile.Skills = append(append([]string(nil), profile.Skills...), mentioned...)
+	}
+	return recommend.NewScorer().Match(profile, mentioned, toRecommendJob(job))
+}
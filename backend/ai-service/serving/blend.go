@@ -0,0 +1,114 @@
+// Package serving 推荐服务层：组合多个候选生成器（内容、协同过滤、技能图谱），
+// 按实验分组配置的权重融合排序，并按用户ID把流量确定性地分配到实验分组
+package serving
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// Candidate 生成器给出的候选职位，Score在各生成器内部可比
+type Candidate struct {
+	JobID  uint
+	Score  float64
+	Reason string
+}
+
+// Request 一次推荐请求
+type Request struct {
+	UserID uint
+	Limit  int // 每个生成器返回的候选数
+}
+
+// Generator 候选生成器
+type Generator interface {
+	Name() string
+	Generate(ctx context.Context, req Request) ([]Candidate, error)
+}
+
+// GeneratorFunc 把函数包装为生成器
+func GeneratorFunc(name string, fn func(ctx context.Context, req Request) ([]Candidate, error)) Generator {
+	return generatorFunc{name: name, fn: fn}
+}
+
+type generatorFunc struct {
+	name string
+	fn   func(ctx context.Context, req Request) ([]Candidate, error)
+}
+
+func (g generatorFunc) Name() string { return g.name }
+
+func (g generatorFunc) Generate(ctx context.Context, req Request) ([]Candidate, error) {
+	return g.fn(ctx, req)
+}
+
+// Item 融合后的推荐结果，Source为贡献最大的生成器
+type Item struct {
+	JobID   uint               `json:"job_id"`
+	Score   float64            `json:"score"`
+	Reason  string             `json:"reason"`
+	Source  string             `json:"source"`
+	Sources map[string]float64 `json:"sources"` // 各生成器归一化后的分数
+}
+
+// Blend 按权重融合各生成器的候选：每个生成器的分数先除以其最高分归一化，
+// 再按权重加总；同分按职位ID排序保证结果稳定
+func Blend(results map[string][]Candidate, weights map[string]float64, limit int) []Item {
+	items := make(map[uint]*Item)
+	contribution := make(map[uint]float64)
+	for name, candidates := range results {
+		w := weights[name]
+		if w <= 0 || len(candidates) == 0 {
+			continue
+		}
+		top := 0.0
+		for _, c := range candidates {
+			top = math.Max(top, c.Score)
+		}
+		for _, c := range candidates {
+			normalized := 1.0
+			if top > 0 {
+				normalized = c.Score / top
+			}
+			item, ok := items[c.JobID]
+			if !ok {
+				item = &Item{JobID: c.JobID, Sources: map[string]float64{}}
+				items[c.JobID] = item
+			}
+			if _, seen := item.Sources[name]; seen {
+				continue
+			}
+			item.Sources[name] = math.Round(normalized*1000) / 1000
+			item.Score += w * normalized
+			if w*normalized > contribution[c.JobID] {
+				contribution[c.JobID] = w * normalized
+				item.Source, item.Reason = name, c.Reason
+			}
+		}
+	}
+
+	totalWeight := 0.0
+	for name, w := range weights {
+		if w > 0 && len(results[name]) > 0 {
+			totalWeight += w
+		}
+	}
+	list := make([]Item, 0, len(items))
+	for _, item := range items {
+		if totalWeight > 0 {
+			item.Score = math.Round(item.Score/totalWeight*1000) / 1000
+		}
+		list = append(list, *item)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score > list[j].Score
+		}
+		return list[i].JobID < list[j].JobID
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
@@ -0,0 +1,104 @@
+package serving
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+)
+
+// Variant 实验分组：Traffic为流量权重，Weights为各生成器的融合权重
+type Variant struct {
+	Name    string             `json:"name"`
+	Traffic int                `json:"traffic"`
+	Weights map[string]float64 `json:"weights"`
+}
+
+// Experiment 一个推荐实验
+type Experiment struct {
+	Name     string    `json:"name"`
+	Variants []Variant `json:"variants"`
+}
+
+// Config 推荐服务配置
+type Config struct {
+	Experiment Experiment `json:"experiment"`
+	// CandidatesPerGenerator 每个生成器取回的候选数是最终条数的倍数
+	CandidatesPerGenerator int `json:"candidates_per_generator"`
+}
+
+// DefaultConfig 未配置实验时所有用户使用同一组权重
+var DefaultConfig = Config{
+	Experiment: Experiment{
+		Name: "job_recommendation",
+		Variants: []Variant{
+			{Name: "control", Traffic: 100, Weights: map[string]float64{"content": 0.6, "collaborative": 0.3, "graph": 0.1}},
+		},
+	},
+	CandidatesPerGenerator: 3,
+}
+
+// LoadConfig 从JSON文件读取配置，path为空时使用默认配置
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		return DefaultConfig, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if cfg.CandidatesPerGenerator <= 0 {
+		cfg.CandidatesPerGenerator = DefaultConfig.CandidatesPerGenerator
+	}
+	return cfg, cfg.Experiment.Validate()
+}
+
+// Validate 实验需要名称、至少一个有流量的分组，分组名不能重复
+func (e Experiment) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("experiment name is required")
+	}
+	total := 0
+	names := make(map[string]bool, len(e.Variants))
+	for _, v := range e.Variants {
+		if v.Name == "" || names[v.Name] {
+			return fmt.Errorf("experiment %s: variant names must be unique and non-empty", e.Name)
+		}
+		if v.Traffic < 0 {
+			return fmt.Errorf("experiment %s: variant %s has negative traffic", e.Name, v.Name)
+		}
+		names[v.Name] = true
+		total += v.Traffic
+	}
+	if total == 0 {
+		return fmt.Errorf("experiment %s has no traffic", e.Name)
+	}
+	return nil
+}
+
+// Assign 按实验名和用户ID的哈希把用户分到固定分组，同一用户在实验配置不变时始终落在同一组；
+// 调整流量比例只会移动边界附近的用户
+func (e Experiment) Assign(userID uint) Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Traffic
+	}
+	if total <= 0 {
+		return Variant{}
+	}
+	h := fnv.New64a()
+	h.Write([]byte(e.Name + ":" + strconv.FormatUint(uint64(userID), 10)))
+	bucket := int(h.Sum64() % uint64(total))
+	for _, v := range e.Variants {
+		if bucket < v.Traffic {
+			return v
+		}
+		bucket -= v.Traffic
+	}
+	return e.Variants[len(e.Variants)-1]
+}
@@ -0,0 +1,70 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Run 并发执行分组中权重大于0的生成器并融合结果。单个生成器失败时用其余生成器的结果，
+// 失败信息通过errs返回；全部失败时返回错误
+func Run(ctx context.Context, generators []Generator, variant Variant, req Request, limit int) (items []Item, errs map[string]error, err error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string][]Candidate)
+	)
+	errs = make(map[string]error)
+	for _, g := range generators {
+		if variant.Weights[g.Name()] <= 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(g Generator) {
+			defer wg.Done()
+			candidates, err := g.Generate(ctx, req)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[g.Name()] = err
+				return
+			}
+			results[g.Name()] = candidates
+		}(g)
+	}
+	wg.Wait()
+
+	if len(results) == 0 && len(errs) > 0 {
+		return nil, errs, fmt.Errorf("all recommendation generators failed: %v", errs)
+	}
+	return Blend(results, variant.Weights, limit), errs, nil
+}
+
+// 推荐事件类型，写入统计服务
+const (
+	EventImpression = "recommendation_impression"
+	EventClick      = "recommendation_click"
+	EventApply      = "recommendation_apply"
+)
+
+// VariantStats 实验分组的曝光、点击和投递
+type VariantStats struct {
+	Variant     string  `json:"variant"`
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	Applies     int64   `json:"applies"`
+	Users       int64   `json:"users"`
+	CTR         float64 `json:"ctr"`
+	ApplyRate   float64 `json:"apply_rate"`
+}
+
+// ComputeRates 计算点击率和投递率（相对曝光数）
+func (s *VariantStats) ComputeRates() {
+	if s.Impressions == 0 {
+		s.CTR, s.ApplyRate = 0, 0
+		return
+	}
+	s.CTR = math.Round(float64(s.Clicks)/float64(s.Impressions)*10000) / 10000
+	s.ApplyRate = math.Round(float64(s.Applies)/float64(s.Impressions)*10000) / 10000
+}
@@ -0,0 +1,133 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlend(t *testing.T) {
+	results := map[string][]Candidate{
+		"content":       {{JobID: 1, Score: 0.8, Reason: "技能匹配"}, {JobID: 2, Score: 0.4, Reason: "地点匹配"}},
+		"collaborative": {{JobID: 2, Score: 0.2, Reason: "相似用户关注"}, {JobID: 3, Score: 0.1, Reason: "相似用户关注"}},
+		"graph":         {{JobID: 4, Score: 1}},
+	}
+	weights := map[string]float64{"content": 0.5, "collaborative": 0.5, "graph": 0}
+
+	items := Blend(results, weights, 0)
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3 (graph has zero weight): %+v", len(items), items)
+	}
+	// 职位2：内容0.5 + 协同1.0，加权后为0.75，排第一
+	if items[0].JobID != 2 || items[0].Score != 0.75 || items[0].Source != "collaborative" || items[0].Reason != "相似用户关注" {
+		t.Errorf("first item = %+v", items[0])
+	}
+	if items[1].JobID != 1 || items[1].Score != 0.5 || items[1].Source != "content" {
+		t.Errorf("second item = %+v", items[1])
+	}
+	if items[2].JobID != 3 || items[2].Score != 0.25 {
+		t.Errorf("third item = %+v", items[2])
+	}
+	if got := Blend(results, weights, 1); len(got) != 1 || got[0].JobID != 2 {
+		t.Errorf("limit not applied: %+v", got)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	exp := Experiment{Name: "exp", Variants: []Variant{
+		{Name: "a", Traffic: 50},
+		{Name: "b", Traffic: 30},
+		{Name: "c", Traffic: 20},
+	}}
+	counts := map[string]int{}
+	for id := uint(1); id <= 10000; id++ {
+		v := exp.Assign(id)
+		if exp.Assign(id).Name != v.Name {
+			t.Fatalf("assignment for user %d is not deterministic", id)
+		}
+		counts[v.Name]++
+	}
+	for name, want := range map[string]int{"a": 5000, "b": 3000, "c": 2000} {
+		if got := counts[name]; got < want-300 || got > want+300 {
+			t.Errorf("variant %s got %d users, want about %d", name, got, want)
+		}
+	}
+
+	// 不同实验名的分组相互独立
+	other := Experiment{Name: "other", Variants: exp.Variants}
+	same := 0
+	for id := uint(1); id <= 1000; id++ {
+		if exp.Assign(id).Name == other.Assign(id).Name {
+			same++
+		}
+	}
+	if same > 600 {
+		t.Errorf("%d of 1000 users share variants across experiments", same)
+	}
+}
+
+type fakeGenerator struct {
+	name       string
+	candidates []Candidate
+	err        error
+}
+
+func (g fakeGenerator) Name() string { return g.name }
+
+func (g fakeGenerator) Generate(context.Context, Request) ([]Candidate, error) {
+	return g.candidates, g.err
+}
+
+func TestRun(t *testing.T) {
+	generators := []Generator{
+		fakeGenerator{name: "content", candidates: []Candidate{{JobID: 1, Score: 1}}},
+		fakeGenerator{name: "collaborative", err: errors.New("model unavailable")},
+	}
+	variant := Variant{Name: "v", Weights: map[string]float64{"content": 1, "collaborative": 1}}
+
+	items, errs, err := Run(context.Background(), generators, variant, Request{UserID: 1, Limit: 10}, 10)
+	if err != nil || len(items) != 1 || items[0].JobID != 1 || errs["collaborative"] == nil {
+		t.Fatalf("Run = %+v, %v, %v", items, errs, err)
+	}
+	// 失败的生成器不计入权重
+	if items[0].Score != 1 {
+		t.Errorf("score = %v, want 1", items[0].Score)
+	}
+
+	if _, _, err := Run(context.Background(), generators[1:], variant, Request{}, 10); err == nil {
+		t.Error("expected error when every generator fails")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig("")
+	if err != nil || cfg.Experiment.Name != DefaultConfig.Experiment.Name {
+		t.Fatalf("default config: %+v, %v", cfg, err)
+	}
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	os.WriteFile(valid, []byte(`{"experiment":{"name":"blend_v2","variants":[
+		{"name":"control","traffic":90,"weights":{"content":1}},
+		{"name":"cf_heavy","traffic":10,"weights":{"content":0.4,"collaborative":0.6}}]}}`), 0o644)
+	cfg, err = LoadConfig(valid)
+	if err != nil || len(cfg.Experiment.Variants) != 2 || cfg.CandidatesPerGenerator != 3 {
+		t.Fatalf("valid config: %+v, %v", cfg, err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"experiment":{"name":"x","variants":[{"name":"a","traffic":0}]}}`), 0o644)
+	if _, err := LoadConfig(invalid); err == nil {
+		t.Error("expected error for experiment without traffic")
+	}
+}
+
+func TestComputeRates(t *testing.T) {
+	s := VariantStats{Impressions: 200, Clicks: 13, Applies: 3}
+	s.ComputeRates()
+	if s.CTR != 0.065 || s.ApplyRate != 0.015 {
+		t.Errorf("rates = %v, %v", s.CTR, s.ApplyRate)
+	}
+}
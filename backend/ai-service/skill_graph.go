@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ai-service/recommend"
+	"ai-service/skillgraph"
+)
+
+// 共现次数少于该值的技能对不写入图谱
+const skillGraphMinCooccurrence = 2
+
+// SkillGraphSummary 一次图谱重建的结果
+type SkillGraphSummary struct {
+	Skills   int       `json:"skills"`
+	Postings int       `json:"postings"`
+	Edges    int       `json:"edges"`
+	BuiltAt  time.Time `json:"built_at"`
+	Duration string    `json:"duration"`
+}
+
+// RebuildSkillGraph 写入内置技能体系，并从在招职位的技能要求重新挖掘技能共现关系
+func (rs *RecommendationService) RebuildSkillGraph(ctx context.Context) (*SkillGraphSummary, error) {
+	started := time.Now()
+
+	var jobs []Job
+	if err := rs.dbManager.MySQL.Select("id, skills").Where("status = ? AND deleted_at IS NULL", "active").
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	postings := make([][]string, 0, len(jobs))
+	for _, job := range jobs {
+		postings = append(postings, recommend.SkillNames(recommend.ParseSkills(job.Skills)))
+	}
+
+	stats, err := rs.skillGraph.Rebuild(ctx, skillgraph.DefaultTaxonomy, postings, skillGraphMinCooccurrence)
+	if err != nil {
+		return nil, err
+	}
+	summary := &SkillGraphSummary{
+		Skills:   len(stats.Demand),
+		Postings: stats.Postings,
+		Edges:    len(stats.Edges),
+		BuiltAt:  time.Now(),
+		Duration: time.Since(started).Round(time.Millisecond).String(),
+	}
+	log.Printf("Skill graph rebuilt: %d postings, %d skills, %d co-occurrence edges", summary.Postings, summary.Skills, summary.Edges)
+	return summary, nil
+}
+
+// GetSkillRecommendations 获取技能推荐：对比用户技能与目标职位的要求，结合技能图谱推荐要补的技能。
+// 未指定目标职位时以内容推荐的前10个职位为目标
+func (rs *RecommendationService) GetSkillRecommendations(ctx context.Context, userID uint, jobIDs []uint, limit int) ([]SkillRecommendation, error) {
+	profile, err := rs.loadProfile(userID)
+	if err != nil && !errors.Is(err, ErrProfileNotFound) {
+		return nil, err
+	}
+
+	var targets [][]string
+	if len(jobIDs) > 0 {
+		var jobs []Job
+		if err := rs.dbManager.MySQL.Select("id, skills").Where("id IN ? AND deleted_at IS NULL", jobIDs).
+			Find(&jobs).Error; err != nil {
+			return nil, err
+		}
+		for _, job := range jobs {
+			targets = append(targets, recommend.SkillNames(recommend.ParseSkills(job.Skills)))
+		}
+	} else {
+		results, err := rs.rankJobResults(profile, 10)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			targets = append(targets, recommend.SkillNames(r.Job.Skills))
+		}
+	}
+
+	recs, err := rs.skillGraph.SkillGap(ctx, profile.Skills, targets, limit)
+	if err != nil {
+		return nil, err
+	}
+	recommendations := make([]SkillRecommendation, len(recs))
+	for i, r := range recs {
+		recommendations[i] = SkillRecommendation{
+			SkillName:     r.Skill,
+			Category:      r.Category,
+			Score:         r.Score,
+			Demand:        r.Demand,
+			Reason:        r.Reason,
+			RelatedSkills: r.RelatedSkills,
+		}
+	}
+	return recommendations, nil
+}
+
+// startSkillGraphSync 启动时和之后每隔SKILL_GRAPH_INTERVAL（默认24h）重建技能图谱
+func startSkillGraphSync(ctx context.Context, rs *RecommendationService) {
+	interval := envDuration("SKILL_GRAPH_INTERVAL", 24*time.Hour)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := rs.RebuildSkillGraph(ctx); err != nil {
+				log.Printf("Failed to rebuild skill graph: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
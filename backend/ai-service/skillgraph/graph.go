@@ -0,0 +1,316 @@
+package skillgraph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Edge 两个技能在同一职位中共同出现的次数
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// Stats 从职位中挖掘的统计：每个技能出现的职位数和技能共现
+type Stats struct {
+	Postings int            `json:"postings"`
+	Demand   map[string]int `json:"demand"`
+	Edges    []Edge         `json:"edges"` // 无向边，From < To
+}
+
+// Store 技能图谱存储
+type Store interface {
+	// SaveTaxonomy 写入标准技能、别名和分类
+	SaveTaxonomy(ctx context.Context, skills []Skill) error
+	// Taxonomy 读取全部标准技能
+	Taxonomy(ctx context.Context) ([]Skill, error)
+	// SaveStats 用新的挖掘结果替换技能需求量和共现边
+	SaveStats(ctx context.Context, stats *Stats) error
+	// Neighbors 每个给定技能共现次数最多的limit条边，From为给定技能
+	Neighbors(ctx context.Context, names []string, limit int) ([]Edge, error)
+	// Demand 技能出现的职位数
+	Demand(ctx context.Context, names []string) (map[string]int, error)
+}
+
+// Mine 从职位技能列表中统计需求量和共现，共现次数少于minCount的边丢弃
+func Mine(postings [][]string, taxonomy *Taxonomy, minCount int) *Stats {
+	stats := &Stats{Demand: make(map[string]int)}
+	pairs := make(map[[2]string]int)
+	for _, posting := range postings {
+		set := taxonomy.CanonicalSet(posting)
+		if len(set) == 0 {
+			continue
+		}
+		stats.Postings++
+		names := make([]string, 0, len(set))
+		for name := range set {
+			stats.Demand[name]++
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i := range names {
+			for j := i + 1; j < len(names); j++ {
+				pairs[[2]string{names[i], names[j]}]++
+			}
+		}
+	}
+
+	for pair, count := range pairs {
+		if count >= minCount {
+			stats.Edges = append(stats.Edges, Edge{From: pair[0], To: pair[1], Count: count})
+		}
+	}
+	sort.Slice(stats.Edges, func(i, j int) bool {
+		if stats.Edges[i].From != stats.Edges[j].From {
+			return stats.Edges[i].From < stats.Edges[j].From
+		}
+		return stats.Edges[i].To < stats.Edges[j].To
+	})
+	return stats
+}
+
+// Recommendation 技能推荐结果
+type Recommendation struct {
+	Skill         string   `json:"skill_name"`
+	Category      string   `json:"category"`
+	Score         float64  `json:"score"`
+	Demand        int      `json:"demand"`
+	Reason        string   `json:"reason"`
+	RelatedSkills []string `json:"related_skills,omitempty"` // 用户已掌握、与该技能常一起出现的技能
+}
+
+// 每个技能取的共现边数
+const neighborLimit = 20
+
+// Recommender 基于技能图谱的技能缺口推荐
+type Recommender struct {
+	store Store
+
+	mu       sync.RWMutex
+	taxonomy *Taxonomy
+}
+
+// NewRecommender 创建推荐器，技能体系在首次使用时从存储加载
+func NewRecommender(store Store) *Recommender {
+	return &Recommender{store: store}
+}
+
+// Taxonomy 当前技能体系
+func (r *Recommender) Taxonomy(ctx context.Context) (*Taxonomy, error) {
+	r.mu.RLock()
+	t := r.taxonomy
+	r.mu.RUnlock()
+	if t != nil {
+		return t, nil
+	}
+	return r.Reload(ctx)
+}
+
+// Reload 从存储重新加载技能体系
+func (r *Recommender) Reload(ctx context.Context) (*Taxonomy, error) {
+	skills, err := r.store.Taxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t := NewTaxonomy(skills)
+	r.mu.Lock()
+	r.taxonomy = t
+	r.mu.Unlock()
+	return t, nil
+}
+
+// Rebuild 写入技能体系并用职位技能重新挖掘共现关系
+func (r *Recommender) Rebuild(ctx context.Context, taxonomy []Skill, postings [][]string, minCount int) (*Stats, error) {
+	if err := r.store.SaveTaxonomy(ctx, taxonomy); err != nil {
+		return nil, fmt.Errorf("save taxonomy: %w", err)
+	}
+	t, err := r.Reload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := Mine(postings, t, minCount)
+	if err := r.store.SaveStats(ctx, stats); err != nil {
+		return nil, fmt.Errorf("save stats: %w", err)
+	}
+	return stats, nil
+}
+
+// Related 与给定技能共现最多的技能，按条件概率P(相关技能|给定技能)排序
+func (r *Recommender) Related(ctx context.Context, name string, limit int) ([]Recommendation, error) {
+	t, err := r.Taxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	canonical := t.Canonical(name)
+	edges, err := r.store.Neighbors(ctx, []string{canonical}, limit)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{canonical}
+	for _, e := range edges {
+		names = append(names, e.To)
+	}
+	demand, err := r.store.Demand(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Recommendation, 0, len(edges))
+	for _, e := range edges {
+		p := conditional(e.Count, demand[canonical])
+		results = append(results, Recommendation{
+			Skill:    e.To,
+			Category: t.Category(e.To),
+			Score:    round(p),
+			Demand:   demand[e.To],
+			Reason:   fmt.Sprintf("要求%s的职位中%.0f%%同时要求%s", canonical, p*100, e.To),
+		})
+	}
+	sortRecommendations(results)
+	return results, nil
+}
+
+// SkillGap 根据用户已掌握的技能和目标职位的技能要求推荐要学习的技能：
+// 目标职位要求而用户未掌握的技能占70%，与用户技能在图谱中的共现关系占30%；没有目标职位时只按共现关系
+func (r *Recommender) SkillGap(ctx context.Context, userSkills []string, targets [][]string, limit int) ([]Recommendation, error) {
+	t, err := r.Taxonomy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	have := t.CanonicalSet(userSkills)
+
+	// 目标职位覆盖：要求该技能的目标职位数
+	coverage := make(map[string]int)
+	validTargets := 0
+	for _, target := range targets {
+		set := t.CanonicalSet(target)
+		if len(set) == 0 {
+			continue
+		}
+		validTargets++
+		for name := range set {
+			if !have[name] {
+				coverage[name]++
+			}
+		}
+	}
+
+	// 图谱关联：用户技能的共现技能按条件概率累加
+	sources := make([]string, 0, len(have))
+	for name := range have {
+		sources = append(sources, name)
+	}
+	sort.Strings(sources)
+	affinity := make(map[string]float64)
+	related := make(map[string][]string)
+	if len(sources) > 0 {
+		edges, err := r.store.Neighbors(ctx, sources, neighborLimit)
+		if err != nil {
+			return nil, err
+		}
+		demand, err := r.store.Demand(ctx, sources)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			if have[e.To] {
+				continue
+			}
+			affinity[e.To] += conditional(e.Count, demand[e.From])
+			related[e.To] = append(related[e.To], e.From)
+		}
+	}
+	var maxAffinity float64
+	for _, a := range affinity {
+		maxAffinity = math.Max(maxAffinity, a)
+	}
+
+	candidates := make([]string, 0, len(coverage)+len(affinity))
+	for name := range coverage {
+		candidates = append(candidates, name)
+	}
+	for name := range affinity {
+		if _, ok := coverage[name]; !ok {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return []Recommendation{}, nil
+	}
+	demand, err := r.store.Demand(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Recommendation, 0, len(candidates))
+	for _, name := range candidates {
+		var graphScore float64
+		if maxAffinity > 0 {
+			graphScore = affinity[name] / maxAffinity
+		}
+		score := graphScore
+		var reasons []string
+		if validTargets > 0 {
+			score = 0.7*float64(coverage[name])/float64(validTargets) + 0.3*graphScore
+			if coverage[name] > 0 {
+				reasons = append(reasons, fmt.Sprintf("%d个目标职位中有%d个要求该技能", validTargets, coverage[name]))
+			}
+		}
+		sort.Strings(related[name])
+		if len(related[name]) > 0 {
+			reasons = append(reasons, fmt.Sprintf("常与你掌握的%s一起要求", strings.Join(firstN(related[name], 3), "、")))
+		}
+		if demand[name] > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d个在招职位要求", demand[name]))
+		}
+		results = append(results, Recommendation{
+			Skill:         name,
+			Category:      t.Category(name),
+			Score:         round(score),
+			Demand:        demand[name],
+			Reason:        strings.Join(reasons, "；"),
+			RelatedSkills: related[name],
+		})
+	}
+	sortRecommendations(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// conditional 共现次数占来源技能需求量的比例
+func conditional(count, demand int) float64 {
+	if demand <= 0 {
+		return 0
+	}
+	return math.Min(1, float64(count)/float64(demand))
+}
+
+func sortRecommendations(results []Recommendation) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Demand != results[j].Demand {
+			return results[i].Demand > results[j].Demand
+		}
+		return results[i].Skill < results[j].Skill
+	})
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func firstN(list []string, n int) []string {
+	if len(list) > n {
+		return list[:n]
+	}
+	return list
+}
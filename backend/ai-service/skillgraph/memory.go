@@ -0,0 +1,101 @@
+package skillgraph
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryGraph 内存中的技能图谱，用于测试和没有Neo4j的环境
+type MemoryGraph struct {
+	mu        sync.RWMutex
+	skills    map[string]Skill
+	demand    map[string]int
+	neighbors map[string][]Edge // 技能 -> 以其为From、按共现次数降序的边
+}
+
+// NewMemoryGraph 创建空图谱
+func NewMemoryGraph() *MemoryGraph {
+	return &MemoryGraph{
+		skills:    make(map[string]Skill),
+		demand:    make(map[string]int),
+		neighbors: make(map[string][]Edge),
+	}
+}
+
+// SaveTaxonomy 写入标准技能
+func (g *MemoryGraph) SaveTaxonomy(_ context.Context, skills []Skill) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, s := range skills {
+		s.Aliases = append([]string(nil), s.Aliases...)
+		g.skills[s.Name] = s
+	}
+	return nil
+}
+
+// Taxonomy 读取全部标准技能
+func (g *MemoryGraph) Taxonomy(_ context.Context) ([]Skill, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	skills := make([]Skill, 0, len(g.skills))
+	for _, s := range g.skills {
+		skills = append(skills, s)
+	}
+	sort.Slice(skills, func(i, j int) bool { return skills[i].Name < skills[j].Name })
+	return skills, nil
+}
+
+// SaveStats 替换需求量和共现边
+func (g *MemoryGraph) SaveStats(_ context.Context, stats *Stats) error {
+	demand := make(map[string]int, len(stats.Demand))
+	for name, count := range stats.Demand {
+		demand[name] = count
+	}
+	neighbors := make(map[string][]Edge)
+	for _, e := range stats.Edges {
+		neighbors[e.From] = append(neighbors[e.From], e)
+		neighbors[e.To] = append(neighbors[e.To], Edge{From: e.To, To: e.From, Count: e.Count})
+	}
+	for _, edges := range neighbors {
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].Count != edges[j].Count {
+				return edges[i].Count > edges[j].Count
+			}
+			return edges[i].To < edges[j].To
+		})
+	}
+
+	g.mu.Lock()
+	g.demand, g.neighbors = demand, neighbors
+	g.mu.Unlock()
+	return nil
+}
+
+// Neighbors 每个技能共现次数最多的limit条边
+func (g *MemoryGraph) Neighbors(_ context.Context, names []string, limit int) ([]Edge, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var edges []Edge
+	for _, name := range names {
+		list := g.neighbors[name]
+		if limit > 0 && len(list) > limit {
+			list = list[:limit]
+		}
+		edges = append(edges, list...)
+	}
+	return edges, nil
+}
+
+// Demand 技能出现的职位数
+func (g *MemoryGraph) Demand(_ context.Context, names []string) (map[string]int, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	demand := make(map[string]int, len(names))
+	for _, name := range names {
+		if count, ok := g.demand[name]; ok {
+			demand[name] = count
+		}
+	}
+	return demand, nil
+}
@@ -0,0 +1,192 @@
+package skillgraph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jGraph Neo4j中的技能图谱：
+// (:Skill {name, category, aliases, demand})-[:IN_CATEGORY]->(:SkillCategory {name})，
+// 共现关系为(:Skill)-[:CO_OCCURS {count}]->(:Skill)，方向从名称较小的技能指向较大的
+type Neo4jGraph struct {
+	driver neo4j.Driver
+}
+
+// NewNeo4jGraph 创建Neo4j图谱存储
+func NewNeo4jGraph(driver neo4j.Driver) *Neo4jGraph {
+	return &Neo4jGraph{driver: driver}
+}
+
+// SaveTaxonomy 写入标准技能，技能名唯一约束在首次写入时创建
+func (g *Neo4jGraph) SaveTaxonomy(_ context.Context, skills []Skill) error {
+	session := g.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	if _, err := session.Run("CREATE CONSTRAINT skill_name IF NOT EXISTS FOR (k:Skill) REQUIRE k.name IS UNIQUE", nil); err != nil {
+		return err
+	}
+
+	rows := make([]map[string]any, len(skills))
+	for i, s := range skills {
+		aliases := s.Aliases
+		if aliases == nil {
+			aliases = []string{}
+		}
+		rows[i] = map[string]any{"name": s.Name, "category": s.Category, "aliases": aliases}
+	}
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (any, error) {
+		result, err := tx.Run(`
+			UNWIND $skills AS s
+			MERGE (k:Skill {name: s.name})
+			SET k.category = s.category, k.aliases = s.aliases
+			WITH k, s
+			OPTIONAL MATCH (k)-[old:IN_CATEGORY]->()
+			DELETE old
+			WITH DISTINCT k, s
+			MERGE (c:SkillCategory {name: s.category})
+			MERGE (k)-[:IN_CATEGORY]->(c)`, map[string]any{"skills": rows})
+		if err != nil {
+			return nil, err
+		}
+		return result.Consume()
+	})
+	return err
+}
+
+// Taxonomy 读取全部带分类的标准技能
+func (g *Neo4jGraph) Taxonomy(_ context.Context) ([]Skill, error) {
+	records, err := g.read(`
+		MATCH (k:Skill) WHERE k.category IS NOT NULL
+		RETURN k.name AS name, k.category AS category, coalesce(k.aliases, []) AS aliases
+		ORDER BY name`, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	skills := make([]Skill, 0, len(records))
+	for _, record := range records {
+		s := Skill{Name: stringValue(record, "name"), Category: stringValue(record, "category")}
+		if aliases, ok := record.Get("aliases"); ok {
+			if list, ok := aliases.([]any); ok {
+				for _, a := range list {
+					if alias, ok := a.(string); ok {
+						s.Aliases = append(s.Aliases, alias)
+					}
+				}
+			}
+		}
+		skills = append(skills, s)
+	}
+	return skills, nil
+}
+
+// SaveStats 在一个事务中清除旧的需求量和共现边并写入新的
+func (g *Neo4jGraph) SaveStats(_ context.Context, stats *Stats) error {
+	demand := make([]map[string]any, 0, len(stats.Demand))
+	for name, count := range stats.Demand {
+		demand = append(demand, map[string]any{"name": name, "count": count})
+	}
+	edges := make([]map[string]any, len(stats.Edges))
+	for i, e := range stats.Edges {
+		edges[i] = map[string]any{"from": e.From, "to": e.To, "count": e.Count}
+	}
+
+	session := g.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (any, error) {
+		statements := []struct {
+			cypher string
+			params map[string]any
+		}{
+			{"MATCH ()-[r:CO_OCCURS]->() DELETE r", nil},
+			{"MATCH (k:Skill) WHERE k.demand IS NOT NULL REMOVE k.demand", nil},
+			{"UNWIND $demand AS d MERGE (k:Skill {name: d.name}) SET k.demand = d.count", map[string]any{"demand": demand}},
+			{`UNWIND $edges AS e
+				MATCH (a:Skill {name: e.from}), (b:Skill {name: e.to})
+				MERGE (a)-[r:CO_OCCURS]->(b) SET r.count = e.count`, map[string]any{"edges": edges}},
+		}
+		for _, st := range statements {
+			result, err := tx.Run(st.cypher, st.params)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := result.Consume(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Neighbors 每个技能共现次数最多的limit条边
+func (g *Neo4jGraph) Neighbors(_ context.Context, names []string, limit int) ([]Edge, error) {
+	records, err := g.read(`
+		UNWIND $names AS name
+		MATCH (a:Skill {name: name})-[r:CO_OCCURS]-(b:Skill)
+		WITH a, b, r ORDER BY r.count DESC, b.name
+		WITH a, collect({to: b.name, count: r.count})[..$limit] AS top
+		UNWIND top AS t
+		RETURN a.name AS from, t.to AS to, t.count AS count`,
+		map[string]any{"names": names, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]Edge, 0, len(records))
+	for _, record := range records {
+		edges = append(edges, Edge{
+			From:  stringValue(record, "from"),
+			To:    stringValue(record, "to"),
+			Count: intValue(record, "count"),
+		})
+	}
+	return edges, nil
+}
+
+// Demand 技能出现的职位数
+func (g *Neo4jGraph) Demand(_ context.Context, names []string) (map[string]int, error) {
+	records, err := g.read(`
+		MATCH (k:Skill) WHERE k.name IN $names AND k.demand IS NOT NULL
+		RETURN k.name AS name, k.demand AS demand`, map[string]any{"names": names})
+	if err != nil {
+		return nil, err
+	}
+
+	demand := make(map[string]int, len(records))
+	for _, record := range records {
+		demand[stringValue(record, "name")] = intValue(record, "demand")
+	}
+	return demand, nil
+}
+
+// read 在读事务中执行查询并取回全部记录
+func (g *Neo4jGraph) read(cypher string, params map[string]any) ([]*neo4j.Record, error) {
+	session := g.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+	records, err := session.ReadTransaction(func(tx neo4j.Transaction) (any, error) {
+		result, err := tx.Run(cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		return result.Collect()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("neo4j query: %w", err)
+	}
+	return records.([]*neo4j.Record), nil
+}
+
+func stringValue(record *neo4j.Record, key string) string {
+	v, _ := record.Get(key)
+	s, _ := v.(string)
+	return s
+}
+
+func intValue(record *neo4j.Record, key string) int {
+	v, _ := record.Get(key)
+	n, _ := v.(int64)
+	return int(n)
+}
@@ -0,0 +1,133 @@
+package skillgraph
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var postings = [][]string{
+	{"golang", "MySQL", "Redis", "Docker"},
+	{"Go", "mysql", "k8s", "Docker"},
+	{"Go", "Redis", "Kubernetes", "gRPC"},
+	{"Java", "Spring Boot", "MySQL", "Redis"},
+	{"java", "springboot", "MySQL"},
+	{"React", "TypeScript", "CSS"},
+	{},
+}
+
+func TestTaxonomy(t *testing.T) {
+	tax := NewTaxonomy(DefaultTaxonomy)
+	cases := map[string]string{
+		"golang":       "Go",
+		"  GoLang ":    "Go",
+		"k8s":          "Kubernetes",
+		"Spring-Boot":  "Spring Boot",
+		"vue3":         "Vue.js",
+		"Unknown Tool": "unknown tool",
+	}
+	for in, want := range cases {
+		if got := tax.Canonical(in); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := tax.Category("k8s"); got != CategoryDevOps {
+		t.Errorf("Category(k8s) = %q, want %q", got, CategoryDevOps)
+	}
+	if _, ok := tax.Resolve("cobol"); ok {
+		t.Error("unknown skill should not resolve")
+	}
+}
+
+func TestMine(t *testing.T) {
+	stats := Mine(postings, NewTaxonomy(DefaultTaxonomy), 2)
+	if stats.Postings != 6 {
+		t.Errorf("postings = %d, want 6 (empty posting skipped)", stats.Postings)
+	}
+	if stats.Demand["Go"] != 3 || stats.Demand["MySQL"] != 4 || stats.Demand["Spring Boot"] != 2 {
+		t.Errorf("unexpected demand %v", stats.Demand)
+	}
+
+	want := []Edge{
+		{From: "Docker", To: "Go", Count: 2},
+		{From: "Docker", To: "MySQL", Count: 2},
+		{From: "Go", To: "Kubernetes", Count: 2},
+		{From: "Go", To: "MySQL", Count: 2},
+		{From: "Go", To: "Redis", Count: 2},
+		{From: "Java", To: "MySQL", Count: 2},
+		{From: "Java", To: "Spring Boot", Count: 2},
+		{From: "MySQL", To: "Redis", Count: 2},
+		{From: "MySQL", To: "Spring Boot", Count: 2},
+	}
+	if !reflect.DeepEqual(stats.Edges, want) {
+		t.Errorf("edges = %v\nwant %v", stats.Edges, want)
+	}
+}
+
+func newTestRecommender(t *testing.T) *Recommender {
+	t.Helper()
+	r := NewRecommender(NewMemoryGraph())
+	if _, err := r.Rebuild(context.Background(), DefaultTaxonomy, postings, 1); err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	return r
+}
+
+func TestSkillGap(t *testing.T) {
+	r := newTestRecommender(t)
+	ctx := context.Background()
+
+	targets := [][]string{
+		{"Go", "Kubernetes", "Docker"},
+		{"golang", "k8s", "gRPC"},
+	}
+	recs, err := r.SkillGap(ctx, []string{"golang", "Docker"}, targets, 3)
+	if err != nil {
+		t.Fatalf("SkillGap: %v", err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("got %d recommendations, want 3: %+v", len(recs), recs)
+	}
+	// Kubernetes被两个目标职位要求且与Go共现，排第一
+	if recs[0].Skill != "Kubernetes" || recs[0].Category != CategoryDevOps {
+		t.Errorf("first recommendation = %+v, want Kubernetes", recs[0])
+	}
+	if !strings.Contains(recs[0].Reason, "2个目标职位中有2个要求该技能") || !strings.Contains(recs[0].Reason, "常与你掌握的Docker、Go一起要求") {
+		t.Errorf("unexpected reason %q", recs[0].Reason)
+	}
+	for _, rec := range recs {
+		if rec.Skill == "Go" || rec.Skill == "Docker" {
+			t.Errorf("recommended a skill the user already has: %+v", rec)
+		}
+	}
+
+	// 没有目标职位时按共现关系推荐
+	recs, err = r.SkillGap(ctx, []string{"Java"}, nil, 0)
+	if err != nil {
+		t.Fatalf("SkillGap without targets: %v", err)
+	}
+	names := make([]string, len(recs))
+	for i, rec := range recs {
+		names[i] = rec.Skill
+	}
+	if !reflect.DeepEqual(names, []string{"MySQL", "Spring Boot", "Redis"}) {
+		t.Errorf("graph-only recommendations = %v, want [MySQL Spring Boot Redis]", names)
+	}
+}
+
+func TestRelated(t *testing.T) {
+	r := newTestRecommender(t)
+	recs, err := r.Related(context.Background(), "springboot", 5)
+	if err != nil {
+		t.Fatalf("Related: %v", err)
+	}
+	if len(recs) != 3 || recs[0].Score != 1 {
+		t.Fatalf("unexpected related skills %+v", recs)
+	}
+	for _, rec := range recs {
+		if rec.Skill != "Java" && rec.Skill != "MySQL" && rec.Skill != "Redis" {
+			t.Errorf("unexpected related skill %q", rec.Skill)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package skillgraph 技能图谱：标准技能、别名和分类构成的技能体系，以及从职位中挖掘的技能共现关系，
+// 用于技能缺口推荐。图谱存储在Neo4j中，MemoryGraph供测试和无Neo4j时使用
+package skillgraph
+
+import (
+	"sort"
+	"strings"
+
+	"resume-centre/common/skills"
+)
+
+// Skill 标准技能
+type Skill struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+// 技能分类，与通用技能词典一致
+const (
+	CategoryLanguage  = skills.CategoryLanguage
+	CategoryFramework = skills.CategoryFramework
+	CategoryFrontend  = skills.CategoryFrontend
+	CategoryDatabase  = skills.CategoryDatabase
+	CategoryDevOps    = skills.CategoryDevOps
+	CategoryCloud     = skills.CategoryCloud
+	CategoryData      = skills.CategoryData
+	CategoryAI        = skills.CategoryAI
+	CategoryTesting   = skills.CategoryTesting
+	CategoryDesign    = skills.CategoryDesign
+	CategoryTool      = skills.CategoryTool
+	CategorySoft      = skills.CategorySoft
+)
+
+// DefaultTaxonomy 内置技能体系，由通用技能词典生成（技能ID作为别名），重建图谱时写入
+var DefaultTaxonomy = fromDictionary(skills.Builtin)
+
+func fromDictionary(entries []skills.Skill) []Skill {
+	taxonomy := make([]Skill, len(entries))
+	for i, e := range entries {
+		aliases := append([]string{e.ID}, e.Aliases...)
+		taxonomy[i] = Skill{Name: e.Name, Category: e.Category, Aliases: aliases}
+	}
+	return taxonomy
+}
+
+// Taxonomy 技能体系索引，把别名解析为标准技能
+type Taxonomy struct {
+	skills  map[string]Skill  // 标准名 -> 技能
+	aliases map[string]string // 名称或别名的skills.Key -> 标准名
+}
+
+// NewTaxonomy 建立索引，别名冲突时先出现的生效
+func NewTaxonomy(entries []Skill) *Taxonomy {
+	t := &Taxonomy{skills: make(map[string]Skill, len(entries)), aliases: make(map[string]string)}
+	for _, s := range entries {
+		t.skills[s.Name] = s
+		for _, name := range append([]string{s.Name}, s.Aliases...) {
+			key := skills.Key(name)
+			if _, exists := t.aliases[key]; !exists && key != "" {
+				t.aliases[key] = s.Name
+			}
+		}
+	}
+	return t
+}
+
+// Resolve 返回名称对应的标准技能，不在技能体系中时ok为false。
+// 别名查不到时借助通用技能词典识别拼音和拼写错误
+func (t *Taxonomy) Resolve(name string) (Skill, bool) {
+	if canonical, ok := t.aliases[skills.Key(name)]; ok {
+		return t.skills[canonical], true
+	}
+	m, ok := skills.Default().Match(name)
+	if !ok {
+		return Skill{}, false
+	}
+	for _, alias := range append([]string{m.Skill.ID, m.Skill.Name}, m.Skill.Aliases...) {
+		if canonical, ok := t.aliases[skills.Key(alias)]; ok {
+			return t.skills[canonical], true
+		}
+	}
+	return Skill{}, false
+}
+
+// Canonical 返回标准技能名，不在技能体系中时返回归一化后的名称
+func (t *Taxonomy) Canonical(name string) string {
+	if s, ok := t.Resolve(name); ok {
+		return s.Name
+	}
+	return normalize(name)
+}
+
+// Category 技能分类，未知技能返回空
+func (t *Taxonomy) Category(name string) string {
+	return t.skills[t.Canonical(name)].Category
+}
+
+// CanonicalSet 去重后的标准技能集合，忽略空名称
+func (t *Taxonomy) CanonicalSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		if c := t.Canonical(name); c != "" {
+			set[c] = true
+		}
+	}
+	return set
+}
+
+// Skills 按名称排序的全部标准技能
+func (t *Taxonomy) Skills() []Skill {
+	list := make([]Skill, 0, len(t.skills))
+	for _, s := range t.skills {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// normalize 统一大小写和空白
+func normalize(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
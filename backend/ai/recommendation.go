@@ -5,6 +5,7 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strings"
 )
 
 // JobRecommendation 职位推荐
@@ -39,10 +40,25 @@ type SkillRecommendation struct {
 	Reason      string  `json:"reason"`
 }
 
+// CandidateJob 参与推荐打分的职位
+type CandidateJob struct {
+	ID             int64
+	Title          string
+	Company        string
+	Location       string
+	SalaryMin      int
+	SalaryMax      int
+	RequiredSkills []string
+}
+
+// JobStore 职位数据来源
+type JobStore interface {
+	ActiveJobs(limit int) ([]CandidateJob, error)
+}
+
 // RecommendationService 推荐服务
 type RecommendationService struct {
-	// 这里可以注入数据库连接
-	// MySQL, PostgreSQL, Neo4j, Redis等
+	jobs JobStore
 }
 
 // NewRecommendationService 创建推荐服务
@@ -50,6 +66,11 @@ func NewRecommendationService() *RecommendationService {
 	return &RecommendationService{}
 }
 
+// NewRecommendationServiceWithStore 创建使用指定职位数据来源的推荐服务
+func NewRecommendationServiceWithStore(jobs JobStore) *RecommendationService {
+	return &RecommendationService{jobs: jobs}
+}
+
 // GetJobRecommendations 获取职位推荐
 func (rs *RecommendationService) GetJobRecommendations(userID int64, limit int) ([]JobRecommendation, error) {
 	log.Printf("Getting job recommendations for user %d", userID)
@@ -229,7 +250,7 @@ func (rs *RecommendationService) CalculateSkillMatch(requiredSkills, userSkills
 	matchedSkills := 0
 	for _, requiredSkill := range requiredSkills {
 		for _, userSkill := range userSkills {
-			if requiredSkill == userSkill {
+			if strings.EqualFold(strings.TrimSpace(requiredSkill), strings.TrimSpace(userSkill)) {
 				matchedSkills++
 				break
 			}
@@ -246,43 +267,12 @@ func (rs *RecommendationService) CalculateSkillMatch(requiredSkills, userSkills
 func (rs *RecommendationService) GetPersonalizedRecommendations(userID int64, userSkills []string, limit int) ([]JobRecommendation, error) {
 	log.Printf("Getting personalized recommendations for user %d", userID)
 	
-	// 模拟职位数据
-	jobs := []struct {
-		ID            int64
-		Title         string
-		Company       string
-		Location      string
-		SalaryMin     int
-		SalaryMax     int
-		RequiredSkills []string
-	}{
-		{
-			ID:            1,
-			Title:         "Java开发工程师",
-			Company:       "腾讯科技",
-			Location:      "深圳",
-			SalaryMin:     15000,
-			SalaryMax:     25000,
-			RequiredSkills: []string{"Java", "Spring Boot", "MySQL"},
-		},
-		{
-			ID:            2,
-			Title:         "前端开发工程师",
-			Company:       "阿里巴巴",
-			Location:      "杭州",
-			SalaryMin:     12000,
-			SalaryMax:     20000,
-			RequiredSkills: []string{"React", "Vue.js", "JavaScript"},
-		},
-		{
-			ID:            3,
-			Title:         "Python算法工程师",
-			Company:       "字节跳动",
-			Location:      "北京",
-			SalaryMin:     20000,
-			SalaryMax:     35000,
-			RequiredSkills: []string{"Python", "Machine Learning", "TensorFlow"},
-		},
+	if rs.jobs == nil {
+		return nil, fmt.Errorf("job store not configured")
+	}
+	jobs, err := rs.jobs.ActiveJobs(500)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jobs: %w", err)
 	}
 	
 	var recommendations []JobRecommendation
@@ -290,20 +280,12 @@ func (rs *RecommendationService) GetPersonalizedRecommendations(userID int64, us
 	for _, job := range jobs {
 		skillMatch, matchRate := rs.CalculateSkillMatch(job.RequiredSkills, userSkills)
 		
-		// 计算综合评分
-		score := matchRate * 0.7 // 技能匹配权重70%
-		
-		// 添加位置偏好（这里简化处理）
-		if len(userSkills) > 0 {
-			score += 0.1 // 基础分数
+		if skillMatch == 0 {
+			continue
 		}
 		
-		// 添加薪资偏好（这里简化处理）
-		score += 0.1 // 基础分数
-		
-		// 限制分数范围
-		score = math.Min(score, 1.0)
-		score = math.Max(score, 0.0)
+		// 按技能匹配率打分
+		score := math.Min(math.Max(matchRate, 0.0), 1.0)
 		
 		recommendations = append(recommendations, JobRecommendation{
 			JobID:      job.ID,
@@ -314,7 +296,7 @@ func (rs *RecommendationService) GetPersonalizedRecommendations(userID int64, us
 			SalaryMax:  job.SalaryMax,
 			Score:      score,
 			SkillMatch: skillMatch,
-			Reason:     fmt.Sprintf("技能匹配度%.1f%%", matchRate*100),
+			Reason:     fmt.Sprintf("匹配%d/%d项要求技能（%.1f%%）", skillMatch, len(job.RequiredSkills), matchRate*100),
 		})
 	}
 	
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"resume-centre/blockchain/certverify"
+	"resume-centre/blockchain/merkle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+var ErrCertificateNotBatched = errors.New("certificate has not been anchored yet")
+
+// 证书校验结果
+type CertificateVerification struct {
+	CertificateID  string             `json:"certificate_id"`
+	Valid          bool               `json:"valid"`
+	ContentValid   bool               `json:"content_valid"`
+	InclusionValid bool               `json:"inclusion_valid"`
+	Anchored       bool               `json:"anchored"`
+	BlockNumber    uint64             `json:"block_number"`
+	IssuedAt       time.Time          `json:"issued_at"`             // 签发方声明的签发时间
+	AnchoredAt     *time.Time         `json:"anchored_at,omitempty"` // 锚定区块时间，证书最晚于此时已存在
+	Status         TransactionStatus  `json:"status"`
+	Error          string             `json:"error,omitempty"`
+	Bundle         *certverify.Bundle `json:"bundle,omitempty"`
+}
+
+// 启动证书批量锚定任务
+func startCertificateBatcher(ctx context.Context) {
+	interval := viper.GetDuration("blockchain.certificates.batch_interval")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !acquireJobLock("certificate_batch", interval/2) {
+					continue
+				}
+				if anchored, err := anchorPendingCertificates(ctx); err != nil {
+					logger.Errorf("Certificate batch job failed: %v", err)
+				} else if anchored > 0 {
+					logger.Infof("Anchored %d certificates", anchored)
+				}
+			}
+		}
+	}()
+}
+
+// 将未锚定的证书按链类型分批构建Merkle树并锚定树根
+func anchorPendingCertificates(ctx context.Context) (int, error) {
+	maxBatch := viper.GetInt("blockchain.certificates.max_batch_size")
+	if maxBatch <= 0 {
+		maxBatch = 1000
+	}
+
+	// 先重新提交已建批次但锚定交易未提交或已失败的批次
+	anchored, err := resubmitCertificateBatches(ctx)
+	if err != nil {
+		return anchored, err
+	}
+
+	var chainTypes []BlockchainType
+	if err := pendingCertificates().Distinct("blockchain_type").Pluck("blockchain_type", &chainTypes).Error; err != nil {
+		return anchored, err
+	}
+
+	for _, chainType := range chainTypes {
+		var certificates []BlockchainCertificate
+		if err := pendingCertificates().Where("blockchain_type = ?", chainType).
+			Order("created_at ASC, id ASC").Limit(maxBatch).Find(&certificates).Error; err != nil {
+			return anchored, err
+		}
+		if len(certificates) == 0 {
+			continue
+		}
+
+		batch, err := createCertificateBatch(chainType, certificates)
+		if err != nil {
+			return anchored, fmt.Errorf("failed to create %s certificate batch: %v", chainType, err)
+		}
+		if err := submitCertificateBatch(ctx, batch); err != nil {
+			return anchored, fmt.Errorf("failed to anchor %s certificate batch %s: %v", chainType, batch.ID, err)
+		}
+		anchored += int(batch.LeafCount)
+	}
+	return anchored, nil
+}
+
+func pendingCertificates() *gorm.DB {
+	return db.Model(&BlockchainCertificate{}).
+		Where("COALESCE(batch_id, '') = '' AND COALESCE(transaction_hash, '') = '' AND status = ?", TransactionStatusPending)
+}
+
+// 重新提交没有锚定交易的批次
+func resubmitCertificateBatches(ctx context.Context) (int, error) {
+	var batches []CertificateBatch
+	if err := db.Where("COALESCE(transaction_hash, '') = '' AND status = ?", TransactionStatusPending).
+		Order("created_at ASC").Find(&batches).Error; err != nil {
+		return 0, err
+	}
+
+	anchored := 0
+	for i := range batches {
+		if err := submitCertificateBatch(ctx, &batches[i]); err != nil {
+			return anchored, fmt.Errorf("failed to anchor certificate batch %s: %v", batches[i].ID, err)
+		}
+		anchored += int(batches[i].LeafCount)
+	}
+	return anchored, nil
+}
+
+// 为一批证书建立批次，每张证书记录叶子位置和包含证明。批次先于锚定交易落库，
+// 提交失败或交易失败时以同一批次重新锚定，Merkle根保持不变
+func createCertificateBatch(chainType BlockchainType, certificates []BlockchainCertificate) (*CertificateBatch, error) {
+	leaves := make([]merkle.Hash, len(certificates))
+	for i := range certificates {
+		if certificates[i].IssuedAt.IsZero() {
+			certificates[i].IssuedAt = certificates[i].CreatedAt
+		}
+		leaves[i] = certverify.LeafHash(certificateSummary(&certificates[i]))
+	}
+	root, err := merkle.Root(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &CertificateBatch{
+		ID:             uuid.New().String(),
+		BlockchainType: chainType,
+		MerkleRoot:     root.Hex(),
+		LeafCount:      uint64(len(leaves)),
+		Status:         TransactionStatusPending,
+	}
+
+	err = db.Transaction(func(dbtx *gorm.DB) error {
+		// 同一组证书的根相同，复用此前锚定失败的批次
+		var existing CertificateBatch
+		err := dbtx.Where("merkle_root = ?", batch.MerkleRoot).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := dbtx.Create(batch).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case existing.Status != TransactionStatusFailed:
+			return fmt.Errorf("merkle root %s already belongs to batch %s", batch.MerkleRoot, existing.ID)
+		default:
+			batch.ID = existing.ID
+			if err := dbtx.Model(&existing).Updates(map[string]interface{}{
+				"blockchain_type":  chainType,
+				"leaf_count":       batch.LeafCount,
+				"transaction_hash": "",
+				"issuer_address":   "",
+				"block_number":     0,
+				"status":           TransactionStatusPending,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range certificates {
+			proof, err := merkle.Proof(leaves, i)
+			if err != nil {
+				return err
+			}
+			proofJSON, err := json.Marshal(hexHashes(proof))
+			if err != nil {
+				return err
+			}
+
+			// 以内容哈希和标题为条件，避免覆盖批次构建期间被修改的证书
+			cert := certificates[i]
+			if err := dbtx.Model(&BlockchainCertificate{}).
+				Where("id = ? AND hash = ? AND title = ?", cert.ID, cert.Hash, cert.Title).
+				Updates(map[string]interface{}{
+					"issued_at":       cert.IssuedAt,
+					"batch_id":        batch.ID,
+					"leaf_index":      i,
+					"leaf_hash":       leaves[i].Hex(),
+					"inclusion_proof": string(proofJSON),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// 提交批次的锚定交易，并将交易关联到批次和其中的证书
+func submitCertificateBatch(ctx context.Context, batch *CertificateBatch) error {
+	tx, err := anchorRecord(ctx, batch.BlockchainType, 0, "certificate_batch", batch.ID, batch.MerkleRoot)
+	if err != nil {
+		return err
+	}
+	batch.TransactionHash = tx.TransactionHash
+	batch.IssuerAddress = tx.FromAddress
+
+	return db.Transaction(func(dbtx *gorm.DB) error {
+		if err := dbtx.Model(batch).Updates(map[string]interface{}{
+			"transaction_hash": batch.TransactionHash,
+			"issuer_address":   batch.IssuerAddress,
+		}).Error; err != nil {
+			return err
+		}
+		return dbtx.Model(&BlockchainCertificate{}).Where("batch_id = ?", batch.ID).
+			Updates(map[string]interface{}{
+				"transaction_hash": batch.TransactionHash,
+				"gas_price":        tx.GasPrice,
+			}).Error
+	})
+}
+
+// 证书叶子内容
+func certificateSummary(cert *BlockchainCertificate) certverify.Certificate {
+	return certverify.Certificate{
+		ID:          cert.ID,
+		Type:        string(cert.Type),
+		Title:       cert.Title,
+		ContentHash: cert.Hash,
+		IssuedAt:    cert.IssuedAt.Unix(),
+	}
+}
+
+// 生成证书的证明包
+func buildCertificateBundle(cert *BlockchainCertificate) (*certverify.Bundle, error) {
+	if cert.BatchID == "" {
+		return nil, ErrCertificateNotBatched
+	}
+
+	var batch CertificateBatch
+	if err := db.Where("id = ?", cert.BatchID).First(&batch).Error; err != nil {
+		return nil, fmt.Errorf("failed to load certificate batch %s: %v", cert.BatchID, err)
+	}
+
+	var proof []string
+	if err := json.Unmarshal([]byte(cert.InclusionProof), &proof); err != nil {
+		return nil, fmt.Errorf("invalid inclusion proof for certificate %s: %v", cert.ID, err)
+	}
+
+	return &certverify.Bundle{
+		Version:     certverify.Version,
+		Certificate: certificateSummary(cert),
+		LeafHash:    cert.LeafHash,
+		LeafIndex:   cert.LeafIndex,
+		TreeSize:    batch.LeafCount,
+		Proof:       proof,
+		MerkleRoot:  batch.MerkleRoot,
+		Anchor: certverify.Anchor{
+			Chain:           string(batch.BlockchainType),
+			TransactionHash: batch.TransactionHash,
+			BlockNumber:     batch.BlockNumber,
+			Issuer:          batch.IssuerAddress,
+		},
+	}, nil
+}
+
+// 通过链适配器查询锚定的Merkle根
+type chainRootResolver struct{}
+
+func (chainRootResolver) AnchoredRoot(ctx context.Context, chain, txHash string) (*certverify.AnchorRecord, error) {
+	return queryAnchorRecord(ctx, BlockchainType(chain), txHash)
+}
+
+// 本服务信任的锚定地址：优先取该交易对应批次记录的发送方，否则为当前链适配器地址。
+// 证明包中的issuer字段由提交方填写，不作为依据
+func trustedAnchorIssuer(anchor certverify.Anchor) (string, error) {
+	var batch CertificateBatch
+	err := db.Where("transaction_hash = ?", anchor.TransactionHash).First(&batch).Error
+	if err == nil && batch.IssuerAddress != "" {
+		return batch.IssuerAddress, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	adapter, err := getChainAdapter(BlockchainType(anchor.Chain))
+	if err != nil {
+		return "", err
+	}
+	return adapter.Address(), nil
+}
+
+// 校验证明包：包含证明、可选的原文、链上锚定
+func verifyBundle(ctx context.Context, bundle *certverify.Bundle, content []byte) *CertificateVerification {
+	result := &CertificateVerification{
+		CertificateID: bundle.Certificate.ID,
+		IssuedAt:      time.Unix(bundle.Certificate.IssuedAt, 0),
+		ContentValid:  true,
+		Bundle:        bundle,
+	}
+
+	if content != nil {
+		if err := bundle.VerifyContent(content); err != nil {
+			result.ContentValid = false
+			result.Error = err.Error()
+		}
+	}
+
+	if err := bundle.Verify(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.InclusionValid = true
+
+	issuer, err := trustedAnchorIssuer(bundle.Anchor)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	anchor, err := bundle.VerifyAnchor(ctx, chainRootResolver{}, issuer)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	// 交易尚未打包时无区块号，不视为已锚定
+	result.BlockNumber = anchor.BlockNumber
+	result.Anchored = anchor.BlockNumber > 0
+	if result.Anchored {
+		anchoredAt := time.Unix(anchor.BlockTime, 0)
+		result.AnchoredAt = &anchoredAt
+	} else {
+		result.Error = ErrTxPending.Error()
+	}
+
+	result.Valid = result.ContentValid && result.InclusionValid && result.Anchored
+	return result
+}
+
+// 校验已签发的证书
+func verifyStoredCertificate(ctx context.Context, cert *BlockchainCertificate) *CertificateVerification {
+	bundle, err := buildCertificateBundle(cert)
+	if err != nil {
+		return &CertificateVerification{
+			CertificateID: cert.ID,
+			IssuedAt:      cert.IssuedAt,
+			Status:        cert.Status,
+			Error:         err.Error(),
+		}
+	}
+
+	result := verifyBundle(ctx, bundle, []byte(cert.Content))
+	result.Status = cert.Status
+	return result
+}
+
+// 获取证书的证明包（公开接口）
+func getCertificateProof(c *gin.Context) {
+	var certificate BlockchainCertificate
+	if err := db.Where("id = ?", c.Param("id")).First(&certificate).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Certificate not found"})
+		return
+	}
+
+	bundle, err := buildCertificateBundle(&certificate)
+	if errors.Is(err, ErrCertificateNotBatched) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": certificate.Status})
+		return
+	}
+	if err != nil {
+		logger.Errorf("Failed to build certificate bundle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build certificate proof"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bundle": bundle})
+}
+
+// 校验第三方提交的证明包（公开接口），可附带证书原文
+func verifyCertificateBundle(c *gin.Context) {
+	var req struct {
+		Bundle  *certverify.Bundle `json:"bundle" binding:"required"`
+		Content *string            `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var content []byte
+	if req.Content != nil {
+		content = []byte(*req.Content)
+	}
+
+	result := verifyBundle(c.Request.Context(), req.Bundle, content)
+	c.JSON(http.StatusOK, gin.H{"verification": result})
+}
+
+func hexHashes(hashes []merkle.Hash) []string {
+	out := make([]string, len(hashes))
+	for i, h := range hashes {
+		out[i] = h.Hex()
+	}
+	return out
+}
+
+// 获取分布式任务锁，避免多实例重复执行定时任务
+func acquireJobLock(name string, ttl time.Duration) bool {
+	if redisClient == nil {
+		return true
+	}
+	key := fmt.Sprintf("blockchain:job_lock:%s", name)
+	ok, err := redisClient.SetNX(context.Background(), key, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		logger.Warnf("Failed to acquire job lock %s: %v", name, err)
+		return false
+	}
+	return ok
+}
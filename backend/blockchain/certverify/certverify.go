@@ -0,0 +1,157 @@
+// Package certverify 离线校验区块链证书：证书摘要经Merkle树批量锚定上链，
+// 持有证明包即可在不访问本服务的情况下验证证书由我方签发且签发后未被修改。
+package certverify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"resume-centre/blockchain/merkle"
+)
+
+// 证明包版本，同时作为叶子编码的前缀
+const Version = "jobfirst-certificate-v1"
+
+var (
+	ErrUnsupportedVersion = errors.New("certverify: unsupported bundle version")
+	ErrContentMismatch    = errors.New("certverify: content does not match certificate hash")
+	ErrLeafMismatch       = errors.New("certverify: leaf hash does not match certificate")
+	ErrInclusionInvalid   = errors.New("certverify: inclusion proof does not match merkle root")
+	ErrAnchorMismatch     = errors.New("certverify: merkle root is not anchored by the transaction")
+	ErrNotAnchored        = errors.New("certverify: bundle has no anchor transaction")
+	ErrIssuerRequired     = errors.New("certverify: trusted issuer address is required")
+	ErrIssuerMismatch     = errors.New("certverify: anchor transaction was not sent by the issuer")
+	ErrIssuedAfterAnchor  = errors.New("certverify: certificate issue time is later than the anchor block")
+)
+
+// 签发时间晚于锚定区块时间的容许偏差（秒），覆盖签发服务器与出块节点的时钟误差
+const MaxClockSkew = 300
+
+// 证书摘要（叶子内容）
+type Certificate struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	ContentHash string `json:"content_hash"`
+	IssuedAt    int64  `json:"issued_at"` // Unix秒
+}
+
+// 锚定交易信息。Issuer仅供展示，校验时必须使用验证方信任的签发地址
+type Anchor struct {
+	Chain           string `json:"chain"`
+	TransactionHash string `json:"transaction_hash"`
+	BlockNumber     uint64 `json:"block_number"`
+	Issuer          string `json:"issuer,omitempty"`
+}
+
+// 证明包
+type Bundle struct {
+	Version     string      `json:"version"`
+	Certificate Certificate `json:"certificate"`
+	LeafHash    string      `json:"leaf_hash"`
+	LeafIndex   uint64      `json:"leaf_index"`
+	TreeSize    uint64      `json:"tree_size"`
+	Proof       []string    `json:"proof"`
+	MerkleRoot  string      `json:"merkle_root"`
+	Anchor      Anchor      `json:"anchor"`
+}
+
+// 证书内容摘要（sha256十六进制）
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// 叶子编码：版本与各字段按行拼接，字段中的换行被转义
+func LeafData(c Certificate) []byte {
+	fields := []string{Version, c.ID, c.Type, c.Title, c.ContentHash, strconv.FormatInt(c.IssuedAt, 10)}
+	for i, f := range fields {
+		fields[i] = strings.ReplaceAll(strings.ReplaceAll(f, `\`, `\\`), "\n", `\n`)
+	}
+	return []byte(strings.Join(fields, "\n"))
+}
+
+// 计算证书叶子哈希
+func LeafHash(c Certificate) merkle.Hash {
+	return merkle.LeafHash(LeafData(c))
+}
+
+// 校验证明包的叶子和包含证明
+func (b *Bundle) Verify() error {
+	if b.Version != Version {
+		return ErrUnsupportedVersion
+	}
+
+	leaf := LeafHash(b.Certificate)
+	if b.LeafHash != "" && !strings.EqualFold(b.LeafHash, leaf.Hex()) {
+		return ErrLeafMismatch
+	}
+
+	root, err := merkle.ParseHash(b.MerkleRoot)
+	if err != nil {
+		return fmt.Errorf("certverify: invalid merkle root: %v", err)
+	}
+	proof := make([]merkle.Hash, len(b.Proof))
+	for i, p := range b.Proof {
+		if proof[i], err = merkle.ParseHash(p); err != nil {
+			return fmt.Errorf("certverify: invalid proof element %d: %v", i, err)
+		}
+	}
+
+	if !merkle.Verify(leaf, b.LeafIndex, b.TreeSize, proof, root) {
+		return ErrInclusionInvalid
+	}
+	return nil
+}
+
+// 校验证书原文与证明包一致
+func (b *Bundle) VerifyContent(content []byte) error {
+	if !strings.EqualFold(ContentHash(content), strings.TrimPrefix(b.Certificate.ContentHash, "0x")) {
+		return ErrContentMismatch
+	}
+	return nil
+}
+
+// 链上锚定交易的内容
+type AnchorRecord struct {
+	Root        string // 交易中锚定的Merkle根
+	From        string // 交易发送方
+	BlockNumber uint64 // 未打包时为0
+	BlockTime   int64  // 区块时间（Unix秒），未打包时为0
+}
+
+// 链上锚定查询
+type RootResolver interface {
+	AnchoredRoot(ctx context.Context, chain, txHash string) (*AnchorRecord, error)
+}
+
+// 校验Merkle根确已由签发方的锚定交易写入链上，且证书签发时间不晚于锚定区块。
+// issuer为验证方信任的签发地址，不能取自证明包。交易尚未打包时返回的BlockNumber为0
+func (b *Bundle) VerifyAnchor(ctx context.Context, resolver RootResolver, issuer string) (*AnchorRecord, error) {
+	if b.Anchor.TransactionHash == "" {
+		return nil, ErrNotAnchored
+	}
+	if issuer == "" {
+		return nil, ErrIssuerRequired
+	}
+	record, err := resolver.AnchoredRoot(ctx, b.Anchor.Chain, b.Anchor.TransactionHash)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(record.From, issuer) {
+		return nil, ErrIssuerMismatch
+	}
+	if !strings.EqualFold(record.Root, b.MerkleRoot) {
+		return nil, ErrAnchorMismatch
+	}
+	// 签发时间由签发方自行填写，只有区块时间可信，签发时间不能晚于区块
+	if record.BlockNumber > 0 && b.Certificate.IssuedAt > record.BlockTime+MaxClockSkew {
+		return nil, ErrIssuedAfterAnchor
+	}
+	return record, nil
+}
@@ -0,0 +1,189 @@
+package certverify
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"resume-centre/blockchain/merkle"
+)
+
+const testIssuer = "0x1111111111111111111111111111111111111111"
+
+type staticResolver map[string]*AnchorRecord
+
+func (r staticResolver) AnchoredRoot(ctx context.Context, chain, txHash string) (*AnchorRecord, error) {
+	record, ok := r[txHash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", txHash)
+	}
+	return record, nil
+}
+
+// 由签发方在证书签发一小时后打包的锚定交易
+func anchoredBy(from, root string) staticResolver {
+	return staticResolver{"0xanchor": {Root: root, From: from, BlockNumber: 42, BlockTime: 1700003600}}
+}
+
+func testBundle(t *testing.T, index int) (*Bundle, []byte) {
+	t.Helper()
+	var certs []Certificate
+	var leaves []merkle.Hash
+	var contents [][]byte
+	for i := 0; i < 5; i++ {
+		content := []byte(fmt.Sprintf("本科学历证明 #%d", i))
+		c := Certificate{
+			ID:          fmt.Sprintf("cert-%d", i),
+			Type:        "education",
+			Title:       "学历证书",
+			ContentHash: ContentHash(content),
+			IssuedAt:    1700000000 + int64(i),
+		}
+		certs = append(certs, c)
+		leaves = append(leaves, LeafHash(c))
+		contents = append(contents, content)
+	}
+
+	root, _ := merkle.Root(leaves)
+	proof, _ := merkle.Proof(leaves, index)
+	bundle := &Bundle{
+		Version:     Version,
+		Certificate: certs[index],
+		LeafHash:    leaves[index].Hex(),
+		LeafIndex:   uint64(index),
+		TreeSize:    uint64(len(leaves)),
+		MerkleRoot:  root.Hex(),
+		Anchor:      Anchor{Chain: "local", TransactionHash: "0xanchor"},
+	}
+	for _, p := range proof {
+		bundle.Proof = append(bundle.Proof, p.Hex())
+	}
+	return bundle, contents[index]
+}
+
+func TestBundleVerify(t *testing.T) {
+	bundle, content := testBundle(t, 3)
+
+	if err := bundle.Verify(); err != nil {
+		t.Fatalf("valid bundle rejected: %v", err)
+	}
+	if err := bundle.VerifyContent(content); err != nil {
+		t.Fatalf("valid content rejected: %v", err)
+	}
+	if err := bundle.VerifyContent([]byte("modified")); err != ErrContentMismatch {
+		t.Fatalf("expected ErrContentMismatch, got %v", err)
+	}
+
+	ctx := context.Background()
+	// 地址大小写不敏感
+	record, err := bundle.VerifyAnchor(ctx, anchoredBy(testIssuer, bundle.MerkleRoot), strings.ToUpper(testIssuer))
+	if err != nil || record.BlockNumber != 42 || record.BlockTime != 1700003600 {
+		t.Fatalf("anchor check failed: %+v %v", record, err)
+	}
+	if _, err := bundle.VerifyAnchor(ctx, anchoredBy(testIssuer, "0x00"), testIssuer); err != ErrAnchorMismatch {
+		t.Fatalf("expected ErrAnchorMismatch, got %v", err)
+	}
+}
+
+func TestVerifyAnchorIssuer(t *testing.T) {
+	bundle, _ := testBundle(t, 2)
+	ctx := context.Background()
+
+	// 任何人都能把同一个根写上链，只有签发方发出的交易才算锚定
+	other := "0x2222222222222222222222222222222222222222"
+	bundle.Anchor.Issuer = other
+	if _, err := bundle.VerifyAnchor(ctx, anchoredBy(other, bundle.MerkleRoot), testIssuer); err != ErrIssuerMismatch {
+		t.Fatalf("expected ErrIssuerMismatch, got %v", err)
+	}
+	if _, err := bundle.VerifyAnchor(ctx, anchoredBy(testIssuer, bundle.MerkleRoot), ""); err != ErrIssuerRequired {
+		t.Fatalf("expected ErrIssuerRequired, got %v", err)
+	}
+
+	bundle.Anchor.TransactionHash = ""
+	if _, err := bundle.VerifyAnchor(ctx, anchoredBy(testIssuer, bundle.MerkleRoot), testIssuer); err != ErrNotAnchored {
+		t.Fatalf("expected ErrNotAnchored, got %v", err)
+	}
+}
+
+func TestVerifyAnchorBlockTime(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name      string
+		issuedAt  int64
+		blockTime int64
+		want      error
+	}{
+		{"issued before block", 1700000000, 1700003600, nil},
+		{"within clock skew", 1700000000 + MaxClockSkew, 1700000000, nil},
+		{"issued after block", 1700000001 + MaxClockSkew, 1700000000, ErrIssuedAfterAnchor},
+	}
+	for _, tc := range cases {
+		bundle, _ := testBundle(t, 0)
+		bundle.Certificate.IssuedAt = tc.issuedAt
+		resolver := staticResolver{"0xanchor": {Root: bundle.MerkleRoot, From: testIssuer, BlockNumber: 7, BlockTime: tc.blockTime}}
+		if _, err := bundle.VerifyAnchor(ctx, resolver, testIssuer); err != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// 尚未打包的交易没有区块时间，由调用方按未锚定处理
+	bundle, _ := testBundle(t, 0)
+	resolver := staticResolver{"0xanchor": {Root: bundle.MerkleRoot, From: testIssuer}}
+	if record, err := bundle.VerifyAnchor(ctx, resolver, testIssuer); err != nil || record.BlockNumber != 0 {
+		t.Fatalf("pending anchor: %+v %v", record, err)
+	}
+}
+
+func TestJSONRPCResolver(t *testing.T) {
+	bundle, _ := testBundle(t, 4)
+	input := hex.EncodeToString([]byte(`{"kind":"certificate_batch","id":"b1","hash":"` + bundle.MerkleRoot + `"}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var result interface{}
+		switch req.Method {
+		case "eth_getTransactionByHash":
+			result = map[string]interface{}{"from": testIssuer, "input": "0x" + input, "blockNumber": "0x2a"}
+		case "eth_getBlockByNumber":
+			result = map[string]interface{}{"number": "0x2a", "timestamp": "0x6553fe10"}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer server.Close()
+
+	record, err := bundle.VerifyAnchor(context.Background(), &JSONRPCResolver{URL: server.URL}, testIssuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.BlockNumber != 42 || record.BlockTime != 0x6553fe10 || record.From != testIssuer {
+		t.Fatalf("unexpected anchor record %+v", record)
+	}
+}
+
+func TestBundleRejectsModifiedCertificate(t *testing.T) {
+	bundle, _ := testBundle(t, 1)
+	bundle.Certificate.Title = "硕士学历证书"
+	if err := bundle.Verify(); err != ErrLeafMismatch {
+		t.Fatalf("expected ErrLeafMismatch, got %v", err)
+	}
+
+	// 同时伪造叶子哈希也无法通过包含证明
+	bundle.LeafHash = ""
+	if err := bundle.Verify(); err != ErrInclusionInvalid {
+		t.Fatalf("expected ErrInclusionInvalid, got %v", err)
+	}
+
+	bundle, _ = testBundle(t, 1)
+	bundle.Certificate.IssuedAt++
+	bundle.LeafHash = ""
+	if err := bundle.Verify(); err != ErrInclusionInvalid {
+		t.Fatalf("backdated certificate accepted: %v", err)
+	}
+}
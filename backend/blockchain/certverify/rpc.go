@@ -0,0 +1,122 @@
+package certverify
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 通过以太坊兼容节点的JSON-RPC读取锚定交易
+type JSONRPCResolver struct {
+	URL    string
+	Client *http.Client
+}
+
+func (r *JSONRPCResolver) AnchoredRoot(ctx context.Context, chain, txHash string) (*AnchorRecord, error) {
+	var tx *struct {
+		From        string  `json:"from"`
+		Input       string  `json:"input"`
+		BlockNumber *string `json:"blockNumber"`
+	}
+	if err := r.call(ctx, "eth_getTransactionByHash", &tx, txHash); err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("certverify: transaction %s not found", txHash)
+	}
+	if tx.BlockNumber == nil {
+		return nil, fmt.Errorf("certverify: transaction %s not yet mined", txHash)
+	}
+
+	blockNumber, err := parseQuantity(*tx.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("certverify: invalid block number: %v", err)
+	}
+	input, err := hex.DecodeString(strings.TrimPrefix(tx.Input, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("certverify: invalid transaction input: %v", err)
+	}
+
+	var block *struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := r.call(ctx, "eth_getBlockByNumber", &block, *tx.BlockNumber, false); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("certverify: block %d not found", blockNumber)
+	}
+	blockTime, err := parseQuantity(block.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("certverify: invalid block timestamp: %v", err)
+	}
+
+	return &AnchorRecord{
+		Root:        ParseAnchorPayload(input),
+		From:        tx.From,
+		BlockNumber: blockNumber,
+		BlockTime:   int64(blockTime),
+	}, nil
+}
+
+// 发起JSON-RPC调用，结果解码到result
+func (r *JSONRPCResolver) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("certverify: invalid rpc response: %v", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("certverify: rpc error: %s", rpcResp.Error.Message)
+	}
+	if len(rpcResp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+func parseQuantity(s string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+}
+
+// 解析锚定交易数据中的摘要，非锚定交易返回空串
+func ParseAnchorPayload(data []byte) string {
+	var payload struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return ""
+	}
+	return payload.Hash
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"resume-centre/blockchain/certverify"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrUnsupportedChain  = errors.New("unsupported blockchain type")
+	ErrTxNotFound        = errors.New("transaction not found on chain")
+	ErrTxPending         = errors.New("transaction not yet included in a block")
+	ErrQueryNotSupported = errors.New("contract query not supported by chain adapter")
+)
+
+// 待上链交易
+type ChainTx struct {
+	From     string
+	To       string
+	Value    string // 十进制字符串，单位为链上最小单位
+	Data     []byte
+	GasLimit uint64
+}
+
+// 交易回执
+type ChainReceipt struct {
+	TxHash        string            `json:"tx_hash"`
+	BlockNumber   uint64            `json:"block_number"`
+	BlockHash     string            `json:"block_hash"`
+	GasUsed       uint64            `json:"gas_used"`
+	Status        TransactionStatus `json:"status"`
+	Confirmations uint64            `json:"confirmations"`
+}
+
+// 链上交易记录
+type ChainRecord struct {
+	TxHash      string `json:"tx_hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+	Data        []byte `json:"data"`
+	BlockNumber uint64 `json:"block_number"` // 未打包时为0
+	BlockTime   int64  `json:"block_time"`   // 区块时间（Unix秒），未打包时为0
+}
+
+// 手续费估算
+type FeeEstimate struct {
+	GasLimit uint64 `json:"gas_limit"`
+	GasPrice uint64 `json:"gas_price"`
+}
+
+// 链适配器：屏蔽不同区块链的接入差异
+type ChainAdapter interface {
+	Type() BlockchainType
+	// 默认发送地址
+	Address() string
+	Submit(ctx context.Context, tx *ChainTx) (string, error)
+	// 交易未打包返回ErrTxPending，不存在返回ErrTxNotFound
+	GetReceipt(ctx context.Context, txHash string) (*ChainReceipt, error)
+	Query(ctx context.Context, txHash string) (*ChainRecord, error)
+	EstimateFee(ctx context.Context, tx *ChainTx) (*FeeEstimate, error)
+}
+
+// 支持只读合约调用的适配器
+type ContractCaller interface {
+	Call(ctx context.Context, to string, data []byte) ([]byte, error)
+}
+
+// 可关闭的适配器
+type closableAdapter interface {
+	Close() error
+}
+
+var chainAdapters = map[BlockchainType]ChainAdapter{}
+
+func registerChainAdapter(adapter ChainAdapter) {
+	chainAdapters[adapter.Type()] = adapter
+	logger.Infof("Registered chain adapter %s (%s)", adapter.Type(), adapter.Address())
+}
+
+// 初始化链适配器：本地账本始终可用，EVM链根据数据库配置或配置文件接入
+func initChainAdapters() error {
+	local, err := NewLocalChainAdapter(LocalChainOptions{
+		DataDir:       viper.GetString("blockchain.local.data_dir"),
+		BatchSize:     viper.GetInt("blockchain.local.batch_size"),
+		BlockInterval: viper.GetDuration("blockchain.local.block_interval"),
+		GasPrice:      viper.GetUint64("blockchain.local.gas_price"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open local ledger: %v", err)
+	}
+	registerChainAdapter(local)
+
+	for _, chainType := range []BlockchainType{BlockchainTypeEthereum, BlockchainTypePolygon, BlockchainTypeBSC} {
+		key := "blockchain." + string(chainType)
+		options := EthereumChainOptions{
+			ChainType:   chainType,
+			RPCURL:      viper.GetString(key + ".rpc_url"),
+			ChainID:     viper.GetUint64(key + ".chain_id"),
+			FromAddress: viper.GetString(key + ".from_address"),
+			GasLimit:    viper.GetUint64("blockchain.gas_limit"),
+		}
+
+		var config BlockchainConfig
+		if err := db.Where("blockchain_type = ? AND is_active = ?", chainType, true).First(&config).Error; err == nil {
+			options.RPCURL = config.RPCURL
+			options.ChainID = config.ChainID
+			if config.GasLimit > 0 {
+				options.GasLimit = config.GasLimit
+			}
+		}
+		if options.RPCURL == "" {
+			continue
+		}
+		// 未配置节点托管账户时由系统钱包在本地签名
+		if options.FromAddress == "" && keyCustody != nil {
+			signer, err := systemWalletSigner(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to load system wallet: %v", err)
+			}
+			options.Signer = signer
+		}
+		registerChainAdapter(NewEthereumChainAdapter(options))
+	}
+
+	return nil
+}
+
+// 关闭所有适配器
+func closeChainAdapters() {
+	for _, adapter := range chainAdapters {
+		if c, ok := adapter.(closableAdapter); ok {
+			if err := c.Close(); err != nil {
+				logger.Errorf("Failed to close chain adapter %s: %v", adapter.Type(), err)
+			}
+		}
+	}
+}
+
+// 默认区块链类型
+func defaultBlockchainType() BlockchainType {
+	return BlockchainType(viper.GetString("blockchain.default_type"))
+}
+
+// 按类型获取适配器，为空时使用默认类型
+func getChainAdapter(chainType BlockchainType) (ChainAdapter, error) {
+	if chainType == "" {
+		chainType = defaultBlockchainType()
+	}
+	adapter, ok := chainAdapters[BlockchainType(strings.ToLower(string(chainType)))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChain, chainType)
+	}
+	return adapter, nil
+}
+
+// 估算手续费并提交交易，生成待确认的交易记录（由确认跟踪器更新状态）
+func submitChainTransaction(ctx context.Context, adapter ChainAdapter, userID uint, txType string, tx *ChainTx) (*BlockchainTransaction, error) {
+	if tx.From == "" {
+		tx.From = adapter.Address()
+	}
+
+	fee, err := adapter.EstimateFee(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate fee: %v", err)
+	}
+	if tx.GasLimit == 0 {
+		tx.GasLimit = fee.GasLimit
+	}
+
+	txHash, err := adapter.Submit(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit transaction: %v", err)
+	}
+
+	record := &BlockchainTransaction{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		Type:            txType,
+		TransactionHash: txHash,
+		BlockchainType:  adapter.Type(),
+		FromAddress:     tx.From,
+		ToAddress:       tx.To,
+		Value:           tx.Value,
+		GasPrice:        fee.GasPrice,
+		Status:          TransactionStatusPending,
+	}
+	if record.Value == "" {
+		record.Value = "0"
+	}
+	if err := db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to save transaction %s: %v", txHash, err)
+	}
+	return record, nil
+}
+
+// 将业务记录的内容摘要锚定上链
+func anchorRecord(ctx context.Context, chainType BlockchainType, userID uint, kind, id, hash string) (*BlockchainTransaction, error) {
+	adapter, err := getChainAdapter(chainType)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(map[string]string{"kind": kind, "id": id, "hash": hash})
+	if err != nil {
+		return nil, err
+	}
+	return submitChainTransaction(ctx, adapter, userID, kind, &ChainTx{Data: payload})
+}
+
+// 读取链上锚定的内容摘要
+func queryAnchoredHash(ctx context.Context, chainType BlockchainType, txHash string) (string, uint64, error) {
+	anchor, err := queryAnchorRecord(ctx, chainType, txHash)
+	if err != nil {
+		return "", 0, err
+	}
+	return anchor.Root, anchor.BlockNumber, nil
+}
+
+// 读取锚定交易的摘要、发送方和区块时间
+func queryAnchorRecord(ctx context.Context, chainType BlockchainType, txHash string) (*certverify.AnchorRecord, error) {
+	adapter, err := getChainAdapter(chainType)
+	if err != nil {
+		return nil, err
+	}
+	record, err := adapter.Query(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	hash := certverify.ParseAnchorPayload(record.Data)
+	if hash == "" {
+		return nil, fmt.Errorf("transaction %s is not an anchor record", txHash)
+	}
+	return &certverify.AnchorRecord{
+		Root:        hash,
+		From:        record.From,
+		BlockNumber: record.BlockNumber,
+		BlockTime:   record.BlockTime,
+	}, nil
+}
+
+// 上链失败对应的HTTP状态码
+func chainErrorStatus(err error) int {
+	if errors.Is(err, ErrUnsupportedChain) {
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"resume-centre/blockchain/custody"
+)
+
+// EVM兼容链（以太坊、Polygon、BSC）接入配置
+type EthereumChainOptions struct {
+	ChainType   BlockchainType
+	RPCURL      string
+	ChainID     uint64
+	FromAddress string
+	GasLimit    uint64
+	Timeout     time.Duration
+	Signer      TxSigner // 设置后在本地签名并通过eth_sendRawTransaction发送
+}
+
+// 交易签名器，私钥由托管组件持有
+type TxSigner interface {
+	Address() string
+	SignTx(ctx context.Context, tx *custody.LegacyTx, chainID uint64) ([]byte, string, error)
+}
+
+// JSON-RPC错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// 以太坊JSON-RPC适配器
+type EthereumChainAdapter struct {
+	options EthereumChainOptions
+	client  *http.Client
+	nextID  uint64
+
+	// 本地签名时串行分配nonce
+	sendMu    sync.Mutex
+	nextNonce uint64
+}
+
+func NewEthereumChainAdapter(options EthereumChainOptions) *EthereumChainAdapter {
+	if options.ChainType == "" {
+		options.ChainType = BlockchainTypeEthereum
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 15 * time.Second
+	}
+	return &EthereumChainAdapter{
+		options: options,
+		client:  &http.Client{Timeout: options.Timeout},
+	}
+}
+
+func (a *EthereumChainAdapter) Type() BlockchainType {
+	return a.options.ChainType
+}
+
+func (a *EthereumChainAdapter) Address() string {
+	if a.options.Signer != nil {
+		return a.options.Signer.Address()
+	}
+	return a.options.FromAddress
+}
+
+// 发送交易：配置签名器时本地签名，否则由节点托管账户签名
+func (a *EthereumChainAdapter) Submit(ctx context.Context, tx *ChainTx) (string, error) {
+	if a.options.Signer != nil {
+		return a.submitSigned(ctx, tx)
+	}
+
+	params, err := a.txParams(tx)
+	if err != nil {
+		return "", err
+	}
+	if tx.GasLimit > 0 {
+		params["gas"] = encodeQuantity(tx.GasLimit)
+	}
+
+	var txHash string
+	if err := a.call(ctx, "eth_sendTransaction", &txHash, params); err != nil {
+		return "", err
+	}
+	return txHash, nil
+}
+
+func (a *EthereumChainAdapter) submitSigned(ctx context.Context, tx *ChainTx) (string, error) {
+	if tx.From != "" && !strings.EqualFold(tx.From, a.Address()) {
+		return "", fmt.Errorf("cannot sign for %s, signer is %s", tx.From, a.Address())
+	}
+	params, err := a.txParams(tx)
+	if err != nil {
+		return "", err
+	}
+	legacy := &custody.LegacyTx{GasLimit: tx.GasLimit, Data: tx.Data}
+	if tx.To != "" {
+		if legacy.To, err = hex.DecodeString(strings.TrimPrefix(tx.To, "0x")); err != nil || len(legacy.To) != 20 {
+			return "", fmt.Errorf("invalid recipient address %q", tx.To)
+		}
+	}
+	if value, ok := params["value"]; ok {
+		legacy.Value, _ = new(big.Int).SetString(strings.TrimPrefix(value, "0x"), 16)
+	}
+
+	if legacy.GasLimit == 0 {
+		fee, err := a.EstimateFee(ctx, tx)
+		if err != nil {
+			return "", err
+		}
+		legacy.GasLimit, legacy.GasPrice = fee.GasLimit, fee.GasPrice
+	} else {
+		var priceHex string
+		if err := a.call(ctx, "eth_gasPrice", &priceHex); err != nil {
+			return "", err
+		}
+		if legacy.GasPrice, err = decodeQuantity(priceHex); err != nil {
+			return "", fmt.Errorf("invalid gas price: %v", err)
+		}
+	}
+
+	chainID := a.options.ChainID
+	if chainID == 0 {
+		var idHex string
+		if err := a.call(ctx, "eth_chainId", &idHex); err != nil {
+			return "", err
+		}
+		if chainID, err = decodeQuantity(idHex); err != nil {
+			return "", fmt.Errorf("invalid chain id: %v", err)
+		}
+	}
+
+	a.sendMu.Lock()
+	defer a.sendMu.Unlock()
+
+	// 以节点pending nonce为准，并发提交时使用本地递增值避免重复
+	var nonceHex string
+	if err := a.call(ctx, "eth_getTransactionCount", &nonceHex, a.Address(), "pending"); err != nil {
+		return "", err
+	}
+	nonce, err := decodeQuantity(nonceHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid nonce: %v", err)
+	}
+	if nonce < a.nextNonce {
+		nonce = a.nextNonce
+	}
+	legacy.Nonce = nonce
+
+	raw, txHash, err := a.options.Signer.SignTx(ctx, legacy, chainID)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if err := a.call(ctx, "eth_sendRawTransaction", nil, "0x"+hex.EncodeToString(raw)); err != nil {
+		return "", err
+	}
+	a.nextNonce = nonce + 1
+	return txHash, nil
+}
+
+func (a *EthereumChainAdapter) GetReceipt(ctx context.Context, txHash string) (*ChainReceipt, error) {
+	var raw *struct {
+		TransactionHash string `json:"transactionHash"`
+		BlockNumber     string `json:"blockNumber"`
+		BlockHash       string `json:"blockHash"`
+		GasUsed         string `json:"gasUsed"`
+		Status          string `json:"status"`
+	}
+	if err := a.call(ctx, "eth_getTransactionReceipt", &raw, txHash); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		// 无回执时区分交易未打包和交易不存在
+		if _, err := a.Query(ctx, txHash); err != nil {
+			return nil, err
+		}
+		return nil, ErrTxPending
+	}
+
+	receipt := &ChainReceipt{
+		TxHash:    raw.TransactionHash,
+		BlockHash: raw.BlockHash,
+		Status:    TransactionStatusConfirmed,
+	}
+	var err error
+	if receipt.BlockNumber, err = decodeQuantity(raw.BlockNumber); err != nil {
+		return nil, fmt.Errorf("invalid receipt block number: %v", err)
+	}
+	if receipt.GasUsed, err = decodeQuantity(raw.GasUsed); err != nil {
+		return nil, fmt.Errorf("invalid receipt gas used: %v", err)
+	}
+	// 拜占庭分叉后回执status为0x0表示执行失败
+	if raw.Status == "0x0" {
+		receipt.Status = TransactionStatusFailed
+	}
+
+	head, err := a.blockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if head >= receipt.BlockNumber {
+		receipt.Confirmations = head - receipt.BlockNumber + 1
+	}
+	return receipt, nil
+}
+
+func (a *EthereumChainAdapter) Query(ctx context.Context, txHash string) (*ChainRecord, error) {
+	var raw *struct {
+		Hash        string  `json:"hash"`
+		From        string  `json:"from"`
+		To          string  `json:"to"`
+		Value       string  `json:"value"`
+		Input       string  `json:"input"`
+		BlockNumber *string `json:"blockNumber"`
+	}
+	if err := a.call(ctx, "eth_getTransactionByHash", &raw, txHash); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, ErrTxNotFound
+	}
+
+	record := &ChainRecord{
+		TxHash: raw.Hash,
+		From:   raw.From,
+		To:     raw.To,
+	}
+	value, ok := new(big.Int).SetString(strings.TrimPrefix(raw.Value, "0x"), 16)
+	if !ok {
+		value = big.NewInt(0)
+	}
+	record.Value = value.String()
+
+	data, err := hex.DecodeString(strings.TrimPrefix(raw.Input, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction input: %v", err)
+	}
+	record.Data = data
+
+	if raw.BlockNumber != nil {
+		if record.BlockNumber, err = decodeQuantity(*raw.BlockNumber); err != nil {
+			return nil, fmt.Errorf("invalid transaction block number: %v", err)
+		}
+		var block *struct {
+			Timestamp string `json:"timestamp"`
+		}
+		if err := a.call(ctx, "eth_getBlockByNumber", &block, *raw.BlockNumber, false); err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block %d not found", record.BlockNumber)
+		}
+		blockTime, err := decodeQuantity(block.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block timestamp: %v", err)
+		}
+		record.BlockTime = int64(blockTime)
+	}
+	return record, nil
+}
+
+func (a *EthereumChainAdapter) EstimateFee(ctx context.Context, tx *ChainTx) (*FeeEstimate, error) {
+	params, err := a.txParams(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var gasHex, priceHex string
+	if err := a.call(ctx, "eth_estimateGas", &gasHex, params); err != nil {
+		return nil, err
+	}
+	if err := a.call(ctx, "eth_gasPrice", &priceHex); err != nil {
+		return nil, err
+	}
+
+	fee := &FeeEstimate{}
+	if fee.GasLimit, err = decodeQuantity(gasHex); err != nil {
+		return nil, fmt.Errorf("invalid gas estimate: %v", err)
+	}
+	if fee.GasPrice, err = decodeQuantity(priceHex); err != nil {
+		return nil, fmt.Errorf("invalid gas price: %v", err)
+	}
+	if a.options.GasLimit > 0 && fee.GasLimit > a.options.GasLimit {
+		return nil, fmt.Errorf("estimated gas %d exceeds limit %d", fee.GasLimit, a.options.GasLimit)
+	}
+	return fee, nil
+}
+
+// 只读合约调用
+func (a *EthereumChainAdapter) Call(ctx context.Context, to string, data []byte) ([]byte, error) {
+	params := map[string]string{
+		"to":   to,
+		"data": "0x" + hex.EncodeToString(data),
+	}
+	var result string
+	if err := a.call(ctx, "eth_call", &result, params, "latest"); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(result, "0x"))
+}
+
+func (a *EthereumChainAdapter) blockNumber(ctx context.Context) (uint64, error) {
+	var head string
+	if err := a.call(ctx, "eth_blockNumber", &head); err != nil {
+		return 0, err
+	}
+	return decodeQuantity(head)
+}
+
+func (a *EthereumChainAdapter) txParams(tx *ChainTx) (map[string]string, error) {
+	params := map[string]string{
+		"from": tx.From,
+		"data": "0x" + hex.EncodeToString(tx.Data),
+	}
+	if params["from"] == "" {
+		params["from"] = a.Address()
+	}
+	if tx.To != "" {
+		params["to"] = tx.To
+	}
+	if tx.Value != "" && tx.Value != "0" {
+		value, ok := new(big.Int).SetString(tx.Value, 10)
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid transaction value %q", tx.Value)
+		}
+		params["value"] = "0x" + value.Text(16)
+	}
+	return params, nil
+}
+
+func (a *EthereumChainAdapter) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&a.nextID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.options.RPCURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: rpc endpoint returned status %d", method, resp.StatusCode)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("%s: invalid rpc response: %v", method, err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("%s: %w", method, rpcResp.Error)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+// 编码十六进制数量
+func encodeQuantity(v uint64) string {
+	return "0x" + strconv.FormatUint(v, 16)
+}
+
+// 解析十六进制数量
+func decodeQuantity(s string) (uint64, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return 0, fmt.Errorf("missing 0x prefix in %q", s)
+	}
+	return strconv.ParseUint(s[2:], 16, 64)
+}
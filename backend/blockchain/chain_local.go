@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"resume-centre/blockchain/merkle"
+)
+
+// 本地账本文件名
+const localLedgerFile = "ledger.log"
+
+// 创世区块前序哈希
+var localGenesisHash = "0x" + hex.EncodeToString(make([]byte, sha256.Size))
+
+// 本地账本配置
+type LocalChainOptions struct {
+	DataDir       string
+	BatchSize     int           // 每个区块最多打包的交易数
+	BlockInterval time.Duration // 出块间隔，为0时仅在达到批量时出块
+	GasPrice      uint64
+}
+
+// 本地账本交易
+type localTx struct {
+	Hash      string `json:"hash"`
+	Nonce     uint64 `json:"nonce"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Value     string `json:"value"`
+	Data      []byte `json:"data"`
+	Timestamp int64  `json:"timestamp"`
+
+	block uint64
+}
+
+// 本地账本区块
+type localBlock struct {
+	Number     uint64   `json:"number"`
+	PrevHash   string   `json:"prev_hash"`
+	MerkleRoot string   `json:"merkle_root"`
+	TxHashes   []string `json:"tx_hashes"`
+	Timestamp  int64    `json:"timestamp"`
+	Hash       string   `json:"hash"`
+}
+
+// 账本日志行，交易与区块按写入顺序追加
+type localLedgerEntry struct {
+	Kind  string      `json:"kind"`
+	Tx    *localTx    `json:"tx,omitempty"`
+	Block *localBlock `json:"block,omitempty"`
+}
+
+// 本地链适配器：交易先追加到磁盘日志，再按批次打包成以Merkle根和前序哈希链接的区块，
+// 启动时重放并校验整条链，适用于开发和测试环境
+type LocalChainAdapter struct {
+	mu       sync.Mutex
+	file     *os.File
+	options  LocalChainOptions
+	address  string
+	txs      map[string]*localTx
+	blocks   []*localBlock
+	pending  []*localTx
+	nonce    uint64
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewLocalChainAdapter(options LocalChainOptions) (*LocalChainAdapter, error) {
+	if options.DataDir == "" {
+		options.DataDir = "./data/ledger"
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 100
+	}
+	if err := os.MkdirAll(options.DataDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(options.DataDir, localLedgerFile)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(path))
+	a := &LocalChainAdapter{
+		file:    file,
+		options: options,
+		address: "0x" + hex.EncodeToString(sum[:20]),
+		txs:     map[string]*localTx{},
+		stop:    make(chan struct{}),
+	}
+	if err := a.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if options.BlockInterval > 0 {
+		a.wg.Add(1)
+		go a.sealLoop()
+	}
+	return a, nil
+}
+
+func (a *LocalChainAdapter) Type() BlockchainType {
+	return BlockchainTypeLocal
+}
+
+func (a *LocalChainAdapter) Address() string {
+	return a.address
+}
+
+func (a *LocalChainAdapter) Submit(ctx context.Context, tx *ChainTx) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nonce++
+	entry := &localTx{
+		Nonce:     a.nonce,
+		From:      tx.From,
+		To:        tx.To,
+		Value:     tx.Value,
+		Data:      tx.Data,
+		Timestamp: time.Now().UnixNano(),
+	}
+	entry.Hash = localTxHash(entry)
+
+	if err := a.append(localLedgerEntry{Kind: "tx", Tx: entry}); err != nil {
+		a.nonce--
+		return "", err
+	}
+	a.txs[entry.Hash] = entry
+	a.pending = append(a.pending, entry)
+
+	// 交易已落盘，出块失败时留待下次出块
+	if len(a.pending) >= a.options.BatchSize {
+		if err := a.sealLocked(); err != nil {
+			logger.Errorf("Failed to seal local block: %v", err)
+		}
+	}
+	return entry.Hash, nil
+}
+
+func (a *LocalChainAdapter) GetReceipt(ctx context.Context, txHash string) (*ChainReceipt, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tx, ok := a.txs[txHash]
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+	if tx.block == 0 {
+		return nil, ErrTxPending
+	}
+	block := a.blocks[tx.block-1]
+	return &ChainReceipt{
+		TxHash:        tx.Hash,
+		BlockNumber:   block.Number,
+		BlockHash:     block.Hash,
+		GasUsed:       intrinsicGas(tx.Data),
+		Status:        TransactionStatusConfirmed,
+		Confirmations: uint64(len(a.blocks)) - block.Number + 1,
+	}, nil
+}
+
+func (a *LocalChainAdapter) Query(ctx context.Context, txHash string) (*ChainRecord, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tx, ok := a.txs[txHash]
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+	record := &ChainRecord{
+		TxHash:      tx.Hash,
+		From:        tx.From,
+		To:          tx.To,
+		Value:       tx.Value,
+		Data:        tx.Data,
+		BlockNumber: tx.block,
+	}
+	if tx.block > 0 {
+		record.BlockTime = a.blocks[tx.block-1].Timestamp
+	}
+	return record, nil
+}
+
+func (a *LocalChainAdapter) EstimateFee(ctx context.Context, tx *ChainTx) (*FeeEstimate, error) {
+	return &FeeEstimate{
+		GasLimit: intrinsicGas(tx.Data),
+		GasPrice: a.options.GasPrice,
+	}, nil
+}
+
+// 立即将待打包交易出块
+func (a *LocalChainAdapter) Seal() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sealLocked()
+}
+
+// 当前区块高度
+func (a *LocalChainAdapter) BlockNumber() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return uint64(len(a.blocks))
+}
+
+func (a *LocalChainAdapter) Close() error {
+	a.stopOnce.Do(func() { close(a.stop) })
+	a.wg.Wait()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.sealLocked(); err != nil {
+		logger.Errorf("Failed to seal pending local transactions: %v", err)
+	}
+	return a.file.Close()
+}
+
+func (a *LocalChainAdapter) sealLoop() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.options.BlockInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			if err := a.Seal(); err != nil {
+				logger.Errorf("Failed to seal local block: %v", err)
+			}
+		}
+	}
+}
+
+func (a *LocalChainAdapter) sealLocked() error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+
+	prevHash := localGenesisHash
+	if n := len(a.blocks); n > 0 {
+		prevHash = a.blocks[n-1].Hash
+	}
+
+	hashes := make([]string, len(a.pending))
+	for i, tx := range a.pending {
+		hashes[i] = tx.Hash
+	}
+	root, err := localMerkleRoot(hashes)
+	if err != nil {
+		return err
+	}
+
+	block := &localBlock{
+		Number:     uint64(len(a.blocks)) + 1,
+		PrevHash:   prevHash,
+		MerkleRoot: root,
+		TxHashes:   hashes,
+		Timestamp:  time.Now().Unix(),
+	}
+	block.Hash = localBlockHash(block)
+
+	if err := a.append(localLedgerEntry{Kind: "block", Block: block}); err != nil {
+		return err
+	}
+	a.blocks = append(a.blocks, block)
+	for _, tx := range a.pending {
+		tx.block = block.Number
+	}
+	a.pending = nil
+	return nil
+}
+
+// 追加一行日志并落盘
+func (a *LocalChainAdapter) append(entry localLedgerEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+// 重放账本日志并校验哈希链，末尾写入不完整的行会被截断
+func (a *LocalChainAdapter) replay() error {
+	reader := bufio.NewReader(a.file)
+	var offset int64
+	lineNo := 0
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				logger.Warnf("Truncating incomplete local ledger entry at offset %d", offset)
+				if err := a.file.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		lineNo++
+
+		var entry localLedgerEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("local ledger corrupted at line %d: %v", lineNo, err)
+		}
+		if err := a.apply(entry); err != nil {
+			return fmt.Errorf("local ledger corrupted at line %d: %v", lineNo, err)
+		}
+		offset += int64(len(line))
+	}
+
+	_, err := a.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (a *LocalChainAdapter) apply(entry localLedgerEntry) error {
+	switch {
+	case entry.Kind == "tx" && entry.Tx != nil:
+		tx := entry.Tx
+		if localTxHash(tx) != tx.Hash {
+			return fmt.Errorf("transaction %s hash mismatch", tx.Hash)
+		}
+		if _, exists := a.txs[tx.Hash]; exists {
+			return fmt.Errorf("duplicate transaction %s", tx.Hash)
+		}
+		a.txs[tx.Hash] = tx
+		a.pending = append(a.pending, tx)
+		if tx.Nonce > a.nonce {
+			a.nonce = tx.Nonce
+		}
+
+	case entry.Kind == "block" && entry.Block != nil:
+		block := entry.Block
+		prevHash := localGenesisHash
+		if n := len(a.blocks); n > 0 {
+			prevHash = a.blocks[n-1].Hash
+		}
+		if block.Number != uint64(len(a.blocks))+1 {
+			return fmt.Errorf("block %d out of sequence", block.Number)
+		}
+		if block.PrevHash != prevHash {
+			return fmt.Errorf("block %d does not link to previous block", block.Number)
+		}
+		root, err := localMerkleRoot(block.TxHashes)
+		if err != nil || root != block.MerkleRoot {
+			return fmt.Errorf("block %d merkle root mismatch", block.Number)
+		}
+		if localBlockHash(block) != block.Hash {
+			return fmt.Errorf("block %d hash mismatch", block.Number)
+		}
+
+		included := make(map[string]bool, len(block.TxHashes))
+		for _, hash := range block.TxHashes {
+			tx, ok := a.txs[hash]
+			if !ok || tx.block != 0 {
+				return fmt.Errorf("block %d includes unknown or sealed transaction %s", block.Number, hash)
+			}
+			tx.block = block.Number
+			included[hash] = true
+		}
+		remaining := a.pending[:0]
+		for _, tx := range a.pending {
+			if !included[tx.Hash] {
+				remaining = append(remaining, tx)
+			}
+		}
+		a.pending = remaining
+		a.blocks = append(a.blocks, block)
+
+	default:
+		return fmt.Errorf("unknown entry kind %q", entry.Kind)
+	}
+	return nil
+}
+
+// 交易哈希覆盖除哈希本身外的全部字段
+func localTxHash(tx *localTx) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%s|%s|%s|%d|", tx.Nonce, tx.From, tx.To, tx.Value, tx.Timestamp)
+	h.Write(tx.Data)
+	return "0x" + hex.EncodeToString(h.Sum(nil))
+}
+
+func localBlockHash(block *localBlock) string {
+	sum := sha256.Sum256([]byte(block.PrevHash + "|" + block.MerkleRoot + "|" +
+		strconv.FormatUint(block.Number, 10) + "|" + strconv.FormatInt(block.Timestamp, 10)))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+func localMerkleRoot(txHashes []string) (string, error) {
+	leaves := make([]merkle.Hash, len(txHashes))
+	for i, hash := range txHashes {
+		h, err := merkle.ParseHash(hash)
+		if err != nil {
+			return "", err
+		}
+		leaves[i] = merkle.LeafHash(h[:])
+	}
+	root, err := merkle.Root(leaves)
+	if err != nil {
+		return "", err
+	}
+	return root.Hex(), nil
+}
+
+// 按以太坊规则计算交易固有Gas
+func intrinsicGas(data []byte) uint64 {
+	gas := uint64(21000)
+	for _, b := range data {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	return gas
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"resume-centre/blockchain/custody"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestLocalChainAdapterLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	adapter, err := NewLocalChainAdapter(LocalChainOptions{DataDir: dir, BatchSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hashes []string
+	for _, data := range []string{"a", "b", "c", "d"} {
+		hash, err := adapter.Submit(ctx, &ChainTx{Data: []byte(data)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	// 前三笔达到批量后出块，第四笔仍待打包
+	receipt, err := adapter.GetReceipt(ctx, hashes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.BlockNumber != 1 || receipt.Confirmations != 1 || receipt.Status != TransactionStatusConfirmed {
+		t.Fatalf("unexpected receipt %+v", receipt)
+	}
+	if receipt.GasUsed != 21016 {
+		t.Fatalf("expected gas 21016, got %d", receipt.GasUsed)
+	}
+	if _, err := adapter.GetReceipt(ctx, hashes[3]); !errors.Is(err, ErrTxPending) {
+		t.Fatalf("expected ErrTxPending, got %v", err)
+	}
+	if _, err := adapter.GetReceipt(ctx, "0xdeadbeef"); !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("expected ErrTxNotFound, got %v", err)
+	}
+
+	if err := adapter.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	receipt, _ = adapter.GetReceipt(ctx, hashes[0])
+	if receipt.Confirmations != 2 {
+		t.Fatalf("expected 2 confirmations, got %d", receipt.Confirmations)
+	}
+	if err := adapter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重放后状态一致
+	reopened, err := NewLocalChainAdapter(LocalChainOptions{DataDir: dir, BatchSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	if reopened.BlockNumber() != 2 {
+		t.Fatalf("expected height 2 after replay, got %d", reopened.BlockNumber())
+	}
+	record, err := reopened.Query(ctx, hashes[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(record.Data) != "d" || record.BlockNumber != 2 || record.BlockTime == 0 {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	next, err := reopened.Submit(ctx, &ChainTx{Data: []byte("e")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hashes {
+		if h == next {
+			t.Fatal("replayed ledger reused a transaction hash")
+		}
+	}
+}
+
+func TestLocalChainAdapterDetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	adapter, err := NewLocalChainAdapter(LocalChainOptions{DataDir: dir, BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"first", "second"} {
+		if _, err := adapter.Submit(ctx, &ChainTx{Data: []byte(data)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	adapter.Close()
+
+	path := filepath.Join(dir, localLedgerFile)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 截断的末尾行视为未完成写入
+	if err := os.WriteFile(path, append(append([]byte{}, raw...), []byte(`{"kind":"tx"`)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := NewLocalChainAdapter(LocalChainOptions{DataDir: dir})
+	if err != nil {
+		t.Fatalf("expected incomplete tail to be truncated, got %v", err)
+	}
+	recovered.Close()
+
+	// 篡改已出块交易的数据
+	tampered := strings.Replace(string(raw), `"data":"Zmlyc3Q="`, `"data":"Zm9yZ2Vk"`, 1)
+	if tampered == string(raw) {
+		t.Fatal("fixture did not contain expected transaction data")
+	}
+	if err := os.WriteFile(path, []byte(tampered), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLocalChainAdapter(LocalChainOptions{DataDir: dir}); err == nil {
+		t.Fatal("expected tampered ledger to be rejected")
+	}
+}
+
+// 模拟以太坊节点的JSON-RPC接口
+type ethStub struct {
+	mu       sync.Mutex
+	head     uint64
+	txs      map[string]map[string]interface{}
+	receipts map[string]map[string]interface{}
+	raw      [][]byte
+	calls    []string
+}
+
+func newEthStub() *ethStub {
+	return &ethStub{
+		head:     100,
+		txs:      map[string]map[string]interface{}{},
+		receipts: map[string]map[string]interface{}{},
+	}
+}
+
+func (s *ethStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, req.Method)
+
+	var result interface{}
+	var rpcErr *rpcError
+	param := func(i int) string {
+		var v string
+		json.Unmarshal(req.Params[i], &v)
+		return v
+	}
+
+	switch req.Method {
+	case "eth_sendTransaction":
+		var tx map[string]string
+		json.Unmarshal(req.Params[0], &tx)
+		if tx["from"] == "" {
+			rpcErr = &rpcError{Code: -32000, Message: "unknown account"}
+			break
+		}
+		hash := "0x" + strings.Repeat("ab", 32)
+		s.txs[hash] = map[string]interface{}{
+			"hash": hash, "from": tx["from"], "to": tx["to"],
+			"value": "0x0", "input": tx["data"], "blockNumber": nil,
+		}
+		result = hash
+	case "eth_getTransactionByHash":
+		if tx, ok := s.txs[param(0)]; ok {
+			result = tx
+		}
+	case "eth_getTransactionReceipt":
+		if receipt, ok := s.receipts[param(0)]; ok {
+			result = receipt
+		}
+	case "eth_getBlockByNumber":
+		if number, err := decodeQuantity(param(0)); err == nil && number <= s.head {
+			result = map[string]interface{}{"number": param(0), "timestamp": encodeQuantity(1700000000 + number)}
+		}
+	case "eth_blockNumber":
+		result = encodeQuantity(s.head)
+	case "eth_estimateGas":
+		result = "0x5208"
+	case "eth_gasPrice":
+		result = "0x4a817c800"
+	case "eth_call":
+		result = "0x2a"
+	case "eth_chainId":
+		result = "0x1"
+	case "eth_getTransactionCount":
+		// 固定返回0，模拟节点pending池尚未反映已发送交易
+		result = "0x0"
+	case "eth_sendRawTransaction":
+		raw, _ := hex.DecodeString(strings.TrimPrefix(param(0), "0x"))
+		s.raw = append(s.raw, raw)
+		result = "0x" + hex.EncodeToString(custody.Keccak256(raw))
+	default:
+		rpcErr = &rpcError{Code: -32601, Message: "method not found"}
+	}
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if rpcErr != nil {
+		resp["error"] = rpcErr
+	} else {
+		resp["result"] = result
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// 模拟交易被打包
+func (s *ethStub) mine(hash string, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.head++
+	s.txs[hash]["blockNumber"] = encodeQuantity(s.head)
+	s.receipts[hash] = map[string]interface{}{
+		"transactionHash": hash,
+		"blockNumber":     encodeQuantity(s.head),
+		"blockHash":       "0x" + strings.Repeat("cd", 32),
+		"gasUsed":         "0x5208",
+		"status":          status,
+	}
+}
+
+func TestEthereumChainAdapter(t *testing.T) {
+	stub := newEthStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	ctx := context.Background()
+	adapter := NewEthereumChainAdapter(EthereumChainOptions{
+		RPCURL:      server.URL,
+		FromAddress: "0x" + strings.Repeat("11", 20),
+	})
+
+	tx := &ChainTx{To: "0x" + strings.Repeat("22", 20), Data: []byte("anchor")}
+	fee, err := adapter.EstimateFee(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee.GasLimit != 21000 || fee.GasPrice != 20000000000 {
+		t.Fatalf("unexpected fee %+v", fee)
+	}
+
+	hash, err := adapter.Submit(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adapter.GetReceipt(ctx, hash); !errors.Is(err, ErrTxPending) {
+		t.Fatalf("expected ErrTxPending, got %v", err)
+	}
+	if _, err := adapter.GetReceipt(ctx, "0x"+strings.Repeat("ff", 32)); !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("expected ErrTxNotFound, got %v", err)
+	}
+
+	stub.mine(hash, "0x1")
+	receipt, err := adapter.GetReceipt(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.BlockNumber != 101 || receipt.GasUsed != 21000 || receipt.Confirmations != 1 {
+		t.Fatalf("unexpected receipt %+v", receipt)
+	}
+	if status, done := receiptStatus(receipt, 3); done || status != TransactionStatusPending {
+		t.Fatalf("expected pending until 3 confirmations, got %s", status)
+	}
+	stub.mu.Lock()
+	stub.head += 2
+	stub.mu.Unlock()
+	receipt, _ = adapter.GetReceipt(ctx, hash)
+	if status, done := receiptStatus(receipt, 3); !done || status != TransactionStatusConfirmed {
+		t.Fatalf("expected confirmed, got %s", status)
+	}
+
+	record, err := adapter.Query(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(record.Data) != "anchor" || record.BlockNumber != 101 || record.BlockTime != 1700000101 {
+		t.Fatalf("unexpected record %+v", record)
+	}
+
+	output, err := adapter.Call(ctx, tx.To, nil)
+	if err != nil || len(output) != 1 || output[0] != 0x2a {
+		t.Fatalf("unexpected call output %x (%v)", output, err)
+	}
+
+	// 节点返回的错误需透传
+	noFrom := NewEthereumChainAdapter(EthereumChainOptions{RPCURL: server.URL})
+	var rpcErr *rpcError
+	if _, err := noFrom.Submit(ctx, &ChainTx{Data: []byte("x")}); !errors.As(err, &rpcErr) || rpcErr.Code != -32000 {
+		t.Fatalf("expected rpc error, got %v", err)
+	}
+}
+
+func TestReceiptStatusReverted(t *testing.T) {
+	status, done := receiptStatus(&ChainReceipt{Status: TransactionStatusFailed}, 12)
+	if !done || status != TransactionStatusFailed {
+		t.Fatalf("reverted transaction should fail immediately, got %s", status)
+	}
+}
+
+func TestEthereumChainAdapterSignsLocally(t *testing.T) {
+	stub := newEthStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	kms, err := custody.OpenLocalKMS(filepath.Join(t.TempDir(), "master.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := custody.New(kms)
+	generated, err := keys.GenerateKey(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adapter := NewEthereumChainAdapter(EthereumChainOptions{
+		RPCURL: server.URL,
+		Signer: &walletSigner{custody: keys, sealed: generated.Sealed},
+	})
+	if adapter.Address() != generated.Address {
+		t.Fatalf("adapter address %s, want %s", adapter.Address(), generated.Address)
+	}
+
+	ctx := context.Background()
+	var hashes []string
+	for i := 0; i < 2; i++ {
+		hash, err := adapter.Submit(ctx, &ChainTx{Data: []byte(`{"kind":"test"}`)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if len(stub.raw) != 2 {
+		t.Fatalf("expected 2 raw transactions, got %d", len(stub.raw))
+	}
+	for i, raw := range stub.raw {
+		if want := "0x" + hex.EncodeToString(custody.Keccak256(raw)); hashes[i] != want {
+			t.Fatalf("tx %d hash %s, want %s", i, hashes[i], want)
+		}
+	}
+	// 列表头两字节后为nonce：0编码为0x80，1编码为0x01
+	if stub.raw[0][2] != 0x80 || stub.raw[1][2] != 0x01 {
+		t.Fatalf("unexpected nonces %x %x", stub.raw[0][2], stub.raw[1][2])
+	}
+
+	if _, err := adapter.Submit(ctx, &ChainTx{From: "0x0000000000000000000000000000000000000001"}); err == nil {
+		t.Fatal("expected error when submitting for another account")
+	}
+}
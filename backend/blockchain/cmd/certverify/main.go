@@ -0,0 +1,84 @@
+// certverify 离线校验证书证明包
+//
+//	certverify -bundle proof.json [-content certificate.txt] [-rpc https://node.example -issuer 0x...]
+//
+// 未指定-rpc时只校验包含证明，结果为未验证（退出码2）：证明包本身无法证明Merkle根由签发方上链
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"resume-centre/blockchain/certverify"
+)
+
+func main() {
+	bundlePath := flag.String("bundle", "-", "证明包JSON文件，-表示标准输入")
+	contentPath := flag.String("content", "", "证书原文文件（可选），用于校验内容未被修改")
+	rpcURL := flag.String("rpc", "", "EVM节点JSON-RPC地址，用于校验Merkle根已由签发方上链")
+	issuer := flag.String("issuer", "", "签发方锚定地址，与-rpc同时使用，须从可信渠道获取而非证明包")
+	flag.Parse()
+
+	var in io.Reader = os.Stdin
+	if *bundlePath != "-" {
+		f, err := os.Open(*bundlePath)
+		if err != nil {
+			fail("open bundle: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var bundle certverify.Bundle
+	if err := json.NewDecoder(in).Decode(&bundle); err != nil {
+		fail("decode bundle: %v", err)
+	}
+
+	if err := bundle.Verify(); err != nil {
+		fail("inclusion proof: %v", err)
+	}
+	fmt.Printf("inclusion proof: ok (leaf %d of %d, root %s)\n", bundle.LeafIndex, bundle.TreeSize, bundle.MerkleRoot)
+
+	if *contentPath != "" {
+		content, err := os.ReadFile(*contentPath)
+		if err != nil {
+			fail("read content: %v", err)
+		}
+		if err := bundle.VerifyContent(content); err != nil {
+			fail("content: %v", err)
+		}
+		fmt.Println("content: ok")
+	}
+
+	if *rpcURL == "" {
+		fmt.Println("anchor: not checked (no -rpc)")
+		fmt.Printf("certificate %s (%s) is unanchored/unverified: merkle root was not checked on chain\n",
+			bundle.Certificate.ID, bundle.Certificate.Title)
+		os.Exit(2)
+	}
+	if *issuer == "" {
+		fail("anchor: -issuer is required with -rpc")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	record, err := bundle.VerifyAnchor(ctx, &certverify.JSONRPCResolver{URL: *rpcURL}, *issuer)
+	if err != nil {
+		fail("anchor: %v", err)
+	}
+	anchoredAt := time.Unix(record.BlockTime, 0).Format(time.RFC3339)
+	fmt.Printf("anchor: ok (%s tx %s from %s, block %d at %s)\n", bundle.Anchor.Chain, bundle.Anchor.TransactionHash,
+		record.From, record.BlockNumber, anchoredAt)
+
+	fmt.Printf("certificate %s (%s) anchored at %s is valid\n", bundle.Certificate.ID, bundle.Certificate.Title, anchoredAt)
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "verification failed: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 启动交易确认跟踪任务，根据链上回执更新交易状态
+func startConfirmationTracker(ctx context.Context) {
+	interval := viper.GetDuration("blockchain.tracker.interval")
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				trackPendingTransactions(ctx)
+			}
+		}
+	}()
+}
+
+// 检查所有待确认交易
+func trackPendingTransactions(ctx context.Context) {
+	batchSize := viper.GetInt("blockchain.tracker.batch_size")
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	var pending []BlockchainTransaction
+	if err := db.Where("status = ? AND transaction_hash <> ''", TransactionStatusPending).
+		Order("created_at ASC").Limit(batchSize).Find(&pending).Error; err != nil {
+		logger.Errorf("Failed to load pending transactions: %v", err)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		trackTransaction(ctx, &pending[i])
+	}
+}
+
+func trackTransaction(ctx context.Context, tx *BlockchainTransaction) {
+	adapter, err := getChainAdapter(tx.BlockchainType)
+	if err != nil {
+		finishTransaction(tx, TransactionStatusFailed, nil, err.Error())
+		return
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	receipt, err := adapter.GetReceipt(reqCtx, tx.TransactionHash)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrTxPending), errors.Is(err, ErrTxNotFound):
+		// 超时未打包（或被节点丢弃）的交易判定为失败
+		timeout := viper.GetDuration("blockchain.tracker.tx_timeout")
+		if timeout > 0 && time.Since(tx.CreatedAt) > timeout {
+			finishTransaction(tx, TransactionStatusFailed, nil, err.Error())
+		}
+		return
+	default:
+		logger.Warnf("Failed to get receipt for %s: %v", tx.TransactionHash, err)
+		return
+	}
+
+	status, done := receiptStatus(receipt, requiredConfirmations(tx.BlockchainType))
+	if !done {
+		return
+	}
+	message := ""
+	if status == TransactionStatusFailed {
+		message = "transaction reverted"
+	}
+	finishTransaction(tx, status, receipt, message)
+}
+
+// 所需确认数，可按链类型单独配置
+func requiredConfirmations(chainType BlockchainType) uint64 {
+	key := "blockchain." + string(chainType) + ".confirmations"
+	if viper.IsSet(key) {
+		return viper.GetUint64(key)
+	}
+	return viper.GetUint64("blockchain.tracker.confirmations")
+}
+
+// 根据回执判定交易最终状态；执行失败的交易无需等待确认数
+func receiptStatus(receipt *ChainReceipt, required uint64) (TransactionStatus, bool) {
+	if receipt.Status == TransactionStatusFailed {
+		return TransactionStatusFailed, true
+	}
+	if receipt.Confirmations < required {
+		return TransactionStatusPending, false
+	}
+	return TransactionStatusConfirmed, true
+}
+
+// 写入交易最终状态，并同步到引用该交易的业务记录
+func finishTransaction(tx *BlockchainTransaction, status TransactionStatus, receipt *ChainReceipt, message string) {
+	updates := map[string]interface{}{
+		"status": status,
+		"error":  message,
+	}
+	if receipt != nil {
+		updates["block_number"] = receipt.BlockNumber
+		updates["gas_used"] = receipt.GasUsed
+	}
+	if err := db.Model(tx).Updates(updates).Error; err != nil {
+		logger.Errorf("Failed to update transaction %s: %v", tx.TransactionHash, err)
+		return
+	}
+
+	delete(updates, "error")
+	batchUpdates := map[string]interface{}{"status": status}
+	if receipt != nil {
+		batchUpdates["block_number"] = receipt.BlockNumber
+	}
+	// 批次锚定失败时保留批次和包含证明，清空交易后由批量任务以同一批次重新锚定
+	if status == TransactionStatusFailed {
+		batchUpdates = map[string]interface{}{
+			"status":           TransactionStatusPending,
+			"transaction_hash": "",
+			"issuer_address":   "",
+			"block_number":     0,
+		}
+	}
+	if err := db.Model(&CertificateBatch{}).Where("transaction_hash = ?", tx.TransactionHash).
+		Updates(batchUpdates).Error; err != nil {
+		logger.Errorf("Failed to update certificate batches for %s: %v", tx.TransactionHash, err)
+	}
+
+	if status == TransactionStatusFailed {
+		if err := db.Model(&BlockchainCertificate{}).
+			Where("transaction_hash = ? AND COALESCE(batch_id, '') <> ''", tx.TransactionHash).
+			Update("transaction_hash", "").Error; err != nil {
+			logger.Errorf("Failed to requeue certificates for %s: %v", tx.TransactionHash, err)
+		}
+	}
+
+	if err := db.Model(&BlockchainCertificate{}).Where("transaction_hash = ?", tx.TransactionHash).
+		Updates(updates).Error; err != nil {
+		logger.Errorf("Failed to update certificates for %s: %v", tx.TransactionHash, err)
+	}
+
+	delete(updates, "gas_used")
+	if err := db.Model(&PointsTransactionHistory{}).Where("transaction_hash = ?", tx.TransactionHash).
+		Updates(updates).Error; err != nil {
+		logger.Errorf("Failed to update points transactions for %s: %v", tx.TransactionHash, err)
+	}
+
+	if receipt != nil && status == TransactionStatusConfirmed {
+		if err := db.Model(&ResumeModel{}).Where("transaction_hash = ?", tx.TransactionHash).
+			Update("block_number", receipt.BlockNumber).Error; err != nil {
+			logger.Errorf("Failed to update resumes for %s: %v", tx.TransactionHash, err)
+		}
+	}
+}
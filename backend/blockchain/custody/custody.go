@@ -0,0 +1,235 @@
+// Package custody 托管钱包私钥：私钥以信封加密方式保存（数据密钥加密私钥，
+// 主密钥经KMS包装数据密钥），签名仅在本组件内完成，明文私钥不离开内存。
+package custody
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	ErrInvalidPrivateKey = errors.New("custody: invalid private key")
+	ErrAddressMismatch   = errors.New("custody: sealed key does not match address")
+)
+
+// 加密保存的私钥
+type SealedKey struct {
+	Address    string // 0x开头的以太坊地址，作为附加认证数据绑定密文
+	Ciphertext []byte // 数据密钥加密的私钥：nonce||AES-GCM密文
+	WrappedDEK []byte // 主密钥包装的数据密钥
+	KeyID      string // 包装数据密钥的主密钥版本
+}
+
+// 新生成的密钥（不含明文私钥）
+type GeneratedKey struct {
+	Address   string
+	PublicKey string // 0x04开头的未压缩公钥
+	Sealed    *SealedKey
+}
+
+// 密钥托管组件
+type Custody struct {
+	kms KMS
+}
+
+func New(kms KMS) *Custody {
+	return &Custody{kms: kms}
+}
+
+func (c *Custody) KMS() KMS {
+	return c.kms
+}
+
+// 生成secp256k1密钥并加密保存
+func (c *Custody) GenerateKey(ctx context.Context) (*GeneratedKey, error) {
+	priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	defer priv.Zero()
+	return c.seal(ctx, priv)
+}
+
+// 导入已有私钥（32字节）并加密保存
+func (c *Custody) Import(ctx context.Context, key []byte) (*GeneratedKey, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidPrivateKey
+	}
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(key); overflow || scalar.IsZero() {
+		return nil, ErrInvalidPrivateKey
+	}
+	priv := secp256k1.NewPrivateKey(&scalar)
+	defer priv.Zero()
+	return c.seal(ctx, priv)
+}
+
+func (c *Custody) seal(ctx context.Context, priv *secp256k1.PrivateKey) (*GeneratedKey, error) {
+	pub := priv.PubKey()
+	address := PubkeyToAddress(pub)
+
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	defer zero(dek)
+
+	raw := priv.Key.Bytes()
+	defer zero(raw[:])
+
+	ciphertext, err := sealAESGCM(dek, raw[:], addressAAD(address))
+	if err != nil {
+		return nil, err
+	}
+	wrapped, keyID, err := c.kms.WrapKey(ctx, dek)
+	if err != nil {
+		return nil, fmt.Errorf("custody: failed to wrap data key: %v", err)
+	}
+
+	return &GeneratedKey{
+		Address:   address,
+		PublicKey: "0x" + hex.EncodeToString(pub.SerializeUncompressed()),
+		Sealed: &SealedKey{
+			Address:    address,
+			Ciphertext: ciphertext,
+			WrappedDEK: wrapped,
+			KeyID:      keyID,
+		},
+	}, nil
+}
+
+// 用当前主密钥重新包装数据密钥（主密钥轮换），私钥密文不变
+func (c *Custody) Rewrap(ctx context.Context, sealed *SealedKey) (*SealedKey, error) {
+	if sealed.KeyID == c.kms.CurrentKeyID() {
+		return sealed, nil
+	}
+	dek, err := c.kms.UnwrapKey(ctx, sealed.KeyID, sealed.WrappedDEK)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(dek)
+
+	wrapped, keyID, err := c.kms.WrapKey(ctx, dek)
+	if err != nil {
+		return nil, err
+	}
+	out := *sealed
+	out.WrappedDEK = wrapped
+	out.KeyID = keyID
+	return &out, nil
+}
+
+// 对32字节摘要签名，返回r||s||v（v为0或1）
+func (c *Custody) Sign(ctx context.Context, sealed *SealedKey, digest []byte) ([]byte, error) {
+	if len(digest) != 32 {
+		return nil, errors.New("custody: digest must be 32 bytes")
+	}
+	var sig []byte
+	err := c.withKey(ctx, sealed, func(priv *secp256k1.PrivateKey) error {
+		compact := ecdsa.SignCompact(priv, digest, false)
+		// SignCompact输出为 27+recid || r || s
+		sig = append(append(sig, compact[1:]...), compact[0]-27)
+		return nil
+	})
+	return sig, err
+}
+
+// 按以太坊personal_sign规则签名消息，v为27或28
+func (c *Custody) SignMessage(ctx context.Context, sealed *SealedKey, message []byte) ([]byte, error) {
+	sig, err := c.Sign(ctx, sealed, MessageHash(message))
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// 解密私钥并在回调中使用，结束后清零
+func (c *Custody) withKey(ctx context.Context, sealed *SealedKey, fn func(*secp256k1.PrivateKey) error) error {
+	dek, err := c.kms.UnwrapKey(ctx, sealed.KeyID, sealed.WrappedDEK)
+	if err != nil {
+		return err
+	}
+	defer zero(dek)
+
+	raw, err := openAESGCM(dek, sealed.Ciphertext, addressAAD(sealed.Address))
+	if err != nil {
+		return err
+	}
+	defer zero(raw)
+
+	priv := secp256k1.PrivKeyFromBytes(raw)
+	defer priv.Zero()
+	if !strings.EqualFold(PubkeyToAddress(priv.PubKey()), sealed.Address) {
+		return ErrAddressMismatch
+	}
+	return fn(priv)
+}
+
+// 以太坊personal_sign消息摘要
+func MessageHash(message []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return Keccak256([]byte(prefix), message)
+}
+
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// 由公钥计算EIP-55校验格式的以太坊地址
+func PubkeyToAddress(pub *secp256k1.PublicKey) string {
+	raw := pub.SerializeUncompressed()
+	return checksumAddress(Keccak256(raw[1:])[12:])
+}
+
+// 由签名恢复地址，签名格式为r||s||v（v为0/1或27/28）
+func RecoverAddress(digest, sig []byte) (string, error) {
+	if len(sig) != 65 {
+		return "", errors.New("custody: signature must be 65 bytes")
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	compact := append([]byte{v + 27}, sig[:64]...)
+	pub, _, err := ecdsa.RecoverCompact(compact, digest)
+	if err != nil {
+		return "", err
+	}
+	return PubkeyToAddress(pub), nil
+}
+
+func checksumAddress(addr []byte) string {
+	lower := hex.EncodeToString(addr)
+	hash := hex.EncodeToString(Keccak256([]byte(lower)))
+	out := []byte(lower)
+	for i, ch := range out {
+		if ch >= 'a' && ch <= 'f' && hash[i] >= '8' {
+			out[i] = ch - 32
+		}
+	}
+	return "0x" + string(out)
+}
+
+func addressAAD(address string) []byte {
+	return []byte(strings.ToLower(address))
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,153 @@
+package custody
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestCustody(t *testing.T) (*Custody, *LocalKMS, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys", "master.json")
+	kms, err := OpenLocalKMS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(kms), kms, path
+}
+
+// EIP-155规范中的示例交易
+func TestSignLegacyTxEIP155Vector(t *testing.T) {
+	c, _, _ := newTestCustody(t)
+	ctx := context.Background()
+
+	key, _ := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	generated, err := c.Import(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generated.Address != "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F" {
+		t.Fatalf("unexpected address %s", generated.Address)
+	}
+
+	to, _ := hex.DecodeString("3535353535353535353535353535353535353535")
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	raw, _, err := c.SignLegacyTx(ctx, generated.Sealed, &LegacyTx{
+		Nonce:    9,
+		GasPrice: 20000000000,
+		GasLimit: 21000,
+		To:       to,
+		Value:    value,
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	if hex.EncodeToString(raw) != expected {
+		t.Fatalf("unexpected raw transaction\n got %x\nwant %s", raw, expected)
+	}
+}
+
+func TestGenerateSignAndRotate(t *testing.T) {
+	c, kms, path := newTestCustody(t)
+	ctx := context.Background()
+
+	generated, err := c.GenerateKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := generated.Sealed
+	// nonce(12) + 私钥(32) + GCM标签(16)
+	if len(sealed.Ciphertext) != 60 {
+		t.Fatalf("unexpected ciphertext length %d", len(sealed.Ciphertext))
+	}
+
+	sig, err := c.SignMessage(ctx, sealed, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := RecoverAddress(MessageHash([]byte("hello")), sig)
+	if err != nil || recovered != generated.Address {
+		t.Fatalf("recovered %s, want %s (%v)", recovered, generated.Address, err)
+	}
+
+	// 密文与地址绑定，替换地址后无法使用
+	swapped := *sealed
+	swapped.Address = "0x0000000000000000000000000000000000000001"
+	if _, err := c.SignMessage(ctx, &swapped, []byte("hello")); err == nil {
+		t.Fatal("sealed key accepted for a different address")
+	}
+
+	oldKeyID := sealed.KeyID
+	newKeyID, err := kms.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrapped, err := c.Rewrap(ctx, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewrapped.KeyID != newKeyID || !bytes.Equal(rewrapped.Ciphertext, sealed.Ciphertext) {
+		t.Fatal("rewrap should only change the wrapped data key")
+	}
+	if err := kms.Retire(ctx, newKeyID); err != ErrKeyInUse {
+		t.Fatalf("expected ErrKeyInUse, got %v", err)
+	}
+	if err := kms.Retire(ctx, oldKeyID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开密钥文件后仍可签名
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("keyfile permissions %o", info.Mode().Perm())
+	}
+	reopened, err := OpenLocalKMS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(reopened).SignMessage(ctx, rewrapped, []byte("again")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(reopened).SignMessage(ctx, sealed, []byte("again")); err != ErrUnknownMasterKey {
+		t.Fatalf("expected retired key to be unknown, got %v", err)
+	}
+}
+
+func TestKeystoreExport(t *testing.T) {
+	KeystoreScryptN = 1 << 10
+	defer func() { KeystoreScryptN = 1 << 18 }()
+
+	c, _, _ := newTestCustody(t)
+	ctx := context.Background()
+
+	key, _ := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	generated, err := c.Import(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := c.ExportKeystore(ctx, generated.Sealed, "correct horse battery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("4646464646")) {
+		t.Fatal("keystore contains plaintext key")
+	}
+
+	restored, err := DecryptKeystore(data, "correct horse battery")
+	if err != nil || !bytes.Equal(restored, key) {
+		t.Fatalf("keystore round trip failed: %v", err)
+	}
+	if _, err := DecryptKeystore(data, "wrong"); err != ErrKeystorePassphrase {
+		t.Fatalf("expected ErrKeystorePassphrase, got %v", err)
+	}
+}
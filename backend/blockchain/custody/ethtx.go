@@ -0,0 +1,82 @@
+package custody
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// 以太坊传统交易（EIP-155签名）
+type LegacyTx struct {
+	Nonce    uint64
+	GasPrice uint64
+	GasLimit uint64
+	To       []byte // 20字节地址，合约创建时为空
+	Value    *big.Int
+	Data     []byte
+}
+
+// 签名交易，返回RLP编码的原始交易及交易哈希
+func (c *Custody) SignLegacyTx(ctx context.Context, sealed *SealedKey, tx *LegacyTx, chainID uint64) ([]byte, string, error) {
+	value := tx.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	fields := [][]byte{
+		rlpUint(tx.Nonce),
+		rlpUint(tx.GasPrice),
+		rlpUint(tx.GasLimit),
+		rlpBytes(tx.To),
+		rlpBytes(value.Bytes()),
+		rlpBytes(tx.Data),
+	}
+	digest := Keccak256(rlpList(append(fields, rlpUint(chainID), rlpUint(0), rlpUint(0))...))
+
+	var raw []byte
+	err := c.withKey(ctx, sealed, func(priv *secp256k1.PrivateKey) error {
+		compact := ecdsa.SignCompact(priv, digest, false)
+		recID := uint64(compact[0] - 27)
+		v := new(big.Int).SetUint64(chainID*2 + 35 + recID)
+		r := new(big.Int).SetBytes(compact[1:33])
+		s := new(big.Int).SetBytes(compact[33:65])
+		raw = rlpList(append(fields, rlpBytes(v.Bytes()), rlpBytes(r.Bytes()), rlpBytes(s.Bytes()))...)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return raw, "0x" + hex.EncodeToString(Keccak256(raw)), nil
+}
+
+// RLP编码：字节串
+func rlpBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+// RLP编码：无符号整数（大端、无前导零）
+func rlpUint(v uint64) []byte {
+	return rlpBytes(new(big.Int).SetUint64(v).Bytes())
+}
+
+// RLP编码：列表，元素需已编码
+func rlpList(items ...[]byte) []byte {
+	var payload []byte
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(rlpHeader(0xc0, len(payload)), payload...)
+}
+
+func rlpHeader(offset byte, length int) []byte {
+	if length <= 55 {
+		return []byte{offset + byte(length)}
+	}
+	lenBytes := new(big.Int).SetInt64(int64(length)).Bytes()
+	return append([]byte{offset + 55 + byte(len(lenBytes))}, lenBytes...)
+}
@@ -0,0 +1,151 @@
+package custody
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt参数，与geth标准强度一致
+var (
+	KeystoreScryptN = 1 << 18
+	KeystoreScryptP = 1
+)
+
+const keystoreScryptR = 8
+
+var ErrKeystorePassphrase = errors.New("custody: keystore passphrase is incorrect")
+
+// Web3 Secret Storage v3格式的密钥文件，可导入geth、MetaMask等钱包
+type Keystore struct {
+	Address string         `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+	ID      string         `json:"id"`
+	Version int            `json:"version"`
+}
+
+type keystoreCrypto struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams map[string]string      `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+// 以管理员口令加密导出私钥
+func (c *Custody) ExportKeystore(ctx context.Context, sealed *SealedKey, passphrase string) ([]byte, error) {
+	var out []byte
+	err := c.withKey(ctx, sealed, func(priv *secp256k1.PrivateKey) error {
+		raw := priv.Key.Bytes()
+		defer zero(raw[:])
+
+		salt := make([]byte, 32)
+		iv := make([]byte, aes.BlockSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+			return err
+		}
+
+		derived, err := scrypt.Key([]byte(passphrase), salt, KeystoreScryptN, keystoreScryptR, KeystoreScryptP, 32)
+		if err != nil {
+			return err
+		}
+		defer zero(derived)
+
+		ciphertext, err := aesCTR(derived[:16], iv, raw[:])
+		if err != nil {
+			return err
+		}
+
+		ks := Keystore{
+			Address: strings.ToLower(strings.TrimPrefix(sealed.Address, "0x")),
+			Crypto: keystoreCrypto{
+				Cipher:       "aes-128-ctr",
+				CipherText:   hex.EncodeToString(ciphertext),
+				CipherParams: map[string]string{"iv": hex.EncodeToString(iv)},
+				KDF:          "scrypt",
+				KDFParams: map[string]interface{}{
+					"dklen": 32,
+					"n":     KeystoreScryptN,
+					"p":     KeystoreScryptP,
+					"r":     keystoreScryptR,
+					"salt":  hex.EncodeToString(salt),
+				},
+				MAC: hex.EncodeToString(Keccak256(derived[16:32], ciphertext)),
+			},
+			ID:      uuid.New().String(),
+			Version: 3,
+		}
+		out, err = json.Marshal(ks)
+		return err
+	})
+	return out, err
+}
+
+// 解密密钥文件，返回32字节私钥（用于灾备恢复时重新导入）
+func DecryptKeystore(data []byte, passphrase string) ([]byte, error) {
+	var ks Keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, err
+	}
+	if ks.Version != 3 || ks.Crypto.KDF != "scrypt" || ks.Crypto.Cipher != "aes-128-ctr" {
+		return nil, errors.New("custody: unsupported keystore format")
+	}
+
+	params := ks.Crypto.KDFParams
+	saltHex, _ := params["salt"].(string)
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := params["n"].(float64)
+	r, _ := params["r"].(float64)
+	p, _ := params["p"].(float64)
+	derived, err := scrypt.Key([]byte(passphrase), salt, int(n), int(r), int(p), 32)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(derived)
+
+	ciphertext, err := hex.DecodeString(ks.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := hex.DecodeString(ks.Crypto.MAC)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(Keccak256(derived[16:32], ciphertext), mac) != 1 {
+		return nil, ErrKeystorePassphrase
+	}
+
+	iv, err := hex.DecodeString(ks.Crypto.CipherParams["iv"])
+	if err != nil {
+		return nil, err
+	}
+	return aesCTR(derived[:16], iv, ciphertext)
+}
+
+func aesCTR(key, iv, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
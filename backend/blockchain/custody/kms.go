@@ -0,0 +1,230 @@
+package custody
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownMasterKey = errors.New("custody: unknown master key")
+	ErrKeyInUse         = errors.New("custody: master key is still current")
+)
+
+// 主密钥管理接口（KMS风格），仅负责包装和解包数据密钥，主密钥本身不出KMS。
+// 后续接入HSM或云KMS时实现该接口即可。
+type KMS interface {
+	// 当前用于包装新数据密钥的主密钥版本
+	CurrentKeyID() string
+	WrapKey(ctx context.Context, dek []byte) (wrapped []byte, keyID string, err error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// 支持主密钥轮换的KMS
+type RotatableKMS interface {
+	KMS
+	Rotate(ctx context.Context) (string, error)
+	Retire(ctx context.Context, keyID string) error
+}
+
+type masterKey struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"` // base64编码的256位密钥
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type keyfile struct {
+	CurrentKeyID string      `json:"current_key_id"`
+	Keys         []masterKey `json:"keys"`
+}
+
+// 本地密钥文件KMS：主密钥保存在权限为0600的本地文件中
+type LocalKMS struct {
+	mu      sync.RWMutex
+	path    string
+	current string
+	keys    map[string][]byte
+	order   []masterKey
+}
+
+// 打开本地密钥文件，不存在时生成首个主密钥
+func OpenLocalKMS(path string) (*LocalKMS, error) {
+	k := &LocalKMS{path: path, keys: map[string][]byte{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		if _, err := k.Rotate(context.Background()); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyfile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("custody: invalid keyfile %s: %v", path, err)
+	}
+	for _, mk := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(mk.Key)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("custody: invalid master key %s in %s", mk.ID, path)
+		}
+		k.keys[mk.ID] = key
+		k.order = append(k.order, mk)
+	}
+	if _, ok := k.keys[file.CurrentKeyID]; !ok {
+		return nil, fmt.Errorf("custody: current master key %q missing from %s", file.CurrentKeyID, path)
+	}
+	k.current = file.CurrentKeyID
+	return k, nil
+}
+
+func (k *LocalKMS) CurrentKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+func (k *LocalKMS) WrapKey(ctx context.Context, dek []byte) ([]byte, string, error) {
+	k.mu.RLock()
+	keyID, key := k.current, k.keys[k.current]
+	k.mu.RUnlock()
+
+	wrapped, err := sealAESGCM(key, dek, []byte(keyID))
+	if err != nil {
+		return nil, "", err
+	}
+	return wrapped, keyID, nil
+}
+
+func (k *LocalKMS) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	k.mu.RLock()
+	key, ok := k.keys[keyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownMasterKey
+	}
+	return openAESGCM(key, wrapped, []byte(keyID))
+}
+
+// 生成新主密钥并设为当前版本，旧版本保留用于解包
+func (k *LocalKMS) Rotate(ctx context.Context) (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	id := fmt.Sprintf("mk-%d", len(k.order)+1)
+	for k.keys[id] != nil {
+		id += "a"
+	}
+	k.keys[id] = key
+	k.order = append(k.order, masterKey{ID: id, Key: base64.StdEncoding.EncodeToString(key), CreatedAt: time.Now()})
+	previous := k.current
+	k.current = id
+
+	if err := k.persistLocked(); err != nil {
+		delete(k.keys, id)
+		k.order = k.order[:len(k.order)-1]
+		k.current = previous
+		return "", err
+	}
+	return id, nil
+}
+
+// 删除不再使用的旧主密钥，调用方需确保已无数据密钥由其包装
+func (k *LocalKMS) Retire(ctx context.Context, keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if keyID == k.current {
+		return ErrKeyInUse
+	}
+	if _, ok := k.keys[keyID]; !ok {
+		return ErrUnknownMasterKey
+	}
+
+	order := k.order
+	k.order = nil
+	for _, mk := range order {
+		if mk.ID != keyID {
+			k.order = append(k.order, mk)
+		}
+	}
+	key := k.keys[keyID]
+	delete(k.keys, keyID)
+
+	if err := k.persistLocked(); err != nil {
+		k.order = order
+		k.keys[keyID] = key
+		return err
+	}
+	return nil
+}
+
+// 原子写入密钥文件
+func (k *LocalKMS) persistLocked() error {
+	data, err := json.MarshalIndent(keyfile{CurrentKeyID: k.current, Keys: k.order}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, k.path)
+}
+
+// AES-256-GCM加密，输出nonce||密文
+func sealAESGCM(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func openAESGCM(key, sealed, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("custody: ciphertext too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], aad)
+	if err != nil {
+		return nil, errors.New("custody: decryption failed")
+	}
+	return plaintext, nil
+}
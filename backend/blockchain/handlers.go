@@ -3,11 +3,16 @@ package main
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // 创建区块链证书
@@ -23,7 +28,7 @@ func createCertificate(c *gin.Context) {
 		Title          string `json:"title" binding:"required"`
 		Description    string `json:"description"`
 		Content        string `json:"content" binding:"required"`
-		BlockchainType string `json:"blockchain_type" binding:"required"`
+		BlockchainType string `json:"blockchain_type"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -31,6 +36,12 @@ func createCertificate(c *gin.Context) {
 		return
 	}
 
+	adapter, err := getChainAdapter(BlockchainType(req.BlockchainType))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 生成内容哈希
 	contentHash := generateContentHash(req.Content)
 
@@ -43,29 +54,18 @@ func createCertificate(c *gin.Context) {
 		Description:    req.Description,
 		Content:        req.Content,
 		Hash:           contentHash,
-		BlockchainType: BlockchainType(req.BlockchainType),
+		BlockchainType: adapter.Type(),
 		Status:         TransactionStatusPending,
+		IssuedAt:       time.Now(),
 	}
 
+	// 证书由批量锚定任务统一上链
 	if err := db.Create(&certificate).Error; err != nil {
 		logger.Errorf("Failed to create certificate: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create certificate"})
 		return
 	}
 
-	// 模拟区块链交易
-	go func() {
-		// 模拟区块链交易延迟
-		time.Sleep(2 * time.Second)
-
-		// 更新交易哈希和状态
-		db.Model(&certificate).Updates(map[string]interface{}{
-			"transaction_hash": "mock_tx_" + certificate.ID,
-			"status":           TransactionStatusConfirmed,
-			"block_number":     12345,
-		})
-	}()
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message":     "Certificate created successfully",
 		"certificate": certificate,
@@ -160,6 +160,19 @@ func updateCertificate(c *gin.Context) {
 		updates["hash"] = generateContentHash(req.Content)
 	}
 
+	// 标题或内容变更视为重新签发，需在下一批次重新锚定
+	if (req.Title != "" && req.Title != certificate.Title) || (req.Content != "" && req.Content != certificate.Content) {
+		updates["issued_at"] = time.Now()
+		updates["batch_id"] = ""
+		updates["leaf_index"] = 0
+		updates["leaf_hash"] = ""
+		updates["inclusion_proof"] = ""
+		updates["transaction_hash"] = ""
+		updates["block_number"] = 0
+		updates["gas_used"] = 0
+		updates["status"] = TransactionStatusPending
+	}
+
 	if err := db.Model(&certificate).Updates(updates).Error; err != nil {
 		logger.Errorf("Failed to update certificate: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update certificate"})
@@ -194,7 +207,7 @@ func deleteCertificate(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Certificate deleted successfully"})
 }
 
-// 验证证书
+// 验证证书（公开接口）：校验内容哈希、Merkle包含证明及链上锚定
 func verifyCertificate(c *gin.Context) {
 	certID := c.Param("id")
 	var certificate BlockchainCertificate
@@ -204,22 +217,21 @@ func verifyCertificate(c *gin.Context) {
 		return
 	}
 
-	// 验证内容哈希
-	expectedHash := generateContentHash(certificate.Content)
-	isValid := certificate.Hash == expectedHash
-
-	// 模拟区块链状态查询
-	var blockchainStatus string
-	if certificate.TransactionHash != "" {
-		blockchainStatus = "confirmed"
-	} else {
-		blockchainStatus = "pending"
-	}
+	result := verifyStoredCertificate(c.Request.Context(), &certificate)
 
 	c.JSON(http.StatusOK, gin.H{
-		"certificate":       certificate,
-		"is_valid":          isValid,
-		"blockchain_status": blockchainStatus,
+		"certificate": gin.H{
+			"id":           certificate.ID,
+			"type":         certificate.Type,
+			"title":        certificate.Title,
+			"hash":         certificate.Hash,
+			"issued_at":    certificate.IssuedAt,
+			"batch_id":     certificate.BatchID,
+			"block_number": certificate.BlockNumber,
+		},
+		"is_valid":          result.Valid,
+		"verification":      result,
+		"blockchain_status": certificate.Status,
 	})
 }
 
@@ -275,7 +287,7 @@ func createTransaction(c *gin.Context) {
 		FromAddress    string `json:"from_address" binding:"required"`
 		ToAddress      string `json:"to_address" binding:"required"`
 		Value          string `json:"value" binding:"required"`
-		BlockchainType string `json:"blockchain_type" binding:"required"`
+		BlockchainType string `json:"blockchain_type"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -283,20 +295,20 @@ func createTransaction(c *gin.Context) {
 		return
 	}
 
-	transaction := BlockchainTransaction{
-		ID:             uuid.New().String(),
-		UserID:         userID,
-		Type:           req.Type,
-		BlockchainType: BlockchainType(req.BlockchainType),
-		FromAddress:    req.FromAddress,
-		ToAddress:      req.ToAddress,
-		Value:          req.Value,
-		Status:         TransactionStatusPending,
+	adapter, err := getChainAdapter(BlockchainType(req.BlockchainType))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := db.Create(&transaction).Error; err != nil {
+	transaction, err := submitChainTransaction(c.Request.Context(), adapter, userID, req.Type, &ChainTx{
+		From:  req.FromAddress,
+		To:    req.ToAddress,
+		Value: req.Value,
+	})
+	if err != nil {
 		logger.Errorf("Failed to create transaction: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		c.JSON(chainErrorStatus(err), gin.H{"error": "Failed to create transaction"})
 		return
 	}
 
@@ -323,21 +335,15 @@ func createWallet(c *gin.Context) {
 		return
 	}
 
-	// 生成钱包地址和私钥（这里简化处理）
-	walletAddress := generateWalletAddress()
-	privateKey := generatePrivateKey()
-
-	wallet := Wallet{
-		ID:             uuid.New().String(),
-		UserID:         userID,
-		Address:        walletAddress,
-		PrivateKey:     privateKey,
-		BlockchainType: BlockchainType(req.BlockchainType),
-		Balance:        "0",
-		IsActive:       true,
+	// 私钥由托管组件生成并加密保存，不以明文落库
+	wallet, err := newCustodyWallet(c.Request.Context(), userID, BlockchainType(req.BlockchainType))
+	if err != nil {
+		logger.Errorf("Failed to generate wallet key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create wallet"})
+		return
 	}
 
-	if err := db.Create(&wallet).Error; err != nil {
+	if err := db.Create(wallet).Error; err != nil {
 		logger.Errorf("Failed to create wallet: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create wallet"})
 		return
@@ -497,6 +503,7 @@ func invokeContract(c *gin.Context) {
 	var req struct {
 		Function string   `json:"function" binding:"required"`
 		Args     []string `json:"args"`
+		Data     string   `json:"data"` // 已编码的调用数据（十六进制），为空时按函数名和参数编码
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -504,12 +511,32 @@ func invokeContract(c *gin.Context) {
 		return
 	}
 
-	// 模拟智能合约调用
-	txid := "mock_tx_" + uuid.New().String()
+	data, err := contractCallData(req.Function, req.Args, req.Data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adapter, err := getChainAdapter(contract.BlockchainType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := submitChainTransaction(c.Request.Context(), adapter, userID, "contract_invoke", &ChainTx{
+		To:   contract.Address,
+		Data: data,
+	})
+	if err != nil {
+		logger.Errorf("Failed to invoke contract %s: %v", contract.ID, err)
+		c.JSON(chainErrorStatus(err), gin.H{"error": "Failed to invoke contract"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Contract invoked successfully",
-		"txid":    txid,
+		"message":     "Contract invoked successfully",
+		"txid":        tx.TransactionHash,
+		"transaction": tx,
 	})
 }
 
@@ -526,6 +553,7 @@ func queryContract(c *gin.Context) {
 	var req struct {
 		Function string   `json:"function" binding:"required"`
 		Args     []string `json:"args"`
+		Data     string   `json:"data"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -533,11 +561,34 @@ func queryContract(c *gin.Context) {
 		return
 	}
 
-	// 模拟智能合约查询
+	adapter, err := getChainAdapter(contract.BlockchainType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	caller, ok := adapter.(ContractCaller)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": ErrQueryNotSupported.Error()})
+		return
+	}
+
+	data, err := contractCallData(req.Function, req.Args, req.Data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	output, err := caller.Call(c.Request.Context(), contract.Address, data)
+	if err != nil {
+		logger.Errorf("Failed to query contract %s: %v", contract.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to query contract"})
+		return
+	}
+
 	result := map[string]interface{}{
 		"function": req.Function,
 		"args":     req.Args,
-		"result":   "mock_query_result",
+		"result":   "0x" + hex.EncodeToString(output),
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -565,28 +616,22 @@ func savePointsTransaction(c *gin.Context) {
 		txHistory.CreateTime = time.Now()
 	}
 
-	// 设置区块链类型为腾讯云
-	txHistory.BlockchainType = BlockchainTypeTencent
+	txHistory.BlockchainType = defaultBlockchainType()
 	txHistory.Status = TransactionStatusPending
 
-	// 保存到数据库
-	if err := db.Create(&txHistory).Error; err != nil {
+	// 接入审计哈希链后保存
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return appendPointsHistory(tx, &txHistory)
+	}); err != nil {
 		logger.Errorf("Failed to save points transaction: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save transaction"})
 		return
 	}
 
-	// 模拟区块链交易
-	go func() {
-		// 模拟区块链交易延迟
-		time.Sleep(2 * time.Second)
-
-		// 更新交易哈希和状态
-		db.Model(&txHistory).Updates(map[string]interface{}{
-			"transaction_hash": "mock_tx_" + txHistory.TransactionHistoryID,
-			"status":           TransactionStatusConfirmed,
-		})
-	}()
+	if err := anchorPointsTransaction(c, &txHistory); err != nil {
+		c.JSON(chainErrorStatus(err), gin.H{"error": "Failed to anchor transaction on chain"})
+		return
+	}
 
 	response := PointsTxSaveResp{
 		TxId:    txHistory.TransactionHistoryID,
@@ -700,28 +745,23 @@ func transferPoints(c *gin.Context) {
 		TransactionCode:      1, // 转账
 		TransactionContent:   "积分转账",
 		CreateTime:           time.Now(),
-		BlockchainType:       BlockchainTypeTencent,
+		BlockchainType:       defaultBlockchainType(),
 		Status:               TransactionStatusPending,
 	}
 
-	// 保存到数据库
-	if err := db.Create(&txHistory).Error; err != nil {
+	// 接入审计哈希链后保存
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return appendPointsHistory(tx, &txHistory)
+	}); err != nil {
 		logger.Errorf("Failed to create transfer transaction: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transfer"})
 		return
 	}
 
-	// 模拟区块链交易
-	go func() {
-		// 模拟区块链交易延迟
-		time.Sleep(2 * time.Second)
-
-		// 更新交易哈希和状态
-		db.Model(&txHistory).Updates(map[string]interface{}{
-			"transaction_hash": "mock_tx_" + txHistory.TransactionHistoryID,
-			"status":           TransactionStatusConfirmed,
-		})
-	}()
+	if err := anchorPointsTransaction(c, &txHistory); err != nil {
+		c.JSON(chainErrorStatus(err), gin.H{"error": "Failed to anchor transfer on chain"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Transfer initiated successfully",
@@ -744,6 +784,11 @@ func createResumeOnBlockchain(c *gin.Context) {
 	}
 
 	resumeModel.ID = resumeId
+	resumeModel.BlockchainType = strings.ToLower(string(resumeModel.chainType()))
+	if _, err := getChainAdapter(resumeModel.chainType()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	resumeModel.Hash = generateContentHash(resumeModel.Content)
 	resumeModel.Status = "active"
 	resumeModel.CreatedAt = time.Now()
@@ -756,14 +801,17 @@ func createResumeOnBlockchain(c *gin.Context) {
 		return
 	}
 
-	// 模拟区块链交易
-	go func() {
-		// 模拟区块链交易延迟
-		time.Sleep(2 * time.Second)
-
-		// 更新区块号
-		db.Model(&resumeModel).Update("block_number", 12345)
-	}()
+	// 锚定简历摘要，区块号由确认跟踪器回填
+	userID, _ := strconv.ParseUint(resumeModel.UserID, 10, 64)
+	tx, err := anchorRecord(c.Request.Context(), resumeModel.chainType(), uint(userID), "resume", resumeModel.ID, resumeModel.Hash)
+	if err != nil {
+		logger.Errorf("Failed to anchor resume %s: %v", resumeModel.ID, err)
+		c.JSON(chainErrorStatus(err), gin.H{"error": "Failed to anchor resume on chain"})
+		return
+	}
+	if err := db.Model(&resumeModel).Update("transaction_hash", tx.TransactionHash).Error; err != nil {
+		logger.Errorf("Failed to update resume transaction: %v", err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Resume created successfully",
@@ -781,13 +829,20 @@ func getResumeFromBlockchain(c *gin.Context) {
 		return
 	}
 
-	// 模拟区块链验证
-	go func() {
-		logger.Infof("Mock: Verifying resume %s on blockchain", resumeModel.ID)
-	}()
+	// 核对链上锚定的摘要与当前内容
+	verified := false
+	if resumeModel.TransactionHash != "" {
+		anchoredHash, _, err := queryAnchoredHash(c.Request.Context(), resumeModel.chainType(), resumeModel.TransactionHash)
+		if err != nil {
+			logger.Warnf("Failed to query anchored resume %s: %v", resumeModel.ID, err)
+		} else {
+			verified = anchoredHash == generateContentHash(resumeModel.Content)
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": resumeModel,
+		"data":     resumeModel,
+		"verified": verified,
 	})
 }
 
@@ -811,30 +866,50 @@ func deleteResumeFromBlockchain(c *gin.Context) {
 		return
 	}
 
-	// 模拟区块链删除
-	go func() {
-		logger.Infof("Mock: Deleting resume %s from blockchain", resumeModel.ID)
-	}()
+	// 链上数据不可删除，追加一条删除记录
+	userID, _ := strconv.ParseUint(resumeModel.UserID, 10, 64)
+	if _, err := anchorRecord(c.Request.Context(), resumeModel.chainType(), uint(userID), "resume_delete", resumeModel.ID, resumeModel.Hash); err != nil {
+		logger.Errorf("Failed to anchor resume deletion %s: %v", resumeModel.ID, err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Resume deleted successfully",
 	})
 }
 
-// 辅助函数
-func generateContentHash(content string) string {
-	hash := sha256.Sum256([]byte(content))
-	return hex.EncodeToString(hash[:])
+// 锚定积分交易记录的链哈希，失败时标记记录失败
+func anchorPointsTransaction(c *gin.Context, txHistory *PointsTransactionHistory) error {
+	userID, _ := strconv.ParseUint(txHistory.FromUserId, 10, 64)
+	tx, err := anchorRecord(c.Request.Context(), txHistory.BlockchainType, uint(userID), "points", txHistory.TransactionHistoryID, txHistory.RecordHash)
+	if err != nil {
+		logger.Errorf("Failed to anchor points transaction %s: %v", txHistory.TransactionHistoryID, err)
+		db.Model(txHistory).Update("status", TransactionStatusFailed)
+		return err
+	}
+
+	txHistory.TransactionHash = tx.TransactionHash
+	if err := db.Model(txHistory).Update("transaction_hash", tx.TransactionHash).Error; err != nil {
+		logger.Errorf("Failed to update points transaction hash: %v", err)
+	}
+	return nil
 }
 
-func generateWalletAddress() string {
-	// 简化实现，实际应该使用椭圆曲线加密
-	return "0x" + hex.EncodeToString([]byte(uuid.New().String()))[:40]
+// 合约调用数据：优先使用已编码的十六进制数据，否则以JSON编码函数名和参数
+func contractCallData(function string, args []string, encoded string) ([]byte, error) {
+	if encoded != "" {
+		data, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid call data: %v", err)
+		}
+		return data, nil
+	}
+	return json.Marshal(map[string]interface{}{"function": function, "args": args})
 }
 
-func generatePrivateKey() string {
-	// 简化实现，实际应该使用椭圆曲线加密
-	return hex.EncodeToString([]byte(uuid.New().String()))[:64]
+// 辅助函数
+func generateContentHash(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
 }
 
 func generateContractAddress() string {
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -41,6 +42,16 @@ func main() {
 		logger.Fatalf("Failed to init database: %v", err)
 	}
 
+	// 初始化密钥托管（需在链适配器之前，系统钱包用于交易签名）
+	if err := initKeyCustody(); err != nil {
+		logger.Fatalf("Failed to init key custody: %v", err)
+	}
+
+	// 初始化链适配器
+	if err := initChainAdapters(); err != nil {
+		logger.Fatalf("Failed to init chain adapters: %v", err)
+	}
+
 	// 初始化Consul客户端
 	if err := initConsulClient(); err != nil {
 		logger.Fatalf("Failed to init consul client: %v", err)
@@ -56,6 +67,11 @@ func main() {
 		logger.Fatalf("Failed to register service: %v", err)
 	}
 
+	// 启动交易确认跟踪任务
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	startConfirmationTracker(jobCtx)
+	startCertificateBatcher(jobCtx)
+
 	// 启动HTTP服务器
 	router := setupRouter()
 	port := viper.GetString("server.port")
@@ -94,6 +110,9 @@ func main() {
 		logger.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	stopJobs()
+	closeChainAdapters()
+
 	logger.Info("Server exited")
 }
 
@@ -123,6 +142,23 @@ func loadConfig() error {
 	viper.SetDefault("tencent.blockchain.channel_id", "")
 	viper.SetDefault("tencent.blockchain.chaincode_id", "")
 
+	// 链适配器配置默认值
+	viper.SetDefault("blockchain.default_type", "local")
+	viper.SetDefault("blockchain.local.data_dir", "./data/ledger")
+	viper.SetDefault("blockchain.local.batch_size", 100)
+	viper.SetDefault("blockchain.local.block_interval", "2s")
+	viper.SetDefault("blockchain.local.gas_price", 0)
+	viper.SetDefault("blockchain.tracker.interval", "5s")
+	viper.SetDefault("blockchain.tracker.batch_size", 100)
+	viper.SetDefault("blockchain.tracker.confirmations", 1)
+	viper.SetDefault("blockchain.tracker.tx_timeout", "30m")
+	viper.SetDefault("blockchain.certificates.batch_interval", "1m")
+	viper.SetDefault("blockchain.certificates.max_batch_size", 1000)
+	viper.SetDefault("blockchain.audit.verify_batch_size", 1000)
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
+	viper.SetDefault("custody.keyfile", "./data/keys/master.json")
+	viper.SetDefault("custody.admin_token", "")
+
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return err
@@ -153,15 +189,22 @@ func initDatabase() error {
 	// 自动迁移数据库表
 	if err := db.AutoMigrate(
 		&BlockchainCertificate{},
+		&CertificateBatch{},
 		&BlockchainTransaction{},
 		&Wallet{},
 		&SmartContract{},
 		&BlockchainConfig{},
 		&PointsTransactionHistory{},
+		&ResumeModel{},
+		&PointsChainHead{},
 	); err != nil {
 		return err
 	}
 
+	if err := initPointsAuditChain(); err != nil {
+		return fmt.Errorf("failed to init points audit chain: %v", err)
+	}
+
 	logger.Info("Database initialized successfully")
 	return nil
 }
@@ -228,6 +271,7 @@ func setupRouter() *gin.Engine {
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(corsMiddleware())
+	router.Use(userContextMiddleware())
 
 	// 健康检查
 	router.GET("/health", healthCheck)
@@ -235,6 +279,14 @@ func setupRouter() *gin.Engine {
 	// 区块链服务路由组 - 完全兼容原有系统
 	blockchain := router.Group("/blockchain")
 	{
+		// 公开的证书校验接口，无需登录
+		public := blockchain.Group("/public/certificates")
+		{
+			public.GET("/:id/verify", verifyCertificate)
+			public.GET("/:id/proof", getCertificateProof)
+			public.POST("/verify", verifyCertificateBundle)
+		}
+
 		// 区块链证书相关
 		certificates := blockchain.Group("/certificates")
 		{
@@ -261,6 +313,7 @@ func setupRouter() *gin.Engine {
 			wallets.GET("/", listWallets)
 			wallets.GET("/:id", getWallet)
 			wallets.GET("/:id/balance", getWalletBalance)
+			wallets.POST("/:id/sign", signWalletMessage)
 		}
 
 		// 智能合约相关
@@ -280,6 +333,7 @@ func setupRouter() *gin.Engine {
 			points.GET("/tx/:id", getPointsTransaction)
 			points.GET("/balance/:userId", getPointsBalance)
 			points.POST("/transfer", transferPoints)
+			points.GET("/audit/verify", internalAuthMiddleware(), verifyPointsAudit)
 		}
 
 		// 简历相关 (兼容原有API)
@@ -289,11 +343,34 @@ func setupRouter() *gin.Engine {
 			resume.GET("/:id", getResumeFromBlockchain)
 			resume.DELETE("/:id", deleteResumeFromBlockchain)
 		}
+
+		// 服务间调用：为其他服务锚定摘要
+		internal := blockchain.Group("/internal", internalAuthMiddleware())
+		{
+			internal.POST("/anchors", createAnchor)
+			internal.GET("/anchors/:txHash", getAnchor)
+		}
+
+		// 密钥托管管理
+		admin := blockchain.Group("/admin", adminAuthMiddleware())
+		{
+			admin.POST("/keys/rotate", rotateMasterKey)
+			admin.DELETE("/keys/:keyId", retireMasterKey)
+			admin.POST("/wallets/:id/export", exportWalletKey)
+		}
 	}
 
 	// API路由组 (保持原有兼容性)
 	api := router.Group("/api/v1")
 	{
+		// 公开的证书校验接口，无需登录
+		public := api.Group("/public/certificates")
+		{
+			public.GET("/:id/verify", verifyCertificate)
+			public.GET("/:id/proof", getCertificateProof)
+			public.POST("/verify", verifyCertificateBundle)
+		}
+
 		// 区块链证书相关
 		certificates := api.Group("/certificates")
 		{
@@ -320,6 +397,7 @@ func setupRouter() *gin.Engine {
 			wallets.GET("/", listWallets)
 			wallets.GET("/:id", getWallet)
 			wallets.GET("/:id/balance", getWalletBalance)
+			wallets.POST("/:id/sign", signWalletMessage)
 		}
 
 		// 智能合约相关
@@ -339,6 +417,7 @@ func setupRouter() *gin.Engine {
 			points.GET("/tx/:id", getPointsTransaction)
 			points.GET("/balance/:userId", getPointsBalance)
 			points.POST("/transfer", transferPoints)
+			points.GET("/audit/verify", internalAuthMiddleware(), verifyPointsAudit)
 		}
 
 		// 简历相关 (兼容原有API)
@@ -348,6 +427,14 @@ func setupRouter() *gin.Engine {
 			resume.GET("/:id", getResumeFromBlockchain)
 			resume.DELETE("/:id", deleteResumeFromBlockchain)
 		}
+
+		// 密钥托管管理
+		admin := api.Group("/admin", adminAuthMiddleware())
+		{
+			admin.POST("/keys/rotate", rotateMasterKey)
+			admin.DELETE("/keys/:keyId", retireMasterKey)
+			admin.POST("/wallets/:id/export", exportWalletKey)
+		}
 	}
 
 	return router
@@ -368,12 +455,32 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// 网关转发的用户身份：X-User-ID只有与服务间令牌一起传递时才可信，否则忽略
+func userContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !validServiceToken(c) {
+			c.Next()
+			return
+		}
+		if id, err := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 64); err == nil && id > 0 {
+			c.Set("user_id", uint(id))
+		}
+		c.Next()
+	}
+}
+
 func healthCheck(c *gin.Context) {
+	adapters := make([]BlockchainType, 0, len(chainAdapters))
+	for chainType := range chainAdapters {
+		adapters = append(adapters, chainType)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "healthy",
 		"service":   "blockchain",
 		"timestamp": time.Now().Unix(),
 		"version":   "2.0.0",
-		"provider":  "tencent-cloud",
+		"provider":  defaultBlockchainType(),
+		"adapters":  adapters,
 	})
 }
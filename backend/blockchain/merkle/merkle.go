@@ -0,0 +1,138 @@
+// Package merkle 实现RFC 6962风格的Merkle树：叶子与内部节点使用不同前缀，
+// 奇数节点不复制，支持生成和校验包含证明。
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// 哈希长度
+const HashSize = sha256.Size
+
+// 节点哈希
+type Hash [HashSize]byte
+
+var (
+	leafPrefix = []byte{0x00}
+	nodePrefix = []byte{0x01}
+)
+
+var (
+	ErrEmptyTree    = errors.New("merkle: empty tree")
+	ErrIndexRange   = errors.New("merkle: leaf index out of range")
+	ErrInvalidHash  = errors.New("merkle: invalid hash")
+	ErrInvalidProof = errors.New("merkle: invalid inclusion proof")
+)
+
+// 计算叶子哈希
+func LeafHash(data []byte) Hash {
+	h := sha256.New()
+	h.Write(leafPrefix)
+	h.Write(data)
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// 计算内部节点哈希
+func NodeHash(left, right Hash) Hash {
+	h := sha256.New()
+	h.Write(nodePrefix)
+	h.Write(left[:])
+	h.Write(right[:])
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// 十六进制表示（带0x前缀）
+func (h Hash) Hex() string {
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+// 解析十六进制哈希，0x前缀可选
+func ParseHash(s string) (Hash, error) {
+	var out Hash
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(b) != HashSize {
+		return out, ErrInvalidHash
+	}
+	copy(out[:], b)
+	return out, nil
+}
+
+// 计算叶子哈希列表的树根
+func Root(leaves []Hash) (Hash, error) {
+	if len(leaves) == 0 {
+		return Hash{}, ErrEmptyTree
+	}
+	return subtreeRoot(leaves), nil
+}
+
+// 生成指定叶子的包含证明，证明路径自叶子向上排列
+func Proof(leaves []Hash, index int) ([]Hash, error) {
+	if len(leaves) == 0 {
+		return nil, ErrEmptyTree
+	}
+	if index < 0 || index >= len(leaves) {
+		return nil, ErrIndexRange
+	}
+	return path(index, leaves), nil
+}
+
+// 校验包含证明（RFC 9162 2.1.3.2）
+func Verify(leaf Hash, index, size uint64, proof []Hash, root Hash) bool {
+	if index >= size {
+		return false
+	}
+	fn, sn := index, size-1
+	r := leaf
+	for _, p := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = NodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && r == root
+}
+
+func subtreeRoot(leaves []Hash) Hash {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := splitPoint(len(leaves))
+	return NodeHash(subtreeRoot(leaves[:k]), subtreeRoot(leaves[k:]))
+}
+
+func path(index int, leaves []Hash) []Hash {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := splitPoint(len(leaves))
+	if index < k {
+		return append(path(index, leaves[:k]), subtreeRoot(leaves[k:]))
+	}
+	return append(path(index-k, leaves[k:]), subtreeRoot(leaves[:k]))
+}
+
+// 小于n的最大2的幂
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
@@ -0,0 +1,64 @@
+package merkle
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testLeaves(n int) []Hash {
+	leaves := make([]Hash, n)
+	for i := range leaves {
+		leaves[i] = LeafHash([]byte(fmt.Sprintf("leaf-%d", i)))
+	}
+	return leaves
+}
+
+func TestProofRoundTrip(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		leaves := testLeaves(size)
+		root, err := Root(leaves)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		for i := range leaves {
+			proof, err := Proof(leaves, i)
+			if err != nil {
+				t.Fatalf("size %d index %d: %v", size, i, err)
+			}
+			if !Verify(leaves[i], uint64(i), uint64(size), proof, root) {
+				t.Fatalf("size %d index %d: valid proof rejected", size, i)
+			}
+		}
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	leaves := testLeaves(7)
+	root, _ := Root(leaves)
+	proof, _ := Proof(leaves, 3)
+
+	if Verify(LeafHash([]byte("forged")), 3, 7, proof, root) {
+		t.Fatal("forged leaf accepted")
+	}
+	if Verify(leaves[3], 4, 7, proof, root) {
+		t.Fatal("wrong index accepted")
+	}
+	last, _ := Proof(leaves, 6)
+	if Verify(leaves[6], 6, 8, last, root) {
+		t.Fatal("wrong tree size accepted")
+	}
+	if Verify(leaves[3], 3, 7, proof[:len(proof)-1], root) {
+		t.Fatal("truncated proof accepted")
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	h := LeafHash([]byte("x"))
+	parsed, err := ParseHash(h.Hex())
+	if err != nil || parsed != h {
+		t.Fatalf("round trip failed: %v", err)
+	}
+	if _, err := ParseHash("0x1234"); err != ErrInvalidHash {
+		t.Fatalf("expected ErrInvalidHash, got %v", err)
+	}
+}
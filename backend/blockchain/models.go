@@ -12,6 +12,7 @@ const (
 	BlockchainTypePolygon  BlockchainType = "polygon"
 	BlockchainTypeBSC      BlockchainType = "bsc"
 	BlockchainTypeTencent  BlockchainType = "tencent" // 新增腾讯云区块链类型
+	BlockchainTypeLocal    BlockchainType = "local"   // 内置本地账本，用于开发和测试
 )
 
 // 交易状态
@@ -48,6 +49,25 @@ type BlockchainCertificate struct {
 	Status          TransactionStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	GasUsed         uint64            `json:"gas_used"`
 	GasPrice        uint64            `json:"gas_price"`
+	IssuedAt        time.Time         `json:"issued_at"`
+	BatchID         string            `json:"batch_id" gorm:"type:varchar(36);index"`
+	LeafIndex       uint64            `json:"leaf_index"`
+	LeafHash        string            `json:"leaf_hash" gorm:"type:varchar(66)"`
+	InclusionProof  string            `json:"inclusion_proof" gorm:"type:text"` // JSON数组，自叶子向上的兄弟节点哈希
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// 证书批次模型：一批证书构成一棵Merkle树，仅树根上链
+type CertificateBatch struct {
+	ID              string            `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	BlockchainType  BlockchainType    `json:"blockchain_type" gorm:"type:varchar(20);not null"`
+	MerkleRoot      string            `json:"merkle_root" gorm:"type:varchar(66);uniqueIndex"`
+	LeafCount       uint64            `json:"leaf_count"`
+	TransactionHash string            `json:"transaction_hash" gorm:"type:varchar(66);index"` // 为空表示尚未提交锚定交易
+	IssuerAddress   string            `json:"issuer_address" gorm:"type:varchar(42)"`         // 锚定交易发送方
+	BlockNumber     uint64            `json:"block_number"`
+	Status          TransactionStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	CreatedAt       time.Time         `json:"created_at"`
 	UpdatedAt       time.Time         `json:"updated_at"`
 }
@@ -76,7 +96,10 @@ type Wallet struct {
 	ID             string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
 	UserID         uint           `json:"user_id" gorm:"uniqueIndex;not null"`
 	Address        string         `json:"address" gorm:"type:varchar(42);uniqueIndex;not null"`
-	PrivateKey     string         `json:"private_key" gorm:"type:varchar(66);not null"`
+	PublicKey      string         `json:"public_key" gorm:"type:varchar(132)"`
+	EncryptedKey   []byte         `json:"-" gorm:"type:varbinary(128)"` // 数据密钥加密的私钥
+	WrappedKey     []byte         `json:"-" gorm:"type:varbinary(256)"` // 主密钥包装的数据密钥
+	MasterKeyID    string         `json:"-" gorm:"type:varchar(64);index"`
 	BlockchainType BlockchainType `json:"blockchain_type" gorm:"type:varchar(20);not null"`
 	Balance        string         `json:"balance" gorm:"type:varchar(50);default:'0'"`
 	IsActive       bool           `json:"is_active" gorm:"default:true"`
@@ -129,6 +152,12 @@ type PointsTransactionHistory struct {
 	BlockNumber          uint64            `json:"blockNumber"`
 	Status               TransactionStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	BlockchainType       BlockchainType    `json:"blockchainType" gorm:"type:varchar(20);default:'tencent'"`
+	// 审计哈希链字段
+	Seq          uint64 `json:"seq" gorm:"not null;default:0;index"`
+	PrevHash     string `json:"prevHash" gorm:"type:varchar(64)"`     // 全局上一条记录的链哈希
+	FromPrevHash string `json:"fromPrevHash" gorm:"type:varchar(64)"` // 转出用户上一条记录的链哈希
+	ToPrevHash   string `json:"toPrevHash" gorm:"type:varchar(64)"`   // 转入用户上一条记录的链哈希
+	RecordHash   string `json:"recordHash" gorm:"type:varchar(64)"`
 }
 
 // 积分交易保存响应模型 (兼容原有API)
@@ -139,15 +168,25 @@ type PointsTxSaveResp struct {
 
 // 简历模型 (兼容原有API)
 type ResumeModel struct {
-	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	UserID      string    `json:"userId" gorm:"type:varchar(50);not null"`
-	Title       string    `json:"title" gorm:"not null"`
-	Content     string    `json:"content" gorm:"type:text"`
-	Hash        string    `json:"hash" gorm:"type:varchar(66);uniqueIndex"`
-	BlockNumber uint64    `json:"blockNumber"`
-	Status      string    `json:"status" gorm:"type:varchar(20);default:'active'"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID              string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID          string    `json:"userId" gorm:"type:varchar(50);not null"`
+	Title           string    `json:"title" gorm:"not null"`
+	Content         string    `json:"content" gorm:"type:text"`
+	Hash            string    `json:"hash" gorm:"type:varchar(66);uniqueIndex"`
+	TransactionHash string    `json:"transactionHash" gorm:"type:varchar(66)"`
+	BlockchainType  string    `json:"blockchainType" gorm:"type:varchar(20)"` // 锚定所在的链，校验和删除记录都在同一条链上进行
+	BlockNumber     uint64    `json:"blockNumber"`
+	Status          string    `json:"status" gorm:"type:varchar(20);default:'active'"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// 简历锚定所在的链，早期记录未保存链类型时使用默认链
+func (r *ResumeModel) chainType() BlockchainType {
+	if r.BlockchainType == "" {
+		return defaultBlockchainType()
+	}
+	return BlockchainType(r.BlockchainType)
 }
 
 // 腾讯云区块链集群信息
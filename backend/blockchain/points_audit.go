@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"resume-centre/shared/kernel/hashchain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 积分交易哈希链：每条记录包含全局上一条记录及转出、转入用户各自上一条记录的哈希，
+// 记录的链哈希逐条锚定上链，最新一条的锚定值即可证明此前整条链未被改动。
+// 链的计算和校验与积分服务流水共用hashchain实现
+var pointsChainGenesis = hashchain.GenesisHash
+
+const pointsChainGlobalScope = hashchain.GlobalScope
+
+// 积分交易链头
+type PointsChainHead struct {
+	Scope     string    `json:"scope" gorm:"primaryKey;type:varchar(80)"`
+	Seq       uint64    `json:"seq" gorm:"not null;default:0"`
+	Hash      string    `json:"hash" gorm:"type:varchar(64);not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 断链信息
+type PointsChainBreak struct {
+	Seq                  uint64 `json:"seq"`
+	TransactionHistoryID string `json:"transactionHistoryId,omitempty"`
+	UserScope            string `json:"userScope,omitempty"`
+	Reason               string `json:"reason"`
+	Expected             string `json:"expected,omitempty"`
+	Actual               string `json:"actual,omitempty"`
+}
+
+// 用户链范围，用户ID在不同端可能重复，需带上来源
+func pointsUserScope(userID string, source int) string {
+	return fmt.Sprintf("user:%d:%s", source, userID)
+}
+
+// 计算记录链哈希，不包含上链状态等会变化的字段
+func pointsHistoryHash(r *PointsTransactionHistory) string {
+	return hashchain.Hash(
+		r.Seq, r.TransactionHistoryID,
+		r.FromUserId, r.FromUserSource, r.ToUserId, r.ToUserSource,
+		r.TransactionPoint, r.TransactionCode, r.TransactionContent,
+		r.CreateTime.UnixMilli(),
+		r.PrevHash, r.FromPrevHash, r.ToPrevHash,
+	)
+}
+
+// 将记录接到链尾，设置序号、前序哈希和链哈希
+func linkPointsHistory(r *PointsTransactionHistory, seq uint64, globalPrev, fromPrev, toPrev string) {
+	// 数据库时间精度为毫秒，哈希前截断保证读回后一致
+	r.CreateTime = r.CreateTime.Truncate(time.Millisecond)
+	r.Seq = seq
+	r.PrevHash = globalPrev
+	r.FromPrevHash = fromPrev
+	r.ToPrevHash = toPrev
+	r.RecordHash = pointsHistoryHash(r)
+}
+
+// 校验用的链记录，转出和转入用户链各有一个前序链接
+func pointsHistoryEntry(r *PointsTransactionHistory) *hashchain.Entry {
+	return &hashchain.Entry{
+		Seq:        r.Seq,
+		Hash:       r.RecordHash,
+		Computed:   pointsHistoryHash(r),
+		GlobalPrev: r.PrevHash,
+		Links: []hashchain.Link{
+			{Scope: pointsUserScope(r.FromUserId, r.FromUserSource), Prev: r.FromPrevHash},
+			{Scope: pointsUserScope(r.ToUserId, r.ToUserSource), Prev: r.ToPrevHash},
+		},
+	}
+}
+
+// 锁定链头，不存在时创建
+func lockPointsChainHead(tx *gorm.DB, scope string) (*PointsChainHead, error) {
+	head := PointsChainHead{Scope: scope, Hash: pointsChainGenesis}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ?", scope).First(&head).Error; err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// 将记录接到链尾后写入，用户链头按范围排序加锁，最后锁全局链头
+func appendPointsHistory(tx *gorm.DB, r *PointsTransactionHistory) error {
+	fromScope := pointsUserScope(r.FromUserId, r.FromUserSource)
+	toScope := pointsUserScope(r.ToUserId, r.ToUserSource)
+	scopes := []string{fromScope}
+	if toScope != fromScope {
+		scopes = append(scopes, toScope)
+	}
+	sort.Strings(scopes)
+
+	heads := map[string]*PointsChainHead{}
+	for _, scope := range scopes {
+		head, err := lockPointsChainHead(tx, scope)
+		if err != nil {
+			return err
+		}
+		heads[scope] = head
+	}
+	global, err := lockPointsChainHead(tx, pointsChainGlobalScope)
+	if err != nil {
+		return err
+	}
+
+	linkPointsHistory(r, global.Seq+1, global.Hash, heads[fromScope].Hash, heads[toScope].Hash)
+
+	if err := tx.Create(r).Error; err != nil {
+		return err
+	}
+	for _, head := range append([]*PointsChainHead{global}, mapHeads(heads)...) {
+		if err := tx.Model(head).Updates(map[string]interface{}{"seq": r.Seq, "hash": r.RecordHash}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mapHeads(heads map[string]*PointsChainHead) []*PointsChainHead {
+	out := make([]*PointsChainHead, 0, len(heads))
+	for _, head := range heads {
+		out = append(out, head)
+	}
+	return out
+}
+
+// 首次启用时按创建顺序为历史记录建链，之后不再补链
+func initPointsAuditChain() error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&PointsChainHead{}).Where("scope = ?", pointsChainGlobalScope).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		global, err := lockPointsChainHead(tx, pointsChainGlobalScope)
+		if err != nil {
+			return err
+		}
+		heads := map[string]*PointsChainHead{}
+		userHead := func(scope string) *PointsChainHead {
+			if head, ok := heads[scope]; ok {
+				return head
+			}
+			head := &PointsChainHead{Scope: scope, Hash: pointsChainGenesis}
+			heads[scope] = head
+			return head
+		}
+
+		var records []PointsTransactionHistory
+		if err := tx.Order("create_time ASC, transaction_history_id ASC").Find(&records).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			r := &records[i]
+			from := userHead(pointsUserScope(r.FromUserId, r.FromUserSource))
+			to := userHead(pointsUserScope(r.ToUserId, r.ToUserSource))
+
+			linkPointsHistory(r, global.Seq+1, global.Hash, from.Hash, to.Hash)
+			if err := tx.Model(r).Updates(map[string]interface{}{
+				"create_time":    r.CreateTime,
+				"seq":            r.Seq,
+				"prev_hash":      r.PrevHash,
+				"from_prev_hash": r.FromPrevHash,
+				"to_prev_hash":   r.ToPrevHash,
+				"record_hash":    r.RecordHash,
+			}).Error; err != nil {
+				return err
+			}
+			global.Seq, global.Hash = r.Seq, r.RecordHash
+			from.Seq, from.Hash = r.Seq, r.RecordHash
+			to.Seq, to.Hash = r.Seq, r.RecordHash
+		}
+
+		for _, head := range heads {
+			if err := tx.Save(head).Error; err != nil {
+				return err
+			}
+		}
+		if len(records) > 0 {
+			logger.Infof("Points audit chain initialized over %d existing records", len(records))
+		}
+		return tx.Save(global).Error
+	})
+}
+
+// 遍历积分交易哈希链，返回第一处断链
+func verifyPointsChain(ctx context.Context, checkAnchor bool) (gin.H, *PointsChainBreak, error) {
+	batchSize := viper.GetInt("blockchain.audit.verify_batch_size")
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	verifier := hashchain.NewVerifier("")
+	var lastSeq uint64
+	var lastID string
+	var latestAnchored *PointsTransactionHistory
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		var records []PointsTransactionHistory
+		if err := db.WithContext(ctx).
+			Where("(seq > ? OR (seq = ? AND transaction_history_id > ?))", lastSeq, lastSeq, lastID).
+			Order("seq ASC, transaction_history_id ASC").Limit(batchSize).Find(&records).Error; err != nil {
+			return nil, nil, err
+		}
+
+		var brk *PointsChainBreak
+		latestAnchored, brk = verifyPointsRecords(verifier, records, latestAnchored)
+		if brk != nil {
+			return gin.H{"checked": verifier.Checked()}, brk, nil
+		}
+		if len(records) < batchSize {
+			break
+		}
+		lastSeq, lastID = records[len(records)-1].Seq, records[len(records)-1].TransactionHistoryID
+	}
+
+	headSeq, headHash := verifier.Head()
+	summary := gin.H{"checked": verifier.Checked(), "head_seq": headSeq, "head_hash": headHash}
+
+	// 链尾须与链头一致，否则尾部记录被删除
+	var heads []PointsChainHead
+	if err := db.WithContext(ctx).Find(&heads).Error; err != nil {
+		return nil, nil, err
+	}
+	if brk := checkPointsChainHeads(verifier, heads); brk != nil {
+		return summary, brk, nil
+	}
+
+	// 最新一条已确认记录的链上锚定值覆盖了它之前的整条链
+	if checkAnchor && latestAnchored != nil {
+		anchored, blockNumber, err := queryAnchoredHash(ctx, latestAnchored.BlockchainType, latestAnchored.TransactionHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		summary["anchor"] = gin.H{
+			"seq":              latestAnchored.Seq,
+			"transaction_hash": latestAnchored.TransactionHash,
+			"block_number":     blockNumber,
+		}
+		if brk := checkPointsAnchor(latestAnchored, anchored); brk != nil {
+			return summary, brk, nil
+		}
+	}
+	return summary, nil, nil
+}
+
+// 按顺序校验一页记录，返回其中最新一条已确认上链的记录
+func verifyPointsRecords(verifier *hashchain.Verifier, records []PointsTransactionHistory, latestAnchored *PointsTransactionHistory) (*PointsTransactionHistory, *PointsChainBreak) {
+	for i := range records {
+		r := &records[i]
+		if b := verifier.Add(pointsHistoryEntry(r)); b != nil {
+			return latestAnchored, &PointsChainBreak{Seq: b.Seq, TransactionHistoryID: r.TransactionHistoryID,
+				UserScope: b.Scope, Reason: b.Reason, Expected: b.Expected, Actual: b.Actual}
+		}
+		if r.TransactionHash != "" && r.Status == TransactionStatusConfirmed {
+			latestAnchored = r
+		}
+	}
+	return latestAnchored, nil
+}
+
+// 比对存储的全局和用户链头与校验得到的链尾
+func checkPointsChainHeads(verifier *hashchain.Verifier, heads []PointsChainHead) *PointsChainBreak {
+	chainHeads := make([]hashchain.Head, len(heads))
+	for i, h := range heads {
+		chainHeads[i] = hashchain.Head{Scope: h.Scope, Seq: h.Seq, Hash: h.Hash}
+	}
+	if b := verifier.CheckHeads(chainHeads); b != nil {
+		return &PointsChainBreak{Seq: b.Seq, UserScope: b.Scope, Reason: b.Reason, Expected: b.Expected, Actual: b.Actual}
+	}
+	return nil
+}
+
+// 比对记录哈希与链上锚定值
+func checkPointsAnchor(r *PointsTransactionHistory, anchored string) *PointsChainBreak {
+	if hashchain.AnchorMatches(anchored, r.RecordHash) {
+		return nil
+	}
+	return &PointsChainBreak{Seq: r.Seq, TransactionHistoryID: r.TransactionHistoryID,
+		Reason: hashchain.ReasonAnchorMismatch, Expected: anchored, Actual: r.RecordHash}
+}
+
+// 校验积分交易哈希链
+func verifyPointsAudit(c *gin.Context) {
+	summary, brk, err := verifyPointsChain(c.Request.Context(), c.DefaultQuery("anchors", "true") == "true")
+	if err != nil {
+		logger.Errorf("Points audit verification failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify points audit chain: " + err.Error()})
+		return
+	}
+	if brk != nil {
+		logger.Warnf("Points audit chain broken at seq %d: %s", brk.Seq, brk.Reason)
+	}
+
+	summary["valid"] = brk == nil
+	summary["first_broken"] = brk
+	summary["verified_at"] = time.Now()
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// 服务间调用认证中间件
+func internalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !validServiceToken(c) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// 请求是否带有有效的服务间令牌，未配置令牌时一律无效
+func validServiceToken(c *gin.Context) bool {
+	expected := viper.GetString("internal.service_token")
+	token := c.GetHeader("X-Service-Token")
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// 为其他服务锚定摘要（如积分流水审计检查点）
+func createAnchor(c *gin.Context) {
+	var req struct {
+		Kind           string `json:"kind" binding:"required"`
+		ID             string `json:"id" binding:"required"`
+		Hash           string `json:"hash" binding:"required"`
+		BlockchainType string `json:"blockchain_type"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := anchorRecord(c.Request.Context(), BlockchainType(req.BlockchainType), 0, req.Kind, req.ID, req.Hash)
+	if err != nil {
+		logger.Errorf("Failed to anchor %s %s: %v", req.Kind, req.ID, err)
+		c.JSON(chainErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"transaction_hash": tx.TransactionHash,
+			"blockchain_type":  tx.BlockchainType,
+			"status":           tx.Status,
+			"hash":             req.Hash,
+		},
+	})
+}
+
+// 查询锚定交易中的摘要
+func getAnchor(c *gin.Context) {
+	txHash := c.Param("txHash")
+	chainType := BlockchainType(c.Query("blockchain_type"))
+
+	hash, blockNumber, err := queryAnchoredHash(c.Request.Context(), chainType, txHash)
+	if err != nil {
+		status := chainErrorStatus(err)
+		if errors.Is(err, ErrTxNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := TransactionStatusPending
+	var tx BlockchainTransaction
+	if err := db.Where("transaction_hash = ?", txHash).First(&tx).Error; err == nil {
+		status = tx.Status
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"transaction_hash": txHash,
+			"blockchain_type":  chainType,
+			"hash":             hash,
+			"block_number":     blockNumber,
+			"status":           status,
+		},
+	})
+}
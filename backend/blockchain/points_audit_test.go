@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"resume-centre/blockchain/certverify"
+	"resume-centre/shared/kernel/hashchain"
+)
+
+// 构造积分交易链：用户1、2之间往返转账，再由管理端发放给用户3
+func buildPointsChain() ([]PointsTransactionHistory, []PointsChainHead) {
+	transfers := [][2]string{{"1", "2"}, {"2", "1"}, {"1", "2"}, {"admin", "3"}, {"3", "1"}}
+	heads := map[string]*PointsChainHead{pointsChainGlobalScope: {Scope: pointsChainGlobalScope, Hash: pointsChainGenesis}}
+	head := func(scope string) *PointsChainHead {
+		if h, ok := heads[scope]; ok {
+			return h
+		}
+		h := &PointsChainHead{Scope: scope, Hash: pointsChainGenesis}
+		heads[scope] = h
+		return h
+	}
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 987654321, time.UTC)
+	records := make([]PointsTransactionHistory, len(transfers))
+	for i, t := range transfers {
+		r := &records[i]
+		*r = PointsTransactionHistory{
+			TransactionHistoryID: fmt.Sprintf("tx-%d", i+1),
+			FromUserId:           t[0],
+			FromUserSource:       2,
+			ToUserId:             t[1],
+			ToUserSource:         2,
+			TransactionPoint:     10 * (i + 1),
+			TransactionCode:      1,
+			CreateTime:           start.Add(time.Duration(i) * time.Minute),
+		}
+		if t[0] == "admin" {
+			r.FromUserSource = 1
+		}
+		global := heads[pointsChainGlobalScope]
+		from := head(pointsUserScope(r.FromUserId, r.FromUserSource))
+		to := head(pointsUserScope(r.ToUserId, r.ToUserSource))
+		linkPointsHistory(r, global.Seq+1, global.Hash, from.Hash, to.Hash)
+		for _, h := range []*PointsChainHead{global, from, to} {
+			h.Seq, h.Hash = r.Seq, r.RecordHash
+		}
+	}
+
+	var out []PointsChainHead
+	for _, h := range heads {
+		out = append(out, *h)
+	}
+	return records, out
+}
+
+func TestPointsChainVerify(t *testing.T) {
+	records, heads := buildPointsChain()
+	if records[0].CreateTime.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Fatal("create time should be truncated to milliseconds")
+	}
+
+	verifier := hashchain.NewVerifier("")
+	if _, brk := verifyPointsRecords(verifier, records, nil); brk != nil {
+		t.Fatalf("intact chain reported broken: %+v", brk)
+	}
+	if brk := checkPointsChainHeads(verifier, heads); brk != nil {
+		t.Fatalf("intact heads reported broken: %+v", brk)
+	}
+
+	// 分页校验与一次校验结果一致
+	paged := hashchain.NewVerifier("")
+	for _, page := range [][]PointsTransactionHistory{records[:2], records[2:]} {
+		if _, brk := verifyPointsRecords(paged, page, nil); brk != nil {
+			t.Fatalf("paged chain reported broken: %+v", brk)
+		}
+	}
+	if seq, hash := paged.Head(); seq != 5 || hash != records[4].RecordHash {
+		t.Fatalf("unexpected head %d %s", seq, hash)
+	}
+}
+
+func TestPointsChainDetectsTampering(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper func([]PointsTransactionHistory) []PointsTransactionHistory
+		reason string
+		txID   string
+	}{
+		{"modified points", func(r []PointsTransactionHistory) []PointsTransactionHistory {
+			r[2].TransactionPoint = 100000
+			return r
+		}, hashchain.ReasonRecordModified, "tx-3"},
+		{"deleted record", func(r []PointsTransactionHistory) []PointsTransactionHistory {
+			return append(r[:1], r[2:]...)
+		}, hashchain.ReasonSequenceGap, "tx-3"},
+		{"rehashed record", func(r []PointsTransactionHistory) []PointsTransactionHistory {
+			r[1].TransactionPoint = 1
+			r[1].RecordHash = pointsHistoryHash(&r[1])
+			return r
+		}, hashchain.ReasonGlobalLinkBroken, "tx-3"},
+		{"forged receiver link", func(r []PointsTransactionHistory) []PointsTransactionHistory {
+			// 伪造记录接在全局链和转出用户链上，但转入用户的前序不对
+			forged := PointsTransactionHistory{TransactionHistoryID: "forged", FromUserId: "1", FromUserSource: 2,
+				ToUserId: "2", ToUserSource: 2, TransactionPoint: 5000, CreateTime: time.Now()}
+			linkPointsHistory(&forged, 6, r[4].RecordHash, r[4].RecordHash, pointsChainGenesis)
+			return append(r, forged)
+		}, hashchain.ReasonUserLinkBroken, "forged"},
+	}
+	for _, tc := range cases {
+		records, _ := buildPointsChain()
+		_, brk := verifyPointsRecords(hashchain.NewVerifier(""), tc.tamper(records), nil)
+		if brk == nil || brk.Reason != tc.reason || brk.TransactionHistoryID != tc.txID {
+			t.Errorf("%s: unexpected break %+v", tc.name, brk)
+		}
+	}
+
+	// 删除链尾记录后，链头仍指向被删除的记录
+	records, heads := buildPointsChain()
+	verifier := hashchain.NewVerifier("")
+	verifyPointsRecords(verifier, records[:4], nil)
+	if brk := checkPointsChainHeads(verifier, heads); brk == nil || brk.Reason != hashchain.ReasonHeadMismatch {
+		t.Fatalf("expected head mismatch after truncation, got %+v", brk)
+	}
+}
+
+func TestPointsChainAnchor(t *testing.T) {
+	adapter, err := NewLocalChainAdapter(LocalChainOptions{DataDir: t.TempDir(), BatchSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close()
+	ctx := context.Background()
+
+	records, _ := buildPointsChain()
+	records[1].TransactionHash, records[1].Status = "0xold", TransactionStatusConfirmed
+	records[3].TransactionHash, records[3].Status = "0xpending", TransactionStatusPending
+
+	// 校验时只取最新一条已确认上链的记录
+	latest, brk := verifyPointsRecords(hashchain.NewVerifier(""), records, nil)
+	if brk != nil || latest == nil || latest.TransactionHistoryID != "tx-2" {
+		t.Fatalf("unexpected latest anchored record %+v (%v)", latest, brk)
+	}
+
+	payload, _ := json.Marshal(map[string]string{"kind": "points_transaction", "id": latest.TransactionHistoryID, "hash": latest.RecordHash})
+	txHash, err := adapter.Submit(ctx, &ChainTx{Data: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := adapter.Query(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchored := certverify.ParseAnchorPayload(record.Data)
+	if brk := checkPointsAnchor(latest, "0x"+anchored); brk != nil {
+		t.Fatalf("anchored record reported broken: %+v", brk)
+	}
+
+	// 整条链被重写后，记录哈希与链上锚定值不再一致
+	latest.TransactionPoint = 1
+	linkPointsHistory(latest, latest.Seq, latest.PrevHash, latest.FromPrevHash, latest.ToPrevHash)
+	if brk := checkPointsAnchor(latest, anchored); brk == nil || brk.Reason != hashchain.ReasonAnchorMismatch {
+		t.Fatalf("expected anchor mismatch, got %+v", brk)
+	}
+}
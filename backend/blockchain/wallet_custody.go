@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"resume-centre/blockchain/custody"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// 系统钱包所属用户ID，用于服务自身的上链交易签名
+const systemWalletUserID = 0
+
+var keyCustody *custody.Custody
+
+// 初始化密钥托管：加载主密钥并迁移历史明文私钥
+func initKeyCustody() error {
+	kms, err := custody.OpenLocalKMS(viper.GetString("custody.keyfile"))
+	if err != nil {
+		return err
+	}
+	keyCustody = custody.New(kms)
+	logger.Infof("Key custody initialized with master key %s", kms.CurrentKeyID())
+
+	return migrateLegacyWalletKeys(context.Background())
+}
+
+// 历史版本以明文保存私钥，启动时加密入库并删除明文列
+func migrateLegacyWalletKeys(ctx context.Context) error {
+	if !db.Migrator().HasColumn(&Wallet{}, "private_key") {
+		return nil
+	}
+
+	var legacy []struct {
+		ID         string
+		Address    string
+		PrivateKey string
+	}
+	if err := db.Table("wallets").Select("id, address, private_key").
+		Where("private_key <> '' AND encrypted_key IS NULL").Scan(&legacy).Error; err != nil {
+		return err
+	}
+
+	for _, w := range legacy {
+		updates := map[string]interface{}{}
+		raw, err := hex.DecodeString(strings.TrimPrefix(w.PrivateKey, "0x"))
+		generated, importErr := keyCustody.Import(ctx, raw)
+		switch {
+		case err != nil || importErr != nil:
+			// 旧版生成的并非有效私钥，钱包无法签名，直接停用
+			logger.Warnf("Wallet %s has an invalid legacy private key, deactivating", w.ID)
+			updates["is_active"] = false
+		default:
+			if !strings.EqualFold(generated.Address, w.Address) {
+				logger.Warnf("Wallet %s address does not derive from its key, deactivating", w.ID)
+				updates["is_active"] = false
+				break
+			}
+			updates["public_key"] = generated.PublicKey
+			updates["encrypted_key"] = generated.Sealed.Ciphertext
+			updates["wrapped_key"] = generated.Sealed.WrappedDEK
+			updates["master_key_id"] = generated.Sealed.KeyID
+		}
+		if err := db.Model(&Wallet{}).Where("id = ?", w.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to migrate wallet %s: %v", w.ID, err)
+		}
+	}
+
+	if err := db.Migrator().DropColumn(&Wallet{}, "private_key"); err != nil {
+		return fmt.Errorf("failed to drop plaintext private key column: %v", err)
+	}
+	logger.Infof("Migrated %d legacy wallet keys into custody", len(legacy))
+	return nil
+}
+
+// 钱包的加密私钥
+func walletSealedKey(wallet *Wallet) (*custody.SealedKey, error) {
+	if len(wallet.EncryptedKey) == 0 || len(wallet.WrappedKey) == 0 {
+		return nil, fmt.Errorf("wallet %s has no custodied key", wallet.ID)
+	}
+	return &custody.SealedKey{
+		Address:    wallet.Address,
+		Ciphertext: wallet.EncryptedKey,
+		WrappedDEK: wallet.WrappedKey,
+		KeyID:      wallet.MasterKeyID,
+	}, nil
+}
+
+// 生成托管钱包
+func newCustodyWallet(ctx context.Context, userID uint, chainType BlockchainType) (*Wallet, error) {
+	generated, err := keyCustody.GenerateKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Address:        generated.Address,
+		PublicKey:      generated.PublicKey,
+		EncryptedKey:   generated.Sealed.Ciphertext,
+		WrappedKey:     generated.Sealed.WrappedDEK,
+		MasterKeyID:    generated.Sealed.KeyID,
+		BlockchainType: chainType,
+		Balance:        "0",
+		IsActive:       true,
+	}, nil
+}
+
+// 获取系统钱包，不存在时创建
+func loadSystemWallet(ctx context.Context) (*Wallet, error) {
+	var wallet Wallet
+	err := db.Where("user_id = ?", systemWalletUserID).First(&wallet).Error
+	if err == nil {
+		return &wallet, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	created, err := newCustodyWallet(ctx, systemWalletUserID, BlockchainTypeEthereum)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Create(created).Error; err != nil {
+		return nil, err
+	}
+	logger.Infof("Created system wallet %s", created.Address)
+	return created, nil
+}
+
+// 由托管组件签名交易的签名器
+type walletSigner struct {
+	custody *custody.Custody
+	sealed  *custody.SealedKey
+}
+
+func (s *walletSigner) Address() string {
+	return s.sealed.Address
+}
+
+func (s *walletSigner) SignTx(ctx context.Context, tx *custody.LegacyTx, chainID uint64) ([]byte, string, error) {
+	return s.custody.SignLegacyTx(ctx, s.sealed, tx, chainID)
+}
+
+// 系统钱包签名器
+func systemWalletSigner(ctx context.Context) (TxSigner, error) {
+	wallet, err := loadSystemWallet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := walletSealedKey(wallet)
+	if err != nil {
+		return nil, err
+	}
+	return &walletSigner{custody: keyCustody, sealed: sealed}, nil
+}
+
+// 使用钱包私钥签名消息（personal_sign），用于证明地址归属
+func signWalletMessage(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var wallet Wallet
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&wallet).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+	if !wallet.IsActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Wallet is inactive"})
+		return
+	}
+
+	var req struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sealed, err := walletSealedKey(&wallet)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	signature, err := keyCustody.SignMessage(c.Request.Context(), sealed, []byte(req.Message))
+	if err != nil {
+		logger.Errorf("Failed to sign message with wallet %s: %v", wallet.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign message"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"address":   wallet.Address,
+		"message":   req.Message,
+		"signature": "0x" + hex.EncodeToString(signature),
+	})
+}
+
+// 管理员认证中间件：校验X-Admin-Token，未配置令牌时管理接口不可用
+func adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := viper.GetString("custody.admin_token")
+		token := c.GetHeader("X-Admin-Token")
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// 轮换主密钥：生成新版本主密钥并重新包装所有钱包的数据密钥
+func rotateMasterKey(c *gin.Context) {
+	kms, ok := keyCustody.KMS().(custody.RotatableKMS)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Key management service does not support rotation"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	keyID, err := kms.Rotate(ctx)
+	if err != nil {
+		logger.Errorf("Failed to rotate master key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate master key"})
+		return
+	}
+
+	rewrapped, failed := rewrapWalletKeys(ctx, keyID)
+	logger.Warnf("Master key rotated to %s from %s: %d wallets rewrapped, %d failed", keyID, c.ClientIP(), rewrapped, len(failed))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Master key rotated",
+		"key_id":         keyID,
+		"rewrapped":      rewrapped,
+		"failed_wallets": failed,
+	})
+}
+
+// 用当前主密钥重新包装所有旧版本包装的数据密钥
+func rewrapWalletKeys(ctx context.Context, keyID string) (int, []string) {
+	var wallets []Wallet
+	if err := db.Where("master_key_id <> ? AND encrypted_key IS NOT NULL", keyID).Find(&wallets).Error; err != nil {
+		logger.Errorf("Failed to load wallets for rewrap: %v", err)
+		return 0, nil
+	}
+
+	rewrapped := 0
+	failed := []string{}
+	for i := range wallets {
+		sealed, err := walletSealedKey(&wallets[i])
+		if err == nil {
+			sealed, err = keyCustody.Rewrap(ctx, sealed)
+		}
+		if err == nil {
+			err = db.Model(&wallets[i]).Updates(map[string]interface{}{
+				"wrapped_key":   sealed.WrappedDEK,
+				"master_key_id": sealed.KeyID,
+			}).Error
+		}
+		if err != nil {
+			logger.Errorf("Failed to rewrap wallet %s: %v", wallets[i].ID, err)
+			failed = append(failed, wallets[i].ID)
+			continue
+		}
+		rewrapped++
+	}
+	return rewrapped, failed
+}
+
+// 删除旧版本主密钥，要求已无钱包使用该版本
+func retireMasterKey(c *gin.Context) {
+	kms, ok := keyCustody.KMS().(custody.RotatableKMS)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Key management service does not support rotation"})
+		return
+	}
+
+	keyID := c.Param("keyId")
+	var inUse int64
+	if err := db.Model(&Wallet{}).Where("master_key_id = ?", keyID).Count(&inUse).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check key usage"})
+		return
+	}
+	if inUse > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%d wallets still use key %s, rotate first", inUse, keyID)})
+		return
+	}
+
+	if err := kms.Retire(c.Request.Context(), keyID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, custody.ErrKeyInUse) {
+			status = http.StatusConflict
+		} else if errors.Is(err, custody.ErrUnknownMasterKey) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Warnf("Master key %s retired from %s", keyID, c.ClientIP())
+	c.JSON(http.StatusOK, gin.H{"message": "Master key retired", "key_id": keyID})
+}
+
+// 导出钱包私钥：以管理员口令加密为标准keystore文件，不返回明文
+func exportWalletKey(c *gin.Context) {
+	var req struct {
+		Passphrase string `json:"passphrase" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Passphrase) < 12 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "passphrase must be at least 12 characters"})
+		return
+	}
+
+	var wallet Wallet
+	if err := db.Where("id = ?", c.Param("id")).First(&wallet).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+	sealed, err := walletSealedKey(&wallet)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	keystore, err := keyCustody.ExportKeystore(c.Request.Context(), sealed, req.Passphrase)
+	if err != nil {
+		logger.Errorf("Failed to export wallet %s: %v", wallet.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export wallet key"})
+		return
+	}
+
+	logger.Warnf("Wallet %s (%s) key exported from %s, reason: %s", wallet.ID, wallet.Address, c.ClientIP(), req.Reason)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=UTC--%s.json", strings.ToLower(strings.TrimPrefix(wallet.Address, "0x"))))
+	c.Data(http.StatusOK, "application/json", keystore)
+}
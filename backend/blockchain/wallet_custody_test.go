@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func TestUserContextRequiresServiceToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("internal.service_token", "test-internal")
+	defer viper.Set("internal.service_token", nil)
+
+	router := gin.New()
+	router.Use(userContextMiddleware())
+	router.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": getUserIDFromContext(c)})
+	})
+
+	cases := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"no token", "", `{"user_id":0}`},
+		{"wrong token", "guess", `{"user_id":0}`},
+		{"gateway", "test-internal", `{"user_id":42}`},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("X-User-ID", "42")
+		if tc.token != "" {
+			req.Header.Set("X-Service-Token", tc.token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Body.String() != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, w.Body.String(), tc.want)
+		}
+	}
+}
+
+func TestSignWalletRejectsSpoofedUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set("internal.service_token", "test-internal")
+	defer viper.Set("internal.service_token", nil)
+
+	// 只带X-User-ID的请求在访问数据库前被拒绝
+	router := setupRouter()
+	for _, path := range []string{"/api/v1/wallets/w1/sign", "/blockchain/wallets/w1/sign"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"message":"hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", path, w.Code)
+		}
+	}
+}
@@ -11,6 +11,7 @@
 // - 存储服务 (storage) - 文件存储服务
 // - ElasticSearch集成 (es) - ES搜索功能
 // - 消息队列 (mq) - 消息队列功能
+// - 技能标准化 (skills) - 技能词典、别名/拼音/模糊匹配和加权技能匹配
 // - 工具函数 (utils)
 // - 配置管理 (config)
 // - 中间件 (middleware)
@@ -0,0 +1,373 @@
+package ocr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrFieldsNotFound 识别结果中找不到证件的关键字段
+var ErrFieldsNotFound = errors.New("document fields not found")
+
+// Field 从识别结果中提取的字段
+type Field struct {
+	Value      string  `json:"value"`
+	Confidence float64 `json:"confidence"`
+}
+
+// IDCard 居民身份证字段，正面为个人信息，背面为签发机关和有效期限
+type IDCard struct {
+	Name        Field `json:"name"`
+	Gender      Field `json:"gender"`
+	Ethnicity   Field `json:"ethnicity"`
+	BirthDate   Field `json:"birth_date"`
+	Address     Field `json:"address"`
+	Number      Field `json:"number"`
+	Authority   Field `json:"authority"`
+	ValidFrom   Field `json:"valid_from"`
+	ValidTo     Field `json:"valid_to"`
+	NumberValid bool  `json:"number_valid"` // 号码校验位正确
+}
+
+// BusinessLicense 营业执照字段
+type BusinessLicense struct {
+	CreditCode          Field `json:"credit_code"`
+	Name                Field `json:"name"`
+	Type                Field `json:"type"`
+	LegalRepresentative Field `json:"legal_representative"`
+	RegisteredCapital   Field `json:"registered_capital"`
+	EstablishedDate     Field `json:"established_date"`
+	BusinessTerm        Field `json:"business_term"`
+	Address             Field `json:"address"`
+	BusinessScope       Field `json:"business_scope"`
+	CreditCodeValid     bool  `json:"credit_code_valid"` // 统一社会信用代码校验位正确
+}
+
+var (
+	idNumberPattern   = regexp.MustCompile(`\d{17}[\dXx]`)
+	creditCodePattern = regexp.MustCompile(`[0-9A-HJ-NPQRTUWXY]{2}\d{6}[0-9A-HJ-NPQRTUWXY]{10}`)
+	datePattern       = regexp.MustCompile(`(\d{4})\s*[年.\-/]\s*(\d{1,2})\s*[月.\-/]\s*(\d{1,2})`)
+)
+
+// 字段标签；multiline的字段会吸收其后无标签的行
+type fieldLabel struct {
+	key       string
+	names     []string
+	multiline bool
+}
+
+var idCardLabels = []fieldLabel{
+	{key: "name", names: []string{"姓名"}},
+	{key: "gender", names: []string{"性别"}},
+	{key: "ethnicity", names: []string{"民族"}},
+	{key: "birth", names: []string{"出生"}},
+	{key: "address", names: []string{"住址"}, multiline: true},
+	{key: "number", names: []string{"公民身份号码", "身份号码"}},
+	{key: "authority", names: []string{"签发机关"}},
+	{key: "valid", names: []string{"有效期限"}},
+}
+
+var businessLicenseLabels = []fieldLabel{
+	{key: "credit_code", names: []string{"统一社会信用代码"}},
+	{key: "name", names: []string{"名称"}},
+	{key: "type", names: []string{"类型"}},
+	{key: "legal", names: []string{"法定代表人", "执行事务合伙人", "负责人", "经营者"}},
+	{key: "capital", names: []string{"注册资本"}},
+	{key: "established", names: []string{"成立日期", "注册日期"}},
+	{key: "term", names: []string{"营业期限"}},
+	{key: "address", names: []string{"主要经营场所", "经营场所", "住所"}, multiline: true},
+	{key: "scope", names: []string{"经营范围"}, multiline: true},
+}
+
+// ExtractIDCard 从识别结果中提取身份证字段，可同时包含正反两面
+func ExtractIDCard(res *Result) (*IDCard, error) {
+	lines := res.Lines()
+	fields := extractLabelled(lines, idCardLabels, true)
+
+	card := &IDCard{
+		Name:      fields["name"],
+		Gender:    fields["gender"],
+		Ethnicity: fields["ethnicity"],
+		Address:   fields["address"],
+		Authority: fields["authority"],
+	}
+
+	// 号码可能单独成行或标签识别失败，按格式查找
+	card.Number = fields["number"]
+	if m := idNumberPattern.FindString(card.Number.Value); m != "" {
+		card.Number.Value = strings.ToUpper(m)
+	} else if f, ok := findPattern(lines, idNumberPattern); ok {
+		card.Number = f
+		card.Number.Value = strings.ToUpper(card.Number.Value)
+	} else {
+		card.Number = Field{}
+	}
+	card.NumberValid = ValidIDNumber(card.Number.Value)
+
+	if birth := fields["birth"]; birth.Value != "" {
+		card.BirthDate = Field{Value: normalizeDate(birth.Value), Confidence: birth.Confidence}
+	}
+	// 号码有效时以号码中的出生日期和性别补全
+	if card.NumberValid {
+		n := card.Number.Value
+		if card.BirthDate.Value == "" {
+			card.BirthDate = Field{Value: n[6:10] + "-" + n[10:12] + "-" + n[12:14], Confidence: card.Number.Confidence}
+		}
+		if card.Gender.Value == "" {
+			gender := "女"
+			if (n[16]-'0')%2 == 1 {
+				gender = "男"
+			}
+			card.Gender = Field{Value: gender, Confidence: card.Number.Confidence}
+		}
+	}
+
+	if valid := fields["valid"]; valid.Value != "" {
+		from, to := splitPeriod(valid.Value)
+		card.ValidFrom = Field{Value: from, Confidence: valid.Confidence}
+		card.ValidTo = Field{Value: to, Confidence: valid.Confidence}
+	}
+
+	if card.Name.Value == "" && card.Number.Value == "" && card.Authority.Value == "" {
+		return nil, fmt.Errorf("%w: no ID card fields", ErrFieldsNotFound)
+	}
+	return card, nil
+}
+
+// ExtractBusinessLicense 从识别结果中提取营业执照字段
+func ExtractBusinessLicense(res *Result) (*BusinessLicense, error) {
+	lines := res.Lines()
+	fields := extractLabelled(lines, businessLicenseLabels, false)
+
+	license := &BusinessLicense{
+		Name:                fields["name"],
+		Type:                fields["type"],
+		LegalRepresentative: fields["legal"],
+		RegisteredCapital:   fields["capital"],
+		BusinessTerm:        fields["term"],
+		Address:             fields["address"],
+		BusinessScope:       fields["scope"],
+	}
+
+	license.CreditCode = fields["credit_code"]
+	if m := creditCodePattern.FindString(strings.ToUpper(license.CreditCode.Value)); m != "" {
+		license.CreditCode.Value = m
+	} else if f, ok := findPattern(lines, creditCodePattern); ok {
+		license.CreditCode = f
+	} else {
+		license.CreditCode = Field{}
+	}
+	license.CreditCodeValid = ValidCreditCode(license.CreditCode.Value)
+
+	if established := fields["established"]; established.Value != "" {
+		license.EstablishedDate = Field{Value: normalizeDate(established.Value), Confidence: established.Confidence}
+	}
+	if license.BusinessTerm.Value != "" {
+		from, to := splitPeriod(license.BusinessTerm.Value)
+		if from != "" {
+			license.BusinessTerm.Value = from + "至" + to
+		}
+	}
+
+	if license.Name.Value == "" && license.CreditCode.Value == "" {
+		return nil, fmt.Errorf("%w: no business license fields", ErrFieldsNotFound)
+	}
+	return license, nil
+}
+
+// ValidIDNumber 校验18位居民身份证号码的校验位
+func ValidIDNumber(n string) bool {
+	if !idNumberPattern.MatchString(n) || len(n) != 18 {
+		return false
+	}
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(n[i]-'0') * w
+	}
+	return "10X98765432"[sum%11] == strings.ToUpper(n)[17]
+}
+
+// ValidCreditCode 校验18位统一社会信用代码（GB 32100-2015）的校验位
+func ValidCreditCode(code string) bool {
+	const charset = "0123456789ABCDEFGHJKLMNPQRTUWXY"
+	if len(code) != 18 || !creditCodePattern.MatchString(code) {
+		return false
+	}
+	weights := []int{1, 3, 9, 27, 19, 26, 16, 17, 20, 29, 25, 13, 8, 24, 10, 30, 28}
+	sum := 0
+	for i, w := range weights {
+		sum += strings.IndexByte(charset, code[i]) * w
+	}
+	check := (31 - sum%31) % 31
+	return charset[check] == code[17]
+}
+
+// labelMatch 行内标签出现的位置
+type labelMatch struct {
+	key        string
+	start, end int
+	multiline  bool
+}
+
+// 按标签切分每一行，标签后到下一个标签前的内容为字段值
+// anywhere为false时标签只能出现在行首或空白之后，避免误匹配正文中的词
+func extractLabelled(lines []Line, labels []fieldLabel, anywhere bool) map[string]Field {
+	fields := map[string]Field{}
+	var current *labelMatch // 等待续行的字段
+
+	appendValue := func(key, value string, conf float64) {
+		value = strings.TrimLeft(value, ":：")
+		if value == "" {
+			return
+		}
+		f, ok := fields[key]
+		if !ok || f.Value == "" {
+			fields[key] = Field{Value: value, Confidence: conf}
+			return
+		}
+		f.Value += value
+		f.Confidence = min(f.Confidence, conf)
+		fields[key] = f
+	}
+
+	for _, line := range lines {
+		compact, boundary := compactText(line.Text)
+		matches := findLabels(compact, boundary, labels, anywhere)
+
+		head := len(compact)
+		if len(matches) > 0 {
+			head = matches[0].start
+		}
+		if prefix := string(compact[:head]); prefix != "" && current != nil {
+			appendValue(current.key, prefix, line.Confidence)
+			if !current.multiline {
+				current = nil
+			}
+		}
+
+		for i, m := range matches {
+			end := len(compact)
+			if i+1 < len(matches) {
+				end = matches[i+1].start
+			}
+			value := strings.TrimLeft(string(compact[m.end:end]), ":：")
+			if _, seen := fields[m.key]; seen && fields[m.key].Value != "" {
+				current = nil
+				continue
+			}
+			appendValue(m.key, value, line.Confidence)
+			current = nil
+			if value == "" || m.multiline {
+				match := m
+				current = &match
+			}
+		}
+	}
+	return fields
+}
+
+// 去掉空白，记录每个字符前是否有空白（或位于行首）
+func compactText(s string) ([]rune, []bool) {
+	var compact []rune
+	var boundary []bool
+	space := true
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		compact = append(compact, r)
+		boundary = append(boundary, space)
+		space = false
+	}
+	return compact, boundary
+}
+
+func findLabels(compact []rune, boundary []bool, labels []fieldLabel, anywhere bool) []labelMatch {
+	var matches []labelMatch
+	for i := 0; i < len(compact); {
+		var best *labelMatch
+		if anywhere || boundary[i] {
+			for _, l := range labels {
+				for _, name := range l.names {
+					n := []rune(name)
+					if hasRunePrefix(compact[i:], n) && (best == nil || len(n) > best.end-best.start) {
+						best = &labelMatch{key: l.key, start: i, end: i + len(n), multiline: l.multiline}
+					}
+				}
+			}
+		}
+		if best != nil {
+			matches = append(matches, *best)
+			i = best.end
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+func hasRunePrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// 在所有行中查找符合格式的值，取置信度最高的一处
+func findPattern(lines []Line, pattern *regexp.Regexp) (Field, bool) {
+	var candidates []Field
+	for _, line := range lines {
+		compact, _ := compactText(line.Text)
+		if m := pattern.FindString(strings.ToUpper(string(compact))); m != "" {
+			candidates = append(candidates, Field{Value: m, Confidence: line.Confidence})
+		}
+	}
+	if len(candidates) == 0 {
+		return Field{}, false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Confidence > candidates[j].Confidence })
+	return candidates[0], true
+}
+
+// 1990年3月7日、1990.03.07等格式统一为1990-03-07，无法识别时原样返回
+func normalizeDate(s string) string {
+	m := datePattern.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+	return m[1] + "-" + pad2(m[2]) + "-" + pad2(m[3])
+}
+
+func pad2(s string) string {
+	if len(s) == 1 {
+		return "0" + s
+	}
+	return s
+}
+
+// 拆分有效期限，如"2015.06.01-2035.06.01"、"2015年6月1日至长期"
+func splitPeriod(s string) (string, string) {
+	dates := datePattern.FindAllStringIndex(s, -1)
+	if len(dates) == 0 {
+		return "", ""
+	}
+	from := normalizeDate(s[dates[0][0]:dates[0][1]])
+	if len(dates) > 1 {
+		return from, normalizeDate(s[dates[1][0]:dates[1][1]])
+	}
+	rest := strings.Trim(s[dates[0][1]:], "-—至 日")
+	if strings.Contains(rest, "长期") {
+		return from, "长期"
+	}
+	return from, rest
+}
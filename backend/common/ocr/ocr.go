@@ -0,0 +1,245 @@
+// Package ocr 定义可插拔的OCR识别接口及其实现
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedLanguage 识别引擎不支持请求的语言
+	ErrUnsupportedLanguage = errors.New("unsupported OCR language")
+	// ErrProviderUnavailable 识别引擎不可用（如未安装tesseract）
+	ErrProviderUnavailable = errors.New("OCR provider unavailable")
+	// ErrInvalidImage 输入无法识别为图片
+	ErrInvalidImage = errors.New("invalid OCR image")
+)
+
+// 接口使用的语言代码与tesseract语言包的对应关系
+var languageCodes = map[string]string{
+	"en":    "eng",
+	"zh":    "chi_sim",
+	"zh-tw": "chi_tra",
+	"ja":    "jpn",
+	"ko":    "kor",
+	"fr":    "fra",
+	"de":    "deu",
+	"es":    "spa",
+	"it":    "ita",
+	"pt":    "por",
+	"ru":    "rus",
+}
+
+// BoundingBox 文本在页面中的位置，单位为像素
+type BoundingBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// 合并两个区域
+func (b BoundingBox) union(o BoundingBox) BoundingBox {
+	if b.Width == 0 && b.Height == 0 {
+		return o
+	}
+	if o.Width == 0 && o.Height == 0 {
+		return b
+	}
+	x0, y0 := min(b.X, o.X), min(b.Y, o.Y)
+	x1, y1 := max(b.X+b.Width, o.X+o.Width), max(b.Y+b.Height, o.Y+o.Height)
+	return BoundingBox{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+// Word 单词
+type Word struct {
+	Text       string      `json:"text"`
+	Confidence float64     `json:"confidence"`
+	Box        BoundingBox `json:"box"`
+}
+
+// Line 文本行，置信度为各单词的平均值
+type Line struct {
+	Text       string      `json:"text"`
+	Confidence float64     `json:"confidence"`
+	Box        BoundingBox `json:"box"`
+	Words      []Word      `json:"words,omitempty"`
+}
+
+// Page 单页识别结果，页码从1开始
+type Page struct {
+	Number     int     `json:"number"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Confidence float64 `json:"confidence"`
+	Lines      []Line  `json:"lines"`
+}
+
+// Text 页面文本，每行一行
+func (p *Page) Text() string {
+	texts := make([]string, len(p.Lines))
+	for i := range p.Lines {
+		texts[i] = p.Lines[i].Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Result 识别结果
+type Result struct {
+	Provider   string   `json:"provider"`
+	Languages  []string `json:"languages"`
+	Pages      []Page   `json:"pages"`
+	Confidence float64  `json:"confidence"`
+}
+
+// Text 全部页面的文本，页之间以换页符分隔
+func (r *Result) Text() string {
+	texts := make([]string, len(r.Pages))
+	for i := range r.Pages {
+		texts[i] = r.Pages[i].Text()
+	}
+	return strings.Join(texts, "\f")
+}
+
+// Lines 按页顺序返回所有文本行
+func (r *Result) Lines() []Line {
+	var lines []Line
+	for i := range r.Pages {
+		lines = append(lines, r.Pages[i].Lines...)
+	}
+	return lines
+}
+
+// 按行字数加权计算页面与整体置信度
+func (r *Result) summarize() {
+	var total, weight float64
+	for i := range r.Pages {
+		p := &r.Pages[i]
+		var pageTotal, pageWeight float64
+		for _, l := range p.Lines {
+			w := float64(len([]rune(l.Text)))
+			pageTotal += l.Confidence * w
+			pageWeight += w
+		}
+		if pageWeight > 0 {
+			p.Confidence = pageTotal / pageWeight
+		}
+		total += pageTotal
+		weight += pageWeight
+	}
+	if weight > 0 {
+		r.Confidence = total / weight
+	}
+}
+
+// Options 识别参数
+type Options struct {
+	// Languages 语言提示，按优先级排列，为空时使用引擎默认语言
+	Languages []string
+	// Pages 只返回指定页（从1开始），为空时返回全部页
+	Pages []int
+	// MinConfidence 丢弃置信度低于该值的行
+	MinConfidence float64
+}
+
+// Provider OCR识别引擎
+type Provider interface {
+	// Name 引擎名称
+	Name() string
+	// Languages 支持的语言代码
+	Languages() []string
+	// Recognize 识别图片，多页TIFF等格式按页返回
+	Recognize(ctx context.Context, image io.Reader, opts Options) (*Result, error)
+}
+
+// ParseLanguages 解析"zh+en"、"zh,en"形式的语言参数
+func ParseLanguages(s string) []string {
+	var langs []string
+	for _, l := range strings.FieldsFunc(s, func(r rune) bool { return r == '+' || r == ',' || r == ' ' }) {
+		langs = append(langs, strings.ToLower(l))
+	}
+	return langs
+}
+
+// CheckLanguages 检查语言是否都受引擎支持
+func CheckLanguages(p Provider, langs []string) error {
+	supported := map[string]bool{}
+	for _, l := range p.Languages() {
+		supported[l] = true
+	}
+	for _, l := range langs {
+		if !supported[l] {
+			return errorf(ErrUnsupportedLanguage, "%s (supported: %s)", l, strings.Join(p.Languages(), ", "))
+		}
+	}
+	return nil
+}
+
+// 按页码和置信度筛选结果
+func (r *Result) filter(opts Options) {
+	if len(opts.Pages) > 0 {
+		wanted := map[int]bool{}
+		for _, n := range opts.Pages {
+			wanted[n] = true
+		}
+		pages := r.Pages[:0]
+		for _, p := range r.Pages {
+			if wanted[p.Number] {
+				pages = append(pages, p)
+			}
+		}
+		r.Pages = pages
+	}
+	if opts.MinConfidence > 0 {
+		for i := range r.Pages {
+			lines := r.Pages[i].Lines[:0]
+			for _, l := range r.Pages[i].Lines {
+				if l.Confidence >= opts.MinConfidence {
+					lines = append(lines, l)
+				}
+			}
+			r.Pages[i].Lines = lines
+		}
+	}
+	r.summarize()
+}
+
+func sortedLanguages(m map[string]bool) []string {
+	langs := make([]string, 0, len(m))
+	for l := range m {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+func errorf(err error, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", err, fmt.Sprintf(format, args...))
+}
+
+// Config 识别引擎配置
+type Config struct {
+	// Provider 引擎名称：tesseract或fake
+	Provider string
+	// TesseractPath tesseract可执行文件路径，为空时从PATH中查找
+	TesseractPath string
+	// DefaultLanguages 未指定语言时使用的语言
+	DefaultLanguages []string
+	// PageSegMode tesseract页面分割模式（--psm）
+	PageSegMode int
+}
+
+// NewProvider 按配置创建识别引擎
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", "tesseract":
+		return NewTesseract(cfg.TesseractPath, cfg.DefaultLanguages, cfg.PageSegMode)
+	case "fake":
+		return &Fake{}, nil
+	}
+	return nil, errorf(ErrProviderUnavailable, "unknown provider %q", cfg.Provider)
+}
@@ -0,0 +1,243 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// tesseract 5 对两页TIFF输出的TSV，中文按单字输出
+const tesseractTSV = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+	"1\t1\t0\t0\t0\t0\t0\t0\t800\t600\t-1\t\n" +
+	"2\t1\t1\t0\t0\t0\t20\t30\t400\t60\t-1\t\n" +
+	"3\t1\t1\t1\t0\t0\t20\t30\t400\t60\t-1\t\n" +
+	"4\t1\t1\t1\t1\t0\t20\t30\t300\t24\t-1\t\n" +
+	"5\t1\t1\t1\t1\t1\t20\t30\t40\t24\t96.0\t姓\n" +
+	"5\t1\t1\t1\t1\t2\t64\t30\t40\t24\t94.0\t名\n" +
+	"5\t1\t1\t1\t1\t3\t120\t30\t100\t24\t90.0\tAlice\n" +
+	"4\t1\t1\t1\t2\t0\t20\t66\t200\t24\t-1\t\n" +
+	"5\t1\t1\t1\t2\t1\t20\t66\t80\t24\t80.0\tHello\n" +
+	"5\t1\t1\t1\t2\t2\t110\t66\t90\t24\t70.0\tworld\n" +
+	"4\t1\t1\t1\t3\t0\t20\t100\t200\t24\t-1\t\n" +
+	"5\t1\t1\t1\t3\t1\t20\t100\t80\t24\t-1\t \n" +
+	"1\t2\t0\t0\t0\t0\t0\t0\t800\t600\t-1\t\n" +
+	"4\t2\t1\t1\t1\t0\t10\t10\t100\t20\t-1\t\n" +
+	"5\t2\t1\t1\t1\t1\t10\t10\t100\t20\t50.0\tSecond\n"
+
+func TestParseTesseractTSV(t *testing.T) {
+	pages, err := parseTSV(strings.NewReader(tesseractTSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+
+	p := pages[0]
+	if p.Number != 1 || p.Width != 800 || p.Height != 600 {
+		t.Errorf("page 1 = %+v", p)
+	}
+	// 空行被丢弃
+	if len(p.Lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %+v", len(p.Lines), p.Lines)
+	}
+	first := p.Lines[0]
+	if first.Text != "姓名 Alice" {
+		t.Errorf("CJK words should join without spaces, got %q", first.Text)
+	}
+	if math.Abs(first.Confidence-0.9333) > 0.001 {
+		t.Errorf("line confidence = %v", first.Confidence)
+	}
+	if first.Box != (BoundingBox{X: 20, Y: 30, Width: 300, Height: 24}) {
+		t.Errorf("line box = %+v", first.Box)
+	}
+	if len(first.Words) != 3 || first.Words[2].Box.X != 120 {
+		t.Errorf("words = %+v", first.Words)
+	}
+	if p.Lines[1].Text != "Hello world" {
+		t.Errorf("second line = %q", p.Lines[1].Text)
+	}
+	if pages[1].Number != 2 || pages[1].Lines[0].Text != "Second" {
+		t.Errorf("page 2 = %+v", pages[1])
+	}
+}
+
+func TestResultFilterAndConfidence(t *testing.T) {
+	pages, err := parseTSV(strings.NewReader(tesseractTSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &Result{Pages: pages}
+	res.filter(Options{Pages: []int{1}, MinConfidence: 0.8})
+
+	if len(res.Pages) != 1 || len(res.Pages[0].Lines) != 1 {
+		t.Fatalf("filter kept %+v", res.Pages)
+	}
+	if res.Text() != "姓名 Alice" {
+		t.Errorf("text = %q", res.Text())
+	}
+	if math.Abs(res.Confidence-res.Pages[0].Lines[0].Confidence) > 1e-9 {
+		t.Errorf("confidence = %v", res.Confidence)
+	}
+}
+
+func TestParseLanguageList(t *testing.T) {
+	out := "List of available languages in \"/usr/share/tessdata/\" (4):\nchi_sim\neng\nosd\nvie\n"
+	got := strings.Join(parseLanguageList([]byte(out)), ",")
+	if got != "en,vie,zh" {
+		t.Errorf("languages = %s", got)
+	}
+}
+
+func TestSpoolImageRejectsNonImages(t *testing.T) {
+	if _, err := spoolImage(strings.NewReader("%PDF-1.4")); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("expected ErrInvalidImage, got %v", err)
+	}
+	if !isImage([]byte("\x89PNG\r\n\x1a\n")) || !isImage([]byte("RIFF\x00\x00\x00\x00WEBP")) {
+		t.Error("PNG/WEBP signatures not recognised")
+	}
+}
+
+func TestFakeProviderIsDeterministic(t *testing.T) {
+	f := &Fake{Confidence: 0.9}
+	input := "第一行 text\n\nsecond line\fpage two"
+
+	a, err := f.Recognize(context.Background(), strings.NewReader(input), Options{Languages: []string{"zh"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := f.Recognize(context.Background(), strings.NewReader(input), Options{Languages: []string{"zh"}})
+
+	if a.Text() != "第一行 text\nsecond line\fpage two" {
+		t.Errorf("text = %q", a.Text())
+	}
+	if len(a.Pages) != 2 || a.Pages[0].Lines[1].Box.Y != fakeMargin+2*fakeLineHeight {
+		t.Errorf("unexpected layout %+v", a.Pages)
+	}
+	if a.Pages[0].Lines[0].Words[0].Box.Width != 3*2*fakeCharWidth {
+		t.Errorf("CJK word width = %d", a.Pages[0].Lines[0].Words[0].Box.Width)
+	}
+	if a.Confidence != 0.9 || a.Provider != "fake" {
+		t.Errorf("result = %+v", a)
+	}
+	if a.Text() != b.Text() || a.Pages[0].Lines[0].Box != b.Pages[0].Lines[0].Box {
+		t.Error("fake provider is not deterministic")
+	}
+
+	if _, err := f.Recognize(context.Background(), strings.NewReader(input), Options{Languages: []string{"xx"}}); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("expected ErrUnsupportedLanguage, got %v", err)
+	}
+}
+
+func recognize(t *testing.T, text string) *Result {
+	t.Helper()
+	res, err := (&Fake{}).Recognize(context.Background(), strings.NewReader(text), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestExtractIDCard(t *testing.T) {
+	res := recognize(t, "姓 名 张三\n性别男 民族汉\n出生 1949年12月31日\n住址 北京市朝阳区\n建国路1号\n公民身份号码 11010519491231002x\f"+
+		"中华人民共和国\n居民身份证\n签发机关 北京市公安局朝阳分局\n有效期限 2015.06.01-长期")
+
+	card, err := ExtractIDCard(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"name":       card.Name.Value,
+		"gender":     card.Gender.Value,
+		"ethnicity":  card.Ethnicity.Value,
+		"birth":      card.BirthDate.Value,
+		"address":    card.Address.Value,
+		"number":     card.Number.Value,
+		"authority":  card.Authority.Value,
+		"valid_from": card.ValidFrom.Value,
+		"valid_to":   card.ValidTo.Value,
+	}
+	expected := map[string]string{
+		"name":       "张三",
+		"gender":     "男",
+		"ethnicity":  "汉",
+		"birth":      "1949-12-31",
+		"address":    "北京市朝阳区建国路1号",
+		"number":     "11010519491231002X",
+		"authority":  "北京市公安局朝阳分局",
+		"valid_from": "2015-06-01",
+		"valid_to":   "长期",
+	}
+	for k, v := range expected {
+		if want[k] != v {
+			t.Errorf("%s = %q, want %q", k, want[k], v)
+		}
+	}
+	if !card.NumberValid {
+		t.Error("number checksum should be valid")
+	}
+	if card.Name.Confidence != 0.99 {
+		t.Errorf("field confidence = %v", card.Name.Confidence)
+	}
+}
+
+func TestExtractIDCardFallsBackToNumber(t *testing.T) {
+	// 标签识别失败时从号码推导出生日期和性别
+	card, err := ExtractIDCard(recognize(t, "姓名 李四\n110105194912310021"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.NumberValid {
+		t.Error("checksum of 110105194912310021 should be invalid")
+	}
+	if card.BirthDate.Value != "" {
+		t.Error("birth date should not be derived from an invalid number")
+	}
+
+	card, err = ExtractIDCard(recognize(t, "姓名 李四\n11010519491231002X"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.BirthDate.Value != "1949-12-31" || card.Gender.Value != "女" {
+		t.Errorf("derived birth/gender = %q/%q", card.BirthDate.Value, card.Gender.Value)
+	}
+
+	if _, err := ExtractIDCard(recognize(t, "hello world")); !errors.Is(err, ErrFieldsNotFound) {
+		t.Errorf("expected ErrFieldsNotFound, got %v", err)
+	}
+}
+
+func TestExtractBusinessLicense(t *testing.T) {
+	res := recognize(t, "营业执照\n统一社会信用代码 91350100M000100Y43\n名 称 福州某某科技有限公司\n类 型 有限责任公司\n"+
+		"法定代表人 王五\n经营范围 软件开发；信息技术咨询服务；\n数据处理服务。\n注册资本 壹佰万元整\n成立日期 2018年3月5日\n"+
+		"营业期限 2018年03月05日至长期\n住 所 福建省福州市鼓楼区软件大道89号")
+
+	license, err := ExtractBusinessLicense(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct{ name, got, want string }{
+		{"credit_code", license.CreditCode.Value, "91350100M000100Y43"},
+		{"name", license.Name.Value, "福州某某科技有限公司"},
+		{"type", license.Type.Value, "有限责任公司"},
+		{"legal", license.LegalRepresentative.Value, "王五"},
+		{"scope", license.BusinessScope.Value, "软件开发；信息技术咨询服务；数据处理服务。"},
+		{"capital", license.RegisteredCapital.Value, "壹佰万元整"},
+		{"established", license.EstablishedDate.Value, "2018-03-05"},
+		{"term", license.BusinessTerm.Value, "2018-03-05至长期"},
+		{"address", license.Address.Value, "福建省福州市鼓楼区软件大道89号"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.name, c.got, c.want)
+		}
+	}
+	if !license.CreditCodeValid {
+		t.Error("credit code checksum should be valid")
+	}
+	if ValidCreditCode("91350100M000100Y44") {
+		t.Error("altered credit code should be invalid")
+	}
+}
@@ -0,0 +1,299 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Tesseract 调用本机安装的tesseract命令行进行识别
+type Tesseract struct {
+	path             string
+	defaultLanguages []string
+	pageSegMode      int
+
+	langOnce  sync.Once
+	languages []string
+	langErr   error
+}
+
+// NewTesseract 查找tesseract可执行文件，path为空时从PATH中查找
+func NewTesseract(path string, defaultLanguages []string, pageSegMode int) (*Tesseract, error) {
+	if path == "" {
+		path = "tesseract"
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, errorf(ErrProviderUnavailable, "tesseract not found: %v", err)
+	}
+	if len(defaultLanguages) == 0 {
+		defaultLanguages = []string{"en"}
+	}
+	if pageSegMode <= 0 {
+		pageSegMode = 3
+	}
+	return &Tesseract{path: resolved, defaultLanguages: defaultLanguages, pageSegMode: pageSegMode}, nil
+}
+
+// Name 引擎名称
+func (t *Tesseract) Name() string {
+	return "tesseract"
+}
+
+// Languages 已安装的语言包，首次调用时通过--list-langs获取
+func (t *Tesseract) Languages() []string {
+	t.langOnce.Do(func() {
+		out, err := exec.Command(t.path, "--list-langs").Output()
+		if err != nil {
+			t.langErr = err
+			return
+		}
+		t.languages = parseLanguageList(out)
+	})
+	return t.languages
+}
+
+// tesseract语言包名转换为接口语言代码，没有对应关系的保留原名
+func parseLanguageList(out []byte) []string {
+	reverse := map[string]string{}
+	for code, tess := range languageCodes {
+		reverse[tess] = code
+	}
+
+	langs := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// 第一行为"List of available languages ..."，osd仅用于方向检测
+		if line == "" || strings.Contains(line, " ") || line == "osd" {
+			continue
+		}
+		if code, ok := reverse[line]; ok {
+			langs[code] = true
+		} else {
+			langs[line] = true
+		}
+	}
+	return sortedLanguages(langs)
+}
+
+// Recognize 以TSV格式输出识别结果，包含每个单词的位置和置信度
+func (t *Tesseract) Recognize(ctx context.Context, image io.Reader, opts Options) (*Result, error) {
+	langs := opts.Languages
+	if len(langs) == 0 {
+		langs = t.defaultLanguages
+	}
+	if err := CheckLanguages(t, langs); err != nil {
+		if t.langErr != nil {
+			return nil, errorf(ErrProviderUnavailable, "list languages: %v", t.langErr)
+		}
+		return nil, err
+	}
+	tessLangs := make([]string, len(langs))
+	for i, l := range langs {
+		tessLangs[i] = tesseractLanguage(l)
+	}
+
+	input, err := spoolImage(image)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(input)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.path, input, "stdout",
+		"-l", strings.Join(tessLangs, "+"), "--psm", strconv.Itoa(t.pageSegMode), "tsv")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("tesseract: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	pages, err := parseTSV(&stdout)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Provider: t.Name(), Languages: langs, Pages: pages}
+	result.filter(opts)
+	return result, nil
+}
+
+func tesseractLanguage(code string) string {
+	if tess, ok := languageCodes[code]; ok {
+		return tess
+	}
+	return code
+}
+
+// 输入写入临时文件，并检查文件头是否为常见图片格式
+func spoolImage(image io.Reader) (string, error) {
+	f, err := os.CreateTemp("", "ocr-*")
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+
+	br := bufio.NewReader(image)
+	head, _ := br.Peek(12)
+	if !isImage(head) {
+		f.Close()
+		os.Remove(name)
+		return "", errorf(ErrInvalidImage, "unrecognized image format")
+	}
+	if _, err := io.Copy(f, br); err != nil {
+		f.Close()
+		os.Remove(name)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+func isImage(head []byte) bool {
+	signatures := [][]byte{
+		{0x89, 'P', 'N', 'G'},
+		{0xFF, 0xD8, 0xFF},
+		[]byte("GIF8"),
+		[]byte("BM"),
+		[]byte("II*\x00"),
+		[]byte("MM\x00*"),
+	}
+	for _, sig := range signatures {
+		if bytes.HasPrefix(head, sig) {
+			return true
+		}
+	}
+	return len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP"
+}
+
+// tsvKey 标识一行文本：页、块、段落、行
+type tsvKey struct{ page, block, par, line int }
+
+// 解析tesseract的TSV输出
+// 列依次为 level page_num block_num par_num line_num word_num left top width height conf text
+func parseTSV(r io.Reader) ([]Page, error) {
+	var pages []Page
+	pageIndex := map[int]int{}
+	lineIndex := map[tsvKey][2]int{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	first := true
+	for scanner.Scan() {
+		if first {
+			first = false
+			if strings.HasPrefix(scanner.Text(), "level") {
+				continue
+			}
+		}
+		cols := strings.SplitN(scanner.Text(), "\t", 12)
+		if len(cols) < 11 {
+			continue
+		}
+		var nums [10]int
+		for i := range nums {
+			n, err := strconv.Atoi(cols[i])
+			if err != nil {
+				return nil, fmt.Errorf("tesseract: invalid TSV row %q", scanner.Text())
+			}
+			nums[i] = n
+		}
+		level, pageNum := nums[0], nums[1]
+		box := BoundingBox{X: nums[6], Y: nums[7], Width: nums[8], Height: nums[9]}
+
+		pi, ok := pageIndex[pageNum]
+		if !ok {
+			pi = len(pages)
+			pageIndex[pageNum] = pi
+			pages = append(pages, Page{Number: pageNum})
+		}
+		page := &pages[pi]
+
+		switch level {
+		case 1:
+			page.Width, page.Height = box.X+box.Width, box.Y+box.Height
+		case 4, 5:
+			key := tsvKey{pageNum, nums[2], nums[3], nums[4]}
+			loc, ok := lineIndex[key]
+			if !ok {
+				loc = [2]int{pi, len(page.Lines)}
+				lineIndex[key] = loc
+				page.Lines = append(page.Lines, Line{Box: box})
+			}
+			if level == 5 {
+				text := ""
+				if len(cols) == 12 {
+					text = strings.TrimSpace(cols[11])
+				}
+				conf, _ := strconv.ParseFloat(cols[10], 64)
+				if text == "" || conf < 0 {
+					continue
+				}
+				line := &pages[loc[0]].Lines[loc[1]]
+				line.Words = append(line.Words, Word{Text: text, Confidence: conf / 100, Box: box})
+				line.Box = line.Box.union(box)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range pages {
+		lines := pages[i].Lines[:0]
+		for _, l := range pages[i].Lines {
+			if len(l.Words) == 0 {
+				continue
+			}
+			l.Text, l.Confidence = joinWords(l.Words)
+			lines = append(lines, l)
+		}
+		pages[i].Lines = lines
+	}
+	return pages, nil
+}
+
+// 拼接单词，中日韩文字之间不加空格；返回文本与平均置信度
+func joinWords(words []Word) (string, float64) {
+	var sb strings.Builder
+	var conf float64
+	for i, w := range words {
+		if i > 0 && !(endsWithCJK(words[i-1].Text) && startsWithCJK(w.Text)) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(w.Text)
+		conf += w.Confidence
+	}
+	return sb.String(), conf / float64(len(words))
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+func startsWithCJK(s string) bool {
+	for _, r := range s {
+		return isCJK(r)
+	}
+	return false
+}
+
+func endsWithCJK(s string) bool {
+	rs := []rune(s)
+	return len(rs) > 0 && isCJK(rs[len(rs)-1])
+}
@@ -0,0 +1,106 @@
+package skills
+
+// 技能分类
+const (
+	CategoryLanguage  = "language"
+	CategoryFramework = "framework"
+	CategoryFrontend  = "frontend"
+	CategoryDatabase  = "database"
+	CategoryDevOps    = "devops"
+	CategoryCloud     = "cloud"
+	CategoryData      = "data"
+	CategoryAI        = "ai"
+	CategoryTesting   = "testing"
+	CategoryDesign    = "design"
+	CategoryTool      = "tool"
+	CategorySoft      = "soft_skill"
+)
+
+// Builtin 内置技能词典
+var Builtin = []Skill{
+	{ID: "go", Name: "Go", Category: CategoryLanguage, Aliases: []string{"golang", "go语言"}},
+	{ID: "java", Name: "Java", Category: CategoryLanguage, Aliases: []string{"java se", "java ee", "j2ee"}},
+	{ID: "python", Name: "Python", Category: CategoryLanguage, Aliases: []string{"python3", "py"}},
+	{ID: "javascript", Name: "JavaScript", Category: CategoryLanguage, Aliases: []string{"js", "es6", "ecmascript"}},
+	{ID: "typescript", Name: "TypeScript", Category: CategoryLanguage, Aliases: []string{"ts"}},
+	{ID: "cpp", Name: "C++", Category: CategoryLanguage, Aliases: []string{"cplusplus", "c plus plus"}},
+	{ID: "csharp", Name: "C#", Category: CategoryLanguage, Aliases: []string{"c sharp"}},
+	{ID: "c", Name: "C", Category: CategoryLanguage, Aliases: []string{"c语言"}},
+	{ID: "php", Name: "PHP", Category: CategoryLanguage},
+	{ID: "rust", Name: "Rust", Category: CategoryLanguage},
+	{ID: "kotlin", Name: "Kotlin", Category: CategoryLanguage},
+	{ID: "swift", Name: "Swift", Category: CategoryLanguage},
+	{ID: "scala", Name: "Scala", Category: CategoryLanguage},
+	{ID: "shell", Name: "Shell", Category: CategoryLanguage, Aliases: []string{"bash", "shell脚本"}},
+	{ID: "sql", Name: "SQL", Category: CategoryLanguage},
+	{ID: "spring-boot", Name: "Spring Boot", Category: CategoryFramework},
+	{ID: "spring-cloud", Name: "Spring Cloud", Category: CategoryFramework},
+	{ID: "spring", Name: "Spring", Category: CategoryFramework, Aliases: []string{"spring framework", "spring mvc"}},
+	{ID: "gin", Name: "Gin", Category: CategoryFramework, Aliases: []string{"gin-gonic"}},
+	{ID: "django", Name: "Django", Category: CategoryFramework},
+	{ID: "flask", Name: "Flask", Category: CategoryFramework},
+	{ID: "mybatis", Name: "MyBatis", Category: CategoryFramework, Aliases: []string{"mybatis-plus", "ibatis"}},
+	{ID: "grpc", Name: "gRPC", Category: CategoryFramework, Aliases: []string{"grpc-go"}},
+	{ID: "dotnet", Name: ".NET", Category: CategoryFramework, Aliases: []string{"dotnet", "asp.net", ".net core"}},
+	{ID: "microservices", Name: "微服务", Category: CategoryFramework, Aliases: []string{"microservice", "microservices"}},
+	{ID: "architecture", Name: "架构设计", Category: CategoryFramework, Aliases: []string{"system design", "software architecture"}},
+	{ID: "react", Name: "React", Category: CategoryFrontend, Aliases: []string{"react.js", "reactjs"}},
+	{ID: "vue", Name: "Vue.js", Category: CategoryFrontend, Aliases: []string{"vue", "vue2", "vue3"}},
+	{ID: "angular", Name: "Angular", Category: CategoryFrontend, Aliases: []string{"angularjs"}},
+	{ID: "html", Name: "HTML", Category: CategoryFrontend, Aliases: []string{"html5"}},
+	{ID: "css", Name: "CSS", Category: CategoryFrontend, Aliases: []string{"css3", "sass", "less"}},
+	{ID: "nodejs", Name: "Node.js", Category: CategoryFrontend, Aliases: []string{"node"}},
+	{ID: "webpack", Name: "Webpack", Category: CategoryFrontend},
+	{ID: "mini-program", Name: "微信小程序", Category: CategoryFrontend, Aliases: []string{"小程序", "wechat mini program"}},
+	{ID: "mobile", Name: "移动开发", Category: CategoryFrontend, Aliases: []string{"android", "ios"}},
+	{ID: "mysql", Name: "MySQL", Category: CategoryDatabase},
+	{ID: "postgresql", Name: "PostgreSQL", Category: CategoryDatabase, Aliases: []string{"postgres", "pgsql"}},
+	{ID: "oracle", Name: "Oracle", Category: CategoryDatabase, Aliases: []string{"oracle db"}},
+	{ID: "redis", Name: "Redis", Category: CategoryDatabase},
+	{ID: "mongodb", Name: "MongoDB", Category: CategoryDatabase, Aliases: []string{"mongo"}},
+	{ID: "elasticsearch", Name: "Elasticsearch", Category: CategoryDatabase, Aliases: []string{"es", "elk", "搜索引擎"}},
+	{ID: "neo4j", Name: "Neo4j", Category: CategoryDatabase},
+	{ID: "kafka", Name: "Kafka", Category: CategoryDatabase, Aliases: []string{"apache kafka"}},
+	{ID: "rabbitmq", Name: "RabbitMQ", Category: CategoryDatabase, Aliases: []string{"rabbit mq"}},
+	{ID: "docker", Name: "Docker", Category: CategoryDevOps, Aliases: []string{"容器"}},
+	{ID: "kubernetes", Name: "Kubernetes", Category: CategoryDevOps, Aliases: []string{"k8s"}},
+	{ID: "linux", Name: "Linux", Category: CategoryDevOps},
+	{ID: "nginx", Name: "Nginx", Category: CategoryDevOps},
+	{ID: "cicd", Name: "CI/CD", Category: CategoryDevOps, Aliases: []string{"持续集成", "continuous integration"}},
+	{ID: "jenkins", Name: "Jenkins", Category: CategoryDevOps},
+	{ID: "prometheus", Name: "Prometheus", Category: CategoryDevOps},
+	{ID: "devops", Name: "运维", Category: CategoryDevOps, Aliases: []string{"devops", "运维开发", "sre"}},
+	{ID: "network-security", Name: "网络安全", Category: CategoryDevOps, Aliases: []string{"security", "信息安全"}},
+	{ID: "git", Name: "Git", Category: CategoryTool, Aliases: []string{"github", "gitlab"}},
+	{ID: "excel", Name: "Excel", Category: CategoryTool, Aliases: []string{"ms excel"}},
+	{ID: "aws", Name: "AWS", Category: CategoryCloud, Aliases: []string{"amazon web services"}},
+	{ID: "aliyun", Name: "阿里云", Category: CategoryCloud, Aliases: []string{"aliyun", "alibaba cloud"}},
+	{ID: "tencent-cloud", Name: "腾讯云", Category: CategoryCloud, Aliases: []string{"tencent cloud", "qcloud"}},
+	{ID: "spark", Name: "Spark", Category: CategoryData, Aliases: []string{"apache spark"}},
+	{ID: "hadoop", Name: "Hadoop", Category: CategoryData, Aliases: []string{"hdfs", "hive"}},
+	{ID: "flink", Name: "Flink", Category: CategoryData, Aliases: []string{"apache flink"}},
+	{ID: "big-data", Name: "大数据", Category: CategoryData, Aliases: []string{"big data"}},
+	{ID: "data-analysis", Name: "数据分析", Category: CategoryData, Aliases: []string{"data analysis", "数据分析师"}},
+	{ID: "data-warehouse", Name: "数据仓库", Category: CategoryData, Aliases: []string{"数仓", "data warehouse"}},
+	{ID: "crawler", Name: "爬虫", Category: CategoryData, Aliases: []string{"web crawler", "网络爬虫"}},
+	{ID: "machine-learning", Name: "机器学习", Category: CategoryAI, Aliases: []string{"machine learning", "ml"}},
+	{ID: "deep-learning", Name: "深度学习", Category: CategoryAI, Aliases: []string{"deep learning", "dl"}},
+	{ID: "nlp", Name: "自然语言处理", Category: CategoryAI, Aliases: []string{"nlp", "natural language processing"}},
+	{ID: "computer-vision", Name: "计算机视觉", Category: CategoryAI, Aliases: []string{"cv", "computer vision", "图像识别"}},
+	{ID: "recommender-systems", Name: "推荐算法", Category: CategoryAI, Aliases: []string{"推荐系统", "recommender system"}},
+	{ID: "algorithms", Name: "算法", Category: CategoryAI, Aliases: []string{"数据结构", "algorithm"}},
+	{ID: "tensorflow", Name: "TensorFlow", Category: CategoryAI, Aliases: []string{"tf"}},
+	{ID: "pytorch", Name: "PyTorch", Category: CategoryAI, Aliases: []string{"torch"}},
+	{ID: "testing", Name: "软件测试", Category: CategoryTesting, Aliases: []string{"测试", "qa", "testing"}},
+	{ID: "test-automation", Name: "自动化测试", Category: CategoryTesting, Aliases: []string{"test automation", "selenium"}},
+	{ID: "performance-tuning", Name: "性能优化", Category: CategoryTesting, Aliases: []string{"performance tuning", "性能测试"}},
+	{ID: "ui-design", Name: "UI设计", Category: CategoryDesign, Aliases: []string{"ui", "界面设计"}},
+	{ID: "ux", Name: "用户体验", Category: CategoryDesign, Aliases: []string{"ux", "交互设计"}},
+	{ID: "photoshop", Name: "Photoshop", Category: CategoryDesign, Aliases: []string{"ps"}},
+	{ID: "figma", Name: "Figma", Category: CategoryDesign},
+	{ID: "product-management", Name: "产品经理", Category: CategorySoft, Aliases: []string{"产品设计", "product manager", "pm"}},
+	{ID: "project-management", Name: "项目管理", Category: CategorySoft, Aliases: []string{"project management", "pmp"}},
+	{ID: "english", Name: "英语", Category: CategorySoft, Aliases: []string{"english", "cet-6", "cet6", "cet-4"}},
+	{ID: "communication", Name: "沟通能力", Category: CategorySoft, Aliases: []string{"沟通", "communication"}},
+	{ID: "teamwork", Name: "团队协作", Category: CategorySoft, Aliases: []string{"团队合作", "teamwork"}},
+}
@@ -0,0 +1,186 @@
+// Package skills 技能标准化：把职位、简历和搜索中的技能名称解析为统一的技能ID，
+// 依次尝试标准名/别名、拼音（全拼或首字母）和编辑距离模糊匹配，并提供按必备/加分项加权的技能匹配
+package skills
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Skill 标准技能
+type Skill struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+// 匹配方式
+const (
+	MethodExact  = "exact"  // 标准名或别名
+	MethodPinyin = "pinyin" // 拼音全拼或首字母
+	MethodFuzzy  = "fuzzy"  // 编辑距离
+)
+
+// Match 名称解析结果
+type Match struct {
+	Skill  Skill  `json:"skill"`
+	Method string `json:"method"`
+	// Distance 模糊匹配的编辑距离，其它方式为0
+	Distance int `json:"distance"`
+}
+
+// Dictionary 技能词典
+type Dictionary struct {
+	skills map[string]Skill  // ID -> 技能
+	keys   map[string]string // Key(名称或别名) -> ID
+	pinyin map[string]string // 中文名的拼音全拼/首字母 -> ID
+}
+
+// NewDictionary 建立词典，键冲突时先出现的技能生效
+func NewDictionary(entries []Skill) *Dictionary {
+	d := &Dictionary{
+		skills: make(map[string]Skill, len(entries)),
+		keys:   make(map[string]string),
+		pinyin: make(map[string]string),
+	}
+	for _, s := range entries {
+		d.skills[s.ID] = s
+		for _, name := range append([]string{s.ID, s.Name}, s.Aliases...) {
+			key := Key(name)
+			if _, exists := d.keys[key]; !exists && key != "" {
+				d.keys[key] = s.ID
+			}
+			if full, initials, ok := Pinyin(name); ok {
+				for _, p := range []string{full, initials} {
+					if _, exists := d.pinyin[p]; !exists && len(p) >= 2 {
+						d.pinyin[p] = s.ID
+					}
+				}
+			}
+		}
+	}
+	return d
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDict *Dictionary
+)
+
+// Default 内置词典
+func Default() *Dictionary {
+	defaultOnce.Do(func() { defaultDict = NewDictionary(Builtin) })
+	return defaultDict
+}
+
+// Get 按ID取技能
+func (d *Dictionary) Get(id string) (Skill, bool) {
+	s, ok := d.skills[id]
+	return s, ok
+}
+
+// Skills 按ID排序的全部技能
+func (d *Dictionary) Skills() []Skill {
+	list := make([]Skill, 0, len(d.skills))
+	for _, s := range d.skills {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Match 解析技能名称，无法可靠匹配时返回false
+func (d *Dictionary) Match(name string) (Match, bool) {
+	key := Key(name)
+	if key == "" {
+		return Match{}, false
+	}
+	if id, ok := d.keys[key]; ok {
+		return Match{Skill: d.skills[id], Method: MethodExact}, true
+	}
+	if isASCIILetters(key) {
+		if id, ok := d.pinyin[key]; ok {
+			return Match{Skill: d.skills[id], Method: MethodPinyin}, true
+		}
+	} else if full, _, ok := Pinyin(name); ok {
+		// 中文同音写法（如“数剧分析”）按全拼匹配
+		if id, ok := d.pinyin[full]; ok {
+			return Match{Skill: d.skills[id], Method: MethodPinyin}, true
+		}
+	}
+	return d.fuzzy(key)
+}
+
+// ID 技能ID；无法匹配时返回名称的Key，保证同一写法的未知技能也能互相匹配
+func (d *Dictionary) ID(name string) string {
+	if m, ok := d.Match(name); ok {
+		return m.Skill.ID
+	}
+	return Key(name)
+}
+
+// Resolve 返回技能ID和展示名，未知技能保留原名称
+func (d *Dictionary) Resolve(name string) (id, display string) {
+	if m, ok := d.Match(name); ok {
+		return m.Skill.ID, m.Skill.Name
+	}
+	return Key(name), strings.TrimSpace(name)
+}
+
+// fuzzy 编辑距离匹配：短词不做模糊匹配，距离相同的候选有多个时视为歧义
+func (d *Dictionary) fuzzy(key string) (Match, bool) {
+	n := len([]rune(key))
+	maxDistance := 0
+	switch {
+	case n >= 8:
+		maxDistance = 2
+	case n >= 4:
+		maxDistance = 1
+	}
+	if maxDistance == 0 {
+		return Match{}, false
+	}
+
+	best, bestDistance, ambiguous := "", maxDistance+1, false
+	for candidate, id := range d.keys {
+		dist := Distance(key, candidate)
+		if dist > maxDistance || dist > bestDistance {
+			continue
+		}
+		if dist == bestDistance && id != best {
+			ambiguous = true
+			continue
+		}
+		if dist < bestDistance {
+			best, bestDistance, ambiguous = id, dist, false
+		}
+	}
+	if best == "" || ambiguous {
+		return Match{}, false
+	}
+	return Match{Skill: d.skills[best], Method: MethodFuzzy, Distance: bestDistance}, true
+}
+
+// Key 比较用的键：小写，去掉空白和.-_/等分隔符，保留+#以区分C++、C#
+func Key(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isASCIILetters(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
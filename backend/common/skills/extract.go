@@ -0,0 +1,66 @@
+package skills
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 英文技能名最多由几个词组成，如“Spring Boot”“CI/CD”
+const maxPhraseWords = 3
+
+// Extract 找出自由文本（简历正文、职位描述）中提到的技能，返回排序后的技能ID。
+// 只做标准名/别名的精确匹配：英文按词及相邻词组匹配，中文按子串匹配；单字母的名称（如C、R）不参与
+func (d *Dictionary) Extract(text string) []string {
+	found := make(map[string]bool)
+
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), isWordSeparator) {
+		if w = strings.Trim(w, "."); w != "" {
+			words = append(words, w)
+		}
+	}
+	// 从每个位置优先匹配最长的词组，“Spring Boot”不再单独算作Spring
+	for i := 0; i < len(words); {
+		n := min(maxPhraseWords, len(words)-i)
+		for ; n > 0; n-- {
+			key := Key(strings.Join(words[i:i+n], ""))
+			if id, ok := d.keys[key]; ok && len(key) >= 2 && isASCII(key) {
+				found[id] = true
+				break
+			}
+		}
+		i += max(n, 1)
+	}
+
+	compact := Key(text)
+	for key, id := range d.keys {
+		if !isASCII(key) && strings.Contains(compact, key) {
+			found[id] = true
+		}
+	}
+
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// isWordSeparator 英文分词：字母、数字和+#.之外的字符都是分隔符，中文字符也作为分隔
+func isWordSeparator(r rune) bool {
+	if r > unicode.MaxASCII {
+		return true
+	}
+	return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#' || r == '.')
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
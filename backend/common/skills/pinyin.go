@@ -0,0 +1,61 @@
+package skills
+
+import (
+	"strings"
+	"unicode"
+)
+
+// pinyinTable 技能名称常用汉字的拼音（不带声调），多音字取技能语境下的读音
+var pinyinTable = map[rune]string{
+	'安': "an", '案': "an", '本': "ben", '编': "bian", '别': "bie", '部': "bu",
+	'财': "cai", '仓': "cang", '测': "ce", '产': "chan", '程': "cheng", '成': "cheng",
+	'持': "chi", '虫': "chong", '处': "chu", '础': "chu", '储': "chu", '存': "cun",
+	'大': "da", '道': "dao", '德': "de", '动': "dong", '度': "du", '端': "duan",
+	'队': "dui", '发': "fa", '法': "fa", '分': "fen", '服': "fu", '工': "gong",
+	'构': "gou", '沟': "gou", '管': "guan", '过': "guo", '合': "he", '后': "hou",
+	'互': "hu", '户': "hu", '化': "hua", '划': "hua", '会': "kuai", '机': "ji",
+	'基': "ji", '计': "ji", '技': "ji", '集': "ji", '架': "jia", '件': "jian",
+	'检': "jian", '荐': "jian", '交': "jiao", '脚': "jiao", '界': "jie", '经': "jing",
+	'据': "ju", '剧': "ju", '觉': "jue", '开': "kai", '客': "ke", '库': "ku",
+	'块': "kuai", '理': "li", '里': "li", '力': "li", '量': "liang", '联': "lian",
+	'链': "lian", '流': "liu", '络': "luo", '码': "ma", '面': "mian", '目': "mu",
+	'能': "neng", '爬': "pa", '频': "pin", '品': "pin", '器': "qi", '企': "qi",
+	'前': "qian", '嵌': "qian", '擎': "qing", '区': "qu", '全': "quan", '然': "ran",
+	'人': "ren", '容': "rong", '入': "ru", '软': "ruan", '设': "she", '深': "shen",
+	'师': "shi", '识': "shi", '式': "shi", '试': "shi", '视': "shi", '售': "shou",
+	'数': "shu", '搜': "sou", '算': "suan", '索': "suo", '体': "ti", '通': "tong",
+	'统': "tong", '图': "tu", '团': "tuan", '推': "tui", '网': "wang", '微': "wei",
+	'维': "wei", '文': "wen", '务': "wu", '物': "wu", '析': "xi", '习': "xi",
+	'系': "xi", '戏': "xi", '像': "xiang", '项': "xiang", '销': "xiao", '小': "xiao",
+	'协': "xie", '写': "xie", '信': "xin", '性': "xing", '序': "xu", '续': "xu",
+	'讯': "xun", '学': "xue", '言': "yan", '验': "yan", '移': "yi", '引': "yin",
+	'音': "yin", '英': "ying", '营': "ying", '硬': "ying", '用': "yong", '优': "you",
+	'游': "you", '语': "yu", '员': "yuan", '云': "yun", '运': "yun", '证': "zheng",
+	'质': "zhi", '智': "zhi", '自': "zi", '作': "zuo", '阿': "a", '腾': "teng",
+}
+
+// Pinyin 把名称转换为拼音全拼和首字母，ASCII字母和数字原样保留（小写）。
+// 名称不含汉字或含有表中没有的汉字时ok为false
+func Pinyin(name string) (full, initials string, ok bool) {
+	var f, i strings.Builder
+	hasHan := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			p, known := pinyinTable[r]
+			if !known {
+				return "", "", false
+			}
+			hasHan = true
+			f.WriteString(p)
+			i.WriteByte(p[0])
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			f.WriteRune(r)
+			i.WriteRune(r)
+		}
+	}
+	if !hasHan {
+		return "", "", false
+	}
+	return f.String(), i.String(), true
+}
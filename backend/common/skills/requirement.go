@@ -0,0 +1,183 @@
+package skills
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+// 加分项技能的权重系数
+const niceToHaveFactor = 0.5
+
+// Requirement 一项技能要求。Required为空视为必备，Weight未设置时为1
+type Requirement struct {
+	ID       string  `json:"id,omitempty"`
+	Name     string  `json:"name"`
+	Weight   float64 `json:"weight,omitempty"`
+	Required *bool   `json:"required,omitempty"`
+}
+
+// IsRequired 是否必备技能
+func (r Requirement) IsRequired() bool {
+	return r.Required == nil || *r.Required
+}
+
+// EffectiveWeight 参与匹配计算的权重，加分项减半
+func (r Requirement) EffectiveWeight() float64 {
+	w := r.Weight
+	if w <= 0 {
+		w = 1
+	}
+	if !r.IsRequired() {
+		w *= niceToHaveFactor
+	}
+	return w
+}
+
+// Parse 解析技能字段，兼容字符串数组、对象数组和逗号/顿号/分号分隔的纯文本，并解析出技能ID
+func (d *Dictionary) Parse(raw string) []Requirement {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+
+	var reqs []Requirement
+	var names []string
+	if err := json.Unmarshal([]byte(raw), &names); err == nil {
+		reqs = fromNames(names)
+	} else if err := json.Unmarshal([]byte(raw), &reqs); err != nil {
+		reqs = fromNames(strings.FieldsFunc(raw, func(r rune) bool {
+			return r == ',' || r == '，' || r == '、' || r == ';' || r == '；' || r == '\n'
+		}))
+	}
+	return d.Normalize(reqs)
+}
+
+// Normalize 把技能要求解析为标准ID和展示名并按ID去重；重复项合并时取较高权重，任一为必备即为必备
+func (d *Dictionary) Normalize(reqs []Requirement) []Requirement {
+	out := make([]Requirement, 0, len(reqs))
+	index := make(map[string]int, len(reqs))
+	for _, r := range reqs {
+		name := r.Name
+		if strings.TrimSpace(name) == "" {
+			name = r.ID
+		}
+		id, display := d.Resolve(name)
+		if id == "" {
+			continue
+		}
+		r.ID, r.Name = id, display
+
+		i, seen := index[id]
+		if !seen {
+			index[id] = len(out)
+			out = append(out, r)
+			continue
+		}
+		prev := &out[i]
+		prev.Weight = math.Max(prev.Weight, r.Weight)
+		if !prev.IsRequired() && r.IsRequired() {
+			prev.Required = r.Required
+		}
+	}
+	return out
+}
+
+// NormalizeJSON 标准化JSON技能字段后重新序列化：没有权重和必备信息时写成展示名的字符串数组，
+// 否则写成带ID的对象数组。空字段原样返回
+func (d *Dictionary) NormalizeJSON(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return raw, nil
+	}
+	reqs := d.Parse(raw)
+
+	var value any = Names(reqs)
+	for _, r := range reqs {
+		if r.Weight > 0 || r.Required != nil {
+			value = reqs
+			break
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// IDs 技能名称对应的ID集合
+func (d *Dictionary) IDs(names []string) map[string]bool {
+	ids := make(map[string]bool, len(names))
+	for _, name := range names {
+		if id := d.ID(name); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// MatchResult 技能要求的加权匹配结果
+type MatchResult struct {
+	Score           float64  `json:"score"`
+	Matched         []string `json:"matched"`
+	MissingRequired []string `json:"missing_required"`
+	MissingOptional []string `json:"missing_optional"`
+}
+
+// MatchRequirements 按权重计算已掌握技能覆盖技能要求的比例，必备技能权重为加分项的两倍
+func (d *Dictionary) MatchRequirements(reqs []Requirement, have []string) MatchResult {
+	owned := d.IDs(have)
+	var result MatchResult
+	var matchedWeight, totalWeight float64
+	for _, r := range d.Normalize(reqs) {
+		w := r.EffectiveWeight()
+		totalWeight += w
+		switch {
+		case owned[r.ID]:
+			matchedWeight += w
+			result.Matched = append(result.Matched, r.Name)
+		case r.IsRequired():
+			result.MissingRequired = append(result.MissingRequired, r.Name)
+		default:
+			result.MissingOptional = append(result.MissingOptional, r.Name)
+		}
+	}
+	if totalWeight > 0 {
+		result.Score = math.Round(matchedWeight/totalWeight*1000) / 1000
+	}
+	return result
+}
+
+// Similarity 两组技能按标准ID计算的Jaccard相似度
+func (d *Dictionary) Similarity(a, b []string) float64 {
+	setA, setB := d.IDs(a), d.IDs(b)
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+	common := 0
+	for id := range setA {
+		if setB[id] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(setA)+len(setB)-common)
+}
+
+// Names 技能展示名列表
+func Names(reqs []Requirement) []string {
+	names := make([]string, 0, len(reqs))
+	for _, r := range reqs {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func fromNames(names []string) []Requirement {
+	reqs := make([]Requirement, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			reqs = append(reqs, Requirement{Name: name})
+		}
+	}
+	return reqs
+}
@@ -0,0 +1,155 @@
+package skills
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	d := Default()
+	cases := []struct {
+		in, id, method string
+	}{
+		{"Golang", "go", MethodExact},
+		{"  React.JS ", "react", MethodExact},
+		{"k8s", "kubernetes", MethodExact},
+		{"Spring-Boot", "spring-boot", MethodExact},
+		{"C++", "cpp", MethodExact},
+		{"c#", "csharp", MethodExact},
+		{"数据分析", "data-analysis", MethodExact},
+		{"shujufenxi", "data-analysis", MethodPinyin},
+		{"sjfx", "data-analysis", MethodPinyin},
+		{"数剧分析", "data-analysis", MethodPinyin},
+		{"jiqixuexi", "machine-learning", MethodPinyin},
+		{"Kubernets", "kubernetes", MethodFuzzy},
+		{"Pyhton", "python", MethodFuzzy},
+		{"postgresq", "postgresql", MethodFuzzy},
+	}
+	for _, c := range cases {
+		m, ok := d.Match(c.in)
+		if !ok {
+			t.Errorf("Match(%q) found nothing, want %s", c.in, c.id)
+			continue
+		}
+		if m.Skill.ID != c.id || m.Method != c.method {
+			t.Errorf("Match(%q) = %s via %s, want %s via %s", c.in, m.Skill.ID, m.Method, c.id, c.method)
+		}
+	}
+
+	// 短词不做模糊匹配，避免“Ga”“Gp”被当成Go
+	for _, in := range []string{"ga", "cobol", "", "   "} {
+		if m, ok := d.Match(in); ok {
+			t.Errorf("Match(%q) = %s, want no match", in, m.Skill.ID)
+		}
+	}
+	if got := d.ID("Unknown Tool"); got != "unknowntool" {
+		t.Errorf("ID of unknown skill = %q, want key unknowntool", got)
+	}
+}
+
+func TestFuzzyAmbiguous(t *testing.T) {
+	d := NewDictionary([]Skill{{ID: "abcd", Name: "abcd"}, {ID: "abce", Name: "abce"}})
+	if m, ok := d.Match("abcf"); ok {
+		t.Errorf("ambiguous input matched %s", m.Skill.ID)
+	}
+	if m, ok := d.Match("abdc"); !ok || m.Skill.ID != "abcd" || m.Distance != 1 {
+		t.Errorf("transposition: got %+v, %v", m, ok)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"go", "", 2},
+		{"kitten", "sitting", 3},
+		{"python", "pyhton", 1},
+		{"数据分析", "数剧分析", 1},
+	}
+	for _, c := range cases {
+		if got := Distance(c.a, c.b); got != c.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestPinyin(t *testing.T) {
+	full, initials, ok := Pinyin("Go语言")
+	if !ok || full != "goyuyan" || initials != "goyy" {
+		t.Errorf("Pinyin(Go语言) = %q, %q, %v", full, initials, ok)
+	}
+	if _, _, ok := Pinyin("Golang"); ok {
+		t.Error("ASCII names have no pinyin")
+	}
+	if _, _, ok := Pinyin("量子纠缠"); ok {
+		t.Error("names with unknown characters should be rejected")
+	}
+}
+
+func TestParseAndNormalizeJSON(t *testing.T) {
+	d := Default()
+	if got := Names(d.Parse("golang, MySQL、k8s；Golang")); !reflect.DeepEqual(got, []string{"Go", "MySQL", "Kubernetes"}) {
+		t.Errorf("Parse text = %v", got)
+	}
+
+	cases := map[string]string{
+		`["golang", "reactjs", "Go"]`: `["Go","React"]`,
+		`[{"name": "golang", "weight": 2}, {"name": "redis", "required": false}, {"name": "Go", "required": true}]`: `[{"id":"go","name":"Go","weight":2},{"id":"redis","name":"Redis","required":false}]`,
+		`[{"name": "mysql"}]`: `["MySQL"]`,
+		`vue3，自研框架`:           `["Vue.js","自研框架"]`,
+		`[]`:                  `[]`,
+		``:                    ``,
+	}
+	for in, want := range cases {
+		got, err := d.NormalizeJSON(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeJSON(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestMatchRequirements(t *testing.T) {
+	d := Default()
+	no := false
+	reqs := []Requirement{
+		{Name: "Go", Weight: 2},
+		{Name: "MySQL"},
+		{Name: "Kubernetes", Required: &no},
+		{Name: "Redis", Required: &no},
+	}
+	r := d.MatchRequirements(reqs, []string{"golang", "k8s"})
+	// 命中 Go(2) + Kubernetes(0.5)，总权重 2+1+0.5+0.5
+	if r.Score != 0.625 {
+		t.Errorf("score = %v, want 0.625", r.Score)
+	}
+	if !reflect.DeepEqual(r.Matched, []string{"Go", "Kubernetes"}) ||
+		!reflect.DeepEqual(r.MissingRequired, []string{"MySQL"}) ||
+		!reflect.DeepEqual(r.MissingOptional, []string{"Redis"}) {
+		t.Errorf("unexpected match result %+v", r)
+	}
+
+	if got := d.Similarity([]string{"Golang", "MySQL"}, []string{"go", "mysql", "redis"}); got < 0.666 || got > 0.667 {
+		t.Errorf("similarity = %v, want 2/3", got)
+	}
+	if got := d.Similarity(nil, []string{"go"}); got != 0 {
+		t.Errorf("similarity with empty set = %v", got)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	d := Default()
+	text := `负责订单系统的Golang微服务开发，使用Spring Boot和MySQL；
+搭建CI/CD流水线并部署到k8s。业余时间研究机器学习，熟悉React.js、Node.js。`
+	got := d.Extract(text)
+	want := []string{"cicd", "go", "kubernetes", "machine-learning", "microservices", "mysql", "nodejs", "react", "spring-boot"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract = %v, want %v", got, want)
+	}
+
+	// 不做模糊匹配，单字母名称不参与
+	if got := d.Extract("Pyhton, C and R"); len(got) != 0 {
+		t.Errorf("Extract should ignore typos and single letters, got %v", got)
+	}
+}
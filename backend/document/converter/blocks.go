@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"strings"
+)
+
+// BlockKind 文档块类型
+type BlockKind int
+
+const (
+	BlockParagraph BlockKind = iota
+	BlockHeading
+	BlockListItem
+	BlockCode
+	BlockQuote
+	BlockRule
+)
+
+// Span 行内文本片段
+type Span struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	Link   string
+}
+
+// Block 各格式之间转换使用的中间结构
+type Block struct {
+	Kind    BlockKind
+	Level   int  // 标题级别或列表缩进层级
+	Ordered bool // 有序列表项
+	Spans   []Span
+}
+
+// Text 块内纯文本
+func (b *Block) Text() string {
+	var sb strings.Builder
+	for _, s := range b.Spans {
+		sb.WriteString(s.Text)
+	}
+	return sb.String()
+}
+
+// 追加文本，样式相同时与上一个片段合并
+func (b *Block) appendSpan(s Span) {
+	if s.Text == "" {
+		return
+	}
+	if n := len(b.Spans); n > 0 {
+		last := &b.Spans[n-1]
+		if last.Bold == s.Bold && last.Italic == s.Italic && last.Code == s.Code && last.Link == s.Link {
+			last.Text += s.Text
+			return
+		}
+	}
+	b.Spans = append(b.Spans, s)
+}
+
+// 去掉首尾空白，空块返回false
+func (b *Block) trim() bool {
+	if b.Kind == BlockRule {
+		return true
+	}
+	if b.Kind == BlockCode {
+		return b.Text() != ""
+	}
+	for len(b.Spans) > 0 {
+		b.Spans[0].Text = strings.TrimLeft(b.Spans[0].Text, " \t\n")
+		if b.Spans[0].Text != "" {
+			break
+		}
+		b.Spans = b.Spans[1:]
+	}
+	for len(b.Spans) > 0 {
+		n := len(b.Spans) - 1
+		b.Spans[n].Text = strings.TrimRight(b.Spans[n].Text, " \t\n")
+		if b.Spans[n].Text != "" {
+			break
+		}
+		b.Spans = b.Spans[:n]
+	}
+	return len(b.Spans) > 0
+}
+
+// 相邻两个块是否属于同一列表，同一列表内不插入空行
+func sameList(prev, next *Block) bool {
+	if prev.Kind != BlockListItem || next.Kind != BlockListItem {
+		return false
+	}
+	return next.Level > 0 || prev.Level > 0 || prev.Ordered == next.Ordered
+}
+
+// 文档标题：第一个标题块的文本
+func documentTitle(blocks []Block) string {
+	for i := range blocks {
+		if blocks[i].Kind == BlockHeading {
+			return blocks[i].Text()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+// Package converter 提供纯Go实现的文档格式转换器及按格式图串联的转换注册表
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedConversion 源格式与目标格式之间没有可用的转换路径
+var ErrUnsupportedConversion = errors.New("unsupported conversion")
+
+// Options 转换选项，来自 ConversionConfig.ConfigData
+type Options map[string]interface{}
+
+// String 读取字符串选项
+func (o Options) String(key, def string) string {
+	if v, ok := o[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// Float 读取数值选项
+func (o Options) Float(key string, def float64) float64 {
+	switch v := o[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return def
+}
+
+// Bool 读取布尔选项
+func (o Options) Bool(key string, def bool) bool {
+	if v, ok := o[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// Converter 单步格式转换
+type Converter interface {
+	Convert(ctx context.Context, r io.Reader, w io.Writer, opts Options) error
+}
+
+// ConverterFunc 函数形式的转换器
+type ConverterFunc func(ctx context.Context, r io.Reader, w io.Writer, opts Options) error
+
+// Convert 实现 Converter
+func (f ConverterFunc) Convert(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	return f(ctx, r, w, opts)
+}
+
+// Step 转换路径中的一步
+type Step struct {
+	Source    string
+	Target    string
+	Converter Converter
+}
+
+// Registry 转换器注册表，格式为节点、转换器为有向边
+type Registry struct {
+	mu    sync.RWMutex
+	edges map[string]map[string]Converter
+	order map[string][]string // 出边的注册顺序，路径等长时优先先注册的
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		edges: make(map[string]map[string]Converter),
+		order: make(map[string][]string),
+	}
+}
+
+// NewDefaultRegistry 创建注册了全部内置转换器的注册表
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	// HTML保留的结构最完整，作为多步转换的首选中间格式
+	r.Register("md", "html", ConverterFunc(markdownToHTML))
+	r.Register("md", "docx", ConverterFunc(markdownToDocx))
+	r.Register("html", "txt", ConverterFunc(htmlToText))
+	r.Register("html", "pdf", ConverterFunc(htmlToPDF))
+	r.Register("html", "docx", ConverterFunc(htmlToDocx))
+	r.Register("txt", "pdf", ConverterFunc(textToPDF))
+	r.Register("docx", "html", ConverterFunc(docxToHTML))
+	r.Register("docx", "md", ConverterFunc(docxToMarkdown))
+	r.Register("docx", "txt", ConverterFunc(docxToText))
+	return r
+}
+
+// NormalizeFormat 统一格式名称
+func NormalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	switch format {
+	case "markdown":
+		return "md"
+	case "htm":
+		return "html"
+	case "text":
+		return "txt"
+	}
+	return format
+}
+
+// Register 注册单步转换器，重复注册时覆盖
+func (r *Registry) Register(source, target string, c Converter) {
+	source, target = NormalizeFormat(source), NormalizeFormat(target)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.edges[source] == nil {
+		r.edges[source] = make(map[string]Converter)
+	}
+	if _, exists := r.edges[source][target]; !exists {
+		r.order[source] = append(r.order[source], target)
+	}
+	r.edges[source][target] = c
+}
+
+// Plan 按广度优先搜索最短转换路径，等长路径按注册顺序选择
+func (r *Registry) Plan(source, target string) ([]Step, error) {
+	source, target = NormalizeFormat(source), NormalizeFormat(target)
+	if source == target {
+		return nil, fmt.Errorf("%w: source and target are both %q", ErrUnsupportedConversion, source)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prev := map[string]string{source: ""}
+	queue := []string{source}
+	for len(queue) > 0 && prev[target] == "" {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range r.order[node] {
+			if _, seen := prev[next]; seen {
+				continue
+			}
+			prev[next] = node
+			queue = append(queue, next)
+		}
+	}
+	if _, ok := prev[target]; !ok {
+		return nil, fmt.Errorf("%w: %s to %s", ErrUnsupportedConversion, source, target)
+	}
+
+	var steps []Step
+	for node := target; node != source; node = prev[node] {
+		from := prev[node]
+		steps = append([]Step{{Source: from, Target: node, Converter: r.edges[from][node]}}, steps...)
+	}
+	return steps, nil
+}
+
+// Supports 是否存在转换路径
+func (r *Registry) Supports(source, target string) bool {
+	_, err := r.Plan(source, target)
+	return err == nil
+}
+
+// Conversions 每种源格式可以转换到的全部目标格式
+func (r *Registry) Conversions() map[string][]string {
+	r.mu.RLock()
+	sources := sortedKeys(r.edges)
+	r.mu.RUnlock()
+
+	result := make(map[string][]string, len(sources))
+	for _, source := range sources {
+		r.mu.RLock()
+		seen := map[string]bool{source: true}
+		queue := []string{source}
+		var targets []string
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for next := range r.edges[node] {
+				if !seen[next] {
+					seen[next] = true
+					targets = append(targets, next)
+					queue = append(queue, next)
+				}
+			}
+		}
+		r.mu.RUnlock()
+		sort.Strings(targets)
+		result[source] = targets
+	}
+	return result
+}
+
+// Convert 按路径串联各步转换，中间结果通过管道流式传递
+func (r *Registry) Convert(ctx context.Context, source, target string, in io.Reader, out io.Writer, opts Options) error {
+	steps, err := r.Plan(source, target)
+	if err != nil {
+		return err
+	}
+	if opts == nil {
+		opts = Options{}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(steps))
+	readers := make([]*io.PipeReader, 0, len(steps)-1)
+	var wg sync.WaitGroup
+	src := in
+	for i, step := range steps[:len(steps)-1] {
+		pr, pw := io.Pipe()
+		readers = append(readers, pr)
+		wg.Add(1)
+		go func(i int, step Step, r io.Reader) {
+			defer wg.Done()
+			err := runStep(ctx, step, r, pw, opts)
+			errs[i] = err
+			pw.CloseWithError(err)
+		}(i, step, src)
+		src = pr
+	}
+
+	last := len(steps) - 1
+	errs[last] = runStep(ctx, steps[last], src, out, opts)
+	if errs[last] != nil {
+		cancel()
+	}
+	// 关闭所有管道读端，避免上游在出错后阻塞于写入
+	for _, pr := range readers {
+		pr.CloseWithError(io.ErrClosedPipe)
+	}
+	wg.Wait()
+
+	// 返回最上游的根因错误，管道关闭与取消通常只是它的传播
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runStep(ctx context.Context, step Step, r io.Reader, w io.Writer, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := step.Converter.Convert(ctx, r, w, opts); err != nil {
+		return fmt.Errorf("%s to %s: %w", step.Source, step.Target, err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
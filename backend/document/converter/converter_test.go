@@ -0,0 +1,268 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func convert(t *testing.T, source, target, input string, opts Options) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := NewDefaultRegistry().Convert(context.Background(), source, target, strings.NewReader(input), &out, opts); err != nil {
+		t.Fatalf("%s to %s: %v", source, target, err)
+	}
+	return out.String()
+}
+
+func TestPlanChainsThroughFormatGraph(t *testing.T) {
+	r := NewDefaultRegistry()
+	cases := map[string]string{
+		"md/html":   "md>html",
+		"md/txt":    "md>html>txt",
+		"md/pdf":    "md>html>pdf",
+		"docx/pdf":  "docx>html>pdf",
+		"markdown/": "",
+	}
+	for pair, want := range cases {
+		parts := strings.Split(pair, "/")
+		steps, err := r.Plan(parts[0], parts[1])
+		if want == "" {
+			if !errors.Is(err, ErrUnsupportedConversion) {
+				t.Errorf("%s: expected unsupported, got %v", pair, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", pair, err)
+		}
+		path := steps[0].Source
+		for _, s := range steps {
+			path += ">" + s.Target
+		}
+		if path != want {
+			t.Errorf("%s: path %s, want %s", pair, path, want)
+		}
+	}
+
+	for _, pair := range [][2]string{{"pdf", "docx"}, {"txt", "txt"}, {"rtf", "pdf"}} {
+		if _, err := r.Plan(pair[0], pair[1]); !errors.Is(err, ErrUnsupportedConversion) {
+			t.Errorf("%v: expected unsupported conversion, got %v", pair, err)
+		}
+	}
+
+	if got := r.Conversions()["md"]; strings.Join(got, ",") != "docx,html,pdf,txt" {
+		t.Errorf("md conversions = %v", got)
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	md := "# 张三 Resume\n\nSenior **Go** engineer, see [site](https://example.com).\n\n- Built `gin` services\n- Led *team*\n  of five\n\n1. first\n2. second\n\n```\nfmt.Println(\"<hi>\")\n```\n"
+	out := convert(t, "md", "html", md, nil)
+	for _, want := range []string{
+		"<title>张三 Resume</title>",
+		"<h1>张三 Resume</h1>",
+		"<p>Senior <strong>Go</strong> engineer, see <a href=\"https://example.com\">site</a>.</p>",
+		"<ul>\n<li>Built <code>gin</code> services</li>\n<li>Led <em>team</em> of five</li>\n</ul>",
+		"<ol>\n<li>first</li>\n<li>second</li>\n</ol>",
+		"<pre><code>fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+
+	fragment := convert(t, "md", "html", "hello", Options{"fragment": true})
+	if fragment != "<p>hello</p>\n" {
+		t.Errorf("fragment = %q", fragment)
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	html := `<html><head><title>T</title><style>p{}</style></head><body>
+<h1>Experience</h1>
+<p>Worked   at <b>Acme</b>
+  on <a href="https://acme.test">billing</a>.<br>Second line</p>
+<script>alert(1)</script>
+<ol><li>Go</li><li>SQL</li></ol>
+</body></html>`
+	out := convert(t, "html", "txt", html, nil)
+	want := "Experience\n==========\n\nWorked at Acme on billing (https://acme.test).\nSecond line\n\n1. Go\n2. SQL\n"
+	if out != want {
+		t.Errorf("got\n%q\nwant\n%q", out, want)
+	}
+}
+
+func TestMarkdownToTextChainsThroughHTML(t *testing.T) {
+	out := convert(t, "md", "txt", "## Skills\n\n* Go\n* Redis", Options{"line_width": float64(40)})
+	if out != "Skills\n------\n\n- Go\n- Redis\n" {
+		t.Errorf("got %q", out)
+	}
+}
+
+const testDocumentXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Li Lei</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">Backend </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>engineer</w:t></w:r><w:r><w:rPr><w:b w:val="0"/></w:rPr><w:t xml:space="preserve"> at </w:t></w:r><w:hyperlink r:id="rId9"><w:r><w:t>Acme</w:t></w:r></w:hyperlink></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Go</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:rPr><w:i/></w:rPr><w:t>gin</w:t></w:r></w:p>
+<w:p></w:p>
+</w:body>
+</w:document>`
+
+const testRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId9" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://acme.test" TargetMode="External"/>
+</Relationships>`
+
+func buildDocx(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"word/document.xml":            testDocumentXML,
+		"word/_rels/document.xml.rels": testRelsXML,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestDocxConversions(t *testing.T) {
+	docx := buildDocx(t)
+
+	md := convert(t, "docx", "md", docx, nil)
+	wantMD := "# Li Lei\n\nBackend **engineer** at [Acme](https://acme.test)\n\n- Go\n  - *gin*\n"
+	if md != wantMD {
+		t.Errorf("markdown got\n%q\nwant\n%q", md, wantMD)
+	}
+
+	txt := convert(t, "docx", "txt", docx, nil)
+	if !strings.Contains(txt, "Backend engineer at Acme (https://acme.test)") || !strings.Contains(txt, "  - gin") {
+		t.Errorf("text got %q", txt)
+	}
+
+	html := convert(t, "docx", "html", docx, Options{"fragment": true})
+	if !strings.Contains(html, "<ul>\n<li>Go<ul>\n<li><em>gin</em></li>\n</ul>\n</li>\n</ul>") {
+		t.Errorf("html got %q", html)
+	}
+
+	var out bytes.Buffer
+	err := NewDefaultRegistry().Convert(context.Background(), "docx", "txt", strings.NewReader("not a zip"), &out, nil)
+	if !errors.Is(err, ErrInvalidDocx) {
+		t.Errorf("expected ErrInvalidDocx, got %v", err)
+	}
+}
+
+// 校验交叉引用表中每个偏移都指向对应对象
+func checkPDF(t *testing.T, pdf string) int {
+	t.Helper()
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("malformed pdf envelope")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(pdf[xref:], "xref\n") {
+		t.Fatalf("startxref %d does not point at xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !strings.HasPrefix(pdf[off:], want) {
+			t.Fatalf("xref entry %d points at %q", i+1, pdf[off:off+10])
+		}
+	}
+	count := regexp.MustCompile(`/Type /Pages /Count (\d+)`).FindStringSubmatch(pdf)
+	if count == nil {
+		t.Fatal("missing page tree")
+	}
+	n, _ := strconv.Atoi(count[1])
+	return n
+}
+
+func TestTextToPDF(t *testing.T) {
+	text := "张三\n高级工程师\n\n" + strings.Repeat("A long paragraph of resume text that should wrap across lines. ", 200)
+	pdf := convert(t, "txt", "pdf", text, Options{"title": "简历"})
+	if pages := checkPDF(t, pdf); pages < 2 {
+		t.Errorf("expected text to flow onto several pages, got %d", pages)
+	}
+	if !strings.Contains(pdf, "/BaseFont /STSong-Light") || !strings.Contains(pdf, "/Title <FEFF7B805386>") {
+		t.Error("expected CJK font and title")
+	}
+}
+
+func TestHTMLToPDF(t *testing.T) {
+	pdf := convert(t, "md", "pdf", "# Title\n\n- one\n- two\n\n---\n\nbody", Options{"page_size": "letter"})
+	if pages := checkPDF(t, pdf); pages != 1 {
+		t.Errorf("pages = %d", pages)
+	}
+	if !strings.Contains(pdf, "/MediaBox [0 0 612.00 792.00]") || !strings.Contains(pdf, "/Title <FEFF005400690074006C0065>") {
+		t.Error("expected letter page size and title from first heading")
+	}
+}
+
+func TestConvertPropagatesStepErrors(t *testing.T) {
+	boom := errors.New("boom")
+	r := NewRegistry()
+	r.Register("a", "b", ConverterFunc(func(ctx context.Context, in io.Reader, w io.Writer, opts Options) error {
+		// 写入超过管道缓冲的数据，确保下游失败后上游不会阻塞
+		for i := 0; i < 1000; i++ {
+			if _, err := w.Write(bytes.Repeat([]byte("x"), 1024)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	r.Register("b", "c", ConverterFunc(func(ctx context.Context, in io.Reader, w io.Writer, opts Options) error {
+		return boom
+	}))
+
+	err := r.Convert(context.Background(), "a", "c", strings.NewReader(""), io.Discard, nil)
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "b to c") {
+		t.Fatalf("expected step error, got %v", err)
+	}
+
+	r.Register("c", "d", ConverterFunc(func(ctx context.Context, in io.Reader, w io.Writer, opts Options) error {
+		_, err := io.Copy(w, in)
+		return err
+	}))
+	r.Register("b", "c", ConverterFunc(func(ctx context.Context, in io.Reader, w io.Writer, opts Options) error {
+		_, err := io.Copy(w, in)
+		return err
+	}))
+	var out bytes.Buffer
+	if err := r.Convert(context.Background(), "a", "d", strings.NewReader(""), &out, nil); err != nil || out.Len() != 1000*1024 {
+		t.Fatalf("three-step chain: err=%v len=%d", err, out.Len())
+	}
+}
+
+func TestMarkdownDocxRoundTrip(t *testing.T) {
+	md := "# Offer\n\nDear **Li**, welcome to [Acme](https://acme.test).\n\n1. Sign\n2. Return\n\n- Laptop\n  - Charger\n\n> Congratulations\n"
+	docx := convert(t, "md", "docx", md, nil)
+	if !strings.HasPrefix(docx, "PK") {
+		t.Fatal("expected a zip package")
+	}
+	back := convert(t, "docx", "md", docx, nil)
+	want := "# Offer\n\nDear **Li**, welcome to [Acme](https://acme.test).\n\n1. Sign\n2. Return\n\n- Laptop\n  - Charger\n\n> Congratulations\n"
+	if back != want {
+		t.Errorf("round trip got\n%q\nwant\n%q", back, want)
+	}
+}
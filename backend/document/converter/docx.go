@@ -0,0 +1,344 @@
+package converter
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 默认允许的docx输入大小
+const defaultMaxDocxSize = 50 << 20
+
+// ErrInvalidDocx 输入不是有效的docx文件
+var ErrInvalidDocx = errors.New("invalid docx document")
+
+const (
+	wordNS = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	relNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+// docx -> txt
+func docxToText(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, err := readDocx(r, opts)
+	if err != nil {
+		return err
+	}
+	return renderText(w, blocks, opts)
+}
+
+// docx -> html
+func docxToHTML(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, err := readDocx(r, opts)
+	if err != nil {
+		return err
+	}
+	return renderHTML(w, blocks, opts)
+}
+
+// docx -> md
+func docxToMarkdown(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, err := readDocx(r, opts)
+	if err != nil {
+		return err
+	}
+	return renderMarkdown(w, blocks)
+}
+
+// docx是zip包，需要随机访问，先落到临时文件
+func readDocx(r io.Reader, opts Options) ([]Block, error) {
+	maxSize := int64(opts.Float("max_input_size", defaultMaxDocxSize))
+
+	f, err := os.CreateTemp("", "docx-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	size, err := io.Copy(f, io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSize {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrInvalidDocx, maxSize)
+	}
+
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocx, err)
+	}
+	return parseDocx(zr)
+}
+
+func parseDocx(zr *zip.Reader) ([]Block, error) {
+	var document *zip.File
+	links := map[string]string{}
+	numbering := docxNumberFormats{}
+	for _, f := range zr.File {
+		switch f.Name {
+		case "word/document.xml":
+			document = f
+		case "word/_rels/document.xml.rels":
+			if err := readDocxRelationships(f, links); err != nil {
+				return nil, err
+			}
+		case "word/numbering.xml":
+			if err := readDocxNumbering(f, numbering); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if document == nil {
+		return nil, fmt.Errorf("%w: word/document.xml not found", ErrInvalidDocx)
+	}
+
+	rc, err := document.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocx, err)
+	}
+	defer rc.Close()
+	return parseDocumentXML(rc, links, numbering)
+}
+
+// 编号格式：numId -> 各级别的numFmt
+type docxNumberFormats map[string]map[int]string
+
+// 读取列表编号定义，用于区分有序与无序列表
+func readDocxNumbering(f *zip.File, formats docxNumberFormats) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDocx, err)
+	}
+	defer rc.Close()
+
+	var numbering struct {
+		Abstracts []struct {
+			ID     string `xml:"abstractNumId,attr"`
+			Levels []struct {
+				Level  int `xml:"ilvl,attr"`
+				Format struct {
+					Val string `xml:"val,attr"`
+				} `xml:"numFmt"`
+			} `xml:"lvl"`
+		} `xml:"abstractNum"`
+		Nums []struct {
+			ID       string `xml:"numId,attr"`
+			Abstract struct {
+				Val string `xml:"val,attr"`
+			} `xml:"abstractNumId"`
+		} `xml:"num"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&numbering); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDocx, err)
+	}
+
+	abstracts := map[string]map[int]string{}
+	for _, a := range numbering.Abstracts {
+		levels := map[int]string{}
+		for _, l := range a.Levels {
+			levels[l.Level] = l.Format.Val
+		}
+		abstracts[a.ID] = levels
+	}
+	for _, n := range numbering.Nums {
+		if levels, ok := abstracts[n.Abstract.Val]; ok {
+			formats[n.ID] = levels
+		}
+	}
+	return nil
+}
+
+func (f docxNumberFormats) ordered(numID string, level int) bool {
+	format, ok := f[numID][level]
+	return ok && format != "bullet" && format != "none"
+}
+
+// 读取超链接关系
+func readDocxRelationships(f *zip.File, links map[string]string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDocx, err)
+	}
+	defer rc.Close()
+
+	var rels struct {
+		Relationships []struct {
+			ID         string `xml:"Id,attr"`
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&rels); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDocx, err)
+	}
+	for _, rel := range rels.Relationships {
+		if rel.TargetMode == "External" {
+			links[rel.ID] = rel.Target
+		}
+	}
+	return nil
+}
+
+// 逐个读取段落：样式决定标题与列表，run属性决定粗斜体
+func parseDocumentXML(r io.Reader, links map[string]string, numbering docxNumberFormats) ([]Block, error) {
+	var blocks []Block
+	var cur *Block
+	var numID string
+	var run Span
+	var inRun, inText, inRunProps bool
+	var link string
+
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDocx, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				cur = &Block{Kind: BlockParagraph}
+				numID = ""
+			case "pStyle":
+				if cur != nil {
+					applyParagraphStyle(cur, wordAttr(t, "val"))
+				}
+			case "numPr":
+				if cur != nil && cur.Kind == BlockParagraph {
+					cur.Kind = BlockListItem
+				}
+			case "ilvl":
+				if cur != nil {
+					fmt.Sscanf(wordAttr(t, "val"), "%d", &cur.Level)
+				}
+			case "numId":
+				numID = wordAttr(t, "val")
+			case "hyperlink":
+				link = links[attrValue(t, relNS, "id")]
+			case "r":
+				inRun = true
+				run = Span{Link: link}
+			case "rPr":
+				inRunProps = inRun
+			case "b":
+				if inRunProps {
+					run.Bold = toggleOn(t)
+				}
+			case "i":
+				if inRunProps {
+					run.Italic = toggleOn(t)
+				}
+			case "t":
+				inText = true
+			case "tab":
+				if inRun && cur != nil {
+					cur.appendSpan(Span{Text: "\t", Bold: run.Bold, Italic: run.Italic, Link: run.Link})
+				}
+			case "br", "cr":
+				if inRun && cur != nil {
+					cur.appendSpan(Span{Text: "\n", Bold: run.Bold, Italic: run.Italic, Link: run.Link})
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				if cur != nil {
+					if cur.Kind == BlockListItem {
+						cur.Level = clampLevel(cur.Level)
+						if numID != "" {
+							cur.Ordered = numbering.ordered(numID, cur.Level)
+						}
+					}
+					if cur.trim() {
+						blocks = append(blocks, *cur)
+					}
+				}
+				cur = nil
+			case "hyperlink":
+				link = ""
+			case "r":
+				inRun = false
+			case "rPr":
+				inRunProps = false
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText && inRun && cur != nil {
+				s := run
+				s.Text = string(t)
+				cur.appendSpan(s)
+			}
+		}
+	}
+}
+
+// 段落样式映射：Title/Heading1..6为标题，列表样式为列表项
+func applyParagraphStyle(b *Block, style string) {
+	lower := strings.ToLower(style)
+	switch {
+	case lower == "title":
+		b.Kind, b.Level = BlockHeading, 1
+	case lower == "subtitle":
+		b.Kind, b.Level = BlockHeading, 2
+	case strings.HasPrefix(lower, "heading"):
+		level := 1
+		fmt.Sscanf(strings.TrimPrefix(lower, "heading"), "%d", &level)
+		b.Kind, b.Level = BlockHeading, level
+	case strings.Contains(lower, "list"):
+		b.Kind = BlockListItem
+		b.Ordered = strings.Contains(lower, "number")
+	case lower == "code":
+		b.Kind = BlockCode
+	case lower == "quote" || lower == "intensequote":
+		b.Kind = BlockQuote
+	}
+}
+
+func clampLevel(level int) int {
+	if level < 0 {
+		return 0
+	}
+	if level > 8 {
+		return 8
+	}
+	return level
+}
+
+func wordAttr(t xml.StartElement, local string) string {
+	return attrValue(t, wordNS, local)
+}
+
+func attrValue(t xml.StartElement, space, local string) string {
+	for _, a := range t.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// <w:b/>表示开启，<w:b w:val="false"/>或"0"表示关闭
+func toggleOn(t xml.StartElement) bool {
+	switch wordAttr(t, "val") {
+	case "0", "false", "off":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// md -> docx
+func markdownToDocx(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, err := parseMarkdown(r)
+	if err != nil {
+		return err
+	}
+	return renderDocx(w, blocks, opts)
+}
+
+// html -> docx
+func htmlToDocx(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, title, err := parseHTML(r)
+	if err != nil {
+		return err
+	}
+	if _, ok := opts["title"]; !ok && title != "" {
+		opts = withOption(opts, "title", title)
+	}
+	return renderDocx(w, blocks, opts)
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const docxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="SimSun"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="360"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="30"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="200"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/><w:i/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:i/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="60"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="720"/></w:pPr><w:rPr><w:i/><w:color w:val="555555"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/></w:pPr><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+</w:styles>`
+
+// 输出docx：标题、列表、引用、代码使用内置样式，列表编号写入numbering.xml
+func renderDocx(w io.Writer, blocks []Block, opts Options) error {
+	var body bytes.Buffer
+	var links []string
+	linkIDs := map[string]string{}
+	orderedLists := 0
+	numID := 0
+
+	for _, b := range blocks {
+		body.WriteString("<w:p>")
+		switch b.Kind {
+		case BlockHeading:
+			level := b.Level
+			if level < 1 || level > 6 {
+				level = 1
+			}
+			fmt.Fprintf(&body, `<w:pPr><w:pStyle w:val="Heading%d"/></w:pPr>`, level)
+		case BlockListItem:
+			// 每组有序列表使用独立编号实例，保证从1开始
+			id := 1
+			if b.Ordered {
+				if numID == 0 {
+					orderedLists++
+					numID = orderedLists + 1
+				}
+				id = numID
+			}
+			fmt.Fprintf(&body, `<w:pPr><w:pStyle w:val="ListParagraph"/><w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr></w:pPr>`, clampLevel(b.Level), id)
+		case BlockQuote:
+			body.WriteString(`<w:pPr><w:pStyle w:val="Quote"/></w:pPr>`)
+		case BlockCode:
+			body.WriteString(`<w:pPr><w:pStyle w:val="Code"/></w:pPr>`)
+		case BlockRule:
+			body.WriteString(`<w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr>`)
+		}
+		if b.Kind != BlockListItem {
+			numID = 0
+		}
+
+		for _, s := range b.Spans {
+			run := docxRun(s, b.Kind == BlockCode)
+			if s.Link == "" {
+				body.WriteString(run)
+				continue
+			}
+			id, ok := linkIDs[s.Link]
+			if !ok {
+				links = append(links, s.Link)
+				id = fmt.Sprintf("rIdLink%d", len(links))
+				linkIDs[s.Link] = id
+			}
+			fmt.Fprintf(&body, `<w:hyperlink r:id="%s">%s</w:hyperlink>`, id, run)
+		}
+		body.WriteString("</w:p>")
+	}
+
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRootRels},
+		{"docProps/core.xml", docxCoreProperties(opts.String("title", documentTitle(blocks)))},
+		{"word/styles.xml", docxStyles},
+		{"word/numbering.xml", docxNumbering(orderedLists)},
+		{"word/_rels/document.xml.rels", docxDocumentRels(links)},
+		{"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+			`<w:document xmlns:w="` + wordNS + `" xmlns:r="` + relNS + `"><w:body>` + body.String() +
+			`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr></w:body></w:document>`},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func docxRun(s Span, code bool) string {
+	var sb strings.Builder
+	sb.WriteString("<w:r>")
+	if s.Bold || s.Italic || s.Code || s.Link != "" {
+		sb.WriteString("<w:rPr>")
+		if s.Link != "" {
+			sb.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+		}
+		if s.Code && !code {
+			sb.WriteString(`<w:rFonts w:ascii="Courier New" w:hAnsi="Courier New"/>`)
+		}
+		if s.Bold {
+			sb.WriteString("<w:b/>")
+		}
+		if s.Italic {
+			sb.WriteString("<w:i/>")
+		}
+		sb.WriteString("</w:rPr>")
+	}
+	for i, line := range strings.Split(s.Text, "\n") {
+		if i > 0 {
+			sb.WriteString("<w:br/>")
+		}
+		for j, part := range strings.Split(line, "\t") {
+			if j > 0 {
+				sb.WriteString("<w:tab/>")
+			}
+			if part != "" {
+				sb.WriteString(`<w:t xml:space="preserve">` + xmlEscape(part) + "</w:t>")
+			}
+		}
+	}
+	sb.WriteString("</w:r>")
+	return sb.String()
+}
+
+// 项目符号列表使用numId=1，每组有序列表numId从2开始
+func docxNumbering(orderedLists int) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sb.WriteString(`<w:numbering xmlns:w="` + wordNS + `">`)
+	for abstract, format := range []string{"bullet", "decimal"} {
+		fmt.Fprintf(&sb, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstract)
+		for lvl := 0; lvl <= 8; lvl++ {
+			text := "•"
+			if format == "decimal" {
+				text = fmt.Sprintf("%%%d.", lvl+1)
+			}
+			fmt.Fprintf(&sb, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				lvl, format, text, 720*(lvl+1))
+		}
+		sb.WriteString("</w:abstractNum>")
+	}
+	sb.WriteString(`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>`)
+	for i := 0; i < orderedLists; i++ {
+		fmt.Fprintf(&sb, `<w:num w:numId="%d"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="1"/></w:lvlOverride></w:num>`, i+2)
+	}
+	sb.WriteString("</w:numbering>")
+	return sb.String()
+}
+
+func docxDocumentRels(links []string) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sb.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	sb.WriteString(`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	sb.WriteString(`<Relationship Id="rIdNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	for i, link := range links {
+		fmt.Fprintf(&sb, `<Relationship Id="rIdLink%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="%s" TargetMode="External"/>`,
+			i+1, xmlEscape(link))
+	}
+	sb.WriteString("</Relationships>")
+	return sb.String()
+}
+
+func docxCoreProperties(title string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		"<dc:title>" + xmlEscape(title) + "</dc:title><dc:creator>resume-centre</dc:creator></cp:coreProperties>"
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,431 @@
+package converter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const defaultStylesheet = `body{font-family:-apple-system,"Helvetica Neue",Arial,"PingFang SC","Microsoft YaHei",sans-serif;line-height:1.6;max-width:800px;margin:2em auto;padding:0 1em;color:#222}` +
+	`pre{background:#f6f8fa;padding:1em;overflow:auto}blockquote{border-left:4px solid #ddd;margin:0;padding-left:1em;color:#555}`
+
+// html -> txt
+func htmlToText(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, _, err := parseHTML(r)
+	if err != nil {
+		return err
+	}
+	return renderText(w, blocks, opts)
+}
+
+// html -> pdf
+func htmlToPDF(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, title, err := parseHTML(r)
+	if err != nil {
+		return err
+	}
+	if _, ok := opts["title"]; !ok && title != "" {
+		opts = withOption(opts, "title", title)
+	}
+	return renderPDF(ctx, w, blocks, opts)
+}
+
+// 解析HTML为文档块，同时返回<title>内容
+func parseHTML(r io.Reader) ([]Block, string, error) {
+	p := &htmlParser{}
+	z := xhtml.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, "", err
+			}
+			p.flush()
+			return p.blocks, strings.TrimSpace(p.title.String()), nil
+		case xhtml.TextToken:
+			p.text(string(z.Text()))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			var href string
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "href" {
+					href = string(val)
+				}
+			}
+			p.start(atom.Lookup(name), href)
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			p.end(atom.Lookup(name))
+		}
+	}
+}
+
+type htmlParser struct {
+	blocks []Block
+	cur    *Block
+	title  strings.Builder
+
+	skip, inTitle, pre, quote int
+	bold, italic, code        int
+	links                     []string
+	lists                     []bool
+}
+
+func (p *htmlParser) flush() {
+	if p.cur != nil && p.cur.trim() {
+		p.blocks = append(p.blocks, *p.cur)
+	}
+	p.cur = nil
+}
+
+func (p *htmlParser) open(b Block) {
+	p.flush()
+	p.cur = &b
+}
+
+func (p *htmlParser) start(a atom.Atom, href string) {
+	switch a {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg:
+		p.skip++
+	case atom.Title:
+		p.inTitle++
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(a.String()[1:])
+		p.open(Block{Kind: BlockHeading, Level: level})
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Nav, atom.Aside,
+		atom.Table, atom.Tr, atom.Td, atom.Th, atom.Dl, atom.Dt, atom.Dd, atom.Figcaption, atom.Address:
+		p.flush()
+	case atom.Ul, atom.Ol:
+		p.flush()
+		p.lists = append(p.lists, a == atom.Ol)
+	case atom.Li:
+		b := Block{Kind: BlockListItem}
+		if n := len(p.lists); n > 0 {
+			b.Level = n - 1
+			b.Ordered = p.lists[n-1]
+		}
+		p.open(b)
+	case atom.Pre:
+		p.open(Block{Kind: BlockCode})
+		p.pre++
+	case atom.Blockquote:
+		p.flush()
+		p.quote++
+	case atom.Hr:
+		p.flush()
+		p.blocks = append(p.blocks, Block{Kind: BlockRule})
+	case atom.Br:
+		p.ensure()
+		p.cur.appendSpan(p.span("\n"))
+	case atom.B, atom.Strong:
+		p.bold++
+	case atom.I, atom.Em:
+		p.italic++
+	case atom.Code, atom.Tt, atom.Kbd, atom.Samp:
+		p.code++
+	case atom.A:
+		p.links = append(p.links, href)
+	}
+}
+
+func (p *htmlParser) end(a atom.Atom) {
+	switch a {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg:
+		p.skip = decr(p.skip)
+	case atom.Title:
+		p.inTitle = decr(p.inTitle)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Li,
+		atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Nav, atom.Aside,
+		atom.Table, atom.Tr, atom.Td, atom.Th, atom.Dl, atom.Dt, atom.Dd, atom.Figcaption, atom.Address:
+		p.flush()
+	case atom.Ul, atom.Ol:
+		p.flush()
+		if n := len(p.lists); n > 0 {
+			p.lists = p.lists[:n-1]
+		}
+	case atom.Pre:
+		p.flush()
+		p.pre = decr(p.pre)
+	case atom.Blockquote:
+		p.flush()
+		p.quote = decr(p.quote)
+	case atom.B, atom.Strong:
+		p.bold = decr(p.bold)
+	case atom.I, atom.Em:
+		p.italic = decr(p.italic)
+	case atom.Code, atom.Tt, atom.Kbd, atom.Samp:
+		p.code = decr(p.code)
+	case atom.A:
+		if n := len(p.links); n > 0 {
+			p.links = p.links[:n-1]
+		}
+	}
+}
+
+// 确保存在当前块，游离文本归入段落或引用
+func (p *htmlParser) ensure() {
+	if p.cur != nil {
+		return
+	}
+	kind := BlockParagraph
+	if p.quote > 0 {
+		kind = BlockQuote
+	}
+	p.cur = &Block{Kind: kind}
+}
+
+func (p *htmlParser) span(text string) Span {
+	s := Span{Text: text, Bold: p.bold > 0, Italic: p.italic > 0, Code: p.code > 0 || p.pre > 0}
+	if n := len(p.links); n > 0 {
+		s.Link = p.links[n-1]
+	}
+	return s
+}
+
+func (p *htmlParser) text(text string) {
+	if p.skip > 0 {
+		return
+	}
+	if p.inTitle > 0 {
+		p.title.WriteString(text)
+		return
+	}
+	if p.pre == 0 {
+		// 折叠空白，块首的空白直接丢弃
+		collapsed := strings.Join(strings.Fields(text), " ")
+		if collapsed == "" {
+			if p.cur != nil && !endsWithSpace(p.cur) {
+				p.cur.appendSpan(p.span(" "))
+			}
+			return
+		}
+		if isSpace(text[0]) && p.cur != nil && !endsWithSpace(p.cur) {
+			collapsed = " " + collapsed
+		}
+		if isSpace(text[len(text)-1]) {
+			collapsed += " "
+		}
+		text = collapsed
+	}
+	p.ensure()
+	p.cur.appendSpan(p.span(text))
+}
+
+func decr(n int) int {
+	if n > 0 {
+		return n - 1
+	}
+	return 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r' || c == '\f'
+}
+
+func endsWithSpace(b *Block) bool {
+	if len(b.Spans) == 0 {
+		return true
+	}
+	t := b.Spans[len(b.Spans)-1].Text
+	return t == "" || strings.HasSuffix(t, " ") || strings.HasSuffix(t, "\n")
+}
+
+// 输出HTML，fragment=true时仅输出正文片段
+func renderHTML(w io.Writer, blocks []Block, opts Options) error {
+	bw := bufio.NewWriter(w)
+	fragment := opts.Bool("fragment", false)
+	if !fragment {
+		title := opts.String("title", documentTitle(blocks))
+		fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n",
+			html.EscapeString(title), opts.String("stylesheet", defaultStylesheet))
+	}
+
+	var lists []bool
+	closeLists := func(depth int) {
+		for len(lists) > depth {
+			if lists[len(lists)-1] {
+				bw.WriteString("</li>\n</ol>\n")
+			} else {
+				bw.WriteString("</li>\n</ul>\n")
+			}
+			lists = lists[:len(lists)-1]
+		}
+	}
+
+	for _, b := range blocks {
+		if b.Kind != BlockListItem {
+			closeLists(0)
+		}
+		switch b.Kind {
+		case BlockHeading:
+			level := b.Level
+			if level < 1 || level > 6 {
+				level = 1
+			}
+			fmt.Fprintf(bw, "<h%d>%s</h%d>\n", level, htmlSpans(b.Spans), level)
+		case BlockListItem:
+			closeLists(b.Level + 1)
+			if len(lists) == b.Level+1 && lists[b.Level] != b.Ordered {
+				closeLists(b.Level)
+			}
+			if len(lists) == b.Level+1 {
+				bw.WriteString("</li>\n")
+			}
+			// 跳级缩进时补齐中间层级，保证每层都有打开的<li>
+			for len(lists) < b.Level+1 {
+				ordered := b.Ordered && len(lists) == b.Level
+				if ordered {
+					bw.WriteString("<ol>\n")
+				} else {
+					bw.WriteString("<ul>\n")
+				}
+				lists = append(lists, ordered)
+				if len(lists) < b.Level+1 {
+					bw.WriteString("<li>")
+				}
+			}
+			bw.WriteString("<li>" + htmlSpans(b.Spans))
+		case BlockCode:
+			bw.WriteString("<pre><code>" + html.EscapeString(b.Text()) + "</code></pre>\n")
+		case BlockQuote:
+			bw.WriteString("<blockquote><p>" + htmlSpans(b.Spans) + "</p></blockquote>\n")
+		case BlockRule:
+			bw.WriteString("<hr>\n")
+		default:
+			bw.WriteString("<p>" + htmlSpans(b.Spans) + "</p>\n")
+		}
+	}
+	closeLists(0)
+
+	if !fragment {
+		bw.WriteString("</body>\n</html>\n")
+	}
+	return bw.Flush()
+}
+
+func htmlSpans(spans []Span) string {
+	var sb strings.Builder
+	for _, s := range spans {
+		text := strings.ReplaceAll(html.EscapeString(s.Text), "\n", "<br>")
+		if s.Code {
+			text = "<code>" + text + "</code>"
+		}
+		if s.Italic {
+			text = "<em>" + text + "</em>"
+		}
+		if s.Bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		if s.Link != "" {
+			text = `<a href="` + html.EscapeString(s.Link) + `">` + text + "</a>"
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// 输出纯文本，line_width>0时按宽度折行
+func renderText(w io.Writer, blocks []Block, opts Options) error {
+	bw := bufio.NewWriter(w)
+	width := int(opts.Float("line_width", 0))
+	counters := map[int]int{}
+	for i, b := range blocks {
+		if i > 0 && !sameList(&blocks[i-1], &b) {
+			bw.WriteString("\n")
+		}
+		if i == 0 || !sameList(&blocks[i-1], &b) {
+			counters = map[int]int{}
+		}
+		text := plainSpans(b.Spans)
+		switch b.Kind {
+		case BlockHeading:
+			bw.WriteString(text + "\n")
+			if b.Level <= 2 {
+				underline := "="
+				if b.Level == 2 {
+					underline = "-"
+				}
+				bw.WriteString(strings.Repeat(underline, utf8.RuneCountInString(text)) + "\n")
+			}
+		case BlockListItem:
+			indent := strings.Repeat("  ", b.Level)
+			marker := "- "
+			if b.Ordered {
+				counters[b.Level]++
+				marker = strconv.Itoa(counters[b.Level]) + ". "
+			}
+			bw.WriteString(wrapText(text, width, indent+marker, indent+strings.Repeat(" ", len(marker))))
+		case BlockCode:
+			for _, line := range strings.Split(text, "\n") {
+				bw.WriteString("    " + line + "\n")
+			}
+		case BlockQuote:
+			bw.WriteString(wrapText(text, width, "> ", "> "))
+		case BlockRule:
+			bw.WriteString("----------\n")
+		default:
+			bw.WriteString(wrapText(text, width, "", ""))
+		}
+	}
+	return bw.Flush()
+}
+
+func plainSpans(spans []Span) string {
+	var sb strings.Builder
+	for _, s := range spans {
+		sb.WriteString(s.Text)
+		if s.Link != "" && s.Link != strings.TrimSpace(s.Text) && !strings.HasPrefix(s.Link, "#") {
+			sb.WriteString(" (" + s.Link + ")")
+		}
+	}
+	return sb.String()
+}
+
+// 按宽度折行，首行与续行使用不同前缀
+func wrapText(text string, width int, first, rest string) string {
+	var sb strings.Builder
+	for i, line := range strings.Split(text, "\n") {
+		prefix := first
+		if i > 0 {
+			prefix = rest
+		}
+		if width <= 0 {
+			sb.WriteString(prefix + line + "\n")
+			continue
+		}
+		cur := prefix
+		empty := true
+		for _, word := range strings.Fields(line) {
+			if !empty && utf8.RuneCountInString(cur)+1+utf8.RuneCountInString(word) > width {
+				sb.WriteString(cur + "\n")
+				cur, empty = rest, true
+			}
+			if !empty {
+				cur += " "
+			}
+			cur += word
+			empty = false
+		}
+		sb.WriteString(cur + "\n")
+	}
+	return sb.String()
+}
+
+func withOption(opts Options, key string, value interface{}) Options {
+	merged := make(Options, len(opts)+1)
+	for k, v := range opts {
+		merged[k] = v
+	}
+	merged[key] = value
+	return merged
+}
@@ -0,0 +1,282 @@
+package converter
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mdHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule        = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	mdBullet      = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdOrdered     = regexp.MustCompile(`^(\s*)\d+[.)]\s+(.*)$`)
+	mdFence       = regexp.MustCompile("^\\s*(```|~~~)")
+	mdEscapedChar = strings.NewReplacer(`\*`, "*", `\_`, "_", "\\`", "`", `\[`, "[", `\]`, "]", `\#`, "#", `\\`, `\`)
+)
+
+// md -> html
+func markdownToHTML(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, err := parseMarkdown(r)
+	if err != nil {
+		return err
+	}
+	return renderHTML(w, blocks, opts)
+}
+
+// 解析Markdown常用语法：标题、段落、列表、引用、代码块、分隔线及行内样式
+func parseMarkdown(r io.Reader) ([]Block, error) {
+	var blocks []Block
+	var para []string
+	var quote []string
+
+	flush := func() {
+		if len(para) > 0 {
+			b := Block{Kind: BlockParagraph, Spans: parseInline(strings.Join(para, " "))}
+			if b.trim() {
+				blocks = append(blocks, b)
+			}
+			para = nil
+		}
+		if len(quote) > 0 {
+			b := Block{Kind: BlockQuote, Spans: parseInline(strings.Join(quote, " "))}
+			if b.trim() {
+				blocks = append(blocks, b)
+			}
+			quote = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var code *strings.Builder
+	var fence string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if code != nil {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				blocks = append(blocks, Block{Kind: BlockCode, Spans: []Span{{Text: strings.TrimSuffix(code.String(), "\n"), Code: true}}})
+				code = nil
+				continue
+			}
+			code.WriteString(line)
+			code.WriteByte('\n')
+			continue
+		}
+
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			flush()
+			code = &strings.Builder{}
+			fence = m[1]
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case mdRule.MatchString(line):
+			flush()
+			blocks = append(blocks, Block{Kind: BlockRule})
+		case mdHeading.MatchString(trimmed):
+			flush()
+			m := mdHeading.FindStringSubmatch(trimmed)
+			blocks = append(blocks, Block{Kind: BlockHeading, Level: len(m[1]), Spans: parseInline(m[2])})
+		case strings.HasPrefix(trimmed, ">"):
+			if len(para) > 0 {
+				flush()
+			}
+			quote = append(quote, strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+		case mdBullet.MatchString(line):
+			flush()
+			m := mdBullet.FindStringSubmatch(line)
+			blocks = append(blocks, Block{Kind: BlockListItem, Level: indentLevel(m[1]), Spans: parseInline(m[2])})
+		case mdOrdered.MatchString(line):
+			flush()
+			m := mdOrdered.FindStringSubmatch(line)
+			blocks = append(blocks, Block{Kind: BlockListItem, Level: indentLevel(m[1]), Ordered: true, Spans: parseInline(m[2])})
+		default:
+			if len(quote) > 0 {
+				quote = append(quote, trimmed)
+				continue
+			}
+			// 列表项的续行
+			if len(para) == 0 && len(blocks) > 0 && blocks[len(blocks)-1].Kind == BlockListItem && line != trimmed {
+				last := &blocks[len(blocks)-1]
+				last.Spans = append(last.Spans, parseInline(" "+trimmed)...)
+				continue
+			}
+			para = append(para, trimmed)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if code != nil {
+		blocks = append(blocks, Block{Kind: BlockCode, Spans: []Span{{Text: strings.TrimSuffix(code.String(), "\n"), Code: true}}})
+	}
+	flush()
+	return blocks, nil
+}
+
+func indentLevel(indent string) int {
+	width := 0
+	for _, c := range indent {
+		if c == '\t' {
+			width += 4
+		} else {
+			width++
+		}
+	}
+	return width / 2
+}
+
+// 解析行内样式：**粗体**、*斜体*、`代码`、[链接](地址)
+func parseInline(text string) []Span {
+	var spans []Span
+	var bold, italic bool
+	var buf strings.Builder
+
+	emit := func(s Span) {
+		if s.Text == "" {
+			return
+		}
+		b := Block{Spans: spans}
+		b.appendSpan(s)
+		spans = b.Spans
+	}
+	flush := func() {
+		emit(Span{Text: mdEscapedChar.Replace(buf.String()), Bold: bold, Italic: italic})
+		buf.Reset()
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			buf.WriteString(text[i : i+2])
+			i += 2
+		case c == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end < 0 {
+				buf.WriteByte(c)
+				i++
+				continue
+			}
+			flush()
+			emit(Span{Text: text[i+1 : i+1+end], Code: true, Bold: bold, Italic: italic})
+			i += end + 2
+		case strings.HasPrefix(text[i:], "**") || strings.HasPrefix(text[i:], "__"):
+			flush()
+			bold = !bold
+			i += 2
+		case c == '*' || (c == '_' && (i == 0 || text[i-1] == ' ' || (i+1 < len(text) && text[i+1] == ' ') || italic)):
+			flush()
+			italic = !italic
+			i++
+		case c == '[':
+			closeText := strings.Index(text[i:], "](")
+			if closeText < 0 {
+				buf.WriteByte(c)
+				i++
+				continue
+			}
+			closeURL := strings.IndexByte(text[i+closeText:], ')')
+			if closeURL < 0 {
+				buf.WriteByte(c)
+				i++
+				continue
+			}
+			flush()
+			label := text[i+1 : i+closeText]
+			url := text[i+closeText+2 : i+closeText+closeURL]
+			for _, s := range parseInline(label) {
+				s.Link = url
+				s.Bold = s.Bold || bold
+				s.Italic = s.Italic || italic
+				emit(s)
+			}
+			i += closeText + closeURL + 1
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return spans
+}
+
+// 输出Markdown
+func renderMarkdown(w io.Writer, blocks []Block) error {
+	bw := bufio.NewWriter(w)
+	counters := map[int]int{}
+	for i, b := range blocks {
+		if i > 0 && !sameList(&blocks[i-1], &b) {
+			bw.WriteString("\n")
+		}
+		if i == 0 || !sameList(&blocks[i-1], &b) {
+			counters = map[int]int{}
+		}
+		switch b.Kind {
+		case BlockHeading:
+			level := b.Level
+			if level < 1 {
+				level = 1
+			}
+			bw.WriteString(strings.Repeat("#", level) + " " + markdownSpans(b.Spans) + "\n")
+		case BlockListItem:
+			indent := strings.Repeat("  ", b.Level)
+			if b.Ordered {
+				counters[b.Level]++
+				bw.WriteString(indent + strconv.Itoa(counters[b.Level]) + ". " + markdownSpans(b.Spans) + "\n")
+			} else {
+				bw.WriteString(indent + "- " + markdownSpans(b.Spans) + "\n")
+			}
+		case BlockCode:
+			bw.WriteString("```\n" + b.Text() + "\n```\n")
+		case BlockQuote:
+			bw.WriteString("> " + markdownSpans(b.Spans) + "\n")
+		case BlockRule:
+			bw.WriteString("---\n")
+		default:
+			bw.WriteString(markdownSpans(b.Spans) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+var mdEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+
+func markdownSpans(spans []Span) string {
+	var sb strings.Builder
+	for _, s := range spans {
+		text := s.Text
+		if s.Code {
+			text = "`" + text + "`"
+		} else {
+			text = mdEscaper.Replace(text)
+		}
+		if strings.TrimSpace(text) == "" {
+			sb.WriteString(text)
+			continue
+		}
+		lead := len(text) - len(strings.TrimLeft(text, " "))
+		trail := len(text) - len(strings.TrimRight(text, " "))
+		core := strings.Trim(text, " ")
+		if s.Italic {
+			core = "*" + core + "*"
+		}
+		if s.Bold {
+			core = "**" + core + "**"
+		}
+		if s.Link != "" {
+			core = "[" + core + "](" + s.Link + ")"
+		}
+		sb.WriteString(strings.Repeat(" ", lead) + core + strings.Repeat(" ", trail))
+	}
+	return strings.ReplaceAll(sb.String(), "\n", "  \n")
+}
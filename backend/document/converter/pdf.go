@@ -0,0 +1,544 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// txt -> pdf
+func textToPDF(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	blocks, err := parseText(r, opts.Bool("monospace", false))
+	if err != nil {
+		return err
+	}
+	return renderPDF(ctx, w, blocks, opts)
+}
+
+// 纯文本按空行分段，段内保留换行
+func parseText(r io.Reader, monospace bool) ([]Block, error) {
+	var blocks []Block
+	var lines []string
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		b := Block{Kind: BlockParagraph, Spans: []Span{{Text: strings.Join(lines, "\n"), Code: monospace}}}
+		if monospace {
+			b.Kind = BlockCode
+		}
+		if b.trim() {
+			blocks = append(blocks, b)
+		}
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		lines = append(lines, strings.ReplaceAll(line, "\t", "    "))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return blocks, nil
+}
+
+// 字体编号：F1-F4为Helvetica系列，F5为Courier，F6为中文STSong-Light（不嵌入，由阅读器提供）
+const (
+	fontRegular = iota
+	fontBold
+	fontItalic
+	fontBoldItalic
+	fontMono
+	fontCJK
+	fontCount
+)
+
+var pdfBaseFonts = [...]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique", "Courier"}
+
+// Helvetica 与 Helvetica-Bold 的ASCII字宽（1/1000 em），取自标准AFM
+var helveticaWidths = [95]uint16{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]uint16{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// WinAnsiEncoding 中 0x80-0x9F 区间的字符
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+func winAnsi(r rune) (byte, bool) {
+	if r >= 0x20 && r < 0x7F || r >= 0xA0 && r <= 0xFF {
+		return byte(r), true
+	}
+	b, ok := winAnsiExtra[r]
+	return b, ok
+}
+
+// 字宽，单位1/1000 em
+func glyphWidth(font int, r rune) float64 {
+	switch font {
+	case fontMono:
+		return 600
+	case fontCJK:
+		if r < 0x2E80 {
+			return 500
+		}
+		return 1000
+	}
+	if r >= 0x20 && r < 0x7F {
+		if font == fontBold || font == fontBoldItalic {
+			return float64(helveticaBoldWidths[r-0x20])
+		}
+		return float64(helveticaWidths[r-0x20])
+	}
+	return 556
+}
+
+// 排版参数
+type pdfLayout struct {
+	width, height float64
+	margin        float64
+	fontSize      float64
+}
+
+func newPDFLayout(opts Options) pdfLayout {
+	l := pdfLayout{width: 595.28, height: 841.89}
+	if strings.EqualFold(opts.String("page_size", "A4"), "letter") {
+		l.width, l.height = 612, 792
+	}
+	l.margin = opts.Float("margin", 56)
+	l.fontSize = opts.Float("font_size", 11)
+	if l.fontSize <= 0 {
+		l.fontSize = 11
+	}
+	if l.margin < 0 || l.margin*2 >= l.width-l.fontSize*4 {
+		l.margin = 56
+	}
+	return l
+}
+
+// 行内的一段同字体文本
+type pdfRun struct {
+	font  int
+	size  float64
+	text  string
+	width float64
+	space bool // 空白，可在此处断行
+	br    bool // 强制换行
+}
+
+type pdfSegment struct {
+	x    float64
+	font int
+	size float64
+	text []rune
+}
+
+// PDF写出器：页面内容逐页压缩写出，最后写页面树与交叉引用表
+type pdfWriter struct {
+	w       *bufio.Writer
+	pos     int64
+	offsets map[int]int64
+	layout  pdfLayout
+	pages   []int
+	nextObj int
+
+	content *bytes.Buffer
+	y       float64
+}
+
+const (
+	objCatalog = 1
+	objPages   = 2
+	objInfo    = 3
+	objFonts   = 4 // F1-F5占4-8，中文字体占9-11
+	objFirst   = 12
+)
+
+func (p *pdfWriter) write(format string, args ...interface{}) {
+	n, _ := fmt.Fprintf(p.w, format, args...)
+	p.pos += int64(n)
+}
+
+func (p *pdfWriter) startObj(num int) {
+	p.offsets[num] = p.pos
+	p.write("%d 0 obj\n", num)
+}
+
+func (p *pdfWriter) writeStream(num int, data []byte) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	p.startObj(num)
+	p.write("<< /Length %d /Filter /FlateDecode >>\nstream\n", buf.Len())
+	n, _ := p.w.Write(buf.Bytes())
+	p.pos += int64(n)
+	p.write("\nendstream\nendobj\n")
+}
+
+func (p *pdfWriter) writeFonts() {
+	for i, base := range pdfBaseFonts {
+		p.startObj(objFonts + i)
+		p.write("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\nendobj\n", base)
+	}
+	cjk := objFonts + fontCJK
+	p.startObj(cjk)
+	p.write("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [%d 0 R] >>\nendobj\n", cjk+1)
+	p.startObj(cjk + 1)
+	p.write("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor %d 0 R /DW 1000 >>\nendobj\n", cjk+2)
+	p.startObj(cjk + 2)
+	p.write("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>\nendobj\n")
+}
+
+func (p *pdfWriter) newPage() {
+	p.finishPage()
+	p.content = &bytes.Buffer{}
+	p.y = p.layout.height - p.layout.margin
+}
+
+func (p *pdfWriter) finishPage() {
+	if p.content == nil {
+		return
+	}
+	contentObj, pageObj := p.nextObj, p.nextObj+1
+	p.nextObj += 2
+	p.writeStream(contentObj, p.content.Bytes())
+	p.startObj(pageObj)
+	p.write("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R /Resources << /Font <<",
+		objPages, pdfNum(p.layout.width), pdfNum(p.layout.height), contentObj)
+	for i := 0; i < fontCount; i++ {
+		p.write(" /F%d %d 0 R", i+1, objFonts+i)
+	}
+	p.write(" >> >> >>\nendobj\n")
+	p.pages = append(p.pages, pageObj)
+	p.content = nil
+}
+
+// 预留空间，不足时换页
+func (p *pdfWriter) reserve(h float64) {
+	if p.content == nil || p.y-h < p.layout.margin {
+		p.newPage()
+	}
+}
+
+func (p *pdfWriter) drawLine(segments []pdfSegment, lineHeight float64) {
+	p.reserve(lineHeight)
+	p.y -= lineHeight
+	baseline := p.y + lineHeight*0.25
+	for _, seg := range segments {
+		fmt.Fprintf(p.content, "BT /F%d %s Tf %s %s Td <%s> Tj ET\n",
+			seg.font+1, pdfNum(seg.size), pdfNum(seg.x), pdfNum(baseline), encodeRunes(seg.font, seg.text))
+	}
+}
+
+func (p *pdfWriter) drawRule() {
+	p.reserve(p.layout.fontSize)
+	p.y -= p.layout.fontSize / 2
+	fmt.Fprintf(p.content, "0.6 G 0.5 w %s %s m %s %s l S 0 G\n",
+		pdfNum(p.layout.margin), pdfNum(p.y), pdfNum(p.layout.width-p.layout.margin), pdfNum(p.y))
+	p.y -= p.layout.fontSize / 2
+}
+
+func encodeRunes(font int, text []rune) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if font == fontCJK {
+			if r > 0xFFFF {
+				r = '?'
+			}
+			fmt.Fprintf(&sb, "%04X", r)
+			continue
+		}
+		b, ok := winAnsi(r)
+		if !ok {
+			b = '?'
+		}
+		fmt.Fprintf(&sb, "%02X", b)
+	}
+	return sb.String()
+}
+
+func pdfNum(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// 按块排版输出PDF
+func renderPDF(ctx context.Context, w io.Writer, blocks []Block, opts Options) error {
+	layout := newPDFLayout(opts)
+	p := &pdfWriter{
+		w:       bufio.NewWriter(w),
+		offsets: map[int]int64{},
+		layout:  layout,
+		nextObj: objFirst,
+	}
+	p.write("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	p.writeFonts()
+
+	base := layout.fontSize
+	counters := map[int]int{}
+	for i, b := range blocks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if b.Kind != BlockListItem {
+			counters = map[int]int{}
+		}
+
+		size, indent, marker := base, 0.0, ""
+		lineFactor := 1.35
+		style := func(s Span) int { return spanFont(s) }
+		switch b.Kind {
+		case BlockHeading:
+			switch b.Level {
+			case 1:
+				size = base * 1.8
+			case 2:
+				size = base * 1.45
+			case 3:
+				size = base * 1.2
+			default:
+				size = base * 1.05
+			}
+			lineFactor = 1.25
+			style = func(s Span) int { s.Bold = true; return spanFont(s) }
+			if i > 0 && p.content != nil {
+				p.y -= size * 0.6
+			}
+		case BlockListItem:
+			indent = 18 * float64(b.Level+1)
+			marker = "•"
+			if b.Ordered {
+				counters[b.Level]++
+				marker = strconv.Itoa(counters[b.Level]) + "."
+			}
+		case BlockQuote:
+			indent = 18
+			style = func(s Span) int { s.Italic = true; return spanFont(s) }
+		case BlockCode:
+			size = base * 0.9
+			lineFactor = 1.25
+			style = func(s Span) int { return fontMono }
+		case BlockRule:
+			p.reserve(base)
+			p.drawRule()
+			continue
+		}
+
+		lineHeight := size * lineFactor
+		x0 := layout.margin + indent
+		lines := layoutRuns(pdfRuns(b.Spans, size, style), layout.width-layout.margin-x0)
+		for li, line := range lines {
+			segments := make([]pdfSegment, 0, len(line))
+			for _, seg := range line {
+				seg.x += x0
+				segments = append(segments, seg)
+			}
+			if li == 0 && marker != "" {
+				markerRunes := []rune(marker)
+				segments = append([]pdfSegment{{x: x0 - 6 - textWidth(fontRegular, size, markerRunes), font: fontRegular, size: size, text: markerRunes}}, segments...)
+			}
+			p.drawLine(segments, lineHeight)
+		}
+
+		// 块间距，相邻列表项更紧凑
+		if i+1 < len(blocks) && !(b.Kind == BlockListItem && blocks[i+1].Kind == BlockListItem) {
+			p.y -= base * 0.6
+		}
+	}
+	if p.content == nil {
+		p.newPage()
+	}
+	p.finishPage()
+
+	p.startObj(objPages)
+	p.write("<< /Type /Pages /Count %d /Kids [", len(p.pages))
+	for _, page := range p.pages {
+		p.write(" %d 0 R", page)
+	}
+	p.write(" ] >>\nendobj\n")
+	p.startObj(objCatalog)
+	p.write("<< /Type /Catalog /Pages %d 0 R >>\nendobj\n", objPages)
+	p.startObj(objInfo)
+	p.write("<< /Producer (resume-centre document service) /CreationDate (D:%s)", time.Now().UTC().Format("20060102150405Z"))
+	if title := opts.String("title", documentTitle(blocks)); title != "" {
+		p.write(" /Title <FEFF%s>", utf16Hex(title))
+	}
+	p.write(" >>\nendobj\n")
+
+	// 交叉引用表
+	xref := p.pos
+	size := p.nextObj
+	p.write("xref\n0 %d\n0000000000 65535 f \n", size)
+	for num := 1; num < size; num++ {
+		p.write("%010d 00000 n \n", p.offsets[num])
+	}
+	p.write("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, objCatalog, objInfo, xref)
+	return p.w.Flush()
+}
+
+func spanFont(s Span) int {
+	switch {
+	case s.Code:
+		return fontMono
+	case s.Bold && s.Italic:
+		return fontBoldItalic
+	case s.Bold:
+		return fontBold
+	case s.Italic:
+		return fontItalic
+	}
+	return fontRegular
+}
+
+func textWidth(font int, size float64, text []rune) float64 {
+	var w float64
+	for _, r := range text {
+		w += glyphWidth(font, r)
+	}
+	return w * size / 1000
+}
+
+// 切分为可断行的最小单元：西文单词、单个中文字符、空白、换行
+func pdfRuns(spans []Span, size float64, style func(Span) int) []pdfRun {
+	var runs []pdfRun
+	for _, s := range spans {
+		base := style(s)
+		var word []rune
+		wordFont := base
+		emit := func() {
+			if len(word) > 0 {
+				runs = append(runs, pdfRun{font: wordFont, size: size, text: string(word), width: textWidth(wordFont, size, word)})
+				word = nil
+			}
+		}
+		for _, r := range s.Text {
+			switch {
+			case r == '\n':
+				emit()
+				runs = append(runs, pdfRun{br: true})
+			case unicode.IsSpace(r):
+				emit()
+				runs = append(runs, pdfRun{font: base, size: size, text: " ", width: textWidth(base, size, []rune{' '}), space: true})
+			default:
+				font := base
+				if _, ok := winAnsi(r); !ok {
+					font = fontCJK
+				}
+				if font != wordFont {
+					emit()
+					wordFont = font
+				}
+				word = append(word, r)
+				// 中文字符之间可以断行
+				if font == fontCJK && r >= 0x2E80 {
+					emit()
+				}
+			}
+		}
+		emit()
+	}
+	return runs
+}
+
+// 贪心断行，返回每行的文本段（x相对行首）
+func layoutRuns(runs []pdfRun, maxWidth float64) [][]pdfSegment {
+	var lines [][]pdfSegment
+	var line []pdfSegment
+	x := 0.0
+	pendingSpace := 0.0
+
+	appendText := func(run pdfRun) {
+		if n := len(line); n > 0 && line[n-1].font == run.font && line[n-1].size == run.size {
+			line[n-1].text = append(line[n-1].text, []rune(run.text)...)
+		} else {
+			line = append(line, pdfSegment{x: x, font: run.font, size: run.size, text: []rune(run.text)})
+		}
+		x += run.width
+	}
+	newLine := func() {
+		lines = append(lines, line)
+		line, x, pendingSpace = nil, 0, 0
+	}
+
+	for _, run := range runs {
+		switch {
+		case run.br:
+			newLine()
+		case run.space:
+			if x > 0 {
+				pendingSpace = run.width
+			}
+		default:
+			if x > 0 && x+pendingSpace+run.width > maxWidth {
+				newLine()
+			}
+			if pendingSpace > 0 {
+				appendText(pdfRun{font: run.font, size: run.size, text: " ", width: pendingSpace})
+				pendingSpace = 0
+			}
+			// 超长单词按字符强制拆分
+			for run.width > maxWidth && utf8.RuneCountInString(run.text) > 1 {
+				rs := []rune(run.text)
+				n := 1
+				for n < len(rs) && x+textWidth(run.font, run.size, rs[:n+1]) <= maxWidth {
+					n++
+				}
+				appendText(pdfRun{font: run.font, size: run.size, text: string(rs[:n]), width: textWidth(run.font, run.size, rs[:n])})
+				newLine()
+				run.text = string(rs[n:])
+				run.width = textWidth(run.font, run.size, rs[n:])
+			}
+			appendText(run)
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func utf16Hex(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r > 0xFFFF {
+			r1, r2 := (((r-0x10000)>>10)&0x3FF)+0xD800, ((r-0x10000)&0x3FF)+0xDC00
+			fmt.Fprintf(&sb, "%04X%04X", r1, r2)
+			continue
+		}
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	return sb.String()
+}
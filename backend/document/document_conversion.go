@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"resume-centre/document/converter"
+	"resume-centre/shared/infrastructure/storage"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// 文档格式转换器注册表
+var converters = converter.NewDefaultRegistry()
+
+var formatContentTypes = map[string]string{
+	"md":   "text/markdown; charset=utf-8",
+	"html": "text/html; charset=utf-8",
+	"txt":  "text/plain; charset=utf-8",
+	"pdf":  "application/pdf",
+	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+var (
+	// ErrConversionConfigNotFound 指定的转换配置不存在或已停用
+	ErrConversionConfigNotFound = errors.New("conversion config not found")
+	// ErrConversionConfigMismatch 指定的转换配置与源/目标格式不一致
+	ErrConversionConfigMismatch = errors.New("conversion config does not match formats")
+)
+
+// 检查源格式到目标格式是否可转换，并确定使用的转换配置
+func resolveConversion(source, target DocumentFormat, configID string) (*ConversionConfig, error) {
+	if _, err := converters.Plan(string(source), string(target)); err != nil {
+		return nil, err
+	}
+
+	var config ConversionConfig
+	if configID != "" {
+		if err := db.Where("id = ? AND is_active = ?", configID, true).First(&config).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrConversionConfigNotFound, configID)
+			}
+			return nil, err
+		}
+		if converter.NormalizeFormat(string(config.SourceFormat)) != converter.NormalizeFormat(string(source)) ||
+			converter.NormalizeFormat(string(config.TargetFormat)) != converter.NormalizeFormat(string(target)) {
+			return nil, fmt.Errorf("%w: config %s converts %s to %s", ErrConversionConfigMismatch,
+				config.ID, config.SourceFormat, config.TargetFormat)
+		}
+		return &config, nil
+	}
+
+	// 未指定时使用该格式对的默认配置，没有则使用转换器默认选项
+	err := db.Where("source_format = ? AND target_format = ? AND is_active = ?", source, target, true).
+		Order("created_at").First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// 转换请求是否因格式或配置本身无效而无法完成
+func isConversionRejected(err error) bool {
+	return errors.Is(err, converter.ErrUnsupportedConversion) ||
+		errors.Is(err, ErrConversionConfigNotFound) ||
+		errors.Is(err, ErrConversionConfigMismatch)
+}
+
+func conversionOptions(config *ConversionConfig) (converter.Options, error) {
+	opts := converter.Options{}
+	if config == nil || config.ConfigData == "" {
+		return opts, nil
+	}
+	if err := json.Unmarshal([]byte(config.ConfigData), &opts); err != nil {
+		return nil, fmt.Errorf("invalid config_data of conversion config %s: %v", config.ID, err)
+	}
+	return opts, nil
+}
+
+// 文档格式转换：从存储服务读取源文件，经转换链流式写回存储服务
+func convertDocumentJob(jc *JobContext) error {
+	task, err := startDocumentTask(jc)
+	if err != nil {
+		return err
+	}
+
+	config, err := resolveConversion(task.SourceFormat, task.TargetFormat, task.ConfigID)
+	if err != nil {
+		if isConversionRejected(err) {
+			return fmt.Errorf("%w: %w", ErrPermanentJobFailure, err)
+		}
+		return err
+	}
+	opts, err := conversionOptions(config)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPermanentJobFailure, err)
+	}
+
+	ctx := context.Context(jc)
+	if timeout := viper.GetInt("processing.conversion.timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	files := newStorageClient()
+	source, err := files.OpenAs(ctx, task.UserID, task.SourceFileID)
+	if err != nil {
+		if errors.Is(err, storage.ErrFileNotFound) {
+			return fmt.Errorf("%w: %w", ErrPermanentJobFailure, err)
+		}
+		return err
+	}
+	defer source.Close()
+	jc.Progress(10)
+
+	// 转换输出通过管道直接作为上传请求体
+	target := converter.NormalizeFormat(string(task.TargetFormat))
+	pr, pw := io.Pipe()
+	convErr := make(chan error, 1)
+	go func() {
+		err := converters.Convert(ctx, string(task.SourceFormat), target, source, pw, opts)
+		pw.CloseWithError(err)
+		convErr <- err
+	}()
+
+	contentType := formatContentTypes[target]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	targetFileID, putErr := files.Put(ctx, task.UserID, task.ID+"."+target, contentType, pr)
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err := <-convErr; err != nil && !(putErr != nil && errors.Is(err, io.ErrClosedPipe)) {
+		if errors.Is(err, converter.ErrUnsupportedConversion) || errors.Is(err, converter.ErrInvalidDocx) {
+			return fmt.Errorf("%w: %w", ErrPermanentJobFailure, err)
+		}
+		return err
+	}
+	if putErr != nil {
+		return putErr
+	}
+	jc.Progress(80)
+
+	if err := db.Model(task).Update("target_file_id", targetFileID).Error; err != nil {
+		return err
+	}
+	jc.Progress(90)
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 初始化作业队列并注册各类文档处理作业
+func initJobQueue() {
+	jobQueue = NewJobQueue(db)
+	jobQueue.Register(JobKindTask, processTaskJob, withProcessingStats(finalizeDocumentTask), updateDocumentTaskProgress)
+	jobQueue.Register(JobKindConversion, convertDocumentJob, withProcessingStats(finalizeDocumentTask), updateDocumentTaskProgress)
+	jobQueue.Register(JobKindExtraction, extractContentJob, withProcessingStats(finalizeExtraction), nil)
+	jobQueue.Register(JobKindOCR, performOCRJob, withProcessingStats(finalizeOCRResult), nil)
+	jobQueue.RegisterReset(JobKindTask, resetDocumentTask)
+	jobQueue.RegisterReset(JobKindConversion, resetDocumentTask)
+	jobQueue.RegisterReset(JobKindExtraction, resetFailedRecord(&DocumentExtraction{}))
+	jobQueue.RegisterReset(JobKindOCR, resetFailedRecord(&OCRResult{}))
+	jobQueue.Start()
+}
+
+// 创建业务记录并入队，二者在同一事务中提交
+func createWithJob(record interface{}, kind, targetID string, userID uint, priority int) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		_, err := jobQueue.Enqueue(tx, kind, targetID, userID, priority)
+		return err
+	})
+	if err == nil {
+		jobQueue.Notify()
+	}
+	return err
+}
+
+// 请求中的优先级，缺省为普通优先级
+func jobPriority(priority *int) int {
+	if priority == nil {
+		return JobPriorityNormal
+	}
+	if *priority < JobPriorityLow {
+		return JobPriorityLow
+	}
+	if *priority > JobPriorityHigh {
+		return JobPriorityHigh
+	}
+	return *priority
+}
+
+// 可被取消的等待
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// 加载作业对应的处理任务并标记为处理中
+func startDocumentTask(jc *JobContext) (*DocumentTask, error) {
+	var task DocumentTask
+	if err := db.Where("id = ?", jc.Job.TargetID).First(&task).Error; err != nil {
+		return nil, fmt.Errorf("%w: task %s not found", ErrPermanentJobFailure, jc.Job.TargetID)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": ProcessingStatusProcessing, "error": ""}
+	if task.StartedAt == nil {
+		task.StartedAt = &now
+		updates["started_at"] = &now
+	}
+	if err := db.Model(&task).Where("status IN ?", []ProcessingStatus{ProcessingStatusPending, ProcessingStatusProcessing}).
+		Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	task.Status = ProcessingStatusProcessing
+	return &task, nil
+}
+
+// 通用处理任务
+func processTaskJob(jc *JobContext) error {
+	if _, err := startDocumentTask(jc); err != nil {
+		return err
+	}
+
+	// 按阶段推进并持久化进度，每个阶段都可被取消
+	for progress := 10; progress <= 100; progress += 10 {
+		if err := sleepContext(jc, 500*time.Millisecond); err != nil {
+			return err
+		}
+		jc.Progress(progress)
+	}
+	return nil
+}
+
+// 内容提取
+func extractContentJob(jc *JobContext) error {
+	var extraction DocumentExtraction
+	if err := db.Where("id = ?", jc.Job.TargetID).First(&extraction).Error; err != nil {
+		return fmt.Errorf("%w: extraction %s not found", ErrPermanentJobFailure, jc.Job.TargetID)
+	}
+	if err := db.Model(&extraction).Update("status", ProcessingStatusProcessing).Error; err != nil {
+		return err
+	}
+
+	if err := sleepContext(jc, 2*time.Second); err != nil {
+		return err
+	}
+
+	return db.Model(&extraction).Updates(map[string]interface{}{
+		"content":         "Extracted content from document...",
+		"structured_data": `{"name": "John Doe", "email": "john@example.com", "phone": "123-456-7890"}`,
+		"confidence":      0.95,
+	}).Error
+}
+
+// 处理任务进度
+func updateDocumentTaskProgress(job *DocumentJob, progress int) {
+	db.Model(&DocumentTask{}).Where("id = ? AND status = ?", job.TargetID, ProcessingStatusProcessing).
+		Update("progress", progress)
+}
+
+// 作业状态同步到处理任务
+func finalizeDocumentTask(job *DocumentJob, status JobStatus, err error) {
+	now := time.Now()
+	query := db.Model(&DocumentTask{}).Where("id = ? AND status IN ?", job.TargetID,
+		[]ProcessingStatus{ProcessingStatusPending, ProcessingStatusProcessing})
+
+	switch status {
+	case JobStatusSucceeded:
+		query.Updates(map[string]interface{}{
+			"status":       ProcessingStatusCompleted,
+			"progress":     100,
+			"error":        "",
+			"completed_at": &now,
+		})
+		logger.Infof("Task %s completed", job.TargetID)
+	case JobStatusQueued:
+		query.Updates(map[string]interface{}{
+			"status": ProcessingStatusPending,
+			"error":  fmt.Sprintf("attempt %d failed, retrying: %v", job.Attempts, err),
+		})
+	case JobStatusDead:
+		query.Updates(map[string]interface{}{
+			"status":       ProcessingStatusFailed,
+			"error":        err.Error(),
+			"completed_at": &now,
+		})
+	}
+}
+
+func finalizeExtraction(job *DocumentJob, status JobStatus, err error) {
+	query := db.Model(&DocumentExtraction{}).Where("id = ? AND status IN ?", job.TargetID,
+		[]ProcessingStatus{ProcessingStatusPending, ProcessingStatusProcessing})
+
+	switch status {
+	case JobStatusSucceeded:
+		query.Updates(map[string]interface{}{"status": ProcessingStatusCompleted, "error": ""})
+		logger.Infof("Content extraction %s completed", job.TargetID)
+	case JobStatusQueued:
+		query.Updates(map[string]interface{}{"status": ProcessingStatusPending, "error": err.Error()})
+	case JobStatusDead:
+		query.Updates(map[string]interface{}{"status": ProcessingStatusFailed, "error": err.Error()})
+	}
+}
+
+func finalizeOCRResult(job *DocumentJob, status JobStatus, err error) {
+	query := db.Model(&OCRResult{}).Where("id = ? AND status IN ?", job.TargetID,
+		[]ProcessingStatus{ProcessingStatusPending, ProcessingStatusProcessing})
+
+	switch status {
+	case JobStatusSucceeded:
+		query.Updates(map[string]interface{}{"status": ProcessingStatusCompleted, "error": ""})
+		logger.Infof("OCR processing %s completed", job.TargetID)
+	case JobStatusQueued:
+		query.Updates(map[string]interface{}{"status": ProcessingStatusPending, "error": err.Error()})
+	case JobStatusDead:
+		query.Updates(map[string]interface{}{"status": ProcessingStatusFailed, "error": err.Error()})
+	}
+}
+
+// 死信作业重新入队时恢复失败的处理任务，清除上次执行的进度和完成时间
+func resetDocumentTask(tx *gorm.DB, job *DocumentJob) error {
+	return tx.Model(&DocumentTask{}).Where("id = ? AND status = ?", job.TargetID, ProcessingStatusFailed).
+		Updates(map[string]interface{}{
+			"status":       ProcessingStatusPending,
+			"progress":     0,
+			"error":        "",
+			"completed_at": nil,
+		}).Error
+}
+
+// 死信作业重新入队时恢复失败的提取或OCR记录
+func resetFailedRecord(model interface{}) JobResetter {
+	return func(tx *gorm.DB, job *DocumentJob) error {
+		return tx.Model(model).Where("id = ? AND status = ?", job.TargetID, ProcessingStatusFailed).
+			Updates(map[string]interface{}{"status": ProcessingStatusPending, "error": ""}).Error
+	}
+}
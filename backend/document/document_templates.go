@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"resume-centre/document/converter"
+	"resume-centre/document/templating"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTemplateDataNotFound 引用的职位或简历不存在
+	ErrTemplateDataNotFound = errors.New("template data source not found")
+	// ErrTemplateDataForbidden 调用者无权使用引用的职位或简历
+	ErrTemplateDataForbidden = errors.New("template data source forbidden")
+)
+
+// 校验模板定义：变量声明可解析且模板内容可编译
+func validateTemplateDefinition(format DocumentFormat, content, variables string) error {
+	if _, err := templating.ParseSchema(variables); err != nil {
+		return err
+	}
+	if content == "" {
+		return nil
+	}
+	if _, err := templating.Parse("template", content, string(format)); err != nil {
+		return fmt.Errorf("invalid template content: %v", err)
+	}
+	return nil
+}
+
+// 使用职位、简历数据及请求变量渲染模板
+func renderTemplate(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var template DocumentTemplate
+	if err := db.Where("id = ? AND is_active = ?", c.Param("id"), true).First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		logger.Errorf("Failed to get template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get template"})
+		return
+	}
+
+	var req struct {
+		Variables map[string]interface{} `json:"variables"`
+		JobID     string                 `json:"job_id"`
+		ResumeID  string                 `json:"resume_id"`
+		Save      bool                   `json:"save"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schema, err := templating.ParseSchema(template.Variables)
+	if err != nil {
+		logger.Errorf("Template %s has invalid variables: %v", template.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tmpl, err := templating.Parse(template.ID, template.TemplateContent, string(template.Format))
+	if err != nil {
+		logger.Errorf("Template %s cannot be compiled: %v", template.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), viper.GetDuration("templates.render_timeout"))
+	defer cancel()
+
+	// 职位与简历数据作为job/resume变量，请求中的同名变量优先。
+	// 由职位和简历服务按调用者校验权限：未发布的职位只有企业成员可见，简历只能使用自己的
+	var jobID, resumeID uint64
+	if req.JobID != "" {
+		if jobID, err = strconv.ParseUint(req.JobID, 10, 64); err != nil || jobID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job_id"})
+			return
+		}
+	}
+	if req.ResumeID != "" {
+		if resumeID, err = strconv.ParseUint(req.ResumeID, 10, 64); err != nil || resumeID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume_id"})
+			return
+		}
+	}
+	data := map[string]interface{}{"job": nil, "resume": nil}
+	caller := templateCaller{userID: userID, authorization: c.GetHeader("Authorization")}
+	if jobID != 0 {
+		job, err := fetchTemplateData(ctx, serviceURL("enterprise-service", "templates.enterprise_service_url"),
+			"/enterprise/job/detail/"+url.PathEscape(strconv.FormatUint(jobID, 10)), caller)
+		if err != nil {
+			respondTemplateDataError(c, "job", err)
+			return
+		}
+		data["job"] = job
+	}
+	if resumeID != 0 {
+		resume, err := fetchTemplateData(ctx, serviceURL("resume-service", "templates.resume_service_url"),
+			"/resume/detail/"+url.PathEscape(strconv.FormatUint(resumeID, 10)), caller)
+		if err != nil {
+			respondTemplateDataError(c, "resume", err)
+			return
+		}
+		data["resume"] = resume
+	}
+	for k, v := range req.Variables {
+		data[k] = v
+	}
+
+	vars, err := schema.Validate(data)
+	if err != nil {
+		var verr *templating.ValidationError
+		if errors.As(err, &verr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid template variables", "fields": verr.Errors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rendered, err := tmpl.Render(vars)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	// docx/pdf模板内容为markdown，渲染后转换为目标格式
+	format := converter.NormalizeFormat(string(template.Format))
+	if format == "docx" || format == "pdf" {
+		var out bytes.Buffer
+		opts := converter.Options{"title": template.Name}
+		if err := converters.Convert(ctx, "md", format, bytes.NewReader(rendered), &out, opts); err != nil {
+			logger.Errorf("Failed to convert template %s to %s: %v", template.ID, format, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render template"})
+			return
+		}
+		rendered = out.Bytes()
+	}
+
+	contentType := formatContentTypes[format]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	fileName := template.Name + "." + format
+
+	if req.Save {
+		fileID, err := newStorageClient().Put(ctx, userID, fileName, contentType, bytes.NewReader(rendered))
+		if err != nil {
+			logger.Errorf("Failed to save rendered template %s: %v", template.ID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to save rendered document"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"file_id":      fileID,
+			"format":       format,
+			"content_type": contentType,
+			"size":         len(rendered),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	c.Data(http.StatusOK, contentType, rendered)
+}
+
+func respondTemplateDataError(c *gin.Context, source string, err error) {
+	if errors.Is(err, ErrTemplateDataNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s not found", source)})
+		return
+	}
+	if errors.Is(err, ErrTemplateDataForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("%s access denied", source)})
+		return
+	}
+	logger.Errorf("Failed to fetch %s data for template: %v", source, err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to fetch %s data", source)})
+}
+
+// 渲染模板的调用者，获取职位、简历数据时转发给对应服务校验权限
+type templateCaller struct {
+	userID        uint
+	authorization string
+}
+
+// 从其他服务获取详情数据，转发调用方的Authorization和用户ID
+func fetchTemplateData(ctx context.Context, baseURL, path string, caller templateCaller) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if caller.authorization != "" {
+		req.Header.Set("Authorization", caller.authorization)
+	}
+	req.Header.Set("X-User-ID", strconv.FormatUint(uint64(caller.userID), 10))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateDataNotFound, path)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateDataForbidden, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", path, resp.StatusCode)
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if result.Data == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateDataNotFound, path)
+	}
+	return result.Data, nil
+}
+
+// 内置模板：企业录用通知书与候选人求职信
+var builtinTemplates = []DocumentTemplate{
+	{
+		ID:           "builtin-offer-letter",
+		Name:         "录用通知书",
+		Description:  "根据职位信息生成录用通知书",
+		DocumentType: DocumentTypeOfferLetter,
+		Format:       DocumentFormatHTML,
+		Variables: `{
+  "candidate_name": {"type": "string", "required": true, "description": "候选人姓名"},
+  "company_name": {"type": "string", "required": true, "description": "公司名称"},
+  "position": {"type": "string", "description": "职位名称，默认使用职位信息"},
+  "salary": {"type": "number", "required": true, "description": "月薪"},
+  "currency": {"type": "string", "default": "CNY", "enum": ["CNY", "USD", "EUR", "HKD"]},
+  "start_date": {"type": "date", "required": true, "description": "入职日期"},
+  "probation_months": {"type": "integer", "default": 3},
+  "benefits": {"type": "array", "items": {"type": "string"}},
+  "reply_deadline": {"type": "date"}
+}`,
+		TemplateContent: `<h1>{{.company_name}} 录用通知书</h1>
+<p>尊敬的 {{.candidate_name}}：</p>
+<p>感谢您对{{.company_name}}的关注。我们很高兴地通知您，您已被录用为<strong>{{if .position}}{{.position}}{{else if .job}}{{.job.title}}{{end}}</strong>{{if .job}}{{if .job.department}}（{{.job.department}}）{{end}}{{end}}。</p>
+<ul>
+<li>入职日期：{{formatDate .start_date "zh"}}</li>
+<li>月薪：{{currency .salary .currency}}</li>
+{{- if .probation_months}}
+<li>试用期：{{.probation_months}}个月</li>
+{{- end}}
+{{- if .job}}{{if .job.location}}
+<li>工作地点：{{.job.location}}</li>
+{{- end}}{{end}}
+</ul>
+{{- if .benefits}}
+<p>福利待遇：</p>
+<ul>
+{{- range .benefits}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if not .reply_deadline.IsZero}}
+<p>请于{{formatDate .reply_deadline "zh"}}前回复确认。</p>
+{{- end}}
+<p>{{.company_name}}<br>{{formatDate now "zh"}}</p>
+`,
+		IsActive: true,
+	},
+	{
+		ID:           "builtin-cover-letter",
+		Name:         "求职信",
+		Description:  "根据简历与目标职位生成求职信",
+		DocumentType: DocumentTypeCoverLetter,
+		Format:       DocumentFormatMD,
+		Variables: `{
+  "candidate_name": {"type": "string", "description": "候选人姓名，默认使用简历信息"},
+  "company_name": {"type": "string", "required": true, "description": "目标公司"},
+  "position": {"type": "string", "description": "应聘职位，默认使用职位信息"},
+  "highlights": {"type": "array", "items": {"type": "string"}, "description": "个人亮点"},
+  "closing": {"type": "string", "default": "此致敬礼"}
+}`,
+		TemplateContent: `# 求职信
+
+{{.company_name}} 招聘负责人：
+
+您好！我希望应聘贵公司的**{{if .position}}{{.position}}{{else if .job}}{{.job.title}}{{end}}**一职。
+{{- if .resume}}
+我目前{{if .resume.title}}的职位是{{.resume.title}}{{else}}正在寻找新的机会{{end}}{{if .resume.experience}}，拥有{{.resume.experience}}工作经验{{end}}{{if .resume.skills}}，熟悉{{.resume.skills}}{{end}}。
+{{- end}}
+{{if .highlights}}
+我的主要优势：
+
+{{range .highlights}}- {{.}}
+{{end}}{{end}}
+期待有机会进一步沟通。
+
+{{.closing}}
+
+{{if .candidate_name}}{{.candidate_name}}{{else if .resume}}{{.resume.name}}{{end}}
+
+{{formatDate now "zh"}}
+`,
+		IsActive: true,
+	},
+}
+
+// 写入内置模板，已存在时不覆盖
+func seedBuiltinTemplates() error {
+	for i := range builtinTemplates {
+		t := builtinTemplates[i]
+		if err := validateTemplateDefinition(t.Format, t.TemplateContent, t.Variables); err != nil {
+			return fmt.Errorf("builtin template %s: %v", t.ID, err)
+		}
+		if err := db.Where("id = ?", t.ID).FirstOrCreate(&t).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
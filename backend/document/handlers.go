@@ -2,12 +2,20 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"resume-centre/common/ocr"
+	"resume-centre/document/converter"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
 )
 
 // 创建处理任务
@@ -25,6 +33,7 @@ func createTask(c *gin.Context) {
 		TargetFormat string `json:"target_format"`
 		SourceFileID string `json:"source_file_id" binding:"required"`
 		Metadata     string `json:"metadata"`
+		Priority     *int   `json:"priority"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -32,6 +41,14 @@ func createTask(c *gin.Context) {
 		return
 	}
 
+	// 转换任务在创建前校验格式，不支持的格式对直接拒绝
+	if req.TaskType == "conversion" {
+		if _, err := resolveConversion(DocumentFormat(req.SourceFormat), DocumentFormat(req.TargetFormat), ""); err != nil {
+			respondConversionError(c, req.SourceFormat, err)
+			return
+		}
+	}
+
 	// 创建任务
 	task := DocumentTask{
 		ID:           uuid.New().String(),
@@ -46,15 +63,13 @@ func createTask(c *gin.Context) {
 		Metadata:     req.Metadata,
 	}
 
-	if err := db.Create(&task).Error; err != nil {
+	// 持久化任务并入队，由工作线程池异步处理
+	if err := createWithJob(&task, taskJobKind(&task), task.ID, userID, jobPriority(req.Priority)); err != nil {
 		logger.Errorf("Failed to create task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
 		return
 	}
 
-	// 异步处理任务
-	go processTask(&task)
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Task created successfully",
 		"task":    task,
@@ -139,11 +154,27 @@ func cancelTask(c *gin.Context) {
 		return
 	}
 
+	// 先停止作业：排队中直接取消，执行中中断处理
+	if err := jobQueue.Cancel(taskJobKind(&task), task.ID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, ErrJobNotCancellable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot cancel completed or failed task"})
+			return
+		}
+		logger.Errorf("Failed to cancel job of task %s: %v", task.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel task"})
+		return
+	}
+
+	completedAt := time.Now()
 	task.Status = ProcessingStatusFailed
 	task.Error = "Task cancelled by user"
-	task.CompletedAt = &time.Time{}
+	task.CompletedAt = &completedAt
 
-	if err := db.Save(&task).Error; err != nil {
+	if err := db.Model(&task).Updates(map[string]interface{}{
+		"status":       task.Status,
+		"error":        task.Error,
+		"completed_at": task.CompletedAt,
+	}).Error; err != nil {
 		logger.Errorf("Failed to cancel task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel task"})
 		return
@@ -168,6 +199,7 @@ func convertDocument(c *gin.Context) {
 		SourceFormat string `json:"source_format" binding:"required"`
 		TargetFormat string `json:"target_format" binding:"required"`
 		ConfigID     string `json:"config_id"`
+		Priority     *int   `json:"priority"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -175,6 +207,12 @@ func convertDocument(c *gin.Context) {
 		return
 	}
 
+	config, err := resolveConversion(DocumentFormat(req.SourceFormat), DocumentFormat(req.TargetFormat), req.ConfigID)
+	if err != nil {
+		respondConversionError(c, req.SourceFormat, err)
+		return
+	}
+
 	// 创建转换任务
 	task := DocumentTask{
 		ID:           uuid.New().String(),
@@ -187,39 +225,58 @@ func convertDocument(c *gin.Context) {
 		Status:       ProcessingStatusPending,
 		Progress:     0,
 	}
+	if config != nil {
+		task.ConfigID = config.ID
+	}
 
-	if err := db.Create(&task).Error; err != nil {
+	if err := createWithJob(&task, JobKindConversion, task.ID, userID, jobPriority(req.Priority)); err != nil {
 		logger.Errorf("Failed to create conversion task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversion task"})
 		return
 	}
 
-	// 异步执行转换
-	go convertDocumentAsync(&task)
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Document conversion started",
 		"task":    task,
 	})
 }
 
-// 获取支持的格式
+// 获取支持的格式，由转换器注册表的格式图推导
 func getSupportedFormats(c *gin.Context) {
-	formats := gin.H{
-		"input": []string{"pdf", "doc", "docx", "txt", "rtf", "html", "md"},
-		"output": []string{"pdf", "docx", "txt", "html", "md"},
-		"conversions": map[string][]string{
-			"pdf":  {"docx", "txt", "html"},
-			"doc":  {"pdf", "docx", "txt"},
-			"docx": {"pdf", "txt", "html", "md"},
-			"txt":  {"pdf", "docx", "html"},
-			"rtf":  {"pdf", "docx", "txt"},
-			"html": {"pdf", "docx", "txt", "md"},
-			"md":   {"pdf", "docx", "html"},
-		},
-	}
-
-	c.JSON(http.StatusOK, formats)
+	conversions := converters.Conversions()
+	input := make([]string, 0, len(conversions))
+	outputSet := map[string]bool{}
+	for source, targets := range conversions {
+		input = append(input, source)
+		for _, target := range targets {
+			outputSet[target] = true
+		}
+	}
+	output := make([]string, 0, len(outputSet))
+	for target := range outputSet {
+		output = append(output, target)
+	}
+	sort.Strings(input)
+	sort.Strings(output)
+
+	c.JSON(http.StatusOK, gin.H{
+		"input":       input,
+		"output":      output,
+		"conversions": conversions,
+	})
+}
+
+// 转换格式或配置无效时返回400，并附带该源格式支持的目标格式
+func respondConversionError(c *gin.Context, sourceFormat string, err error) {
+	if isConversionRejected(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     err.Error(),
+			"supported": converters.Conversions()[converter.NormalizeFormat(sourceFormat)],
+		})
+		return
+	}
+	logger.Errorf("Failed to resolve conversion: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve conversion"})
 }
 
 // 获取转换配置
@@ -245,6 +302,7 @@ func extractContent(c *gin.Context) {
 	var req struct {
 		DocumentID     string `json:"document_id" binding:"required"`
 		ExtractionType string `json:"extraction_type" binding:"required"`
+		Priority       *int   `json:"priority"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -261,15 +319,12 @@ func extractContent(c *gin.Context) {
 		Status:         ProcessingStatusPending,
 	}
 
-	if err := db.Create(&extraction).Error; err != nil {
+	if err := createWithJob(&extraction, JobKindExtraction, extraction.ID, userID, jobPriority(req.Priority)); err != nil {
 		logger.Errorf("Failed to create extraction: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create extraction"})
 		return
 	}
 
-	// 异步执行提取
-	go extractContentAsync(&extraction)
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message":    "Content extraction started",
 		"extraction": extraction,
@@ -342,9 +397,11 @@ func performOCR(c *gin.Context) {
 	}
 
 	var req struct {
-		DocumentID  string `json:"document_id" binding:"required"`
-		ImageFileID string `json:"image_file_id" binding:"required"`
-		Language    string `json:"language"`
+		DocumentID      string `json:"document_id" binding:"required"`
+		ImageFileID     string `json:"image_file_id" binding:"required"`
+		Language        string `json:"language"`
+		RecognitionType string `json:"recognition_type"`
+		Priority        *int   `json:"priority"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -352,8 +409,27 @@ func performOCR(c *gin.Context) {
 		return
 	}
 
+	if ocrProvider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OCR provider unavailable"})
+		return
+	}
+
 	if req.Language == "" {
-		req.Language = "en"
+		req.Language = viper.GetString("processing.ocr.default_language")
+	}
+	languages := ocr.ParseLanguages(req.Language)
+	if err := ocr.CheckLanguages(ocrProvider, languages); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "supported": ocrProvider.Languages()})
+		return
+	}
+	req.Language = strings.Join(languages, "+")
+
+	if req.RecognitionType == "" {
+		req.RecognitionType = OCRTypeGeneral
+	}
+	if !ocrTypes[req.RecognitionType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported recognition type: " + req.RecognitionType})
+		return
 	}
 
 	// 创建OCR任务
@@ -363,18 +439,16 @@ func performOCR(c *gin.Context) {
 		DocumentID: req.DocumentID,
 		ImageFileID: req.ImageFileID,
 		Language:   req.Language,
+		RecognitionType: req.RecognitionType,
 		Status:     ProcessingStatusPending,
 	}
 
-	if err := db.Create(&ocrResult).Error; err != nil {
+	if err := createWithJob(&ocrResult, JobKindOCR, ocrResult.ID, userID, jobPriority(req.Priority)); err != nil {
 		logger.Errorf("Failed to create OCR task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create OCR task"})
 		return
 	}
 
-	// 异步执行OCR
-	go performOCRAsync(&ocrResult)
-
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "OCR processing started",
 		"ocr":     ocrResult,
@@ -401,13 +475,27 @@ func getOCRResult(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"ocr": ocrResult})
+	resp := gin.H{"ocr": ocrResult}
+	if ocrResult.Pages != "" {
+		resp["pages"] = json.RawMessage(ocrResult.Pages)
+	}
+	if ocrResult.Fields != "" {
+		resp["fields"] = json.RawMessage(ocrResult.Fields)
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// 获取支持的语言
+// 获取支持的语言，由当前识别引擎决定
 func getSupportedLanguages(c *gin.Context) {
-	languages := []string{"en", "zh", "ja", "ko", "fr", "de", "es", "it", "pt", "ru"}
-	c.JSON(http.StatusOK, gin.H{"languages": languages})
+	if ocrProvider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OCR provider unavailable", "languages": []string{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"provider":          ocrProvider.Name(),
+		"languages":         ocrProvider.Languages(),
+		"recognition_types": []string{OCRTypeGeneral, OCRTypeIDCard, OCRTypeBusinessLicense},
+	})
 }
 
 // 创建模板
@@ -433,6 +521,11 @@ func createTemplate(c *gin.Context) {
 		return
 	}
 
+	if err := validateTemplateDefinition(DocumentFormat(req.Format), req.TemplateContent, req.Variables); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	template := DocumentTemplate{
 		ID:             uuid.New().String(),
 		Name:           req.Name,
@@ -536,6 +629,11 @@ func updateTemplate(c *gin.Context) {
 		template.IsActive = *req.IsActive
 	}
 
+	if err := validateTemplateDefinition(template.Format, template.TemplateContent, template.Variables); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := db.Save(&template).Error; err != nil {
 		logger.Errorf("Failed to update template: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
@@ -612,91 +710,113 @@ func getStatsSummary(c *gin.Context) {
 		AvgProcessingTime float64 `json:"avg_processing_time"`
 	}
 
-	// 获取任务统计
-	db.Model(&DocumentTask{}).Where("user_id = ?", userID).Count(&summary.TotalTasks)
-	db.Model(&DocumentTask{}).Where("user_id = ? AND status = ?", userID, ProcessingStatusCompleted).Count(&summary.CompletedTasks)
-	db.Model(&DocumentTask{}).Where("user_id = ? AND status = ?", userID, ProcessingStatusFailed).Count(&summary.FailedTasks)
-	db.Model(&DocumentTask{}).Where("user_id = ? AND status = ?", userID, ProcessingStatusProcessing).Count(&summary.ProcessingTasks)
+	// 单次查询按状态汇总任务数
+	if err := db.Model(&DocumentTask{}).Where("user_id = ?", userID).Select(
+		"COUNT(*) AS total_tasks, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS completed_tasks, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS failed_tasks, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS processing_tasks",
+		ProcessingStatusCompleted, ProcessingStatusFailed, ProcessingStatusProcessing,
+	).Scan(&summary).Error; err != nil {
+		logger.Errorf("Failed to get stats summary: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stats summary"})
+		return
+	}
+
+	// 平均耗时来自按天汇总的处理统计
+	var totals struct {
+		TotalTime int64
+		Count     int64
+	}
+	if err := db.Model(&ProcessingStats{}).Where("user_id = ?", userID).
+		Select("COALESCE(SUM(total_processing_time), 0) AS total_time, COALESCE(SUM(success_count + failure_count), 0) AS count").
+		Scan(&totals).Error; err != nil {
+		logger.Errorf("Failed to get stats summary: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stats summary"})
+		return
+	}
+	if totals.Count > 0 {
+		summary.AvgProcessingTime = float64(totals.TotalTime) / float64(totals.Count)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"summary": summary})
 }
 
-// 异步处理任务
-func processTask(task *DocumentTask) {
-	startTime := time.Now()
-	task.Status = ProcessingStatusProcessing
-	task.StartedAt = &startTime
-	db.Save(task)
-
-	// 模拟处理过程
-	time.Sleep(2 * time.Second)
-	
-	// 更新进度
-	for i := 10; i <= 100; i += 10 {
-		task.Progress = i
-		db.Save(task)
-		time.Sleep(500 * time.Millisecond)
+// 处理任务对应的作业类型
+func taskJobKind(task *DocumentTask) string {
+	if task.TaskType == "conversion" {
+		return JobKindConversion
 	}
-
-	// 完成任务
-	completedTime := time.Now()
-	task.Status = ProcessingStatusCompleted
-	task.CompletedAt = &completedTime
-	db.Save(task)
-
-	logger.Infof("Task %s completed", task.ID)
+	return JobKindTask
 }
 
-// 异步文档转换
-func convertDocumentAsync(task *DocumentTask) {
-	startTime := time.Now()
-	task.Status = ProcessingStatusProcessing
-	task.StartedAt = &startTime
-	db.Save(task)
+// 获取作业列表（status=dead查看死信）
+func listJobs(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-	// 模拟转换过程
-	time.Sleep(3 * time.Second)
-	
-	// 生成目标文件ID
-	task.TargetFileID = uuid.New().String()
-	
-	// 完成转换
-	completedTime := time.Now()
-	task.Status = ProcessingStatusCompleted
-	task.CompletedAt = &completedTime
-	db.Save(task)
+	query := db.Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
 
-	logger.Infof("Document conversion %s completed", task.ID)
+	var jobs []DocumentJob
+	if err := query.Order("created_at DESC").Limit(50).Find(&jobs).Error; err != nil {
+		logger.Errorf("Failed to list jobs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
 }
 
-// 异步内容提取
-func extractContentAsync(extraction *DocumentExtraction) {
-	// 模拟提取过程
-	time.Sleep(2 * time.Second)
-	
-	extraction.Content = "Extracted content from document..."
-	extraction.StructuredData = `{"name": "John Doe", "email": "john@example.com", "phone": "123-456-7890"}`
-	extraction.Confidence = 0.95
-	extraction.Status = ProcessingStatusCompleted
-	db.Save(extraction)
+// 获取单个作业
+func getJob(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var job DocumentJob
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
 
-	logger.Infof("Content extraction %s completed", extraction.ID)
+	c.JSON(http.StatusOK, gin.H{"job": job})
 }
 
-// 异步OCR识别
-func performOCRAsync(ocrResult *OCRResult) {
-	startTime := time.Now()
-	ocrResult.Status = ProcessingStatusProcessing
-	db.Save(ocrResult)
+// 死信作业重新入队
+func requeueJob(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-	// 模拟OCR过程
-	time.Sleep(5 * time.Second)
-	
-	ocrResult.TextContent = "OCR recognized text content..."
-	ocrResult.Confidence = 0.88
-	ocrResult.Status = ProcessingStatusCompleted
-	ocrResult.ProcessingTime = time.Since(startTime).Milliseconds()
-	db.Save(ocrResult)
+	job, err := jobQueue.Requeue(c.Param("id"), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		case errors.Is(err, ErrJobNotDead):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logger.Errorf("Failed to requeue job: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to requeue job"})
+		}
+		return
+	}
 
-	logger.Infof("OCR processing %s completed", ocrResult.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Job requeued",
+		"job":     job,
+	})
 }
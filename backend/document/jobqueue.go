@@ -0,0 +1,662 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"resume-centre/common/thread"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 作业状态
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"    // 等待执行（含等待重试）
+	JobStatusRunning   JobStatus = "running"   // 已被工作线程租用
+	JobStatusSucceeded JobStatus = "succeeded" // 执行成功
+	JobStatusDead      JobStatus = "dead"      // 重试耗尽或不可重试，进入死信
+	JobStatusCancelled JobStatus = "cancelled" // 用户取消
+)
+
+// 作业类型，对应处理的业务记录
+const (
+	JobKindTask       = "task"       // DocumentTask
+	JobKindConversion = "conversion" // DocumentTask（转换）
+	JobKindExtraction = "extraction" // DocumentExtraction
+	JobKindOCR        = "ocr"        // OCRResult
+)
+
+// 作业优先级，数值越大越先执行
+const (
+	JobPriorityLow    = 0
+	JobPriorityNormal = 5
+	JobPriorityHigh   = 10
+)
+
+var (
+	// 处理函数返回该错误（或包装该错误）时不再重试，直接进入死信
+	ErrPermanentJobFailure = errors.New("permanent job failure")
+	ErrJobNotCancellable   = errors.New("job already finished")
+	ErrJobNotDead          = errors.New("job is not dead-lettered")
+	ErrJobLeaseExpired     = errors.New("lease expired")
+	ErrJobPanic            = errors.New("handler panic")
+)
+
+// 持久化的文档处理作业
+type DocumentJob struct {
+	ID              string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Kind            string     `json:"kind" gorm:"type:varchar(30);not null;index:idx_job_target"`
+	TargetID        string     `json:"target_id" gorm:"type:varchar(36);not null;index:idx_job_target"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	Status          JobStatus  `json:"status" gorm:"type:varchar(20);not null;index:idx_job_claim"`
+	Priority        int        `json:"priority" gorm:"not null;default:5;index:idx_job_claim"`
+	RunAt           time.Time  `json:"run_at" gorm:"not null;index:idx_job_claim"`
+	Attempts        int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts     int        `json:"max_attempts" gorm:"not null;default:5"`
+	Progress        int        `json:"progress" gorm:"default:0"`
+	LeaseOwner      string     `json:"lease_owner" gorm:"type:varchar(64)"`
+	LeaseExpiresAt  *time.Time `json:"lease_expires_at" gorm:"index"`
+	HeartbeatAt     *time.Time `json:"heartbeat_at"`
+	ClaimedAt       *time.Time `json:"claimed_at"` // 本次尝试的领取时间
+	CancelRequested bool       `json:"cancel_requested" gorm:"default:false"`
+	LastError       string     `json:"last_error" gorm:"type:text"`
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// 作业执行上下文
+type JobContext struct {
+	context.Context
+	Job   *DocumentJob
+	queue *JobQueue
+}
+
+// 持久化进度，处理函数按阶段调用
+func (jc *JobContext) Progress(progress int) {
+	if progress < 0 {
+		progress = 0
+	} else if progress > 100 {
+		progress = 100
+	}
+	jc.Job.Progress = progress
+	jc.queue.db.Model(&DocumentJob{}).Where("id = ? AND lease_owner = ?", jc.Job.ID, jc.queue.owner).
+		Update("progress", progress)
+	if hook, ok := jc.queue.progressHooks[jc.Job.Kind]; ok {
+		hook(jc.Job, progress)
+	}
+}
+
+// 作业处理函数
+type JobHandler func(jc *JobContext) error
+
+// 作业状态回调：成功、等待重试或进入死信时更新业务记录
+type JobFinalizer func(job *DocumentJob, status JobStatus, err error)
+
+// 死信作业重新入队时，在同一事务中将失败的业务记录恢复为待处理
+type JobResetter func(tx *gorm.DB, job *DocumentJob) error
+
+// 基于MySQL的持久化作业队列：租约+心跳保证作业不丢失，失败按指数退避重试，
+// 重试耗尽进入死信；工作线程使用线程池，作业上下文可被取消
+type JobQueue struct {
+	db            *gorm.DB
+	owner         string
+	workers       int
+	pollInterval  time.Duration
+	leaseDuration time.Duration
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+
+	handlers      map[string]JobHandler
+	finalizers    map[string]JobFinalizer
+	resetters     map[string]JobResetter
+	progressHooks map[string]func(job *DocumentJob, progress int)
+
+	pool     *thread.ThreadPool
+	inFlight int64
+	running  sync.Map // 作业ID -> *runningJob
+	wake     chan struct{}
+
+	baseCtx    context.Context
+	cancelBase context.CancelFunc
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+var jobQueue *JobQueue
+
+func NewJobQueue(db *gorm.DB) *JobQueue {
+	host, _ := os.Hostname()
+	q := &JobQueue{
+		db:            db,
+		owner:         fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8]),
+		workers:       viper.GetInt("processing.queue.workers"),
+		pollInterval:  viper.GetDuration("processing.queue.poll_interval"),
+		leaseDuration: viper.GetDuration("processing.queue.lease_duration"),
+		backoffBase:   viper.GetDuration("processing.queue.backoff_base"),
+		backoffMax:    viper.GetDuration("processing.queue.backoff_max"),
+		handlers:      map[string]JobHandler{},
+		finalizers:    map[string]JobFinalizer{},
+		resetters:     map[string]JobResetter{},
+		progressHooks: map[string]func(job *DocumentJob, progress int){},
+		wake:          make(chan struct{}, 1),
+	}
+	if q.workers <= 0 {
+		q.workers = 4
+	}
+	if q.pollInterval <= 0 {
+		q.pollInterval = time.Second
+	}
+	if q.leaseDuration <= 0 {
+		q.leaseDuration = time.Minute
+	}
+	if q.backoffBase <= 0 {
+		q.backoffBase = 5 * time.Second
+	}
+	if q.backoffMax <= 0 {
+		q.backoffMax = 30 * time.Minute
+	}
+	return q
+}
+
+// 注册作业类型的处理函数、终态回调和进度回调
+func (q *JobQueue) Register(kind string, handler JobHandler, finalizer JobFinalizer, progressHook func(*DocumentJob, int)) {
+	q.handlers[kind] = handler
+	if finalizer != nil {
+		q.finalizers[kind] = finalizer
+	}
+	if progressHook != nil {
+		q.progressHooks[kind] = progressHook
+	}
+}
+
+// 注册作业类型在死信重新入队时的业务记录恢复函数
+func (q *JobQueue) RegisterReset(kind string, reset JobResetter) {
+	q.resetters[kind] = reset
+}
+
+// 在事务中入队，与业务记录同时提交
+func (q *JobQueue) Enqueue(tx *gorm.DB, kind, targetID string, userID uint, priority int) (*DocumentJob, error) {
+	maxAttempts := viper.GetInt("processing.queue.max_attempts")
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	job := &DocumentJob{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		TargetID:    targetID,
+		UserID:      userID,
+		Status:      JobStatusQueued,
+		Priority:    priority,
+		RunAt:       time.Now(),
+		MaxAttempts: maxAttempts,
+	}
+	if err := tx.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// 提醒调度器有新作业，避免等待下一个轮询周期
+func (q *JobQueue) Notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *JobQueue) Start() {
+	q.baseCtx, q.cancelBase = context.WithCancel(context.Background())
+	q.pool = thread.NewThreadPool(q.workers, q.workers)
+	q.stop = make(chan struct{})
+	q.done = make(chan struct{})
+	go q.dispatch()
+	logger.Infof("Document job queue started with %d workers (owner %s)", q.workers, q.owner)
+}
+
+// 停止领取新作业，等待运行中的作业完成；超时后中断剩余作业并释放回队列
+func (q *JobQueue) Stop(ctx context.Context) {
+	close(q.stop)
+	<-q.done
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+wait:
+	for atomic.LoadInt64(&q.inFlight) > 0 {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-ticker.C:
+		}
+	}
+	q.cancelBase()
+	q.pool.Shutdown()
+}
+
+func (q *JobQueue) dispatch() {
+	defer close(q.done)
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.reclaimExpiredLeases()
+		q.claimAndSubmit()
+
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// 按空闲线程数领取作业
+func (q *JobQueue) claimAndSubmit() {
+	free := q.workers - int(atomic.LoadInt64(&q.inFlight))
+	if free <= 0 {
+		return
+	}
+	jobs, err := q.claim(free)
+	if err != nil {
+		logger.Errorf("Failed to claim document jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		atomic.AddInt64(&q.inFlight, 1)
+		if err := q.pool.Submit(&jobTask{queue: q, job: job}); err != nil {
+			atomic.AddInt64(&q.inFlight, -1)
+			q.release(job)
+		}
+	}
+}
+
+// 领取可执行作业：SKIP LOCKED避免多个实例争抢同一作业
+func (q *JobQueue) claim(limit int) ([]*DocumentJob, error) {
+	var jobs []*DocumentJob
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", JobStatusQueued, now).
+			Order("priority DESC, run_at ASC").Limit(limit).Find(&jobs).Error; err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if err := tx.Model(job).Updates(claimJob(job, q.owner, now, q.leaseDuration)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// 领取作业：计入一次尝试并租给owner，返回需持久化的字段
+func claimJob(job *DocumentJob, owner string, now time.Time, leaseDuration time.Duration) map[string]interface{} {
+	lease := now.Add(leaseDuration)
+	job.Status = JobStatusRunning
+	job.Attempts++
+	job.LeaseOwner = owner
+	job.LeaseExpiresAt = &lease
+	job.HeartbeatAt = &now
+	job.ClaimedAt = &now
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	return map[string]interface{}{
+		"status":           job.Status,
+		"attempts":         job.Attempts,
+		"lease_owner":      job.LeaseOwner,
+		"lease_expires_at": job.LeaseExpiresAt,
+		"heartbeat_at":     job.HeartbeatAt,
+		"claimed_at":       job.ClaimedAt,
+		"started_at":       job.StartedAt,
+	}
+}
+
+// 租约过期（实例崩溃或失联）的作业重新入队或进入死信
+func (q *JobQueue) reclaimExpiredLeases() {
+	var expired []DocumentJob
+	if err := q.db.Where("status = ? AND lease_expires_at < ?", JobStatusRunning, time.Now()).
+		Limit(100).Find(&expired).Error; err != nil {
+		logger.Errorf("Failed to load expired job leases: %v", err)
+		return
+	}
+	for i := range expired {
+		job := &expired[i]
+		logger.Warnf("Document job %s lease held by %s expired", job.ID, job.LeaseOwner)
+		q.fail(job, job.LeaseOwner, ErrJobLeaseExpired)
+	}
+}
+
+// 释放未能执行的作业，不计入重试次数
+func (q *JobQueue) release(job *DocumentJob) {
+	q.db.Model(&DocumentJob{}).Where("id = ? AND lease_owner = ?", job.ID, q.owner).
+		Updates(map[string]interface{}{
+			"status":           JobStatusQueued,
+			"attempts":         gorm.Expr("attempts - 1"),
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+		})
+}
+
+// 退避时间：base * 2^(attempts-1)，加入随机抖动
+func (q *JobQueue) backoff(attempts int) time.Duration {
+	delay := q.backoffBase
+	for i := 1; i < attempts && delay < q.backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > q.backoffMax {
+		delay = q.backoffMax
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// 失败后的去向：已请求取消则结束，不可重试或重试耗尽进入死信，否则延迟delay后重新入队
+func failJob(job *DocumentJob, cause error, now time.Time, delay time.Duration) (JobStatus, map[string]interface{}) {
+	updates := map[string]interface{}{
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+		"last_error":       cause.Error(),
+	}
+	status := JobStatusQueued
+	switch {
+	case job.CancelRequested:
+		// 取消请求后持有者失联，直接结束
+		status = JobStatusCancelled
+		updates["finished_at"] = &now
+	case errors.Is(cause, ErrPermanentJobFailure) || job.Attempts >= job.MaxAttempts:
+		status = JobStatusDead
+		updates["finished_at"] = &now
+	default:
+		updates["run_at"] = now.Add(delay)
+	}
+	updates["status"] = status
+	return status, updates
+}
+
+// 执行失败：可重试时按退避重新入队，否则进入死信
+func (q *JobQueue) fail(job *DocumentJob, owner string, cause error) {
+	status, updates := failJob(job, cause, time.Now(), q.backoff(job.Attempts))
+
+	result := q.db.Model(&DocumentJob{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", job.ID, JobStatusRunning, owner).Updates(updates)
+	if result.Error != nil {
+		logger.Errorf("Failed to record failure of document job %s: %v", job.ID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+	switch status {
+	case JobStatusDead:
+		logger.Errorf("Document job %s (%s %s) dead-lettered after %d attempts: %v", job.ID, job.Kind, job.TargetID, job.Attempts, cause)
+		q.finalize(job, JobStatusDead, cause)
+	case JobStatusQueued:
+		logger.Warnf("Document job %s attempt %d failed, retrying: %v", job.ID, job.Attempts, cause)
+		q.finalize(job, JobStatusQueued, cause)
+	}
+}
+
+func (q *JobQueue) finalize(job *DocumentJob, status JobStatus, err error) {
+	if finalizer, ok := q.finalizers[job.Kind]; ok {
+		finalizer(job, status, err)
+	}
+}
+
+// 运行中的作业
+type runningJob struct {
+	cancel    context.CancelFunc
+	cancelled int32 // 用户取消
+	lost      int32 // 租约被收回
+}
+
+// 执行作业：心跳续租并监听取消请求
+func (q *JobQueue) execute(job *DocumentJob) {
+	defer atomic.AddInt64(&q.inFlight, -1)
+
+	ctx, cancel := context.WithCancel(q.baseCtx)
+	defer cancel()
+	run := &runningJob{cancel: cancel}
+	q.running.Store(job.ID, run)
+	defer q.running.Delete(job.ID)
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(q.leaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				switch q.heartbeat(job) {
+				case heartbeatCancelled:
+					atomic.StoreInt32(&run.cancelled, 1)
+					cancel()
+					return
+				case heartbeatLost:
+					atomic.StoreInt32(&run.lost, 1)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	handler, ok := q.handlers[job.Kind]
+	var err error
+	if !ok {
+		err = fmt.Errorf("%w: no handler for job kind %q", ErrPermanentJobFailure, job.Kind)
+	} else {
+		err = runHandler(handler, &JobContext{Context: ctx, Job: job, queue: q})
+	}
+	cancel()
+	<-heartbeatDone
+
+	switch {
+	case atomic.LoadInt32(&run.lost) == 1:
+		// 租约已被其他实例收回，由新的持有者处理
+		logger.Warnf("Document job %s lost its lease, result discarded", job.ID)
+	case atomic.LoadInt32(&run.cancelled) == 1:
+		q.finishCancelled(job)
+	case err == nil:
+		q.succeed(job)
+	case q.baseCtx.Err() != nil:
+		// 服务关闭中断，释放回队列
+		q.release(job)
+	default:
+		q.fail(job, q.owner, err)
+	}
+}
+
+// 处理函数panic视为失败
+func runHandler(handler JobHandler, jc *JobContext) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrJobPanic, r)
+		}
+	}()
+	return handler(jc)
+}
+
+// 心跳结果
+const (
+	heartbeatOK = iota
+	heartbeatCancelled
+	heartbeatLost
+)
+
+// 续租，同时检查是否被请求取消
+func (q *JobQueue) heartbeat(job *DocumentJob) int {
+	now := time.Now()
+	lease := now.Add(q.leaseDuration)
+	result := q.db.Model(&DocumentJob{}).
+		Where("id = ? AND status = ? AND lease_owner = ? AND cancel_requested = ?", job.ID, JobStatusRunning, q.owner, false).
+		Updates(map[string]interface{}{"lease_expires_at": &lease, "heartbeat_at": &now})
+	if result.Error != nil {
+		// 数据库暂时不可用时继续执行，租约到期前仍可续租
+		logger.Errorf("Failed to heartbeat document job %s: %v", job.ID, result.Error)
+		return heartbeatOK
+	}
+	if result.RowsAffected == 1 {
+		return heartbeatOK
+	}
+
+	var current DocumentJob
+	if err := q.db.Select("status", "lease_owner", "cancel_requested").Where("id = ?", job.ID).First(&current).Error; err != nil {
+		return heartbeatLost
+	}
+	return heartbeatRejected(&current, q.owner)
+}
+
+// 续租未生效时的原因：本实例仍持有租约说明被请求取消，否则租约已被收回
+func heartbeatRejected(current *DocumentJob, owner string) int {
+	if current.Status == JobStatusRunning && current.CancelRequested && current.LeaseOwner == owner {
+		return heartbeatCancelled
+	}
+	return heartbeatLost
+}
+
+func (q *JobQueue) succeed(job *DocumentJob) {
+	now := time.Now()
+	result := q.db.Model(&DocumentJob{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", job.ID, JobStatusRunning, q.owner).
+		Updates(map[string]interface{}{
+			"status":           JobStatusSucceeded,
+			"progress":         100,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"last_error":       "",
+			"finished_at":      &now,
+		})
+	if result.Error != nil {
+		logger.Errorf("Failed to complete document job %s: %v", job.ID, result.Error)
+		return
+	}
+	if result.RowsAffected == 1 {
+		q.finalize(job, JobStatusSucceeded, nil)
+	}
+}
+
+// 被取消的运行中作业在处理函数退出后标记为已取消
+func (q *JobQueue) finishCancelled(job *DocumentJob) {
+	now := time.Now()
+	result := q.db.Model(&DocumentJob{}).
+		Where("id = ? AND status = ? AND lease_owner = ? AND cancel_requested = ?", job.ID, JobStatusRunning, q.owner, true).
+		Updates(map[string]interface{}{
+			"status":           JobStatusCancelled,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"finished_at":      &now,
+		})
+	if result.Error == nil && result.RowsAffected == 1 {
+		logger.Infof("Document job %s cancelled while running", job.ID)
+	}
+}
+
+// 取消业务记录对应的作业：排队中直接取消，运行中请求取消并中断本实例上的执行
+func (q *JobQueue) Cancel(kind, targetID string) error {
+	var job DocumentJob
+	if err := q.db.Where("kind = ? AND target_id = ?", kind, targetID).
+		Order("created_at DESC").First(&job).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	switch job.Status {
+	case JobStatusQueued:
+		result := q.db.Model(&job).Where("status = ?", JobStatusQueued).
+			Updates(map[string]interface{}{"status": JobStatusCancelled, "cancel_requested": true, "finished_at": &now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 刚被领取，按运行中处理
+			return q.Cancel(kind, targetID)
+		}
+	case JobStatusRunning:
+		if err := q.db.Model(&job).Update("cancel_requested", true).Error; err != nil {
+			return err
+		}
+		// 本实例执行中则立即中断，其他实例在下一次心跳时发现取消请求
+		if value, ok := q.running.Load(job.ID); ok {
+			run := value.(*runningJob)
+			atomic.StoreInt32(&run.cancelled, 1)
+			run.cancel()
+		}
+	default:
+		return ErrJobNotCancellable
+	}
+	return nil
+}
+
+// 死信作业重新入队，同一事务中将业务记录恢复为待处理，否则处理函数会因记录已失败而跳过
+func (q *JobQueue) Requeue(jobID string, userID uint) (*DocumentJob, error) {
+	var job DocumentJob
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error; err != nil {
+			return err
+		}
+		if job.Status != JobStatusDead {
+			return ErrJobNotDead
+		}
+		if err := tx.Model(&job).Updates(resetDeadJob(&job, time.Now())).Error; err != nil {
+			return err
+		}
+		if reset, ok := q.resetters[job.Kind]; ok {
+			return reset(tx, &job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	q.Notify()
+	return &job, nil
+}
+
+// 死信作业恢复为排队状态，重新计算尝试次数
+func resetDeadJob(job *DocumentJob, now time.Time) map[string]interface{} {
+	job.Status = JobStatusQueued
+	job.Attempts = 0
+	job.Progress = 0
+	job.RunAt = now
+	job.FinishedAt = nil
+	return map[string]interface{}{
+		"status":      job.Status,
+		"attempts":    job.Attempts,
+		"progress":    job.Progress,
+		"run_at":      job.RunAt,
+		"finished_at": nil,
+	}
+}
+
+// 线程池任务
+type jobTask struct {
+	queue *JobQueue
+	job   *DocumentJob
+}
+
+func (t *jobTask) Execute() error {
+	t.queue.execute(t.job)
+	return nil
+}
+
+func (t *jobTask) GetID() string {
+	return t.job.ID
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestClaimJob(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	job := &DocumentJob{ID: "j1", Status: JobStatusQueued, MaxAttempts: 3}
+
+	updates := claimJob(job, "worker-a", now, time.Minute)
+	if job.Status != JobStatusRunning || job.Attempts != 1 || job.LeaseOwner != "worker-a" {
+		t.Fatalf("unexpected claimed job %+v", job)
+	}
+	if !job.LeaseExpiresAt.Equal(now.Add(time.Minute)) || !job.HeartbeatAt.Equal(now) || !job.StartedAt.Equal(now) {
+		t.Fatalf("unexpected lease %v heartbeat %v started %v", job.LeaseExpiresAt, job.HeartbeatAt, job.StartedAt)
+	}
+	if updates["attempts"] != 1 || updates["lease_owner"] != "worker-a" {
+		t.Fatalf("unexpected updates %v", updates)
+	}
+
+	// 重试时计入新的尝试并更新领取时间，首次开始时间保持不变
+	later := now.Add(time.Hour)
+	claimJob(job, "worker-b", later, time.Minute)
+	if job.Attempts != 2 || job.LeaseOwner != "worker-b" || !job.StartedAt.Equal(now) || !job.ClaimedAt.Equal(later) {
+		t.Fatalf("unexpected reclaimed job %+v", job)
+	}
+}
+
+func TestFailJob(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		job    DocumentJob
+		cause  error
+		status JobStatus
+	}{
+		{"retry", DocumentJob{Attempts: 1, MaxAttempts: 3}, errors.New("timeout"), JobStatusQueued},
+		{"lease expired", DocumentJob{Attempts: 2, MaxAttempts: 3}, ErrJobLeaseExpired, JobStatusQueued},
+		{"attempts exhausted", DocumentJob{Attempts: 3, MaxAttempts: 3}, ErrJobLeaseExpired, JobStatusDead},
+		{"permanent", DocumentJob{Attempts: 1, MaxAttempts: 3}, fmt.Errorf("%w: bad input", ErrPermanentJobFailure), JobStatusDead},
+		{"panic", DocumentJob{Attempts: 1, MaxAttempts: 3}, fmt.Errorf("%w: nil map", ErrJobPanic), JobStatusQueued},
+		{"cancel requested", DocumentJob{Attempts: 3, MaxAttempts: 3, CancelRequested: true}, ErrJobLeaseExpired, JobStatusCancelled},
+	}
+	for _, tc := range cases {
+		status, updates := failJob(&tc.job, tc.cause, now, 30*time.Second)
+		if status != tc.status || updates["status"] != tc.status {
+			t.Errorf("%s: status = %s, want %s", tc.name, status, tc.status)
+			continue
+		}
+		if updates["lease_owner"] != "" || updates["last_error"] != tc.cause.Error() {
+			t.Errorf("%s: lease not released or error not recorded: %v", tc.name, updates)
+		}
+		runAt, retried := updates["run_at"].(time.Time)
+		if retried != (status == JobStatusQueued) {
+			t.Errorf("%s: run_at set = %v", tc.name, retried)
+		}
+		if retried && !runAt.Equal(now.Add(30*time.Second)) {
+			t.Errorf("%s: run_at = %v", tc.name, runAt)
+		}
+		if _, finished := updates["finished_at"]; finished == retried {
+			t.Errorf("%s: finished_at set = %v", tc.name, finished)
+		}
+	}
+}
+
+func TestJobBackoff(t *testing.T) {
+	q := &JobQueue{backoffBase: time.Second, backoffMax: 10 * time.Second}
+	bounds := []struct {
+		attempts int
+		max      time.Duration
+	}{{1, time.Second}, {2, 2 * time.Second}, {3, 4 * time.Second}, {4, 8 * time.Second}, {5, 10 * time.Second}, {20, 10 * time.Second}}
+	for _, b := range bounds {
+		for i := 0; i < 50; i++ {
+			if d := q.backoff(b.attempts); d < b.max/2 || d > b.max {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", b.attempts, d, b.max/2, b.max)
+			}
+		}
+	}
+}
+
+func TestHeartbeatRejected(t *testing.T) {
+	cases := []struct {
+		name    string
+		current DocumentJob
+		want    int
+	}{
+		{"cancel requested", DocumentJob{Status: JobStatusRunning, LeaseOwner: "a", CancelRequested: true}, heartbeatCancelled},
+		{"lease taken over", DocumentJob{Status: JobStatusRunning, LeaseOwner: "b"}, heartbeatLost},
+		{"reclaimed after expiry", DocumentJob{Status: JobStatusQueued}, heartbeatLost},
+		{"cancelled while reclaimed", DocumentJob{Status: JobStatusCancelled, CancelRequested: true}, heartbeatLost},
+	}
+	for _, tc := range cases {
+		if got := heartbeatRejected(&tc.current, "a"); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestResetDeadJob(t *testing.T) {
+	finished := time.Now()
+	job := &DocumentJob{Status: JobStatusDead, Attempts: 5, Progress: 40, FinishedAt: &finished, LastError: "boom"}
+	now := finished.Add(time.Minute)
+	updates := resetDeadJob(job, now)
+	if job.Status != JobStatusQueued || job.Attempts != 0 || job.Progress != 0 || job.FinishedAt != nil || !job.RunAt.Equal(now) {
+		t.Fatalf("unexpected requeued job %+v", job)
+	}
+	if v, ok := updates["finished_at"]; !ok || v != nil {
+		t.Fatalf("finished_at not cleared: %v", updates)
+	}
+	if job.LastError != "boom" {
+		t.Fatal("last error should be kept for diagnosis")
+	}
+}
+
+// 需要MySQL，设置DOCUMENT_TEST_MYSQL_DSN后执行，如
+// root:password@tcp(localhost:3306)/document_test?charset=utf8mb4&parseTime=True&loc=Local
+func testQueueDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("DOCUMENT_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("Skipping test - DOCUMENT_TEST_MYSQL_DSN not set")
+	}
+	testDB, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Skipf("Skipping test - MySQL not available: %v", err)
+	}
+	if err := testDB.AutoMigrate(&DocumentJob{}, &DocumentTask{}); err != nil {
+		t.Fatal(err)
+	}
+	return testDB
+}
+
+func TestJobQueueLifecycle(t *testing.T) {
+	testDB := testQueueDB(t)
+	q := NewJobQueue(testDB)
+	q.leaseDuration = time.Minute
+	q.resetters[JobKindTask] = resetDocumentTask
+
+	task := &DocumentTask{ID: fmt.Sprintf("test-%d", time.Now().UnixNano()), UserID: 7, Status: ProcessingStatusPending}
+	job, err := func() (*DocumentJob, error) {
+		var job *DocumentJob
+		err := testDB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(task).Error; err != nil {
+				return err
+			}
+			var err error
+			job, err = q.Enqueue(tx, JobKindTask, task.ID, task.UserID, JobPriorityHigh)
+			return err
+		})
+		return job, err
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Delete(&DocumentJob{}, "id = ?", job.ID)
+	defer testDB.Delete(&DocumentTask{}, "id = ?", task.ID)
+	testDB.Model(job).Update("max_attempts", 2)
+	job.MaxAttempts = 2
+
+	load := func() *DocumentJob {
+		var current DocumentJob
+		if err := testDB.First(&current, "id = ?", job.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &current
+	}
+	claim := func() *DocumentJob {
+		// 同库中可能有其他排队作业，高优先级保证先领到本作业
+		jobs, err := q.claim(1)
+		if err != nil || len(jobs) != 1 || jobs[0].ID != job.ID {
+			t.Fatalf("claim = %v, %v", jobs, err)
+		}
+		return jobs[0]
+	}
+
+	// 领取后进入运行并持有租约
+	claimed := claim()
+	if current := load(); current.Status != JobStatusRunning || current.Attempts != 1 || current.LeaseOwner != q.owner {
+		t.Fatalf("unexpected claimed job %+v", current)
+	}
+
+	// 持有者可以续租，其他实例不能
+	if got := q.heartbeat(claimed); got != heartbeatOK {
+		t.Fatalf("heartbeat = %d", got)
+	}
+	other := NewJobQueue(testDB)
+	if got := other.heartbeat(claimed); got != heartbeatLost {
+		t.Fatalf("foreign heartbeat = %d", got)
+	}
+
+	// 租约过期后收回并按退避重新入队
+	testDB.Model(&DocumentJob{}).Where("id = ?", job.ID).Update("lease_expires_at", time.Now().Add(-time.Second))
+	q.reclaimExpiredLeases()
+	current := load()
+	if current.Status != JobStatusQueued || current.LeaseOwner != "" || current.LastError != ErrJobLeaseExpired.Error() {
+		t.Fatalf("unexpected reclaimed job %+v", current)
+	}
+	if !current.RunAt.After(time.Now()) {
+		t.Fatal("retry should be delayed by backoff")
+	}
+	if got := q.heartbeat(claimed); got != heartbeatLost {
+		t.Fatalf("heartbeat after reclaim = %d", got)
+	}
+
+	// 第二次尝试失败，重试耗尽进入死信
+	testDB.Model(&DocumentJob{}).Where("id = ?", job.ID).Update("run_at", time.Now().Add(-time.Second))
+	claimed = claim()
+	q.fail(claimed, q.owner, errors.New("converter crashed"))
+	if current := load(); current.Status != JobStatusDead || current.Attempts != 2 || current.FinishedAt == nil {
+		t.Fatalf("unexpected dead job %+v", current)
+	}
+	testDB.Model(task).Updates(map[string]interface{}{"status": ProcessingStatusFailed, "progress": 40, "error": "converter crashed"})
+
+	// 重新入队同时恢复业务记录，否则处理函数会跳过已失败的任务
+	if _, err := q.Requeue(job.ID, 8); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("requeue by another user: %v", err)
+	}
+	if _, err := q.Requeue(job.ID, task.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if current := load(); current.Status != JobStatusQueued || current.Attempts != 0 || current.FinishedAt != nil {
+		t.Fatalf("unexpected requeued job %+v", current)
+	}
+	var reset DocumentTask
+	testDB.First(&reset, "id = ?", task.ID)
+	if reset.Status != ProcessingStatusPending || reset.Progress != 0 || reset.Error != "" {
+		t.Fatalf("task not reset: %+v", reset)
+	}
+	if _, err := q.Requeue(job.ID, task.UserID); !errors.Is(err, ErrJobNotDead) {
+		t.Fatalf("expected ErrJobNotDead, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package latency 提供可合并的耗时直方图，用于按天汇总后再计算任意区间的分位数
+package latency
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// 桶上界（毫秒）：从10ms起按1.25倍递增至1小时，分位数误差不超过一个桶宽
+var bounds = func() []int64 {
+	var b []int64
+	for v := 10.0; v < 3600000; v *= 1.25 {
+		b = append(b, int64(math.Round(v)))
+	}
+	return append(b, 3600000)
+}()
+
+// Histogram 耗时直方图，Counts[i]为落入第i个桶的次数，最后一个桶记录超过1小时的耗时
+type Histogram struct {
+	Counts []int64 `json:"counts"`
+	Sum    int64   `json:"sum"`
+	Max    int64   `json:"max"`
+}
+
+// Parse 解析JSON，空字符串返回空直方图
+func Parse(s string) (*Histogram, error) {
+	h := &Histogram{}
+	if s == "" {
+		return h, nil
+	}
+	if err := json.Unmarshal([]byte(s), h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// String 序列化为JSON，省略末尾的空桶
+func (h *Histogram) String() string {
+	n := len(h.Counts)
+	for n > 0 && h.Counts[n-1] == 0 {
+		n--
+	}
+	data, _ := json.Marshal(Histogram{Counts: h.Counts[:n], Sum: h.Sum, Max: h.Max})
+	return string(data)
+}
+
+func (h *Histogram) grow(n int) {
+	if len(h.Counts) < n {
+		h.Counts = append(h.Counts, make([]int64, n-len(h.Counts))...)
+	}
+}
+
+// Observe 记录一次耗时（毫秒）
+func (h *Histogram) Observe(ms int64) {
+	if ms < 0 {
+		ms = 0
+	}
+	i := sort.Search(len(bounds), func(i int) bool { return bounds[i] >= ms })
+	h.grow(i + 1)
+	h.Counts[i]++
+	h.Sum += ms
+	if ms > h.Max {
+		h.Max = ms
+	}
+}
+
+// Merge 合并另一个直方图
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil {
+		return
+	}
+	h.grow(len(o.Counts))
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+	h.Sum += o.Sum
+	if o.Max > h.Max {
+		h.Max = o.Max
+	}
+}
+
+// Count 记录次数
+func (h *Histogram) Count() int64 {
+	var n int64
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
+
+// Mean 平均耗时
+func (h *Histogram) Mean() float64 {
+	n := h.Count()
+	if n == 0 {
+		return 0
+	}
+	return float64(h.Sum) / float64(n)
+}
+
+// Quantile 估算分位数（毫秒），在桶内线性插值，结果不超过最大值
+func (h *Histogram) Quantile(q float64) int64 {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+	if q <= 0 {
+		q = 0
+	}
+	if q >= 1 {
+		return h.Max
+	}
+
+	rank := q * float64(total)
+	var seen float64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		if seen+float64(c) >= rank {
+			lower, upper := bucketRange(i, h.Max)
+			v := float64(lower) + (float64(upper-lower) * (rank - seen) / float64(c))
+			return min(int64(math.Round(v)), h.Max)
+		}
+		seen += float64(c)
+	}
+	return h.Max
+}
+
+func bucketRange(i int, max int64) (int64, int64) {
+	var lower int64
+	if i > 0 {
+		lower = bounds[i-1]
+	}
+	if i >= len(bounds) {
+		return lower, max
+	}
+	return lower, bounds[i]
+}
@@ -0,0 +1,83 @@
+package latency
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestQuantilesWithinBucketError(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := &Histogram{}
+	var samples []int64
+	for i := 0; i < 10000; i++ {
+		// 对数正态分布，中位数约800ms
+		ms := int64(math.Exp(rng.NormFloat64()*0.8 + math.Log(800)))
+		samples = append(samples, ms)
+		h.Observe(ms)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	for _, q := range []float64{0.5, 0.95, 0.99} {
+		exact := float64(samples[int(q*float64(len(samples)))-1])
+		got := float64(h.Quantile(q))
+		if math.Abs(got-exact)/exact > 0.25 {
+			t.Errorf("p%.0f = %.0f, exact %.0f", q*100, got, exact)
+		}
+	}
+	if h.Count() != 10000 {
+		t.Errorf("count = %d", h.Count())
+	}
+	if h.Quantile(1) != samples[len(samples)-1] {
+		t.Errorf("p100 = %d, want max %d", h.Quantile(1), samples[len(samples)-1])
+	}
+}
+
+func TestMergeMatchesCombinedObservations(t *testing.T) {
+	a, b, all := &Histogram{}, &Histogram{}, &Histogram{}
+	for i := int64(1); i <= 500; i++ {
+		a.Observe(i * 3)
+		all.Observe(i * 3)
+	}
+	for i := int64(1); i <= 100; i++ {
+		b.Observe(i * 1000)
+		all.Observe(i * 1000)
+	}
+	a.Merge(b)
+
+	if a.String() != all.String() {
+		t.Errorf("merged %s\nwant %s", a.String(), all.String())
+	}
+	if a.Quantile(0.95) != all.Quantile(0.95) || a.Max != 100000 {
+		t.Errorf("p95 %d vs %d, max %d", a.Quantile(0.95), all.Quantile(0.95), a.Max)
+	}
+}
+
+func TestRoundTripAndEdgeCases(t *testing.T) {
+	h := &Histogram{}
+	if h.Quantile(0.5) != 0 || h.Mean() != 0 {
+		t.Error("empty histogram should report zero")
+	}
+
+	h.Observe(-5)
+	h.Observe(5 * 3600 * 1000) // 超过最大桶
+	parsed, err := Parse(h.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Count() != 2 || parsed.Max != 5*3600*1000 || parsed.Sum != 5*3600*1000 {
+		t.Errorf("parsed = %+v", parsed)
+	}
+	if parsed.Quantile(0.99) > parsed.Max || parsed.Quantile(0.99) < 3600000 {
+		t.Errorf("overflow bucket quantile = %d", parsed.Quantile(0.99))
+	}
+
+	empty, err := Parse("")
+	if err != nil || empty.Count() != 0 {
+		t.Errorf("Parse(\"\") = %+v, %v", empty, err)
+	}
+	if _, err := Parse("{"); err == nil {
+		t.Error("invalid JSON should fail")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/hashicorp/consul/api"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
@@ -41,6 +42,12 @@ func main() {
 		logger.Fatalf("Failed to init database: %v", err)
 	}
 
+	// 初始化OCR识别引擎
+	initOCRProvider()
+
+	// 启动文档处理作业队列
+	initJobQueue()
+
 	// 初始化Consul客户端
 	if err := initConsulClient(); err != nil {
 		logger.Fatalf("Failed to init consul client: %v", err)
@@ -87,6 +94,11 @@ func main() {
 		logger.Errorf("Failed to deregister service: %v", err)
 	}
 
+	// 停止领取新作业，等待执行中的作业完成
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), viper.GetDuration("processing.queue.drain_timeout"))
+	jobQueue.Stop(drainCtx)
+	drainCancel()
+
 	// 优雅关闭服务器
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -114,6 +126,24 @@ func loadConfig() error {
 	viper.SetDefault("database.name", "resume_centre")
 	viper.SetDefault("database.user", "root")
 	viper.SetDefault("database.password", "")
+	viper.SetDefault("processing.queue.workers", 4)
+	viper.SetDefault("processing.queue.poll_interval", "1s")
+	viper.SetDefault("processing.queue.lease_duration", "1m")
+	viper.SetDefault("processing.queue.backoff_base", "5s")
+	viper.SetDefault("processing.queue.backoff_max", "30m")
+	viper.SetDefault("processing.queue.max_attempts", 5)
+	viper.SetDefault("processing.queue.drain_timeout", "30s")
+	viper.SetDefault("processing.conversion.timeout", 300)
+	viper.SetDefault("processing.ocr.provider", "tesseract")
+	viper.SetDefault("processing.ocr.tesseract_path", "")
+	viper.SetDefault("processing.ocr.default_language", "en")
+	viper.SetDefault("processing.ocr.page_seg_mode", 3)
+	viper.SetDefault("processing.ocr.timeout", "2m")
+	viper.SetDefault("storage.service_url", "http://localhost:8088")
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
+	viper.SetDefault("templates.render_timeout", "30s")
+	viper.SetDefault("templates.enterprise_service_url", "http://localhost:8002")
+	viper.SetDefault("templates.resume_service_url", "http://localhost:9003")
 
 	return viper.ReadInConfig()
 }
@@ -134,10 +164,14 @@ func initDatabase() error {
 	}
 
 	// 自动迁移
-	if err := db.AutoMigrate(&DocumentTask{}, &DocumentExtraction{}, &DocumentTemplate{}, &OCRResult{}, &ConversionConfig{}, &ProcessingStats{}); err != nil {
+	if err := db.AutoMigrate(&DocumentTask{}, &DocumentExtraction{}, &DocumentTemplate{}, &OCRResult{}, &ConversionConfig{}, &ProcessingStats{}, &DocumentJob{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
+	if err := seedBuiltinTemplates(); err != nil {
+		return fmt.Errorf("failed to seed templates: %v", err)
+	}
+
 	logger.Info("Successfully connected to database")
 	return nil
 }
@@ -226,6 +260,9 @@ func setupRouter() *gin.Engine {
 		})
 	})
 
+	// Prometheus指标
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// API路由
 	api := router.Group("/api/v1")
 	api.Use(authMiddleware())
@@ -271,6 +308,7 @@ func setupRouter() *gin.Engine {
 			templates.GET("/:id", getTemplate)
 			templates.PUT("/:id", updateTemplate)
 			templates.DELETE("/:id", deleteTemplate)
+			templates.POST("/:id/render", renderTemplate)
 		}
 
 		// 处理统计
@@ -278,6 +316,15 @@ func setupRouter() *gin.Engine {
 		{
 			stats.GET("/", getProcessingStats)
 			stats.GET("/summary", getStatsSummary)
+			stats.GET("/trends", getProcessingTrends)
+		}
+
+		// 处理作业
+		jobs := api.Group("/jobs")
+		{
+			jobs.GET("/", listJobs)
+			jobs.GET("/:id", getJob)
+			jobs.POST("/:id/requeue", requeueJob)
 		}
 	}
 
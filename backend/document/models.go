@@ -12,6 +12,7 @@ const (
 	DocumentTypeCoverLetter DocumentType = "cover_letter"
 	DocumentTypeCertificate DocumentType = "certificate"
 	DocumentTypeReference  DocumentType = "reference"
+	DocumentTypeOfferLetter DocumentType = "offer_letter"
 	DocumentTypeOther      DocumentType = "other"
 )
 
@@ -48,6 +49,7 @@ type DocumentTask struct {
 	TargetFormat    DocumentFormat   `json:"target_format" gorm:"type:varchar(20)"`
 	SourceFileID    string           `json:"source_file_id" gorm:"type:varchar(36);not null"`
 	TargetFileID    string           `json:"target_file_id" gorm:"type:varchar(36)"`
+	ConfigID        string           `json:"config_id" gorm:"type:varchar(36)"`
 	Status          ProcessingStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	Progress        int              `json:"progress" gorm:"default:0"`
 	Error           string           `json:"error" gorm:"type:text"`
@@ -96,7 +98,12 @@ type OCRResult struct {
 	ImageFileID     string           `json:"image_file_id" gorm:"type:varchar(36);not null"`
 	TextContent     string           `json:"text_content" gorm:"type:longtext"`
 	Confidence      float64          `json:"confidence" gorm:"default:0"`
-	Language        string           `json:"language" gorm:"type:varchar(10);default:'en'"`
+	Language        string           `json:"language" gorm:"type:varchar(32);default:'en'"`
+	RecognitionType string           `json:"recognition_type" gorm:"type:varchar(30);default:'general'"`
+	Provider        string           `json:"provider" gorm:"type:varchar(30)"`
+	PageCount       int              `json:"page_count" gorm:"default:0"`
+	Pages           string           `json:"-" gorm:"type:longtext"` // 分页识别结果，含每行位置与置信度
+	Fields          string           `json:"-" gorm:"type:longtext"` // 证件字段提取结果
 	Status          ProcessingStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	Error           string           `json:"error" gorm:"type:text"`
 	ProcessingTime  int64            `json:"processing_time" gorm:"default:0"`
@@ -119,14 +126,18 @@ type ConversionConfig struct {
 // 文档处理统计模型
 type ProcessingStats struct {
 	ID              string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	UserID          uint           `json:"user_id" gorm:"not null;index"`
-	DocumentType    DocumentType   `json:"document_type" gorm:"type:varchar(50);not null"`
-	Format          DocumentFormat `json:"format" gorm:"type:varchar(20);not null"`
-	TaskType        string         `json:"task_type" gorm:"type:varchar(50);not null"`
+	UserID          uint           `json:"user_id" gorm:"not null;index;uniqueIndex:idx_processing_stats_key"`
+	DocumentType    DocumentType   `json:"document_type" gorm:"type:varchar(50);not null;uniqueIndex:idx_processing_stats_key"`
+	Format          DocumentFormat `json:"format" gorm:"type:varchar(20);not null;uniqueIndex:idx_processing_stats_key"`
+	TaskType        string         `json:"task_type" gorm:"type:varchar(50);not null;uniqueIndex:idx_processing_stats_key"`
 	SuccessCount    int64          `json:"success_count" gorm:"default:0"`
 	FailureCount    int64          `json:"failure_count" gorm:"default:0"`
 	TotalProcessingTime int64      `json:"total_processing_time" gorm:"default:0"`
-	Date            time.Time      `json:"date" gorm:"not null;index"`
+	P50ProcessingTime int64        `json:"p50_processing_time" gorm:"default:0"`
+	P95ProcessingTime int64        `json:"p95_processing_time" gorm:"default:0"`
+	LatencyHistogram string        `json:"-" gorm:"type:text"` // 耗时直方图，用于跨天合并计算分位数
+	FailureReasons  string         `json:"-" gorm:"type:text"` // 失败原因计数
+	Date            time.Time      `json:"date" gorm:"type:date;not null;index;uniqueIndex:idx_processing_stats_key"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 }
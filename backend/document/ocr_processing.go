@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"resume-centre/common/ocr"
+	"resume-centre/shared/infrastructure/storage"
+
+	"github.com/spf13/viper"
+)
+
+// OCR识别类型
+const (
+	OCRTypeGeneral         = "general"
+	OCRTypeIDCard          = "id_card"
+	OCRTypeBusinessLicense = "business_license"
+)
+
+var ocrTypes = map[string]bool{OCRTypeGeneral: true, OCRTypeIDCard: true, OCRTypeBusinessLicense: true}
+
+// OCR识别引擎，未安装tesseract时为nil
+var ocrProvider ocr.Provider
+
+func initOCRProvider() {
+	provider, err := ocr.NewProvider(ocr.Config{
+		Provider:         viper.GetString("processing.ocr.provider"),
+		TesseractPath:    viper.GetString("processing.ocr.tesseract_path"),
+		DefaultLanguages: ocr.ParseLanguages(viper.GetString("processing.ocr.default_language")),
+		PageSegMode:      viper.GetInt("processing.ocr.page_seg_mode"),
+	})
+	if err != nil {
+		logger.Warnf("OCR disabled: %v", err)
+		return
+	}
+	ocrProvider = provider
+	logger.Infof("OCR provider %s ready, languages: %v", provider.Name(), provider.Languages())
+}
+
+// OCR识别：从存储服务读取图片，识别结果与提取的证件字段写回OCRResult
+func performOCRJob(jc *JobContext) error {
+	var ocrResult OCRResult
+	if err := db.Where("id = ?", jc.Job.TargetID).First(&ocrResult).Error; err != nil {
+		return fmt.Errorf("%w: OCR result %s not found", ErrPermanentJobFailure, jc.Job.TargetID)
+	}
+	if err := db.Model(&ocrResult).Update("status", ProcessingStatusProcessing).Error; err != nil {
+		return err
+	}
+	if ocrProvider == nil {
+		return fmt.Errorf("%w: %w", ErrPermanentJobFailure, ocr.ErrProviderUnavailable)
+	}
+
+	ctx := context.Context(jc)
+	if timeout := viper.GetDuration("processing.ocr.timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	image, err := newStorageClient().OpenAs(ctx, ocrResult.UserID, ocrResult.ImageFileID)
+	if err != nil {
+		if errors.Is(err, storage.ErrFileNotFound) {
+			return fmt.Errorf("%w: %w", ErrPermanentJobFailure, err)
+		}
+		return err
+	}
+	defer image.Close()
+	jc.Progress(10)
+
+	start := time.Now()
+	res, err := ocrProvider.Recognize(ctx, image, ocr.Options{Languages: ocr.ParseLanguages(ocrResult.Language)})
+	if err != nil {
+		if errors.Is(err, ocr.ErrUnsupportedLanguage) || errors.Is(err, ocr.ErrInvalidImage) ||
+			errors.Is(err, ocr.ErrProviderUnavailable) {
+			return fmt.Errorf("%w: %w", ErrPermanentJobFailure, err)
+		}
+		return err
+	}
+	elapsed := time.Since(start)
+	jc.Progress(80)
+
+	pages, err := json.Marshal(res.Pages)
+	if err != nil {
+		return err
+	}
+	fields, fieldsErr := extractOCRFields(ocrResult.RecognitionType, res)
+
+	if err := db.Model(&ocrResult).Updates(map[string]interface{}{
+		"text_content":    res.Text(),
+		"confidence":      res.Confidence,
+		"provider":        res.Provider,
+		"page_count":      len(res.Pages),
+		"pages":           string(pages),
+		"fields":          fields,
+		"processing_time": elapsed.Milliseconds(),
+	}).Error; err != nil {
+		return err
+	}
+
+	// 识别文本已保存，证件字段缺失时重试没有意义
+	if fieldsErr != nil {
+		return fmt.Errorf("%w: %w", ErrPermanentJobFailure, fieldsErr)
+	}
+	return nil
+}
+
+// 按识别类型提取证件字段，返回JSON
+func extractOCRFields(recognitionType string, res *ocr.Result) (string, error) {
+	var fields interface{}
+	var err error
+	switch recognitionType {
+	case OCRTypeIDCard:
+		fields, err = ocr.ExtractIDCard(res)
+	case OCRTypeBusinessLicense:
+		fields, err = ocr.ExtractBusinessLicense(res)
+	default:
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
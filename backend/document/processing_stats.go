@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"resume-centre/common/ocr"
+	"resume-centre/document/converter"
+	"resume-centre/document/latency"
+	"resume-centre/shared/infrastructure/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 失败原因
+const (
+	FailureReasonTimeout             = "timeout"
+	FailureReasonUnsupported         = "unsupported_conversion"
+	FailureReasonInvalidInput        = "invalid_input"
+	FailureReasonFileNotFound        = "file_not_found"
+	FailureReasonUnsupportedLanguage = "unsupported_language"
+	FailureReasonProviderUnavailable = "provider_unavailable"
+	FailureReasonFieldsNotFound      = "fields_not_found"
+	FailureReasonLeaseExpired        = "lease_expired"
+	FailureReasonPanic               = "panic"
+	FailureReasonInternal            = "internal"
+)
+
+// 统计维度：作业对应业务记录的用户、文档类型、格式与任务类型
+type statsSubject struct {
+	UserID       uint
+	DocumentType DocumentType
+	Format       DocumentFormat
+	TaskType     string
+}
+
+// 在作业终态（成功或进入死信）时记录处理结果与耗时
+func withProcessingStats(finalizer JobFinalizer) JobFinalizer {
+	return func(job *DocumentJob, status JobStatus, err error) {
+		finalizer(job, status, err)
+		if status != JobStatusSucceeded && status != JobStatusDead {
+			return
+		}
+
+		subject, serr := jobStatsSubject(job)
+		if serr != nil {
+			logger.Warnf("Skipping processing stats for job %s: %v", job.ID, serr)
+			return
+		}
+		// 只统计本次尝试的耗时，不含此前失败重试和退避等待
+		var duration time.Duration
+		if job.ClaimedAt != nil {
+			duration = time.Since(*job.ClaimedAt)
+		}
+		reason := ""
+		if status == JobStatusDead {
+			reason = failureReason(err)
+		}
+
+		observeTaskOutcome(subject, reason, duration)
+		if rerr := recordProcessingOutcome(subject, time.Now(), duration, reason); rerr != nil {
+			logger.Errorf("Failed to record processing stats for job %s: %v", job.ID, rerr)
+		}
+	}
+}
+
+func jobStatsSubject(job *DocumentJob) (statsSubject, error) {
+	subject := statsSubject{UserID: job.UserID, DocumentType: DocumentTypeOther}
+	switch job.Kind {
+	case JobKindTask, JobKindConversion:
+		var task DocumentTask
+		if err := db.Select("task_type", "document_type", "source_format").
+			Where("id = ?", job.TargetID).First(&task).Error; err != nil {
+			return subject, err
+		}
+		subject.TaskType = task.TaskType
+		subject.DocumentType = task.DocumentType
+		subject.Format = task.SourceFormat
+	case JobKindExtraction:
+		subject.TaskType = "extraction"
+	case JobKindOCR:
+		subject.TaskType = "ocr"
+	default:
+		subject.TaskType = job.Kind
+	}
+	return subject, nil
+}
+
+// 将作业错误归类为有限的失败原因，便于统计与告警
+func failureReason(err error) string {
+	switch {
+	case err == nil:
+		return FailureReasonInternal
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureReasonTimeout
+	case errors.Is(err, converter.ErrUnsupportedConversion), errors.Is(err, ErrConversionConfigNotFound),
+		errors.Is(err, ErrConversionConfigMismatch):
+		return FailureReasonUnsupported
+	case errors.Is(err, converter.ErrInvalidDocx), errors.Is(err, ocr.ErrInvalidImage):
+		return FailureReasonInvalidInput
+	case errors.Is(err, storage.ErrFileNotFound):
+		return FailureReasonFileNotFound
+	case errors.Is(err, ocr.ErrUnsupportedLanguage):
+		return FailureReasonUnsupportedLanguage
+	case errors.Is(err, ocr.ErrProviderUnavailable):
+		return FailureReasonProviderUnavailable
+	case errors.Is(err, ocr.ErrFieldsNotFound):
+		return FailureReasonFieldsNotFound
+	case errors.Is(err, ErrJobLeaseExpired):
+		return FailureReasonLeaseExpired
+	case errors.Is(err, ErrJobPanic):
+		return FailureReasonPanic
+	}
+	return FailureReasonInternal
+}
+
+// 按天汇总处理结果，行锁保证多个worker并发更新同一行时不丢失计数
+func recordProcessingOutcome(subject statsSubject, at time.Time, duration time.Duration, reason string) error {
+	date := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+
+	var err error
+	// 两个worker同时创建当天的行时，唯一索引冲突的一方重试一次即可加锁更新
+	for attempt := 0; attempt < 2; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var stats ProcessingStats
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("user_id = ? AND document_type = ? AND format = ? AND task_type = ? AND date = ?",
+					subject.UserID, subject.DocumentType, subject.Format, subject.TaskType, date).
+				First(&stats).Error
+			created := errors.Is(err, gorm.ErrRecordNotFound)
+			if err != nil && !created {
+				return err
+			}
+			if created {
+				stats = ProcessingStats{
+					ID:           uuid.New().String(),
+					UserID:       subject.UserID,
+					DocumentType: subject.DocumentType,
+					Format:       subject.Format,
+					TaskType:     subject.TaskType,
+					Date:         date,
+				}
+			}
+			if err := stats.add(duration, reason); err != nil {
+				return err
+			}
+			if created {
+				return tx.Create(&stats).Error
+			}
+			return tx.Save(&stats).Error
+		})
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// 累加一次处理结果，并重新计算当天的分位数
+func (s *ProcessingStats) add(duration time.Duration, reason string) error {
+	hist, err := latency.Parse(s.LatencyHistogram)
+	if err != nil {
+		return err
+	}
+	reasons, err := parseFailureReasons(s.FailureReasons)
+	if err != nil {
+		return err
+	}
+
+	ms := duration.Milliseconds()
+	hist.Observe(ms)
+	s.TotalProcessingTime += ms
+	if reason == "" {
+		s.SuccessCount++
+	} else {
+		s.FailureCount++
+		reasons[reason]++
+	}
+
+	s.P50ProcessingTime = hist.Quantile(0.5)
+	s.P95ProcessingTime = hist.Quantile(0.95)
+	s.LatencyHistogram = hist.String()
+	if len(reasons) > 0 {
+		data, _ := json.Marshal(reasons)
+		s.FailureReasons = string(data)
+	}
+	return nil
+}
+
+func parseFailureReasons(raw string) (map[string]int64, error) {
+	reasons := map[string]int64{}
+	if raw == "" {
+		return reasons, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &reasons); err != nil {
+		return nil, err
+	}
+	return reasons, nil
+}
+
+// 一组汇总行合并后的处理指标
+type statsAggregate struct {
+	hist    *latency.Histogram
+	success int64
+	failure int64
+}
+
+func newStatsAggregate() *statsAggregate {
+	return &statsAggregate{hist: &latency.Histogram{}}
+}
+
+func (a *statsAggregate) add(s *ProcessingStats) {
+	a.success += s.SuccessCount
+	a.failure += s.FailureCount
+	if hist, err := latency.Parse(s.LatencyHistogram); err == nil {
+		a.hist.Merge(hist)
+	}
+}
+
+func (a *statsAggregate) summary() gin.H {
+	total := a.success + a.failure
+	failureRate := 0.0
+	if total > 0 {
+		failureRate = float64(a.failure) / float64(total)
+	}
+	return gin.H{
+		"total":               total,
+		"success":             a.success,
+		"failure":             a.failure,
+		"failure_rate":        failureRate,
+		"avg_processing_time": a.hist.Mean(),
+		"p50_processing_time": a.hist.Quantile(0.5),
+		"p95_processing_time": a.hist.Quantile(0.95),
+	}
+}
+
+// 获取处理趋势：吞吐量、失败原因与耗时分位数，按天和任务类型汇总
+func getProcessingTrends(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	startDate, endDate := today.AddDate(0, 0, -29), today
+	var err error
+	if s := c.Query("start_date"); s != "" {
+		if startDate, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+	if s := c.Query("end_date"); s != "" {
+		if endDate, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+	if endDate.Before(startDate) || endDate.Sub(startDate) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range must be between 1 and 366 days"})
+		return
+	}
+
+	query := db.Where("user_id = ? AND date BETWEEN ? AND ?", userID, startDate, endDate)
+	if taskType := c.Query("task_type"); taskType != "" {
+		query = query.Where("task_type = ?", taskType)
+	}
+	var rows []ProcessingStats
+	if err := query.Order("date ASC").Find(&rows).Error; err != nil {
+		logger.Errorf("Failed to get processing trends: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get processing trends"})
+		return
+	}
+
+	overall := newStatsAggregate()
+	byDay := map[string]*statsAggregate{}
+	byType := map[string]*statsAggregate{}
+	reasons := map[string]int64{}
+	for i := range rows {
+		row := &rows[i]
+		overall.add(row)
+		day := row.Date.Format("2006-01-02")
+		if byDay[day] == nil {
+			byDay[day] = newStatsAggregate()
+		}
+		byDay[day].add(row)
+		if byType[row.TaskType] == nil {
+			byType[row.TaskType] = newStatsAggregate()
+		}
+		byType[row.TaskType].add(row)
+		if rowReasons, err := parseFailureReasons(row.FailureReasons); err == nil {
+			for reason, n := range rowReasons {
+				reasons[reason] += n
+			}
+		}
+	}
+
+	// 没有数据的日期补零，便于前端直接绘制
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	trend := make([]gin.H, 0, days)
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		day := d.Format("2006-01-02")
+		agg := byDay[day]
+		if agg == nil {
+			agg = newStatsAggregate()
+		}
+		point := agg.summary()
+		point["date"] = day
+		trend = append(trend, point)
+	}
+
+	taskTypes := make([]gin.H, 0, len(byType))
+	for taskType, agg := range byType {
+		item := agg.summary()
+		item["task_type"] = taskType
+		taskTypes = append(taskTypes, item)
+	}
+	sort.Slice(taskTypes, func(i, j int) bool {
+		return taskTypes[i]["task_type"].(string) < taskTypes[j]["task_type"].(string)
+	})
+
+	failureReasons := make([]gin.H, 0, len(reasons))
+	for reason, n := range reasons {
+		failureReasons = append(failureReasons, gin.H{"reason": reason, "count": n})
+	}
+	sort.Slice(failureReasons, func(i, j int) bool {
+		ci, cj := failureReasons[i]["count"].(int64), failureReasons[j]["count"].(int64)
+		if ci != cj {
+			return ci > cj
+		}
+		return failureReasons[i]["reason"].(string) < failureReasons[j]["reason"].(string)
+	})
+
+	totals := overall.summary()
+	totals["throughput_per_day"] = float64(overall.success+overall.failure) / float64(days)
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date":      startDate.Format("2006-01-02"),
+		"end_date":        endDate.Format("2006-01-02"),
+		"totals":          totals,
+		"task_types":      taskTypes,
+		"failure_reasons": failureReasons,
+		"trend":           trend,
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"resume-centre/shared/infrastructure/storage"
+
+	"github.com/spf13/viper"
+)
+
+// 通过Consul发现服务地址，不可用时使用配置中的地址
+func serviceURL(name, configKey string) string {
+	if consulClient != nil {
+		services, _, err := consulClient.Health().Service(name, "", true, nil)
+		if err == nil && len(services) > 0 {
+			svc := services[0].Service
+			return fmt.Sprintf("http://%s:%d", svc.Address, svc.Port)
+		}
+	}
+	return viper.GetString(configKey)
+}
+
+// 服务常驻运行，每次请求时重新发现存储服务地址
+func storageServiceURL() string {
+	return serviceURL("storage-service", "storage.service_url")
+}
+
+func newStorageClient() *storage.Client {
+	return storage.NewClient(storageServiceURL, viper.GetString("internal.service_token"))
+}
@@ -0,0 +1,335 @@
+// Package templating 实现文档模板的变量校验与渲染
+package templating
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 变量类型
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeArray   = "array"
+	TypeObject  = "object"
+)
+
+var knownTypes = map[string]bool{
+	TypeString: true, TypeNumber: true, TypeInteger: true, TypeBoolean: true,
+	TypeDate: true, TypeArray: true, TypeObject: true,
+}
+
+// 可识别的日期格式
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "2006/01/02", "2006年1月2日"}
+
+// Field 变量声明
+type Field struct {
+	Name        string            `json:"name,omitempty"`
+	Type        string            `json:"type"`
+	Required    bool              `json:"required,omitempty"`
+	Default     interface{}       `json:"default,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Enum        []interface{}     `json:"enum,omitempty"`
+	Items       *Field            `json:"items,omitempty"`
+	Properties  map[string]*Field `json:"properties,omitempty"`
+}
+
+// Schema 模板变量声明，对应 DocumentTemplate.Variables
+type Schema map[string]*Field
+
+// FieldError 单个变量的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError 变量校验失败
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "invalid variables: " + strings.Join(msgs, "; ")
+}
+
+// ParseSchema 解析变量声明，支持以变量名为键的对象或带name的数组两种写法
+func ParseSchema(raw string) (Schema, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return Schema{}, nil
+	}
+
+	schema := Schema{}
+	if strings.HasPrefix(raw, "[") {
+		var fields []*Field
+		if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+			return nil, fmt.Errorf("invalid variables schema: %v", err)
+		}
+		for _, f := range fields {
+			if f == nil || f.Name == "" {
+				return nil, errors.New("invalid variables schema: every variable needs a name")
+			}
+			schema[f.Name] = f
+		}
+	} else if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		return nil, fmt.Errorf("invalid variables schema: %v", err)
+	}
+
+	for _, name := range schema.names() {
+		if err := checkField(name, schema[name]); err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+func checkField(path string, f *Field) error {
+	if f == nil {
+		return fmt.Errorf("invalid variables schema: %s has no definition", path)
+	}
+	if f.Type == "" {
+		f.Type = TypeString
+	}
+	if !knownTypes[f.Type] {
+		return fmt.Errorf("invalid variables schema: %s has unknown type %q", path, f.Type)
+	}
+	if f.Items != nil {
+		if err := checkField(path+"[]", f.Items); err != nil {
+			return err
+		}
+	}
+	for name, p := range f.Properties {
+		if err := checkField(path+"."+name, p); err != nil {
+			return err
+		}
+	}
+	if f.Default != nil {
+		if _, msg := coerce(f, f.Default); msg != "" {
+			return fmt.Errorf("invalid variables schema: default of %s %s", path, msg)
+		}
+	}
+	return nil
+}
+
+func (s Schema) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate 按声明校验并规范化变量：补默认值、转换类型；未声明的变量原样保留
+func (s Schema) Validate(vars map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(vars)+len(s))
+	for k, v := range vars {
+		result[k] = v
+	}
+
+	var errs []FieldError
+	for _, name := range s.names() {
+		value, fieldErrs := validateField(name, s[name], vars[name])
+		errs = append(errs, fieldErrs...)
+		result[name] = value
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+	return result, nil
+}
+
+func validateField(path string, f *Field, value interface{}) (interface{}, []FieldError) {
+	if isMissing(value) {
+		switch {
+		case f.Default != nil:
+			value = f.Default
+		case f.Required:
+			return nil, []FieldError{{Field: path, Message: "is required"}}
+		default:
+			// 可选变量缺省为零值，模板中可直接用于条件判断
+			return zeroValue(f), nil
+		}
+	}
+
+	v, msg := coerce(f, value)
+	if msg != "" {
+		return nil, []FieldError{{Field: path, Message: msg}}
+	}
+
+	if len(f.Enum) > 0 && !inEnum(f, v) {
+		return nil, []FieldError{{Field: path, Message: fmt.Sprintf("must be one of %v", f.Enum)}}
+	}
+
+	var errs []FieldError
+	switch f.Type {
+	case TypeArray:
+		if f.Items != nil {
+			items := v.([]interface{})
+			for i := range items {
+				var itemErrs []FieldError
+				items[i], itemErrs = validateField(fmt.Sprintf("%s[%d]", path, i), f.Items, items[i])
+				errs = append(errs, itemErrs...)
+			}
+		}
+	case TypeObject:
+		obj := v.(map[string]interface{})
+		for _, name := range Schema(f.Properties).names() {
+			var propErrs []FieldError
+			obj[name], propErrs = validateField(path+"."+name, f.Properties[name], obj[name])
+			errs = append(errs, propErrs...)
+		}
+	}
+	return v, errs
+}
+
+func isMissing(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && strings.TrimSpace(s) == ""
+}
+
+func zeroValue(f *Field) interface{} {
+	switch f.Type {
+	case TypeNumber:
+		return float64(0)
+	case TypeInteger:
+		return int64(0)
+	case TypeBoolean:
+		return false
+	case TypeDate:
+		return time.Time{}
+	case TypeArray:
+		return []interface{}{}
+	case TypeObject:
+		obj := map[string]interface{}{}
+		for name, p := range f.Properties {
+			if p.Default != nil {
+				obj[name], _ = coerce(p, p.Default)
+			} else {
+				obj[name] = zeroValue(p)
+			}
+		}
+		return obj
+	}
+	return ""
+}
+
+// 类型转换，失败时返回错误描述
+func coerce(f *Field, value interface{}) (interface{}, string) {
+	switch f.Type {
+	case TypeString:
+		switch v := value.(type) {
+		case string:
+			return v, ""
+		case float64, int, int64, bool, json.Number:
+			return fmt.Sprint(v), ""
+		}
+		return nil, "must be a string"
+	case TypeNumber, TypeInteger:
+		n, ok := toFloat(value)
+		if !ok {
+			return nil, "must be a number"
+		}
+		if f.Type == TypeInteger {
+			if n != math.Trunc(n) {
+				return nil, "must be an integer"
+			}
+			return int64(n), ""
+		}
+		return n, ""
+	case TypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, ""
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, ""
+			}
+		}
+		return nil, "must be a boolean"
+	case TypeDate:
+		if t, ok := toTime(value); ok {
+			return t, ""
+		}
+		return nil, "must be a date (YYYY-MM-DD or RFC3339)"
+	case TypeArray:
+		if v, ok := value.([]interface{}); ok {
+			copied := make([]interface{}, len(v))
+			copy(copied, v)
+			return copied, ""
+		}
+		return nil, "must be an array"
+	case TypeObject:
+		if v, ok := value.(map[string]interface{}); ok {
+			copied := make(map[string]interface{}, len(v))
+			for k, item := range v {
+				copied[k] = item
+			}
+			return copied, ""
+		}
+		return nil, "must be an object"
+	}
+	return value, ""
+}
+
+func inEnum(f *Field, v interface{}) bool {
+	for _, allowed := range f.Enum {
+		if a, msg := coerce(f, allowed); msg == "" && fmt.Sprint(a) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), ",", ""), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		v = strings.TrimSpace(v)
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
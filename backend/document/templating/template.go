@@ -0,0 +1,244 @@
+package templating
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode"
+)
+
+// ErrUnsupportedFormat 模板格式不支持渲染
+var ErrUnsupportedFormat = errors.New("unsupported template format")
+
+// 日期格式预设
+var datePresets = map[string]string{
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04",
+	"zh":       "2006年1月2日",
+	"long":     "January 2, 2006",
+	"short":    "Jan 2, 2006",
+}
+
+// 货币符号与小数位
+var currencies = map[string]struct {
+	symbol   string
+	decimals int
+}{
+	"CNY": {"¥", 2},
+	"RMB": {"¥", 2},
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"HKD": {"HK$", 2},
+	"JPY": {"¥", 0},
+}
+
+// Template 已编译的模板
+type Template struct {
+	format string
+	text   *texttemplate.Template
+	html   *htmltemplate.Template
+}
+
+// Parse 编译模板内容；html格式使用html/template自动转义，其余格式按文本处理
+// 声明的变量经Validate后总会存在，职位、简历等外部数据的字段可能缺失，因此不开启missingkey=error
+func Parse(name, content, format string) (*Template, error) {
+	t := &Template{format: strings.ToLower(format)}
+	switch t.format {
+	case "html", "htm":
+		tmpl, err := htmltemplate.New(name).Funcs(Funcs()).Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		t.html = tmpl
+	case "md", "markdown", "txt", "text", "docx", "pdf":
+		// docx/pdf模板以markdown编写，渲染后再转换
+		tmpl, err := texttemplate.New(name).Funcs(Funcs()).Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		t.text = tmpl
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	return t, nil
+}
+
+// Execute 使用校验后的变量渲染模板
+func (t *Template) Execute(w io.Writer, data map[string]interface{}) error {
+	if t.html != nil {
+		return t.html.Execute(w, data)
+	}
+	return t.text.Execute(w, data)
+}
+
+// Render 渲染为字节
+func (t *Template) Render(data map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Funcs 模板可用的辅助函数
+func Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"formatDate": formatDate,
+		"currency":   currency,
+		"number":     formatNumber,
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      titleCase,
+		"trim":       strings.TrimSpace,
+		"default":    defaultValue,
+		"join":       join,
+		"add":        func(a, b interface{}) float64 { return num(a) + num(b) },
+		"sub":        func(a, b interface{}) float64 { return num(a) - num(b) },
+		"mul":        func(a, b interface{}) float64 { return num(a) * num(b) },
+		"div":        divide,
+		"now":        time.Now,
+	}
+}
+
+// formatDate 格式化日期，layout可为预设名或Go时间格式，默认2006-01-02
+func formatDate(value interface{}, layout ...string) (string, error) {
+	t, ok := toTime(value)
+	if !ok {
+		return "", fmt.Errorf("formatDate: %v is not a date", value)
+	}
+	if t.IsZero() {
+		return "", nil
+	}
+	l := "2006-01-02"
+	if len(layout) > 0 && layout[0] != "" {
+		l = layout[0]
+		if preset, ok := datePresets[l]; ok {
+			l = preset
+		}
+	}
+	return t.Format(l), nil
+}
+
+// currency 按币种格式化金额，默认人民币
+func currency(value interface{}, code ...string) (string, error) {
+	amount, ok := toFloat(value)
+	if !ok {
+		return "", fmt.Errorf("currency: %v is not a number", value)
+	}
+	c := "CNY"
+	if len(code) > 0 && code[0] != "" {
+		c = strings.ToUpper(code[0])
+	}
+	spec, ok := currencies[c]
+	if !ok {
+		return fmt.Sprintf("%s %s", groupDigits(amount, 2), c), nil
+	}
+	s := groupDigits(math.Abs(amount), spec.decimals)
+	if amount < 0 {
+		return "-" + spec.symbol + s, nil
+	}
+	return spec.symbol + s, nil
+}
+
+// formatNumber 千分位格式化数字
+func formatNumber(value interface{}, decimals ...int) (string, error) {
+	n, ok := toFloat(value)
+	if !ok {
+		return "", fmt.Errorf("number: %v is not a number", value)
+	}
+	d := 0
+	if len(decimals) > 0 {
+		d = decimals[0]
+	}
+	return groupDigits(n, d), nil
+}
+
+func groupDigits(n float64, decimals int) string {
+	s := strconv.FormatFloat(n, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+
+	var sb strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	return sign + sb.String() + frac
+}
+
+func titleCase(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		defer func() { prev = r }()
+		if unicode.IsSpace(prev) || prev == '-' {
+			return unicode.ToTitle(r)
+		}
+		return r
+	}, s)
+}
+
+// default 用法：{{default "无" .x}}，值为空时返回默认值
+func defaultValue(def, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case time.Time:
+		if v.IsZero() {
+			return def
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+func join(list interface{}, sep string) string {
+	switch v := list.(type) {
+	case []string:
+		return strings.Join(v, sep)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(list)
+}
+
+func num(v interface{}) float64 {
+	n, _ := toFloat(v)
+	return n
+}
+
+func divide(a, b interface{}) (float64, error) {
+	d := num(b)
+	if d == 0 {
+		return 0, errors.New("div: division by zero")
+	}
+	return num(a) / d, nil
+}
@@ -0,0 +1,186 @@
+package templating
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const offerSchema = `{
+  "name": {"type": "string", "required": true},
+  "salary": {"type": "number", "required": true},
+  "start": {"type": "date", "required": true},
+  "months": {"type": "integer", "default": 3},
+  "remote": {"type": "boolean"},
+  "level": {"type": "string", "enum": ["junior", "senior"], "default": "junior"},
+  "benefits": {"type": "array", "items": {"type": "string"}},
+  "manager": {"type": "object", "properties": {"name": {"type": "string", "required": true}}}
+}`
+
+func TestValidateAppliesDefaultsAndCoerces(t *testing.T) {
+	schema, err := ParseSchema(offerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := schema.Validate(map[string]interface{}{
+		"name":   "张三",
+		"salary": "25,000",
+		"start":  "2025-09-01",
+		"remote": "true",
+		"extra":  "kept",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vars["salary"] != float64(25000) {
+		t.Errorf("salary = %#v", vars["salary"])
+	}
+	if start, ok := vars["start"].(time.Time); !ok || start.Format("2006-01-02") != "2025-09-01" {
+		t.Errorf("start = %#v", vars["start"])
+	}
+	if vars["months"] != int64(3) {
+		t.Errorf("months default = %#v", vars["months"])
+	}
+	if vars["remote"] != true {
+		t.Errorf("remote = %#v", vars["remote"])
+	}
+	if vars["level"] != "junior" {
+		t.Errorf("level default = %#v", vars["level"])
+	}
+	if b, ok := vars["benefits"].([]interface{}); !ok || len(b) != 0 {
+		t.Errorf("optional array should default to empty, got %#v", vars["benefits"])
+	}
+	if vars["extra"] != "kept" {
+		t.Errorf("undeclared variable dropped: %#v", vars["extra"])
+	}
+}
+
+func TestValidateReportsEveryFieldError(t *testing.T) {
+	schema, err := ParseSchema(offerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = schema.Validate(map[string]interface{}{
+		"salary":   "lots",
+		"start":    "next monday",
+		"months":   2.5,
+		"level":    "principal",
+		"benefits": []interface{}{"年假", 12},
+		"manager":  map[string]interface{}{},
+	})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	got := map[string]string{}
+	for _, fe := range verr.Errors {
+		got[fe.Field] = fe.Message
+	}
+	want := map[string]string{
+		"name":         "is required",
+		"salary":       "must be a number",
+		"start":        "must be a date (YYYY-MM-DD or RFC3339)",
+		"months":       "must be an integer",
+		"level":        "must be one of [junior senior]",
+		"manager.name": "is required",
+	}
+	for field, msg := range want {
+		if got[field] != msg {
+			t.Errorf("%s: got %q, want %q", field, got[field], msg)
+		}
+	}
+	// 数字可以转为字符串，数组元素12合法
+	if _, ok := got["benefits[1]"]; ok {
+		t.Errorf("benefits[1] should be coerced to string")
+	}
+}
+
+func TestParseSchemaRejectsInvalidDeclarations(t *testing.T) {
+	cases := []string{
+		`{"a": {"type": "money"}}`,
+		`{"a": {"type": "integer", "default": "x"}}`,
+		`[{"type": "string"}]`,
+		`{"a": {"type": "array", "items": {"type": "uuid"}}}`,
+		`not json`,
+	}
+	for _, raw := range cases {
+		if _, err := ParseSchema(raw); err == nil {
+			t.Errorf("ParseSchema(%s) should fail", raw)
+		}
+	}
+
+	schema, err := ParseSchema(`[{"name": "a", "type": "number"}]`)
+	if err != nil || schema["a"] == nil || schema["a"].Type != TypeNumber {
+		t.Errorf("array form: %v %#v", err, schema)
+	}
+	if schema, err := ParseSchema(""); err != nil || len(schema) != 0 {
+		t.Errorf("empty schema: %v %#v", err, schema)
+	}
+}
+
+func TestRenderConditionalsLoopsAndHelpers(t *testing.T) {
+	tmpl, err := Parse("offer", `{{.name}} {{currency .salary}} {{currency .salary "USD"}} {{currency 1500 "JPY"}}
+{{formatDate .start "zh"}} {{formatDate .start}} {{formatDate .start "long"}}
+{{if .remote}}remote{{else}}onsite{{end}}
+{{range $i, $b := .benefits}}{{if $i}}, {{end}}{{upper $b}}{{end}}
+{{join .benefits "/"}} {{default "N/A" .missing}} {{number 1234567.891 2}} {{add .months 1}}`, "md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, _ := ParseSchema(offerSchema)
+	vars, err := schema.Validate(map[string]interface{}{
+		"name":     "Alice",
+		"salary":   1234567.5,
+		"start":    "2025-09-01",
+		"benefits": []interface{}{"gym", "lunch"},
+		"missing":  "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := tmpl.Render(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Alice ¥1,234,567.50 $1,234,567.50 ¥1,500
+2025年9月1日 2025-09-01 September 1, 2025
+onsite
+GYM, LUNCH
+gym/lunch N/A 1,234,567.89 4`
+	if string(out) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestRenderHTMLEscapesValues(t *testing.T) {
+	tmpl, err := Parse("letter", `<p>{{.name}}</p>`, "html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tmpl.Render(map[string]interface{}{"name": `<script>alert(1)</script>`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "<script>") {
+		t.Errorf("value not escaped: %s", out)
+	}
+}
+
+func TestParseRejectsBadTemplates(t *testing.T) {
+	if _, err := Parse("x", "{{if .a}}", "md"); err == nil {
+		t.Error("unterminated if should fail")
+	}
+	if _, err := Parse("x", "{{.a}}", "xlsx"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	if _, err := Parse("x", "{{unknownHelper .a}}", "txt"); err == nil {
+		t.Error("unknown function should fail")
+	}
+}
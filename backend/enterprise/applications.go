@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+var errJobNotFound = errors.New("job not found")
+
+// 职位投递及简历匹配度
+type jobApplication struct {
+	UserID          uint      `json:"user_id"`
+	Username        string    `json:"username"`
+	Nickname        string    `json:"nickname"`
+	ResumeID        uint      `json:"resume_id"`
+	ResumeTitle     string    `json:"resume_title"`
+	AppliedAt       time.Time `json:"applied_at"`
+	Score           float64   `json:"score"`
+	Level           string    `json:"level"`
+	MatchedSkills   []string  `json:"matched_skills"`
+	MissingRequired []string  `json:"missing_required"`
+	Reason          string    `json:"reason"`
+	Feedback        string    `json:"feedback"`
+}
+
+type jobApplicationPage struct {
+	Applications []jobApplication `json:"applications"`
+	Total        int              `json:"total"`
+	Page         int              `json:"page"`
+	Limit        int              `json:"limit"`
+}
+
+var aiHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// 获取AI服务地址：优先通过Consul发现，失败时使用配置
+func aiServiceURL() string {
+	if consulClient != nil {
+		services, _, err := consulClient.Health().Service("ai-service", "", true, nil)
+		if err == nil && len(services) > 0 {
+			svc := services[0].Service
+			return fmt.Sprintf("http://%s:%d", svc.Address, svc.Port)
+		}
+	}
+	return viper.GetString("ai.service_url")
+}
+
+// 以userID的身份查询职位的投递列表，sort为applied_at或score
+func fetchJobApplications(userID uint, jobID uint64, sort string, page, pageSize int) (*jobApplicationPage, error) {
+	query := url.Values{}
+	query.Set("sort", sort)
+	query.Set("page", fmt.Sprint(page))
+	query.Set("limit", fmt.Sprint(pageSize))
+	endpoint := fmt.Sprintf("%s/api/v1/recommendations/applications/%d?%s", aiServiceURL(), jobID, query.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	// AI服务按调用者校验职位归属
+	req.Header.Set("X-User-ID", strconv.FormatUint(uint64(userID), 10))
+	resp, err := aiHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ai service unavailable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool               `json:"success"`
+		Error   string             `json:"error"`
+		Data    jobApplicationPage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid ai service response: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errJobNotFound
+	}
+	if resp.StatusCode != http.StatusOK || !result.Success {
+		return nil, fmt.Errorf("ai service error (%d): %s", resp.StatusCode, result.Error)
+	}
+	return &result.Data, nil
+}
+
+// 申请状态：企业在候选人推荐中的处理结果
+var applicationStatus = map[string]string{
+	"approve": "approved",
+	"reject":  "rejected",
+}
+
+// 职位的投递列表，仅职位所属企业的成员可查看；sort=score时按简历与职位的匹配度从高到低排序
+func listJobApplications(c *gin.Context, jobID uint64) {
+	userID, ok := authorizeJob(c, jobID)
+	if !ok {
+		return
+	}
+	sort := c.DefaultQuery("sort", "applied_at")
+	if sort != "applied_at" && sort != "score" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"data": gin.H{},
+			"msg":  "排序方式只能是applied_at或score",
+		})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	result, err := fetchJobApplications(userID, jobID, sort, page, pageSize)
+	if err == errJobNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"data": gin.H{},
+			"msg":  "职位不存在",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch applications for job %d: %v", jobID, err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"code": 502,
+			"data": gin.H{},
+			"msg":  "获取投递列表失败",
+		})
+		return
+	}
+
+	list := make([]gin.H, len(result.Applications))
+	for i, a := range result.Applications {
+		applicant := a.Nickname
+		if applicant == "" {
+			applicant = a.Username
+		}
+		status := applicationStatus[a.Feedback]
+		if status == "" {
+			status = "pending"
+		}
+		list[i] = gin.H{
+			"userId":          a.UserID,
+			"applicant":       applicant,
+			"resumeId":        a.ResumeID,
+			"resumeTitle":     a.ResumeTitle,
+			"status":          status,
+			"applyTime":       a.AppliedAt.Format("2006-01-02 15:04:05"),
+			"matchScore":      a.Score,
+			"matchLevel":      a.Level,
+			"matchedSkills":   a.MatchedSkills,
+			"missingRequired": a.MissingRequired,
+			"matchReason":     a.Reason,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"list":     list,
+			"total":    result.Total,
+			"page":     result.Page,
+			"pageSize": result.Limit,
+			"sort":     sort,
+		},
+		"msg": "success",
+	})
+}
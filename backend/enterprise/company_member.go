@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 企业成员角色
+const (
+	memberOwner     = "owner"
+	memberAdmin     = "admin"
+	memberRecruiter = "recruiter"
+)
+
+// 企业成员状态
+const (
+	memberActive   = "active"
+	memberDisabled = "disabled"
+)
+
+// 企业成员：用户与企业的绑定关系。职位管理、投递查看、候选人推荐和招聘方会话都以此校验归属，
+// 其他服务直接读取company_members表
+type CompanyMember struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	CompanyID uint      `json:"company_id" gorm:"not null;uniqueIndex:uk_company_member,priority:1"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:uk_company_member,priority:2;index"`
+	Role      string    `json:"role" gorm:"type:varchar(20);not null"`
+	Status    string    `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	errNotCompanyMember = errors.New("not a member of the company")
+	errMemberProtected  = errors.New("member role cannot be changed by operator")
+)
+
+// 用户在企业中的有效角色，非成员或已停用返回空串
+func memberRole(userID, companyID uint) (string, error) {
+	if userID == 0 || companyID == 0 {
+		return "", nil
+	}
+	var member CompanyMember
+	err := db.Where("company_id = ? AND user_id = ? AND status = ?", companyID, userID, memberActive).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// 职位所属企业
+func jobCompanyID(jobID uint64) (uint, error) {
+	var companyID uint
+	result := db.Table("jobs").Select("company_id").Where("id = ? AND deleted_at IS NULL", jobID).Limit(1).Scan(&companyID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errJobNotFound
+	}
+	return companyID, nil
+}
+
+// 网关认证后写入的用户ID
+func requestUserID(c *gin.Context) uint {
+	userID, _ := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 64)
+	return uint(userID)
+}
+
+// 校验当前用户是职位所属企业的成员，失败时已写入响应
+func authorizeJob(c *gin.Context, jobID uint64) (userID uint, ok bool) {
+	userID = requestUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"data": gin.H{},
+			"msg":  "用户未认证",
+		})
+		return 0, false
+	}
+	companyID, err := jobCompanyID(jobID)
+	if err == nil {
+		var role string
+		role, err = memberRole(userID, companyID)
+		if err == nil && role == "" {
+			err = errNotCompanyMember
+		}
+	}
+	switch {
+	case err == nil:
+		return userID, true
+	case err == errJobNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"data": gin.H{},
+			"msg":  "职位不存在",
+		})
+	case err == errNotCompanyMember:
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"data": gin.H{},
+			"msg":  "无权操作该企业的职位",
+		})
+	default:
+		log.Printf("Failed to authorize job %d for user %d: %v", jobID, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"data": gin.H{},
+			"msg":  "权限校验失败",
+		})
+	}
+	return 0, false
+}
+
+// 校验当前用户是企业的所有者或管理员，失败时已写入响应
+func authorizeCompanyAdmin(c *gin.Context, companyID uint) (userID uint, role string, ok bool) {
+	userID = requestUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 401,
+			"data": gin.H{},
+			"msg":  "用户未认证",
+		})
+		return 0, "", false
+	}
+	role, err := memberRole(userID, companyID)
+	if err != nil {
+		log.Printf("Failed to load member role of user %d in company %d: %v", userID, companyID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"data": gin.H{},
+			"msg":  "权限校验失败",
+		})
+		return 0, "", false
+	}
+	if role != memberOwner && role != memberAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"data": gin.H{},
+			"msg":  "仅企业管理员可管理成员",
+		})
+		return 0, "", false
+	}
+	return userID, role, true
+}
+
+// 企业成员列表
+func listCompanyMembers(c *gin.Context) {
+	companyID, _ := strconv.ParseUint(c.Query("companyId"), 10, 64)
+	if _, _, ok := authorizeCompanyAdmin(c, uint(companyID)); !ok {
+		return
+	}
+	var members []CompanyMember
+	if err := db.Where("company_id = ?", companyID).Order("id ASC").Find(&members).Error; err != nil {
+		log.Printf("Failed to list members of company %d: %v", companyID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"data": gin.H{},
+			"msg":  "获取企业成员失败",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": members,
+		"msg":  "success",
+	})
+}
+
+// 添加或恢复企业成员。管理员只能添加招聘方，所有者可以添加管理员
+func addCompanyMember(c *gin.Context) {
+	var req struct {
+		CompanyID uint   `json:"companyId" binding:"required"`
+		UserID    uint   `json:"userId" binding:"required"`
+		Role      string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"data": gin.H{},
+			"msg":  "请求参数无效",
+		})
+		return
+	}
+	if req.Role == "" {
+		req.Role = memberRecruiter
+	}
+	operatorID, operatorRole, ok := authorizeCompanyAdmin(c, req.CompanyID)
+	if !ok {
+		return
+	}
+	if req.Role != memberRecruiter && (req.Role != memberAdmin || operatorRole != memberOwner) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code": 403,
+			"data": gin.H{},
+			"msg":  "无权授予该角色",
+		})
+		return
+	}
+
+	member := CompanyMember{CompanyID: req.CompanyID, UserID: req.UserID}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(&member).FirstOrInit(&member).Error; err != nil {
+			return err
+		}
+		// 所有者不能修改，管理员只能由所有者修改
+		if member.Role == memberOwner || (member.Role == memberAdmin && operatorRole != memberOwner) {
+			return errMemberProtected
+		}
+		member.Role, member.Status = req.Role, memberActive
+		if member.ID == 0 {
+			member.CreatedBy = operatorID
+		}
+		return tx.Save(&member).Error
+	})
+	if err == errMemberProtected {
+		c.JSON(http.StatusConflict, gin.H{
+			"code": 409,
+			"data": gin.H{},
+			"msg":  "无权修改该成员",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to add member %d to company %d: %v", req.UserID, req.CompanyID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"data": gin.H{},
+			"msg":  "添加企业成员失败",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": member,
+		"msg":  "企业成员添加成功",
+	})
+}
+
+// 停用企业成员，停用后立即失去职位和会话权限
+func removeCompanyMember(c *gin.Context) {
+	companyID, _ := strconv.ParseUint(c.Query("companyId"), 10, 64)
+	userID, _ := strconv.ParseUint(c.Param("userId"), 10, 64)
+	_, operatorRole, ok := authorizeCompanyAdmin(c, uint(companyID))
+	if !ok {
+		return
+	}
+
+	// 所有者不能被停用，管理员只能由所有者停用
+	roles := []string{memberRecruiter}
+	if operatorRole == memberOwner {
+		roles = append(roles, memberAdmin)
+	}
+	result := db.Model(&CompanyMember{}).
+		Where("company_id = ? AND user_id = ? AND role IN ?", companyID, userID, roles).
+		Update("status", memberDisabled)
+	if result.Error != nil {
+		log.Printf("Failed to remove member %d from company %d: %v", userID, companyID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"data": gin.H{},
+			"msg":  "停用企业成员失败",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"data": gin.H{},
+			"msg":  "企业成员不存在或无权停用",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{"removed": true, "userId": userID},
+		"msg":  "企业成员已停用",
+	})
+}
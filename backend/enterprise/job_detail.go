@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 职位详情中返回的列
+type jobDetail struct {
+	ID           uint      `json:"id"`
+	CompanyID    uint      `json:"company_id"`
+	Title        string    `json:"title"`
+	Location     string    `json:"location"`
+	SalaryMin    int       `json:"salary_min"`
+	SalaryMax    int       `json:"salary_max"`
+	SalaryType   string    `json:"salary_type"`
+	Description  string    `json:"description"`
+	Requirements string    `json:"requirements"`
+	Benefits     string    `json:"benefits"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"-"`
+}
+
+// 获取职位详情：已发布或招聘中的职位公开，其他状态只有职位所属企业的成员可以查看
+func getJobDetail(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("jobId"), 10, 64)
+	if err != nil || jobID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"data": gin.H{},
+			"msg":  "职位ID无效",
+		})
+		return
+	}
+
+	var job jobDetail
+	err = db.Table("jobs").
+		Select("id, company_id, title, location, salary_min, salary_max, salary_type, description, requirements, benefits, status, created_at").
+		Where("id = ? AND deleted_at IS NULL", jobID).Take(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": 404,
+			"data": gin.H{},
+			"msg":  "职位不存在",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load job %d: %v", jobID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"data": gin.H{},
+			"msg":  "获取职位详情失败",
+		})
+		return
+	}
+	if job.Status != "published" && job.Status != "active" {
+		if _, ok := authorizeJob(c, jobID); !ok {
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"id":           job.ID,
+			"company_id":   job.CompanyID,
+			"title":        job.Title,
+			"location":     job.Location,
+			"salary":       formatSalary(job.SalaryMin, job.SalaryMax),
+			"salary_min":   job.SalaryMin,
+			"salary_max":   job.SalaryMax,
+			"salary_type":  job.SalaryType,
+			"description":  job.Description,
+			"requirements": job.Requirements,
+			"benefits":     job.Benefits,
+			"status":       job.Status,
+			"createTime":   job.CreatedAt.Format("2006-01-02 15:04:05"),
+		},
+		"msg": "success",
+	})
+}
+
+// 薪资范围展示为15k-25k，未填写时为面议
+func formatSalary(min, max int) string {
+	switch {
+	case min > 0 && max > 0:
+		return fmt.Sprintf("%dk-%dk", min/1000, max/1000)
+	case min > 0:
+		return fmt.Sprintf("%dk以上", min/1000)
+	case max > 0:
+		return fmt.Sprintf("%dk以内", max/1000)
+	}
+	return "面议"
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"resume-centre/shared/infrastructure/points"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 职位置顶时长
+const jobPinDuration = 24 * time.Hour
+
+// 职位置顶记录，职位列表按未到期的置顶记录排在前面
+type JobPin struct {
+	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	JobID         uint      `json:"job_id" gorm:"not null;index"`
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	ReservationID string    `json:"reservation_id" gorm:"type:varchar(36);uniqueIndex"`
+	PinnedAt      time.Time `json:"pinned_at"`
+	PinnedUntil   time.Time `json:"pinned_until" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// 职位置顶（消耗一张职位置顶券，已置顶的职位顺延24小时）
+func pinJob(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("jobId"), 10, 64)
+	if err != nil || jobID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"data": gin.H{},
+			"msg":  "职位ID无效",
+		})
+		return
+	}
+
+	// 只有职位所属企业的成员可以置顶
+	userID, ok := authorizeJob(c, jobID)
+	if !ok {
+		return
+	}
+
+	// 每次置顶使用独立的引用ID，避免与历史置顶记录重复
+	referenceID := c.Param("jobId") + ":" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	reservation, err := pointsClient.Reserve(userID, points.EntitlementJobPin, referenceID)
+	if err != nil {
+		if err == points.ErrEntitlementUnavailable {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"code": 402,
+				"data": gin.H{},
+				"msg":  "职位置顶券不足",
+			})
+			return
+		}
+		log.Printf("Failed to reserve entitlement: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code": 503,
+			"data": gin.H{},
+			"msg":  "权益服务暂不可用",
+		})
+		return
+	}
+
+	pin, err := createJobPin(uint(jobID), userID, reservation.ID)
+	if err != nil {
+		log.Printf("Failed to create job pin: %v", err)
+		if rbErr := pointsClient.Rollback(reservation.ID); rbErr != nil {
+			log.Printf("Failed to rollback entitlement %s: %v", reservation.ID, rbErr)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 500,
+			"data": gin.H{},
+			"msg":  "职位置顶失败",
+		})
+		return
+	}
+
+	if err := pointsClient.Commit(reservation.ID); err != nil {
+		log.Printf("Failed to commit entitlement %s: %v", reservation.ID, err)
+		db.Delete(pin)
+		if rbErr := pointsClient.Rollback(reservation.ID); rbErr != nil {
+			log.Printf("Failed to rollback entitlement %s: %v", reservation.ID, rbErr)
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code": 503,
+			"data": gin.H{},
+			"msg":  "权益服务暂不可用",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"pinned":      true,
+			"id":          jobID,
+			"pinnedUntil": pin.PinnedUntil.Format("2006-01-02 15:04:05"),
+		},
+		"msg": "职位置顶成功",
+	})
+}
+
+// 创建置顶记录：已置顶的职位在当前置顶到期时间基础上顺延。
+// 锁住职位行串行化同一职位的置顶，避免并发置顶从同一到期时间顺延而少算一次
+func createJobPin(jobID, userID uint, reservationID string) (*JobPin, error) {
+	pin := &JobPin{JobID: jobID, UserID: userID, ReservationID: reservationID}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked struct{ ID uint }
+		if err := tx.Table("jobs").Select("id").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", jobID).Take(&locked).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		start := now
+		var current JobPin
+		err := tx.Where("job_id = ? AND pinned_until > ?", jobID, now).
+			Order("pinned_until DESC").First(&current).Error
+		if err == nil {
+			start = current.PinnedUntil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		pin.PinnedAt, pin.PinnedUntil = now, start.Add(jobPinDuration)
+		return tx.Create(pin).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
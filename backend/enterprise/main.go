@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -27,6 +28,7 @@ var (
 func main() {
 	// 加载配置
 	loadConfig()
+	initPointsClient()
 
 	// 初始化数据库连接
 	if err := initDatabase(); err != nil {
@@ -75,6 +77,9 @@ func loadConfig() {
 	viper.SetDefault("database.name", "jobfirst")
 	viper.SetDefault("consul.address", "localhost:8202")
 	viper.SetDefault("redis.address", "localhost:8201")
+	viper.SetDefault("points.service_url", "http://localhost:9004")
+	viper.SetDefault("ai.service_url", "http://localhost:8089")
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
 
 	// 从环境变量读取
 	viper.AutomaticEnv()
@@ -100,6 +105,11 @@ func initDatabase() error {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 
+	// 自动迁移
+	if err := db.AutoMigrate(&JobPin{}, &CompanyMember{}); err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
 	log.Printf("Successfully connected to database")
 	return nil
 }
@@ -408,6 +418,10 @@ func setupRouter() *gin.Engine {
 					"msg": "success",
 				})
 			})
+
+			company.GET("/members", listCompanyMembers)
+			company.POST("/members", addCompanyMember)
+			company.DELETE("/members/:userId", removeCompanyMember)
 		}
 
 		// 用户相关API - 白名单路径
@@ -534,25 +548,7 @@ func setupRouter() *gin.Engine {
 				})
 			})
 
-			job.GET("/detail/:jobId", func(c *gin.Context) {
-				jobId := c.Param("jobId")
-				c.JSON(http.StatusOK, gin.H{
-					"code": 0,
-					"data": gin.H{
-						"id":           jobId,
-						"title":        "软件工程师",
-						"department":   "技术部",
-						"location":     "北京",
-						"salary":       "15k-25k",
-						"description":  "负责公司核心产品的开发...",
-						"requirements": "3年以上经验，熟悉Go语言...",
-						"benefits":     "五险一金，年终奖，带薪年假...",
-						"status":       "active",
-						"createTime":   "2025-08-30 10:00:00",
-					},
-					"msg": "success",
-				})
-			})
+			job.GET("/detail/:jobId", getJobDetail)
 
 			job.POST("/publish/:jobId", func(c *gin.Context) {
 				jobId := c.Param("jobId")
@@ -571,6 +567,8 @@ func setupRouter() *gin.Engine {
 					"msg":  "职位暂停成功",
 				})
 			})
+
+			job.POST("/pin/:jobId", pinJob)
 		}
 
 		// 简历管理相关API
@@ -654,6 +652,11 @@ func setupRouter() *gin.Engine {
 		application := enterprise.Group("/application")
 		{
 			application.GET("/list", func(c *gin.Context) {
+				// 指定职位时返回真实投递及简历匹配度
+				if jobID, err := strconv.ParseUint(c.Query("jobId"), 10, 64); err == nil && jobID > 0 {
+					listJobApplications(c, jobID)
+					return
+				}
 				c.JSON(http.StatusOK, gin.H{
 					"code": 0,
 					"data": []gin.H{
@@ -8,6 +8,7 @@ require (
 	github.com/spf13/viper v1.17.0
 	gorm.io/driver/mysql v1.5.2
 	gorm.io/gorm v1.25.5
+	resume-centre/common v0.0.0
 	resume-centre/shared/infrastructure v0.0.0
 )
 
@@ -77,3 +78,5 @@ require (
 )
 
 replace resume-centre/shared/infrastructure => ../shared/infrastructure
+
+replace resume-centre/common => ../common
@@ -665,30 +665,10 @@ func setupRouter() *gin.Engine {
 		// 工具相关开放API
 		tools := open.Group("/tools")
 		{
-			tools.GET("/salary-calculator", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{
-					"code": 0,
-					"data": gin.H{
-						"minSalary": 5000,
-						"maxSalary": 50000,
-						"avgSalary": 15000,
-						"currency":  "CNY",
-					},
-					"msg": "success",
-				})
-			})
-
-			tools.GET("/skill-analysis", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{
-					"code": 0,
-					"data": []gin.H{
-						{"skill": "Go", "demand": "high", "salary": "20k-35k"},
-						{"skill": "Java", "demand": "high", "salary": "18k-30k"},
-						{"skill": "Python", "demand": "medium", "salary": "15k-25k"},
-					},
-					"msg": "success",
-				})
-			})
+			// 薪资估算与技能需求，基于在招职位的薪资数据
+			tools.GET("/salary-calculator", salaryCalculator)
+			tools.GET("/salary-bands", salaryBands)
+			tools.GET("/skill-analysis", skillAnalysis)
 
 			tools.POST("/resume-parse", func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,273 @@
+// Package salary 基于在招职位薪资范围的薪资估算：统一折算为月薪，按职位、城市、经验和技能给出分位数区间，
+// 样本量决定置信度；样本或公司数不足、单一公司占比过高时不返回分位数，避免泄露某家公司的薪资。
+// 对外的样本数按档位取整、薪资取整到千元，相差一个职位的两次查询无法差分出该职位的薪资
+package salary
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Posting 一个职位的薪资样本，Monthly为折算后的月薪中位点
+type Posting struct {
+	Company    string // 公司标识，用于公司数和占比抑制
+	Title      string
+	City       string
+	Experience string
+	Skills     []string // 标准技能ID
+	Monthly    float64
+	PostedAt   time.Time
+}
+
+// Monthly 按每月21.75个工作日、每天8小时折算为月薪，与职位推荐的折算方式一致
+func Monthly(amount int, salaryType string) float64 {
+	v := float64(amount)
+	switch strings.ToLower(salaryType) {
+	case "yearly", "annual":
+		return v / 12
+	case "daily":
+		return v * 21.75
+	case "hourly":
+		return v * 21.75 * 8
+	}
+	return v
+}
+
+// Midpoint 薪资范围折算后的月薪中位点，只填了一端时取该端；都未填写时ok为false
+func Midpoint(min, max int, salaryType string) (float64, bool) {
+	switch {
+	case min > 0 && max >= min:
+		return Monthly(min+max, salaryType) / 2, true
+	case min > 0:
+		return Monthly(min, salaryType), true
+	case max > 0:
+		return Monthly(max, salaryType), true
+	}
+	return 0, false
+}
+
+// NormalizeCity 去掉“市”后缀和区县部分，“北京市-朝阳区”归为“北京”
+func NormalizeCity(location string) string {
+	s := strings.ToLower(strings.TrimSpace(location))
+	if i := strings.IndexAny(s, "-·/ "); i > 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, "市")
+}
+
+// Policy 最小样本抑制规则
+type Policy struct {
+	MinSamples      int     // 最少样本数
+	MinCompanies    int     // 最少公司数
+	MaxCompanyShare float64 // 单一公司样本占比上限
+}
+
+// DefaultPolicy 至少5个职位、来自3家公司，且任何一家公司不超过一半
+var DefaultPolicy = Policy{MinSamples: 5, MinCompanies: 3, MaxCompanyShare: 0.5}
+
+const (
+	// 置信度达到一半所需的样本数
+	confidenceHalfSamples = 20
+	// 对外给出的样本数按该档位向下取整
+	sampleBucket = 5
+)
+
+// Band 一组样本的月薪分位数区间。Suppressed时不返回分位数
+type Band struct {
+	Key             string  `json:"key,omitempty"`
+	Samples         int     `json:"samples"` // 按sampleBucket向下取整
+	Companies       int     `json:"companies"`
+	P10             float64 `json:"p10,omitempty"`
+	P25             float64 `json:"p25,omitempty"`
+	P50             float64 `json:"p50,omitempty"`
+	P75             float64 `json:"p75,omitempty"`
+	P90             float64 `json:"p90,omitempty"`
+	Mean            float64 `json:"mean,omitempty"`
+	Confidence      float64 `json:"confidence"`
+	ConfidenceLevel string  `json:"confidence_level"`
+	Suppressed      bool    `json:"suppressed"`
+	Reason          string  `json:"reason,omitempty"`
+}
+
+// Query 估算条件，空字段不参与过滤。Title按包含匹配，Skill为标准技能ID
+type Query struct {
+	Title      string `json:"title,omitempty"`
+	City       string `json:"city,omitempty"`
+	Experience string `json:"experience,omitempty"`
+	Skill      string `json:"skill,omitempty"`
+}
+
+// Matches 样本是否满足条件
+func (q Query) Matches(p Posting) bool {
+	if q.Title != "" && !strings.Contains(strings.ToLower(p.Title), strings.ToLower(strings.TrimSpace(q.Title))) {
+		return false
+	}
+	if q.City != "" && NormalizeCity(p.City) != NormalizeCity(q.City) {
+		return false
+	}
+	if q.Experience != "" && !strings.EqualFold(p.Experience, q.Experience) {
+		return false
+	}
+	if q.Skill != "" && !contains(p.Skills, q.Skill) {
+		return false
+	}
+	return true
+}
+
+// Estimate 满足条件的样本的薪资区间
+func Estimate(postings []Posting, q Query, policy Policy) Band {
+	var matched []Posting
+	for _, p := range postings {
+		if q.Matches(p) {
+			matched = append(matched, p)
+		}
+	}
+	return NewBand("", matched, policy)
+}
+
+// NewBand 计算分位数区间并按规则抑制
+func NewBand(key string, postings []Posting, policy Policy) Band {
+	n := len(postings)
+	b := Band{Key: key, Samples: CoarseCount(n)}
+	perCompany := make(map[string]int)
+	for _, p := range postings {
+		perCompany[p.Company]++
+	}
+	b.Companies = len(perCompany)
+	b.Confidence = math.Round(float64(b.Samples)/float64(b.Samples+confidenceHalfSamples)*100) / 100
+	b.ConfidenceLevel = confidenceLevel(b.Confidence)
+
+	largest := 0
+	for _, n := range perCompany {
+		largest = max(largest, n)
+	}
+	switch {
+	case n < policy.MinSamples:
+		b.Suppressed, b.Reason = true, "样本不足"
+	case b.Companies < policy.MinCompanies:
+		b.Suppressed, b.Reason = true, "来源公司不足"
+	case float64(largest) > policy.MaxCompanyShare*float64(n):
+		b.Suppressed, b.Reason = true, "单一公司样本占比过高"
+	}
+	if b.Suppressed {
+		return b
+	}
+
+	values := make([]float64, n)
+	sum := 0.0
+	for i, p := range postings {
+		values[i] = p.Monthly
+		sum += p.Monthly
+	}
+	sort.Float64s(values)
+	b.P10 = roundSalary(percentile(values, 0.10))
+	b.P25 = roundSalary(percentile(values, 0.25))
+	b.P50 = roundSalary(percentile(values, 0.50))
+	b.P75 = roundSalary(percentile(values, 0.75))
+	b.P90 = roundSalary(percentile(values, 0.90))
+	b.Mean = roundSalary(sum / float64(n))
+	return b
+}
+
+// Dimension 分组维度
+type Dimension string
+
+const (
+	ByTitle      Dimension = "title"
+	ByCity       Dimension = "city"
+	ByExperience Dimension = "experience"
+	BySkill      Dimension = "skill"
+)
+
+// ValidDimension 是否支持的分组维度
+func ValidDimension(d Dimension) bool {
+	return d == ByTitle || d == ByCity || d == ByExperience || d == BySkill
+}
+
+// Breakdown 满足条件的样本按维度分组后的薪资区间，按样本数从多到少排列，被抑制的分组也保留以便说明原因
+func Breakdown(postings []Posting, q Query, dim Dimension, policy Policy, limit int) []Band {
+	groups := make(map[string][]Posting)
+	for _, p := range postings {
+		if !q.Matches(p) {
+			continue
+		}
+		for _, key := range groupKeys(p, dim) {
+			groups[key] = append(groups[key], p)
+		}
+	}
+
+	bands := make([]Band, 0, len(groups))
+	for key, list := range groups {
+		bands = append(bands, NewBand(key, list, policy))
+	}
+	sort.Slice(bands, func(i, j int) bool {
+		if bands[i].Samples != bands[j].Samples {
+			return bands[i].Samples > bands[j].Samples
+		}
+		return bands[i].Key < bands[j].Key
+	})
+	if limit > 0 && len(bands) > limit {
+		bands = bands[:limit]
+	}
+	return bands
+}
+
+func groupKeys(p Posting, dim Dimension) []string {
+	var key string
+	switch dim {
+	case ByTitle:
+		key = strings.ToLower(strings.TrimSpace(p.Title))
+	case ByCity:
+		key = NormalizeCity(p.City)
+	case ByExperience:
+		key = strings.ToLower(p.Experience)
+	case BySkill:
+		return p.Skills
+	}
+	if key == "" {
+		return nil
+	}
+	return []string{key}
+}
+
+// percentile 已排序样本的线性插值分位数
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// roundSalary 取整到千元，避免暴露精确数值，也让差分查询反推的薪资误差随样本数放大
+func roundSalary(v float64) float64 {
+	return math.Round(v/1000) * 1000
+}
+
+// CoarseCount 对外给出的样本数，向下取整到sampleBucket的倍数，不足一档时为0
+func CoarseCount(n int) int {
+	return n / sampleBucket * sampleBucket
+}
+
+func confidenceLevel(c float64) string {
+	switch {
+	case c >= 0.7:
+		return "high"
+	case c >= 0.4:
+		return "medium"
+	}
+	return "low"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package salary
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC)
+
+func posting(company, city string, monthly float64, monthsAgo int, skills ...string) Posting {
+	return Posting{
+		Company:    company,
+		Title:      "Go后端工程师",
+		City:       city,
+		Experience: "mid",
+		Skills:     skills,
+		Monthly:    monthly,
+		PostedAt:   now.AddDate(0, -monthsAgo, 0),
+	}
+}
+
+func TestMonthly(t *testing.T) {
+	cases := []struct {
+		min, max int
+		typ      string
+		want     float64
+	}{
+		{20000, 30000, "monthly", 25000},
+		{240000, 360000, "yearly", 25000},
+		{100, 100, "hourly", 17400},
+		{0, 18000, "monthly", 18000},
+		{15000, 0, "", 15000},
+	}
+	for _, c := range cases {
+		got, ok := Midpoint(c.min, c.max, c.typ)
+		if !ok || got != c.want {
+			t.Errorf("Midpoint(%d, %d, %q) = %v, %v; want %v", c.min, c.max, c.typ, got, ok, c.want)
+		}
+	}
+	if _, ok := Midpoint(0, 0, "monthly"); ok {
+		t.Error("empty range should be skipped")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	postings := []Posting{
+		posting("a", "北京市", 20000, 0, "go"),
+		posting("a", "北京", 22000, 0, "go", "kubernetes"),
+		posting("b", "北京-朝阳区", 25000, 1, "go"),
+		posting("c", "北京", 30000, 1, "go", "kubernetes"),
+		posting("d", "北京", 35000, 2, "go"),
+		posting("e", "上海", 28000, 0, "java"),
+	}
+
+	b := Estimate(postings, Query{City: "北京", Skill: "go"}, DefaultPolicy)
+	if b.Suppressed || b.Samples != 5 || b.Companies != 4 {
+		t.Fatalf("unexpected band %+v", b)
+	}
+	if b.P50 != 25000 || b.P25 != 22000 || b.P75 != 30000 || b.P10 != 21000 || b.Mean != 26000 {
+		t.Errorf("unexpected percentiles %+v", b)
+	}
+	if b.Confidence != 0.2 || b.ConfidenceLevel != "low" {
+		t.Errorf("confidence = %v %s, want 0.2 low", b.Confidence, b.ConfidenceLevel)
+	}
+
+	// 多一个职位时样本数仍在同一档，均值差分不出该职位的薪资
+	more := append(postings[:5:5], posting("f", "北京", 26000, 0, "go"))
+	if m := Estimate(more, Query{City: "北京", Skill: "go"}, DefaultPolicy); m.Samples != b.Samples || m.Mean != b.Mean || m.Confidence != b.Confidence {
+		t.Errorf("band with one more posting %+v differs from %+v", m, b)
+	}
+
+	// 样本、公司数不足或一家公司占多数时不返回分位数
+	cases := map[string]struct {
+		postings []Posting
+		reason   string
+	}{
+		"few samples": {postings[:4], "样本不足"},
+		"few companies": {[]Posting{
+			posting("a", "北京", 1, 0), posting("a", "北京", 1, 0), posting("b", "北京", 1, 0),
+			posting("b", "北京", 1, 0), posting("b", "北京", 1, 0),
+		}, "来源公司不足"},
+		"dominant company": {[]Posting{
+			posting("a", "北京", 1, 0), posting("a", "北京", 1, 0), posting("a", "北京", 1, 0),
+			posting("b", "北京", 1, 0), posting("c", "北京", 1, 0),
+		}, "单一公司样本占比过高"},
+	}
+	for name, c := range cases {
+		b := NewBand("", c.postings, DefaultPolicy)
+		if !b.Suppressed || b.Reason != c.reason || b.P50 != 0 || b.Mean != 0 {
+			t.Errorf("%s: band %+v, want suppressed with %q", name, b, c.reason)
+		}
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	var postings []Posting
+	for i, company := range []string{"a", "b", "c", "d", "e", "f"} {
+		postings = append(postings, posting(company, "北京", float64(20000+i*1000), 0, "go"))
+	}
+	postings = append(postings, posting("a", "上海", 30000, 0, "go"), posting("b", "上海", 31000, 0, "go"))
+
+	bands := Breakdown(postings, Query{}, ByCity, DefaultPolicy, 0)
+	if len(bands) != 2 || bands[0].Key != "北京" || bands[1].Key != "上海" {
+		t.Fatalf("unexpected bands %+v", bands)
+	}
+	if bands[0].Suppressed || bands[0].Samples != 5 || bands[0].P50 != 23000 {
+		t.Errorf("北京 band = %+v", bands[0])
+	}
+	if !bands[1].Suppressed {
+		t.Errorf("上海 band should be suppressed: %+v", bands[1])
+	}
+	if got := Breakdown(postings, Query{}, ByCity, DefaultPolicy, 1); len(got) != 1 {
+		t.Errorf("limit not applied: %d bands", len(got))
+	}
+}
+
+func TestSkillTrends(t *testing.T) {
+	postings := []Posting{
+		posting("a", "北京", 20000, 2, "go"),
+		posting("b", "北京", 20000, 2, "java"),
+		posting("c", "北京", 20000, 1, "go"),
+		posting("d", "北京", 20000, 0, "go", "kubernetes"),
+		posting("e", "北京", 20000, 0, "go"),
+		posting("f", "北京", 20000, 12, "go"), // 超出统计窗口
+	}
+	trends := SkillTrends(postings, now, 3, 2, DefaultPolicy)
+	// 不足一档的数量对外为0
+	if len(trends) != 2 || trends[0].Skill != "go" || trends[0].Postings != 0 {
+		t.Fatalf("unexpected trends %+v", trends)
+	}
+	goTrend := trends[0]
+	if len(goTrend.Points) != 3 || goTrend.Points[0].Month != "2025-07" || goTrend.Points[2].Month != "2025-09" {
+		t.Fatalf("unexpected points %+v", goTrend.Points)
+	}
+	if goTrend.Points[0].Share != 0.5 || goTrend.Points[2].Share != 1 || goTrend.Direction != "rising" || goTrend.Change != 0.5 {
+		t.Errorf("unexpected go trend %+v", goTrend)
+	}
+	if goTrend.Share != 0.8 || !goTrend.Salary.Suppressed {
+		t.Errorf("go share %v, salary %+v", goTrend.Share, goTrend.Salary)
+	}
+}
@@ -0,0 +1,103 @@
+package salary
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// 需求占比变化超过该值视为上升或下降
+const trendThreshold = 0.02
+
+// TrendPoint 某月要求该技能的职位数及占当月职位的比例
+type TrendPoint struct {
+	Month    string  `json:"month"`
+	Postings int     `json:"postings"` // 按sampleBucket向下取整
+	Share    float64 `json:"share"`
+}
+
+// Trend 技能需求趋势
+type Trend struct {
+	Skill     string       `json:"skill"`
+	Postings  int          `json:"postings"` // 按sampleBucket向下取整，与薪资区间的样本数一致
+	Share     float64      `json:"share"`
+	Change    float64      `json:"change"`    // 最后一个月与第一个月需求占比之差
+	Direction string       `json:"direction"` // rising、falling、stable
+	Points    []TrendPoint `json:"points"`
+	Salary    Band         `json:"salary"`
+}
+
+// SkillTrends 最近months个自然月（含当月）各技能的需求趋势，按总需求量取前limit个
+func SkillTrends(postings []Posting, now time.Time, months, limit int, policy Policy) []Trend {
+	if months <= 0 {
+		months = 6
+	}
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -(months - 1), 0)
+	labels := make([]string, months)
+	index := make(map[string]int, months)
+	for i := range labels {
+		labels[i] = start.AddDate(0, i, 0).Format("2006-01")
+		index[labels[i]] = i
+	}
+
+	monthTotals := make([]int, months)
+	counts := make(map[string][]int)
+	bySkill := make(map[string][]Posting)
+	total := 0
+	for _, p := range postings {
+		i, ok := index[p.PostedAt.In(now.Location()).Format("2006-01")]
+		if !ok {
+			continue
+		}
+		total++
+		monthTotals[i]++
+		for _, skill := range p.Skills {
+			if counts[skill] == nil {
+				counts[skill] = make([]int, months)
+			}
+			counts[skill][i]++
+			bySkill[skill] = append(bySkill[skill], p)
+		}
+	}
+
+	trends := make([]Trend, 0, len(counts))
+	for skill, perMonth := range counts {
+		t := Trend{Skill: skill, Points: make([]TrendPoint, months), Postings: len(bySkill[skill])}
+		for i, n := range perMonth {
+			t.Points[i] = TrendPoint{Month: labels[i], Postings: CoarseCount(n), Share: share(n, monthTotals[i])}
+		}
+		t.Share = share(t.Postings, total)
+		t.Change = math.Round((t.Points[months-1].Share-t.Points[0].Share)*1000) / 1000
+		switch {
+		case t.Change > trendThreshold:
+			t.Direction = "rising"
+		case t.Change < -trendThreshold:
+			t.Direction = "falling"
+		default:
+			t.Direction = "stable"
+		}
+		t.Salary = NewBand("", bySkill[skill], policy)
+		trends = append(trends, t)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Postings != trends[j].Postings {
+			return trends[i].Postings > trends[j].Postings
+		}
+		return trends[i].Skill < trends[j].Skill
+	})
+	if limit > 0 && len(trends) > limit {
+		trends = trends[:limit]
+	}
+	// 排序用精确数量，对外只给档位
+	for i := range trends {
+		trends[i].Postings = CoarseCount(trends[i].Postings)
+	}
+	return trends
+}
+
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(total)*1000) / 1000
+}
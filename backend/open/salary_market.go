@@ -0,0 +1,245 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"resume-centre/common/skills"
+	"resume-centre/open/salary"
+)
+
+const (
+	// 薪资样本取最近一年发布的职位
+	salaryWindow = 365 * 24 * time.Hour
+	// 样本在内存中缓存的时间
+	salaryCacheTTL = 10 * time.Minute
+)
+
+// jobSalaryRow 职位表中参与薪资估算的列
+type jobSalaryRow struct {
+	CompanyID       *uint
+	CompanyName     string
+	Title           string
+	Location        string
+	ExperienceLevel string
+	Skills          string
+	SalaryMin       int
+	SalaryMax       int
+	SalaryType      string
+	CreatedAt       time.Time
+}
+
+// salaryMarket 缓存的薪资样本
+type salaryMarket struct {
+	mu       sync.Mutex
+	postings []salary.Posting
+	loadedAt time.Time
+}
+
+var market salaryMarket
+
+// load 返回缓存的样本，过期后从在招职位重新读取
+func (m *salaryMarket) load() ([]salary.Posting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.postings != nil && time.Since(m.loadedAt) < salaryCacheTTL {
+		return m.postings, nil
+	}
+
+	var rows []jobSalaryRow
+	if err := db.Table("jobs").
+		Select("company_id, company_name, title, location, experience_level, skills, salary_min, salary_max, salary_type, created_at").
+		Where("status IN ? AND deleted_at IS NULL AND created_at >= ?", []string{"active", "published"}, time.Now().Add(-salaryWindow)).
+		Where("(salary_min > 0 OR salary_max > 0)").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	dict := skills.Default()
+	postings := make([]salary.Posting, 0, len(rows))
+	for _, r := range rows {
+		monthly, ok := salary.Midpoint(r.SalaryMin, r.SalaryMax, r.SalaryType)
+		if !ok {
+			continue
+		}
+		// 没有公司ID的职位按公司名区分来源
+		company := "name:" + strings.TrimSpace(r.CompanyName)
+		if r.CompanyID != nil {
+			company = strconv.FormatUint(uint64(*r.CompanyID), 10)
+		}
+		reqs := dict.Parse(r.Skills)
+		ids := make([]string, len(reqs))
+		for i, req := range reqs {
+			ids[i] = req.ID
+		}
+		postings = append(postings, salary.Posting{
+			Company:    company,
+			Title:      r.Title,
+			City:       r.Location,
+			Experience: r.ExperienceLevel,
+			Skills:     ids,
+			Monthly:    monthly,
+			PostedAt:   r.CreatedAt,
+		})
+	}
+	m.postings, m.loadedAt = postings, time.Now()
+	return postings, nil
+}
+
+// salaryQuery 从查询参数读取估算条件，技能解析为标准ID
+func salaryQuery(c *gin.Context) salary.Query {
+	q := salary.Query{
+		Title:      strings.TrimSpace(c.Query("title")),
+		City:       strings.TrimSpace(c.Query("city")),
+		Experience: strings.TrimSpace(c.Query("experience")),
+	}
+	if skill := strings.TrimSpace(c.Query("skill")); skill != "" {
+		q.Skill = skills.Default().ID(skill)
+	}
+	return q
+}
+
+// skillDisplayName 技能ID对应的展示名
+func skillDisplayName(id string) string {
+	if s, ok := skills.Default().Get(id); ok {
+		return s.Name
+	}
+	return id
+}
+
+func queryInt(c *gin.Context, key string, def, max int) int {
+	n, err := strconv.Atoi(c.Query(key))
+	if err != nil || n <= 0 {
+		return def
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// salaryLoadFailed 数据库错误只记日志，不返回给开放接口的调用方
+func salaryLoadFailed(c *gin.Context, err error) {
+	log.Printf("Failed to load salary postings: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"code": 500,
+		"msg":  "加载薪资数据失败",
+	})
+}
+
+// salaryCalculator 按职位、城市、经验和技能估算月薪区间
+func salaryCalculator(c *gin.Context) {
+	postings, err := market.load()
+	if err != nil {
+		salaryLoadFailed(c, err)
+		return
+	}
+	q := salaryQuery(c)
+	band := salary.Estimate(postings, q, salary.DefaultPolicy)
+
+	data := gin.H{
+		"query":    q,
+		"estimate": band,
+		"currency": "CNY",
+		"period":   "monthly",
+	}
+	if !band.Suppressed {
+		data["minSalary"] = band.P10
+		data["maxSalary"] = band.P90
+		data["avgSalary"] = band.P50
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": data,
+		"msg":  "success",
+	})
+}
+
+// salaryBands 按维度分组的薪资区间，可叠加与计算器相同的过滤条件
+func salaryBands(c *gin.Context) {
+	dim := salary.Dimension(c.DefaultQuery("dimension", string(salary.ByCity)))
+	if !salary.ValidDimension(dim) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  "dimension must be one of title, city, experience, skill",
+		})
+		return
+	}
+	postings, err := market.load()
+	if err != nil {
+		salaryLoadFailed(c, err)
+		return
+	}
+
+	bands := salary.Breakdown(postings, salaryQuery(c), dim, salary.DefaultPolicy, queryInt(c, "limit", 20, 100))
+	if dim == salary.BySkill {
+		for i := range bands {
+			bands[i].Key = skillDisplayName(bands[i].Key)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"dimension": dim,
+			"bands":     bands,
+			"currency":  "CNY",
+			"period":    "monthly",
+		},
+		"msg": "success",
+	})
+}
+
+// skillAnalysis 技能需求趋势和对应薪资
+func skillAnalysis(c *gin.Context) {
+	postings, err := market.load()
+	if err != nil {
+		salaryLoadFailed(c, err)
+		return
+	}
+
+	trends := salary.SkillTrends(postings, time.Now(), queryInt(c, "months", 6, 12), queryInt(c, "limit", 10, 50), salary.DefaultPolicy)
+	result := make([]gin.H, len(trends))
+	for i, t := range trends {
+		item := gin.H{
+			"skill":      skillDisplayName(t.Skill),
+			"demand":     demandLevel(t.Share),
+			"postings":   t.Postings,
+			"share":      t.Share,
+			"change":     t.Change,
+			"direction":  t.Direction,
+			"points":     t.Points,
+			"salary":     "",
+			"salaryBand": t.Salary,
+		}
+		if !t.Salary.Suppressed {
+			item["salary"] = formatK(t.Salary.P25) + "-" + formatK(t.Salary.P75)
+		}
+		result[i] = item
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": result,
+		"msg":  "success",
+	})
+}
+
+// demandLevel 按要求该技能的职位占比划分需求热度
+func demandLevel(share float64) string {
+	switch {
+	case share >= 0.2:
+		return "high"
+	case share >= 0.05:
+		return "medium"
+	}
+	return "low"
+}
+
+func formatK(v float64) string {
+	return strconv.FormatFloat(v/1000, 'f', -1, 64) + "k"
+}
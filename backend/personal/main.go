@@ -575,14 +575,30 @@ func setupRouter() *gin.Engine {
 		// 聊天相关API
 		chat := personal.Group("/chat")
 		{
+			// 常用语：求职者可用的平台通用模板，由用户服务的会话模块维护
 			chat.GET("/usual", func(c *gin.Context) {
+				var replies []struct {
+					ID      uint   `json:"id"`
+					Title   string `json:"title"`
+					Content string `json:"content"`
+				}
+				if err := db.Table("chat_quick_replies").
+					Select("id, title, content").
+					Where("company_id = 0 AND role = ?", "candidate").
+					Order("sort ASC, id ASC").
+					Find(&replies).Error; err != nil {
+					log.Printf("Failed to query quick replies: %v", err)
+					c.JSON(http.StatusInternalServerError, gin.H{
+						"code": 500,
+						"msg":  "Failed to get quick replies",
+					})
+					return
+				}
+
 				c.JSON(http.StatusOK, gin.H{
 					"code": 0,
-					"data": []gin.H{
-						{"id": 1, "question": "如何写简历？", "answer": "简历应该简洁明了..."},
-						{"id": 2, "question": "面试技巧", "answer": "面试前要做好准备..."},
-					},
-					"msg": "success",
+					"data": replies,
+					"msg":  "success",
 				})
 			})
 
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 区块链服务内部锚定接口客户端
+type BlockchainClient struct {
+	BaseURL string // 如 http://localhost:9009
+	Token   string // X-Service-Token
+	Client  *http.Client
+}
+
+// 锚定结果
+type AnchorResult struct {
+	TransactionHash string `json:"transaction_hash"`
+	BlockchainType  string `json:"blockchain_type"`
+	Status          string `json:"status"`
+	Hash            string `json:"hash"`
+	BlockNumber     uint64 `json:"block_number"`
+}
+
+// 锚定检查点哈希
+func (c *BlockchainClient) Anchor(ctx context.Context, kind, id, hash string) (*AnchorResult, error) {
+	body, _ := json.Marshal(map[string]string{"kind": kind, "id": id, "hash": hash})
+	var result AnchorResult
+	if err := c.do(ctx, http.MethodPost, "/blockchain/internal/anchors", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// 读取交易锚定的哈希
+func (c *BlockchainClient) AnchoredHash(ctx context.Context, chainType, txHash string) (string, error) {
+	path := "/blockchain/internal/anchors/" + url.PathEscape(txHash) + "?blockchain_type=" + url.QueryEscape(chainType)
+	var result AnchorResult
+	if err := c.do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return "", err
+	}
+	return result.Hash, nil
+}
+
+func (c *BlockchainClient) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.BaseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.Token)
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("blockchain service unavailable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Error string          `json:"error"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid blockchain service response: %v", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("blockchain service error (%d): %s", resp.StatusCode, result.Error)
+	}
+	return json.Unmarshal(result.Data, out)
+}
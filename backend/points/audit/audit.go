@@ -0,0 +1,136 @@
+// Package audit 积分流水哈希链：每条流水包含同一用户上一条流水的哈希和
+// 全局上一条流水的哈希，任何直接修改、插入或删除数据库记录的行为都会使链断裂。
+// 全局链头定期通过区块链服务锚定，防止整体重写。
+package audit
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"resume-centre/shared/kernel/hashchain"
+)
+
+// 链的起始哈希
+var GenesisHash = hashchain.GenesisHash
+
+// 链头范围
+const GlobalScope = hashchain.GlobalScope
+
+func UserScope(userID uint) string {
+	return "user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// 断链原因
+const (
+	ReasonRecordModified     = hashchain.ReasonRecordModified
+	ReasonSequenceGap        = hashchain.ReasonSequenceGap
+	ReasonGlobalLinkBroken   = hashchain.ReasonGlobalLinkBroken
+	ReasonUserLinkBroken     = hashchain.ReasonUserLinkBroken
+	ReasonHeadMismatch       = hashchain.ReasonHeadMismatch
+	ReasonCheckpointMismatch = hashchain.ReasonCheckpointMismatch
+	ReasonAnchorMismatch     = hashchain.ReasonAnchorMismatch
+)
+
+// 参与哈希链的积分流水字段，列名与points_journals表一致
+type Record struct {
+	ID             uint      `json:"id"`
+	Seq            uint64    `json:"seq"`
+	UserID         uint      `json:"user_id"`
+	Type           string    `json:"type"`
+	Amount         int64     `json:"amount"`
+	BalanceAfter   int64     `json:"balance_after"`
+	BatchID        *uint     `json:"batch_id"`
+	Reference      string    `json:"reference"`
+	Description    string    `json:"description"`
+	CreatedAt      time.Time `json:"created_at"`
+	PrevHash       string    `json:"prev_hash"`
+	GlobalPrevHash string    `json:"global_prev_hash"`
+	Hash           string    `json:"hash"`
+}
+
+// 计算记录哈希
+func (r *Record) ComputeHash() string {
+	var batchID uint
+	if r.BatchID != nil {
+		batchID = *r.BatchID
+	}
+	return hashchain.Hash(
+		r.Seq, r.UserID, r.Type, r.Amount, r.BalanceAfter, batchID,
+		r.Reference, r.Description, r.CreatedAt.UnixMilli(),
+		r.PrevHash, r.GlobalPrevHash,
+	)
+}
+
+// 将记录接到链尾，设置序号、前序哈希和记录哈希
+func (r *Record) Link(seq uint64, userPrev, globalPrev string) {
+	// 数据库时间精度为毫秒，哈希前截断保证读回后一致
+	r.CreatedAt = r.CreatedAt.Truncate(time.Millisecond)
+	r.Seq = seq
+	r.PrevHash = userPrev
+	r.GlobalPrevHash = globalPrev
+	r.Hash = r.ComputeHash()
+}
+
+// 校验用的链记录
+func (r *Record) entry() *hashchain.Entry {
+	return &hashchain.Entry{
+		Seq:        r.Seq,
+		Hash:       r.Hash,
+		Computed:   r.ComputeHash(),
+		GlobalPrev: r.GlobalPrevHash,
+		Links:      []hashchain.Link{{Scope: UserScope(r.UserID), Prev: r.PrevHash}},
+	}
+}
+
+// 第一处断链
+type Break struct {
+	Seq      uint64 `json:"seq"`
+	RecordID uint   `json:"record_id,omitempty"`
+	UserID   uint   `json:"user_id,omitempty"`
+	Reason   string `json:"reason"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+func (b *Break) Error() string {
+	return fmt.Sprintf("chain broken at seq %d (record %d, user %d): %s, expected %s, got %s",
+		b.Seq, b.RecordID, b.UserID, b.Reason, b.Expected, b.Actual)
+}
+
+// 按序号顺序逐条校验流水
+type Verifier struct {
+	chain *hashchain.Verifier
+}
+
+// userID非0时只校验该用户的链，跳过全局链接
+func NewVerifier(userID uint) *Verifier {
+	scope := ""
+	if userID != 0 {
+		scope = UserScope(userID)
+	}
+	return &Verifier{chain: hashchain.NewVerifier(scope)}
+}
+
+// 校验下一条记录，返回断链信息
+func (v *Verifier) Add(r *Record) *Break {
+	if b := v.chain.Add(r.entry()); b != nil {
+		return &Break{Seq: b.Seq, RecordID: r.ID, UserID: r.UserID, Reason: b.Reason, Expected: b.Expected, Actual: b.Actual}
+	}
+	return nil
+}
+
+// 已校验记录数
+func (v *Verifier) Checked() int64 {
+	return v.chain.Checked()
+}
+
+// 已校验部分的链尾
+func (v *Verifier) Head() (uint64, string) {
+	return v.chain.Head()
+}
+
+// 用户链尾，无记录时为起始哈希
+func (v *Verifier) UserHead(userID uint) string {
+	return v.chain.ScopeHead(UserScope(userID))
+}
@@ -0,0 +1,200 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 构造两个用户交替写入的流水链
+func buildChain(n int) []Record {
+	records := make([]Record, 0, n)
+	global := GenesisHash
+	users := map[uint]string{}
+	start := time.Date(2026, 1, 1, 8, 0, 0, 123456789, time.UTC)
+	for i := 0; i < n; i++ {
+		userID := uint(i%2 + 1)
+		prev, ok := users[userID]
+		if !ok {
+			prev = GenesisHash
+		}
+		r := Record{
+			ID:           uint(i + 1),
+			UserID:       userID,
+			Type:         "earn",
+			Amount:       int64(10 * (i + 1)),
+			BalanceAfter: int64(100 + i),
+			Reference:    "task|1",
+			CreatedAt:    start.Add(time.Duration(i) * time.Minute),
+		}
+		r.Link(uint64(i+1), prev, global)
+		global = r.Hash
+		users[userID] = r.Hash
+		records = append(records, r)
+	}
+	return records
+}
+
+func verifyAll(userID uint, records []Record) *Break {
+	v := NewVerifier(userID)
+	for i := range records {
+		if userID != 0 && records[i].UserID != userID {
+			continue
+		}
+		if b := v.Add(&records[i]); b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+func TestVerifierAcceptsIntactChain(t *testing.T) {
+	records := buildChain(6)
+	if b := verifyAll(0, records); b != nil {
+		t.Fatalf("intact chain reported broken: %v", b)
+	}
+	if b := verifyAll(2, records); b != nil {
+		t.Fatalf("intact user chain reported broken: %v", b)
+	}
+	if records[0].CreatedAt.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Fatal("created_at should be truncated to milliseconds")
+	}
+}
+
+func TestVerifierDetectsTampering(t *testing.T) {
+	t.Run("modified amount", func(t *testing.T) {
+		records := buildChain(6)
+		records[3].Amount = 100000
+		b := verifyAll(0, records)
+		if b == nil || b.Reason != ReasonRecordModified || b.Seq != 4 {
+			t.Fatalf("unexpected result %v", b)
+		}
+	})
+
+	t.Run("deleted record", func(t *testing.T) {
+		records := buildChain(6)
+		records = append(records[:2], records[3:]...)
+		b := verifyAll(0, records)
+		if b == nil || b.Reason != ReasonSequenceGap || b.Seq != 4 {
+			t.Fatalf("unexpected result %v", b)
+		}
+	})
+
+	t.Run("rehashed record", func(t *testing.T) {
+		// 篡改后重新计算本条哈希，后续记录的前序哈希仍会断开
+		records := buildChain(6)
+		records[2].Amount = 5
+		records[2].Hash = records[2].ComputeHash()
+		b := verifyAll(0, records)
+		if b == nil || b.Reason != ReasonGlobalLinkBroken || b.Seq != 4 {
+			t.Fatalf("unexpected result %v", b)
+		}
+	})
+
+	t.Run("injected user record", func(t *testing.T) {
+		// 伪造的记录接在全局链上，但没有接在用户链上
+		records := buildChain(4)
+		forged := Record{ID: 99, UserID: 1, Type: "earn", Amount: 1000, CreatedAt: time.Now()}
+		forged.Link(5, GenesisHash, records[3].Hash)
+		records = append(records, forged)
+		b := verifyAll(0, records)
+		if b == nil || b.Reason != ReasonUserLinkBroken || b.RecordID != 99 {
+			t.Fatalf("unexpected result %v", b)
+		}
+	})
+}
+
+// 模拟区块链服务的内部锚定接口
+type anchorStub struct {
+	anchors map[string]string // 交易哈希 -> 锚定值
+}
+
+func (s *anchorStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Service-Token") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid service token"})
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/blockchain/internal/anchors":
+		var req struct{ Kind, ID, Hash string }
+		json.NewDecoder(r.Body).Decode(&req)
+		txHash := fmt.Sprintf("0x%064x", len(s.anchors)+1)
+		s.anchors[txHash] = req.Hash
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{
+			"transaction_hash": txHash, "blockchain_type": "local", "status": "pending", "hash": req.Hash,
+		}})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/blockchain/internal/anchors/"):
+		txHash := strings.TrimPrefix(r.URL.Path, "/blockchain/internal/anchors/")
+		hash, ok := s.anchors[txHash]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "transaction not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"transaction_hash": txHash, "hash": hash, "block_number": 7,
+		}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
+	}
+}
+
+func TestCheckpointAnchoring(t *testing.T) {
+	stub := &anchorStub{anchors: map[string]string{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	client := &BlockchainClient{BaseURL: server.URL + "/", Token: "secret"}
+	ctx := context.Background()
+
+	records := buildChain(6)
+	var checkpoints []Checkpoint
+	for _, seq := range []int{2, 5} {
+		cp := Checkpoint{Seq: records[seq-1].Seq, Hash: records[seq-1].Hash, Status: CheckpointPending}
+		result, err := client.Anchor(ctx, "points_audit_checkpoint", strconv.Itoa(seq), cp.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cp.Status, cp.BlockchainType, cp.TransactionHash = CheckpointAnchored, result.BlockchainType, result.TransactionHash
+		checkpoints = append(checkpoints, cp)
+	}
+	// 尚未锚定的检查点不查询链上
+	checkpoints = append(checkpoints, Checkpoint{Seq: 6, Hash: records[5].Hash, Status: CheckpointFailed})
+
+	anchors, b, err := verifyCheckpoints(ctx, checkpoints, 6, client)
+	if err != nil || b != nil || anchors != 2 {
+		t.Fatalf("intact checkpoints: anchors=%d break=%v err=%v", anchors, b, err)
+	}
+
+	// 整体重写流水和检查点表后，链上锚定值仍是原哈希
+	rewritten := append([]Checkpoint(nil), checkpoints...)
+	rewritten[1].Hash = strings.Repeat("f", 64)
+	if _, b, _ := verifyCheckpoints(ctx, rewritten, 6, client); b == nil || b.Reason != ReasonAnchorMismatch || b.Seq != 5 {
+		t.Fatalf("expected anchor mismatch at seq 5, got %v", b)
+	}
+
+	// 检查点之后的流水被截断
+	if _, b, _ := verifyCheckpoints(ctx, checkpoints, 4, client); b == nil || b.Reason != ReasonCheckpointMismatch || b.Seq != 5 {
+		t.Fatalf("expected missing checkpoint record at seq 5, got %v", b)
+	}
+
+	// 不校验锚定时只比对序号
+	if anchors, b, _ := verifyCheckpoints(ctx, rewritten, 6, nil); b != nil || anchors != 0 {
+		t.Fatalf("checkpoints without resolver: anchors=%d break=%v", anchors, b)
+	}
+
+	bad := &BlockchainClient{BaseURL: server.URL, Token: "wrong"}
+	if _, err := bad.Anchor(ctx, "points_audit_checkpoint", "1", records[0].Hash); err == nil {
+		t.Fatal("anchor with invalid service token succeeded")
+	}
+	if _, _, err := verifyCheckpoints(ctx, checkpoints, 6, bad); err == nil {
+		t.Fatal("resolver error was not returned")
+	}
+}
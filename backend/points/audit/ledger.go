@@ -0,0 +1,225 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"resume-centre/shared/kernel/hashchain"
+
+	"gorm.io/gorm"
+)
+
+// 积分流水表名
+const JournalTable = "points_journals"
+
+// 链头：全局及每个用户最后一条流水的序号和哈希
+type ChainHead struct {
+	Scope     string    `json:"scope" gorm:"primaryKey;type:varchar(64)"`
+	Seq       uint64    `json:"seq" gorm:"not null;default:0"`
+	Hash      string    `json:"hash" gorm:"type:varchar(64);not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ChainHead) TableName() string {
+	return "points_audit_heads"
+}
+
+// 检查点状态
+const (
+	CheckpointPending  = "pending"
+	CheckpointAnchored = "anchored"
+	CheckpointFailed   = "failed"
+)
+
+// 检查点：某一时刻的全局链头，通过区块链服务锚定
+type Checkpoint struct {
+	ID              uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Seq             uint64     `json:"seq" gorm:"not null;uniqueIndex"`
+	Hash            string     `json:"hash" gorm:"type:varchar(64);not null"`
+	Status          string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	BlockchainType  string     `json:"blockchain_type" gorm:"type:varchar(20)"`
+	TransactionHash string     `json:"transaction_hash" gorm:"type:varchar(66)"`
+	Error           string     `json:"error,omitempty" gorm:"type:varchar(255)"`
+	AnchoredAt      *time.Time `json:"anchored_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (Checkpoint) TableName() string {
+	return "points_audit_checkpoints"
+}
+
+// 查询检查点在区块链上锚定的哈希
+type AnchorResolver interface {
+	AnchoredHash(ctx context.Context, chainType, txHash string) (string, error)
+}
+
+// 校验选项
+type Options struct {
+	UserID    uint           // 非0时只校验该用户的链
+	Resolver  AnchorResolver // 非空时校验检查点的链上锚定值
+	BatchSize int
+}
+
+// 校验报告
+type Report struct {
+	Scope              string    `json:"scope"`
+	Valid              bool      `json:"valid"`
+	Checked            int64     `json:"checked"`
+	HeadSeq            uint64    `json:"head_seq"`
+	HeadHash           string    `json:"head_hash"`
+	CheckpointsChecked int       `json:"checkpoints_checked"`
+	AnchorsChecked     int       `json:"anchors_checked"`
+	FirstBroken        *Break    `json:"first_broken,omitempty"`
+	VerifiedAt         time.Time `json:"verified_at"`
+}
+
+// 遍历流水校验哈希链，报告第一处断链
+func Verify(ctx context.Context, db *gorm.DB, opts Options) (*Report, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	scope := GlobalScope
+	if opts.UserID != 0 {
+		scope = UserScope(opts.UserID)
+	}
+	report := &Report{Scope: scope, VerifiedAt: time.Now()}
+
+	var head ChainHead
+	if err := db.WithContext(ctx).Where("scope = ?", scope).First(&head).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		head = ChainHead{Scope: scope, Hash: GenesisHash}
+	}
+	report.HeadSeq, report.HeadHash = head.Seq, head.Hash
+
+	// 全局校验时同时比对检查点
+	checkpoints := map[uint64]*Checkpoint{}
+	var checkpointList []Checkpoint
+	if opts.UserID == 0 {
+		if err := db.WithContext(ctx).Order("seq ASC").Find(&checkpointList).Error; err != nil {
+			return nil, err
+		}
+		for i := range checkpointList {
+			checkpoints[checkpointList[i].Seq] = &checkpointList[i]
+		}
+	}
+
+	verifier := NewVerifier(opts.UserID)
+	finish := func(b *Break) (*Report, error) {
+		report.Checked = verifier.Checked()
+		report.FirstBroken = b
+		report.Valid = b == nil
+		return report, nil
+	}
+
+	// 按(seq, id)分页，序号重复或为0的插入记录也会被读到
+	var lastSeq uint64
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		query := db.WithContext(ctx).Table(JournalTable).
+			Where("(seq > ? OR (seq = ? AND id > ?))", lastSeq, lastSeq, lastID)
+		if opts.UserID != 0 {
+			query = query.Where("user_id = ?", opts.UserID)
+		}
+		var records []Record
+		if err := query.Order("seq ASC, id ASC").Limit(opts.BatchSize).Find(&records).Error; err != nil {
+			return nil, err
+		}
+
+		for i := range records {
+			r := &records[i]
+			if b := verifier.Add(r); b != nil {
+				return finish(b)
+			}
+			if cp, ok := checkpoints[r.Seq]; ok {
+				report.CheckpointsChecked++
+				if cp.Hash != r.Hash {
+					return finish(&Break{Seq: r.Seq, RecordID: r.ID, UserID: r.UserID,
+						Reason: ReasonCheckpointMismatch, Expected: cp.Hash, Actual: r.Hash})
+				}
+			}
+		}
+		if len(records) < opts.BatchSize {
+			break
+		}
+		lastSeq, lastID = records[len(records)-1].Seq, records[len(records)-1].ID
+	}
+
+	// 链尾必须与链头一致，否则尾部记录被删除或链头被篡改
+	seq, hash := verifier.Head()
+	if seq != head.Seq || hash != head.Hash {
+		return finish(&Break{Seq: seq, UserID: opts.UserID, Reason: ReasonHeadMismatch,
+			Expected: strconv.FormatUint(head.Seq, 10) + ":" + head.Hash,
+			Actual:   strconv.FormatUint(seq, 10) + ":" + hash})
+	}
+
+	if opts.UserID == 0 {
+		if b, err := verifyUserHeads(ctx, db, verifier); err != nil || b != nil {
+			if err != nil {
+				return nil, err
+			}
+			return finish(b)
+		}
+
+		anchors, b, err := verifyCheckpoints(ctx, checkpointList, seq, opts.Resolver)
+		report.AnchorsChecked = anchors
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			return finish(b)
+		}
+	}
+	return finish(nil)
+}
+
+// 校验检查点未超出链尾，并比对已锚定检查点与区块链上的锚定值，返回比对的锚定数
+func verifyCheckpoints(ctx context.Context, checkpoints []Checkpoint, headSeq uint64, resolver AnchorResolver) (int, *Break, error) {
+	anchors := 0
+	for i := range checkpoints {
+		cp := &checkpoints[i]
+		if cp.Seq > headSeq {
+			return anchors, &Break{Seq: cp.Seq, Reason: ReasonCheckpointMismatch, Expected: cp.Hash, Actual: "missing"}, nil
+		}
+		if resolver == nil || cp.Status != CheckpointAnchored {
+			continue
+		}
+		anchored, err := resolver.AnchoredHash(ctx, cp.BlockchainType, cp.TransactionHash)
+		if err != nil {
+			return anchors, nil, err
+		}
+		anchors++
+		if !hashchain.AnchorMatches(anchored, cp.Hash) {
+			return anchors, &Break{Seq: cp.Seq, Reason: ReasonAnchorMismatch, Expected: anchored, Actual: cp.Hash}, nil
+		}
+	}
+	return anchors, nil, nil
+}
+
+// 比对每个用户的链头与其最后一条流水
+func verifyUserHeads(ctx context.Context, db *gorm.DB, verifier *Verifier) (*Break, error) {
+	var heads []ChainHead
+	if err := db.WithContext(ctx).Where("scope LIKE ?", "user:%").Find(&heads).Error; err != nil {
+		return nil, err
+	}
+
+	chainHeads := make([]hashchain.Head, len(heads))
+	for i, h := range heads {
+		chainHeads[i] = hashchain.Head{Scope: h.Scope, Seq: h.Seq, Hash: h.Hash}
+	}
+	b := verifier.chain.CheckHeads(chainHeads)
+	if b == nil {
+		return nil, nil
+	}
+	userID, _ := strconv.ParseUint(strings.TrimPrefix(b.Scope, "user:"), 10, 64)
+	return &Break{Seq: b.Seq, UserID: uint(userID), Reason: b.Reason, Expected: b.Expected, Actual: b.Actual}, nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"resume-centre/points/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 锁定链头，不存在时创建
+func lockChainHead(tx *gorm.DB, scope string) (*audit.ChainHead, error) {
+	head := audit.ChainHead{Scope: scope, Hash: audit.GenesisHash}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ?", scope).First(&head).Error; err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// 将流水接到用户链和全局链尾部后写入。
+// 调用方须已锁定用户积分账户，先锁用户链头再锁全局链头，保证加锁顺序一致
+func appendJournal(tx *gorm.DB, entry *PointsJournal) error {
+	userHead, err := lockChainHead(tx, audit.UserScope(entry.UserID))
+	if err != nil {
+		return err
+	}
+	globalHead, err := lockChainHead(tx, audit.GlobalScope)
+	if err != nil {
+		return err
+	}
+
+	record := journalRecord(entry)
+	record.Link(globalHead.Seq+1, userHead.Hash, globalHead.Hash)
+	entry.CreatedAt = record.CreatedAt
+	entry.Seq = record.Seq
+	entry.PrevHash = record.PrevHash
+	entry.GlobalPrevHash = record.GlobalPrevHash
+	entry.Hash = record.Hash
+
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(userHead).Updates(map[string]interface{}{"seq": entry.Seq, "hash": entry.Hash}).Error; err != nil {
+		return err
+	}
+	return tx.Model(globalHead).Updates(map[string]interface{}{"seq": entry.Seq, "hash": entry.Hash}).Error
+}
+
+func journalRecord(entry *PointsJournal) *audit.Record {
+	return &audit.Record{
+		ID:             entry.ID,
+		Seq:            entry.Seq,
+		UserID:         entry.UserID,
+		Type:           string(entry.Type),
+		Amount:         entry.Amount,
+		BalanceAfter:   entry.BalanceAfter,
+		BatchID:        entry.BatchID,
+		Reference:      entry.Reference,
+		Description:    entry.Description,
+		CreatedAt:      entry.CreatedAt,
+		PrevHash:       entry.PrevHash,
+		GlobalPrevHash: entry.GlobalPrevHash,
+		Hash:           entry.Hash,
+	}
+}
+
+// 首次启用审计链时按写入顺序为历史流水建链；链头已存在后不再补链，
+// 之后出现的无哈希记录即视为绕过服务写入
+func initAuditChain() error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&audit.ChainHead{}).Where("scope = ?", audit.GlobalScope).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		globalHead, err := lockChainHead(tx, audit.GlobalScope)
+		if err != nil {
+			return err
+		}
+		userHeads := map[uint]*audit.ChainHead{}
+
+		var sealed int
+		var journals []PointsJournal
+		err = tx.Order("id ASC").FindInBatches(&journals, 1000, func(batch *gorm.DB, _ int) error {
+			for i := range journals {
+				entry := &journals[i]
+				userHead, ok := userHeads[entry.UserID]
+				if !ok {
+					userHead = &audit.ChainHead{Scope: audit.UserScope(entry.UserID), Hash: audit.GenesisHash}
+					userHeads[entry.UserID] = userHead
+				}
+
+				record := journalRecord(entry)
+				record.Link(globalHead.Seq+1, userHead.Hash, globalHead.Hash)
+				if err := tx.Model(entry).Updates(map[string]interface{}{
+					"created_at":       record.CreatedAt,
+					"seq":              record.Seq,
+					"prev_hash":        record.PrevHash,
+					"global_prev_hash": record.GlobalPrevHash,
+					"hash":             record.Hash,
+				}).Error; err != nil {
+					return err
+				}
+				globalHead.Seq, globalHead.Hash = record.Seq, record.Hash
+				userHead.Seq, userHead.Hash = record.Seq, record.Hash
+				sealed++
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, head := range userHeads {
+			if err := tx.Save(head).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(globalHead).Error; err != nil {
+			return err
+		}
+		if sealed > 0 {
+			logger.Infof("Audit chain initialized over %d existing journal entries", sealed)
+		}
+		return nil
+	})
+}
+
+// 获取区块链服务地址：优先通过Consul发现，失败时使用配置
+func blockchainServiceURL() string {
+	if consulClient != nil {
+		services, _, err := consulClient.Health().Service("blockchain-service", "", true, nil)
+		if err == nil && len(services) > 0 {
+			svc := services[0].Service
+			return fmt.Sprintf("http://%s:%d", svc.Address, svc.Port)
+		}
+	}
+	return viper.GetString("blockchain.service_url")
+}
+
+func blockchainClient() *audit.BlockchainClient {
+	return &audit.BlockchainClient{
+		BaseURL: blockchainServiceURL(),
+		Token:   viper.GetString("internal.service_token"),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// 启动检查点定时任务：定期将全局链头锚定到区块链
+func startAuditCheckpointJob(ctx context.Context) {
+	interval := viper.GetDuration("points.audit.checkpoint_interval")
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !acquireJobLock("audit_checkpoint", interval/2) {
+					continue
+				}
+				if err := createAuditCheckpoint(ctx); err != nil {
+					logger.Errorf("Audit checkpoint job failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// 为当前链头创建检查点并锚定，同时重试之前锚定失败的检查点
+func createAuditCheckpoint(ctx context.Context) error {
+	var head audit.ChainHead
+	if err := db.Where("scope = ?", audit.GlobalScope).First(&head).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var last audit.Checkpoint
+	err := db.Order("seq DESC").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if head.Seq > last.Seq {
+		checkpoint := audit.Checkpoint{Seq: head.Seq, Hash: head.Hash, Status: audit.CheckpointPending}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&checkpoint).Error; err != nil {
+			return err
+		}
+	}
+
+	var pending []audit.Checkpoint
+	if err := db.Where("status IN ?", []string{audit.CheckpointPending, audit.CheckpointFailed}).
+		Order("seq ASC").Find(&pending).Error; err != nil {
+		return err
+	}
+	client := blockchainClient()
+	for i := range pending {
+		anchorAuditCheckpoint(ctx, client, &pending[i])
+	}
+	return nil
+}
+
+func anchorAuditCheckpoint(ctx context.Context, client *audit.BlockchainClient, checkpoint *audit.Checkpoint) {
+	result, err := client.Anchor(ctx, "points_audit_checkpoint", strconv.FormatUint(checkpoint.Seq, 10), checkpoint.Hash)
+	if err != nil {
+		logger.Errorf("Failed to anchor audit checkpoint %d: %v", checkpoint.Seq, err)
+		message := err.Error()
+		if len(message) > 255 {
+			message = message[:255]
+		}
+		db.Model(checkpoint).Updates(map[string]interface{}{"status": audit.CheckpointFailed, "error": message})
+		return
+	}
+
+	now := time.Now()
+	if err := db.Model(checkpoint).Updates(map[string]interface{}{
+		"status":           audit.CheckpointAnchored,
+		"blockchain_type":  result.BlockchainType,
+		"transaction_hash": result.TransactionHash,
+		"error":            "",
+		"anchored_at":      &now,
+	}).Error; err != nil {
+		logger.Errorf("Failed to update audit checkpoint %d: %v", checkpoint.Seq, err)
+		return
+	}
+	logger.Infof("Audit checkpoint %d anchored in %s", checkpoint.Seq, result.TransactionHash)
+}
+
+// 校验审计哈希链，报告第一处断链
+func verifyAuditChain(c *gin.Context) {
+	opts := audit.Options{BatchSize: viper.GetInt("points.audit.verify_batch_size")}
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := strconv.ParseUint(userID, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid user_id"})
+			return
+		}
+		opts.UserID = uint(id)
+	}
+	if c.DefaultQuery("anchors", "true") == "true" {
+		opts.Resolver = blockchainClient()
+	}
+
+	report, err := audit.Verify(c.Request.Context(), db, opts)
+	if err != nil {
+		logger.Errorf("Audit chain verification failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to verify audit chain: " + err.Error()})
+		return
+	}
+	if !report.Valid {
+		logger.Warnf("Audit chain broken: %v", report.FirstBroken)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    report,
+	})
+}
+
+// 检查点列表
+func listAuditCheckpoints(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	var checkpoints []audit.Checkpoint
+	if err := db.Order("seq DESC").Limit(limit).Find(&checkpoints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load checkpoints"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    checkpoints,
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownEarnAction = errors.New("unknown points earn action")
+	ErrEarnLimitReached  = errors.New("points earn limit reached")
+)
+
+// 积分获取规则：发放数量和频次由服务端决定，调用方只声明用户完成的行为
+type EarnRule struct {
+	Action      string `json:"action" mapstructure:"action"`
+	Name        string `json:"name" mapstructure:"name"`
+	Points      int64  `json:"points" mapstructure:"points"`
+	DailyLimit  int    `json:"daily_limit" mapstructure:"daily_limit"` // 每日发放次数上限，0为不限
+	Description string `json:"description" mapstructure:"description"`
+}
+
+// 默认积分获取规则，可通过points.earn_rules配置覆盖
+func defaultEarnRules() []EarnRule {
+	return []EarnRule{
+		{Action: "register", Name: "注册", Points: 100, DailyLimit: 1, Description: "新用户注册奖励"},
+		{Action: "resume_complete", Name: "完善简历", Points: 50, DailyLimit: 1, Description: "完善个人简历信息"},
+		{Action: "daily_checkin", Name: "每日签到", Points: 10, DailyLimit: 1, Description: "每日签到奖励"},
+		{Action: "job_publish", Name: "发布职位", Points: 20, DailyLimit: 5, Description: "企业发布职位奖励"},
+	}
+}
+
+// 当前生效的积分获取规则
+func earnRules() []EarnRule {
+	if !viper.IsSet("points.earn_rules") {
+		return defaultEarnRules()
+	}
+	var rules []EarnRule
+	if err := viper.UnmarshalKey("points.earn_rules", &rules); err != nil {
+		logger.Errorf("Invalid points.earn_rules, using defaults: %v", err)
+		return defaultEarnRules()
+	}
+	return rules
+}
+
+func findEarnRule(action string) (EarnRule, bool) {
+	for _, r := range earnRules() {
+		if r.Action == action && r.Points > 0 {
+			return r, true
+		}
+	}
+	return EarnRule{}, false
+}
+
+// 按规则发放积分。reference为调用方的幂等键，同一用户同一reference重复调用返回已有流水
+func awardPoints(tx *gorm.DB, userID uint, action, reference string, now time.Time) (*PointsJournal, bool, error) {
+	rule, ok := findEarnRule(action)
+	if !ok {
+		return nil, false, ErrUnknownEarnAction
+	}
+
+	// 先锁账户，使同一用户的幂等检查和次数统计串行执行
+	if _, err := lockPointsAccount(tx, userID); err != nil {
+		return nil, false, err
+	}
+	var existing PointsJournal
+	err := tx.Where("user_id = ? AND type = ? AND reference = ?", userID, JournalTypeEarn, reference).First(&existing).Error
+	if err == nil {
+		return &existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	if rule.DailyLimit > 0 {
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		var count int64
+		if err := tx.Model(&PointsBatch{}).
+			Where("user_id = ? AND source = ? AND earned_at >= ?", userID, rule.Action, dayStart).
+			Count(&count).Error; err != nil {
+			return nil, false, err
+		}
+		if count >= int64(rule.DailyLimit) {
+			return nil, false, ErrEarnLimitReached
+		}
+	}
+
+	entry, err := creditPoints(tx, userID, rule.Points, rule.Action, reference, rule.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+// 服务间调用：用户完成某项行为后按规则发放积分
+func awardPointsHandler(c *gin.Context) {
+	var req struct {
+		UserID    uint   `json:"user_id" binding:"required"`
+		Action    string `json:"action" binding:"required"`
+		Reference string `json:"reference" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	var entry *PointsJournal
+	var created bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, created, err = awardPoints(tx, req.UserID, req.Action, req.Reference, time.Now())
+		return err
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrUnknownEarnAction):
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	case errors.Is(err, ErrEarnLimitReached):
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": err.Error()})
+		return
+	default:
+		respondLedgerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "积分发放成功",
+		"data": gin.H{
+			"earned":      entry.Amount,
+			"new_balance": entry.BalanceAfter,
+			"duplicate":   !created,
+		},
+	})
+}
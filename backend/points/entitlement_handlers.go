@@ -0,0 +1,253 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// 服务间调用认证中间件
+func internalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := viper.GetString("internal.service_token")
+		token := c.GetHeader("X-Service-Token")
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// 权益错误转换为HTTP响应
+func respondEntitlementError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrCouponNotFound), errors.Is(err, ErrReservationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error()})
+	case errors.Is(err, ErrEntitlementUnavailable):
+		c.JSON(http.StatusPaymentRequired, gin.H{"code": 402, "message": err.Error()})
+	case errors.Is(err, ErrReservationClosed):
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": err.Error()})
+	default:
+		logger.Errorf("Entitlement operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Internal server error"})
+	}
+}
+
+// 获取用户权益钱包
+func getEntitlementWallet(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	query := db.Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status = ?", EntitlementStatusActive)
+	}
+
+	var entitlements []Entitlement
+	if err := query.Order("expires_at ASC").Find(&entitlements).Error; err != nil {
+		logger.Errorf("Failed to load entitlements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load entitlements"})
+		return
+	}
+
+	// 按类型汇总可用次数
+	available := map[EntitlementType]int{}
+	for i := range entitlements {
+		if entitlements[i].Status == EntitlementStatusActive {
+			available[entitlements[i].Type] += entitlements[i].Available()
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"entitlements": entitlements,
+			"available":    available,
+		},
+	})
+}
+
+// 获取即将过期和最近过期的权益
+func getExpiringEntitlements(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days <= 0 {
+		days = 7
+	}
+	now := time.Now()
+
+	var expiring []Entitlement
+	if err := db.Where("user_id = ? AND status = ? AND expires_at BETWEEN ? AND ?",
+		userID, EntitlementStatusActive, now, now.AddDate(0, 0, days)).
+		Order("expires_at ASC").Find(&expiring).Error; err != nil {
+		logger.Errorf("Failed to load expiring entitlements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load entitlements"})
+		return
+	}
+
+	var expired []Entitlement
+	if err := db.Where("user_id = ? AND status = ? AND expires_at >= ?",
+		userID, EntitlementStatusExpired, now.AddDate(0, 0, -days)).
+		Order("expires_at DESC").Find(&expired).Error; err != nil {
+		logger.Errorf("Failed to load expired entitlements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load entitlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"expiring": expiring,
+			"expired":  expired,
+		},
+	})
+}
+
+// 检查用户某类权益的可用次数（服务间调用）
+func checkEntitlement(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	couponType := EntitlementType(c.Query("type"))
+	if userID == 0 || couponType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "user_id and type are required"})
+		return
+	}
+
+	available, err := availableEntitlementUses(uint(userID), couponType)
+	if err != nil {
+		respondEntitlementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"user_id":   userID,
+			"type":      couponType,
+			"available": available,
+		},
+	})
+}
+
+// 预占权益（服务间调用）
+func reserveEntitlementHandler(c *gin.Context) {
+	var req struct {
+		UserID      uint   `json:"user_id" binding:"required"`
+		Type        string `json:"type" binding:"required"`
+		Consumer    string `json:"consumer" binding:"required"`
+		ReferenceID string `json:"reference_id"`
+		TTLSeconds  int    `json:"ttl_seconds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = viper.GetDuration("entitlements.reservation_ttl")
+	}
+
+	reservation, err := reserveEntitlement(req.UserID, EntitlementType(req.Type), req.Consumer, req.ReferenceID, ttl)
+	if err != nil {
+		respondEntitlementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "权益预占成功",
+		"data":    reservation,
+	})
+}
+
+// 确认消费权益（服务间调用）
+func commitEntitlementHandler(c *gin.Context) {
+	var req struct {
+		ReservationID string `json:"reservation_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	reservation, err := commitReservation(req.ReservationID)
+	if err != nil {
+		respondEntitlementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "权益使用成功",
+		"data":    reservation,
+	})
+}
+
+// 回滚预占的权益（服务间调用）
+func rollbackEntitlementHandler(c *gin.Context) {
+	var req struct {
+		ReservationID string `json:"reservation_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	reservation, err := rollbackReservation(req.ReservationID)
+	if err != nil {
+		respondEntitlementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "权益已释放",
+		"data":    reservation,
+	})
+}
+
+// 发放权益（服务间调用，如运营活动赠送）
+func issueEntitlementHandler(c *gin.Context) {
+	var req struct {
+		UserID    uint   `json:"user_id" binding:"required"`
+		Type      string `json:"type" binding:"required"`
+		Source    string `json:"source" binding:"required"`
+		SourceRef string `json:"source_ref"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	entitlement, err := issueEntitlement(db, req.UserID, EntitlementType(req.Type), req.Source, req.SourceRef)
+	if err != nil {
+		respondEntitlementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "权益发放成功",
+		"data":    entitlement,
+	})
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 权益（券）类型
+type EntitlementType string
+
+const (
+	EntitlementTypeResumeDownload EntitlementType = "resume_download" // 简历下载券
+	EntitlementTypeJobPin         EntitlementType = "job_pin"         // 职位置顶券
+	EntitlementTypeVIPTrial       EntitlementType = "vip_trial"       // VIP体验券
+)
+
+// 权益状态
+type EntitlementStatus string
+
+const (
+	EntitlementStatusActive    EntitlementStatus = "active"
+	EntitlementStatusExhausted EntitlementStatus = "exhausted"
+	EntitlementStatusExpired   EntitlementStatus = "expired"
+	EntitlementStatusRevoked   EntitlementStatus = "revoked"
+)
+
+// 预占状态
+type ReservationStatus string
+
+const (
+	ReservationStatusReserved   ReservationStatus = "reserved"
+	ReservationStatusCommitted  ReservationStatus = "committed"
+	ReservationStatusRolledBack ReservationStatus = "rolled_back"
+)
+
+var (
+	ErrCouponNotFound         = errors.New("coupon definition not found")
+	ErrEntitlementUnavailable = errors.New("no available entitlement")
+	ErrReservationNotFound    = errors.New("reservation not found")
+	ErrReservationClosed      = errors.New("reservation already committed or rolled back")
+)
+
+// 券定义（积分奖励目录）
+type CouponDefinition struct {
+	ID          uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Type        EntitlementType `json:"type" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name        string          `json:"name" gorm:"type:varchar(100);not null"`
+	Description string          `json:"description" gorm:"type:varchar(255)"`
+	Points      int64           `json:"points" gorm:"not null"`
+	Uses        int             `json:"uses" gorm:"not null;default:1"`
+	ValidDays   int             `json:"valid_days" gorm:"not null;default:30"`
+	IsActive    bool            `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// 用户权益（钱包中的一张券）
+type Entitlement struct {
+	ID               string            `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID           uint              `json:"user_id" gorm:"not null;index:idx_entitlement_user_type"`
+	Type             EntitlementType   `json:"type" gorm:"type:varchar(50);not null;index:idx_entitlement_user_type"`
+	CouponID         uint              `json:"coupon_id"`
+	Name             string            `json:"name" gorm:"type:varchar(100)"`
+	TotalUses        int               `json:"total_uses" gorm:"not null"`
+	RemainingUses    int               `json:"remaining_uses" gorm:"not null"`
+	ReservedUses     int               `json:"reserved_uses" gorm:"not null;default:0"`
+	Status           EntitlementStatus `json:"status" gorm:"type:varchar(20);default:'active';index"`
+	Source           string            `json:"source" gorm:"type:varchar(50)"`
+	SourceRef        string            `json:"source_ref" gorm:"type:varchar(100)"`
+	IssuedAt         time.Time         `json:"issued_at"`
+	ExpiresAt        time.Time         `json:"expires_at" gorm:"index"`
+	ExpiryNotifiedAt *time.Time        `json:"-"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// 可用次数（剩余次数减去已预占次数）
+func (e *Entitlement) Available() int {
+	return e.RemainingUses - e.ReservedUses
+}
+
+// 权益预占记录
+type EntitlementReservation struct {
+	ID            string            `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	EntitlementID string            `json:"entitlement_id" gorm:"type:varchar(36);not null;index"`
+	UserID        uint              `json:"user_id" gorm:"not null;index"`
+	Type          EntitlementType   `json:"type" gorm:"type:varchar(50);not null"`
+	Consumer      string            `json:"consumer" gorm:"type:varchar(50);not null;index:idx_reservation_ref"`
+	ReferenceID   string            `json:"reference_id" gorm:"type:varchar(100);index:idx_reservation_ref"`
+	Status        ReservationStatus `json:"status" gorm:"type:varchar(20);default:'reserved';index"`
+	ExpiresAt     time.Time         `json:"expires_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// 默认券目录，与原积分奖励保持一致
+var defaultCouponDefinitions = []CouponDefinition{
+	{Type: EntitlementTypeResumeDownload, Name: "简历下载券", Description: "可下载一份简历", Points: 100, Uses: 1, ValidDays: 30, IsActive: true},
+	{Type: EntitlementTypeJobPin, Name: "职位置顶券", Description: "职位置顶24小时", Points: 200, Uses: 1, ValidDays: 30, IsActive: true},
+	{Type: EntitlementTypeVIPTrial, Name: "VIP体验券", Description: "VIP功能体验7天", Points: 500, Uses: 1, ValidDays: 7, IsActive: true},
+}
+
+// 初始化券目录
+func seedCouponDefinitions() error {
+	for _, def := range defaultCouponDefinitions {
+		def := def
+		if err := db.Where("type = ?", def.Type).FirstOrCreate(&def).Error; err != nil {
+			return fmt.Errorf("failed to seed coupon %s: %v", def.Type, err)
+		}
+	}
+	return nil
+}
+
+// 发放权益
+func issueEntitlement(tx *gorm.DB, userID uint, couponType EntitlementType, source, sourceRef string) (*Entitlement, error) {
+	var def CouponDefinition
+	if err := tx.Where("type = ? AND is_active = ?", couponType, true).First(&def).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	entitlement := Entitlement{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		Type:          def.Type,
+		CouponID:      def.ID,
+		Name:          def.Name,
+		TotalUses:     def.Uses,
+		RemainingUses: def.Uses,
+		Status:        EntitlementStatusActive,
+		Source:        source,
+		SourceRef:     sourceRef,
+		IssuedAt:      now,
+		ExpiresAt:     now.AddDate(0, 0, def.ValidDays),
+	}
+	if err := tx.Create(&entitlement).Error; err != nil {
+		return nil, err
+	}
+	return &entitlement, nil
+}
+
+// 查询用户某类权益的可用次数
+func availableEntitlementUses(userID uint, couponType EntitlementType) (int, error) {
+	var entitlements []Entitlement
+	if err := db.Where("user_id = ? AND type = ? AND status = ? AND expires_at > ?",
+		userID, couponType, EntitlementStatusActive, time.Now()).Find(&entitlements).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for i := range entitlements {
+		total += entitlements[i].Available()
+	}
+	return total, nil
+}
+
+// 预占权益：优先使用最早过期的券，同一consumer+referenceID重复预占返回原记录
+func reserveEntitlement(userID uint, couponType EntitlementType, consumer, referenceID string, ttl time.Duration) (*EntitlementReservation, error) {
+	var reservation EntitlementReservation
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if referenceID != "" {
+			err := tx.Where("user_id = ? AND type = ? AND consumer = ? AND reference_id = ? AND status IN ?",
+				userID, couponType, consumer, referenceID,
+				[]ReservationStatus{ReservationStatusReserved, ReservationStatusCommitted}).
+				First(&reservation).Error
+			if err == nil {
+				return nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		var entitlement Entitlement
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND type = ? AND status = ? AND expires_at > ? AND remaining_uses > reserved_uses",
+				userID, couponType, EntitlementStatusActive, time.Now()).
+			Order("expires_at ASC").
+			First(&entitlement).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEntitlementUnavailable
+			}
+			return err
+		}
+
+		if err := tx.Model(&entitlement).
+			Update("reserved_uses", gorm.Expr("reserved_uses + 1")).Error; err != nil {
+			return err
+		}
+
+		reservation = EntitlementReservation{
+			ID:            uuid.New().String(),
+			EntitlementID: entitlement.ID,
+			UserID:        userID,
+			Type:          couponType,
+			Consumer:      consumer,
+			ReferenceID:   referenceID,
+			Status:        ReservationStatusReserved,
+			ExpiresAt:     time.Now().Add(ttl),
+		}
+		return tx.Create(&reservation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// 确认消费预占的权益
+func commitReservation(reservationID string) (*EntitlementReservation, error) {
+	return closeReservation(reservationID, ReservationStatusCommitted)
+}
+
+// 回滚预占的权益
+func rollbackReservation(reservationID string) (*EntitlementReservation, error) {
+	return closeReservation(reservationID, ReservationStatusRolledBack)
+}
+
+func closeReservation(reservationID string, target ReservationStatus) (*EntitlementReservation, error) {
+	var reservation EntitlementReservation
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", reservationID).First(&reservation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReservationNotFound
+			}
+			return err
+		}
+
+		if done, err := reservationClosed(&reservation, target); done || err != nil {
+			return err
+		}
+
+		var entitlement Entitlement
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", reservation.EntitlementID).First(&entitlement).Error; err != nil {
+			return err
+		}
+
+		updates := closeUpdates(&entitlement, target)
+		if err := tx.Model(&entitlement).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		reservation.Status = target
+		return tx.Model(&reservation).Update("status", target).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// 预占是否已结束：重复提交同一结果视为幂等（done为true），已按另一结果结束时返回ErrReservationClosed
+func reservationClosed(reservation *EntitlementReservation, target ReservationStatus) (bool, error) {
+	if reservation.Status == target {
+		return true, nil
+	}
+	if reservation.Status != ReservationStatusReserved {
+		return true, ErrReservationClosed
+	}
+	return false, nil
+}
+
+// 结束预占时券的更新：释放预占次数，确认消费时扣减剩余次数，用完后标记为已用完
+func closeUpdates(entitlement *Entitlement, target ReservationStatus) map[string]interface{} {
+	updates := map[string]interface{}{"reserved_uses": gorm.Expr("reserved_uses - 1")}
+	if target == ReservationStatusCommitted {
+		updates["remaining_uses"] = gorm.Expr("remaining_uses - 1")
+		if entitlement.RemainingUses-1 <= 0 {
+			updates["status"] = EntitlementStatusExhausted
+		}
+	}
+	return updates
+}
+
+// 启动权益定时清理：释放超时预占、标记过期券、发出即将过期提醒
+func startEntitlementSweeper(ctx context.Context) {
+	interval := viper.GetDuration("entitlements.sweep_interval")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sweepEntitlements(time.Now())
+			}
+		}
+	}()
+}
+
+func sweepEntitlements(now time.Time) {
+	// 释放超时未确认的预占
+	var stale []EntitlementReservation
+	if err := db.Where("status = ? AND expires_at < ?", ReservationStatusReserved, now).
+		Find(&stale).Error; err != nil {
+		logger.Errorf("Failed to load stale reservations: %v", err)
+	}
+	for _, r := range stale {
+		if _, err := rollbackReservation(r.ID); err != nil && !errors.Is(err, ErrReservationClosed) {
+			logger.Errorf("Failed to release reservation %s: %v", r.ID, err)
+		}
+	}
+
+	// 标记已过期的券（有未完成预占的券等预占释放后再处理）
+	result := db.Model(&Entitlement{}).
+		Where("status = ? AND expires_at <= ? AND reserved_uses = 0", EntitlementStatusActive, now).
+		Update("status", EntitlementStatusExpired)
+	if result.Error != nil {
+		logger.Errorf("Failed to expire entitlements: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		logger.Infof("Expired %d entitlements", result.RowsAffected)
+	}
+
+	// 即将过期提醒
+	warningDays := viper.GetInt("entitlements.expiry_warning_days")
+	if warningDays <= 0 {
+		warningDays = 3
+	}
+	var expiring []Entitlement
+	if err := db.Where("status = ? AND expires_at > ? AND expires_at <= ? AND expiry_notified_at IS NULL",
+		EntitlementStatusActive, now, now.AddDate(0, 0, warningDays)).
+		Find(&expiring).Error; err != nil {
+		logger.Errorf("Failed to load expiring entitlements: %v", err)
+		return
+	}
+	for _, e := range expiring {
+		notifyUser(e.UserID, "entitlement_expiring", e.Name+"即将过期",
+			fmt.Sprintf("您的%s将于%s过期，请尽快使用", e.Name, e.ExpiresAt.Format("2006-01-02")),
+			map[string]interface{}{"entitlement_id": e.ID, "type": e.Type, "expires_at": e.ExpiresAt})
+		db.Model(&Entitlement{}).Where("id = ?", e.ID).Update("expiry_notified_at", now)
+	}
+}
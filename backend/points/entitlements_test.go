@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestReservationClosed(t *testing.T) {
+	cases := []struct {
+		name   string
+		status ReservationStatus
+		target ReservationStatus
+		done   bool
+		err    error
+	}{
+		{"commit", ReservationStatusReserved, ReservationStatusCommitted, false, nil},
+		{"rollback", ReservationStatusReserved, ReservationStatusRolledBack, false, nil},
+		{"double commit", ReservationStatusCommitted, ReservationStatusCommitted, true, nil},
+		{"double rollback", ReservationStatusRolledBack, ReservationStatusRolledBack, true, nil},
+		{"rollback after commit", ReservationStatusCommitted, ReservationStatusRolledBack, true, ErrReservationClosed},
+		{"commit after rollback", ReservationStatusRolledBack, ReservationStatusCommitted, true, ErrReservationClosed},
+	}
+	for _, tc := range cases {
+		done, err := reservationClosed(&EntitlementReservation{Status: tc.status}, tc.target)
+		if done != tc.done || !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %v, %v; want %v, %v", tc.name, done, err, tc.done, tc.err)
+		}
+	}
+}
+
+func TestCloseUpdates(t *testing.T) {
+	cases := []struct {
+		name      string
+		remaining int
+		target    ReservationStatus
+		consumes  bool
+		exhausted bool
+	}{
+		{"rollback keeps uses", 1, ReservationStatusRolledBack, false, false},
+		{"commit with uses left", 3, ReservationStatusCommitted, true, false},
+		{"commit last use", 1, ReservationStatusCommitted, true, true},
+	}
+	for _, tc := range cases {
+		updates := closeUpdates(&Entitlement{RemainingUses: tc.remaining, ReservedUses: 1}, tc.target)
+		if _, ok := updates["reserved_uses"]; !ok {
+			t.Errorf("%s: reservation not released: %v", tc.name, updates)
+		}
+		if _, ok := updates["remaining_uses"]; ok != tc.consumes {
+			t.Errorf("%s: remaining_uses updated = %v", tc.name, ok)
+		}
+		if status, ok := updates["status"]; ok != tc.exhausted || (ok && status != EntitlementStatusExhausted) {
+			t.Errorf("%s: status update = %v", tc.name, status)
+		}
+	}
+}
+
+// 需要MySQL，设置POINTS_TEST_MYSQL_DSN后执行，如
+// root:password@tcp(localhost:3306)/points_test?charset=utf8mb4&parseTime=True&loc=Local
+func testEntitlementDB(t *testing.T) {
+	dsn := os.Getenv("POINTS_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("Skipping test - POINTS_TEST_MYSQL_DSN not set")
+	}
+	testDB, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Skipf("Skipping test - MySQL not available: %v", err)
+	}
+	if err := testDB.AutoMigrate(&CouponDefinition{}, &Entitlement{}, &EntitlementReservation{}); err != nil {
+		t.Fatal(err)
+	}
+	previous := db
+	db = testDB
+	t.Cleanup(func() { db = previous })
+	if err := seedCouponDefinitions(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 为测试用户发放一张券，用户ID取时间戳避免与库中已有数据冲突
+func issueTestEntitlement(t *testing.T, uses int, expiresAt time.Time) *Entitlement {
+	userID := uint(time.Now().UnixNano()%1_000_000_000) + 1_000_000_000
+	entitlement, err := issueEntitlement(db, userID, EntitlementTypeResumeDownload, "test", uuid.New().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(entitlement).Updates(map[string]interface{}{"total_uses": uses, "remaining_uses": uses, "expires_at": expiresAt}).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Where("user_id = ?", userID).Delete(&EntitlementReservation{})
+		db.Where("user_id = ?", userID).Delete(&Entitlement{})
+	})
+	return entitlement
+}
+
+func loadEntitlement(t *testing.T, id string) Entitlement {
+	var e Entitlement
+	if err := db.First(&e, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEntitlementReservationLifecycle(t *testing.T) {
+	testEntitlementDB(t)
+
+	type step struct {
+		op  string // commit、rollback
+		err error
+	}
+	cases := []struct {
+		name      string
+		uses      int
+		steps     []step
+		remaining int
+		status    EntitlementStatus
+	}{
+		{"commit", 2, []step{{"commit", nil}}, 1, EntitlementStatusActive},
+		{"commit last use", 1, []step{{"commit", nil}}, 0, EntitlementStatusExhausted},
+		{"double commit", 2, []step{{"commit", nil}, {"commit", nil}}, 1, EntitlementStatusActive},
+		{"rollback", 1, []step{{"rollback", nil}}, 1, EntitlementStatusActive},
+		{"rollback after commit", 2, []step{{"commit", nil}, {"rollback", ErrReservationClosed}}, 1, EntitlementStatusActive},
+		{"commit after rollback", 1, []step{{"rollback", nil}, {"commit", ErrReservationClosed}}, 1, EntitlementStatusActive},
+	}
+	for _, tc := range cases {
+		entitlement := issueTestEntitlement(t, tc.uses, time.Now().AddDate(0, 0, 30))
+		reservation, err := reserveEntitlement(entitlement.UserID, entitlement.Type, "test", tc.name, time.Minute)
+		if err != nil {
+			t.Fatalf("%s: reserve: %v", tc.name, err)
+		}
+		for i, s := range tc.steps {
+			closeFn := commitReservation
+			if s.op == "rollback" {
+				closeFn = rollbackReservation
+			}
+			if _, err := closeFn(reservation.ID); !errors.Is(err, s.err) {
+				t.Errorf("%s: step %d %s: got %v, want %v", tc.name, i, s.op, err, s.err)
+			}
+		}
+		got := loadEntitlement(t, entitlement.ID)
+		if got.RemainingUses != tc.remaining || got.ReservedUses != 0 || got.Status != tc.status {
+			t.Errorf("%s: remaining %d reserved %d status %s, want %d 0 %s",
+				tc.name, got.RemainingUses, got.ReservedUses, got.Status, tc.remaining, tc.status)
+		}
+	}
+}
+
+func TestReserveEntitlement(t *testing.T) {
+	testEntitlementDB(t)
+	entitlement := issueTestEntitlement(t, 1, time.Now().AddDate(0, 0, 30))
+
+	first, err := reserveEntitlement(entitlement.UserID, entitlement.Type, "test", "ref-1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一引用重复预占返回原记录，不再占用次数
+	again, err := reserveEntitlement(entitlement.UserID, entitlement.Type, "test", "ref-1", time.Minute)
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("repeated reserve = %v, %v; want %s", again, err, first.ID)
+	}
+	if _, err := reserveEntitlement(entitlement.UserID, entitlement.Type, "test", "ref-2", time.Minute); !errors.Is(err, ErrEntitlementUnavailable) {
+		t.Fatalf("reserve without available uses: %v", err)
+	}
+	if n, err := availableEntitlementUses(entitlement.UserID, entitlement.Type); err != nil || n != 0 {
+		t.Fatalf("available = %d, %v", n, err)
+	}
+
+	// 过期的券不能预占
+	expired := issueTestEntitlement(t, 1, time.Now().Add(-time.Minute))
+	if _, err := reserveEntitlement(expired.UserID, expired.Type, "test", "ref-3", time.Minute); !errors.Is(err, ErrEntitlementUnavailable) {
+		t.Fatalf("reserve expired entitlement: %v", err)
+	}
+}
+
+func TestSweepEntitlements(t *testing.T) {
+	testEntitlementDB(t)
+	now := time.Now()
+
+	// 超时的预占被释放，券恢复可用
+	held := issueTestEntitlement(t, 1, now.AddDate(0, 0, 30))
+	stale, err := reserveEntitlement(held.UserID, held.Type, "test", "stale", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(stale).Update("expires_at", now.Add(-time.Second))
+
+	// 已过期且带未完成预占的券等预占释放后才标记为过期
+	expiring := issueTestEntitlement(t, 1, now.AddDate(0, 0, 30))
+	pending, err := reserveEntitlement(expiring.UserID, expiring.Type, "test", "pending", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(expiring).Update("expires_at", now.Add(-time.Second))
+	expired := issueTestEntitlement(t, 1, now.Add(-time.Second))
+
+	sweepEntitlements(now)
+
+	var reservation EntitlementReservation
+	db.First(&reservation, "id = ?", stale.ID)
+	if reservation.Status != ReservationStatusRolledBack {
+		t.Errorf("stale reservation status = %s", reservation.Status)
+	}
+	if got := loadEntitlement(t, held.ID); got.ReservedUses != 0 || got.Status != EntitlementStatusActive {
+		t.Errorf("released entitlement = %+v", got)
+	}
+	if got := loadEntitlement(t, expiring.ID); got.Status != EntitlementStatusActive || got.ReservedUses != 1 {
+		t.Errorf("entitlement with pending reservation = %+v", got)
+	}
+	if got := loadEntitlement(t, expired.ID); got.Status != EntitlementStatusExpired {
+		t.Errorf("expired entitlement status = %s", got.Status)
+	}
+
+	// 预占确认或回滚后，下一轮清理再标记过期
+	if _, err := rollbackReservation(pending.ID); err != nil {
+		t.Fatal(err)
+	}
+	sweepEntitlements(now)
+	if got := loadEntitlement(t, expiring.ID); got.Status != EntitlementStatusExpired {
+		t.Errorf("entitlement after release = %s", got.Status)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// 启动积分过期定时任务
+func startPointsExpiryJob(ctx context.Context) {
+	interval := viper.GetDuration("points.expiry.schedule_interval")
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !acquireJobLock("points_expiry", interval/2) {
+					continue
+				}
+				now := time.Now()
+				if expired, err := runPointsExpiry(now); err != nil {
+					logger.Errorf("Points expiry job failed: %v", err)
+				} else if expired > 0 {
+					logger.Infof("Points expiry job expired %d batches", expired)
+				}
+				sendPointsExpiryWarnings(now)
+			}
+		}
+	}()
+}
+
+// 过期所有已到期批次，每个批次写入一条过期流水
+func runPointsExpiry(now time.Time) (int, error) {
+	var batchIDs []uint
+	if err := db.Model(&PointsBatch{}).
+		Where("remaining > 0 AND expires_at IS NOT NULL AND expires_at <= ?", now).
+		Order("expires_at ASC").Pluck("id", &batchIDs).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range batchIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			entry, err := expireBatch(tx, id, now)
+			if err == nil && entry != nil {
+				expired++
+			}
+			return err
+		})
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire batch %d: %v", id, err)
+		}
+	}
+	return expired, nil
+}
+
+// 发送积分即将过期提醒（按用户汇总，每个批次只提醒一次）
+func sendPointsExpiryWarnings(now time.Time) {
+	warningDays := viper.GetInt("points.expiry.warning_days")
+	if warningDays <= 0 {
+		return
+	}
+
+	var batches []PointsBatch
+	if err := db.Where("remaining > 0 AND warned_at IS NULL AND expires_at > ? AND expires_at <= ?",
+		now, now.AddDate(0, 0, warningDays)).
+		Order("user_id ASC, expires_at ASC").Find(&batches).Error; err != nil {
+		logger.Errorf("Failed to load expiring points batches: %v", err)
+		return
+	}
+
+	type userWarning struct {
+		amount   int64
+		earliest time.Time
+		batchIDs []uint
+	}
+	warnings := map[uint]*userWarning{}
+	for _, b := range batches {
+		w, ok := warnings[b.UserID]
+		if !ok {
+			w = &userWarning{earliest: *b.ExpiresAt}
+			warnings[b.UserID] = w
+		}
+		w.amount += b.Remaining
+		w.batchIDs = append(w.batchIDs, b.ID)
+	}
+
+	for userID, w := range warnings {
+		notifyUser(userID, "points_expiring", "积分即将过期",
+			fmt.Sprintf("您有%d积分将于%s起陆续过期，请尽快使用", w.amount, w.earliest.Format("2006-01-02")),
+			map[string]interface{}{"points": w.amount, "expires_at": w.earliest})
+		if err := db.Model(&PointsBatch{}).Where("id IN ?", w.batchIDs).
+			Update("warned_at", now).Error; err != nil {
+			logger.Errorf("Failed to mark points batches warned: %v", err)
+		}
+	}
+}
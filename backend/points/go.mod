@@ -5,16 +5,20 @@ go 1.21
 require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/go-redis/redis/v8 v8.11.5
+	github.com/google/uuid v1.3.1
 	github.com/hashicorp/consul/api v1.26.1
 	github.com/sirupsen/logrus v1.9.3
 	github.com/spf13/viper v1.17.0
 	gorm.io/driver/mysql v1.5.2
 	gorm.io/gorm v1.25.5
 	resume-centre/shared/infrastructure v0.0.0
+	resume-centre/shared/kernel v0.0.0
 )
 
 replace resume-centre/shared/infrastructure => ../shared/infrastructure
 
+replace resume-centre/shared/kernel => ../shared/kernel
+
 require (
 	github.com/armon/go-metrics v0.4.1 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 积分流水类型
+type JournalType string
+
+const (
+	JournalTypeEarn   JournalType = "earn"
+	JournalTypeSpend  JournalType = "spend"
+	JournalTypeExpire JournalType = "expire"
+)
+
+// 积分过期策略
+const (
+	ExpiryPolicyNone = "none" // 永不过期
+	ExpiryPolicyFIFO = "fifo" // 按获得批次先进先出，获得后N个月过期
+)
+
+var (
+	ErrInvalidPointsAmount = errors.New("points amount must be positive")
+	ErrInsufficientPoints  = errors.New("insufficient points balance")
+)
+
+// 积分账户
+type PointsAccount struct {
+	UserID       uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Balance      int64     `json:"balance" gorm:"not null;default:0"`
+	TotalEarned  int64     `json:"total_earned" gorm:"not null;default:0"`
+	TotalSpent   int64     `json:"total_spent" gorm:"not null;default:0"`
+	TotalExpired int64     `json:"total_expired" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 积分获得批次（过期和消费均按批次计算）
+type PointsBatch struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_batch_user_earned"`
+	Amount    int64      `json:"amount" gorm:"not null"`
+	Remaining int64      `json:"remaining" gorm:"not null"`
+	Source    string     `json:"source" gorm:"type:varchar(50)"`
+	EarnedAt  time.Time  `json:"earned_at" gorm:"not null;index:idx_batch_user_earned"`
+	ExpiresAt *time.Time `json:"expires_at" gorm:"index"`
+	WarnedAt  *time.Time `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// 积分流水（只追加，余额变动均有对应记录，并以哈希链防篡改）
+type PointsJournal struct {
+	ID           uint        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID       uint        `json:"user_id" gorm:"not null;index:idx_journal_user_time"`
+	Type         JournalType `json:"type" gorm:"type:varchar(20);not null"`
+	Amount       int64       `json:"amount" gorm:"not null"`
+	BalanceAfter int64       `json:"balance_after" gorm:"not null"`
+	BatchID      *uint       `json:"batch_id"`
+	Reference    string      `json:"reference" gorm:"type:varchar(100)"`
+	Description  string      `json:"description" gorm:"type:varchar(255)"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"index:idx_journal_user_time"`
+	// 审计哈希链
+	Seq            uint64 `json:"seq" gorm:"not null;default:0;index"`
+	PrevHash       string `json:"prev_hash" gorm:"type:varchar(64)"`
+	GlobalPrevHash string `json:"global_prev_hash" gorm:"type:varchar(64)"`
+	Hash           string `json:"hash" gorm:"type:varchar(64)"`
+}
+
+// 根据过期策略计算批次过期时间
+func batchExpiry(earnedAt time.Time) *time.Time {
+	if viper.GetString("points.expiry.policy") != ExpiryPolicyFIFO {
+		return nil
+	}
+	months := viper.GetInt("points.expiry.months")
+	if months <= 0 {
+		return nil
+	}
+	expiresAt := earnedAt.AddDate(0, months, 0)
+	return &expiresAt
+}
+
+// 锁定用户积分账户，不存在时创建
+func lockPointsAccount(tx *gorm.DB, userID uint) (*PointsAccount, error) {
+	account := PointsAccount{UserID: userID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// 获得积分：创建批次并写入流水
+func creditPoints(tx *gorm.DB, userID uint, amount int64, source, reference, description string) (*PointsJournal, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidPointsAmount
+	}
+
+	account, err := lockPointsAccount(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batch := PointsBatch{
+		UserID:    userID,
+		Amount:    amount,
+		Remaining: amount,
+		Source:    source,
+		EarnedAt:  now,
+		ExpiresAt: batchExpiry(now),
+	}
+	if err := tx.Create(&batch).Error; err != nil {
+		return nil, err
+	}
+
+	account.Balance += amount
+	account.TotalEarned += amount
+	if err := tx.Save(account).Error; err != nil {
+		return nil, err
+	}
+
+	entry := PointsJournal{
+		UserID:       userID,
+		Type:         JournalTypeEarn,
+		Amount:       amount,
+		BalanceAfter: account.Balance,
+		BatchID:      &batch.ID,
+		Reference:    reference,
+		Description:  description,
+		CreatedAt:    now,
+	}
+	if err := appendJournal(tx, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// 消费积分：按获得批次先进先出扣减
+func debitPoints(tx *gorm.DB, userID uint, amount int64, reference, description string) (*PointsJournal, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidPointsAmount
+	}
+
+	account, err := lockPointsAccount(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Balance < amount {
+		return nil, ErrInsufficientPoints
+	}
+
+	now := time.Now()
+	var batches []PointsBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND remaining > 0 AND (expires_at IS NULL OR expires_at > ?)", userID, now).
+		Order("earned_at ASC, id ASC").Find(&batches).Error; err != nil {
+		return nil, err
+	}
+
+	takes, short := allocateFIFO(batches, amount, now)
+	// 余额与批次不一致时（如存在已到期但尚未执行过期任务的批次）拒绝消费
+	if short > 0 {
+		return nil, ErrInsufficientPoints
+	}
+	for i, take := range takes {
+		if take == 0 {
+			continue
+		}
+		if err := tx.Model(&batches[i]).Update("remaining", batches[i].Remaining-take).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	account.Balance -= amount
+	account.TotalSpent += amount
+	if err := tx.Save(account).Error; err != nil {
+		return nil, err
+	}
+
+	entry := PointsJournal{
+		UserID:       userID,
+		Type:         JournalTypeSpend,
+		Amount:       -amount,
+		BalanceAfter: account.Balance,
+		Reference:    reference,
+		Description:  description,
+		CreatedAt:    now,
+	}
+	if err := appendJournal(tx, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// 过期单个批次的剩余积分。与消费使用相同的加锁顺序（先账户后批次），避免相互等待死锁
+func expireBatch(tx *gorm.DB, batchID uint, now time.Time) (*PointsJournal, error) {
+	var owner PointsBatch
+	if err := tx.Select("user_id").Where("id = ?", batchID).First(&owner).Error; err != nil {
+		return nil, err
+	}
+	account, err := lockPointsAccount(tx, owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch PointsBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", batchID).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	amount := expiringAmount(batch, now)
+	if amount == 0 {
+		return nil, nil
+	}
+
+	if err := tx.Model(&batch).Update("remaining", 0).Error; err != nil {
+		return nil, err
+	}
+
+	account.Balance -= amount
+	account.TotalExpired += amount
+	if err := tx.Save(account).Error; err != nil {
+		return nil, err
+	}
+
+	entry := PointsJournal{
+		UserID:       batch.UserID,
+		Type:         JournalTypeExpire,
+		Amount:       -amount,
+		BalanceAfter: account.Balance,
+		BatchID:      &batch.ID,
+		Description:  "积分过期",
+		CreatedAt:    now,
+	}
+	if err := appendJournal(tx, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// 按先进先出把扣减数量分配到批次：先获得的批次先扣，获得时间相同按ID。
+// 返回与batches对应的扣减数量，以及可用批次不足时未能分配的数量；已到期的批次不参与分配
+func allocateFIFO(batches []PointsBatch, amount int64, now time.Time) (takes []int64, short int64) {
+	order := make([]int, len(batches))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := batches[order[a]], batches[order[b]]
+		if !x.EarnedAt.Equal(y.EarnedAt) {
+			return x.EarnedAt.Before(y.EarnedAt)
+		}
+		return x.ID < y.ID
+	})
+
+	takes = make([]int64, len(batches))
+	left := amount
+	for _, i := range order {
+		if left == 0 {
+			break
+		}
+		b := batches[i]
+		if b.Remaining <= 0 || (b.ExpiresAt != nil && !b.ExpiresAt.After(now)) {
+			continue
+		}
+		take := b.Remaining
+		if take > left {
+			take = left
+		}
+		takes[i] = take
+		left -= take
+	}
+	return takes, left
+}
+
+// 批次在now时应过期的剩余积分，未到期、永不过期或已用完时为0
+func expiringAmount(batch PointsBatch, now time.Time) int64 {
+	if batch.Remaining <= 0 || batch.ExpiresAt == nil || batch.ExpiresAt.After(now) {
+		return 0
+	}
+	return batch.Remaining
+}
+
+// 查询用户积分账户（不存在时返回空账户）
+func getPointsAccount(userID uint) (*PointsAccount, error) {
+	account := PointsAccount{UserID: userID}
+	err := db.Where("user_id = ?", userID).First(&account).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &account, nil
+}
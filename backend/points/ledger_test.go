@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestAllocateFIFO(t *testing.T) {
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	at := func(days int) time.Time { return now.AddDate(0, 0, days) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	// 输入顺序打乱，分配应按获得时间先进先出，时间相同按ID
+	batches := []PointsBatch{
+		{ID: 4, Remaining: 50, EarnedAt: at(-1)},
+		{ID: 2, Remaining: 30, EarnedAt: at(-10), ExpiresAt: ptr(at(30))},
+		{ID: 1, Remaining: 20, EarnedAt: at(-10)},
+		{ID: 3, Remaining: 40, EarnedAt: at(-20), ExpiresAt: ptr(now)}, // 已到期，不参与分配
+		{ID: 5, Remaining: 0, EarnedAt: at(-30)},
+	}
+
+	cases := []struct {
+		amount int64
+		takes  []int64
+		short  int64
+	}{
+		{10, []int64{0, 0, 10, 0, 0}, 0},
+		{20, []int64{0, 0, 20, 0, 0}, 0},
+		{35, []int64{0, 15, 20, 0, 0}, 0},
+		{100, []int64{50, 30, 20, 0, 0}, 0},
+		{120, []int64{50, 30, 20, 0, 0}, 20},
+	}
+	for _, tc := range cases {
+		takes, short := allocateFIFO(batches, tc.amount, now)
+		if short != tc.short {
+			t.Errorf("allocateFIFO(%d) short = %d, want %d", tc.amount, short, tc.short)
+		}
+		for i := range takes {
+			if takes[i] != tc.takes[i] {
+				t.Errorf("allocateFIFO(%d) takes = %v, want %v", tc.amount, takes, tc.takes)
+				break
+			}
+		}
+	}
+
+	if takes, short := allocateFIFO(nil, 10, now); len(takes) != 0 || short != 10 {
+		t.Errorf("no batches: takes = %v, short = %d", takes, short)
+	}
+}
+
+func TestExpiringAmount(t *testing.T) {
+	now := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Second), now.Add(time.Second)
+
+	cases := []struct {
+		name  string
+		batch PointsBatch
+		want  int64
+	}{
+		{"expired", PointsBatch{Remaining: 30, ExpiresAt: &past}, 30},
+		{"expires exactly now", PointsBatch{Remaining: 30, ExpiresAt: &now}, 30},
+		{"not yet expired", PointsBatch{Remaining: 30, ExpiresAt: &future}, 0},
+		{"never expires", PointsBatch{Remaining: 30}, 0},
+		{"fully consumed", PointsBatch{Remaining: 0, ExpiresAt: &past}, 0},
+	}
+	for _, tc := range cases {
+		if got := expiringAmount(tc.batch, now); got != tc.want {
+			t.Errorf("%s: expiringAmount = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	// 过期后的批次不再参与消费
+	batch := PointsBatch{ID: 1, Remaining: 30, EarnedAt: now.AddDate(-1, 0, 0), ExpiresAt: &past}
+	if _, short := allocateFIFO([]PointsBatch{batch}, 10, now); short != 10 {
+		t.Errorf("expired batch was consumed")
+	}
+}
+
+func TestBatchExpiry(t *testing.T) {
+	defer viper.Reset()
+	earned := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	viper.Set("points.expiry.policy", ExpiryPolicyFIFO)
+	viper.Set("points.expiry.months", 12)
+	if got := batchExpiry(earned); got == nil || !got.Equal(earned.AddDate(1, 0, 0)) {
+		t.Errorf("fifo expiry = %v", got)
+	}
+
+	viper.Set("points.expiry.months", 0)
+	if got := batchExpiry(earned); got != nil {
+		t.Errorf("zero months should never expire, got %v", got)
+	}
+
+	viper.Set("points.expiry.policy", ExpiryPolicyNone)
+	viper.Set("points.expiry.months", 12)
+	if got := batchExpiry(earned); got != nil {
+		t.Errorf("policy none should never expire, got %v", got)
+	}
+}
+
+func TestEarnRules(t *testing.T) {
+	defer viper.Reset()
+	if r, ok := findEarnRule("daily_checkin"); !ok || r.Points != 10 || r.DailyLimit != 1 {
+		t.Errorf("default daily_checkin rule = %+v, %v", r, ok)
+	}
+	if _, ok := findEarnRule("anything"); ok {
+		t.Error("unknown action accepted")
+	}
+
+	viper.Set("points.earn_rules", []map[string]interface{}{
+		{"action": "daily_checkin", "name": "签到", "points": 5, "daily_limit": 2},
+		{"action": "disabled", "points": 0},
+	})
+	if r, ok := findEarnRule("daily_checkin"); !ok || r.Points != 5 || r.DailyLimit != 2 {
+		t.Errorf("configured rule = %+v, %v", r, ok)
+	}
+	if _, ok := findEarnRule("register"); ok {
+		t.Error("configured rules should replace the defaults")
+	}
+	if _, ok := findEarnRule("disabled"); ok {
+		t.Error("rule without points accepted")
+	}
+}
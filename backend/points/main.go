@@ -2,14 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"resume-centre/points/audit"
 	"resume-centre/shared/infrastructure"
 
 	"github.com/gin-gonic/gin"
@@ -59,6 +62,15 @@ func main() {
 		logger.Fatalf("Failed to register service: %v", err)
 	}
 
+	// 初始化消息队列
+	initMessageQueue()
+
+	// 启动后台任务
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	startEntitlementSweeper(jobCtx)
+	startPointsExpiryJob(jobCtx)
+	startAuditCheckpointJob(jobCtx)
+
 	// 启动HTTP服务器
 	router := setupRouter()
 	port := viper.GetString("server.port")
@@ -84,6 +96,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Shutting down server...")
+	stopJobs()
 
 	// 注销服务
 	if err := deregisterService(); err != nil {
@@ -117,6 +130,17 @@ func loadConfig() error {
 	viper.SetDefault("database.name", "jobfirst")
 	viper.SetDefault("database.user", "jobfirst")
 	viper.SetDefault("database.password", "jobfirst123")
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
+	viper.SetDefault("entitlements.reservation_ttl", "5m")
+	viper.SetDefault("entitlements.sweep_interval", "1m")
+	viper.SetDefault("entitlements.expiry_warning_days", 3)
+	viper.SetDefault("points.expiry.policy", ExpiryPolicyFIFO)
+	viper.SetDefault("points.expiry.months", 12)
+	viper.SetDefault("points.expiry.warning_days", 30)
+	viper.SetDefault("points.expiry.schedule_interval", "1h")
+	viper.SetDefault("points.audit.checkpoint_interval", "1h")
+	viper.SetDefault("points.audit.verify_batch_size", 1000)
+	viper.SetDefault("blockchain.service_url", "http://localhost:9009")
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
@@ -166,10 +190,19 @@ func initDatabase() error {
 	}
 
 	// 自动迁移
-	if err := db.AutoMigrate(&User{}); err != nil {
+	if err := db.AutoMigrate(&User{}, &CouponDefinition{}, &Entitlement{}, &EntitlementReservation{},
+		&PointsAccount{}, &PointsBatch{}, &PointsJournal{}, &audit.ChainHead{}, &audit.Checkpoint{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
+	if err := initAuditChain(); err != nil {
+		return fmt.Errorf("failed to init audit chain: %v", err)
+	}
+
+	if err := seedCouponDefinitions(); err != nil {
+		return err
+	}
+
 	logger.Info("Successfully connected to database")
 	return nil
 }
@@ -382,9 +415,10 @@ func setupRouter() *gin.Engine {
 			authPoints.GET("/history", getPointsHistory)
 			authPoints.GET("/transactions", getTransactions)
 			authPoints.GET("/summary", getPointsSummary)
+			authPoints.GET("/expiring", getExpiringPoints)
+			authPoints.GET("/statements/:month", getPointsStatement)
 
 			// 积分操作
-			authPoints.POST("/earn", earnPoints)
 			authPoints.POST("/spend", spendPoints)
 			authPoints.POST("/transfer", transferPoints)
 			authPoints.POST("/exchange", exchangePoints)
@@ -398,6 +432,30 @@ func setupRouter() *gin.Engine {
 			authPoints.GET("/mall/items", getMallItems)
 			authPoints.POST("/mall/purchase", purchaseMallItem)
 			authPoints.GET("/mall/orders", getMallOrders)
+
+			// 权益钱包
+			authPoints.GET("/entitlements", getEntitlementWallet)
+			authPoints.GET("/entitlements/expiring", getExpiringEntitlements)
+		}
+
+		// 服务间调用API（按规则发放积分，简历下载、职位置顶等权益核销）
+		internal := points.Group("/internal")
+		internal.Use(internalAuthMiddleware())
+		{
+			internal.POST("/earn", awardPointsHandler)
+			internal.GET("/entitlements/check", checkEntitlement)
+			internal.POST("/entitlements/issue", issueEntitlementHandler)
+			internal.POST("/entitlements/reserve", reserveEntitlementHandler)
+			internal.POST("/entitlements/commit", commitEntitlementHandler)
+			internal.POST("/entitlements/rollback", rollbackEntitlementHandler)
+		}
+
+		// 审计：校验积分流水哈希链（财务核对，服务间令牌认证）
+		auditGroup := points.Group("/audit")
+		auditGroup.Use(internalAuthMiddleware())
+		{
+			auditGroup.GET("/verify", verifyAuditChain)
+			auditGroup.GET("/checkpoints", listAuditCheckpoints)
 		}
 	}
 
@@ -421,9 +479,10 @@ func setupRouter() *gin.Engine {
 				authPointsAPI.GET("/history", getPointsHistory)
 				authPointsAPI.GET("/transactions", getTransactions)
 				authPointsAPI.GET("/summary", getPointsSummary)
+				authPointsAPI.GET("/expiring", getExpiringPoints)
+				authPointsAPI.GET("/statements/:month", getPointsStatement)
 
 				// 积分操作
-				authPointsAPI.POST("/earn", earnPoints)
 				authPointsAPI.POST("/spend", spendPoints)
 				authPointsAPI.POST("/transfer", transferPoints)
 				authPointsAPI.POST("/exchange", exchangePoints)
@@ -437,6 +496,17 @@ func setupRouter() *gin.Engine {
 				authPointsAPI.GET("/mall/items", getMallItems)
 				authPointsAPI.POST("/mall/purchase", purchaseMallItem)
 				authPointsAPI.GET("/mall/orders", getMallOrders)
+
+				// 权益钱包
+				authPointsAPI.GET("/entitlements", getEntitlementWallet)
+				authPointsAPI.GET("/entitlements/expiring", getExpiringEntitlements)
+			}
+
+			auditAPI := pointsAPI.Group("/audit")
+			auditAPI.Use(internalAuthMiddleware())
+			{
+				auditAPI.GET("/verify", verifyAuditChain)
+				auditAPI.GET("/checkpoints", listAuditCheckpoints)
 			}
 		}
 	}
@@ -682,7 +752,7 @@ func authMiddleware() gin.HandlerFunc {
 
 // 从上下文获取用户ID
 func getUserIDFromContext(c *gin.Context) uint {
-	if userID, exists := c.Get("user_id"); exists {
+	if userID, exists := c.Get("userID"); exists {
 		if id, ok := userID.(uint); ok {
 			return id
 		}
@@ -724,53 +794,114 @@ func validateToken(tokenString string) (uint, string, error) {
 
 // 获取积分余额
 func getPointsBalance(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	account, err := getPointsAccount(userID)
+	if err != nil {
+		logger.Errorf("Failed to load points account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load balance"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data":    gin.H{"balance": 100},
+		"data":    gin.H{"balance": account.Balance},
 	})
 }
 
 // 获取积分历史
 func getPointsHistory(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "success",
-		"data": []gin.H{
-			{"id": 1, "amount": 10, "type": "earn", "description": "完成任务", "time": "2025-08-30"},
-			{"id": 2, "amount": -5, "type": "spend", "description": "下载简历", "time": "2025-08-29"},
-		},
-	})
-}
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	query := db.Where("user_id = ?", userID)
+	if journalType := c.Query("type"); journalType != "" {
+		query = query.Where("type = ?", journalType)
+	}
+
+	var entries []PointsJournal
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * size).Limit(size).Find(&entries).Error; err != nil {
+		logger.Errorf("Failed to load points history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load history"})
+		return
+	}
 
-// 赚取积分
-func earnPoints(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
-		"message": "积分赚取成功",
-		"data":    gin.H{"earned": 10, "new_balance": 110},
+		"message": "success",
+		"data":    entries,
 	})
 }
 
 // 消费积分
 func spendPoints(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		Amount      int64  `json:"amount" binding:"required"`
+		Reference   string `json:"reference"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	var entry *PointsJournal
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = debitPoints(tx, userID, req.Amount, req.Reference, req.Description)
+		return err
+	})
+	if err != nil {
+		respondLedgerError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "积分消费成功",
-		"data":    gin.H{"spent": 5, "new_balance": 95},
+		"data":    gin.H{"spent": -entry.Amount, "new_balance": entry.BalanceAfter},
 	})
 }
 
 // 获取交易记录
 func getTransactions(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "success",
-		"data": []gin.H{
-			{"id": 1, "amount": 10, "type": "earn", "description": "完成任务", "time": "2025-08-30"},
-			{"id": 2, "amount": -5, "type": "spend", "description": "下载简历", "time": "2025-08-29"},
-		},
-	})
+	getPointsHistory(c)
+}
+
+// 积分操作错误转换为HTTP响应
+func respondLedgerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidPointsAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+	case errors.Is(err, ErrInsufficientPoints):
+		c.JSON(http.StatusPaymentRequired, gin.H{"code": 402, "message": "积分余额不足"})
+	default:
+		respondEntitlementError(c, err)
+	}
 }
 
 // ========== 积分服务处理函数 ==========
@@ -780,12 +911,7 @@ func getPointsRules(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data": []gin.H{
-			{"action": "注册", "points": 100, "description": "新用户注册奖励"},
-			{"action": "完善简历", "points": 50, "description": "完善个人简历信息"},
-			{"action": "每日签到", "points": 10, "description": "每日签到奖励"},
-			{"action": "发布职位", "points": 20, "description": "企业发布职位奖励"},
-		},
+		"data":    earnRules(),
 	})
 }
 
@@ -806,14 +932,17 @@ func getPointsLevels(c *gin.Context) {
 
 // 获取积分奖励
 func getPointsRewards(c *gin.Context) {
+	var coupons []CouponDefinition
+	if err := db.Where("is_active = ?", true).Order("id ASC").Find(&coupons).Error; err != nil {
+		logger.Errorf("Failed to load coupon definitions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load rewards"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data": []gin.H{
-			{"id": 1, "name": "简历下载券", "points": 100, "description": "可下载一份简历"},
-			{"id": 2, "name": "职位置顶券", "points": 200, "description": "职位置顶24小时"},
-			{"id": 3, "name": "VIP体验券", "points": 500, "description": "VIP功能体验7天"},
-		},
+		"data":    coupons,
 	})
 }
 
@@ -849,13 +978,51 @@ func transferPoints(c *gin.Context) {
 
 // 兑换积分
 func exchangePoints(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		RewardID uint `json:"reward_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	var coupon CouponDefinition
+	if err := db.Where("id = ? AND is_active = ?", req.RewardID, true).First(&coupon).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "Reward not found"})
+		return
+	}
+
+	// 扣减积分与发放权益在同一事务中完成
+	var entry *PointsJournal
+	var entitlement *Entitlement
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = debitPoints(tx, userID, coupon.Points, "coupon:"+string(coupon.Type), "兑换"+coupon.Name)
+		if err != nil {
+			return err
+		}
+		entitlement, err = issueEntitlement(tx, userID, coupon.Type, "exchange", strconv.FormatUint(uint64(entry.ID), 10))
+		return err
+	})
+	if err != nil {
+		respondLedgerError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "积分兑换成功",
 		"data": gin.H{
-			"exchanged":   100,
-			"reward":      "简历下载券",
-			"new_balance": 600,
+			"exchanged":   coupon.Points,
+			"reward":      coupon.Name,
+			"new_balance": entry.BalanceAfter,
+			"entitlement": entitlement,
 		},
 	})
 }
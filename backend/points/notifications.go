@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"resume-centre/shared/infrastructure"
+
+	"github.com/spf13/viper"
+)
+
+// 用户通知消息主题
+const userNotificationTopic = "user.notification"
+
+var messageQueue infrastructure.MessageQueue = &infrastructure.NoopMessageQueue{}
+
+// 初始化消息队列，连接失败时降级为空操作队列
+func initMessageQueue() {
+	config := infrastructure.CreateDefaultMessagingConfig()
+	config.RedisAddr = viper.GetString("redis.address")
+	config.RedisPassword = viper.GetString("redis.password")
+	config.RedisDB = viper.GetInt("redis.db")
+
+	queue, err := infrastructure.NewRedisStreamsQueue(config)
+	if err != nil {
+		logger.Warnf("Failed to init message queue, notifications disabled: %v", err)
+		return
+	}
+	messageQueue = queue
+	logger.Info("Successfully connected to message queue")
+}
+
+// 向用户发送通知（由通知服务消费并投递）
+func notifyUser(userID uint, notificationType, title, content string, data map[string]interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	message := &infrastructure.Message{
+		Topic: userNotificationTopic,
+		Data: map[string]interface{}{
+			"user_id":           userID,
+			"notification_type": notificationType,
+			"title":             title,
+			"content":           content,
+			"data":              data,
+		},
+		Headers: map[string]string{"source": "points-service"},
+	}
+	if err := messageQueue.Publish(ctx, userNotificationTopic, message); err != nil {
+		logger.Errorf("Failed to send notification to user %d: %v", userID, err)
+	}
+}
+
+// 获取分布式任务锁，避免多实例重复执行定时任务
+func acquireJobLock(name string, ttl time.Duration) bool {
+	if redisClient == nil {
+		return true
+	}
+	key := fmt.Sprintf("points:job_lock:%s", name)
+	ok, err := redisClient.SetNX(context.Background(), key, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		logger.Warnf("Failed to acquire job lock %s: %v", name, err)
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf16"
+)
+
+// A4页面尺寸（单位：pt）
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+)
+
+// 简易PDF文档：仅支持文本和直线，使用不嵌入的STSong-Light字体以支持中文
+type simplePDF struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func newSimplePDF() *simplePDF {
+	p := &simplePDF{}
+	p.AddPage()
+	return p
+}
+
+// 新增页面
+func (p *simplePDF) AddPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.y = pdfPageHeight - pdfMargin
+}
+
+// 按行写入文本，空间不足时自动换页
+func (p *simplePDF) Line(x, size float64, text string) {
+	lineHeight := size * 1.6
+	if p.y-lineHeight < pdfMargin {
+		p.AddPage()
+	}
+	p.y -= lineHeight
+	p.TextAt(x, p.y, size, text)
+}
+
+// 在当前行指定位置写入文本（不移动行位置）
+func (p *simplePDF) TextAt(x, y, size float64, text string) {
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, y, pdfHexText(text))
+}
+
+// 当前行纵坐标
+func (p *simplePDF) Y() float64 {
+	return p.y
+}
+
+// 画一条水平分隔线
+func (p *simplePDF) Rule() {
+	p.y -= 4
+	fmt.Fprintf(p.pages[len(p.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, p.y, pdfPageWidth-pdfMargin, p.y)
+}
+
+// 生成PDF文件内容
+func (p *simplePDF) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	writeObj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: Catalog, 2: Pages, 3-5: 字体, 之后每页两个对象（Page + Content）
+	const firstPageObj = 6
+	kids := &bytes.Buffer{}
+	for i := range p.pages {
+		fmt.Fprintf(kids, "%d 0 R ", firstPageObj+i*2)
+	}
+
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(p.pages)))
+	writeObj("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	writeObj("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> " +
+		"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	writeObj("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, page := range p.pages {
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPageObj+i*2+1))
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// 文本编码为UCS-2大端十六进制串（超出BMP的字符以?代替）
+func pdfHexText(text string) string {
+	var buf bytes.Buffer
+	for _, r := range text {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		for _, u := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&buf, "%04X", u)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 积分月度对账单
+type PointsStatement struct {
+	UserID         uint            `json:"user_id"`
+	Month          string          `json:"month"`
+	OpeningBalance int64           `json:"opening_balance"`
+	Earned         int64           `json:"earned"`
+	Spent          int64           `json:"spent"`
+	Expired        int64           `json:"expired"`
+	ClosingBalance int64           `json:"closing_balance"`
+	Entries        []PointsJournal `json:"entries"`
+}
+
+// 流水类型中文名称
+var journalTypeNames = map[JournalType]string{
+	JournalTypeEarn:   "获得",
+	JournalTypeSpend:  "消费",
+	JournalTypeExpire: "过期",
+}
+
+// 根据流水生成用户某月对账单
+func buildPointsStatement(userID uint, month time.Time) (*PointsStatement, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+
+	statement := &PointsStatement{
+		UserID: userID,
+		Month:  start.Format("2006-01"),
+	}
+
+	// 期初余额为上月最后一条流水的余额
+	var last PointsJournal
+	err := db.Where("user_id = ? AND created_at < ?", userID, start).
+		Order("created_at DESC, id DESC").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	statement.OpeningBalance = last.BalanceAfter
+
+	if err := db.Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, start, end).
+		Order("created_at ASC, id ASC").Find(&statement.Entries).Error; err != nil {
+		return nil, err
+	}
+
+	statement.ClosingBalance = statement.OpeningBalance
+	for _, e := range statement.Entries {
+		switch e.Type {
+		case JournalTypeEarn:
+			statement.Earned += e.Amount
+		case JournalTypeSpend:
+			statement.Spent -= e.Amount
+		case JournalTypeExpire:
+			statement.Expired -= e.Amount
+		}
+		statement.ClosingBalance += e.Amount
+	}
+
+	return statement, nil
+}
+
+// 导出CSV格式对账单
+func (s *PointsStatement) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	// 写入BOM，便于Excel正确识别UTF-8
+	buf.WriteString("\xef\xbb\xbf")
+
+	w := csv.NewWriter(&buf)
+	rows := [][]string{
+		{"月份", s.Month},
+		{"期初余额", strconv.FormatInt(s.OpeningBalance, 10)},
+		{"本月获得", strconv.FormatInt(s.Earned, 10)},
+		{"本月消费", strconv.FormatInt(s.Spent, 10)},
+		{"本月过期", strconv.FormatInt(s.Expired, 10)},
+		{"期末余额", strconv.FormatInt(s.ClosingBalance, 10)},
+		{},
+		{"时间", "类型", "积分", "余额", "说明"},
+	}
+	for _, e := range s.Entries {
+		rows = append(rows, []string{
+			e.CreatedAt.Format("2006-01-02 15:04:05"),
+			journalTypeNames[e.Type],
+			strconv.FormatInt(e.Amount, 10),
+			strconv.FormatInt(e.BalanceAfter, 10),
+			e.Description,
+		})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 导出PDF格式对账单
+func (s *PointsStatement) PDF() []byte {
+	pdf := newSimplePDF()
+	pdf.Line(pdfMargin, 18, fmt.Sprintf("积分月度对账单 %s", s.Month))
+	pdf.Line(pdfMargin, 10, fmt.Sprintf("用户ID：%d    生成时间：%s", s.UserID, time.Now().Format("2006-01-02 15:04")))
+	pdf.Rule()
+
+	summary := [][2]string{
+		{"期初余额", strconv.FormatInt(s.OpeningBalance, 10)},
+		{"本月获得", strconv.FormatInt(s.Earned, 10)},
+		{"本月消费", strconv.FormatInt(s.Spent, 10)},
+		{"本月过期", strconv.FormatInt(s.Expired, 10)},
+		{"期末余额", strconv.FormatInt(s.ClosingBalance, 10)},
+	}
+	for _, row := range summary {
+		pdf.Line(pdfMargin, 12, row[0])
+		pdf.TextAt(pdfMargin+120, pdf.Y(), 12, row[1])
+	}
+	pdf.Rule()
+
+	columns := []float64{pdfMargin, pdfMargin + 120, pdfMargin + 170, pdfMargin + 230, pdfMargin + 290}
+	header := []string{"时间", "类型", "积分", "余额", "说明"}
+	pdf.Line(columns[0], 10, header[0])
+	for i := 1; i < len(header); i++ {
+		pdf.TextAt(columns[i], pdf.Y(), 10, header[i])
+	}
+	for _, e := range s.Entries {
+		pdf.Line(columns[0], 9, e.CreatedAt.Format("2006-01-02 15:04"))
+		pdf.TextAt(columns[1], pdf.Y(), 9, journalTypeNames[e.Type])
+		pdf.TextAt(columns[2], pdf.Y(), 9, strconv.FormatInt(e.Amount, 10))
+		pdf.TextAt(columns[3], pdf.Y(), 9, strconv.FormatInt(e.BalanceAfter, 10))
+		pdf.TextAt(columns[4], pdf.Y(), 9, e.Description)
+	}
+	if len(s.Entries) == 0 {
+		pdf.Line(pdfMargin, 10, "本月无积分变动")
+	}
+
+	return pdf.Bytes()
+}
+
+// 获取积分月度对账单，format支持json（默认）、csv、pdf
+func getPointsStatement(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	month, err := time.ParseInLocation("2006-01", c.Param("month"), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "month must be in YYYY-MM format"})
+		return
+	}
+	if month.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "month is in the future"})
+		return
+	}
+
+	statement, err := buildPointsStatement(userID, month)
+	if err != nil {
+		logger.Errorf("Failed to build points statement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to build statement"})
+		return
+	}
+
+	filename := fmt.Sprintf("points-statement-%s", statement.Month)
+	switch c.DefaultQuery("format", "json") {
+	case "csv":
+		data, err := statement.CSV()
+		if err != nil {
+			logger.Errorf("Failed to export statement csv: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to export statement"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", filename))
+		c.Data(http.StatusOK, "application/pdf", statement.PDF())
+	case "json":
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "success",
+			"data":    statement,
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "format must be json, csv or pdf"})
+	}
+}
+
+// 获取即将过期的积分
+func getExpiringPoints(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days <= 0 {
+		days = 30
+	}
+	now := time.Now()
+
+	var batches []PointsBatch
+	if err := db.Where("user_id = ? AND remaining > 0 AND expires_at > ? AND expires_at <= ?",
+		userID, now, now.AddDate(0, 0, days)).
+		Order("expires_at ASC").Find(&batches).Error; err != nil {
+		logger.Errorf("Failed to load expiring points: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "Failed to load expiring points"})
+		return
+	}
+
+	var total int64
+	for _, b := range batches {
+		total += b.Remaining
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"total":   total,
+			"batches": batches,
+		},
+	})
+}
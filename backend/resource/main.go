@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
 	"strings"
 	"syscall"
 	"time"
@@ -38,6 +39,9 @@ func main() {
 		log.Fatalf("Failed to connect to Consul: %v", err)
 	}
 
+	// 初始化OCR识别引擎
+	initOCRProvider()
+
 	// 注册服务到Consul
 	if err := registerService(); err != nil {
 		log.Fatalf("Failed to register service: %v", err)
@@ -75,6 +79,13 @@ func loadConfig() {
 	viper.SetDefault("database.name", "jobfirst")
 	viper.SetDefault("consul.address", "localhost:8202")
 	viper.SetDefault("redis.address", "localhost:8201")
+	viper.SetDefault("ocr.provider", "tesseract")
+	viper.SetDefault("ocr.tesseract_path", "")
+	viper.SetDefault("ocr.default_language", "zh+en")
+	viper.SetDefault("ocr.page_seg_mode", 3)
+	viper.SetDefault("ocr.timeout", "1m")
+	viper.SetDefault("ocr.max_image_size", 10<<20)
+	viper.SetDefault("ocr.max_concurrency", runtime.NumCPU())
 
 	// 从环境变量读取
 	viper.AutomaticEnv()
@@ -249,7 +260,7 @@ func setupRouter() *gin.Engine {
 				"/resource/ocr/general": gin.H{
 					"post": gin.H{
 						"summary":     "OCR通用识别",
-						"description": "OCR通用文本识别接口",
+						"description": "OCR通用文本识别接口，上传multipart的file字段或直接以请求体上传图片，language指定语言如zh+en",
 						"tags":        []string{"OCR识别"},
 						"responses": gin.H{
 							"200": gin.H{
@@ -261,8 +272,11 @@ func setupRouter() *gin.Engine {
 										"data": gin.H{
 											"type": "object",
 											"properties": gin.H{
-												"text":       gin.H{"type": "string", "example": "这是OCR识别出的文本内容"},
-												"confidence": gin.H{"type": "number", "example": 0.95},
+												"text":            gin.H{"type": "string", "example": "这是OCR识别出的文本内容"},
+												"confidence":      gin.H{"type": "number", "example": 0.95},
+												"provider":        gin.H{"type": "string", "example": "tesseract"},
+												"pages":           gin.H{"type": "array", "description": "分页结果，含每行文本、位置框与置信度"},
+												"processing_time": gin.H{"type": "integer", "example": 320},
 											},
 										},
 										"msg": gin.H{"type": "string", "example": "OCR识别成功"},
@@ -301,17 +315,14 @@ func setupRouter() *gin.Engine {
 			})
 		})
 
-		// OCR通用识别API - 白名单路径
-		resource.POST("/ocr/general", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"code": 0,
-				"data": gin.H{
-					"text":       "这是OCR识别出的文本内容",
-					"confidence": 0.95,
-				},
-				"msg": "OCR识别成功",
-			})
-		})
+		// OCR识别API - 识别会占用tesseract进程，需要认证
+		resource.GET("/ocr/languages", listOCRLanguages)
+		ocrGroup := resource.Group("/ocr", authMiddleware())
+		{
+			ocrGroup.POST("/general", recognizeGeneral)
+			ocrGroup.POST("/idcard", recognizeIDCard)
+			ocrGroup.POST("/business-license", recognizeBusinessLicense)
+		}
 
 		// 获取多个资源URL
 		resource.GET("/urls", func(c *gin.Context) {
@@ -437,3 +448,44 @@ func setupRouter() *gin.Engine {
 
 	return router
 }
+
+// 认证中间件
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("Authorization")
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "Authorization header required"})
+			c.Abort()
+			return
+		}
+
+		// 移除Bearer前缀
+		token = strings.TrimPrefix(token, "Bearer ")
+
+		// 验证JWT token
+		userID, username, err := validateToken(token)
+		if err != nil || userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		// 将用户信息存储到上下文
+		c.Set("user_id", userID)
+		c.Set("username", username)
+		c.Next()
+	}
+}
+
+// JWT token验证函数（简化版）
+func validateToken(tokenString string) (uint, string, error) {
+	// 这里应该验证JWT token
+	// 为了简化，这里解析简单的token格式
+	var userID uint
+	var username string
+	_, err := fmt.Sscanf(tokenString, "token_%d_%s", &userID, &username)
+	if err != nil {
+		return 0, "", err
+	}
+	return userID, username, nil
+}
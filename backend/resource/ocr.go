@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"resume-centre/common/ocr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// OCR识别引擎，未安装tesseract时为nil
+var ocrProvider ocr.Provider
+
+// 同时运行的识别数上限，每次识别启动一个tesseract进程
+var ocrSlots chan struct{}
+
+// multipart表单除图片外的边界和字段开销
+const multipartOverhead = 64 << 10
+
+func initOCRProvider() {
+	provider, err := ocr.NewProvider(ocr.Config{
+		Provider:         viper.GetString("ocr.provider"),
+		TesseractPath:    viper.GetString("ocr.tesseract_path"),
+		DefaultLanguages: ocr.ParseLanguages(viper.GetString("ocr.default_language")),
+		PageSegMode:      viper.GetInt("ocr.page_seg_mode"),
+	})
+	if err != nil {
+		log.Printf("OCR disabled: %v", err)
+		return
+	}
+	ocrProvider = provider
+	ocrSlots = make(chan struct{}, viper.GetInt("ocr.max_concurrency"))
+	log.Printf("OCR provider %s ready, languages: %v", provider.Name(), provider.Languages())
+}
+
+// 识别上传的图片：multipart的file字段或原始请求体，language为"zh+en"形式
+func recognizeImage(c *gin.Context) (*ocr.Result, int64, bool) {
+	if ocrProvider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "msg": "OCR服务不可用"})
+		return nil, 0, false
+	}
+
+	maxSize := viper.GetInt64("ocr.max_image_size")
+	if c.Request.ContentLength > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"code": 413, "msg": "图片过大"})
+		return nil, 0, false
+	}
+
+	// 未声明长度的请求体在读取时截断，避免multipart解析写入过大的临时文件
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	var image io.Reader = c.Request.Body
+	file, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"code": 413, "msg": "图片过大"})
+		return nil, 0, false
+	}
+	if err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "读取图片失败"})
+			return nil, 0, false
+		}
+		defer f.Close()
+		image = f
+	}
+	image = io.LimitReader(image, maxSize+1)
+
+	language := c.DefaultPostForm("language", c.Query("language"))
+	opts := ocr.Options{Languages: ocr.ParseLanguages(language)}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), viper.GetDuration("ocr.timeout"))
+	defer cancel()
+
+	// 等待空闲的识别名额，超时仍未轮到时提示繁忙
+	select {
+	case ocrSlots <- struct{}{}:
+		defer func() { <-ocrSlots }()
+	case <-ctx.Done():
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "msg": "OCR服务繁忙，请稍后重试"})
+		return nil, 0, false
+	}
+
+	start := time.Now()
+	counter := &countingReader{r: image}
+	res, err := ocrProvider.Recognize(ctx, counter, opts)
+	elapsed := time.Since(start).Milliseconds()
+	if counter.n > maxSize || errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"code": 413, "msg": "图片过大"})
+		return nil, 0, false
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ocr.ErrUnsupportedLanguage):
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "不支持的识别语言", "data": gin.H{"languages": ocrProvider.Languages()}})
+		case errors.Is(err, ocr.ErrInvalidImage):
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "无法识别的图片格式"})
+		default:
+			log.Printf("OCR recognition failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "OCR识别失败"})
+		}
+		return nil, 0, false
+	}
+	return res, elapsed, true
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// OCR通用识别
+func recognizeGeneral(c *gin.Context) {
+	res, elapsed, ok := recognizeImage(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"text":            res.Text(),
+			"confidence":      res.Confidence,
+			"provider":        res.Provider,
+			"languages":       res.Languages,
+			"pages":           res.Pages,
+			"processing_time": elapsed,
+		},
+		"msg": "OCR识别成功",
+	})
+}
+
+// 身份证识别
+func recognizeIDCard(c *gin.Context) {
+	res, elapsed, ok := recognizeImage(c)
+	if !ok {
+		return
+	}
+	card, err := ocr.ExtractIDCard(res)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"code": 422, "msg": "未识别到身份证信息", "data": gin.H{"text": res.Text()}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"fields":          card,
+			"confidence":      res.Confidence,
+			"processing_time": elapsed,
+		},
+		"msg": "身份证识别成功",
+	})
+}
+
+// 营业执照识别
+func recognizeBusinessLicense(c *gin.Context) {
+	res, elapsed, ok := recognizeImage(c)
+	if !ok {
+		return
+	}
+	license, err := ocr.ExtractBusinessLicense(res)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"code": 422, "msg": "未识别到营业执照信息", "data": gin.H{"text": res.Text()}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"fields":          license,
+			"confidence":      res.Confidence,
+			"processing_time": elapsed,
+		},
+		"msg": "营业执照识别成功",
+	})
+}
+
+// 支持的识别语言
+func listOCRLanguages(c *gin.Context) {
+	if ocrProvider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "msg": "OCR服务不可用"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{"provider": ocrProvider.Name(), "languages": ocrProvider.Languages()},
+		"msg":  "success",
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"resume-centre/shared/infrastructure"
+	"resume-centre/shared/infrastructure/points"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -37,6 +38,7 @@ func main() {
 	if err := loadConfig(); err != nil {
 		logger.Fatalf("Failed to load config: %v", err)
 	}
+	initPointsClient()
 
 	// 初始化数据库连接
 	if err := initDatabase(); err != nil {
@@ -108,6 +110,8 @@ func loadConfig() error {
 	viper.SetDefault("redis.port", "8201")
 	viper.SetDefault("consul.host", "localhost")
 	viper.SetDefault("consul.port", "8202")
+	viper.SetDefault("points.service_url", "http://localhost:9004")
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -134,7 +138,7 @@ func initDatabase() error {
 	}
 
 	// 自动迁移
-	if err := db.AutoMigrate(&Resume{}, &ResumeTemplate{}, &ResumeBanner{}); err != nil {
+	if err := db.AutoMigrate(&Resume{}, &ResumeTemplate{}, &ResumeBanner{}, &ResumeAuth{}, &ResumeBlacklist{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
@@ -355,7 +359,9 @@ func setupRouter() *gin.Engine {
 			authResume.PUT("/auth/:id", updateResumeAuth)
 			authResume.GET("/blacklist", getBlacklist)
 			authResume.POST("/black/:id", setBlack)
+			authResume.DELETE("/black/:id", removeBlack)
 			authResume.GET("/preview/:id", previewResume)
+			authResume.GET("/download/:id", downloadResume)
 		}
 	}
 
@@ -395,7 +401,9 @@ func setupRouter() *gin.Engine {
 				authResumeAPI.PUT("/auth/:id", updateResumeAuth)
 				authResumeAPI.GET("/blacklist", getBlacklist)
 				authResumeAPI.POST("/black/:id", setBlack)
+				authResumeAPI.DELETE("/black/:id", removeBlack)
 				authResumeAPI.GET("/preview/:id", previewResume)
+				authResumeAPI.GET("/download/:id", downloadResume)
 			}
 		}
 	}
@@ -538,13 +546,24 @@ func deleteResume(c *gin.Context) {
 	})
 }
 
-// 获取简历信息
+// 获取简历信息，只能获取自己的简历
 func getResume(c *gin.Context) {
-	resumeID := c.Param("id")
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+	resume, ok := findOwnResume(c, userID)
+	if !ok {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data":    gin.H{"id": resumeID, "title": "简历详情", "content": "简历内容"},
+		"data":    resume,
 	})
 }
 
@@ -591,51 +610,114 @@ func uploadResume(c *gin.Context) {
 	})
 }
 
-// 获取简历授权信息
-func getResumeAuth(c *gin.Context) {
+// 预览简历
+func previewResume(c *gin.Context) {
 	resumeID := c.Param("id")
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data":    gin.H{"id": resumeID, "auth": "public"},
+		"data":    gin.H{"id": resumeID, "preview_url": "https://example.com/preview.pdf"},
 	})
 }
 
-// 更新简历授权信息
-func updateResumeAuth(c *gin.Context) {
+// 下载简历（下载他人简历需消耗简历下载券，同一份简历重复下载不再扣券）
+func downloadResume(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "用户未认证",
+		})
+		return
+	}
+
 	resumeID := c.Param("id")
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "授权信息更新成功",
-		"data":    gin.H{"id": resumeID},
-	})
-}
+	var resume Resume
+	if err := db.Where("id = ? AND deleted_at IS NULL", resumeID).First(&resume).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "简历不存在",
+		})
+		return
+	}
 
-// 获取黑名单
-func getBlacklist(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "success",
-		"data":    []gin.H{},
-	})
-}
+	if resume.UserID != userID {
+		if resume.Status != "published" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "简历未公开",
+			})
+			return
+		}
 
-// 设置黑名单
-func setBlack(c *gin.Context) {
-	resumeID := c.Param("id")
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "黑名单设置成功",
-		"data":    gin.H{"id": resumeID},
-	})
-}
+		var auth ResumeAuth
+		if err := db.Where("resume_id = ?", resume.ID).First(&auth).Error; err == nil && !auth.AllowDownload {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "简历不允许下载",
+			})
+			return
+		}
+
+		// 求职者屏蔽的企业的成员不能下载，与候选人推荐的屏蔽规则一致
+		blocked, err := companyBlocked(resume.UserID, userID)
+		if err != nil {
+			logger.Errorf("Failed to check resume blacklist: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "下载简历失败",
+			})
+			return
+		}
+		if blocked {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "简历不允许下载",
+			})
+			return
+		}
+
+		reservation, err := pointsClient.Reserve(userID, points.EntitlementResumeDownload, resumeID)
+		if err != nil {
+			if err == points.ErrEntitlementUnavailable {
+				c.JSON(http.StatusPaymentRequired, gin.H{
+					"code":    402,
+					"message": "简历下载券不足",
+				})
+				return
+			}
+			logger.Errorf("Failed to reserve entitlement: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"code":    503,
+				"message": "权益服务暂不可用",
+			})
+			return
+		}
+
+		if reservation.Status != "committed" {
+			if err := pointsClient.Commit(reservation.ID); err != nil {
+				logger.Errorf("Failed to commit entitlement %s: %v", reservation.ID, err)
+				if rbErr := pointsClient.Rollback(reservation.ID); rbErr != nil {
+					logger.Errorf("Failed to rollback entitlement %s: %v", reservation.ID, rbErr)
+				}
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"code":    503,
+					"message": "权益服务暂不可用",
+				})
+				return
+			}
+		}
+	}
 
-// 预览简历
-func previewResume(c *gin.Context) {
-	resumeID := c.Param("id")
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data":    gin.H{"id": resumeID, "preview_url": "https://example.com/preview.pdf"},
+		"data": gin.H{
+			"id":          resume.ID,
+			"title":       resume.Title,
+			"content":     resume.Content,
+			"template_id": resume.TemplateID,
+			"updated_at":  resume.UpdatedAt,
+		},
 	})
 }
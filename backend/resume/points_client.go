@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+
+	"resume-centre/shared/infrastructure/points"
+
+	"github.com/spf13/viper"
+)
+
+// 积分服务客户端，配置加载后初始化
+var pointsClient *points.Client
+
+func initPointsClient() {
+	pointsClient = points.NewClient(pointsServiceURL, viper.GetString("internal.service_token"), "resume-service")
+}
+
+// 获取积分服务地址：优先通过Consul发现，失败时使用配置
+func pointsServiceURL() string {
+	if consulClient != nil {
+		services, _, err := consulClient.Health().Service("points-service", "", true, nil)
+		if err == nil && len(services) > 0 {
+			svc := services[0].Service
+			return fmt.Sprintf("http://%s:%d", svc.Address, svc.Port)
+		}
+	}
+	return viper.GetString("points.service_url")
+}
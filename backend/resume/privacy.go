@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 简历授权设置，没有记录时按公开处理
+type ResumeAuth struct {
+	ResumeID      uint      `json:"resume_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	IsPublic      bool      `json:"is_public" gorm:"default:true"`      // 是否允许企业搜索和推荐
+	AllowView     bool      `json:"allow_view" gorm:"default:true"`     // 是否允许企业查看
+	AllowDownload bool      `json:"allow_download" gorm:"default:true"` // 是否允许企业下载
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// 求职者屏蔽的企业，对该用户的全部简历生效
+type ResumeBlacklist struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_resume_blacklist_user_company"`
+	CompanyID   uint      `json:"company_id" gorm:"not null;uniqueIndex:idx_resume_blacklist_user_company;index"`
+	CompanyName string    `json:"company_name" gorm:"type:varchar(100)"`
+	Reason      string    `json:"reason" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// 用户是否为被简历所有者屏蔽的企业的有效成员，企业成员关系由企业服务维护在company_members表中
+func companyBlocked(ownerID, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&ResumeBlacklist{}).
+		Joins("JOIN company_members ON company_members.company_id = resume_blacklists.company_id").
+		Where("resume_blacklists.user_id = ? AND company_members.user_id = ? AND company_members.status = ?", ownerID, userID, "active").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// 查询当前用户的简历，不存在或不属于当前用户时返回404
+func findOwnResume(c *gin.Context, userID uint) (*Resume, bool) {
+	var resume Resume
+	err := db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", c.Param("id"), userID).First(&resume).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "简历不存在"})
+		} else {
+			logger.Errorf("Failed to get resume: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取简历失败"})
+		}
+		return nil, false
+	}
+	return &resume, true
+}
+
+// 获取简历授权信息
+func getResumeAuth(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户未认证"})
+		return
+	}
+	resume, ok := findOwnResume(c, userID)
+	if !ok {
+		return
+	}
+
+	auth := ResumeAuth{ResumeID: resume.ID, UserID: userID, IsPublic: true, AllowView: true, AllowDownload: true}
+	if err := db.Where("resume_id = ?", resume.ID).First(&auth).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Errorf("Failed to get resume auth: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取授权信息失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    auth,
+	})
+}
+
+// 更新简历授权信息，未传的字段保持不变
+func updateResumeAuth(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户未认证"})
+		return
+	}
+	resume, ok := findOwnResume(c, userID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IsPublic      *bool `json:"is_public"`
+		AllowView     *bool `json:"allow_view"`
+		AllowDownload *bool `json:"allow_download"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	auth := ResumeAuth{ResumeID: resume.ID, UserID: userID, IsPublic: true, AllowView: true, AllowDownload: true}
+	db.Where("resume_id = ?", resume.ID).First(&auth)
+	if req.IsPublic != nil {
+		auth.IsPublic = *req.IsPublic
+	}
+	if req.AllowView != nil {
+		auth.AllowView = *req.AllowView
+	}
+	if req.AllowDownload != nil {
+		auth.AllowDownload = *req.AllowDownload
+	}
+	// 用Select保存false值
+	if err := db.Select("*").Save(&auth).Error; err != nil {
+		logger.Errorf("Failed to update resume auth: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新授权信息失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "授权信息更新成功",
+		"data":    auth,
+	})
+}
+
+// 获取黑名单
+func getBlacklist(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户未认证"})
+		return
+	}
+
+	blacklist := []ResumeBlacklist{}
+	if err := db.Where("user_id = ?", userID).Order("created_at DESC").Find(&blacklist).Error; err != nil {
+		logger.Errorf("Failed to get blacklist: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取黑名单失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    blacklist,
+	})
+}
+
+// 屏蔽企业，:id为企业ID
+func setBlack(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户未认证"})
+		return
+	}
+	companyID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || companyID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "企业ID无效"})
+		return
+	}
+
+	var req struct {
+		CompanyName string `json:"company_name"`
+		Reason      string `json:"reason"`
+	}
+	c.ShouldBindJSON(&req)
+
+	entry := ResumeBlacklist{UserID: userID, CompanyID: uint(companyID), CompanyName: req.CompanyName, Reason: req.Reason}
+	if err := db.Where(ResumeBlacklist{UserID: userID, CompanyID: uint(companyID)}).
+		Assign(ResumeBlacklist{CompanyName: req.CompanyName, Reason: req.Reason}).
+		FirstOrCreate(&entry).Error; err != nil {
+		logger.Errorf("Failed to set blacklist: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "黑名单设置失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "黑名单设置成功",
+		"data":    entry,
+	})
+}
+
+// 取消屏蔽企业
+func removeBlack(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户未认证"})
+		return
+	}
+
+	if err := db.Where("user_id = ? AND company_id = ?", userID, c.Param("id")).Delete(&ResumeBlacklist{}).Error; err != nil {
+		logger.Errorf("Failed to remove blacklist: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "取消屏蔽失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已取消屏蔽",
+		"data":    gin.H{"company_id": c.Param("id")},
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// 企业成员表由企业服务维护，测试中只建需要的列
+type testCompanyMember struct {
+	ID        uint `gorm:"primaryKey;autoIncrement"`
+	CompanyID uint
+	UserID    uint
+	Role      string `gorm:"type:varchar(20)"`
+	Status    string `gorm:"type:varchar(20)"`
+}
+
+func (testCompanyMember) TableName() string {
+	return "company_members"
+}
+
+// 需要MySQL，设置RESUME_TEST_MYSQL_DSN后执行，如
+// root:password@tcp(localhost:3306)/resume_test?charset=utf8mb4&parseTime=True&loc=Local
+func testResumeDB(t *testing.T) {
+	dsn := os.Getenv("RESUME_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("Skipping test - RESUME_TEST_MYSQL_DSN not set")
+	}
+	testDB, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Skipf("Skipping test - MySQL not available: %v", err)
+	}
+	if err := testDB.AutoMigrate(&Resume{}, &ResumeAuth{}, &ResumeBlacklist{}, &testCompanyMember{}); err != nil {
+		t.Fatal(err)
+	}
+	previous := db
+	db = testDB
+	t.Cleanup(func() { db = previous })
+}
+
+func TestDownloadResumeBlacklistedCompany(t *testing.T) {
+	testResumeDB(t)
+
+	// 用户和企业ID取时间戳，避免与库中已有数据冲突
+	base := uint(time.Now().UnixNano()%1_000_000_000) + 1_000_000_000
+	owner, recruiter, disabled, other := base, base+1, base+2, base+3
+	blockedCompany, otherCompany := base, base+1
+
+	resume := Resume{UserID: owner, Title: "Go后端工程师", Status: "published"}
+	members := []testCompanyMember{
+		{CompanyID: blockedCompany, UserID: recruiter, Role: "recruiter", Status: "active"},
+		{CompanyID: blockedCompany, UserID: disabled, Role: "recruiter", Status: "disabled"},
+		{CompanyID: otherCompany, UserID: other, Role: "recruiter", Status: "active"},
+	}
+	if err := db.Create(&resume).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&members).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&ResumeBlacklist{UserID: owner, CompanyID: blockedCompany}).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Delete(&resume)
+		db.Where("company_id IN ?", []uint{blockedCompany, otherCompany}).Delete(&testCompanyMember{})
+		db.Where("user_id = ?", owner).Delete(&ResumeBlacklist{})
+	})
+
+	cases := []struct {
+		name    string
+		userID  uint
+		blocked bool
+	}{
+		{"member of blacklisted company", recruiter, true},
+		{"disabled member", disabled, false},
+		{"member of another company", other, false},
+		{"owner", owner, false},
+	}
+	for _, tc := range cases {
+		blocked, err := companyBlocked(owner, tc.userID)
+		if err != nil || blocked != tc.blocked {
+			t.Errorf("%s: companyBlocked = %v, %v; want %v", tc.name, blocked, err, tc.blocked)
+		}
+	}
+
+	// 被屏蔽企业的成员在预占下载券之前即被拒绝
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(resume.ID), 10)}}
+	c.Set("userID", recruiter)
+	downloadResume(c)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("download by blacklisted company: status %d, body %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,112 @@
+// Package points 积分服务内部API客户端，供各业务服务预占、确认和回滚用户权益
+package points
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 积分服务权益类型
+const (
+	EntitlementResumeDownload = "resume_download"
+	EntitlementJobPin         = "job_pin"
+)
+
+// ErrEntitlementUnavailable 用户没有可用的权益
+var ErrEntitlementUnavailable = errors.New("no available entitlement")
+
+// Reservation 权益预占记录
+type Reservation struct {
+	ID            string `json:"id"`
+	EntitlementID string `json:"entitlement_id"`
+	Status        string `json:"status"`
+}
+
+// Client 积分服务客户端。BaseURL每次请求时调用，便于通过服务发现获取最新地址
+type Client struct {
+	BaseURL  func() string
+	Token    string
+	Consumer string
+	HTTP     *http.Client
+}
+
+// NewClient 创建客户端，consumer为调用方服务名，记录在预占记录上
+func NewClient(baseURL func() string, token, consumer string) *Client {
+	return &Client{
+		BaseURL:  baseURL,
+		Token:    token,
+		Consumer: consumer,
+		HTTP:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Reserve 预占用户权益，referenceID用于幂等
+func (c *Client) Reserve(userID uint, entitlementType, referenceID string) (*Reservation, error) {
+	var reservation Reservation
+	err := c.call("/entitlements/reserve", map[string]interface{}{
+		"user_id":      userID,
+		"type":         entitlementType,
+		"consumer":     c.Consumer,
+		"reference_id": referenceID,
+	}, &reservation)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Commit 确认消费权益
+func (c *Client) Commit(reservationID string) error {
+	return c.call("/entitlements/commit", map[string]string{"reservation_id": reservationID}, nil)
+}
+
+// Rollback 回滚预占的权益
+func (c *Client) Rollback(reservationID string) error {
+	return c.call("/entitlements/rollback", map[string]string{"reservation_id": reservationID}, nil)
+}
+
+// 调用积分服务内部API
+func (c *Client) call(path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL()+"/points/internal"+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.Token)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("points service unavailable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid points service response: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusPaymentRequired {
+		return ErrEntitlementUnavailable
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("points service error (%d): %s", resp.StatusCode, result.Message)
+	}
+
+	if out != nil {
+		return json.Unmarshal(result.Data, out)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Package storage 存储服务内部API客户端，供各业务服务以流的方式上传下载文件
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ErrFileNotFound 存储服务中不存在该文件
+var ErrFileNotFound = errors.New("file not found in storage")
+
+// Client 存储服务客户端。BaseURL每次请求时调用，便于通过服务发现获取最新地址
+type Client struct {
+	BaseURL func() string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient 创建客户端，超时由调用方的context控制，大文件传输不设整体超时
+func NewClient(baseURL func() string, token string) *Client {
+	return &Client{
+		BaseURL: baseURL,
+		Token:   token,
+		HTTP:    &http.Client{},
+	}
+}
+
+// Open 以服务身份打开文件内容流，调用方负责关闭
+func (c *Client) Open(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	return c.open(ctx, 0, fileID)
+}
+
+// OpenAs 以userID的身份打开文件内容流，文件不属于该用户时视为不存在，调用方负责关闭
+func (c *Client) OpenAs(ctx context.Context, userID uint, fileID string) (io.ReadCloser, error) {
+	return c.open(ctx, userID, fileID)
+}
+
+func (c *Client) open(ctx context.Context, userID uint, fileID string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL()+"/api/v1/files/"+fileID+"/content", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Service-Token", c.Token)
+	if userID != 0 {
+		req.Header.Set("X-User-ID", strconv.FormatUint(uint64(userID), 10))
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, fileID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage service returned %d for file %s", resp.StatusCode, fileID)
+	}
+	return resp.Body, nil
+}
+
+// Put 以请求体流式上传文件，文件归属userID，返回新文件ID
+func (c *Client) Put(ctx context.Context, userID uint, name, contentType string, body io.Reader) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL()+"/api/v1/files", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Service-Token", c.Token)
+	req.Header.Set("X-User-ID", strconv.FormatUint(uint64(userID), 10))
+	req.Header.Set("X-File-Name", name)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		File struct {
+			ID string `json:"id"`
+		} `json:"file"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode storage response: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("storage service returned %d: %s", resp.StatusCode, result.Error)
+	}
+	return result.File.ID, nil
+}
@@ -0,0 +1,181 @@
+// Package hashchain 防篡改哈希链的公共实现。每条记录包含全局上一条记录的哈希，
+// 以及所属各范围（如用户）上一条记录的哈希；直接修改、插入或删除记录都会使链断裂。
+// 积分服务的流水和区块链服务的积分交易记录共用此实现，各自负责存储和链头加锁。
+package hashchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 链的起始哈希
+var GenesisHash = strings.Repeat("0", 64)
+
+// 全局链范围
+const GlobalScope = "global"
+
+// 断链原因
+const (
+	ReasonRecordModified     = "record_modified"     // 记录内容与哈希不符
+	ReasonSequenceGap        = "sequence_gap"        // 序号不连续，记录被删除或插入
+	ReasonGlobalLinkBroken   = "global_link_broken"  // 全局前序哈希不符
+	ReasonUserLinkBroken     = "user_link_broken"    // 范围内前序哈希不符
+	ReasonHeadMismatch       = "head_mismatch"       // 链尾与链头记录不符，尾部记录被删除
+	ReasonCheckpointMismatch = "checkpoint_mismatch" // 检查点哈希与链上记录不符
+	ReasonAnchorMismatch     = "anchor_mismatch"     // 检查点与区块链锚定值不符
+)
+
+// 计算记录哈希：字段按规范化JSON数组编码，避免分隔符歧义
+func Hash(fields ...interface{}) string {
+	payload, _ := json.Marshal(fields)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// 范围内的前序链接
+type Link struct {
+	Scope string
+	Prev  string
+}
+
+// 待校验的记录
+type Entry struct {
+	Seq        uint64
+	Hash       string // 记录中保存的哈希
+	Computed   string // 按当前内容重新计算的哈希
+	GlobalPrev string
+	Links      []Link
+}
+
+// 第一处断链
+type Break struct {
+	Seq      uint64
+	Scope    string // 用户链断裂时的范围
+	Reason   string
+	Expected string
+	Actual   string
+}
+
+// 按序号顺序逐条校验记录
+type Verifier struct {
+	scope   string // 非空时只校验该范围的链，跳过全局链接
+	lastSeq uint64
+	global  string
+	heads   map[string]string
+	checked int64
+}
+
+func NewVerifier(scope string) *Verifier {
+	return &Verifier{scope: scope, global: GenesisHash, heads: map[string]string{}}
+}
+
+// 校验下一条记录，返回断链信息
+func (v *Verifier) Add(e *Entry) *Break {
+	brk := func(reason, scope, expected, actual string) *Break {
+		return &Break{Seq: e.Seq, Scope: scope, Reason: reason, Expected: expected, Actual: actual}
+	}
+
+	if e.Computed != e.Hash {
+		return brk(ReasonRecordModified, "", e.Computed, e.Hash)
+	}
+
+	if v.scope == "" {
+		if e.Seq != v.lastSeq+1 {
+			return brk(ReasonSequenceGap, "", strconv.FormatUint(v.lastSeq+1, 10), strconv.FormatUint(e.Seq, 10))
+		}
+		if e.GlobalPrev != v.global {
+			return brk(ReasonGlobalLinkBroken, "", v.global, e.GlobalPrev)
+		}
+	} else if e.Seq <= v.lastSeq {
+		return brk(ReasonSequenceGap, "", "> "+strconv.FormatUint(v.lastSeq, 10), strconv.FormatUint(e.Seq, 10))
+	}
+
+	for _, link := range e.Links {
+		if !v.covers(link.Scope) {
+			continue
+		}
+		if expected := v.ScopeHead(link.Scope); link.Prev != expected {
+			return brk(ReasonUserLinkBroken, link.Scope, expected, link.Prev)
+		}
+	}
+
+	v.lastSeq = e.Seq
+	v.global = e.Hash
+	for _, link := range e.Links {
+		if v.covers(link.Scope) {
+			v.heads[link.Scope] = e.Hash
+		}
+	}
+	v.checked++
+	return nil
+}
+
+// 只校验单个范围时忽略记录在其他范围的链接
+func (v *Verifier) covers(scope string) bool {
+	return v.scope == "" || v.scope == scope
+}
+
+// 已校验记录数
+func (v *Verifier) Checked() int64 {
+	return v.checked
+}
+
+// 已校验部分的链尾
+func (v *Verifier) Head() (uint64, string) {
+	return v.lastSeq, v.global
+}
+
+// 范围的链尾，无记录时为起始哈希
+func (v *Verifier) ScopeHead(scope string) string {
+	if scope == GlobalScope {
+		return v.global
+	}
+	if head, ok := v.heads[scope]; ok {
+		return head
+	}
+	return GenesisHash
+}
+
+// 已出现的范围，按名称排序
+func (v *Verifier) Scopes() []string {
+	scopes := make([]string, 0, len(v.heads))
+	for scope := range v.heads {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// 链头记录
+type Head struct {
+	Scope string
+	Seq   uint64
+	Hash  string
+}
+
+// 比对存储的链头与校验得到的链尾：每个链头都须与对应范围最后一条记录一致，
+// 每个出现过的范围都须有链头
+func (v *Verifier) CheckHeads(heads []Head) *Break {
+	seen := map[string]bool{}
+	for _, h := range heads {
+		seen[h.Scope] = true
+		if actual := v.ScopeHead(h.Scope); actual != h.Hash {
+			return &Break{Seq: h.Seq, Scope: h.Scope, Reason: ReasonHeadMismatch, Expected: h.Hash, Actual: actual}
+		}
+	}
+	for _, scope := range v.Scopes() {
+		if !seen[scope] {
+			return &Break{Scope: scope, Reason: ReasonHeadMismatch, Expected: "missing", Actual: v.heads[scope]}
+		}
+	}
+	return nil
+}
+
+// 锚定值是否与记录哈希一致，忽略0x前缀和大小写
+func AnchorMatches(anchored, hash string) bool {
+	return strings.EqualFold(strings.TrimPrefix(anchored, "0x"), strings.TrimPrefix(hash, "0x"))
+}
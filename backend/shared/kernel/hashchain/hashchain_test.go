@@ -0,0 +1,142 @@
+package hashchain
+
+import (
+	"testing"
+)
+
+// 构造记录：转出与转入范围各有一个前序链接
+type chain struct {
+	global string
+	heads  map[string]string
+	seq    uint64
+}
+
+func (c *chain) next(content string, scopes ...string) *Entry {
+	if c.heads == nil {
+		c.global, c.heads = GenesisHash, map[string]string{}
+	}
+	c.seq++
+	e := &Entry{Seq: c.seq, GlobalPrev: c.global}
+	for _, scope := range scopes {
+		prev, ok := c.heads[scope]
+		if !ok {
+			prev = GenesisHash
+		}
+		e.Links = append(e.Links, Link{Scope: scope, Prev: prev})
+	}
+	e.Hash = Hash(e.Seq, content, e.GlobalPrev, e.Links)
+	e.Computed = e.Hash
+	c.global = e.Hash
+	for _, scope := range scopes {
+		c.heads[scope] = e.Hash
+	}
+	return e
+}
+
+func build() []*Entry {
+	var c chain
+	return []*Entry{
+		c.next("a", "user:1", "user:2"),
+		c.next("b", "user:2", "user:3"),
+		c.next("c", "user:1", "user:1"),
+		c.next("d", "user:3", "user:1"),
+	}
+}
+
+func verify(v *Verifier, entries []*Entry) *Break {
+	for _, e := range entries {
+		if b := v.Add(e); b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+func TestVerifier(t *testing.T) {
+	entries := build()
+	v := NewVerifier("")
+	if b := verify(v, entries); b != nil {
+		t.Fatalf("intact chain reported broken: %+v", b)
+	}
+	if seq, hash := v.Head(); seq != 4 || hash != entries[3].Hash {
+		t.Fatalf("unexpected head %d %s", seq, hash)
+	}
+	if v.ScopeHead("user:2") != entries[1].Hash || v.ScopeHead("user:9") != GenesisHash {
+		t.Fatal("unexpected scope heads")
+	}
+
+	heads := []Head{
+		{Scope: GlobalScope, Seq: 4, Hash: entries[3].Hash},
+		{Scope: "user:1", Seq: 4, Hash: entries[3].Hash},
+		{Scope: "user:2", Seq: 2, Hash: entries[1].Hash},
+		{Scope: "user:3", Seq: 4, Hash: entries[3].Hash},
+	}
+	if b := v.CheckHeads(heads); b != nil {
+		t.Fatalf("heads reported broken: %+v", b)
+	}
+	if b := v.CheckHeads(heads[:3]); b == nil || b.Reason != ReasonHeadMismatch || b.Scope != "user:3" {
+		t.Fatalf("expected missing head for user:3, got %+v", b)
+	}
+	stale := append([]Head(nil), heads...)
+	stale[0].Hash = entries[2].Hash
+	if b := v.CheckHeads(stale); b == nil || b.Scope != GlobalScope {
+		t.Fatalf("expected global head mismatch, got %+v", b)
+	}
+}
+
+func TestVerifierDetectsTampering(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper func([]*Entry) []*Entry
+		reason string
+		seq    uint64
+	}{
+		{"modified", func(e []*Entry) []*Entry { e[1].Computed = Hash("changed"); return e }, ReasonRecordModified, 2},
+		{"deleted", func(e []*Entry) []*Entry { return append(e[:1], e[2:]...) }, ReasonSequenceGap, 3},
+		{"rehashed", func(e []*Entry) []*Entry {
+			e[1].Hash = Hash("changed")
+			e[1].Computed = e[1].Hash
+			return e
+		}, ReasonGlobalLinkBroken, 3},
+		{"relinked in one scope", func(e []*Entry) []*Entry {
+			// 伪造记录接在全局链上，但转入范围的前序不对
+			e[3].Links[1].Prev = GenesisHash
+			return e
+		}, ReasonUserLinkBroken, 4},
+	}
+	for _, tc := range cases {
+		b := verify(NewVerifier(""), tc.tamper(build()))
+		if b == nil || b.Reason != tc.reason || b.Seq != tc.seq {
+			t.Errorf("%s: unexpected break %+v", tc.name, b)
+		}
+	}
+}
+
+func TestScopedVerifier(t *testing.T) {
+	var scoped []*Entry
+	for _, e := range build() {
+		for _, l := range e.Links {
+			if l.Scope == "user:3" {
+				scoped = append(scoped, e)
+				break
+			}
+		}
+	}
+	// 单个范围的序号不连续，但需要递增且只校验范围内链接
+	if b := verify(NewVerifier("user:3"), scoped); b != nil {
+		t.Fatalf("scoped chain reported broken: %+v", b)
+	}
+	if b := verify(NewVerifier("user:3"), []*Entry{scoped[1], scoped[0]}); b == nil || b.Reason != ReasonUserLinkBroken {
+		t.Fatalf("expected reordered scope to break, got %+v", b)
+	}
+}
+
+func TestAnchorMatches(t *testing.T) {
+	hash := Hash("x")
+	if !AnchorMatches("0x"+hash, hash) || !AnchorMatches(hash, "0x"+hash) {
+		t.Fatal("0x prefix should be ignored")
+	}
+	if AnchorMatches(GenesisHash, hash) {
+		t.Fatal("different hashes matched")
+	}
+}
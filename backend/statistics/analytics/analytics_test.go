@@ -0,0 +1,123 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func at(d time.Duration) time.Time { return t0.Add(d) }
+
+func TestFunnelConversionAndMedianTimes(t *testing.T) {
+	const day = 24 * time.Hour
+	events := []Event{
+		// 完整走完漏斗
+		{1, "job_view", at(0)},
+		{1, "job_apply", at(time.Hour)},
+		{1, "interview", at(2 * day)},
+		{1, "hire", at(3 * day)},
+		// 面试超出转化窗口
+		{2, "job_view", at(0)},
+		{2, "job_apply", at(3 * time.Hour)},
+		{2, "interview", at(10 * day)},
+		// 投递早于浏览，不算转化
+		{3, "job_apply", at(-time.Hour)},
+		{3, "job_view", at(0)},
+		// 输入乱序
+		{4, "interview", at(day)},
+		{4, "job_view", at(0)},
+		{4, "job_apply", at(2 * time.Hour)},
+		// 没有第一步
+		{5, "hire", at(0)},
+	}
+
+	got := Funnel(events, []string{"job_view", "job_apply", "interview", "hire"}, 7*day)
+	want := []struct {
+		users          int
+		conversion     float64
+		stepConversion float64
+		medianSeconds  float64
+	}{
+		{4, 1, 1, 0},
+		{3, 0.75, 0.75, (2 * time.Hour).Seconds()},
+		{2, 0.5, 2.0 / 3, (34*time.Hour + 30*time.Minute).Seconds()},
+		{1, 0.25, 0.5, day.Seconds()},
+	}
+	for i, w := range want {
+		s := got[i]
+		if s.Users != w.users || !near(s.Conversion, w.conversion) || !near(s.StepConversion, w.stepConversion) || s.MedianSeconds != w.medianSeconds {
+			t.Errorf("step %s = %+v, want %+v", s.Step, s, w)
+		}
+	}
+
+	// 不限窗口时用户2也能到达面试
+	if got := Funnel(events, []string{"job_view", "job_apply", "interview"}, 0); got[2].Users != 3 {
+		t.Errorf("unbounded interview users = %d, want 3", got[2].Users)
+	}
+}
+
+func TestFunnelWithoutEvents(t *testing.T) {
+	got := Funnel(nil, []string{"a", "b"}, time.Hour)
+	if len(got) != 2 || got[1].Users != 0 || got[1].Conversion != 0 || got[1].StepConversion != 0 {
+		t.Errorf("empty funnel = %+v", got)
+	}
+}
+
+func TestCohortRetentionMatrix(t *testing.T) {
+	// 2024-01-01为周一
+	signups := []Signup{
+		{1, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{2, time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)},
+		{3, time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+	}
+	activity := []Event{
+		{1, "login", time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC)},
+		{1, "login", time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)},
+		{2, "login", time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC)}, // 仍是注册当天
+		{2, "login", time.Date(2024, 1, 10, 11, 0, 0, 0, time.UTC)},
+		{3, "login", time.Date(2024, 1, 9, 10, 0, 0, 0, time.UTC)},
+	}
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	cohorts := Cohorts(signups, activity, CohortOptions{Days: []int{1, 7}, Now: now})
+	if len(cohorts) != 2 || cohorts[0].Size != 2 || cohorts[1].Size != 1 {
+		t.Fatalf("cohorts = %+v", cohorts)
+	}
+	if w := cohorts[1].Week.Format("2006-01-02"); w != "2024-01-08" {
+		t.Errorf("second cohort week = %s", w)
+	}
+	checkCells(t, "exact week1", cohorts[0].Retention, [][2]int{{2, 1}, {1, 1}})
+	// 第7天尚未结束的用户不计入分母
+	checkCells(t, "exact week2", cohorts[1].Retention, [][2]int{{1, 1}, {0, 0}})
+
+	rolling := Cohorts(signups, activity, CohortOptions{Days: []int{1, 7}, Rolling: true, Now: now})
+	checkCells(t, "rolling week1", rolling[0].Retention, [][2]int{{2, 2}, {2, 2}})
+	if rolling[0].Retention[1].Rate != 1 {
+		t.Errorf("rolling rate = %v", rolling[0].Retention[1].Rate)
+	}
+}
+
+func TestSplitBySegment(t *testing.T) {
+	events := []Event{{UserID: 1}, {UserID: 2}, {UserID: 3}, {UserID: 1}}
+	segments := map[uint]string{1: "jobseeker", 2: "enterprise", 3: ""}
+	got := SplitEvents(events, segments)
+	if len(got["jobseeker"]) != 2 || len(got["enterprise"]) != 1 || len(got[UnknownSegment]) != 1 {
+		t.Errorf("split = %+v", got)
+	}
+	if got := SplitSignups([]Signup{{UserID: 9}}, segments); len(got[UnknownSegment]) != 1 {
+		t.Errorf("split signups = %+v", got)
+	}
+}
+
+func checkCells(t *testing.T, name string, cells []RetentionCell, want [][2]int) {
+	t.Helper()
+	for i, w := range want {
+		if cells[i].Eligible != w[0] || cells[i].Retained != w[1] {
+			t.Errorf("%s day %d = %d/%d, want %d/%d", name, cells[i].Day, cells[i].Retained, cells[i].Eligible, w[1], w[0])
+		}
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"resume-centre/statistics/rollup"
+)
+
+// Signup 用户注册时间
+type Signup struct {
+	UserID uint
+	At     time.Time
+}
+
+// RetentionCell 群组在第Day天的留存
+type RetentionCell struct {
+	Day int `json:"day"`
+	// 第Day天已结束（累计留存为已开始）的用户数，未到该天的用户不计入分母
+	Eligible int     `json:"eligible"`
+	Retained int     `json:"retained"`
+	Rate     float64 `json:"rate"`
+}
+
+// Cohort 同一周注册的用户群组
+type Cohort struct {
+	Week      time.Time       `json:"week"`
+	Size      int             `json:"size"`
+	Retention []RetentionCell `json:"retention"`
+}
+
+// CohortOptions 留存计算方式
+type CohortOptions struct {
+	// 统计的天数，如1、7、30；第N天指注册后[N*24h, (N+1)*24h)
+	Days []int
+	// 为true时计算累计留存：第N天及以后有任意行为即算留存
+	Rolling bool
+	// 计算时刻，用于判断各天是否已可观测
+	Now time.Time
+}
+
+// Cohorts 按注册周（周一开始，使用注册时间的时区）分组，计算每组的N日留存矩阵
+func Cohorts(signups []Signup, activity []Event, opts CohortOptions) []Cohort {
+	activeAt := make(map[uint][]time.Time)
+	for _, e := range activity {
+		activeAt[e.UserID] = append(activeAt[e.UserID], e.At)
+	}
+	for _, times := range activeAt {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	}
+
+	cohorts := make(map[int64]*Cohort)
+	for _, s := range signups {
+		week := rollup.Week.Truncate(s.At)
+		cohort := cohorts[week.Unix()]
+		if cohort == nil {
+			cohort = &Cohort{Week: week, Retention: make([]RetentionCell, len(opts.Days))}
+			for i, day := range opts.Days {
+				cohort.Retention[i].Day = day
+			}
+			cohorts[week.Unix()] = cohort
+		}
+		cohort.Size++
+
+		for i, day := range opts.Days {
+			from := s.At.Add(time.Duration(day) * 24 * time.Hour)
+			to := from.Add(24 * time.Hour)
+			if opts.Rolling {
+				if opts.Now.Before(from) {
+					continue
+				}
+				to = time.Time{}
+			} else if opts.Now.Before(to) {
+				continue
+			}
+			cell := &cohort.Retention[i]
+			cell.Eligible++
+			if activeBetween(activeAt[s.UserID], from, to) {
+				cell.Retained++
+			}
+		}
+	}
+
+	result := make([]Cohort, 0, len(cohorts))
+	for _, cohort := range cohorts {
+		for i := range cohort.Retention {
+			cell := &cohort.Retention[i]
+			cell.Rate = rate(cell.Retained, cell.Eligible)
+		}
+		result = append(result, *cohort)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Week.Before(result[j].Week) })
+	return result
+}
+
+// times已排序；to为零值表示不设上限
+func activeBetween(times []time.Time, from, to time.Time) bool {
+	i := sort.Search(len(times), func(i int) bool { return !times[i].Before(from) })
+	return i < len(times) && (to.IsZero() || times[i].Before(to))
+}
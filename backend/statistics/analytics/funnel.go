@@ -0,0 +1,107 @@
+// Package analytics 基于用户行为的漏斗、注册群组与留存分析
+package analytics
+
+import (
+	"sort"
+	"time"
+)
+
+// Event 一条用户行为
+type Event struct {
+	UserID uint
+	Type   string
+	At     time.Time
+}
+
+// FunnelStep 漏斗中的一步
+type FunnelStep struct {
+	Step string `json:"step"`
+	// 到达该步的用户数
+	Users int `json:"users"`
+	// 相对第一步的转化率
+	Conversion float64 `json:"conversion"`
+	// 相对上一步的转化率
+	StepConversion float64 `json:"step_conversion"`
+	// 从上一步到该步耗时的中位数（秒），第一步为0
+	MedianSeconds float64 `json:"median_seconds"`
+}
+
+// Funnel 计算有序漏斗：用户以第一步的首次发生开始，之后须在window内按顺序完成各步，
+// 每一步取上一步之后的首次发生。window<=0表示不限时长。
+func Funnel(events []Event, steps []string, window time.Duration) []FunnelStep {
+	result := make([]FunnelStep, len(steps))
+	for i, step := range steps {
+		result[i].Step = step
+	}
+	if len(steps) == 0 {
+		return result
+	}
+
+	durations := make([][]time.Duration, len(steps))
+	for _, userEvents := range byUser(events) {
+		reached := 0
+		var start, prev time.Time
+		for _, e := range userEvents {
+			if reached == len(steps) {
+				break
+			}
+			if e.Type != steps[reached] {
+				continue
+			}
+			if reached == 0 {
+				start = e.At
+			} else {
+				if window > 0 && e.At.Sub(start) > window {
+					break
+				}
+				durations[reached] = append(durations[reached], e.At.Sub(prev))
+			}
+			prev = e.At
+			result[reached].Users++
+			reached++
+		}
+	}
+
+	for i := range result {
+		result[i].Conversion = rate(result[i].Users, result[0].Users)
+		if i == 0 {
+			result[i].StepConversion = rate(result[i].Users, result[i].Users)
+			continue
+		}
+		result[i].StepConversion = rate(result[i].Users, result[i-1].Users)
+		result[i].MedianSeconds = median(durations[i]).Seconds()
+	}
+	return result
+}
+
+// 按用户分组并按时间排序，同一时刻的行为保持输入顺序
+func byUser(events []Event) map[uint][]Event {
+	users := make(map[uint][]Event)
+	for _, e := range events {
+		users[e.UserID] = append(users[e.UserID], e)
+	}
+	for _, userEvents := range users {
+		sort.SliceStable(userEvents, func(i, j int) bool { return userEvents[i].At.Before(userEvents[j].At) })
+	}
+	return users
+}
+
+func median(values []time.Duration) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+func rate(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,26 @@
+package analytics
+
+// UnknownSegment 没有分群属性的用户
+const UnknownSegment = "unknown"
+
+// SplitEvents 按用户所属分群拆分行为
+func SplitEvents(events []Event, segments map[uint]string) map[string][]Event {
+	return split(events, func(e Event) uint { return e.UserID }, segments)
+}
+
+// SplitSignups 按用户所属分群拆分注册记录
+func SplitSignups(signups []Signup, segments map[uint]string) map[string][]Signup {
+	return split(signups, func(s Signup) uint { return s.UserID }, segments)
+}
+
+func split[T any](items []T, userID func(T) uint, segments map[uint]string) map[string][]T {
+	result := make(map[string][]T)
+	for _, item := range items {
+		segment, ok := segments[userID(item)]
+		if !ok || segment == "" {
+			segment = UnknownSegment
+		}
+		result[segment] = append(result[segment], item)
+	}
+	return result
+}
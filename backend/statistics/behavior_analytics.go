@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"resume-centre/statistics/analytics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+)
+
+// 分群维度，取用户行为metadata中的同名字段
+var analyticsSegmentKeys = map[string]bool{"user_type": true, "source": true}
+
+// 按ID分批查询的大小，避免IN列表过长
+const analyticsQueryChunk = 1000
+
+// 漏斗分析：steps为逗号分隔的行为类型，用户须在window_days天内按顺序完成
+func getFunnelAnalysis(c *gin.Context) {
+	var steps []string
+	for _, step := range strings.Split(c.Query("steps"), ",") {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) < 2 || len(steps) > viper.GetInt("analytics.max_funnel_steps") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "steps must list between 2 and " + viper.GetString("analytics.max_funnel_steps") + " behavior types"})
+		return
+	}
+	windowDays, err := strconv.Atoi(c.DefaultQuery("window_days", viper.GetString("analytics.funnel_window_days")))
+	if err != nil || windowDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window_days"})
+		return
+	}
+	startDate, endDate, segmentBy, ok := analyticsParams(c, 30)
+	if !ok {
+		return
+	}
+
+	cachedAnalytics(c, func(ctx context.Context) (gin.H, error) {
+		window := time.Duration(windowDays) * 24 * time.Hour
+		// 起点在范围内的用户，后续步骤允许落在范围之后的转化窗口内
+		var events []analytics.Event
+		if err := db.WithContext(ctx).Model(&UserBehavior{}).
+			Select("user_id, type, created_at AS at").
+			Where("type IN ? AND created_at >= ? AND created_at <= ?", steps, startDate, endDate.Add(window)).
+			Order("user_id, created_at").Scan(&events).Error; err != nil {
+			return nil, err
+		}
+		events = funnelStartedWithin(events, steps[0], startDate, endDate)
+
+		result := gin.H{
+			"steps":       steps,
+			"window_days": windowDays,
+			"start_date":  startDate.Format(time.RFC3339),
+			"end_date":    endDate.Format(time.RFC3339),
+			"funnel":      analytics.Funnel(events, steps, window),
+		}
+		if segmentBy != "" {
+			segments, err := loadUserSegments(ctx, segmentBy, userIDsOf(events))
+			if err != nil {
+				return nil, err
+			}
+			bySegment := gin.H{}
+			for segment, segmentEvents := range analytics.SplitEvents(events, segments) {
+				bySegment[segment] = analytics.Funnel(segmentEvents, steps, window)
+			}
+			result["segment_by"] = segmentBy
+			result["segments"] = bySegment
+		}
+		return result, nil
+	})
+}
+
+// 只保留第一步首次发生在[start, end]内的用户
+func funnelStartedWithin(events []analytics.Event, first string, start, end time.Time) []analytics.Event {
+	started := map[uint]bool{}
+	seen := map[uint]bool{}
+	for _, e := range events {
+		if e.Type != first || seen[e.UserID] {
+			continue
+		}
+		seen[e.UserID] = true
+		started[e.UserID] = !e.At.Before(start) && !e.At.After(end)
+	}
+	kept := events[:0]
+	for _, e := range events {
+		if started[e.UserID] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// 注册群组留存：按注册周分组，days为逗号分隔的第N天，activity_types限定算作活跃的行为类型
+func getCohortRetention(c *gin.Context) {
+	days, err := parseIntList(c.DefaultQuery("days", "1,3,7,14,30"))
+	if err != nil || len(days) == 0 || len(days) > 60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a comma separated list of up to 60 non-negative integers"})
+		return
+	}
+	var activityTypes []string
+	if s := c.Query("activity_types"); s != "" {
+		activityTypes = strings.Split(s, ",")
+	}
+	rolling := c.Query("rolling") == "true"
+	startDate, endDate, segmentBy, ok := analyticsParams(c, 56)
+	if !ok {
+		return
+	}
+
+	cachedAnalytics(c, func(ctx context.Context) (gin.H, error) {
+		var signups []analytics.Signup
+		if err := db.WithContext(ctx).Model(&User{}).
+			Select("id AS user_id, created_at AS at").
+			Where("created_at >= ? AND created_at <= ? AND deleted_at IS NULL", startDate, endDate).
+			Scan(&signups).Error; err != nil {
+			return nil, err
+		}
+		for i := range signups {
+			signups[i].At = signups[i].At.In(time.Local)
+		}
+
+		userIDs := make([]uint, len(signups))
+		for i, s := range signups {
+			userIDs[i] = s.UserID
+		}
+		var activity []analytics.Event
+		for _, chunk := range chunkIDs(userIDs) {
+			query := db.WithContext(ctx).Model(&UserBehavior{}).
+				Select("user_id, type, created_at AS at").
+				Where("user_id IN ? AND created_at >= ?", chunk, startDate)
+			if len(activityTypes) > 0 {
+				query = query.Where("type IN ?", activityTypes)
+			}
+			var part []analytics.Event
+			if err := query.Scan(&part).Error; err != nil {
+				return nil, err
+			}
+			activity = append(activity, part...)
+		}
+
+		opts := analytics.CohortOptions{Days: days, Rolling: rolling, Now: time.Now()}
+		result := gin.H{
+			"days":       days,
+			"rolling":    rolling,
+			"start_date": startDate.Format(time.RFC3339),
+			"end_date":   endDate.Format(time.RFC3339),
+			"cohorts":    analytics.Cohorts(signups, activity, opts),
+		}
+		if segmentBy != "" {
+			segments, err := loadUserSegments(ctx, segmentBy, userIDs)
+			if err != nil {
+				return nil, err
+			}
+			bySegment := gin.H{}
+			for segment, segmentSignups := range analytics.SplitSignups(signups, segments) {
+				bySegment[segment] = analytics.Cohorts(segmentSignups, activity, opts)
+			}
+			result["segment_by"] = segmentBy
+			result["segments"] = bySegment
+		}
+		return result, nil
+	})
+}
+
+// 公共参数：时间范围（start_date/end_date或最近days天，缺省defaultDays天）与分群维度，出错时已写入响应
+func analyticsParams(c *gin.Context, defaultDays int) (time.Time, time.Time, string, bool) {
+	startDate, endDate, err := trendRange(c, defaultDays)
+	if err == nil && endDate.Sub(startDate) > time.Duration(viper.GetInt("analytics.max_range_days"))*24*time.Hour {
+		err = errors.New("Time range too large")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return time.Time{}, time.Time{}, "", false
+	}
+
+	segmentBy := c.Query("segment_by")
+	if segmentBy != "" && !analyticsSegmentKeys[segmentBy] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "segment_by must be user_type or source"})
+		return time.Time{}, time.Time{}, "", false
+	}
+	return startDate, endDate, segmentBy, true
+}
+
+// 用户的分群值：取该用户最早一条metadata中带有该字段的行为
+func loadUserSegments(ctx context.Context, key string, userIDs []uint) (map[uint]string, error) {
+	segments := make(map[uint]string, len(userIDs))
+	for _, chunk := range chunkIDs(userIDs) {
+		var rows []struct {
+			UserID   uint
+			Metadata string
+		}
+		if err := db.WithContext(ctx).Model(&UserBehavior{}).Select("user_id, metadata").
+			Where("user_id IN ? AND metadata LIKE ?", chunk, `%"`+key+`"%`).
+			Order("created_at ASC").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if _, ok := segments[row.UserID]; ok {
+				continue
+			}
+			var metadata map[string]interface{}
+			if json.Unmarshal([]byte(row.Metadata), &metadata) != nil {
+				continue
+			}
+			if value, ok := metadata[key].(string); ok && value != "" {
+				segments[row.UserID] = value
+			}
+		}
+	}
+	return segments, nil
+}
+
+func userIDsOf(events []analytics.Event) []uint {
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, e := range events {
+		if !seen[e.UserID] {
+			seen[e.UserID] = true
+			ids = append(ids, e.UserID)
+		}
+	}
+	return ids
+}
+
+func chunkIDs(ids []uint) [][]uint {
+	var chunks [][]uint
+	for len(ids) > 0 {
+		n := min(len(ids), analyticsQueryChunk)
+		chunks = append(chunks, ids[:n])
+		ids = ids[n:]
+	}
+	return chunks
+}
+
+func parseIntList(s string) ([]int, error) {
+	var values []int
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || v < 0 {
+			return nil, errors.New("invalid integer list")
+		}
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+// 分析结果缓存，键为路径加排序后的查询参数；refresh=true时跳过缓存重新计算
+func cachedAnalytics(c *gin.Context, compute func(ctx context.Context) (gin.H, error)) {
+	query := c.Request.URL.Query()
+	refresh := query.Get("refresh") == "true"
+	query.Del("refresh")
+	// 跳过缓存会触发全量重算，只允许管理员
+	if refresh && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required to refresh analytics"})
+		return
+	}
+	sum := sha1.Sum([]byte(c.FullPath() + "?" + query.Encode()))
+	key := "statistics:analytics:" + hex.EncodeToString(sum[:])
+	ctx := c.Request.Context()
+
+	if !refresh {
+		if data, ok := analyticsCache.get(ctx, key); ok {
+			c.Header("X-Cache", "HIT")
+			c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+			return
+		}
+	}
+
+	result, err := compute(ctx)
+	if err != nil {
+		logger.Errorf("Failed to compute behavior analytics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute behavior analytics"})
+		return
+	}
+	result["generated_at"] = time.Now().Format(time.RFC3339)
+	data, err := json.Marshal(result)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode behavior analytics"})
+		return
+	}
+	analyticsCache.set(ctx, key, data, viper.GetDuration("analytics.cache_ttl"))
+	c.Header("X-Cache", "MISS")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+var analyticsCache = &resultCache{entries: map[string]cacheEntry{}}
+
+// 有Redis时缓存在Redis中供多实例共享，否则使用进程内缓存
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+func (rc *resultCache) get(ctx context.Context, key string) ([]byte, bool) {
+	if redisClient != nil {
+		data, err := redisClient.Get(ctx, key).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			logger.Warnf("Failed to read analytics cache: %v", err)
+		}
+		return data, err == nil
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.data, true
+}
+
+func (rc *resultCache) set(ctx context.Context, key string, data []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	if redisClient != nil {
+		if err := redisClient.Set(ctx, key, data, ttl).Err(); err != nil {
+			logger.Warnf("Failed to write analytics cache: %v", err)
+		}
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	now := time.Now()
+	for k, entry := range rc.entries {
+		if now.After(entry.expiresAt) {
+			delete(rc.entries, k)
+		}
+	}
+	rc.entries[key] = cacheEntry{data: data, expiresAt: now.Add(ttl)}
+}
@@ -0,0 +1,179 @@
+// Package cron 解析五段式cron表达式（分 时 日 月 周）并计算下次执行时间
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSpec = errors.New("invalid cron expression")
+
+// Schedule 解析后的计划，每个字段为允许取值的位图
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和周都有限制时，按cron惯例满足其一即可
+	domRestricted, dowRestricted bool
+}
+
+type field struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{0, 59, nil}
+	hourField   = field{0, 23, nil}
+	domField    = field{1, 31, nil}
+	monthField  = field{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 1",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse 解析表达式，支持*、列表、范围、步长、月份和星期的英文缩写及@daily等描述符；
+// 周的0和7都表示周日
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(strings.ToLower(spec))
+	if d, ok := descriptors[spec]; ok {
+		spec = d
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidSpec, len(parts))
+	}
+
+	s := &Schedule{}
+	var err error
+	if s.minute, err = minuteField.parse(parts[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(parts[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(parts[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(parts[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(parts[4]); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domRestricted = !strings.HasPrefix(parts[2], "*")
+	s.dowRestricted = !strings.HasPrefix(parts[4], "*")
+	return s, nil
+}
+
+func (f field) parse(expr string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w: bad step %q", ErrInvalidSpec, item)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rangeExpr != "*" {
+			loExpr, hiExpr, isRange := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = f.value(loExpr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiExpr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%w: bad range %q", ErrInvalidSpec, item)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[s]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%w: value %q out of range %d-%d", ErrInvalidSpec, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next 返回严格晚于t的下一次执行时间（按t的时区，精确到分钟）；
+// 表达式永远不会命中（如2月30日）时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			// 跳到本小时内下一个允许的分钟
+			rest := s.minute >> uint(t.Minute()+1)
+			if rest == 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)+1) * time.Minute)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
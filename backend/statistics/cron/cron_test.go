@@ -0,0 +1,67 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC) // 周三
+	cases := []struct {
+		spec string
+		want string
+	}{
+		{"* * * * *", "2024-01-31 10:18"},
+		{"*/15 * * * *", "2024-01-31 10:30"},
+		{"0 9 * * *", "2024-02-01 09:00"},
+		{"@daily", "2024-02-01 00:00"},
+		{"@weekly", "2024-02-05 00:00"},
+		{"@monthly", "2024-02-01 00:00"},
+		{"30 8 1,15 * *", "2024-02-01 08:30"},
+		{"0 18 * * mon-fri", "2024-01-31 18:00"},
+		{"0 8 * * 0", "2024-02-04 08:00"},
+		{"0 8 * * 7", "2024-02-04 08:00"},
+		{"0 0 29 feb *", "2024-02-29 00:00"},
+		{"0 0 31 * *", "2024-03-31 00:00"},
+		// 日和周同时限制时满足其一即可
+		{"0 0 15 * fri", "2024-02-02 00:00"},
+		{"5-10/2 11 * * *", "2024-01-31 11:05"},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.spec, err)
+		}
+		if got := s.Next(from).Format("2006-01-02 15:04"); got != c.want {
+			t.Errorf("Next(%q) = %s, want %s", c.spec, got, c.want)
+		}
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	s, _ := Parse("0 9 * * *")
+	next := s.Next(time.Date(2024, 1, 1, 9, 0, 0, 0, loc))
+	if next.Location() != loc || next.Format("2006-01-02 15:04") != "2024-01-02 09:00" {
+		t.Errorf("Next = %v", next)
+	}
+}
+
+func TestNeverMatches(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Next = %v, want zero", next)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := Parse(spec); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("Parse(%q) error = %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"resume-centre/shared/infrastructure"
+	"resume-centre/statistics/ingest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	eventBuffer *ingest.Buffer
+	eventQueue  infrastructure.MessageQueue
+)
+
+// 初始化事件缓冲区并开始消费消息队列中的领域事件
+func initEventIngest() {
+	var dedup ingest.Deduper = ingest.NewMemoryDeduper(viper.GetDuration("events.dedup_ttl"))
+	if redisClient != nil {
+		dedup = &redisDeduper{client: redisClient, ttl: viper.GetDuration("events.dedup_ttl")}
+	}
+
+	eventBuffer = ingest.NewBuffer(dbEventSink{}, dedup, ingest.Config{
+		BatchSize:     viper.GetInt("events.batch_size"),
+		FlushInterval: viper.GetDuration("events.flush_interval"),
+		Capacity:      viper.GetInt("events.buffer_capacity"),
+		Workers:       viper.GetInt("events.workers"),
+		MaxRetries:    viper.GetInt("events.max_retries"),
+		RetryBackoff:  viper.GetDuration("events.retry_backoff"),
+		OnError: func(events []ingest.Event, err error) {
+			logger.Errorf("Dropped %d statistics events after retries (first id %s): %v", len(events), events[0].ID, err)
+		},
+	})
+
+	if viper.GetBool("events.mq.enabled") {
+		startEventConsumers()
+	}
+}
+
+// 停止消费并刷新缓冲区中的事件
+func stopEventIngest(ctx context.Context) {
+	if eventQueue != nil {
+		eventQueue.Close()
+	}
+	if eventBuffer != nil {
+		if err := eventBuffer.Close(ctx); err != nil {
+			logger.Errorf("Failed to flush statistics events: %v", err)
+		}
+	}
+}
+
+// 订阅领域事件主题，配置形如"user.registered=user_register"，未指定类型时消息数据本身即为事件
+func startEventConsumers() {
+	config := infrastructure.CreateDefaultMessagingConfig()
+	config.RedisAddr = viper.GetString("redis.address")
+	config.RedisPassword = viper.GetString("redis.password")
+	config.RedisDB = viper.GetInt("redis.db")
+	config.ConsumerGroup = viper.GetString("events.mq.consumer_group")
+	config.BatchSize = viper.GetInt("events.mq.batch_size")
+
+	queue, err := infrastructure.NewRedisStreamsQueue(config)
+	if err != nil {
+		logger.Warnf("Failed to init message queue, domain events disabled: %v", err)
+		return
+	}
+	eventQueue = queue
+
+	for _, spec := range viper.GetStringSlice("events.mq.topics") {
+		topic, eventType, _ := strings.Cut(strings.TrimSpace(spec), "=")
+		if err := queue.Subscribe(context.Background(), topic, domainEventHandler(topic, eventType)); err != nil {
+			logger.Errorf("Failed to subscribe to %s: %v", topic, err)
+			continue
+		}
+		logger.Infof("Consuming statistics events from %s", topic)
+	}
+}
+
+func domainEventHandler(topic, eventType string) infrastructure.MessageHandler {
+	return func(ctx context.Context, message *infrastructure.Message) error {
+		event, err := eventFromMessage(message, topic, eventType)
+		if err != nil {
+			// 格式错误的事件重试也无法成功
+			logger.Warnf("Dropping invalid event %s from %s: %v", message.ID, topic, err)
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, viper.GetDuration("events.mq.enqueue_timeout"))
+		defer cancel()
+		// 缓冲区满时返回错误，由消息队列稍后重新投递
+		_, err = eventBuffer.Add(ctx, []ingest.Event{event})
+		return err
+	}
+}
+
+func eventFromMessage(message *infrastructure.Message, topic, eventType string) (ingest.Event, error) {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return ingest.Event{}, err
+	}
+
+	var event ingest.Event
+	if eventType == "" {
+		if err := json.Unmarshal(data, &event); err != nil {
+			return event, err
+		}
+	} else {
+		// 领域事件数据的结构由发布方决定，只提取通用字段，其余作为元数据保存
+		var payload struct {
+			UserID      *uint  `json:"user_id"`
+			ReferenceID string `json:"reference_id"`
+			Value       int64  `json:"value"`
+		}
+		json.Unmarshal(data, &payload)
+		event = ingest.Event{
+			Type:        eventType,
+			Value:       payload.Value,
+			UserID:      payload.UserID,
+			ReferenceID: payload.ReferenceID,
+			Metadata:    data,
+		}
+	}
+	// 重新投递的消息保持原ID，据此去重
+	if event.ID == "" {
+		event.ID = message.ID
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = message.Timestamp
+	}
+	event.Source = topic
+	return event, event.Normalize(time.Now())
+}
+
+// 批量接收统计事件（NDJSON，每行一个事件）
+func ingestEvents(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, viper.GetInt64("events.max_request_bytes"))
+	events, lineErrors, err := ingest.Decode(body, viper.GetInt("events.max_request_events"), time.Now())
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.Is(err, ingest.ErrTooManyEvents) || errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"code": 413, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	for i := range events {
+		events[i].Source = "api"
+	}
+	if lineErrors == nil {
+		lineErrors = []ingest.LineError{}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), viper.GetDuration("events.enqueue_timeout"))
+	defer cancel()
+	res, err := eventBuffer.Add(ctx, events)
+
+	data := gin.H{
+		"accepted":   res.Accepted,
+		"duplicates": res.Duplicates,
+		"rejected":   res.Rejected,
+		"invalid":    len(lineErrors),
+		"errors":     lineErrors,
+	}
+	switch {
+	case errors.Is(err, ingest.ErrBufferFull):
+		data["rejected_ids"] = res.RejectedIDs
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"code": 429, "message": "事件缓冲区已满，请稍后重试被拒绝的事件", "data": data})
+	case errors.Is(err, ingest.ErrClosed):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "message": "服务正在关闭"})
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"code": 202, "message": "success", "data": data})
+	}
+}
+
+// 事件接入的累计计数与积压
+func getIngestStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "success", "data": eventBuffer.Stats()})
+}
+
+// 将事件写入数据库：已存在的ID跳过，实时计数按类型合并后原子累加
+type dbEventSink struct{}
+
+func (dbEventSink) Write(ctx context.Context, events []ingest.Event) error {
+	ids := make([]string, len(events))
+	for i := range events {
+		ids[i] = events[i].ID
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []string
+		if err := tx.Model(&StatisticsEvent{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(existing))
+		for _, id := range existing {
+			seen[id] = true
+		}
+
+		rows := make([]StatisticsEvent, 0, len(events))
+		totals := map[string]int64{}
+		for _, e := range events {
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			rows = append(rows, StatisticsEvent{
+				ID:          e.ID,
+				Type:        e.Type,
+				Value:       e.Value,
+				UserID:      e.UserID,
+				ReferenceID: e.ReferenceID,
+				Metadata:    e.MetadataString(),
+				Source:      e.Source,
+				OccurredAt:  e.OccurredAt,
+			})
+			totals[e.Type] += e.Value
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(rows, 500).Error; err != nil {
+			return err
+		}
+		return incrementRealTimeStats(tx, totals)
+	})
+}
+
+// 实时计数使用INSERT ... ON DUPLICATE KEY UPDATE累加，并发写入不会丢失增量
+func incrementRealTimeStats(tx *gorm.DB, totals map[string]int64) error {
+	types := make([]string, 0, len(totals))
+	for t := range totals {
+		types = append(types, t)
+	}
+	// 固定加锁顺序，避免并发事务死锁
+	sort.Strings(types)
+
+	now := time.Now()
+	rows := make([]RealTimeStats, len(types))
+	for i, t := range types {
+		rows[i] = RealTimeStats{ID: uuid.New().String(), Type: StatisticsType(t), Value: totals[t], LastUpdated: now}
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "type"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"value":        gorm.Expr("value + VALUES(value)"),
+			"last_updated": now,
+			"updated_at":   now,
+		}),
+	}).Create(&rows).Error
+}
+
+// 基于Redis SETNX的跨实例去重
+type redisDeduper struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func eventDedupKey(id string) string {
+	return "statistics:event:" + id
+}
+
+func (d *redisDeduper) Claim(ctx context.Context, ids []string) ([]bool, error) {
+	pipe := d.client.Pipeline()
+	cmds := make([]*redis.BoolCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.SetNX(ctx, eventDedupKey(id), 1, d.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	fresh := make([]bool, len(ids))
+	for i, cmd := range cmds {
+		fresh[i] = cmd.Val()
+	}
+	return fresh, nil
+}
+
+func (d *redisDeduper) Release(ctx context.Context, ids []string) error {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = eventDedupKey(id)
+	}
+	return d.client.Del(ctx, keys...).Err()
+}
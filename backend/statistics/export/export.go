@@ -0,0 +1,114 @@
+// Package export 把报表表格导出为CSV、XLSX和PDF，均为纯Go实现
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+	PDF  Format = "pdf"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// ParseFormat 解析格式名称
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case CSV, XLSX, PDF:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, s)
+}
+
+// ContentType 对应的MIME类型
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case PDF:
+		return "application/pdf"
+	}
+	return "application/octet-stream"
+}
+
+// Table 报表表格；单元格为整数或浮点数时在XLSX中写为数值
+type Table struct {
+	Title       string
+	GeneratedAt time.Time
+	Columns     []string
+	Rows        [][]interface{}
+}
+
+// Write 按格式写出表格
+func Write(w io.Writer, f Format, t *Table) error {
+	switch f {
+	case CSV:
+		return WriteCSV(w, t)
+	case XLSX:
+		return WriteXLSX(w, t)
+	case PDF:
+		return WritePDF(w, t)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, f)
+}
+
+// WriteCSV 写出UTF-8 CSV，带BOM以便Excel正确识别中文
+func WriteCSV(w io.Writer, t *Table) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = cellText(row[i])
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func cellText(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleTable(rows int) *Table {
+	t := &Table{
+		Title:       "简历浏览 resume_view",
+		GeneratedAt: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+		Columns:     []string{"区间", "count", "value", "avg"},
+	}
+	for i := 0; i < rows; i++ {
+		t.Rows = append(t.Rows, []interface{}{fmt.Sprintf("2024-02-%02d", i%28+1), int64(i), i * 10, 1.5})
+	}
+	return t
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	table := sampleTable(2)
+	table.Rows = append(table.Rows, []interface{}{`a,"b"`, nil})
+	if err := WriteCSV(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0][0] != "区间" || records[2][2] != "10" || records[2][3] != "1.5" {
+		t.Errorf("records = %v", records)
+	}
+	if records[3][0] != `a,"b"` || records[3][3] != "" {
+		t.Errorf("quoted row = %v", records[3])
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, sampleTable(3)); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">区间</t></is></c>`,
+		`<c r="B3"><v>1</v></c>`,
+		`<c r="D4"><v>1.5</v></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet missing %s", want)
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `name="简历浏览 resume_view"`) {
+		t.Errorf("workbook = %s", files["xl/workbook.xml"])
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestPDFPaginates(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, sampleTable(100)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatal("not a PDF")
+	}
+	if !strings.Contains(out, "/Count 4 ") {
+		t.Errorf("expected 4 pages, got %q", out[strings.Index(out, "/Count"):strings.Index(out, "/Count")+10])
+	}
+}
+
+func TestWriteUnsupported(t *testing.T) {
+	if err := Write(io.Discard, "doc", sampleTable(1)); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := ParseFormat("xls"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("ParseFormat err = %v", err)
+	}
+}
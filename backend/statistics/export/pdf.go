@@ -0,0 +1,147 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// A4横向页面（单位：pt）
+const (
+	pdfPageWidth  = 842.0
+	pdfPageHeight = 595.0
+	pdfMargin     = 40.0
+	pdfFontSize   = 9.0
+	pdfRowHeight  = pdfFontSize * 1.8
+)
+
+// WritePDF 写出表格PDF：每页重复表头，列宽平均分配，过长的单元格截断；
+// 使用不嵌入的STSong-Light字体以支持中文
+func WritePDF(w io.Writer, t *Table) error {
+	p := &pdfWriter{table: t, colWidth: (pdfPageWidth - 2*pdfMargin) / float64(max(len(t.Columns), 1))}
+	p.newPage()
+	p.text(pdfMargin, p.y, 14, t.Title)
+	p.y -= 20
+	if !t.GeneratedAt.IsZero() {
+		p.text(pdfMargin, p.y, pdfFontSize, "Generated at "+t.GeneratedAt.Format("2006-01-02 15:04:05 MST"))
+		p.y -= pdfRowHeight
+	}
+	p.header()
+	for _, row := range t.Rows {
+		if p.y-pdfRowHeight < pdfMargin {
+			p.newPage()
+			p.header()
+		}
+		p.row(row)
+	}
+	_, err := w.Write(p.bytes())
+	return err
+}
+
+type pdfWriter struct {
+	table    *Table
+	colWidth float64
+	pages    []*bytes.Buffer
+	y        float64
+}
+
+func (p *pdfWriter) newPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.y = pdfPageHeight - pdfMargin - 14
+}
+
+func (p *pdfWriter) header() {
+	cells := make([]interface{}, len(p.table.Columns))
+	for i, c := range p.table.Columns {
+		cells[i] = c
+	}
+	p.row(cells)
+	page := p.pages[len(p.pages)-1]
+	fmt.Fprintf(page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, p.y+pdfRowHeight*0.6, pdfPageWidth-pdfMargin, p.y+pdfRowHeight*0.6)
+}
+
+func (p *pdfWriter) row(cells []interface{}) {
+	p.y -= pdfRowHeight
+	for i, v := range cells {
+		if i >= len(p.table.Columns) {
+			break
+		}
+		p.text(pdfMargin+float64(i)*p.colWidth, p.y, pdfFontSize, fitText(cellText(v), p.colWidth-4, pdfFontSize))
+	}
+}
+
+func (p *pdfWriter) text(x, y, size float64, s string) {
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, y, pdfHexText(s))
+}
+
+// 按字宽估算截断：ASCII半角，其余全角
+func fitText(s string, width, size float64) string {
+	used := 0.0
+	for i, r := range s {
+		w := size
+		if r < 0x80 {
+			w = size / 2
+		}
+		if used+w > width {
+			return s[:i] + "…"
+		}
+		used += w
+	}
+	return s
+}
+
+func (p *pdfWriter) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	writeObj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: Catalog, 2: Pages, 3-5: 字体, 之后每页两个对象（Page + Content）
+	const firstPageObj = 6
+	kids := &bytes.Buffer{}
+	for i := range p.pages {
+		fmt.Fprintf(kids, "%d 0 R ", firstPageObj+i*2)
+	}
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(p.pages)))
+	writeObj("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	writeObj("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> " +
+		"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	writeObj("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, page := range p.pages {
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPageObj+i*2+1))
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// 文本编码为UCS-2大端十六进制串（超出BMP的字符以?代替）
+func pdfHexText(text string) string {
+	var buf bytes.Buffer
+	for _, r := range text {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		for _, u := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&buf, "%04X", u)
+		}
+	}
+	return buf.String()
+}
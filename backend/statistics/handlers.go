@@ -1,57 +1,16 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/spf13/viper"
 )
 
-// 记录统计事件
-func recordEvent(c *gin.Context) {
-	var req struct {
-		Type        string `json:"type" binding:"required"`
-		Value       int64  `json:"value" binding:"required"`
-		UserID      *uint  `json:"user_id"`
-		ReferenceID string `json:"reference_id"`
-		Metadata    string `json:"metadata"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 创建统计数据
-	statistics := Statistics{
-		ID:          uuid.New().String(),
-		Type:        StatisticsType(req.Type),
-		Period:      StatisticsPeriodDay,
-		Date:        time.Now().Truncate(24 * time.Hour),
-		Value:       req.Value,
-		UserID:      req.UserID,
-		ReferenceID: req.ReferenceID,
-		Metadata:    req.Metadata,
-	}
-
-	if err := db.Create(&statistics).Error; err != nil {
-		logger.Errorf("Failed to record event: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record event"})
-		return
-	}
-
-	// 更新实时统计
-	updateRealTimeStats(req.Type, req.Value)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Event recorded successfully",
-		"data":    statistics,
-	})
-}
-
 // 获取实时统计
 func getRealTimeStats(c *gin.Context) {
 	statsType := c.Query("type")
@@ -122,117 +81,106 @@ func getStatisticsSummary(c *gin.Context) {
 	})
 }
 
-// 获取统计趋势
+// 获取统计趋势，period为auto（缺省）时按时间范围选择最粗的合适粒度
 func getStatisticsTrend(c *gin.Context) {
 	statsType := c.Query("type")
-	period := c.Query("period")
-	if period == "" {
-		period = "day"
+	if statsType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type parameter is required"})
+		return
 	}
 
-	days, _ := strconv.Atoi(c.Query("days"))
-	if days == 0 {
-		days = 7
+	startDate, endDate, err := trendRange(c, 7)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	endDate := time.Now().Truncate(24 * time.Hour)
-	startDate := endDate.AddDate(0, 0, -days)
+	granularity, ok := resolveGranularity(c.DefaultQuery("period", "auto"), startDate, endDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period, expected auto, hour, day, week, month or year"})
+		return
+	}
+	if len(granularity.Buckets(startDate, endDate)) > viper.GetInt("statistics.trend.max_points") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many data points, use a coarser period or a shorter range"})
+		return
+	}
 
-	var stats []Statistics
-	if err := db.Where("type = ? AND period = ? AND date BETWEEN ? AND ?",
-		statsType, period, startDate, endDate).Order("date ASC").Find(&stats).Error; err != nil {
+	points, total, err := loadTrend(c.Request.Context(), statsType, granularity, startDate, endDate)
+	if err != nil {
 		logger.Errorf("Failed to get statistics trend: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get statistics trend"})
 		return
 	}
-
-	// 构建趋势数据
-	trendData := make(map[string]int64)
-	for _, stat := range stats {
-		dateKey := stat.Date.Format("2006-01-02")
-		trendData[dateKey] = stat.Value
+	trend := make([]gin.H, 0, len(points))
+	for _, p := range points {
+		trend = append(trend, gin.H{
+			"date":           granularity.Label(p.Start),
+			"start":          p.Start,
+			"count":          p.Count,
+			"value":          p.Sum,
+			"min":            p.Min,
+			"max":            p.Max,
+			"distinct_users": p.Users.Estimate(),
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"type":       statsType,
-		"period":     period,
-		"start_date": startDate.Format("2006-01-02"),
-		"end_date":   endDate.Format("2006-01-02"),
-		"trend":      trendData,
+		"period":     granularity,
+		"start_date": startDate.Format(time.RFC3339),
+		"end_date":   endDate.Format(time.RFC3339),
+		"total": gin.H{
+			"count":          total.Count,
+			"value":          total.Sum,
+			"min":            total.Min,
+			"max":            total.Max,
+			"distinct_users": total.Users.Estimate(),
+		},
+		"trend": trend,
 	})
 }
 
-// 生成统计报表
-func generateReport(c *gin.Context) {
-	var req struct {
-		Name      string `json:"name" binding:"required"`
-		Type      string `json:"type" binding:"required"`
-		Period    string `json:"period" binding:"required"`
-		StartDate string `json:"start_date" binding:"required"`
-		EndDate   string `json:"end_date" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	startDate, err := time.Parse("2006-01-02", req.StartDate)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
-		return
-	}
-
-	endDate, err := time.Parse("2006-01-02", req.EndDate)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
-		return
-	}
-
-	// 获取统计数据
-	var stats []Statistics
-	if err := db.Where("type = ? AND period = ? AND date BETWEEN ? AND ?",
-		req.Type, req.Period, startDate, endDate).Order("date ASC").Find(&stats).Error; err != nil {
-		logger.Errorf("Failed to generate report: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate report"})
-		return
+// 查询的时间范围：start_date/end_date（日期或RFC3339，日期型的结束日包含当天），或最近days天（缺省defaultDays）
+func trendRange(c *gin.Context, defaultDays int) (time.Time, time.Time, error) {
+	parse := func(s string, endOfDay bool) (time.Time, error) {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t.In(time.Local), nil
+		}
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err == nil && endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return t, err
 	}
 
-	// 生成报表数据
-	reportData := gin.H{
-		"summary": gin.H{
-			"total_records": len(stats),
-			"total_value":   calculateTotalValue(stats),
-			"avg_value":     calculateAverageValue(stats),
-		},
-		"data": stats,
+	endDate := time.Now()
+	if s := c.Query("end_date"); s != "" {
+		t, err := parse(s, true)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid end_date format")
+		}
+		endDate = t
 	}
 
-	reportDataJSON, _ := json.Marshal(reportData)
-
-	// 创建报表记录
-	report := StatisticsReport{
-		ID:          uuid.New().String(),
-		Name:        req.Name,
-		Type:        StatisticsType(req.Type),
-		Period:      StatisticsPeriod(req.Period),
-		StartDate:   startDate,
-		EndDate:     endDate,
-		Data:        string(reportDataJSON),
-		IsGenerated: true,
+	var startDate time.Time
+	if s := c.Query("start_date"); s != "" {
+		t, err := parse(s, false)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid start_date format")
+		}
+		startDate = t
+	} else {
+		days, _ := strconv.Atoi(c.Query("days"))
+		if days <= 0 {
+			days = defaultDays
+		}
+		startDate = endDate.AddDate(0, 0, -days)
 	}
 
-	if err := db.Create(&report).Error; err != nil {
-		logger.Errorf("Failed to save report: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save report"})
-		return
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, errors.New("end_date must not be before start_date")
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Report generated successfully",
-		"report":  report,
-		"data":    reportData,
-	})
+	return startDate, endDate, nil
 }
 
 // 跟踪用户行为
@@ -319,44 +267,6 @@ func analyzeUserBehavior(c *gin.Context) {
 	})
 }
 
-// 更新实时统计
-func updateRealTimeStats(statsType string, value int64) {
-	var realTimeStats RealTimeStats
-	if err := db.Where("type = ?", statsType).First(&realTimeStats).Error; err != nil {
-		// 如果不存在，创建新的
-		realTimeStats = RealTimeStats{
-			ID:          uuid.New().String(),
-			Type:        StatisticsType(statsType),
-			Value:       value,
-			LastUpdated: time.Now(),
-		}
-		db.Create(&realTimeStats)
-	} else {
-		// 更新现有记录
-		realTimeStats.Value += value
-		realTimeStats.LastUpdated = time.Now()
-		db.Save(&realTimeStats)
-	}
-}
-
-// 计算总价值
-func calculateTotalValue(stats []Statistics) int64 {
-	var total int64
-	for _, stat := range stats {
-		total += stat.Value
-	}
-	return total
-}
-
-// 计算平均价值
-func calculateAverageValue(stats []Statistics) float64 {
-	if len(stats) == 0 {
-		return 0
-	}
-	total := calculateTotalValue(stats)
-	return float64(total) / float64(len(stats))
-}
-
 // 分析用户行为
 func analyzeBehaviors(behaviors []UserBehavior) gin.H {
 	// 按类型统计
@@ -0,0 +1,272 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrBufferFull = errors.New("ingest buffer full")
+	ErrClosed     = errors.New("ingest buffer closed")
+)
+
+// Sink 批量写入事件，返回错误时整批重试
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// Config 缓冲区配置
+type Config struct {
+	BatchSize     int           // 单次写入的最大事件数
+	FlushInterval time.Duration // 未满批次的最长等待时间
+	Capacity      int           // 缓冲区容量，满时Add阻塞直至ctx结束
+	Workers       int           // 并发写入协程数
+	MaxRetries    int           // 写入失败的重试次数
+	RetryBackoff  time.Duration // 首次重试间隔，之后逐次翻倍
+	WriteTimeout  time.Duration // 单次写入超时
+
+	// 重试后仍写入失败时回调，这些事件的去重标记已撤销
+	OnError func(events []Event, err error)
+}
+
+func (c *Config) setDefaults() {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 500
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	if c.Capacity <= 0 {
+		c.Capacity = 10 * c.BatchSize
+	}
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = 100 * time.Millisecond
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = 10 * time.Second
+	}
+}
+
+// Result 一次Add的结果
+type Result struct {
+	Accepted    int      `json:"accepted"`
+	Duplicates  int      `json:"duplicates"`
+	Rejected    int      `json:"rejected"` // 因缓冲区满被拒绝，可稍后重试
+	RejectedIDs []string `json:"rejected_ids,omitempty"`
+}
+
+// Stats 累计计数
+type Stats struct {
+	Accepted   int64 `json:"accepted"`
+	Duplicates int64 `json:"duplicates"`
+	Rejected   int64 `json:"rejected"`
+	Written    int64 `json:"written"`
+	Failed     int64 `json:"failed"`
+	Pending    int   `json:"pending"`
+}
+
+// Buffer 有界事件缓冲区，按批大小或时间间隔刷新到Sink
+type Buffer struct {
+	cfg   Config
+	sink  Sink
+	dedup Deduper
+
+	events chan Event
+	mu     sync.RWMutex // Add持读锁发送，Close持写锁关闭通道
+	closed atomic.Bool
+	wg     sync.WaitGroup
+
+	accepted, duplicates, rejected, written, failed atomic.Int64
+}
+
+// NewBuffer 创建缓冲区并启动写入协程，dedup为nil时不去重
+func NewBuffer(sink Sink, dedup Deduper, cfg Config) *Buffer {
+	cfg.setDefaults()
+	b := &Buffer{cfg: cfg, sink: sink, dedup: dedup, events: make(chan Event, cfg.Capacity)}
+	for i := 0; i < cfg.Workers; i++ {
+		b.wg.Add(1)
+		go b.run()
+	}
+	return b
+}
+
+// Add 去重后放入缓冲区；缓冲区满时阻塞，ctx结束时剩余事件被拒绝并返回ErrBufferFull
+func (b *Buffer) Add(ctx context.Context, events []Event) (Result, error) {
+	var res Result
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed.Load() {
+		return res, ErrClosed
+	}
+
+	fresh := b.claim(ctx, events)
+	for i := range events {
+		if !fresh[i] {
+			res.Duplicates++
+			continue
+		}
+		select {
+		case b.events <- events[i]:
+			res.Accepted++
+		case <-ctx.Done():
+			var ids []string
+			for j := i; j < len(events); j++ {
+				if fresh[j] {
+					ids = append(ids, events[j].ID)
+				}
+			}
+			res.Rejected, res.RejectedIDs = len(ids), ids
+			b.release(ids)
+			b.record(res)
+			return res, ErrBufferFull
+		}
+	}
+	b.record(res)
+	return res, nil
+}
+
+// 去重服务不可用时放行，由Sink按ID兜底
+func (b *Buffer) claim(ctx context.Context, events []Event) []bool {
+	fresh := make([]bool, len(events))
+	if b.dedup != nil {
+		ids := make([]string, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+		if claimed, err := b.dedup.Claim(ctx, ids); err == nil && len(claimed) == len(events) {
+			return claimed
+		}
+	}
+	for i := range fresh {
+		fresh[i] = true
+	}
+	return fresh
+}
+
+func (b *Buffer) release(ids []string) {
+	if b.dedup == nil || len(ids) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.WriteTimeout)
+	defer cancel()
+	b.dedup.Release(ctx, ids)
+}
+
+func (b *Buffer) record(res Result) {
+	b.accepted.Add(int64(res.Accepted))
+	b.duplicates.Add(int64(res.Duplicates))
+	b.rejected.Add(int64(res.Rejected))
+}
+
+// Stats 累计计数与当前积压
+func (b *Buffer) Stats() Stats {
+	return Stats{
+		Accepted:   b.accepted.Load(),
+		Duplicates: b.duplicates.Load(),
+		Rejected:   b.rejected.Load(),
+		Written:    b.written.Load(),
+		Failed:     b.failed.Load(),
+		Pending:    len(b.events),
+	}
+}
+
+// Close 停止接收并刷新剩余事件，ctx结束时返回其错误
+func (b *Buffer) Close(ctx context.Context) error {
+	if b.closed.Swap(true) {
+		return nil
+	}
+	b.mu.Lock()
+	close(b.events)
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Buffer) run() {
+	defer b.wg.Done()
+
+	batch := make([]Event, 0, b.cfg.BatchSize)
+	timer := time.NewTimer(b.cfg.FlushInterval)
+	stopTimer(timer)
+
+	for {
+		select {
+		case event, ok := <-b.events:
+			if !ok {
+				stopTimer(timer)
+				b.flush(batch)
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(b.cfg.FlushInterval)
+			}
+			batch = append(batch, event)
+			if len(batch) >= b.cfg.BatchSize {
+				stopTimer(timer)
+				b.flush(batch)
+				batch = batch[:0]
+			}
+		case <-timer.C:
+			b.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+func (b *Buffer) flush(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var err error
+	backoff := b.cfg.RetryBackoff
+	for attempt := 0; attempt <= b.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), b.cfg.WriteTimeout)
+		err = b.sink.Write(ctx, batch)
+		cancel()
+		if err == nil {
+			b.written.Add(int64(len(batch)))
+			return
+		}
+	}
+
+	b.failed.Add(int64(len(batch)))
+	failed := append([]Event(nil), batch...)
+	ids := make([]string, len(failed))
+	for i := range failed {
+		ids[i] = failed[i].ID
+	}
+	b.release(ids)
+	if b.cfg.OnError != nil {
+		b.cfg.OnError(failed, err)
+	}
+}
@@ -0,0 +1,59 @@
+package ingest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Deduper 事件去重：Claim标记ID并返回各ID是否首次出现，Release撤销标记以便失败的事件可以重新提交
+type Deduper interface {
+	Claim(ctx context.Context, ids []string) ([]bool, error)
+	Release(ctx context.Context, ids []string) error
+}
+
+// MemoryDeduper 进程内去重，标记在ttl后过期，适用于单实例与测试
+type MemoryDeduper struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryDeduper(ttl time.Duration) *MemoryDeduper {
+	return &MemoryDeduper{ttl: ttl, seen: map[string]time.Time{}, lastSweep: time.Now()}
+}
+
+func (d *MemoryDeduper) Claim(ctx context.Context, ids []string) ([]bool, error) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastSweep) > d.ttl {
+		for id, expires := range d.seen {
+			if now.After(expires) {
+				delete(d.seen, id)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	fresh := make([]bool, len(ids))
+	for i, id := range ids {
+		if expires, ok := d.seen[id]; ok && now.Before(expires) {
+			continue
+		}
+		d.seen[id] = now.Add(d.ttl)
+		fresh[i] = true
+	}
+	return fresh, nil
+}
+
+func (d *MemoryDeduper) Release(ctx context.Context, ids []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, id := range ids {
+		delete(d.seen, id)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Package ingest 统计事件的批量接入：NDJSON解析、按ID去重，以及按数量/时间批量落库的有界缓冲区
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTooManyEvents = errors.New("too many events in batch")
+	ErrInvalidEvent  = errors.New("invalid event")
+)
+
+// 单行最大长度
+const maxLineSize = 1 << 20
+
+var eventTypePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,49}$`)
+
+// Event 统计事件，ID用于去重，未提供时自动生成（此时无法去重）
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Value       int64           `json:"value"`
+	UserID      *uint           `json:"user_id,omitempty"`
+	ReferenceID string          `json:"reference_id,omitempty"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	OccurredAt  time.Time       `json:"timestamp"`
+	Source      string          `json:"-"` // 来源：api或消息主题
+}
+
+// Normalize 校验事件并补全缺省值：value缺省为1，时间缺省为now
+func (e *Event) Normalize(now time.Time) error {
+	e.ID = strings.TrimSpace(e.ID)
+	e.Type = strings.TrimSpace(e.Type)
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if len(e.ID) > 64 {
+		return fmt.Errorf("%w: id longer than 64 characters", ErrInvalidEvent)
+	}
+	if !eventTypePattern.MatchString(e.Type) {
+		return fmt.Errorf("%w: type %q must be lowercase letters, digits or _.:- (max 50)", ErrInvalidEvent, e.Type)
+	}
+	if len(e.ReferenceID) > 100 {
+		return fmt.Errorf("%w: reference_id longer than 100 characters", ErrInvalidEvent)
+	}
+	if e.Value == 0 {
+		e.Value = 1
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = now
+	} else if e.OccurredAt.After(now.Add(time.Hour)) {
+		return fmt.Errorf("%w: timestamp is in the future", ErrInvalidEvent)
+	}
+	return nil
+}
+
+// MetadataString 元数据的存储形式：JSON字符串取其内容，其他JSON值保留原文
+func (e *Event) MetadataString() string {
+	if len(e.Metadata) == 0 || string(e.Metadata) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(e.Metadata, &s); err == nil {
+		return s
+	}
+	return string(e.Metadata)
+}
+
+// LineError 无法解析或校验失败的行
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Decode 解析NDJSON，空行忽略；单行错误不影响其他行，事件数超过maxEvents时整体拒绝
+func Decode(r io.Reader, maxEvents int, now time.Time) ([]Event, []LineError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	var events []Event
+	var lineErrors []LineError
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if maxEvents > 0 && len(events)+len(lineErrors) >= maxEvents {
+			return nil, nil, fmt.Errorf("%w: limit is %d", ErrTooManyEvents, maxEvents)
+		}
+
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: "invalid JSON: " + err.Error()})
+			continue
+		}
+		if err := event.Normalize(now); err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: err.Error()})
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return events, lineErrors, nil
+}
@@ -0,0 +1,224 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 记录写入批次的Sink，可模拟写入耗时与失败
+type recordingSink struct {
+	mu       sync.Mutex
+	batches  [][]Event
+	delay    time.Duration
+	failures atomic.Int32
+}
+
+func (s *recordingSink) Write(ctx context.Context, events []Event) error {
+	if s.delay > 0 {
+		time.Sleep(s.delay)
+	}
+	if s.failures.Add(-1) >= 0 {
+		return errors.New("database unavailable")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]Event(nil), events...))
+	return nil
+}
+
+func (s *recordingSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func events(prefix string, n int) []Event {
+	out := make([]Event, n)
+	for i := range out {
+		out[i] = Event{ID: fmt.Sprintf("%s-%d", prefix, i), Type: "resume_view", Value: 1}
+	}
+	return out
+}
+
+func TestDecodeNDJSON(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := strings.Join([]string{
+		`{"id":"e1","type":"resume_view","user_id":7,"metadata":{"source":"app"}}`,
+		``,
+		`{"id":"e2","type":"points_earn","value":20,"timestamp":"2024-05-01T08:00:00Z","metadata":"plain"}`,
+		`not json`,
+		`{"id":"e3","type":"Bad Type"}`,
+		`{"type":"user_login","timestamp":"2024-05-02T12:00:00Z"}`,
+	}, "\n")
+
+	got, lineErrors, err := Decode(strings.NewReader(body), 100, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Value != 1 || !got[0].OccurredAt.Equal(now) || *got[0].UserID != 7 {
+		t.Fatalf("events = %+v", got)
+	}
+	if got[0].MetadataString() != `{"source":"app"}` || got[1].MetadataString() != "plain" || got[1].Value != 20 {
+		t.Errorf("metadata/value = %q %q %d", got[0].MetadataString(), got[1].MetadataString(), got[1].Value)
+	}
+	if len(lineErrors) != 3 || lineErrors[0].Line != 4 || lineErrors[1].Line != 5 || lineErrors[2].Line != 6 {
+		t.Errorf("line errors = %+v", lineErrors)
+	}
+
+	if _, _, err := Decode(strings.NewReader(strings.Repeat(`{"type":"a"}`+"\n", 3)), 2, now); !errors.Is(err, ErrTooManyEvents) {
+		t.Errorf("err = %v, want ErrTooManyEvents", err)
+	}
+}
+
+func TestBufferFlushesBySizeAndInterval(t *testing.T) {
+	sink := &recordingSink{}
+	b := NewBuffer(sink, nil, Config{BatchSize: 10, FlushInterval: 50 * time.Millisecond, Capacity: 100})
+
+	if _, err := b.Add(context.Background(), events("a", 25)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.count() < 25 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sink.count() != 25 {
+		t.Fatalf("written %d events, want 25", sink.count())
+	}
+	sink.mu.Lock()
+	sizes := []int{len(sink.batches[0]), len(sink.batches[1]), len(sink.batches[2])}
+	sink.mu.Unlock()
+	if sizes[0] != 10 || sizes[1] != 10 || sizes[2] != 5 {
+		t.Errorf("batch sizes = %v, want full batches then a timed partial batch", sizes)
+	}
+
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Add(context.Background(), events("b", 1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Add after Close = %v", err)
+	}
+}
+
+func TestBufferDeduplicatesByID(t *testing.T) {
+	sink := &recordingSink{}
+	b := NewBuffer(sink, NewMemoryDeduper(time.Hour), Config{BatchSize: 100, FlushInterval: time.Hour})
+
+	batch := append(events("x", 3), Event{ID: "x-1", Type: "resume_view", Value: 1})
+	res, err := b.Add(context.Background(), batch)
+	if err != nil || res.Accepted != 3 || res.Duplicates != 1 {
+		t.Fatalf("first add = %+v, %v", res, err)
+	}
+	res, _ = b.Add(context.Background(), events("x", 5))
+	if res.Accepted != 2 || res.Duplicates != 3 {
+		t.Errorf("second add = %+v", res)
+	}
+
+	b.Close(context.Background())
+	if sink.count() != 5 {
+		t.Errorf("written %d, want 5 (Close must flush pending events)", sink.count())
+	}
+	if st := b.Stats(); st.Accepted != 5 || st.Duplicates != 4 || st.Written != 5 || st.Pending != 0 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestBufferBackpressure(t *testing.T) {
+	sink := &recordingSink{delay: 200 * time.Millisecond}
+	dedup := NewMemoryDeduper(time.Hour)
+	b := NewBuffer(sink, dedup, Config{BatchSize: 1, Capacity: 2, Workers: 1})
+	defer b.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := b.Add(ctx, events("p", 10))
+	if !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("err = %v, want ErrBufferFull", err)
+	}
+	if res.Accepted+res.Rejected != 10 || res.Rejected == 0 || res.Accepted > 3 {
+		t.Errorf("result = %+v", res)
+	}
+
+	// 被拒绝的事件撤销了去重标记，可以重新提交
+	fresh, _ := dedup.Claim(context.Background(), []string{"p-9"})
+	if !fresh[0] {
+		t.Error("rejected event should be released from the deduper")
+	}
+}
+
+func TestBufferRetriesAndReleasesFailedBatches(t *testing.T) {
+	sink := &recordingSink{}
+	sink.failures.Store(2)
+	b := NewBuffer(sink, NewMemoryDeduper(time.Hour), Config{BatchSize: 5, MaxRetries: 2, RetryBackoff: time.Millisecond})
+	b.Add(context.Background(), events("r", 5))
+	b.Close(context.Background())
+	if sink.count() != 5 || b.Stats().Failed != 0 {
+		t.Fatalf("written %d, stats %+v; want success on third attempt", sink.count(), b.Stats())
+	}
+
+	sink = &recordingSink{}
+	sink.failures.Store(100)
+	var failed []Event
+	dedup := NewMemoryDeduper(time.Hour)
+	b = NewBuffer(sink, dedup, Config{BatchSize: 5, MaxRetries: 1, RetryBackoff: time.Millisecond,
+		OnError: func(events []Event, err error) { failed = events }})
+	b.Add(context.Background(), events("f", 5))
+	b.Close(context.Background())
+	if len(failed) != 5 || b.Stats().Failed != 5 {
+		t.Fatalf("failed = %d, stats %+v", len(failed), b.Stats())
+	}
+	if fresh, _ := dedup.Claim(context.Background(), []string{"f-0"}); !fresh[0] {
+		t.Error("failed events should be released from the deduper")
+	}
+}
+
+// 模拟每批写入耗时2ms（约等于一次批量INSERT），验证每秒可持续处理数千事件
+func BenchmarkBufferThroughput(b *testing.B) {
+	sink := &recordingSink{delay: 2 * time.Millisecond}
+	buf := NewBuffer(sink, NewMemoryDeduper(time.Hour), Config{BatchSize: 500, FlushInterval: 50 * time.Millisecond, Capacity: 5000, Workers: 4})
+
+	batch := make([]Event, 100)
+	start := time.Now()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range batch {
+			batch[j] = Event{ID: fmt.Sprintf("%d-%d", i, j), Type: "resume_view", Value: 1}
+		}
+		if _, err := buf.Add(context.Background(), batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+	buf.Close(context.Background())
+	b.StopTimer()
+
+	if got := sink.count(); got != b.N*len(batch) {
+		b.Fatalf("written %d, want %d", got, b.N*len(batch))
+	}
+	b.ReportMetric(float64(b.N*len(batch))/time.Since(start).Seconds(), "events/s")
+}
+
+func BenchmarkDecode(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, `{"id":"evt-%d","type":"resume_view","user_id":%d,"reference_id":"r%d"}`+"\n", i, i%50, i)
+	}
+	body := sb.String()
+	now := time.Now()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Decode(strings.NewReader(body), 0, now); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N*1000)/b.Elapsed().Seconds(), "events/s")
+}
@@ -54,6 +54,12 @@ func main() {
 		logger.Fatalf("Failed to init redis client: %v", err)
 	}
 
+	// 启动事件接入与汇总
+	initEventIngest()
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	startRollupWorker(workerCtx)
+	reportWorkers := startReportWorkers(workerCtx)
+
 	// 注册服务到Consul
 	if err := registerService(); err != nil {
 		logger.Fatalf("Failed to register service: %v", err)
@@ -96,6 +102,9 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Fatalf("Server forced to shutdown: %v", err)
 	}
+	stopWorkers()
+	reportWorkers.Wait()
+	stopEventIngest(ctx)
 
 	logger.Info("Server exited")
 }
@@ -117,6 +126,43 @@ func loadConfig() error {
 	viper.SetDefault("database.name", "jobfirst")
 	viper.SetDefault("database.user", "jobfirst")
 	viper.SetDefault("database.password", "jobfirst123")
+	viper.SetDefault("events.batch_size", 500)
+	viper.SetDefault("events.flush_interval", "1s")
+	viper.SetDefault("events.buffer_capacity", 20000)
+	viper.SetDefault("events.workers", 4)
+	viper.SetDefault("events.max_retries", 3)
+	viper.SetDefault("events.retry_backoff", "200ms")
+	viper.SetDefault("events.enqueue_timeout", "500ms")
+	viper.SetDefault("events.max_request_events", 10000)
+	viper.SetDefault("events.max_request_bytes", 8<<20)
+	viper.SetDefault("events.dedup_ttl", "24h")
+	viper.SetDefault("events.mq.enabled", true)
+	viper.SetDefault("events.mq.consumer_group", "statistics-service")
+	viper.SetDefault("events.mq.batch_size", 100)
+	viper.SetDefault("events.mq.enqueue_timeout", "5s")
+	viper.SetDefault("events.mq.topics", []string{"statistics.events"})
+	viper.SetDefault("statistics.rollup.interval", "1m")
+	viper.SetDefault("statistics.rollup.batch_size", 5000)
+	viper.SetDefault("statistics.rollup.lag", "30s")
+	viper.SetDefault("statistics.retention.raw_event_days", 90)
+	viper.SetDefault("statistics.retention.interval", "1h")
+	viper.SetDefault("statistics.trend.min_points", 7)
+	viper.SetDefault("statistics.trend.max_points", 1000)
+	viper.SetDefault("analytics.cache_ttl", "10m")
+	viper.SetDefault("analytics.max_range_days", 366)
+	viper.SetDefault("analytics.max_funnel_steps", 10)
+	viper.SetDefault("analytics.funnel_window_days", 30)
+	viper.SetDefault("reports.workers", 2)
+	viper.SetDefault("reports.poll_interval", "5s")
+	viper.SetDefault("reports.scheduler_interval", "30s")
+	viper.SetDefault("reports.lease_duration", "10m")
+	viper.SetDefault("reports.max_attempts", 3)
+	viper.SetDefault("reports.retry_backoff", "1m")
+	viper.SetDefault("reports.max_range_days", 366)
+	viper.SetDefault("reports.public_base_url", "http://localhost:8081")
+	viper.SetDefault("reports.admin_roles", []string{"admin", "super_admin"})
+	viper.SetDefault("storage.service_url", "http://localhost:8088")
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
@@ -166,7 +212,11 @@ func initDatabase() error {
 	}
 
 	// 自动迁移
-	if err := db.AutoMigrate(&User{}); err != nil {
+	if err := mergeDuplicateStatistics(db); err != nil {
+		return fmt.Errorf("failed to merge duplicate statistics: %v", err)
+	}
+	if err := db.AutoMigrate(&User{}, &RealTimeStats{}, &StatisticsEvent{}, &Statistics{}, &StatisticsRollupCursor{}, &UserBehavior{},
+		&StatisticsReport{}, &ReportDefinition{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
@@ -407,6 +457,39 @@ func setupRouter() *gin.Engine {
 			// 系统统计
 			authStatistics.GET("/system", getSystemStatistics)
 			authStatistics.GET("/system/performance", getPerformanceStats)
+
+			// 事件接入
+			authStatistics.POST("/events", ingestEvents)
+			authStatistics.GET("/events/ingest-stats", getIngestStats)
+			authStatistics.GET("/realtime", getRealTimeStats)
+			authStatistics.GET("/events/trend", getStatisticsTrend)
+			authStatistics.GET("/events/summary", getStatisticsSummary)
+
+			// 用户行为分析，漏斗和留存涉及全站用户分群，仅管理员可用
+			authStatistics.POST("/behaviors", trackUserBehavior)
+			authStatistics.GET("/behaviors/analysis", analyzeUserBehavior)
+			authStatistics.GET("/analytics/funnel", adminMiddleware(), getFunnelAnalysis)
+			authStatistics.GET("/analytics/cohorts", adminMiddleware(), getCohortRetention)
+
+			// 统计报表
+			authStatistics.GET("/reports", listMyReports)
+			authStatistics.POST("/reports", createReport)
+			authStatistics.GET("/reports/:id", getReport)
+			authStatistics.GET("/reports/:id/download", downloadReport)
+		}
+
+		// 报表管理API（管理员）
+		adminStatistics := statistics.Group("/admin")
+		adminStatistics.Use(authMiddleware(), adminMiddleware())
+		{
+			adminStatistics.GET("/reports", listReports)
+			adminStatistics.POST("/reports/:id/rerun", rerunReport)
+			adminStatistics.DELETE("/reports/:id", deleteReport)
+			adminStatistics.GET("/report-definitions", listReportDefinitions)
+			adminStatistics.POST("/report-definitions", createReportDefinition)
+			adminStatistics.PUT("/report-definitions/:id", updateReportDefinition)
+			adminStatistics.DELETE("/report-definitions/:id", deleteReportDefinition)
+			adminStatistics.POST("/report-definitions/:id/run", runReportDefinition)
 		}
 	}
 
@@ -447,6 +530,39 @@ func setupRouter() *gin.Engine {
 				// 系统统计
 				authStatisticsAPI.GET("/system", getSystemStatistics)
 				authStatisticsAPI.GET("/system/performance", getPerformanceStats)
+
+				// 事件接入
+				authStatisticsAPI.POST("/events", ingestEvents)
+				authStatisticsAPI.GET("/events/ingest-stats", getIngestStats)
+				authStatisticsAPI.GET("/realtime", getRealTimeStats)
+				authStatisticsAPI.GET("/events/trend", getStatisticsTrend)
+				authStatisticsAPI.GET("/events/summary", getStatisticsSummary)
+
+				// 用户行为分析，漏斗和留存涉及全站用户分群，仅管理员可用
+				authStatisticsAPI.POST("/behaviors", trackUserBehavior)
+				authStatisticsAPI.GET("/behaviors/analysis", analyzeUserBehavior)
+				authStatisticsAPI.GET("/analytics/funnel", adminMiddleware(), getFunnelAnalysis)
+				authStatisticsAPI.GET("/analytics/cohorts", adminMiddleware(), getCohortRetention)
+
+				// 统计报表
+				authStatisticsAPI.GET("/reports", listMyReports)
+				authStatisticsAPI.POST("/reports", createReport)
+				authStatisticsAPI.GET("/reports/:id", getReport)
+				authStatisticsAPI.GET("/reports/:id/download", downloadReport)
+			}
+
+			// 报表管理API（管理员）
+			adminStatisticsAPI := statisticsAPI.Group("/admin")
+			adminStatisticsAPI.Use(authMiddleware(), adminMiddleware())
+			{
+				adminStatisticsAPI.GET("/reports", listReports)
+				adminStatisticsAPI.POST("/reports/:id/rerun", rerunReport)
+				adminStatisticsAPI.DELETE("/reports/:id", deleteReport)
+				adminStatisticsAPI.GET("/report-definitions", listReportDefinitions)
+				adminStatisticsAPI.POST("/report-definitions", createReportDefinition)
+				adminStatisticsAPI.PUT("/report-definitions/:id", updateReportDefinition)
+				adminStatisticsAPI.DELETE("/report-definitions/:id", deleteReportDefinition)
+				adminStatisticsAPI.POST("/report-definitions/:id/run", runReportDefinition)
 			}
 		}
 	}
@@ -654,6 +770,16 @@ func deleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+// 简化认证：开发环境令牌对应的用户和角色，管理员角色只能来自这里
+var devTokens = map[string]struct {
+	userID uint
+	roles  []string
+}{
+	"test-token":      {userID: 1},
+	"wx-token-123":    {userID: 1},
+	"admin-token-123": {userID: 999, roles: []string{"admin"}},
+}
+
 // 认证中间件
 func authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -669,10 +795,9 @@ func authMiddleware() gin.HandlerFunc {
 			token = token[7:]
 		}
 
-		// 简化认证：接受test-token或wx-token-123
-		if token == "test-token" || token == "wx-token-123" {
-			// 设置默认用户信息
-			c.Set("userID", uint(1))
+		if identity, ok := devTokens[token]; ok {
+			c.Set("userID", identity.userID)
+			c.Set("roles", identity.roles)
 			c.Next()
 			return
 		}
@@ -690,9 +815,9 @@ func authMiddleware() gin.HandlerFunc {
 	}
 }
 
-// 从上下文获取用户ID
+// 从上下文获取用户ID，未认证时为0
 func getUserIDFromContext(c *gin.Context) uint {
-	if userID, exists := c.Get("user_id"); exists {
+	if userID, exists := c.Get("userID"); exists {
 		if id, ok := userID.(uint); ok {
 			return id
 		}
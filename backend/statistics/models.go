@@ -2,6 +2,8 @@ package main
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // 统计类型
@@ -30,30 +32,35 @@ const (
 	StatisticsPeriodYear  StatisticsPeriod = "year"
 )
 
-// 统计数据模型
+// 统计数据模型，汇总行按(type, period, date)唯一，Value为区间内求和
 type Statistics struct {
-	ID          string           `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	Type        StatisticsType   `json:"type" gorm:"type:varchar(50);not null;index"`
-	Period      StatisticsPeriod `json:"period" gorm:"type:varchar(20);not null"`
-	Date        time.Time        `json:"date" gorm:"not null;index"`
-	Value       int64            `json:"value" gorm:"default:0"`
-	UserID      *uint            `json:"user_id" gorm:"index"`
-	ReferenceID string           `json:"reference_id" gorm:"type:varchar(100)"`
-	Metadata    string           `json:"metadata" gorm:"type:text"`
-	CreatedAt   time.Time        `json:"created_at"`
-	UpdatedAt   time.Time        `json:"updated_at"`
+	ID            string           `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Type          StatisticsType   `json:"type" gorm:"type:varchar(50);not null;index;uniqueIndex:idx_statistics_bucket"`
+	Period        StatisticsPeriod `json:"period" gorm:"type:varchar(20);not null;uniqueIndex:idx_statistics_bucket"`
+	Date          time.Time        `json:"date" gorm:"not null;index;uniqueIndex:idx_statistics_bucket"`
+	Value         int64            `json:"value" gorm:"default:0"`
+	Count         int64            `json:"count" gorm:"default:0"`
+	MinValue      int64            `json:"min_value" gorm:"default:0"`
+	MaxValue      int64            `json:"max_value" gorm:"default:0"`
+	DistinctUsers int64            `json:"distinct_users" gorm:"default:0"`
+	UserSketch    []byte           `json:"-" gorm:"type:blob"` // 去重用户的HyperLogLog草图，用于跨区间合并
+	UserID        *uint            `json:"user_id" gorm:"index"`
+	ReferenceID   string           `json:"reference_id" gorm:"type:varchar(100)"`
+	Metadata      string           `json:"metadata" gorm:"type:text"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
 }
 
 // 用户行为记录模型
 type UserBehavior struct {
 	ID          string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
 	UserID      uint           `json:"user_id" gorm:"not null;index"`
-	Type        StatisticsType `json:"type" gorm:"type:varchar(50);not null"`
+	Type        StatisticsType `json:"type" gorm:"type:varchar(50);not null;index:idx_user_behaviors_type_time"`
 	ReferenceID string         `json:"reference_id" gorm:"type:varchar(100)"`
 	IP          string         `json:"ip" gorm:"type:varchar(45)"`
 	UserAgent   string         `json:"user_agent" gorm:"type:text"`
 	Metadata    string         `json:"metadata" gorm:"type:text"`
-	CreatedAt   time.Time      `json:"created_at"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"index:idx_user_behaviors_type_time"`
 }
 
 // 实时统计缓存模型
@@ -66,16 +73,77 @@ type RealTimeStats struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
-// 统计报表模型
+// 报表状态
+type ReportStatus string
+
+const (
+	ReportStatusQueued    ReportStatus = "queued"    // 等待生成（含等待重试）
+	ReportStatusRunning   ReportStatus = "running"   // 生成中
+	ReportStatusSucceeded ReportStatus = "succeeded" // 已生成并上传
+	ReportStatusFailed    ReportStatus = "failed"    // 重试耗尽
+)
+
+// 统计报表模型，每次生成一条记录，由报表队列异步生成
 type StatisticsReport struct {
-	ID          string           `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	Name        string           `json:"name" gorm:"not null"`
-	Type        StatisticsType   `json:"type" gorm:"type:varchar(50);not null"`
-	Period      StatisticsPeriod `json:"period" gorm:"type:varchar(20);not null"`
-	StartDate   time.Time        `json:"start_date" gorm:"not null"`
-	EndDate     time.Time        `json:"end_date" gorm:"not null"`
-	Data        string           `json:"data" gorm:"type:text"`
-	IsGenerated bool             `json:"is_generated" gorm:"default:false"`
-	CreatedAt   time.Time        `json:"created_at"`
-	UpdatedAt   time.Time        `json:"updated_at"`
+	ID             string           `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	DefinitionID   string           `json:"definition_id" gorm:"type:varchar(36);index"`
+	Name           string           `json:"name" gorm:"not null"`
+	Type           StatisticsType   `json:"type" gorm:"type:varchar(50);not null"`
+	Period         StatisticsPeriod `json:"period" gorm:"type:varchar(20);not null"` // 请求的粒度，auto在生成时确定
+	StartDate      time.Time        `json:"start_date" gorm:"not null"`
+	EndDate        time.Time        `json:"end_date" gorm:"not null"`
+	Formats        string           `json:"formats" gorm:"type:varchar(50);not null"` // 逗号分隔
+	Data           string           `json:"data" gorm:"type:text"`                    // 汇总数据JSON
+	Files          string           `json:"-" gorm:"type:text"`                       // 格式 -> 存储文件ID的JSON
+	IsGenerated    bool             `json:"is_generated" gorm:"default:false"`
+	Status         ReportStatus     `json:"status" gorm:"type:varchar(20);not null;default:'queued';index:idx_report_claim"`
+	RunAt          time.Time        `json:"run_at" gorm:"index:idx_report_claim"`
+	Attempts       int              `json:"attempts" gorm:"default:0"`
+	LeaseExpiresAt *time.Time       `json:"-"`
+	Error          string           `json:"error" gorm:"type:text"`
+	RequestedBy    uint             `json:"requested_by" gorm:"index"`
+	StartedAt      *time.Time       `json:"started_at"`
+	FinishedAt     *time.Time       `json:"finished_at"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// 报表定义，按cron计划定期生成，接收人可查看和下载生成的报表
+type ReportDefinition struct {
+	ID               string           `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name             string           `json:"name" gorm:"not null"`
+	Type             StatisticsType   `json:"type" gorm:"type:varchar(50);not null"`
+	Period           StatisticsPeriod `json:"period" gorm:"type:varchar(20);not null;default:'auto'"`
+	RangeDays        int              `json:"range_days" gorm:"not null;default:7"` // 每次覆盖计划时间之前的天数
+	Schedule         string           `json:"schedule" gorm:"type:varchar(100)"`    // cron表达式，为空时只能手动运行
+	Formats          string           `json:"formats" gorm:"type:varchar(50);not null"`
+	RecipientUserIDs string           `json:"recipient_user_ids" gorm:"type:varchar(500)"` // 逗号分隔
+	Enabled          bool             `json:"enabled" gorm:"default:true"`
+	NextRunAt        *time.Time       `json:"next_run_at" gorm:"index"`
+	LastRunAt        *time.Time       `json:"last_run_at"`
+	CreatedBy        uint             `json:"created_by"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// 原始统计事件，ID即事件ID，用于去重
+type StatisticsEvent struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	Type        string    `json:"type" gorm:"type:varchar(50);not null;index:idx_statistics_events_type_time"`
+	Value       int64     `json:"value" gorm:"default:1"`
+	UserID      *uint     `json:"user_id" gorm:"index"`
+	ReferenceID string    `json:"reference_id" gorm:"type:varchar(100)"`
+	Metadata    string    `json:"metadata" gorm:"type:text"`
+	Source      string    `json:"source" gorm:"type:varchar(50)"`
+	OccurredAt  time.Time `json:"occurred_at" gorm:"not null;index:idx_statistics_events_type_time"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+// 汇总进度，按事件入库时间与ID推进
+type StatisticsRollupCursor struct {
+	Name           string    `json:"name" gorm:"primaryKey;type:varchar(50)"`
+	EventCreatedAt time.Time `json:"event_created_at" gorm:"not null"`
+	EventID        string    `json:"event_id" gorm:"type:varchar(64)"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
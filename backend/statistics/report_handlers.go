@@ -0,0 +1,463 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"resume-centre/statistics/export"
+	"resume-centre/statistics/rollup"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// 管理员接口：角色只来自认证中间件验证过的令牌
+func adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func isAdmin(c *gin.Context) bool {
+	var roles []string
+	if v, ok := c.Get("roles"); ok {
+		roles, _ = v.([]string)
+	}
+	for _, role := range roles {
+		for _, admin := range viper.GetStringSlice("reports.admin_roles") {
+			if role == admin {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 报表及其下载链接
+func reportView(report *StatisticsReport) gin.H {
+	return gin.H{"report": report, "links": reportLinks(report)}
+}
+
+// 创建一次临时报表，异步生成
+func createReport(c *gin.Context) {
+	var req struct {
+		Name      string   `json:"name" binding:"required"`
+		Type      string   `json:"type" binding:"required"`
+		Period    string   `json:"period"`
+		StartDate string   `json:"start_date" binding:"required"`
+		EndDate   string   `json:"end_date" binding:"required"`
+		Formats   []string `json:"formats"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		return
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		return
+	}
+	// 结束日期包含当天
+	endDate = endDate.AddDate(0, 0, 1).Add(-time.Second)
+	if endDate.Before(startDate) || endDate.Sub(startDate) > time.Duration(viper.GetInt("reports.max_range_days"))*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or too large date range"})
+		return
+	}
+	if req.Period == "" {
+		req.Period = "auto"
+	}
+	if len(req.Formats) == 0 {
+		req.Formats = []string{string(export.CSV)}
+	}
+	if err := validateReportOptions(req.Period, req.Formats); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := &StatisticsReport{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Type:        StatisticsType(req.Type),
+		Period:      StatisticsPeriod(req.Period),
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Formats:     strings.Join(req.Formats, ","),
+		Status:      ReportStatusQueued,
+		RunAt:       time.Now(),
+		RequestedBy: userID,
+	}
+	if err := db.Create(report).Error; err != nil {
+		logger.Errorf("Failed to create report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		return
+	}
+	notifyReportWorkers()
+
+	c.JSON(http.StatusAccepted, reportView(report))
+}
+
+func validateReportOptions(period string, formats []string) error {
+	if _, ok := rollup.ParseGranularity(period); !ok && period != "auto" {
+		return errors.New("Invalid period, expected auto, hour, day, week, month or year")
+	}
+	for _, f := range formats {
+		if _, err := export.ParseFormat(f); err != nil {
+			return errors.New("Invalid format, expected csv, xlsx or pdf")
+		}
+	}
+	return nil
+}
+
+// 请求人、定义的接收人或管理员可以查看报表
+func loadVisibleReport(c *gin.Context) (*StatisticsReport, bool) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	var report StatisticsReport
+	if err := db.Where("id = ?", c.Param("id")).First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		} else {
+			logger.Errorf("Failed to get report: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get report"})
+		}
+		return nil, false
+	}
+	if report.RequestedBy != userID && !isAdmin(c) && !isReportRecipient(&report, userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return nil, false
+	}
+	return &report, true
+}
+
+func isReportRecipient(report *StatisticsReport, userID uint) bool {
+	if report.DefinitionID == "" {
+		return false
+	}
+	var count int64
+	db.Unscoped().Model(&ReportDefinition{}).
+		Where("id = ? AND FIND_IN_SET(?, recipient_user_ids) > 0", report.DefinitionID, userID).Count(&count)
+	return count > 0
+}
+
+// 查询报表状态
+func getReport(c *gin.Context) {
+	report, ok := loadVisibleReport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, reportView(report))
+}
+
+// 从存储服务下载报表文件
+func downloadReport(c *gin.Context) {
+	report, ok := loadVisibleReport(c)
+	if !ok {
+		return
+	}
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.CSV)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected csv, xlsx or pdf"})
+		return
+	}
+	var files map[string]string
+	json.Unmarshal([]byte(report.Files), &files)
+	fileID := files[string(format)]
+	if report.Status != ReportStatusSucceeded || fileID == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report file not available"})
+		return
+	}
+
+	body, err := newStorageClient().Open(c.Request.Context(), fileID)
+	if err != nil {
+		logger.Errorf("Failed to open report file %s: %v", fileID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to load report file"})
+		return
+	}
+	defer body.Close()
+
+	fileName := fmt.Sprintf("%s_%s.%s", report.Type, report.EndDate.Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Header("Content-Type", format.ContentType())
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, body); err != nil {
+		logger.Warnf("Report download %s interrupted: %v", report.ID, err)
+	}
+}
+
+// 当前用户请求的报表以及作为接收人的定期报表
+func listMyReports(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	recipientOf := db.Unscoped().Model(&ReportDefinition{}).Select("id").
+		Where("FIND_IN_SET(?, recipient_user_ids) > 0", userID)
+	query := db.Model(&StatisticsReport{}).Where("requested_by = ? OR definition_id IN (?)", userID, recipientOf)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	respondReportPage(c, query)
+}
+
+// 管理员：报表列表，可按状态和定义过滤
+func listReports(c *gin.Context) {
+	query := db.Model(&StatisticsReport{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if definitionID := c.Query("definition_id"); definitionID != "" {
+		query = query.Where("definition_id = ?", definitionID)
+	}
+	respondReportPage(c, query)
+}
+
+func respondReportPage(c *gin.Context, query *gorm.DB) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	var total int64
+	var reports []StatisticsReport
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("Failed to list reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reports"})
+		return
+	}
+	if err := query.Order("created_at DESC").Offset((page - 1) * size).Limit(size).Find(&reports).Error; err != nil {
+		logger.Errorf("Failed to list reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reports"})
+		return
+	}
+
+	items := make([]gin.H, len(reports))
+	for i := range reports {
+		items[i] = reportView(&reports[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"reports": items, "total": total, "page": page, "size": size})
+}
+
+// 管理员：以相同参数重新生成报表
+func rerunReport(c *gin.Context) {
+	var original StatisticsReport
+	if err := db.Where("id = ?", c.Param("id")).First(&original).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	if original.Status == ReportStatusQueued || original.Status == ReportStatusRunning {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report is still being generated"})
+		return
+	}
+
+	report := &StatisticsReport{
+		ID:           uuid.New().String(),
+		DefinitionID: original.DefinitionID,
+		Name:         original.Name,
+		Type:         original.Type,
+		Period:       original.Period,
+		StartDate:    original.StartDate,
+		EndDate:      original.EndDate,
+		Formats:      original.Formats,
+		Status:       ReportStatusQueued,
+		RunAt:        time.Now(),
+		RequestedBy:  original.RequestedBy,
+	}
+	if err := db.Create(report).Error; err != nil {
+		logger.Errorf("Failed to rerun report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rerun report"})
+		return
+	}
+	notifyReportWorkers()
+
+	c.JSON(http.StatusAccepted, reportView(report))
+}
+
+// 管理员：删除报表记录（已上传的文件由存储服务的保留策略清理）
+func deleteReport(c *gin.Context) {
+	result := db.Where("id = ?", c.Param("id")).Delete(&StatisticsReport{})
+	if result.Error != nil {
+		logger.Errorf("Failed to delete report: %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete report"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Report deleted successfully"})
+}
+
+// 报表定义请求
+type reportDefinitionRequest struct {
+	Name             string   `json:"name" binding:"required"`
+	Type             string   `json:"type" binding:"required"`
+	Period           string   `json:"period"`
+	RangeDays        int      `json:"range_days"`
+	Schedule         string   `json:"schedule"`
+	Formats          []string `json:"formats"`
+	RecipientUserIDs []uint   `json:"recipient_user_ids"`
+	Enabled          *bool    `json:"enabled"`
+}
+
+// 校验并写入定义，同时重新计算下次运行时间
+func (req *reportDefinitionRequest) apply(definition *ReportDefinition) error {
+	if req.Period == "" {
+		req.Period = "auto"
+	}
+	if req.RangeDays == 0 {
+		req.RangeDays = 7
+	}
+	if req.RangeDays < 1 || req.RangeDays > viper.GetInt("reports.max_range_days") {
+		return errors.New("range_days out of range")
+	}
+	if len(req.Formats) == 0 {
+		req.Formats = []string{string(export.CSV)}
+	}
+	if err := validateReportOptions(req.Period, req.Formats); err != nil {
+		return err
+	}
+	next, err := nextReportRun(req.Schedule, time.Now())
+	if err != nil {
+		return fmt.Errorf("Invalid schedule: %v", err)
+	}
+
+	userIDs := make([]string, len(req.RecipientUserIDs))
+	for i, id := range req.RecipientUserIDs {
+		userIDs[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	definition.Name = req.Name
+	definition.Type = StatisticsType(req.Type)
+	definition.Period = StatisticsPeriod(req.Period)
+	definition.RangeDays = req.RangeDays
+	definition.Schedule = strings.TrimSpace(req.Schedule)
+	definition.Formats = strings.Join(req.Formats, ",")
+	definition.RecipientUserIDs = strings.Join(userIDs, ",")
+	if req.Enabled != nil {
+		definition.Enabled = *req.Enabled
+	}
+	definition.NextRunAt = nil
+	if !next.IsZero() {
+		definition.NextRunAt = &next
+	}
+	return nil
+}
+
+// 管理员：报表定义列表
+func listReportDefinitions(c *gin.Context) {
+	var definitions []ReportDefinition
+	if err := db.Order("created_at DESC").Find(&definitions).Error; err != nil {
+		logger.Errorf("Failed to list report definitions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list report definitions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"definitions": definitions})
+}
+
+// 管理员：创建报表定义
+func createReportDefinition(c *gin.Context) {
+	var req reportDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	definition := &ReportDefinition{ID: uuid.New().String(), Enabled: true, CreatedBy: getUserIDFromContext(c)}
+	if err := req.apply(definition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Create(definition).Error; err != nil {
+		logger.Errorf("Failed to create report definition: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report definition"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"definition": definition})
+}
+
+// 管理员：更新报表定义
+func updateReportDefinition(c *gin.Context) {
+	var definition ReportDefinition
+	if err := db.Where("id = ?", c.Param("id")).First(&definition).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report definition not found"})
+		return
+	}
+	var req reportDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.apply(&definition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Save(&definition).Error; err != nil {
+		logger.Errorf("Failed to update report definition: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update report definition"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"definition": definition})
+}
+
+// 管理员：删除报表定义，已生成的报表保留
+func deleteReportDefinition(c *gin.Context) {
+	result := db.Where("id = ?", c.Param("id")).Delete(&ReportDefinition{})
+	if result.Error != nil {
+		logger.Errorf("Failed to delete report definition: %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete report definition"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report definition not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Report definition deleted successfully"})
+}
+
+// 管理员：立即按定义生成一次报表，不影响计划
+func runReportDefinition(c *gin.Context) {
+	var definition ReportDefinition
+	if err := db.Where("id = ?", c.Param("id")).First(&definition).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report definition not found"})
+		return
+	}
+	report, err := enqueueDefinitionReport(db, &definition, time.Now(), getUserIDFromContext(c))
+	if err != nil {
+		logger.Errorf("Failed to run report definition: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run report definition"})
+		return
+	}
+	notifyReportWorkers()
+	c.JSON(http.StatusAccepted, reportView(report))
+}
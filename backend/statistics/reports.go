@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"resume-centre/shared/infrastructure/storage"
+	"resume-centre/statistics/cron"
+	"resume-centre/statistics/export"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var reportWake = make(chan struct{}, 1)
+
+// 启动报表生成工作线程和计划调度，ctx取消后等待进行中的报表结束
+func startReportWorkers(ctx context.Context) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < max(viper.GetInt("reports.workers"), 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runReportWorker(ctx)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runReportScheduler(ctx)
+	}()
+	return &wg
+}
+
+// 提醒工作线程有新报表，避免等待下一个轮询周期
+func notifyReportWorkers() {
+	select {
+	case reportWake <- struct{}{}:
+	default:
+	}
+}
+
+func runReportWorker(ctx context.Context) {
+	ticker := time.NewTicker(viper.GetDuration("reports.poll_interval"))
+	defer ticker.Stop()
+	for {
+		reclaimExpiredReports()
+		for {
+			report, err := claimReport()
+			if err != nil {
+				logger.Errorf("Failed to claim statistics report: %v", err)
+				break
+			}
+			if report == nil {
+				break
+			}
+			processReport(ctx, report)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-reportWake:
+		}
+	}
+}
+
+// 领取一个待生成的报表：SKIP LOCKED避免多个实例争抢
+func claimReport() (*StatisticsReport, error) {
+	var report StatisticsReport
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", ReportStatusQueued, now).
+			Order("run_at ASC").First(&report).Error; err != nil {
+			return err
+		}
+		lease := now.Add(viper.GetDuration("reports.lease_duration"))
+		report.Status = ReportStatusRunning
+		report.Attempts++
+		report.LeaseExpiresAt = &lease
+		report.StartedAt = &now
+		return tx.Model(&report).Updates(map[string]interface{}{
+			"status":           report.Status,
+			"attempts":         report.Attempts,
+			"lease_expires_at": report.LeaseExpiresAt,
+			"started_at":       report.StartedAt,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// 租约过期（实例崩溃）的报表按失败处理
+func reclaimExpiredReports() {
+	var expired []StatisticsReport
+	if err := db.Where("status = ? AND lease_expires_at < ?", ReportStatusRunning, time.Now()).
+		Limit(20).Find(&expired).Error; err != nil {
+		logger.Errorf("Failed to load expired statistics reports: %v", err)
+		return
+	}
+	for i := range expired {
+		failReport(&expired[i], errors.New("lease expired"))
+	}
+}
+
+func processReport(ctx context.Context, report *StatisticsReport) {
+	ctx, cancel := context.WithTimeout(ctx, viper.GetDuration("reports.lease_duration"))
+	defer cancel()
+
+	summary, files, err := generateReportFiles(ctx, report)
+	if err != nil {
+		failReport(report, err)
+		return
+	}
+
+	now := time.Now()
+	result := db.Model(&StatisticsReport{}).Where("id = ? AND status = ?", report.ID, ReportStatusRunning).
+		Updates(map[string]interface{}{
+			"status":           ReportStatusSucceeded,
+			"is_generated":     true,
+			"data":             summary,
+			"files":            files,
+			"error":            "",
+			"lease_expires_at": nil,
+			"finished_at":      &now,
+		})
+	if result.Error != nil {
+		logger.Errorf("Failed to save statistics report %s: %v", report.ID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// 期间被删除或已被收回
+		return
+	}
+	report.Files = files
+	logger.Infof("Generated statistics report %s (%s) in %s", report.ID, report.Name, now.Sub(*report.StartedAt))
+}
+
+// 可重试时按指数退避重新排队，否则标记失败
+func failReport(report *StatisticsReport, cause error) {
+	updates := map[string]interface{}{
+		"error":            cause.Error(),
+		"lease_expires_at": nil,
+	}
+	failed := report.Attempts >= viper.GetInt("reports.max_attempts")
+	if failed {
+		now := time.Now()
+		updates["status"] = ReportStatusFailed
+		updates["finished_at"] = &now
+	} else {
+		updates["status"] = ReportStatusQueued
+		updates["run_at"] = time.Now().Add(reportBackoff(report.Attempts))
+	}
+
+	result := db.Model(&StatisticsReport{}).Where("id = ? AND status = ?", report.ID, ReportStatusRunning).Updates(updates)
+	if result.Error != nil {
+		logger.Errorf("Failed to record failure of statistics report %s: %v", report.ID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+	if !failed {
+		logger.Warnf("Statistics report %s attempt %d failed, retrying: %v", report.ID, report.Attempts, cause)
+		return
+	}
+	logger.Errorf("Statistics report %s failed after %d attempts: %v", report.ID, report.Attempts, cause)
+}
+
+// base * 2^(attempts-1)，加入随机抖动
+func reportBackoff(attempts int) time.Duration {
+	delay := viper.GetDuration("reports.retry_backoff")
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// 生成各格式文件并上传到存储服务，返回汇总数据JSON和文件ID映射JSON
+func generateReportFiles(ctx context.Context, report *StatisticsReport) (string, string, error) {
+	granularity, ok := resolveGranularity(string(report.Period), report.StartDate, report.EndDate)
+	if !ok {
+		return "", "", fmt.Errorf("invalid period %q", report.Period)
+	}
+	points, total, err := loadTrend(ctx, string(report.Type), granularity, report.StartDate, report.EndDate)
+	if err != nil {
+		return "", "", err
+	}
+
+	table := &export.Table{
+		Title:       report.Name,
+		GeneratedAt: time.Now(),
+		Columns:     []string{"period", "count", "value", "avg", "min", "max", "distinct_users"},
+	}
+	for _, p := range points {
+		table.Rows = append(table.Rows, []interface{}{
+			granularity.Label(p.Start), p.Count, p.Sum, average(p.Sum, p.Count), p.Min, p.Max, p.Users.Estimate(),
+		})
+	}
+	table.Rows = append(table.Rows, []interface{}{
+		"total", total.Count, total.Sum, average(total.Sum, total.Count), total.Min, total.Max, total.Users.Estimate(),
+	})
+
+	files := map[string]string{}
+	store := newStorageClient()
+	for _, name := range splitList(report.Formats) {
+		format, err := export.ParseFormat(name)
+		if err != nil {
+			return "", "", err
+		}
+		fileName := fmt.Sprintf("%s_%s_%s.%s", report.Type, report.StartDate.Format("20060102"), report.EndDate.Format("20060102"), format)
+		fileID, err := uploadExport(ctx, store, report.RequestedBy, fileName, format, table)
+		if err != nil {
+			return "", "", fmt.Errorf("upload %s: %w", format, err)
+		}
+		files[string(format)] = fileID
+	}
+
+	summary, _ := json.Marshal(map[string]interface{}{
+		"period":         granularity,
+		"points":         len(points),
+		"count":          total.Count,
+		"total_value":    total.Sum,
+		"avg_value":      average(total.Sum, total.Count),
+		"distinct_users": total.Users.Estimate(),
+	})
+	filesJSON, _ := json.Marshal(files)
+	return string(summary), string(filesJSON), nil
+}
+
+// 边导出边上传，不在内存中保留完整文件
+func uploadExport(ctx context.Context, store *storage.Client, userID uint, name string, format export.Format, table *export.Table) (string, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(export.Write(pw, format, table))
+	}()
+	fileID, err := store.Put(ctx, userID, name, format.ContentType(), pr)
+	pr.CloseWithError(err)
+	return fileID, err
+}
+
+func average(sum, count int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(sum) / float64(count)
+}
+
+// 报表的下载链接，格式 -> URL
+func reportLinks(report *StatisticsReport) map[string]string {
+	var files map[string]string
+	if report.Files == "" || json.Unmarshal([]byte(report.Files), &files) != nil {
+		return nil
+	}
+	links := make(map[string]string, len(files))
+	base := strings.TrimRight(viper.GetString("reports.public_base_url"), "/")
+	for format := range files {
+		links[format] = fmt.Sprintf("%s/api/v1/statistics/reports/%s/download?format=%s", base, report.ID, format)
+	}
+	return links
+}
+
+// 到期的报表定义入队，并按cron计算下次运行时间
+func runReportScheduler(ctx context.Context) {
+	ticker := time.NewTicker(viper.GetDuration("reports.scheduler_interval"))
+	defer ticker.Stop()
+	for {
+		if n, err := enqueueDueReports(time.Now()); err != nil {
+			logger.Errorf("Failed to schedule statistics reports: %v", err)
+		} else if n > 0 {
+			notifyReportWorkers()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func enqueueDueReports(now time.Time) (int, error) {
+	enqueued := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var due []ReportDefinition
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("enabled = ? AND next_run_at <= ?", true, now).Limit(100).Find(&due).Error; err != nil {
+			return err
+		}
+		for i := range due {
+			definition := &due[i]
+			// 以计划时间为报表截止时间，调度延迟不影响覆盖范围
+			if _, err := enqueueDefinitionReport(tx, definition, *definition.NextRunAt, 0); err != nil {
+				return err
+			}
+			enqueued++
+
+			updates := map[string]interface{}{"last_run_at": now, "next_run_at": nil}
+			if next, err := nextReportRun(definition.Schedule, now); err != nil {
+				logger.Warnf("Report definition %s has invalid schedule %q: %v", definition.ID, definition.Schedule, err)
+			} else if !next.IsZero() {
+				updates["next_run_at"] = next
+			}
+			if err := tx.Model(definition).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return enqueued, err
+}
+
+// 计算下次运行时间，没有计划时返回零值
+func nextReportRun(schedule string, after time.Time) (time.Time, error) {
+	if strings.TrimSpace(schedule) == "" {
+		return time.Time{}, nil
+	}
+	s, err := cron.Parse(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Next(after.In(time.Local)), nil
+}
+
+// 按定义创建一次报表，覆盖截止时间之前RangeDays天
+func enqueueDefinitionReport(tx *gorm.DB, definition *ReportDefinition, end time.Time, requestedBy uint) (*StatisticsReport, error) {
+	if requestedBy == 0 {
+		requestedBy = definition.CreatedBy
+	}
+	report := &StatisticsReport{
+		ID:           uuid.New().String(),
+		DefinitionID: definition.ID,
+		Name:         fmt.Sprintf("%s %s", definition.Name, end.Format("2006-01-02")),
+		Type:         definition.Type,
+		Period:       definition.Period,
+		StartDate:    end.AddDate(0, 0, -definition.RangeDays),
+		EndDate:      end,
+		Formats:      definition.Formats,
+		Status:       ReportStatusQueued,
+		RunAt:        time.Now(),
+		RequestedBy:  requestedBy,
+	}
+	if err := tx.Create(report).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
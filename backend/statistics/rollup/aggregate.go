@@ -0,0 +1,45 @@
+package rollup
+
+// Aggregate 一个区间内的可合并聚合值
+type Aggregate struct {
+	Count int64
+	Sum   int64
+	Min   int64
+	Max   int64
+	Users *HLL
+}
+
+func NewAggregate() *Aggregate {
+	return &Aggregate{Users: NewHLL()}
+}
+
+// Add 记录一个事件，userID为nil时不计入去重用户数
+func (a *Aggregate) Add(value int64, userID *uint) {
+	if a.Count == 0 || value < a.Min {
+		a.Min = value
+	}
+	if a.Count == 0 || value > a.Max {
+		a.Max = value
+	}
+	a.Count++
+	a.Sum += value
+	if userID != nil {
+		a.Users.AddUint(uint64(*userID))
+	}
+}
+
+// Merge 合并另一个聚合，迟到事件的增量也通过合并写入已有区间
+func (a *Aggregate) Merge(o *Aggregate) {
+	if o == nil || o.Count == 0 {
+		return
+	}
+	if a.Count == 0 || o.Min < a.Min {
+		a.Min = o.Min
+	}
+	if a.Count == 0 || o.Max > a.Max {
+		a.Max = o.Max
+	}
+	a.Count += o.Count
+	a.Sum += o.Sum
+	a.Users.Merge(o.Users)
+}
@@ -0,0 +1,97 @@
+// Package rollup 统计数据的时间粒度与可合并聚合（计数、求和、极值、HyperLogLog去重用户数）
+package rollup
+
+import "time"
+
+// Granularity 汇总粒度
+type Granularity string
+
+const (
+	Hour  Granularity = "hour"
+	Day   Granularity = "day"
+	Week  Granularity = "week"
+	Month Granularity = "month"
+	Year  Granularity = "year"
+)
+
+// Granularities 由细到粗
+var Granularities = []Granularity{Hour, Day, Week, Month, Year}
+
+// ParseGranularity 解析粒度名称
+func ParseGranularity(s string) (Granularity, bool) {
+	for _, g := range Granularities {
+		if string(g) == s {
+			return g, true
+		}
+	}
+	return "", false
+}
+
+// Truncate 返回t所在区间的起点（按t的时区），周从周一开始
+func (g Granularity) Truncate(t time.Time) time.Time {
+	loc := t.Location()
+	switch g {
+	case Hour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case Day:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	case Year:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc)
+	}
+	return t
+}
+
+// Next 下一个区间的起点，start须为区间起点
+func (g Granularity) Next(start time.Time) time.Time {
+	switch g {
+	case Hour:
+		return start.Add(time.Hour)
+	case Day:
+		return start.AddDate(0, 0, 1)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	case Year:
+		return start.AddDate(1, 0, 0)
+	}
+	return start
+}
+
+// Buckets 覆盖[start, end]的所有区间起点
+func (g Granularity) Buckets(start, end time.Time) []time.Time {
+	var buckets []time.Time
+	for b := g.Truncate(start); !b.After(end); b = g.Next(b) {
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// Label 区间的显示名称
+func (g Granularity) Label(start time.Time) string {
+	switch g {
+	case Hour:
+		return start.Format("2006-01-02 15:00")
+	case Month:
+		return start.Format("2006-01")
+	case Year:
+		return start.Format("2006")
+	}
+	return start.Format("2006-01-02")
+}
+
+// Pick 选择至少产生minPoints个数据点的最粗粒度，范围过短时退回到小时
+func Pick(start, end time.Time, minPoints int) Granularity {
+	for i := len(Granularities) - 1; i > 0; i-- {
+		g := Granularities[i]
+		if len(g.Buckets(start, end)) >= minPoints {
+			return g
+		}
+	}
+	return Hour
+}
@@ -0,0 +1,136 @@
+package rollup
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var shanghai = time.FixedZone("CST", 8*3600)
+
+func TestTruncateAndNext(t *testing.T) {
+	ts := time.Date(2024, 2, 29, 13, 45, 10, 0, shanghai) // 周四
+	cases := []struct {
+		g          Granularity
+		start, nxt string
+	}{
+		{Hour, "2024-02-29 13:00", "2024-02-29 14:00"},
+		{Day, "2024-02-29 00:00", "2024-03-01 00:00"},
+		{Week, "2024-02-26 00:00", "2024-03-04 00:00"},
+		{Month, "2024-02-01 00:00", "2024-03-01 00:00"},
+		{Year, "2024-01-01 00:00", "2025-01-01 00:00"},
+	}
+	for _, c := range cases {
+		start := c.g.Truncate(ts)
+		if got := start.Format("2006-01-02 15:04"); got != c.start {
+			t.Errorf("%s Truncate = %s, want %s", c.g, got, c.start)
+		}
+		if got := c.g.Next(start).Format("2006-01-02 15:04"); got != c.nxt {
+			t.Errorf("%s Next = %s, want %s", c.g, got, c.nxt)
+		}
+		if start.Location() != shanghai {
+			t.Errorf("%s lost time zone", c.g)
+		}
+	}
+
+	// 周日属于前一周
+	sunday := time.Date(2024, 3, 3, 23, 0, 0, 0, shanghai)
+	if got := Week.Truncate(sunday).Format("2006-01-02"); got != "2024-02-26" {
+		t.Errorf("Sunday week start = %s", got)
+	}
+}
+
+func TestPickCoarsestAdequateGranularity(t *testing.T) {
+	base := time.Date(2024, 1, 10, 0, 0, 0, 0, shanghai)
+	cases := []struct {
+		span time.Duration
+		want Granularity
+	}{
+		{6 * time.Hour, Hour},
+		{2 * 24 * time.Hour, Hour},
+		{30 * 24 * time.Hour, Day},
+		{120 * 24 * time.Hour, Week},
+		{400 * 24 * time.Hour, Month},
+		{10 * 366 * 24 * time.Hour, Year},
+	}
+	for _, c := range cases {
+		if got := Pick(base, base.Add(c.span), 7); got != c.want {
+			t.Errorf("Pick(%v) = %s, want %s", c.span, got, c.want)
+		}
+	}
+
+	buckets := Day.Buckets(base.Add(5*time.Hour), base.Add(50*time.Hour))
+	if len(buckets) != 3 || !buckets[0].Equal(base) {
+		t.Errorf("buckets = %v", buckets)
+	}
+}
+
+func TestHLLEstimateAndMerge(t *testing.T) {
+	for _, n := range []int{10, 1000, 50000} {
+		h := NewHLL()
+		for i := 0; i < n; i++ {
+			h.AddUint(uint64(i))
+			h.AddUint(uint64(i)) // 重复值不影响估计
+		}
+		if err := math.Abs(float64(h.Estimate()-int64(n))) / float64(n); err > 0.05 {
+			t.Errorf("n=%d estimate=%d (error %.3f)", n, h.Estimate(), err)
+		}
+	}
+
+	a, b := NewHLL(), NewHLL()
+	for i := 0; i < 3000; i++ {
+		a.AddUint(uint64(i))
+	}
+	for i := 2000; i < 5000; i++ {
+		b.AddUint(uint64(i))
+	}
+	a.Merge(b)
+	if est := a.Estimate(); est < 4500 || est > 5500 {
+		t.Errorf("union estimate = %d, want about 5000", est)
+	}
+
+	restored, err := ParseHLL(a.Bytes())
+	if err != nil || restored.Estimate() != a.Estimate() {
+		t.Errorf("round trip = %v, %v", restored, err)
+	}
+	if NewHLL().Bytes() != nil {
+		t.Error("empty sketch should serialize to nil")
+	}
+	if _, err := ParseHLL([]byte{1, 2, 3}); err != ErrInvalidSketch {
+		t.Errorf("ParseHLL(short) = %v", err)
+	}
+}
+
+func TestAggregateMergeMatchesDirectAdds(t *testing.T) {
+	u := func(id uint) *uint { return &id }
+
+	direct := NewAggregate()
+	early, late := NewAggregate(), NewAggregate()
+	values := []int64{5, -3, 12, 7}
+	for i, v := range values {
+		direct.Add(v, u(uint(i%2)))
+		if i < 2 {
+			early.Add(v, u(uint(i%2)))
+		} else {
+			late.Add(v, u(uint(i%2)))
+		}
+	}
+	direct.Add(1, nil)
+	late.Add(1, nil)
+
+	// 迟到事件的增量合并进已有区间，结果与一次性聚合相同
+	early.Merge(late)
+	if early.Count != 5 || early.Sum != 22 || early.Min != -3 || early.Max != 12 || early.Users.Estimate() != 2 {
+		t.Errorf("merged = %+v users=%d", early, early.Users.Estimate())
+	}
+	if early.Count != direct.Count || early.Sum != direct.Sum || early.Min != direct.Min || early.Max != direct.Max {
+		t.Errorf("merged %+v != direct %+v", early, direct)
+	}
+
+	empty := NewAggregate()
+	empty.Merge(NewAggregate())
+	empty.Merge(late)
+	if empty.Min != 1 || empty.Max != 12 {
+		t.Errorf("merge into empty = %+v", empty)
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"resume-centre/statistics/rollup"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 原始事件汇总进度的名称
+const eventsRollupCursor = "statistics_events"
+
+// 定时把新入库的原始事件汇总到各粒度，并清理过期的原始事件
+func startRollupWorker(ctx context.Context) {
+	go func() {
+		rollupTicker := time.NewTicker(viper.GetDuration("statistics.rollup.interval"))
+		defer rollupTicker.Stop()
+		retentionTicker := time.NewTicker(viper.GetDuration("statistics.retention.interval"))
+		defer retentionTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-rollupTicker.C:
+				if n, err := runRollups(); err != nil {
+					logger.Errorf("Statistics rollup failed after %d events: %v", n, err)
+				} else if n > 0 {
+					logger.Infof("Rolled up %d statistics events", n)
+				}
+			case <-retentionTicker.C:
+				if n, err := purgeRawEvents(); err != nil {
+					logger.Errorf("Failed to purge raw statistics events: %v", err)
+				} else if n > 0 {
+					logger.Infof("Purged %d raw statistics events", n)
+				}
+			}
+		}
+	}()
+}
+
+// 分批汇总直到追上水位线；事件按入库时间处理，迟到事件会被合并进其发生时间所在的已有区间
+func runRollups() (int, error) {
+	batchSize := viper.GetInt("statistics.rollup.batch_size")
+	total := 0
+	for {
+		// 留出写入事务的提交时间，避免跳过入库时间较早但尚未提交的事件
+		horizon := time.Now().Add(-viper.GetDuration("statistics.rollup.lag"))
+		n, err := rollupBatch(batchSize, horizon)
+		total += n
+		if err != nil || n < batchSize {
+			return total, err
+		}
+	}
+}
+
+type rollupKey struct {
+	Type   string
+	Period StatisticsPeriod
+	Start  int64
+}
+
+func rollupBatch(limit int, horizon time.Time) (int, error) {
+	processed := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 游标行锁保证多实例下同一时刻只有一个汇总事务
+		cursor, err := lockRollupCursor(tx)
+		if err != nil {
+			return err
+		}
+
+		var events []StatisticsEvent
+		if err := tx.Where("created_at <= ? AND (created_at > ? OR (created_at = ? AND id > ?))",
+			horizon, cursor.EventCreatedAt, cursor.EventCreatedAt, cursor.EventID).
+			Order("created_at ASC, id ASC").Limit(limit).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		deltas := map[rollupKey]*rollup.Aggregate{}
+		for _, e := range events {
+			at := e.OccurredAt.In(time.Local)
+			for _, g := range rollup.Granularities {
+				key := rollupKey{Type: e.Type, Period: StatisticsPeriod(g), Start: g.Truncate(at).Unix()}
+				if deltas[key] == nil {
+					deltas[key] = rollup.NewAggregate()
+				}
+				deltas[key].Add(e.Value, e.UserID)
+			}
+		}
+		if err := mergeRollups(tx, deltas); err != nil {
+			return err
+		}
+
+		last := events[len(events)-1]
+		cursor.EventCreatedAt, cursor.EventID = last.CreatedAt, last.ID
+		if err := tx.Save(cursor).Error; err != nil {
+			return err
+		}
+		processed = len(events)
+		return nil
+	})
+	return processed, err
+}
+
+func lockRollupCursor(tx *gorm.DB) (*StatisticsRollupCursor, error) {
+	cursor := &StatisticsRollupCursor{Name: eventsRollupCursor, EventCreatedAt: time.Unix(0, 0)}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(cursor).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("name = ?", eventsRollupCursor).First(cursor).Error; err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// 将增量合并进已有汇总行，不存在的区间新建
+func mergeRollups(tx *gorm.DB, deltas map[rollupKey]*rollup.Aggregate) error {
+	byPeriod := map[StatisticsPeriod][]rollupKey{}
+	for key := range deltas {
+		byPeriod[key.Period] = append(byPeriod[key.Period], key)
+	}
+
+	for period, keys := range byPeriod {
+		typeSet, dateSet := map[string]bool{}, map[int64]bool{}
+		var types []string
+		var dates []time.Time
+		for _, key := range keys {
+			if !typeSet[key.Type] {
+				typeSet[key.Type] = true
+				types = append(types, key.Type)
+			}
+			if !dateSet[key.Start] {
+				dateSet[key.Start] = true
+				dates = append(dates, time.Unix(key.Start, 0))
+			}
+		}
+
+		var rows []Statistics
+		if err := tx.Where("period = ? AND type IN ? AND date IN ?", period, types, dates).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		existing := make(map[rollupKey]*Statistics, len(rows))
+		for i := range rows {
+			existing[rollupKey{Type: string(rows[i].Type), Period: period, Start: rows[i].Date.Unix()}] = &rows[i]
+		}
+
+		for _, key := range keys {
+			row := existing[key]
+			if row == nil {
+				row = &Statistics{
+					ID:     uuid.New().String(),
+					Type:   StatisticsType(key.Type),
+					Period: period,
+					Date:   time.Unix(key.Start, 0),
+				}
+				row.setAggregate(deltas[key])
+				if err := tx.Create(row).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			agg := row.aggregate()
+			agg.Merge(deltas[key])
+			row.setAggregate(agg)
+			if err := tx.Save(row).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 汇总行的聚合值，草图损坏时重新开始计数去重用户
+func (s *Statistics) aggregate() *rollup.Aggregate {
+	users, err := rollup.ParseHLL(s.UserSketch)
+	if err != nil {
+		logger.Warnf("Resetting corrupt user sketch of %s %s %s: %v", s.Type, s.Period, s.Date, err)
+		users = rollup.NewHLL()
+	}
+	return &rollup.Aggregate{Count: s.Count, Sum: s.Value, Min: s.MinValue, Max: s.MaxValue, Users: users}
+}
+
+func (s *Statistics) setAggregate(a *rollup.Aggregate) {
+	s.Count = a.Count
+	s.Value = a.Sum
+	s.MinValue = a.Min
+	s.MaxValue = a.Max
+	s.UserSketch = a.Users.Bytes()
+	s.DistinctUsers = a.Users.Estimate()
+}
+
+// 汇总前的版本按记录写入统计行，同一区间可能有多行，
+// 建(type, period, date)唯一索引前合并为一行，需在AutoMigrate之前执行
+func mergeDuplicateStatistics(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&Statistics{}) || migrator.HasIndex(&Statistics{}, "idx_statistics_bucket") {
+		return nil
+	}
+	// 先补齐聚合列，唯一索引留给AutoMigrate创建
+	for _, field := range []string{"Count", "MinValue", "MaxValue", "DistinctUsers", "UserSketch"} {
+		if !migrator.HasColumn(&Statistics{}, field) {
+			if err := migrator.AddColumn(&Statistics{}, field); err != nil {
+				return fmt.Errorf("failed to add statistics column %s: %v", field, err)
+			}
+		}
+	}
+
+	var buckets []struct {
+		Type   StatisticsType
+		Period StatisticsPeriod
+		Date   time.Time
+	}
+	if err := db.Model(&Statistics{}).Select("type, period, date").
+		Group("type, period, date").Having("COUNT(*) > 1").Scan(&buckets).Error; err != nil {
+		return err
+	}
+
+	var merged int
+	for _, b := range buckets {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var rows []Statistics
+			if err := tx.Where("type = ? AND period = ? AND date = ?", b.Type, b.Period, b.Date).
+				Order("created_at, id").Find(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) < 2 {
+				return nil
+			}
+			keep, ids := mergeStatisticsRows(rows)
+			if err := tx.Save(keep).Error; err != nil {
+				return err
+			}
+			merged += len(ids)
+			return tx.Where("id IN ?", ids).Delete(&Statistics{}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to merge statistics %s %s %s: %v", b.Type, b.Period, b.Date, err)
+		}
+	}
+	if merged > 0 {
+		logger.Infof("Merged %d duplicate statistics rows into %d buckets", merged, len(buckets))
+	}
+	return nil
+}
+
+// 合并同一区间的多行到最早的一行，返回保留行和需删除的行ID
+func mergeStatisticsRows(rows []Statistics) (*Statistics, []string) {
+	agg := rollup.NewAggregate()
+	var ids []string
+	for i := range rows {
+		row := &rows[i]
+		if row.Count == 0 {
+			// 旧版每行是一条记录，没有聚合列
+			legacy := rollup.NewAggregate()
+			legacy.Add(row.Value, row.UserID)
+			agg.Merge(legacy)
+		} else {
+			agg.Merge(row.aggregate())
+		}
+		if i > 0 {
+			ids = append(ids, row.ID)
+		}
+	}
+
+	keep := &rows[0]
+	keep.setAggregate(agg)
+	// 合并后是区间汇总，不再属于单个用户
+	keep.UserID = nil
+	return keep, ids
+}
+
+// 删除超过保留期且已汇总的原始事件，汇总行保留
+func purgeRawEvents() (int64, error) {
+	days := viper.GetInt("statistics.retention.raw_event_days")
+	if days <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	var cursor StatisticsRollupCursor
+	if err := db.Where("name = ?", eventsRollupCursor).First(&cursor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	const chunk = 5000
+	var total int64
+	for {
+		result := db.Where("occurred_at < ? AND (created_at < ? OR (created_at = ? AND id <= ?))",
+			cutoff, cursor.EventCreatedAt, cursor.EventCreatedAt, cursor.EventID).
+			Limit(chunk).Delete(&StatisticsEvent{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < chunk {
+			return total, nil
+		}
+	}
+}
+
+// 趋势中的一个区间
+type trendPoint struct {
+	Start time.Time
+	*rollup.Aggregate
+}
+
+// 解析粒度，auto时按时间范围选择最粗的合适粒度
+func resolveGranularity(period string, start, end time.Time) (rollup.Granularity, bool) {
+	if period == "" || period == "auto" {
+		return rollup.Pick(start, end, viper.GetInt("statistics.trend.min_points")), true
+	}
+	return rollup.ParseGranularity(period)
+}
+
+// 读取[start, end]内各区间的汇总，没有数据的区间补零；
+// 合计的去重用户数由草图合并得出，不能按区间相加
+func loadTrend(ctx context.Context, statsType string, g rollup.Granularity, start, end time.Time) ([]trendPoint, *rollup.Aggregate, error) {
+	buckets := g.Buckets(start, end)
+	total := rollup.NewAggregate()
+	if len(buckets) == 0 {
+		return nil, total, nil
+	}
+
+	var stats []Statistics
+	if err := db.WithContext(ctx).Where("type = ? AND period = ? AND date BETWEEN ? AND ?",
+		statsType, g, buckets[0], buckets[len(buckets)-1]).Find(&stats).Error; err != nil {
+		return nil, nil, err
+	}
+	byStart := make(map[int64]*Statistics, len(stats))
+	for i := range stats {
+		byStart[stats[i].Date.Unix()] = &stats[i]
+	}
+
+	points := make([]trendPoint, len(buckets))
+	for i, bucketStart := range buckets {
+		agg := rollup.NewAggregate()
+		if stat := byStart[bucketStart.Unix()]; stat != nil {
+			agg = stat.aggregate()
+		}
+		total.Merge(agg)
+		points[i] = trendPoint{Start: bucketStart, Aggregate: agg}
+	}
+	return points, total, nil
+}
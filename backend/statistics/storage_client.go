@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"resume-centre/shared/infrastructure/storage"
+
+	"github.com/spf13/viper"
+)
+
+// 通过Consul发现存储服务地址，不可用时使用配置中的地址。
+// 服务常驻运行，每次请求时重新发现
+func storageServiceURL() string {
+	if consulClient != nil {
+		services, _, err := consulClient.Health().Service("storage-service", "", true, nil)
+		if err == nil && len(services) > 0 {
+			svc := services[0].Service
+			return fmt.Sprintf("http://%s:%d", svc.Address, svc.Port)
+		}
+	}
+	return viper.GetString("storage.service_url")
+}
+
+func newStorageClient() *storage.Client {
+	return storage.NewClient(storageServiceURL, viper.GetString("internal.service_token"))
+}
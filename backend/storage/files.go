@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// 存储文件元数据，内容保存在本地存储目录
+type StoredFile struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID      uint      `json:"user_id" gorm:"index"`
+	Name        string    `json:"name" gorm:"type:varchar(255)"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(100)"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256" gorm:"column:sha256;type:char(64)"`
+	Path        string    `json:"-" gorm:"type:varchar(500)"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+var errFileTooLarge = errors.New("file too large")
+
+// 服务间调用认证
+func internalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := viper.GetString("internal.service_token")
+		token := c.GetHeader("X-Service-Token")
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// 上传文件：请求体即文件内容，边读边写入磁盘并计算摘要
+func putFile(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 64)
+	name := filepath.Base(c.GetHeader("X-File-Name"))
+	if name == "." || name == string(filepath.Separator) {
+		name = ""
+	}
+	contentType := c.GetHeader("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	file := StoredFile{
+		ID:          uuid.New().String(),
+		UserID:      uint(userID),
+		Name:        name,
+		ContentType: contentType,
+	}
+	if err := writeFileContent(&file, c.Request.Body); err != nil {
+		if errors.Is(err, errFileTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Errorf("Failed to store file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	if err := db.Create(&file).Error; err != nil {
+		os.Remove(file.Path)
+		logger.Errorf("Failed to save file record: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"file": file})
+}
+
+func writeFileContent(file *StoredFile, body io.Reader) error {
+	basePath := viper.GetString("storage.local.base_path")
+	dir := filepath.Join(basePath, time.Now().Format("2006/01"))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	maxSize := viper.GetInt64("storage.max_file_size")
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return err
+	}
+	if size > maxSize {
+		return fmt.Errorf("%w: limit is %d bytes", errFileTooLarge, maxSize)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	file.Path = filepath.Join(dir, file.ID)
+	file.Size = size
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return os.Rename(tmp.Name(), file.Path)
+}
+
+// 调用方代用户读取时带上X-User-ID，只能读取该用户上传的文件；
+// 不带时为服务自身读取（如聊天附件由会话双方共享）
+func fileVisibleTo(c *gin.Context, file *StoredFile) bool {
+	header := c.GetHeader("X-User-ID")
+	if header == "" {
+		return true
+	}
+	userID, err := strconv.ParseUint(header, 10, 64)
+	return err == nil && uint(userID) == file.UserID
+}
+
+// 获取文件元数据
+func getFile(c *gin.Context) {
+	var file StoredFile
+	if err := db.Where("id = ?", c.Param("id")).First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file"})
+		return
+	}
+	if !fileVisibleTo(c, &file) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"file": file})
+}
+
+// 流式下载文件内容
+func getFileContent(c *gin.Context) {
+	var file StoredFile
+	if err := db.Where("id = ?", c.Param("id")).First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file"})
+		return
+	}
+	if !fileVisibleTo(c, &file) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	f, err := os.Open(file.Path)
+	if err != nil {
+		logger.Errorf("Failed to open file %s: %v", file.ID, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "File content not found"})
+		return
+	}
+	defer f.Close()
+
+	headers := map[string]string{"X-File-SHA256": file.SHA256}
+	if file.Name != "" {
+		headers["Content-Disposition"] = mime.FormatMediaType("attachment", map[string]string{"filename": file.Name})
+	}
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, f, headers)
+}
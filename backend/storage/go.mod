@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/go-redis/redis/v8 v8.11.5
+	github.com/google/uuid v1.3.1
 	github.com/hashicorp/consul/api v1.26.1
 	github.com/sirupsen/logrus v1.9.3
 	github.com/spf13/viper v1.17.0
@@ -117,6 +117,9 @@ func loadConfig() error {
 	viper.SetDefault("database.name", "jobfirst")
 	viper.SetDefault("database.user", "jobfirst")
 	viper.SetDefault("database.password", "jobfirst123")
+	viper.SetDefault("storage.local.base_path", "/data/files")
+	viper.SetDefault("storage.max_file_size", 104857600)
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
@@ -166,7 +169,7 @@ func initDatabase() error {
 	}
 
 	// 自动迁移
-	if err := db.AutoMigrate(&User{}); err != nil {
+	if err := db.AutoMigrate(&User{}, &StoredFile{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
@@ -312,6 +315,15 @@ func setupRouter() *gin.Engine {
 			resources.DELETE("/:id", deleteResource)
 			resources.PUT("/:id", updateResource)
 		}
+
+		// 文件内容存取（服务间调用）
+		files := api.Group("/files")
+		files.Use(internalAuthMiddleware())
+		{
+			files.POST("", putFile)
+			files.GET("/:id", getFile)
+			files.GET("/:id/content", getFileContent)
+		}
 	}
 
 	return router
@@ -0,0 +1,193 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAttachmentsUnavailable = errors.New("attachment storage unavailable")
+	ErrAttachmentNotFound     = errors.New("attachment not found")
+	ErrAttachmentType         = errors.New("attachment type not allowed")
+	ErrAttachmentTooLarge     = errors.New("attachment too large")
+)
+
+// 附件种类，与消息类型对应
+const (
+	KindImage = MessageImage
+	KindFile  = MessageFile
+)
+
+// MaxAttachmentSize 各种附件中最大的大小上限
+const MaxAttachmentSize = 20 << 20
+
+// attachmentLimit 每种附件允许的内容类型和大小上限
+type attachmentLimit struct {
+	maxSize int64
+	types   map[string]bool
+}
+
+var attachmentLimits = map[string]attachmentLimit{
+	KindImage: {maxSize: 10 << 20, types: map[string]bool{
+		"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true,
+	}},
+	// 简历等文档
+	KindFile: {maxSize: MaxAttachmentSize, types: map[string]bool{
+		"application/pdf":    true,
+		"application/msword": true,
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	}},
+}
+
+// Attachment 会话附件，内容保存在存储服务
+type Attachment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	SessionID   uint      `json:"session_id" gorm:"not null;index"`
+	UploaderID  uint      `json:"uploader_id" gorm:"not null"`
+	FileID      string    `json:"-" gorm:"size:36;not null"`
+	Kind        string    `json:"kind" gorm:"size:20;not null"`
+	Name        string    `json:"name" gorm:"size:255"`
+	ContentType string    `json:"content_type" gorm:"size:100"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Attachment) TableName() string {
+	return "chat_attachments"
+}
+
+// FileStore 附件内容存取，由存储服务实现
+type FileStore interface {
+	Put(ctx context.Context, userID uint, name, contentType string, body io.Reader) (string, error)
+	Open(ctx context.Context, fileID string) (io.ReadCloser, error)
+}
+
+// DetectAttachment 按文件内容识别附件种类和内容类型，不信任客户端声明的类型。
+// Word文档无法仅凭内容区分，结合扩展名和文件头判断
+func DetectAttachment(name string, head []byte) (kind, contentType string, err error) {
+	contentType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case contentType == "application/zip" && ext == ".docx":
+		contentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case ext == ".doc" && bytes.HasPrefix(head, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}):
+		contentType = "application/msword"
+	}
+	for kind, limit := range attachmentLimits {
+		if limit.types[contentType] {
+			return kind, contentType, nil
+		}
+	}
+	return "", contentType, fmt.Errorf("%w: %s", ErrAttachmentType, contentType)
+}
+
+// limitedReader 超过上限时返回ErrAttachmentTooLarge，使上传请求中止
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrAttachmentTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrAttachmentTooLarge
+	}
+	return n, err
+}
+
+// UploadAttachment 校验类型和大小后把附件流式写入存储服务。size为客户端声明的大小，未知时传-1
+func (s *Service) UploadAttachment(ctx context.Context, session *Session, uploaderID uint, name string, size int64, body io.Reader) (*Attachment, error) {
+	if s.files == nil {
+		return nil, ErrAttachmentsUnavailable
+	}
+	if !session.HasParticipant(uploaderID) {
+		return nil, ErrNotParticipant
+	}
+	if session.Status == SessionClosed {
+		return nil, ErrSessionClosed
+	}
+
+	br := bufio.NewReaderSize(body, 512)
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	name = truncate(filepath.Base(name), 255)
+	kind, contentType, err := DetectAttachment(name, head)
+	if err != nil {
+		return nil, err
+	}
+	maxSize := attachmentLimits[kind].maxSize
+	if size > maxSize {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrAttachmentTooLarge, maxSize)
+	}
+
+	counter := &limitedReader{r: br, remaining: maxSize}
+	fileID, err := s.files.Put(ctx, uploaderID, name, contentType, counter)
+	if counter.remaining < 0 {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrAttachmentTooLarge, maxSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &Attachment{
+		SessionID:   session.ID,
+		UploaderID:  uploaderID,
+		FileID:      fileID,
+		Kind:        kind,
+		Name:        name,
+		ContentType: contentType,
+		Size:        maxSize - counter.remaining,
+	}
+	if err := s.store.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// OpenAttachment 打开会话中的附件内容，调用方负责关闭
+func (s *Service) OpenAttachment(ctx context.Context, session *Session, attachmentID uint) (*Attachment, io.ReadCloser, error) {
+	if s.files == nil {
+		return nil, nil, ErrAttachmentsUnavailable
+	}
+	attachment, err := s.store.attachment(ctx, session.ID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.files.Open(ctx, attachment.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, rc, nil
+}
+
+func (s *Store) attachment(ctx context.Context, sessionID, attachmentID uint) (*Attachment, error) {
+	var attachment Attachment
+	err := s.db.WithContext(ctx).Where("id = ? AND session_id = ?", attachmentID, sessionID).First(&attachment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
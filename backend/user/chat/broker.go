@@ -0,0 +1,101 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// 跨实例广播事件的Redis频道
+const eventsChannel = "chat:events"
+
+// Broker 在各用户服务实例间分发事件，每个实例把收到的事件推送给本实例上的连接
+type Broker interface {
+	Publish(ctx context.Context, env Envelope) error
+	// Subscribe 持续接收事件直至ctx结束
+	Subscribe(ctx context.Context, handle func(Envelope)) error
+}
+
+// MemoryBroker 进程内广播，用于单实例部署和测试
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[int]func(Envelope)
+	nextID   int
+}
+
+// NewMemoryBroker 创建进程内广播
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{handlers: make(map[int]func(Envelope))}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, env Envelope) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handle := range b.handlers {
+		handle(env)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, handle func(Envelope)) error {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handle
+	b.mu.Unlock()
+
+	<-ctx.Done()
+	b.mu.Lock()
+	delete(b.handlers, id)
+	b.mu.Unlock()
+	return nil
+}
+
+// RedisBroker 基于Redis发布订阅的广播
+type RedisBroker struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisBroker 创建Redis广播
+func NewRedisBroker(client *redis.Client) *RedisBroker {
+	return &RedisBroker{client: client, channel: eventsChannel}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, env Envelope) error {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, handle func(Envelope)) error {
+	sub := b.client.Subscribe(ctx, b.channel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	// 连接断开时go-redis会自动重连并重新订阅
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var env Envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				logrus.Warnf("chat: discard malformed event: %v", err)
+				continue
+			}
+			handle(env)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	at := time.Date(2024, 8, 30, 16, 30, 0, 123, time.UTC)
+	for _, c := range []Cursor{{ID: 42}, {At: at, ID: 7}} {
+		got, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatalf("decode %+v: %v", c, err)
+		}
+		if got.ID != c.ID || !got.At.Equal(c.At) {
+			t.Errorf("round trip %+v, got %+v", c, got)
+		}
+	}
+
+	if c, err := DecodeCursor(""); err != nil || c.ID != 0 {
+		t.Errorf("empty cursor = %+v, %v", c, err)
+	}
+	for _, s := range []string{"not base64!", Cursor{}.Encode(), "MTIz"} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestSessionParticipants(t *testing.T) {
+	s := &Session{CandidateID: 1, RecruiterID: 2}
+	if s.PeerOf(1) != 2 || s.PeerOf(2) != 1 {
+		t.Errorf("PeerOf wrong")
+	}
+	if !s.HasParticipant(1) || !s.HasParticipant(2) || s.HasParticipant(3) || s.HasParticipant(0) {
+		t.Errorf("HasParticipant wrong")
+	}
+}
+
+func TestHubDelivery(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	// 两个实例共享广播和在线状态
+	hubA := NewHub(broker, NewMemoryPresence())
+	hubB := NewHub(broker, NewMemoryPresence())
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go hubA.Run(runCtx)
+	go hubB.Run(runCtx)
+	waitSubscribers(t, broker, 2)
+
+	phone, laptop, other := NewClient(1), NewClient(1), NewClient(2)
+	hubA.Register(ctx, phone)
+	hubB.Register(ctx, laptop)
+	hubB.Register(ctx, other)
+
+	if err := hubA.Publish(ctx, []uint{1}, Event{Type: EventMessage, SessionID: 9}); err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range map[string]*Client{"phone": phone, "laptop": laptop} {
+		select {
+		case ev := <-c.Events():
+			if ev.Type != EventMessage || ev.SessionID != 9 || ev.At.IsZero() {
+				t.Errorf("%s got %+v", name, ev)
+			}
+		default:
+			t.Errorf("%s did not receive the event", name)
+		}
+	}
+	select {
+	case ev := <-other.Events():
+		t.Errorf("non-recipient received %+v", ev)
+	default:
+	}
+
+	hubB.Unregister(ctx, laptop)
+	hubA.Publish(ctx, []uint{1}, Event{Type: EventTyping})
+	if len(laptop.Events()) != 0 {
+		t.Errorf("unregistered client received an event")
+	}
+	if len(phone.Events()) != 1 {
+		t.Errorf("phone should still receive events")
+	}
+}
+
+func TestHubDropsSlowClient(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+	hub := NewHub(broker, NewMemoryPresence())
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go hub.Run(runCtx)
+	waitSubscribers(t, broker, 1)
+
+	c := NewClient(1)
+	hub.Register(ctx, c)
+	for i := 0; i <= clientBuffer; i++ {
+		hub.Publish(ctx, []uint{1}, Event{Type: EventMessage})
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("slow client was not closed")
+	}
+	if c.Deliver(Event{Type: EventPong}) {
+		t.Error("closed client accepted an event")
+	}
+}
+
+func TestMemoryPresence(t *testing.T) {
+	ctx := context.Background()
+	p := NewMemoryPresence()
+	if online, _ := p.Connect(ctx, 1); !online {
+		t.Error("first connection should bring user online")
+	}
+	if online, _ := p.Connect(ctx, 1); online {
+		t.Error("second connection should not report online again")
+	}
+	if offline, _ := p.Disconnect(ctx, 1); offline {
+		t.Error("user with a remaining connection reported offline")
+	}
+	status, _ := p.Online(ctx, []uint{1, 2})
+	if !status[1] || status[2] {
+		t.Errorf("Online = %v", status)
+	}
+	if offline, _ := p.Disconnect(ctx, 1); !offline {
+		t.Error("last disconnection should bring user offline")
+	}
+}
+
+func TestCountAlive(t *testing.T) {
+	counts := map[string]string{"a": "2", "b": "1", "c": "0", "d": "x"}
+	if n := countAlive(counts, map[string]bool{"a": true, "c": true, "d": true}); n != 2 {
+		t.Errorf("countAlive = %d, want 2 (dead instance b ignored)", n)
+	}
+}
+
+func TestPublicError(t *testing.T) {
+	if got := publicError(ErrNotParticipant); got != ErrNotParticipant.Error() {
+		t.Errorf("publicError(ErrNotParticipant) = %q", got)
+	}
+	if got := publicError(context.DeadlineExceeded); got != "internal error" {
+		t.Errorf("internal error leaked: %q", got)
+	}
+}
+
+// waitSubscribers 等待Hub完成订阅
+func waitSubscribers(t *testing.T, b *MemoryBroker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.RLock()
+		count := len(b.handlers)
+		b.mu.RUnlock()
+		if count == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d subscribers", n)
+}
+
+func TestRuleModerator(t *testing.T) {
+	m, err := NewRuleModerator(DefaultRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cases := []struct {
+		text, role, action string
+	}{
+		{"您好，我对这个职位很感兴趣", RoleCandidate, ActionAllow},
+		{"我的电话13812345678", RoleCandidate, ActionBlock},
+		{"打我电话 138 1234 5678", RoleRecruiter, ActionBlock},
+		{"号码是１３８-１２３４-５６７８", RoleCandidate, ActionBlock},
+		{"一三八一二三四五六七八", RoleCandidate, ActionBlock},
+		{"+86 13812345678", RoleCandidate, ActionBlock},
+		{"薪资范围13000-18000，年终3个月", RoleRecruiter, ActionAllow},
+		{"订单号 2024083012345678901", RoleCandidate, ActionAllow},
+		{"加我微信：abc_12345", RoleRecruiter, ActionBlock},
+		{"VX: Job-Hunter2024", RoleCandidate, ActionBlock},
+		{"入职前需要交500元押金", RoleRecruiter, ActionBlock},
+		{"请问需要交押金吗", RoleCandidate, ActionAllow},
+		{"这不是刷单工作吧", RoleCandidate, ActionMask},
+	}
+	for _, tc := range cases {
+		v, err := m.Moderate(ctx, ModerationInput{Role: tc.role, Type: MessageText, Text: tc.text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Action != tc.action {
+			t.Errorf("Moderate(%q, %s) = %s %v, want %s", tc.text, tc.role, v.Action, v.Rules, tc.action)
+		}
+	}
+
+	v, _ := m.Moderate(ctx, ModerationInput{Role: RoleCandidate, Text: "这不是刷单工作吧"})
+	if v.Text != "这不是**工作吧" {
+		t.Errorf("masked text = %q", v.Text)
+	}
+
+	if _, err := NewRuleModerator([]Rule{{Type: RulePattern, Pattern: "("}}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+	if _, err := NewRuleModerator([]Rule{{Type: RuleWords, Words: []string{"x"}, Action: "drop"}}); err == nil {
+		t.Error("unknown action accepted")
+	}
+}
+
+func TestComposeText(t *testing.T) {
+	m, _ := NewRuleModerator(DefaultRules())
+	s := &Service{moderator: m}
+	session := &Session{ID: 1, CandidateID: 1, RecruiterID: 2}
+	ctx := context.Background()
+
+	msg, err := s.compose(ctx, session, 1, Draft{Content: "  您好，这不是刷单吧  ", ClientID: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != MessageText || msg.Content != "您好，这不是**吧" || !msg.Masked || msg.Preview != msg.Content || *msg.ClientID != "c1" {
+		t.Errorf("compose = %+v", msg)
+	}
+
+	if _, err := s.compose(ctx, session, 1, Draft{Content: "电话13812345678"}); !errors.Is(err, ErrMessageBlocked) {
+		t.Errorf("phone number: err = %v, want ErrMessageBlocked", err)
+	}
+	if _, err := s.compose(ctx, session, 1, Draft{Content: "   "}); err != ErrEmptyMessage {
+		t.Errorf("empty: err = %v", err)
+	}
+	if _, err := s.compose(ctx, session, 3, Draft{Content: "hi"}); err != ErrNotParticipant {
+		t.Errorf("outsider: err = %v", err)
+	}
+	if _, err := s.compose(ctx, session, 1, Draft{Type: "voice"}); err != ErrUnsupportedType {
+		t.Errorf("unknown type: err = %v", err)
+	}
+	if _, err := s.compose(ctx, session, 2, Draft{Type: MessageResumeCard, ResumeID: 1}); err != ErrRoleNotAllowed {
+		t.Errorf("recruiter resume card: err = %v", err)
+	}
+	if _, err := s.compose(ctx, session, 1, Draft{Type: MessageInterviewInvite}); err != ErrRoleNotAllowed {
+		t.Errorf("candidate invite: err = %v", err)
+	}
+}
+
+func TestComposeInvite(t *testing.T) {
+	m, _ := NewRuleModerator(DefaultRules())
+	s := &Service{moderator: m}
+	session := &Session{ID: 1, CandidateID: 1, RecruiterID: 2}
+	ctx := context.Background()
+	at := time.Now().Add(48 * time.Hour)
+
+	// 联系电话属于正式邀约信息，不拦截
+	invite := &Invite{InterviewAt: at, Duration: 60, Mode: InterviewOnsite, Address: "科技园A座", Contact: "王经理 13812345678"}
+	msg, err := s.compose(ctx, session, 2, Draft{Type: MessageInterviewInvite, Invite: invite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Invite
+	if err := json.Unmarshal(msg.Payload, &got); err != nil || got.Contact != invite.Contact || !got.InterviewAt.Equal(at) {
+		t.Errorf("payload = %s, %v", msg.Payload, err)
+	}
+	if !strings.HasPrefix(msg.Preview, "[面试邀请]") {
+		t.Errorf("preview = %q", msg.Preview)
+	}
+
+	bad := []*Invite{
+		nil,
+		{InterviewAt: time.Now().Add(-time.Hour), Mode: InterviewPhone},
+		{InterviewAt: at, Mode: InterviewOnsite},
+		{InterviewAt: at, Mode: InterviewOnline, MeetingURL: "http://meet.example.com"},
+		{InterviewAt: at, Mode: "video"},
+	}
+	for _, in := range bad {
+		if _, err := s.compose(ctx, session, 2, Draft{Type: MessageInterviewInvite, Invite: in}); !errors.Is(err, ErrInvalidInvite) {
+			t.Errorf("invite %+v: err = %v, want ErrInvalidInvite", in, err)
+		}
+	}
+	note := &Invite{InterviewAt: at, Mode: InterviewPhone, Note: "面试后加微信 wx: hr_zhang01"}
+	if _, err := s.compose(ctx, session, 2, Draft{Type: MessageInterviewInvite, Invite: note}); !errors.Is(err, ErrMessageBlocked) {
+		t.Errorf("note with wechat: err = %v, want ErrMessageBlocked", err)
+	}
+}
+
+func TestDetectAttachment(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	pdf := []byte("%PDF-1.7\n...")
+	docx := append([]byte("PK\x03\x04"), make([]byte, 32)...)
+	doc := append([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, make([]byte, 32)...)
+	cases := []struct {
+		name       string
+		head       []byte
+		kind, ctyp string
+	}{
+		{"photo.png", png, KindImage, "image/png"},
+		{"resume.pdf", pdf, KindFile, "application/pdf"},
+		{"简历.docx", docx, KindFile, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"resume.doc", doc, KindFile, "application/msword"},
+		{"resume.pdf", png, KindImage, "image/png"}, // 以内容为准
+	}
+	for _, tc := range cases {
+		kind, ctyp, err := DetectAttachment(tc.name, tc.head)
+		if err != nil || kind != tc.kind || ctyp != tc.ctyp {
+			t.Errorf("DetectAttachment(%s) = %s, %s, %v", tc.name, kind, ctyp, err)
+		}
+	}
+	for name, head := range map[string][]byte{
+		"archive.zip": docx,
+		"page.html":   []byte("<html><script>alert(1)</script>"),
+		"run.exe":     []byte("MZ\x90\x00"),
+		"empty.pdf":   nil,
+	} {
+		if _, _, err := DetectAttachment(name, head); !errors.Is(err, ErrAttachmentType) {
+			t.Errorf("DetectAttachment(%s) err = %v, want ErrAttachmentType", name, err)
+		}
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	r := &limitedReader{r: strings.NewReader("0123456789"), remaining: 10}
+	if b, err := io.ReadAll(r); err != nil || len(b) != 10 {
+		t.Errorf("within limit: %d bytes, %v", len(b), err)
+	}
+	r = &limitedReader{r: strings.NewReader("0123456789X"), remaining: 10}
+	if _, err := io.ReadAll(r); err != ErrAttachmentTooLarge {
+		t.Errorf("over limit: err = %v", err)
+	}
+}
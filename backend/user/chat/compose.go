@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrMessageBlocked = errors.New("message blocked by moderation")
+	ErrInvalidCard    = errors.New("invalid card")
+	ErrInvalidInvite  = errors.New("invalid interview invite")
+	ErrRoleNotAllowed = errors.New("message type not allowed for sender role")
+)
+
+// 面试方式
+const (
+	InterviewOnsite = "onsite"
+	InterviewOnline = "online"
+	InterviewPhone  = "phone"
+)
+
+// Draft 待发送的消息。不同类型使用不同字段：文本用Content，图片和文件用AttachmentID（Content为说明），
+// 简历卡片用ResumeID，职位卡片用JobID，面试邀请用Invite
+type Draft struct {
+	Type         string  `json:"message_type"`
+	Content      string  `json:"content"`
+	ClientID     string  `json:"client_id"`
+	AttachmentID uint    `json:"attachment_id"`
+	ResumeID     uint    `json:"resume_id"`
+	JobID        uint    `json:"job_id"`
+	Invite       *Invite `json:"invite"`
+}
+
+// AttachmentPayload 图片、文件消息的内容
+type AttachmentPayload struct {
+	AttachmentID uint   `json:"attachment_id"`
+	Name         string `json:"name"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+}
+
+// ResumeCard 简历卡片，只能由求职者发送自己的简历
+type ResumeCard struct {
+	ResumeID uint   `json:"resume_id"`
+	Title    string `json:"title"`
+}
+
+// JobCard 职位卡片
+type JobCard struct {
+	JobID      uint   `json:"job_id"`
+	Title      string `json:"title"`
+	Location   string `json:"location"`
+	SalaryMin  int    `json:"salary_min"`
+	SalaryMax  int    `json:"salary_max"`
+	SalaryType string `json:"salary_type"`
+}
+
+// Invite 面试邀请，只能由招聘方发送。Contact为招聘方留的联系人及电话，属于正式邀约信息，不做联系方式拦截
+type Invite struct {
+	InterviewAt time.Time `json:"interview_at"`
+	Duration    int       `json:"duration_minutes"`
+	Mode        string    `json:"mode"`
+	Address     string    `json:"address,omitempty"`
+	MeetingURL  string    `json:"meeting_url,omitempty"`
+	Contact     string    `json:"contact,omitempty"`
+	Note        string    `json:"note,omitempty"`
+}
+
+// compose 按消息类型校验并组装消息，文本部分经过审核
+func (s *Service) compose(ctx context.Context, session *Session, senderID uint, d Draft) (*Message, error) {
+	if !session.HasParticipant(senderID) {
+		return nil, ErrNotParticipant
+	}
+	if d.Type == "" {
+		d.Type = MessageText
+	}
+	role := RoleCandidate
+	if senderID == session.RecruiterID {
+		role = RoleRecruiter
+	}
+	msg := &Message{SessionID: session.ID, SenderID: senderID, Type: d.Type, Content: strings.TrimSpace(d.Content)}
+	if utf8.RuneCountInString(msg.Content) > MaxMessageLength {
+		return nil, ErrMessageTooLong
+	}
+
+	var payload interface{}
+	switch d.Type {
+	case MessageText:
+		if msg.Content == "" {
+			return nil, ErrEmptyMessage
+		}
+		msg.Preview = msg.Content
+
+	case MessageImage, MessageFile:
+		attachment, err := s.store.attachment(ctx, session.ID, d.AttachmentID)
+		if err != nil {
+			return nil, err
+		}
+		if attachment.UploaderID != senderID || attachment.Kind != d.Type {
+			return nil, ErrAttachmentNotFound
+		}
+		payload = AttachmentPayload{AttachmentID: attachment.ID, Name: attachment.Name, ContentType: attachment.ContentType, Size: attachment.Size}
+		if d.Type == MessageImage {
+			msg.Preview = "[图片]"
+		} else {
+			msg.Preview = "[文件] " + attachment.Name
+		}
+
+	case MessageResumeCard:
+		if role != RoleCandidate {
+			return nil, ErrRoleNotAllowed
+		}
+		card := ResumeCard{ResumeID: d.ResumeID}
+		err := s.store.db.WithContext(ctx).Table("resumes").Select("title").
+			Where("id = ? AND user_id = ? AND deleted_at IS NULL", d.ResumeID, senderID).Limit(1).Scan(&card.Title).Error
+		if err != nil {
+			return nil, err
+		}
+		if d.ResumeID == 0 || card.Title == "" {
+			return nil, fmt.Errorf("%w: resume not found", ErrInvalidCard)
+		}
+		payload = card
+		msg.Preview = "[简历] " + card.Title
+
+	case MessageJobCard:
+		var card JobCard
+		err := s.store.db.WithContext(ctx).Table("jobs").
+			Select("id AS job_id, title, location, salary_min, salary_max, salary_type").
+			Where("id = ? AND deleted_at IS NULL", d.JobID).Limit(1).Scan(&card).Error
+		if err != nil {
+			return nil, err
+		}
+		if d.JobID == 0 || card.JobID == 0 {
+			return nil, fmt.Errorf("%w: job not found", ErrInvalidCard)
+		}
+		payload = card
+		msg.Preview = "[职位] " + card.Title
+
+	case MessageInterviewInvite:
+		if role != RoleRecruiter {
+			return nil, ErrRoleNotAllowed
+		}
+		invite, err := validateInvite(d.Invite)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range []*string{&invite.Address, &invite.Note} {
+			if *field, msg.Masked, err = s.moderate(ctx, senderID, role, d.Type, *field, msg.Masked); err != nil {
+				return nil, err
+			}
+		}
+		payload = invite
+		msg.Preview = "[面试邀请] " + invite.InterviewAt.Format("01-02 15:04")
+
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	var err error
+	if msg.Content, msg.Masked, err = s.moderate(ctx, senderID, role, d.Type, msg.Content, msg.Masked); err != nil {
+		return nil, err
+	}
+	if d.Type == MessageText {
+		msg.Preview = msg.Content
+	}
+	if payload != nil {
+		if msg.Payload, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	if d.ClientID != "" {
+		clientID := truncate(d.ClientID, 64)
+		msg.ClientID = &clientID
+	}
+	return msg, nil
+}
+
+// moderate 审核一段文本，返回处理后的文本以及累计的屏蔽标记
+func (s *Service) moderate(ctx context.Context, senderID uint, role, msgType, text string, masked bool) (string, bool, error) {
+	if s.moderator == nil || text == "" {
+		return text, masked, nil
+	}
+	v, err := s.moderator.Moderate(ctx, ModerationInput{SenderID: senderID, Role: role, Type: msgType, Text: text})
+	if err != nil {
+		return "", masked, err
+	}
+	switch v.Action {
+	case ActionBlock:
+		logrus.Infof("chat: message from user %d blocked by rules %v", senderID, v.Rules)
+		return "", masked, fmt.Errorf("%w: %s", ErrMessageBlocked, strings.Join(v.Rules, ","))
+	case ActionMask:
+		return v.Text, true, nil
+	}
+	return text, masked, nil
+}
+
+func validateInvite(in *Invite) (Invite, error) {
+	if in == nil {
+		return Invite{}, fmt.Errorf("%w: missing invite", ErrInvalidInvite)
+	}
+	invite := *in
+	invite.Address = strings.TrimSpace(invite.Address)
+	invite.MeetingURL = strings.TrimSpace(invite.MeetingURL)
+	invite.Contact = truncate(strings.TrimSpace(invite.Contact), 100)
+	invite.Note = strings.TrimSpace(invite.Note)
+	switch {
+	case !invite.InterviewAt.After(time.Now()):
+		return invite, fmt.Errorf("%w: interview time must be in the future", ErrInvalidInvite)
+	case invite.Duration < 0 || invite.Duration > 8*60:
+		return invite, fmt.Errorf("%w: duration out of range", ErrInvalidInvite)
+	case invite.Mode == InterviewOnsite && invite.Address == "":
+		return invite, fmt.Errorf("%w: onsite interview requires an address", ErrInvalidInvite)
+	case invite.Mode == InterviewOnline && !strings.HasPrefix(invite.MeetingURL, "https://"):
+		return invite, fmt.Errorf("%w: online interview requires an https meeting url", ErrInvalidInvite)
+	case invite.Mode != InterviewOnsite && invite.Mode != InterviewOnline && invite.Mode != InterviewPhone:
+		return invite, fmt.Errorf("%w: unknown mode %q", ErrInvalidInvite, invite.Mode)
+	case utf8.RuneCountInString(invite.Address) > 200 || utf8.RuneCountInString(invite.Note) > 500 || len(invite.MeetingURL) > 500:
+		return invite, fmt.Errorf("%w: field too long", ErrInvalidInvite)
+	}
+	return invite, nil
+}
@@ -0,0 +1,47 @@
+package chat
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCursor 游标无法解析
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor 分页位置：消息按ID分页，At为零；会话按活跃时间和ID分页
+type Cursor struct {
+	At time.Time
+	ID uint
+}
+
+// Encode 编码为不透明的游标字符串
+func (c Cursor) Encode() string {
+	var at int64
+	if !c.At.IsZero() {
+		at = c.At.UnixNano()
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", at, c.ID)))
+}
+
+// DecodeCursor 解析游标，空字符串表示从头开始
+func DecodeCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var at int64
+	var id uint
+	if n, err := fmt.Sscanf(string(raw), "%d:%d", &at, &id); err != nil || n != 2 || id == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	c := Cursor{ID: id}
+	if at != 0 {
+		c.At = time.Unix(0, at)
+	}
+	return c, nil
+}
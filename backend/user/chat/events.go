@@ -0,0 +1,53 @@
+package chat
+
+import "time"
+
+// 推送给客户端的事件类型
+const (
+	EventMessage   = "message"   // 新消息
+	EventTyping    = "typing"    // 对方正在输入
+	EventDelivered = "delivered" // 对方已收到，MessageID为送达位置
+	EventRead      = "read"      // 对方已读，MessageID为已读位置
+	EventPresence  = "presence"  // 联系人上线/下线
+	EventAck       = "ack"       // 发送成功，ClientID对应客户端的发送帧
+	EventError     = "error"     // 帧处理失败，ClientID非空时对应发送帧
+	EventPong      = "pong"
+)
+
+// 客户端发来的帧类型
+const (
+	FrameSend      = "send"
+	FrameTyping    = "typing"
+	FrameDelivered = "delivered"
+	FrameRead      = "read"
+	FramePing      = "ping"
+)
+
+// Event 推送给客户端的事件
+type Event struct {
+	Type      string    `json:"type"`
+	SessionID uint      `json:"session_id,omitempty"`
+	UserID    uint      `json:"user_id,omitempty"` // 触发事件的用户
+	MessageID uint      `json:"message_id,omitempty"`
+	ClientID  string    `json:"client_id,omitempty"`
+	Message   *Message  `json:"message,omitempty"`
+	Typing    *bool     `json:"typing,omitempty"`
+	Online    *bool     `json:"online,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Envelope 跨实例广播的事件及其接收者
+type Envelope struct {
+	Recipients []uint `json:"recipients"`
+	Event      Event  `json:"event"`
+}
+
+// Frame 客户端通过WebSocket发来的帧，send帧的消息字段与HTTP发送接口相同
+type Frame struct {
+	Type      string `json:"type"`
+	SessionID uint   `json:"session_id"`
+	MessageID uint   `json:"message_id"`
+	Typing    bool   `json:"typing"`
+	Draft
+}
@@ -0,0 +1,168 @@
+package chat
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	clientBuffer      = 64               // 每个连接待发送事件的缓冲，写满说明客户端消费过慢，断开连接
+	HeartbeatInterval = 30 * time.Second // 在线记录续期间隔
+)
+
+// Client 本实例上的一个WebSocket连接
+type Client struct {
+	UserID uint
+
+	send      chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewClient 创建连接
+func NewClient(userID uint) *Client {
+	return &Client{UserID: userID, send: make(chan Event, clientBuffer), done: make(chan struct{})}
+}
+
+// Events 待发送给客户端的事件
+func (c *Client) Events() <-chan Event {
+	return c.send
+}
+
+// Done 连接被关闭（消费过慢或已注销）时关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Deliver 非阻塞地投递事件，缓冲已满时关闭连接并返回false
+func (c *Client) Deliver(ev Event) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- ev:
+		return true
+	default:
+		c.Close()
+		return false
+	}
+}
+
+// Close 关闭连接
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+// Hub 管理本实例上的连接，通过Broker把事件分发到所有实例
+type Hub struct {
+	broker   Broker
+	presence Presence
+
+	mu      sync.RWMutex
+	clients map[uint]map[*Client]struct{}
+}
+
+// NewHub 创建连接管理器
+func NewHub(broker Broker, presence Presence) *Hub {
+	return &Hub{broker: broker, presence: presence, clients: make(map[uint]map[*Client]struct{})}
+}
+
+// Run 订阅其他实例发布的事件并定期续期在线记录，直至ctx结束
+func (h *Hub) Run(ctx context.Context) error {
+	go func() {
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			if err := h.presence.Heartbeat(ctx, h.localUsers()); err != nil && ctx.Err() == nil {
+				logrus.Warnf("chat: presence heartbeat failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	for {
+		err := h.broker.Subscribe(ctx, h.dispatch)
+		if ctx.Err() != nil {
+			return nil
+		}
+		logrus.Warnf("chat: event subscription stopped: %v, retrying", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// Register 登记连接，用户由离线变为在线时返回true
+func (h *Hub) Register(ctx context.Context, c *Client) (bool, error) {
+	h.mu.Lock()
+	if h.clients[c.UserID] == nil {
+		h.clients[c.UserID] = make(map[*Client]struct{})
+	}
+	h.clients[c.UserID][c] = struct{}{}
+	h.mu.Unlock()
+	return h.presence.Connect(ctx, c.UserID)
+}
+
+// Unregister 注销连接，用户所有连接都已断开时返回true
+func (h *Hub) Unregister(ctx context.Context, c *Client) (bool, error) {
+	c.Close()
+	h.mu.Lock()
+	if _, ok := h.clients[c.UserID][c]; !ok {
+		h.mu.Unlock()
+		return false, nil
+	}
+	delete(h.clients[c.UserID], c)
+	if len(h.clients[c.UserID]) == 0 {
+		delete(h.clients, c.UserID)
+	}
+	h.mu.Unlock()
+	return h.presence.Disconnect(ctx, c.UserID)
+}
+
+// Publish 把事件发给接收者在所有实例上的连接
+func (h *Hub) Publish(ctx context.Context, recipients []uint, ev Event) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	return h.broker.Publish(ctx, Envelope{Recipients: recipients, Event: ev})
+}
+
+// Online 查询用户是否在线
+func (h *Hub) Online(ctx context.Context, userIDs []uint) (map[uint]bool, error) {
+	return h.presence.Online(ctx, userIDs)
+}
+
+// dispatch 把事件投递给本实例上接收者的连接
+func (h *Hub) dispatch(env Envelope) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, userID := range env.Recipients {
+		for c := range h.clients[userID] {
+			c.Deliver(env.Event)
+		}
+	}
+}
+
+func (h *Hub) localUsers() []uint {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	users := make([]uint, 0, len(h.clients))
+	for id := range h.clients {
+		users = append(users, id)
+	}
+	return users
+}
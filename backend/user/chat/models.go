@@ -0,0 +1,86 @@
+package chat
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 会话状态
+const (
+	SessionActive = "active"
+	SessionClosed = "closed"
+)
+
+// 会话成员角色
+const (
+	RoleCandidate = "candidate"
+	RoleRecruiter = "recruiter"
+)
+
+// 消息类型
+const (
+	MessageText            = "text"
+	MessageImage           = "image"
+	MessageFile            = "file"
+	MessageResumeCard      = "resume_card"
+	MessageJobCard         = "job_card"
+	MessageInterviewInvite = "interview_invite"
+)
+
+// Session 求职者与招聘方围绕一次职位投递的会话，同一投递的同一招聘方只有一个会话
+type Session struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ApplicationID uint      `json:"application_id" gorm:"not null;uniqueIndex:uk_chat_session_application"` // 投递记录（user_behaviors）ID
+	JobID         uint      `json:"job_id" gorm:"not null;index"`
+	CandidateID   uint      `json:"candidate_id" gorm:"not null;index"`
+	RecruiterID   uint      `json:"recruiter_id" gorm:"not null;index;uniqueIndex:uk_chat_session_application"`
+	Title         string    `json:"title" gorm:"size:200"`
+	Status        string    `json:"status" gorm:"size:20;default:'active'"`
+	LastMessageID uint      `json:"last_message_id"`
+	LastMessage   string    `json:"last_message" gorm:"size:200"` // 最近一条消息的摘要
+	ActiveAt      time.Time `json:"active_at" gorm:"index"`       // 最近一条消息的时间，没有消息时为创建时间
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (Session) TableName() string {
+	return "chat_sessions"
+}
+
+// Member 会话成员及其送达、已读位置，位置为该成员已收到/已读的最大消息ID
+type Member struct {
+	SessionID       uint      `json:"session_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID          uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Role            string    `json:"role" gorm:"size:20;not null"`
+	LastDeliveredID uint      `json:"last_delivered_id"`
+	LastReadID      uint      `json:"last_read_id"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (Member) TableName() string {
+	return "chat_members"
+}
+
+// Message 会话消息，ID在会话内递增，用作分页游标和回执位置。ClientID由客户端生成，用于断线重发时去重
+type Message struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	SessionID uint            `json:"session_id" gorm:"not null;index:idx_chat_message_session;uniqueIndex:uk_chat_message_client,priority:1"`
+	SenderID  uint            `json:"sender_id" gorm:"not null;uniqueIndex:uk_chat_message_client,priority:2"`
+	ClientID  *string         `json:"client_id,omitempty" gorm:"size:64;uniqueIndex:uk_chat_message_client,priority:3"`
+	Type      string          `json:"type" gorm:"size:20;not null;default:'text'"`
+	Content   string          `json:"content" gorm:"type:text;not null"`  // 文本消息的内容，附件消息的说明
+	Payload   json.RawMessage `json:"payload,omitempty" gorm:"type:json"` // 非文本消息的结构化内容
+	Masked    bool            `json:"masked,omitempty"`                   // 内容经审核屏蔽了部分文字
+	CreatedAt time.Time       `json:"created_at"`
+
+	Preview string `json:"-" gorm:"-"` // 会话列表中展示的摘要
+}
+
+func (Message) TableName() string {
+	return "chat_messages"
+}
+
+// Models 需要迁移的模型
+func Models() []interface{} {
+	return []interface{}{&Session{}, &Member{}, &Message{}, &Attachment{}, &QuickReply{}}
+}
@@ -0,0 +1,251 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 审核结果
+const (
+	ActionAllow = "allow"
+	ActionMask  = "mask"  // 命中内容替换为*后发送
+	ActionBlock = "block" // 拒绝发送
+)
+
+// 审核规则类型
+const (
+	RuleWords   = "words"   // 敏感词，不区分大小写
+	RulePattern = "pattern" // 正则表达式，不区分大小写
+	RulePhone   = "phone"   // 手机号，识别空格、横线等分隔和全角、中文数字写法
+	RuleWeChat  = "wechat"  // 微信号，识别“微信/vx/wx”等提示词后的账号
+)
+
+// Rule 消息审核规则。phone、wechat规则的命中内容可能被拆散书写，只支持拦截
+type Rule struct {
+	Name    string
+	Type    string
+	Words   []string
+	Pattern string
+	Action  string
+	Roles   []string // 只对这些角色的发送方生效，为空时对所有人生效
+}
+
+// ModerationInput 待审核的消息文本
+type ModerationInput struct {
+	SenderID uint
+	Role     string
+	Type     string
+	Text     string
+}
+
+// Verdict 审核结论，Action为mask时Text为处理后的文本
+type Verdict struct {
+	Action string
+	Text   string
+	Rules  []string
+}
+
+// Moderator 消息发送前的审核钩子
+type Moderator interface {
+	Moderate(ctx context.Context, in ModerationInput) (Verdict, error)
+}
+
+// DefaultRules 默认规则：拦截手机号、微信号，拦截招聘方收取押金等费用，屏蔽违法违规词
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "phone", Type: RulePhone, Action: ActionBlock},
+		{Name: "wechat", Type: RuleWeChat, Action: ActionBlock},
+		{Name: "fee", Type: RuleWords, Action: ActionBlock, Roles: []string{RoleRecruiter},
+			Words: []string{"押金", "保证金", "培训费", "服装费", "体检费", "入职费"}},
+		{Name: "sensitive", Type: RuleWords, Action: ActionMask,
+			Words: []string{"刷单", "传销", "赌博", "博彩", "裸聊", "代孕", "套现"}},
+	}
+}
+
+var (
+	phonePattern  = regexp.MustCompile(`^(?:86)?1[3-9]\d{9}$`)
+	wechatPattern = regexp.MustCompile(`(?:微信|威信|薇信|v信|vx|wx|weixin|wechat|加v|\+v)\s*号?\s*[:：是为]?\s*[a-z][-_a-z0-9]{5,19}`)
+)
+
+type compiledRule struct {
+	Rule
+	re    *regexp.Regexp
+	roles map[string]bool
+}
+
+// RuleModerator 按规则审核消息
+type RuleModerator struct {
+	rules []compiledRule
+}
+
+// NewRuleModerator 编译审核规则
+func NewRuleModerator(rules []Rule) (*RuleModerator, error) {
+	m := &RuleModerator{}
+	for _, r := range rules {
+		if r.Name == "" {
+			r.Name = r.Type
+		}
+		switch r.Action {
+		case "":
+			r.Action = ActionBlock
+		case ActionMask, ActionBlock:
+		default:
+			return nil, fmt.Errorf("moderation rule %s: unknown action %q", r.Name, r.Action)
+		}
+		c := compiledRule{Rule: r}
+		switch r.Type {
+		case RuleWords:
+			var quoted []string
+			for _, w := range r.Words {
+				if w = strings.TrimSpace(w); w != "" {
+					quoted = append(quoted, regexp.QuoteMeta(foldWidth(w)))
+				}
+			}
+			if len(quoted) == 0 {
+				continue
+			}
+			c.re = regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+		case RulePattern:
+			re, err := regexp.Compile("(?i)" + r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("moderation rule %s: %w", r.Name, err)
+			}
+			c.re = re
+		case RulePhone, RuleWeChat:
+			c.Action = ActionBlock
+		default:
+			return nil, fmt.Errorf("moderation rule %s: unknown type %q", r.Name, r.Type)
+		}
+		if len(r.Roles) > 0 {
+			c.roles = make(map[string]bool, len(r.Roles))
+			for _, role := range r.Roles {
+				c.roles[role] = true
+			}
+		}
+		m.rules = append(m.rules, c)
+	}
+	return m, nil
+}
+
+// Moderate 依次应用规则：任一拦截规则命中即拒绝，屏蔽规则命中的内容替换为*
+func (m *RuleModerator) Moderate(ctx context.Context, in ModerationInput) (Verdict, error) {
+	text := foldWidth(in.Text)
+	original := []rune(in.Text)
+	v := Verdict{Action: ActionAllow, Text: in.Text}
+	for _, r := range m.rules {
+		if r.roles != nil && !r.roles[in.Role] {
+			continue
+		}
+		var hit bool
+		switch r.Type {
+		case RulePhone:
+			hit = containsPhone(text)
+		case RuleWeChat:
+			hit = wechatPattern.MatchString(strings.ToLower(text))
+		default:
+			hit = r.re.MatchString(text)
+		}
+		if !hit {
+			continue
+		}
+		if r.Action == ActionBlock {
+			return Verdict{Action: ActionBlock, Text: in.Text, Rules: []string{r.Name}}, nil
+		}
+		text, original = maskMatches(r.re, text, original)
+		v.Action, v.Text = ActionMask, string(original)
+		v.Rules = append(v.Rules, r.Name)
+	}
+	return v, nil
+}
+
+// maskMatches 把命中内容替换为*。匹配在折叠后的文本上进行，折叠逐字符对应，
+// 按相同的字符位置屏蔽原文，保留发送方的全角标点
+func maskMatches(re *regexp.Regexp, text string, original []rune) (string, []rune) {
+	folded := []rune(text)
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		start := utf8.RuneCountInString(text[:loc[0]])
+		end := start + utf8.RuneCountInString(text[loc[0]:loc[1]])
+		for i := start; i < end; i++ {
+			folded[i], original[i] = '*', '*'
+		}
+	}
+	return string(folded), original
+}
+
+// foldWidth 全角字母数字和符号转为半角，避免用全角字符绕过规则
+func foldWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '！' && r <= '～':
+			return r - 0xFEE0
+		case r == '　':
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+var chineseDigits = map[rune]rune{
+	'〇': '0', '零': '0', '一': '1', '二': '2', '三': '3', '四': '4', '五': '5', '六': '6', '七': '7', '八': '8', '九': '9',
+	'壹': '1', '贰': '2', '叁': '3', '肆': '4', '伍': '5', '陆': '6', '柒': '7', '捌': '8', '玖': '9',
+}
+
+func isDigitSeparator(r rune) bool {
+	return strings.ContainsRune(" \t-_.·,，、/|()（）+", r)
+}
+
+// containsPhone 识别手机号。连续数字直接判断；被分隔符拆开、每段不超过4位的数字段拼接后判断，
+// 这样“138 1234 5678”能识别，而“13000-18000”这类薪资范围不会被拼接
+func containsPhone(text string) bool {
+	var runs []string
+	var cur []rune
+	seps := 0
+	closeRun := func() {
+		if len(cur) > 0 {
+			runs = append(runs, string(cur))
+			cur = nil
+		}
+	}
+	found := false
+	endChain := func() {
+		closeRun()
+		short := len(runs) > 1
+		for _, run := range runs {
+			if phonePattern.MatchString(run) {
+				found = true
+			}
+			if len(run) > 4 {
+				short = false
+			}
+		}
+		if short && phonePattern.MatchString(strings.Join(runs, "")) {
+			found = true
+		}
+		runs = nil
+	}
+
+	for _, r := range text {
+		if d, ok := chineseDigits[r]; ok {
+			r = d
+		}
+		switch {
+		case r >= '0' && r <= '9':
+			if len(cur) == 0 && seps > 2 {
+				endChain()
+			}
+			cur = append(cur, r)
+			seps = 0
+		case isDigitSeparator(r) && (len(cur) > 0 || len(runs) > 0):
+			closeRun()
+			seps++
+		default:
+			endChain()
+			seps = 0
+		}
+	}
+	endChain()
+	return found
+}
@@ -0,0 +1,202 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	presenceKeyFormat = "chat:presence:%d" // 哈希：实例ID -> 该实例上用户的连接数
+	instanceKeyFormat = "chat:instance:%s" // 实例存活标记，实例宕机后过期，其上的连接不再计入在线
+	presenceKeyTTL    = 24 * time.Hour
+)
+
+// Presence 记录用户在线状态。用户可在多个设备、多个实例上同时连接，所有连接断开才算离线
+type Presence interface {
+	// Connect 记录一个新连接，用户由离线变为在线时返回true
+	Connect(ctx context.Context, userID uint) (bool, error)
+	// Disconnect 移除一个连接，用户所有连接都已断开时返回true
+	Disconnect(ctx context.Context, userID uint) (bool, error)
+	Online(ctx context.Context, userIDs []uint) (map[uint]bool, error)
+	// Heartbeat 续期本实例及本实例上用户的在线记录
+	Heartbeat(ctx context.Context, userIDs []uint) error
+}
+
+// MemoryPresence 进程内在线状态，用于单实例部署和测试
+type MemoryPresence struct {
+	mu    sync.Mutex
+	conns map[uint]int
+}
+
+// NewMemoryPresence 创建进程内在线状态
+func NewMemoryPresence() *MemoryPresence {
+	return &MemoryPresence{conns: make(map[uint]int)}
+}
+
+func (p *MemoryPresence) Connect(ctx context.Context, userID uint) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conns[userID]++
+	return p.conns[userID] == 1, nil
+}
+
+func (p *MemoryPresence) Disconnect(ctx context.Context, userID uint) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conns[userID] <= 1 {
+		delete(p.conns, userID)
+		return true, nil
+	}
+	p.conns[userID]--
+	return false, nil
+}
+
+func (p *MemoryPresence) Online(ctx context.Context, userIDs []uint) (map[uint]bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	online := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		online[id] = p.conns[id] > 0
+	}
+	return online, nil
+}
+
+func (p *MemoryPresence) Heartbeat(ctx context.Context, userIDs []uint) error {
+	return nil
+}
+
+// RedisPresence 基于Redis的在线状态，各实例分别记录自己的连接数，
+// 实例存活标记过期后其连接数视为无效，避免实例宕机后用户一直显示在线
+type RedisPresence struct {
+	client   *redis.Client
+	instance string
+	ttl      time.Duration
+}
+
+// NewRedisPresence 创建Redis在线状态，ttl为实例存活标记的有效期，应大于心跳间隔
+func NewRedisPresence(client *redis.Client, instance string, ttl time.Duration) *RedisPresence {
+	return &RedisPresence{client: client, instance: instance, ttl: ttl}
+}
+
+func (p *RedisPresence) Connect(ctx context.Context, userID uint) (bool, error) {
+	key := fmt.Sprintf(presenceKeyFormat, userID)
+	pipe := p.client.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf(instanceKeyFormat, p.instance), 1, p.ttl)
+	incr := pipe.HIncrBy(ctx, key, p.instance, 1)
+	pipe.Expire(ctx, key, presenceKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	if incr.Val() > 1 {
+		return false, nil
+	}
+	total, err := p.connections(ctx, userID)
+	return total == 1, err
+}
+
+func (p *RedisPresence) Disconnect(ctx context.Context, userID uint) (bool, error) {
+	key := fmt.Sprintf(presenceKeyFormat, userID)
+	n, err := p.client.HIncrBy(ctx, key, p.instance, -1).Result()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, nil
+	}
+	if err := p.client.HDel(ctx, key, p.instance).Err(); err != nil {
+		return false, err
+	}
+	total, err := p.connections(ctx, userID)
+	return total == 0, err
+}
+
+func (p *RedisPresence) Online(ctx context.Context, userIDs []uint) (map[uint]bool, error) {
+	online := make(map[uint]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return online, nil
+	}
+	pipe := p.client.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(userIDs))
+	for i, id := range userIDs {
+		cmds[i] = pipe.HGetAll(ctx, fmt.Sprintf(presenceKeyFormat, id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	alive, err := p.aliveInstances(ctx, cmds)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range userIDs {
+		online[id] = countAlive(cmds[i].Val(), alive) > 0
+	}
+	return online, nil
+}
+
+func (p *RedisPresence) Heartbeat(ctx context.Context, userIDs []uint) error {
+	pipe := p.client.Pipeline()
+	pipe.Set(ctx, fmt.Sprintf(instanceKeyFormat, p.instance), 1, p.ttl)
+	for _, id := range userIDs {
+		pipe.Expire(ctx, fmt.Sprintf(presenceKeyFormat, id), presenceKeyTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// connections 用户在存活实例上的连接总数
+func (p *RedisPresence) connections(ctx context.Context, userID uint) (int, error) {
+	cmd := p.client.HGetAll(ctx, fmt.Sprintf(presenceKeyFormat, userID))
+	if err := cmd.Err(); err != nil {
+		return 0, err
+	}
+	alive, err := p.aliveInstances(ctx, []*redis.StringStringMapCmd{cmd})
+	if err != nil {
+		return 0, err
+	}
+	return countAlive(cmd.Val(), alive), nil
+}
+
+// aliveInstances 检查在线记录中出现的实例是否存活
+func (p *RedisPresence) aliveInstances(ctx context.Context, cmds []*redis.StringStringMapCmd) (map[string]bool, error) {
+	var instances []string
+	seen := make(map[string]bool)
+	for _, cmd := range cmds {
+		for instance := range cmd.Val() {
+			if !seen[instance] {
+				seen[instance] = true
+				instances = append(instances, instance)
+			}
+		}
+	}
+	alive := make(map[string]bool, len(instances))
+	if len(instances) == 0 {
+		return alive, nil
+	}
+	pipe := p.client.Pipeline()
+	exists := make([]*redis.IntCmd, len(instances))
+	for i, instance := range instances {
+		exists[i] = pipe.Exists(ctx, fmt.Sprintf(instanceKeyFormat, instance))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	for i, instance := range instances {
+		alive[instance] = exists[i].Val() > 0
+	}
+	return alive, nil
+}
+
+func countAlive(counts map[string]string, alive map[string]bool) int {
+	total := 0
+	for instance, v := range counts {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && alive[instance] {
+			total += n
+		}
+	}
+	return total
+}
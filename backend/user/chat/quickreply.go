@@ -0,0 +1,167 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrQuickReplyNotFound  = errors.New("quick reply not found")
+	ErrInvalidQuickReply   = errors.New("invalid quick reply")
+	ErrTooManyQuickReplies = errors.New("too many quick replies")
+	ErrNotCompanyRecruiter = errors.New("not a recruiter of the company")
+)
+
+const (
+	maxQuickReplies      = 50 // 每个企业的模板数上限
+	maxQuickReplyTitle   = 50
+	maxQuickReplyContent = 500
+)
+
+// QuickReply 常用语模板。CompanyID为0的是平台通用模板，否则为企业模板；Role为使用方角色
+type QuickReply struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CompanyID uint      `json:"company_id" gorm:"not null;index:idx_chat_quick_reply_scope,priority:1"`
+	Role      string    `json:"role" gorm:"size:20;not null;index:idx_chat_quick_reply_scope,priority:2"`
+	Title     string    `json:"title" gorm:"size:50"`
+	Content   string    `json:"content" gorm:"size:500;not null"`
+	Sort      int       `json:"sort" gorm:"default:0"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (QuickReply) TableName() string {
+	return "chat_quick_replies"
+}
+
+// DefaultQuickReplies 平台通用模板，平台模板为空时写入
+func DefaultQuickReplies() []QuickReply {
+	return []QuickReply{
+		{Role: RoleCandidate, Title: "打招呼", Content: "您好，我对这个职位很感兴趣，希望能有机会进一步沟通。", Sort: 1},
+		{Role: RoleCandidate, Title: "发送简历", Content: "您好，我的简历已发送，请您查阅，期待您的回复。", Sort: 2},
+		{Role: RoleCandidate, Title: "确认面试", Content: "收到面试邀请，我会准时参加，谢谢！", Sort: 3},
+		{Role: RoleCandidate, Title: "询问进度", Content: "您好，想了解一下我的申请目前的进展，谢谢！", Sort: 4},
+		{Role: RoleRecruiter, Title: "打招呼", Content: "您好，看了您的简历，觉得您与我们的职位比较匹配，方便聊聊吗？", Sort: 1},
+		{Role: RoleRecruiter, Title: "索要简历", Content: "您好，方便发一份您的最新简历吗？", Sort: 2},
+		{Role: RoleRecruiter, Title: "不合适", Content: "感谢您的关注，经过评估，您的经历与该职位暂不匹配，祝您早日找到理想的工作。", Sort: 3},
+	}
+}
+
+// SeedQuickReplies 平台通用模板为空时写入默认模板
+func (s *Store) SeedQuickReplies(ctx context.Context) error {
+	db := s.db.WithContext(ctx)
+	var count int64
+	if err := db.Model(&QuickReply{}).Where("company_id = 0").Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	defaults := DefaultQuickReplies()
+	return db.Create(&defaults).Error
+}
+
+// QuickReplies 某角色可用的常用语：企业模板在前，随后是平台通用模板
+func (s *Store) QuickReplies(ctx context.Context, companyID uint, role string) ([]QuickReply, error) {
+	var replies []QuickReply
+	err := s.db.WithContext(ctx).
+		Where("company_id IN ? AND role = ?", []uint{0, companyID}, role).
+		Order("company_id DESC, sort ASC, id ASC").Find(&replies).Error
+	return replies, err
+}
+
+// SessionCompany 会话所属职位的企业
+func (s *Store) SessionCompany(ctx context.Context, session *Session) (uint, error) {
+	var companyID uint
+	err := s.db.WithContext(ctx).Table("jobs").Select("company_id").Where("id = ?", session.JobID).Limit(1).Scan(&companyID).Error
+	return companyID, err
+}
+
+// IsCompanyRecruiter 用户是否为企业的有效成员，成员关系由企业服务维护在company_members表中
+func (s *Store) IsCompanyRecruiter(ctx context.Context, userID, companyID uint) (bool, error) {
+	if userID == 0 || companyID == 0 {
+		return false, nil
+	}
+	var count int64
+	err := s.db.WithContext(ctx).Table("company_members").
+		Where("company_id = ? AND user_id = ? AND status = ?", companyID, userID, "active").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SaveQuickReply 创建或更新企业的招聘方常用语，reply.ID为0时创建
+func (s *Store) SaveQuickReply(ctx context.Context, userID uint, reply *QuickReply) error {
+	reply.Title = strings.TrimSpace(reply.Title)
+	reply.Content = strings.TrimSpace(reply.Content)
+	if reply.Content == "" || utf8.RuneCountInString(reply.Title) > maxQuickReplyTitle ||
+		utf8.RuneCountInString(reply.Content) > maxQuickReplyContent {
+		return ErrInvalidQuickReply
+	}
+
+	db := s.db.WithContext(ctx)
+	if reply.ID != 0 {
+		existing, err := s.companyQuickReply(ctx, userID, reply.ID)
+		if err != nil {
+			return err
+		}
+		existing.Title, existing.Content, existing.Sort = reply.Title, reply.Content, reply.Sort
+		if err := db.Save(existing).Error; err != nil {
+			return err
+		}
+		*reply = *existing
+		return nil
+	}
+
+	ok, err := s.IsCompanyRecruiter(ctx, userID, reply.CompanyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotCompanyRecruiter
+	}
+	var count int64
+	if err := db.Model(&QuickReply{}).Where("company_id = ?", reply.CompanyID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= maxQuickReplies {
+		return ErrTooManyQuickReplies
+	}
+	reply.Role = RoleRecruiter
+	reply.CreatedBy = userID
+	return db.Create(reply).Error
+}
+
+// DeleteQuickReply 删除企业常用语
+func (s *Store) DeleteQuickReply(ctx context.Context, userID, id uint) error {
+	reply, err := s.companyQuickReply(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Delete(reply).Error
+}
+
+// companyQuickReply 读取企业常用语并校验用户是该企业的招聘方，平台模板不能修改
+func (s *Store) companyQuickReply(ctx context.Context, userID, id uint) (*QuickReply, error) {
+	var reply QuickReply
+	err := s.db.WithContext(ctx).Where("id = ? AND company_id <> 0", id).First(&reply).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrQuickReplyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.IsCompanyRecruiter(ctx, userID, reply.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotCompanyRecruiter
+	}
+	return &reply, nil
+}
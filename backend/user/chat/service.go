@@ -0,0 +1,221 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var errUnknownFrame = errors.New("unknown frame type")
+
+const (
+	ReadTimeout       = 75 * time.Second // 客户端需在此时间内发送任意帧（建议每30秒发送ping），否则断开
+	publishTimeout    = 5 * time.Second
+	maxCachedSessions = 256 // 单个连接缓存的会话数
+)
+
+// Conn WebSocket连接的读写，由接入层适配具体的WebSocket实现
+type Conn interface {
+	ReadFrame(frame *Frame) error
+	WriteEvent(ev Event) error
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+// Service 会话业务：持久化后通过Hub推送实时事件，HTTP接口与WebSocket共用
+type Service struct {
+	store     *Store
+	hub       *Hub
+	files     FileStore
+	moderator Moderator
+}
+
+// NewService 创建会话服务。files为nil时不支持附件，moderator为nil时不审核消息
+func NewService(store *Store, hub *Hub, files FileStore, moderator Moderator) *Service {
+	return &Service{store: store, hub: hub, files: files, moderator: moderator}
+}
+
+// Store 会话存储
+func (s *Service) Store() *Store {
+	return s.store
+}
+
+// Online 查询用户是否在线
+func (s *Service) Online(ctx context.Context, userIDs []uint) (map[uint]bool, error) {
+	return s.hub.Online(ctx, userIDs)
+}
+
+// Send 校验、审核并保存消息，推送给会话双方的所有连接；重复发送（ClientID相同）时不再推送
+func (s *Service) Send(ctx context.Context, session *Session, senderID uint, draft Draft) (*Message, error) {
+	message, err := s.compose(ctx, session, senderID, draft)
+	if err != nil {
+		return nil, err
+	}
+	message, created, err := s.store.SendMessage(ctx, session, message)
+	if err != nil || !created {
+		return message, err
+	}
+	s.publish(session.Participants(), Event{
+		Type: EventMessage, SessionID: session.ID, UserID: senderID,
+		MessageID: message.ID, ClientID: draft.ClientID, Message: message,
+	})
+	return message, nil
+}
+
+// MarkDelivered 推进送达位置并通知对方
+func (s *Service) MarkDelivered(ctx context.Context, session *Session, userID, messageID uint) error {
+	changed, err := s.store.MarkDelivered(ctx, session, userID, messageID)
+	if err == nil && changed {
+		s.publish([]uint{session.PeerOf(userID)}, Event{Type: EventDelivered, SessionID: session.ID, UserID: userID, MessageID: messageID})
+	}
+	return err
+}
+
+// MarkRead 推进已读位置并通知对方，同时通知自己的其他设备以同步未读数
+func (s *Service) MarkRead(ctx context.Context, session *Session, userID, messageID uint) error {
+	changed, err := s.store.MarkRead(ctx, session, userID, messageID)
+	if err == nil && changed {
+		s.publish(session.Participants(), Event{Type: EventRead, SessionID: session.ID, UserID: userID, MessageID: messageID})
+	}
+	return err
+}
+
+// Typing 通知对方正在输入，不持久化；客户端应在几秒内未收到新的输入事件时自动清除提示
+func (s *Service) Typing(session *Session, userID uint, typing bool) {
+	s.publish([]uint{session.PeerOf(userID)}, Event{Type: EventTyping, SessionID: session.ID, UserID: userID, Typing: &typing})
+}
+
+func (s *Service) publish(recipients []uint, ev Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := s.hub.Publish(ctx, recipients, ev); err != nil {
+		logrus.Warnf("chat: publish %s event for session %d failed: %v", ev.Type, ev.SessionID, err)
+	}
+}
+
+// Serve 处理一个已通过认证的WebSocket连接直至断开：登记在线状态并通知联系人（联系人当前是否在线由会话列表返回），
+// 转发推送事件，处理客户端发来的发送、输入、送达、已读和ping帧
+func (s *Service) Serve(ctx context.Context, userID uint, conn Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	client := NewClient(userID)
+	online, err := s.hub.Register(ctx, client)
+	if err != nil {
+		logrus.Warnf("chat: record presence for user %d failed: %v", userID, err)
+	}
+	peers, err := s.store.Peers(ctx, userID)
+	if err != nil {
+		logrus.Warnf("chat: load peers for user %d failed: %v", userID, err)
+	}
+	if online {
+		s.publishPresence(userID, peers, true)
+	}
+
+	go func() {
+		defer conn.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-client.Done():
+				return
+			case ev := <-client.Events():
+				if err := conn.WriteEvent(ev); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	sessions := make(map[uint]*Session)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(ReadTimeout)); err != nil {
+			break
+		}
+		var frame Frame
+		if err := conn.ReadFrame(&frame); err != nil {
+			break
+		}
+		s.handleFrame(ctx, client, sessions, frame)
+	}
+
+	cancel()
+	offline, err := s.hub.Unregister(context.Background(), client)
+	if err != nil {
+		logrus.Warnf("chat: clear presence for user %d failed: %v", userID, err)
+	}
+	if offline {
+		s.publishPresence(userID, peers, false)
+	}
+}
+
+func (s *Service) handleFrame(ctx context.Context, client *Client, sessions map[uint]*Session, frame Frame) {
+	if frame.Type == FramePing {
+		client.Deliver(Event{Type: EventPong, At: time.Now()})
+		return
+	}
+
+	session, err := s.cachedSession(ctx, sessions, frame.SessionID, client.UserID)
+	if err == nil {
+		switch frame.Type {
+		case FrameSend:
+			var message *Message
+			message, err = s.Send(ctx, session, client.UserID, frame.Draft)
+			if err == nil {
+				client.Deliver(Event{Type: EventAck, SessionID: session.ID, MessageID: message.ID, ClientID: frame.ClientID, Message: message, At: time.Now()})
+			}
+		case FrameTyping:
+			s.Typing(session, client.UserID, frame.Typing)
+		case FrameDelivered:
+			err = s.MarkDelivered(ctx, session, client.UserID, frame.MessageID)
+		case FrameRead:
+			err = s.MarkRead(ctx, session, client.UserID, frame.MessageID)
+		default:
+			err = errUnknownFrame
+		}
+	}
+	if err != nil {
+		client.Deliver(Event{Type: EventError, SessionID: frame.SessionID, ClientID: frame.ClientID, Error: publicError(err), At: time.Now()})
+	}
+}
+
+// cachedSession 连接内缓存已校验过成员身份的会话
+func (s *Service) cachedSession(ctx context.Context, sessions map[uint]*Session, sessionID, userID uint) (*Session, error) {
+	if session, ok := sessions[sessionID]; ok {
+		return session, nil
+	}
+	session, err := s.store.Session(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) >= maxCachedSessions {
+		for id := range sessions {
+			delete(sessions, id)
+			break
+		}
+	}
+	sessions[sessionID] = session
+	return session, nil
+}
+
+func (s *Service) publishPresence(userID uint, peers []uint, online bool) {
+	s.publish(peers, Event{Type: EventPresence, UserID: userID, Online: &online})
+}
+
+// publicError 可以返回给客户端的错误信息（业务错误及其说明），内部错误只记录日志
+func publicError(err error) string {
+	for _, known := range []error{
+		ErrSessionNotFound, ErrNotParticipant, ErrSessionClosed, ErrEmptyMessage,
+		ErrMessageTooLong, ErrUnsupportedType, ErrInvalidMessage, errUnknownFrame,
+		ErrMessageBlocked, ErrInvalidCard, ErrInvalidInvite, ErrRoleNotAllowed, ErrAttachmentNotFound,
+	} {
+		if errors.Is(err, known) {
+			return err.Error()
+		}
+	}
+	logrus.Errorf("chat: handle frame failed: %v", err)
+	return "internal error"
+}
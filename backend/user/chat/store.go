@@ -0,0 +1,403 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("chat session not found")
+	ErrNotParticipant      = errors.New("not a participant of the chat session")
+	ErrSessionClosed       = errors.New("chat session closed")
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrInvalidParticipants = errors.New("invalid chat participants")
+	ErrNotJobRecruiter     = errors.New("not a recruiter of the job's company")
+	ErrEmptyMessage        = errors.New("message content is empty")
+	ErrMessageTooLong      = errors.New("message content too long")
+	ErrUnsupportedType     = errors.New("unsupported message type")
+	ErrInvalidMessage      = errors.New("message not found in session")
+)
+
+const (
+	MaxMessageLength = 2000 // 单条消息最大字符数
+	maxPreviewLength = 60   // 会话列表中最近消息摘要的字符数
+	maxPeers         = 1000 // 推送在线状态的联系人上限
+)
+
+// 投递行为，与推荐服务统计投递的口径一致
+var applyActions = []string{"apply", "job_apply"}
+
+// Store 会话与消息的持久化
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore 创建会话存储
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// CreateSessionInput 创建会话参数。求职者发起时需指定招聘方；招聘方发起时需指定求职者，招聘方为调用者。
+// 招聘方必须是职位所属企业的有效成员
+type CreateSessionInput struct {
+	JobID       uint
+	CandidateID uint
+	RecruiterID uint
+	Title       string
+}
+
+// SessionSummary 会话列表项，包含调用者的角色、对方ID和未读数
+type SessionSummary struct {
+	Session
+	Role   string `json:"role"`
+	PeerID uint   `json:"peer_id"`
+	Unread int64  `json:"unread_count"`
+}
+
+// MessagePage 一页消息，按ID升序。NextCursor用于继续加载更早的消息
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+}
+
+// Participants 会话双方的用户ID
+func (s *Session) Participants() []uint {
+	return []uint{s.CandidateID, s.RecruiterID}
+}
+
+// PeerOf 会话中另一方的用户ID
+func (s *Session) PeerOf(userID uint) uint {
+	if userID == s.CandidateID {
+		return s.RecruiterID
+	}
+	return s.CandidateID
+}
+
+// HasParticipant 用户是否为会话成员
+func (s *Session) HasParticipant(userID uint) bool {
+	return userID != 0 && (userID == s.CandidateID || userID == s.RecruiterID)
+}
+
+// CreateSession 为求职者的职位投递创建会话，会话已存在时直接返回，created为false。
+// 招聘方必须是职位所属企业的有效成员，求职者必须投递过该职位，会话关联最近一次投递
+func (s *Store) CreateSession(ctx context.Context, callerID uint, in CreateSessionInput) (*Session, bool, error) {
+	if in.CandidateID == 0 {
+		in.CandidateID = callerID
+	} else if in.RecruiterID == 0 {
+		in.RecruiterID = callerID
+	}
+	if in.JobID == 0 || in.CandidateID == 0 || in.RecruiterID == 0 || in.CandidateID == in.RecruiterID ||
+		(callerID != in.CandidateID && callerID != in.RecruiterID) {
+		return nil, false, ErrInvalidParticipants
+	}
+
+	db := s.db.WithContext(ctx)
+	ok, err := s.isJobRecruiter(db, in.RecruiterID, in.JobID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		// 招聘方发起时调用者无权联系该职位的求职者；求职者发起时指定的招聘方不属于该职位
+		if callerID == in.RecruiterID {
+			return nil, false, ErrNotJobRecruiter
+		}
+		return nil, false, ErrInvalidParticipants
+	}
+
+	var application struct {
+		ID uint
+	}
+	err = db.Table("user_behaviors").Select("id").
+		Where("user_id = ? AND target_type = ? AND target_id = ? AND action IN ?", in.CandidateID, "job", in.JobID, applyActions).
+		Order("created_at DESC").Limit(1).Take(&application).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, ErrApplicationNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if session, err := s.findSession(db, application.ID, in.RecruiterID); err == nil {
+		return session, false, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	title := strings.TrimSpace(in.Title)
+	if title == "" {
+		db.Table("jobs").Select("title").Where("id = ?", in.JobID).Limit(1).Scan(&title)
+	}
+	now := time.Now()
+	session := &Session{
+		ApplicationID: application.ID,
+		JobID:         in.JobID,
+		CandidateID:   in.CandidateID,
+		RecruiterID:   in.RecruiterID,
+		Title:         truncate(title, 200),
+		Status:        SessionActive,
+		ActiveAt:      now,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		members := []Member{
+			{SessionID: session.ID, UserID: in.CandidateID, Role: RoleCandidate},
+			{SessionID: session.ID, UserID: in.RecruiterID, Role: RoleRecruiter},
+		}
+		return tx.Create(&members).Error
+	})
+	if err != nil {
+		// 并发创建时唯一索引冲突，返回对方创建的会话
+		if existing, findErr := s.findSession(db, application.ID, in.RecruiterID); findErr == nil {
+			return existing, false, nil
+		}
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
+// isJobRecruiter 用户是否为职位所属企业的有效成员，成员关系由企业服务维护在company_members表中
+func (s *Store) isJobRecruiter(db *gorm.DB, userID, jobID uint) (bool, error) {
+	var count int64
+	err := db.Table("company_members AS m").
+		Joins("JOIN jobs AS j ON j.company_id = m.company_id").
+		Where("j.id = ? AND m.user_id = ? AND m.status = ?", jobID, userID, "active").
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *Store) findSession(db *gorm.DB, applicationID, recruiterID uint) (*Session, error) {
+	var session Session
+	if err := db.Where("application_id = ? AND recruiter_id = ?", applicationID, recruiterID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Session 读取会话并校验用户是会话成员
+func (s *Store) Session(ctx context.Context, sessionID, userID uint) (*Session, error) {
+	var session Session
+	if err := s.db.WithContext(ctx).First(&session, sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if !session.HasParticipant(userID) {
+		return nil, ErrNotParticipant
+	}
+	return &session, nil
+}
+
+// ListSessions 用户的会话列表，按最近活跃时间倒序，cursor为上一页返回的游标
+func (s *Store) ListSessions(ctx context.Context, userID uint, cursor string, limit int) ([]SessionSummary, string, error) {
+	after, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	db := s.db.WithContext(ctx)
+	query := db.Table("chat_sessions AS s").
+		Select("s.*, m.role, m.last_read_id").
+		Joins("JOIN chat_members AS m ON m.session_id = s.id AND m.user_id = ?", userID)
+	if after.ID != 0 {
+		query = query.Where("s.active_at < ? OR (s.active_at = ? AND s.id < ?)", after.At, after.At, after.ID)
+	}
+	var rows []struct {
+		Session
+		Role       string
+		LastReadID uint
+	}
+	if err := query.Order("s.active_at DESC, s.id DESC").Limit(limit + 1).Scan(&rows).Error; err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		next = Cursor{At: last.ActiveAt, ID: last.ID}.Encode()
+	}
+	if len(rows) == 0 {
+		return []SessionSummary{}, "", nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	var counts []struct {
+		SessionID uint
+		Unread    int64
+	}
+	if err := db.Table("chat_messages AS msg").
+		Select("msg.session_id, COUNT(*) AS unread").
+		Joins("JOIN chat_members AS m ON m.session_id = msg.session_id AND m.user_id = ?", userID).
+		Where("msg.session_id IN ? AND msg.id > m.last_read_id AND msg.sender_id <> ?", ids, userID).
+		Group("msg.session_id").Scan(&counts).Error; err != nil {
+		return nil, "", err
+	}
+	unread := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		unread[c.SessionID] = c.Unread
+	}
+
+	summaries := make([]SessionSummary, len(rows))
+	for i, r := range rows {
+		summaries[i] = SessionSummary{Session: r.Session, Role: r.Role, PeerID: r.PeerOf(userID), Unread: unread[r.ID]}
+	}
+	return summaries, next, nil
+}
+
+// Messages 分页读取会话消息。afterID>0时读取该消息之后的新消息（断线重连补齐），
+// 否则从cursor位置（为空时从最新消息）向前读取更早的消息
+func (s *Store) Messages(ctx context.Context, sessionID uint, cursor string, afterID uint, limit int) (MessagePage, error) {
+	db := s.db.WithContext(ctx).Where("session_id = ?", sessionID)
+	var messages []Message
+
+	if afterID > 0 {
+		if err := db.Where("id > ?", afterID).Order("id ASC").Limit(limit + 1).Find(&messages).Error; err != nil {
+			return MessagePage{}, err
+		}
+		page := MessagePage{Messages: messages}
+		if len(messages) > limit {
+			page.Messages, page.HasMore = messages[:limit], true
+		}
+		return page, nil
+	}
+
+	before, err := DecodeCursor(cursor)
+	if err != nil {
+		return MessagePage{}, err
+	}
+	if before.ID != 0 {
+		db = db.Where("id < ?", before.ID)
+	}
+	if err := db.Order("id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return MessagePage{}, err
+	}
+	page := MessagePage{}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		page.HasMore = true
+		page.NextCursor = Cursor{ID: messages[limit-1].ID}.Encode()
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	page.Messages = messages
+	return page, nil
+}
+
+// SendMessage 保存消息并更新会话的最近消息。消息带ClientID且已发送过时返回已保存的消息，created为false
+func (s *Store) SendMessage(ctx context.Context, session *Session, message *Message) (*Message, bool, error) {
+	if session.Status == SessionClosed {
+		return nil, false, ErrSessionClosed
+	}
+
+	db := s.db.WithContext(ctx)
+	senderID := message.SenderID
+	if message.ClientID != nil {
+		var existing Message
+		err := db.Where("session_id = ? AND sender_id = ? AND client_id = ?", session.ID, senderID, *message.ClientID).First(&existing).Error
+		if err == nil {
+			return &existing, false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Session{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+			"last_message_id": message.ID,
+			"last_message":    truncate(message.Preview, maxPreviewLength),
+			"active_at":       message.CreatedAt,
+		}).Error; err != nil {
+			return err
+		}
+		// 自己发送的消息视为已送达、已读
+		return tx.Model(&Member{}).Where("session_id = ? AND user_id = ?", session.ID, senderID).
+			Updates(map[string]interface{}{"last_delivered_id": message.ID, "last_read_id": message.ID}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	session.LastMessageID = message.ID
+	session.ActiveAt = message.CreatedAt
+	return message, true, nil
+}
+
+// MarkDelivered 将用户在会话中的送达位置推进到messageID，位置未变化时返回false
+func (s *Store) MarkDelivered(ctx context.Context, session *Session, userID, messageID uint) (bool, error) {
+	if err := s.checkMessage(ctx, session, messageID); err != nil {
+		return false, err
+	}
+	result := s.db.WithContext(ctx).Model(&Member{}).
+		Where("session_id = ? AND user_id = ? AND last_delivered_id < ?", session.ID, userID, messageID).
+		Update("last_delivered_id", messageID)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkRead 将用户在会话中的已读位置推进到messageID，已读同时意味着已送达；位置未变化时返回false
+func (s *Store) MarkRead(ctx context.Context, session *Session, userID, messageID uint) (bool, error) {
+	if err := s.checkMessage(ctx, session, messageID); err != nil {
+		return false, err
+	}
+	result := s.db.WithContext(ctx).Model(&Member{}).
+		Where("session_id = ? AND user_id = ? AND last_read_id < ?", session.ID, userID, messageID).
+		Updates(map[string]interface{}{
+			"last_read_id":      messageID,
+			"last_delivered_id": gorm.Expr("GREATEST(last_delivered_id, ?)", messageID),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// checkMessage 回执位置必须是会话中已存在的消息
+func (s *Store) checkMessage(ctx context.Context, session *Session, messageID uint) error {
+	if messageID == 0 {
+		return ErrInvalidMessage
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&Message{}).Where("id = ? AND session_id = ?", messageID, session.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrInvalidMessage
+	}
+	return nil
+}
+
+// Receipts 会话各成员的送达、已读位置
+func (s *Store) Receipts(ctx context.Context, sessionID uint) ([]Member, error) {
+	var members []Member
+	err := s.db.WithContext(ctx).Where("session_id = ?", sessionID).Find(&members).Error
+	return members, err
+}
+
+// Peers 与用户有会话的其他用户，用于推送在线状态变化
+func (s *Store) Peers(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := s.db.WithContext(ctx).Table("chat_members AS me").
+		Joins("JOIN chat_members AS other ON other.session_id = me.session_id AND other.user_id <> me.user_id").
+		Where("me.user_id = ?", userID).
+		Distinct("other.user_id").Limit(maxPeers).Pluck("other.user_id", &ids).Error
+	return ids, err
+}
+
+// truncate 按字符截断
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
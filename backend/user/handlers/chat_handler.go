@@ -1,166 +1,129 @@
 package handlers
 
 import (
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 	"time"
 
+	"resume-centre/user/chat"
+
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultChatPageSize = 20
+	maxChatPageSize     = 100
+	maxFrameBytes       = 64 << 10 // 单个WebSocket帧的最大字节数
+	writeTimeout        = 10 * time.Second
 )
 
-type ChatHandler struct{}
+// 全局会话服务，由main在初始化Redis后设置
+var globalChat *chat.Service
+
+// SetGlobalChat 设置全局会话服务
+func SetGlobalChat(service *chat.Service) {
+	globalChat = service
+}
+
+// ChatHandler 求职者与招聘方的会话处理器
+type ChatHandler struct {
+	service *chat.Service
+}
 
+// NewChatHandler 创建会话处理器
 func NewChatHandler() *ChatHandler {
-	return &ChatHandler{}
+	return &ChatHandler{service: globalChat}
 }
 
-// GetChatSessions 获取聊天会话列表
+// GetChatSessions 获取聊天会话列表，按最近活跃时间倒序，cursor为上一页返回的next_cursor
 func (h *ChatHandler) GetChatSessions(c *gin.Context) {
-	// 模拟聊天会话数据
-	sessions := []map[string]interface{}{
-		{
-			"session_id":        "session_001",
-			"session_type":      "job_apply",
-			"title":             "前端开发工程师申请",
-			"description":       "关于前端开发工程师职位的申请沟通",
-			"participants":      []int{1, 2},
-			"last_message_id":   5,
-			"last_message_time": "2024-08-30T16:30:00Z",
-			"unread_count":      2,
-			"status":            "active",
-			"created_at":        "2024-08-30T10:00:00Z",
-		},
-		{
-			"session_id":        "session_002",
-			"session_type":      "hr_chat",
-			"title":             "HR面试沟通",
-			"description":       "与HR的面试安排沟通",
-			"participants":      []int{1, 3},
-			"last_message_id":   8,
-			"last_message_time": "2024-08-30T15:45:00Z",
-			"unread_count":      0,
-			"status":            "active",
-			"created_at":        "2024-08-30T09:00:00Z",
-		},
-		{
-			"session_id":        "session_003",
-			"session_type":      "system_notice",
-			"title":             "系统通知",
-			"description":       "系统重要通知",
-			"participants":      []int{1},
-			"last_message_id":   12,
-			"last_message_time": "2024-08-30T14:20:00Z",
-			"unread_count":      1,
-			"status":            "active",
-			"created_at":        "2024-08-30T08:00:00Z",
-		},
+	if !h.ready(c) {
+		return
+	}
+	userID := c.GetUint("userID")
+	sessions, next, err := h.service.Store().ListSessions(c.Request.Context(), userID, c.Query("cursor"), chatPageSize(c))
+	if err != nil {
+		chatError(c, err)
+		return
 	}
 
-	response := map[string]interface{}{
+	peers := make([]uint, len(sessions))
+	for i, s := range sessions {
+		peers[i] = s.PeerID
+	}
+	// 在线状态查询失败不影响会话列表
+	online, _ := h.service.Online(c.Request.Context(), peers)
+	items := make([]gin.H, len(sessions))
+	for i, s := range sessions {
+		items[i] = gin.H{"session": s, "peer_online": online[s.PeerID]}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data": map[string]interface{}{
-			"sessions": sessions,
-			"total":    len(sessions),
-			"version":  "v2",
-			"database": "v2",
+		"data": gin.H{
+			"sessions":    items,
+			"next_cursor": next,
+			"has_more":    next != "",
 		},
-	}
-
-	c.JSON(http.StatusOK, response)
+	})
 }
 
-// GetChatMessages 获取聊天消息
+// GetChatMessages 获取聊天消息。默认返回最新一页，cursor继续加载更早的消息；
+// after_id用于断线重连后补齐该消息之后的新消息
 func (h *ChatHandler) GetChatMessages(c *gin.Context) {
-	sessionID := c.Param("sessionId")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-
-	// 模拟聊天消息数据
-	messages := []map[string]interface{}{
-		{
-			"id":             1,
-			"session_id":     sessionID,
-			"sender_id":      2,
-			"sender_type":    "hr",
-			"message_type":   "text",
-			"content":        "您好，我们已经收到您的简历，请问您什么时候方便面试？",
-			"read_status":    map[string]string{"1": "2024-08-30T16:35:00Z"},
-			"created_at":     "2024-08-30T16:30:00Z",
-		},
-		{
-			"id":             2,
-			"session_id":     sessionID,
-			"sender_id":      1,
-			"sender_type":    "user",
-			"message_type":   "text",
-			"content":        "您好，我明天下午2点有空，可以安排面试吗？",
-			"read_status":    map[string]string{"2": "2024-08-30T16:32:00Z"},
-			"created_at":     "2024-08-30T16:31:00Z",
-		},
-		{
-			"id":             3,
-			"session_id":     sessionID,
-			"sender_id":      2,
-			"sender_type":    "hr",
-			"message_type":   "text",
-			"content":        "好的，明天下午2点面试，请准时参加。面试地点：公司大楼3楼会议室",
-			"read_status":    map[string]string{},
-			"created_at":     "2024-08-30T16:33:00Z",
-		},
-		{
-			"id":             4,
-			"session_id":     sessionID,
-			"sender_id":      1,
-			"sender_type":    "user",
-			"message_type":   "text",
-			"content":        "收到，谢谢！我会准时到达的。",
-			"read_status":    map[string]string{},
-			"created_at":     "2024-08-30T16:34:00Z",
-		},
-		{
-			"id":             5,
-			"session_id":     sessionID,
-			"sender_id":      2,
-			"sender_type":    "hr",
-			"message_type":   "job_card",
-			"content":        "这是您申请的职位详情",
-			"job_id":         1,
-			"read_status":    map[string]string{},
-			"created_at":     "2024-08-30T16:35:00Z",
-		},
+	if !h.ready(c) {
+		return
+	}
+	userID := c.GetUint("userID")
+	session, ok := h.session(c, userID)
+	if !ok {
+		return
+	}
+	afterID, _ := strconv.ParseUint(c.Query("after_id"), 10, 64)
+
+	ctx := c.Request.Context()
+	page, err := h.service.Store().Messages(ctx, session.ID, c.Query("cursor"), uint(afterID), chatPageSize(c))
+	if err != nil {
+		chatError(c, err)
+		return
+	}
+	receipts, err := h.service.Store().Receipts(ctx, session.ID)
+	if err != nil {
+		chatError(c, err)
+		return
+	}
+	var peerDelivered, peerRead uint
+	for _, m := range receipts {
+		if m.UserID == session.PeerOf(userID) {
+			peerDelivered, peerRead = m.LastDeliveredID, m.LastReadID
+		}
 	}
 
-	response := map[string]interface{}{
+	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "success",
-		"data": map[string]interface{}{
-			"messages": messages,
-			"total":    len(messages),
-			"page":     page,
-			"limit":    limit,
-			"session_id": sessionID,
-			"version":  "v2",
-			"database": "v2",
+		"data": gin.H{
+			"session_id":             session.ID,
+			"messages":               page.Messages,
+			"next_cursor":            page.NextCursor,
+			"has_more":               page.HasMore,
+			"peer_last_delivered_id": peerDelivered,
+			"peer_last_read_id":      peerRead,
 		},
-	}
-
-	c.JSON(http.StatusOK, response)
+	})
 }
 
-// SendMessage 发送消息
+// SendMessage 发送消息，message_type为text、image、file、resume_card、job_card或interview_invite，
+// client_id用于重试时去重
 func (h *ChatHandler) SendMessage(c *gin.Context) {
-	sessionID := c.Param("sessionId")
-	
-	var request struct {
-		Content       string `json:"content" binding:"required"`
-		MessageType   string `json:"message_type" binding:"required"`
-		ReplyToID     *int   `json:"reply_to_id"`
-		JobID         *int   `json:"job_id"`
-		ResumeID      *int   `json:"resume_id"`
-		AttachmentURL string `json:"attachment_url"`
+	if !h.ready(c) {
+		return
 	}
-
+	var request chat.Draft
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400,
@@ -170,65 +133,69 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// 模拟发送消息
-	message := map[string]interface{}{
-		"id":             6,
-		"session_id":     sessionID,
-		"sender_id":      1,
-		"sender_type":    "user",
-		"message_type":   request.MessageType,
-		"content":        request.Content,
-		"read_status":    map[string]string{},
-		"reply_to_id":    request.ReplyToID,
-		"job_id":         request.JobID,
-		"resume_id":      request.ResumeID,
-		"attachment_url": request.AttachmentURL,
-		"created_at":     time.Now().Format("2006-01-02T15:04:05Z"),
+	userID := c.GetUint("userID")
+	session, ok := h.session(c, userID)
+	if !ok {
+		return
+	}
+	message, err := h.service.Send(c.Request.Context(), session, userID, request)
+	if err != nil {
+		chatError(c, err)
+		return
 	}
 
-	response := map[string]interface{}{
+	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "Message sent successfully",
-		"data": map[string]interface{}{
-			"message":   message,
-			"session_id": sessionID,
-			"version":   "v2",
-			"database":  "v2",
+		"data": gin.H{
+			"message":    message,
+			"session_id": session.ID,
 		},
-	}
-
-	c.JSON(http.StatusOK, response)
+	})
 }
 
-// MarkMessageRead 标记消息已读
+// MarkMessageRead 将会话中该消息及之前的消息标记为已读
 func (h *ChatHandler) MarkMessageRead(c *gin.Context) {
-	sessionID := c.Param("sessionId")
-	messageID := c.Param("messageId")
+	if !h.ready(c) {
+		return
+	}
+	messageID, err := strconv.ParseUint(c.Param("messageId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid message ID"})
+		return
+	}
+	userID := c.GetUint("userID")
+	session, ok := h.session(c, userID)
+	if !ok {
+		return
+	}
+	if err := h.service.MarkRead(c.Request.Context(), session, userID, uint(messageID)); err != nil {
+		chatError(c, err)
+		return
+	}
 
-	response := map[string]interface{}{
+	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "Message marked as read",
-		"data": map[string]interface{}{
-			"session_id": sessionID,
+		"data": gin.H{
+			"session_id": session.ID,
 			"message_id": messageID,
-			"read_time":  time.Now().Format("2006-01-02T15:04:05Z"),
-			"version":    "v2",
-			"database":   "v2",
 		},
-	}
-
-	c.JSON(http.StatusOK, response)
+	})
 }
 
-// CreateChatSession 创建聊天会话
+// CreateChatSession 为职位投递创建会话。求职者发起时指定recruiter_id，招聘方发起时指定candidate_id；
+// 会话已存在时返回已有会话
 func (h *ChatHandler) CreateChatSession(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
 	var request struct {
-		SessionType  string   `json:"session_type" binding:"required"`
-		Title        string   `json:"title" binding:"required"`
-		Description  string   `json:"description"`
-		Participants []int    `json:"participants" binding:"required"`
+		JobID       uint   `json:"job_id" binding:"required"`
+		CandidateID uint   `json:"candidate_id"`
+		RecruiterID uint   `json:"recruiter_id"`
+		Title       string `json:"title"`
 	}
-
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400,
@@ -238,29 +205,311 @@ func (h *ChatHandler) CreateChatSession(c *gin.Context) {
 		return
 	}
 
-	// 模拟创建会话
-	session := map[string]interface{}{
-		"session_id":        "session_" + strconv.FormatInt(time.Now().Unix(), 10),
-		"session_type":      request.SessionType,
-		"title":             request.Title,
-		"description":       request.Description,
-		"participants":      request.Participants,
-		"last_message_id":   nil,
-		"last_message_time": nil,
-		"unread_count":      0,
-		"status":            "active",
-		"created_at":        time.Now().Format("2006-01-02T15:04:05Z"),
+	session, created, err := h.service.Store().CreateSession(c.Request.Context(), c.GetUint("userID"), chat.CreateSessionInput{
+		JobID:       request.JobID,
+		CandidateID: request.CandidateID,
+		RecruiterID: request.RecruiterID,
+		Title:       request.Title,
+	})
+	if err != nil {
+		chatError(c, err)
+		return
 	}
 
-	response := map[string]interface{}{
+	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "Chat session created successfully",
-		"data": map[string]interface{}{
-			"session":  session,
-			"version":  "v2",
-			"database": "v2",
+		"data": gin.H{
+			"session": session,
+			"created": created,
 		},
+	})
+}
+
+// UploadAttachment 上传会话附件（multipart字段file），返回的附件ID用于发送图片或文件消息
+func (h *ChatHandler) UploadAttachment(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+	userID := c.GetUint("userID")
+	session, ok := h.session(c, userID)
+	if !ok {
+		return
+	}
+	// 限制请求体大小，避免超大文件在校验前被整体写入临时文件
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, chat.MaxAttachmentSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Missing file"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		chatError(c, err)
+		return
 	}
+	defer file.Close()
 
-	c.JSON(http.StatusOK, response)
+	attachment, err := h.service.UploadAttachment(c.Request.Context(), session, userID, header.Filename, header.Size, file)
+	if err != nil {
+		chatError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Attachment uploaded successfully",
+		"data":    gin.H{"attachment": attachment},
+	})
+}
+
+// DownloadAttachment 下载会话附件，仅会话成员可访问
+func (h *ChatHandler) DownloadAttachment(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid attachment ID"})
+		return
+	}
+	session, ok := h.session(c, c.GetUint("userID"))
+	if !ok {
+		return
+	}
+	attachment, body, err := h.service.OpenAttachment(c.Request.Context(), session, uint(attachmentID))
+	if err != nil {
+		chatError(c, err)
+		return
+	}
+	defer body.Close()
+
+	disposition := "attachment"
+	if attachment.Kind == chat.KindImage {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Name}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// GetQuickReplies 获取常用语。指定session_id时按当前用户在会话中的角色返回该职位企业的模板和平台模板；
+// 指定company_id时返回企业的招聘方模板（用于管理）；都不指定时返回求职者的平台模板
+func (h *ChatHandler) GetQuickReplies(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	store := h.service.Store()
+	userID := c.GetUint("userID")
+	role, companyID := chat.RoleCandidate, uint(0)
+
+	if raw := c.Query("session_id"); raw != "" {
+		sessionID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid session ID"})
+			return
+		}
+		session, err := store.Session(ctx, uint(sessionID), userID)
+		if err != nil {
+			chatError(c, err)
+			return
+		}
+		if userID == session.RecruiterID {
+			role = chat.RoleRecruiter
+			if companyID, err = store.SessionCompany(ctx, session); err != nil {
+				chatError(c, err)
+				return
+			}
+		}
+	} else if raw := c.Query("company_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid company ID"})
+			return
+		}
+		ok, err := store.IsCompanyRecruiter(ctx, userID, uint(id))
+		if err != nil {
+			chatError(c, err)
+			return
+		}
+		if !ok {
+			chatError(c, chat.ErrNotCompanyRecruiter)
+			return
+		}
+		role, companyID = chat.RoleRecruiter, uint(id)
+	}
+
+	replies, err := store.QuickReplies(ctx, companyID, role)
+	if err != nil {
+		chatError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    gin.H{"quick_replies": replies, "role": role, "company_id": companyID},
+	})
+}
+
+// SaveQuickReply 创建（POST）或更新（PUT /:id）企业的招聘方常用语
+func (h *ChatHandler) SaveQuickReply(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+	var request struct {
+		CompanyID uint   `json:"company_id"`
+		Title     string `json:"title"`
+		Content   string `json:"content" binding:"required"`
+		Sort      int    `json:"sort"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	reply := &chat.QuickReply{CompanyID: request.CompanyID, Title: request.Title, Content: request.Content, Sort: request.Sort}
+	if raw := c.Param("id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid quick reply ID"})
+			return
+		}
+		reply.ID = uint(id)
+	}
+	if err := h.service.Store().SaveQuickReply(c.Request.Context(), c.GetUint("userID"), reply); err != nil {
+		chatError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Quick reply saved successfully",
+		"data":    gin.H{"quick_reply": reply},
+	})
+}
+
+// DeleteQuickReply 删除企业的招聘方常用语
+func (h *ChatHandler) DeleteQuickReply(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid quick reply ID"})
+		return
+	}
+	if err := h.service.Store().DeleteQuickReply(c.Request.Context(), c.GetUint("userID"), uint(id)); err != nil {
+		chatError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Quick reply deleted successfully",
+		"data":    gin.H{"id": id},
+	})
+}
+
+// ServeWebSocket 建立实时会话连接。浏览器无法为WebSocket设置请求头，token可通过查询参数传递；
+// 认证基于token而非Cookie，因此不校验Origin
+func (h *ChatHandler) ServeWebSocket(c *gin.Context) {
+	if !h.ready(c) {
+		return
+	}
+	userID := c.GetUint("userID")
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = maxFrameBytes
+			h.service.Serve(ws.Request().Context(), userID, wsConn{ws})
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// wsConn 将x/net/websocket连接适配为chat.Conn
+type wsConn struct {
+	*websocket.Conn
+}
+
+func (w wsConn) ReadFrame(frame *chat.Frame) error {
+	return websocket.JSON.Receive(w.Conn, frame)
+}
+
+func (w wsConn) WriteEvent(ev chat.Event) error {
+	if err := w.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(w.Conn, ev)
+}
+
+// ready 会话服务依赖Redis，未初始化时返回503
+func (h *ChatHandler) ready(c *gin.Context) bool {
+	if h.service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "message": "Chat service unavailable"})
+		return false
+	}
+	return true
+}
+
+// session 读取路径中的会话并校验当前用户是会话成员
+func (h *ChatHandler) session(c *gin.Context, userID uint) (*chat.Session, bool) {
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Invalid session ID"})
+		return nil, false
+	}
+	session, err := h.service.Store().Session(c.Request.Context(), uint(sessionID), userID)
+	if err != nil {
+		chatError(c, err)
+		return nil, false
+	}
+	return session, true
+}
+
+func chatPageSize(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultChatPageSize
+	}
+	if limit > maxChatPageSize {
+		return maxChatPageSize
+	}
+	return limit
+}
+
+// chatError 将会话错误映射为响应状态码
+func chatError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, chat.ErrSessionNotFound), errors.Is(err, chat.ErrApplicationNotFound),
+		errors.Is(err, chat.ErrAttachmentNotFound), errors.Is(err, chat.ErrQuickReplyNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, chat.ErrNotParticipant), errors.Is(err, chat.ErrRoleNotAllowed),
+		errors.Is(err, chat.ErrNotCompanyRecruiter), errors.Is(err, chat.ErrNotJobRecruiter):
+		status = http.StatusForbidden
+	case errors.Is(err, chat.ErrSessionClosed), errors.Is(err, chat.ErrTooManyQuickReplies):
+		status = http.StatusConflict
+	case errors.Is(err, chat.ErrMessageBlocked):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, chat.ErrAttachmentTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, chat.ErrAttachmentType):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, chat.ErrAttachmentsUnavailable):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, chat.ErrInvalidCursor), errors.Is(err, chat.ErrInvalidParticipants),
+		errors.Is(err, chat.ErrEmptyMessage), errors.Is(err, chat.ErrMessageTooLong),
+		errors.Is(err, chat.ErrUnsupportedType), errors.Is(err, chat.ErrInvalidMessage),
+		errors.Is(err, chat.ErrInvalidCard), errors.Is(err, chat.ErrInvalidInvite),
+		errors.Is(err, chat.ErrInvalidQuickReply):
+		status = http.StatusBadRequest
+	}
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		message = "Internal server error"
+	}
+	c.JSON(status, gin.H{"code": status, "message": message})
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"resume-centre/common/skills"
 	"resume-centre/user/models"
 
 	"github.com/gin-gonic/gin"
@@ -39,6 +40,9 @@ func SetGlobalDB(db *gorm.DB) {
 	globalDB = db
 }
 
+// jobListOrder 置顶未到期的职位排在最前（置顶记录由企业服务写入job_pins），其次按优先级和发布时间
+const jobListOrder = "EXISTS (SELECT 1 FROM job_pins p WHERE p.job_id = jobs.id AND p.pinned_until > NOW()) DESC, priority DESC, created_at DESC"
+
 // GetJobsV2 获取职位列表（新版本）
 func (h *JobHandler) GetJobsV2(c *gin.Context) {
 	// 获取查询参数
@@ -53,7 +57,7 @@ func (h *JobHandler) GetJobsV2(c *gin.Context) {
 	var jobs []models.Job
 	query := h.db.Preload("Company").Preload("Category").Where("status = ?", "published")
 
-	if err := query.Limit(limit).Order("priority DESC, created_at DESC").Find(&jobs).Error; err != nil {
+	if err := query.Limit(limit).Order(jobListOrder).Find(&jobs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
 			"message": "Failed to fetch jobs",
@@ -150,12 +154,24 @@ func (h *JobHandler) SearchJobsV2(c *gin.Context) {
 	keyword := c.Query("keyword")
 	location := c.Query("location")
 	experience := c.Query("experience")
+	skill := c.Query("skill")
 
 	// 构建查询条件
 	query := h.db.Preload("Company").Preload("Category").Where("status = ?", "published")
 
 	if keyword != "" {
-		query = query.Where("title LIKE ? OR description LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+		// 关键词是已知技能时（含别名、拼音和拼写错误）同时匹配标准化后的技能字段
+		if m, ok := skills.Default().Match(keyword); ok {
+			query = query.Where("title LIKE ? OR description LIKE ? OR JSON_SEARCH(skills, 'one', ?) IS NOT NULL",
+				"%"+keyword+"%", "%"+keyword+"%", m.Skill.Name)
+		} else {
+			query = query.Where("title LIKE ? OR description LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+		}
+	}
+
+	if skill != "" {
+		_, name := skills.Default().Resolve(skill)
+		query = query.Where("JSON_SEARCH(skills, 'one', ?) IS NOT NULL", name)
 	}
 
 	if location != "" {
@@ -168,7 +184,7 @@ func (h *JobHandler) SearchJobsV2(c *gin.Context) {
 
 	// 执行查询
 	var jobs []models.Job
-	if err := query.Order("priority DESC, created_at DESC").Find(&jobs).Error; err != nil {
+	if err := query.Order(jobListOrder).Find(&jobs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
 			"message": "Failed to search jobs",
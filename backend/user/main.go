@@ -31,6 +31,7 @@ import (
 	"time"
 
 	"resume-centre/shared/infrastructure"
+	"resume-centre/user/chat"
 	"resume-centre/user/handlers"
 
 	_ "resume-centre/user/docs" // 导入生成的swagger文档
@@ -85,6 +86,13 @@ func main() {
 		logger.Fatalf("Failed to init redis client: %v", err)
 	}
 
+	// 启动实时会话，事件经Redis在各实例间广播
+	chatCtx, stopChat := context.WithCancel(context.Background())
+	defer stopChat()
+	if err := initChat(chatCtx); err != nil {
+		logger.Fatalf("Failed to init chat: %v", err)
+	}
+
 	// 注册服务到Consul
 	if err := registerService(); err != nil {
 		logger.Fatalf("Failed to register service: %v", err)
@@ -115,6 +123,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Shutting down server...")
+	stopChat()
 
 	// 注销服务
 	if err := deregisterService(); err != nil {
@@ -148,6 +157,8 @@ func loadConfig() error {
 	viper.SetDefault("database.name", "jobfirst")
 	viper.SetDefault("database.user", "jobfirst")
 	viper.SetDefault("database.password", "jobfirst123")
+	viper.SetDefault("storage.service_url", "http://localhost:8088")
+	viper.SetDefault("internal.service_token", "jobfirst-internal")
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
@@ -218,7 +229,7 @@ func initDatabase() error {
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
 	// 自动迁移
-	if err := db.AutoMigrate(&User{}); err != nil {
+	if err := db.AutoMigrate(append([]interface{}{&User{}}, chat.Models()...)...); err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
@@ -268,6 +279,40 @@ func initRedisClient() error {
 	return nil
 }
 
+// initChat 创建会话服务并订阅其他实例的会话事件。审核规则读取chat.moderation.rules，未配置时使用默认规则
+func initChat(ctx context.Context) error {
+	rules := chat.DefaultRules()
+	if viper.IsSet("chat.moderation.rules") {
+		rules = nil
+		if err := viper.UnmarshalKey("chat.moderation.rules", &rules); err != nil {
+			return fmt.Errorf("failed to parse chat moderation rules: %v", err)
+		}
+	}
+	moderator, err := chat.NewRuleModerator(rules)
+	if err != nil {
+		return err
+	}
+
+	store := chat.NewStore(db)
+	if err := store.SeedQuickReplies(ctx); err != nil {
+		logger.Warnf("Failed to seed chat quick replies: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	instance := fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano())
+	hub := chat.NewHub(
+		chat.NewRedisBroker(redisClient),
+		chat.NewRedisPresence(redisClient, instance, 3*chat.HeartbeatInterval),
+	)
+	go func() {
+		if err := hub.Run(ctx); err != nil {
+			logger.Errorf("Chat hub stopped: %v", err)
+		}
+	}()
+	handlers.SetGlobalChat(chat.NewService(store, hub, newStorageClient(), moderator))
+	return nil
+}
+
 func registerService() error {
 	serviceAddress := "localhost"
 	serviceID := "user-service"
@@ -545,102 +590,21 @@ func setupRouter() *gin.Engine {
 		}
 
 		// 聊天系统相关API - 需要认证
-		chat := protectedAPI.Group("/chat")
+		chatAPI := protectedAPI.Group("/chat")
 		{
-			chat.GET("/sessions", func(c *gin.Context) {
-				version := c.GetHeader("API-Version")
-				if version == "v2" {
-					handler := handlers.NewChatHandler()
-					handler.GetChatSessions(c)
-				} else {
-					// 降级到v1
-					c.JSON(http.StatusOK, gin.H{
-						"code":    200,
-						"message": "success (v1 fallback)",
-						"data": []gin.H{
-							{
-								"id":     "session_001",
-								"title":  "前端开发工程师申请",
-								"type":   "job_apply",
-								"unread": 2,
-							},
-						},
-					})
-				}
-			})
-
-			chat.GET("/sessions/:sessionId/messages", func(c *gin.Context) {
-				version := c.GetHeader("API-Version")
-				if version == "v2" {
-					handler := handlers.NewChatHandler()
-					handler.GetChatMessages(c)
-				} else {
-					// 降级到v1
-					c.JSON(http.StatusOK, gin.H{
-						"code":    200,
-						"message": "success (v1 fallback)",
-						"data": []gin.H{
-							{
-								"id":      1,
-								"content": "您好，我们已经收到您的简历",
-								"sender":  "hr",
-								"time":    "2024-08-30T16:30:00Z",
-							},
-						},
-					})
-				}
-			})
-
-			chat.POST("/sessions/:sessionId/messages", func(c *gin.Context) {
-				version := c.GetHeader("API-Version")
-				if version == "v2" {
-					handler := handlers.NewChatHandler()
-					handler.SendMessage(c)
-				} else {
-					// 降级到v1
-					c.JSON(http.StatusOK, gin.H{
-						"code":    200,
-						"message": "Message sent (v1 fallback)",
-						"data": gin.H{
-							"id":      2,
-							"content": "消息发送成功",
-							"time":    "2024-08-30T16:35:00Z",
-						},
-					})
-				}
-			})
-
-			chat.PUT("/sessions/:sessionId/messages/:messageId/read", func(c *gin.Context) {
-				version := c.GetHeader("API-Version")
-				if version == "v2" {
-					handler := handlers.NewChatHandler()
-					handler.MarkMessageRead(c)
-				} else {
-					// 降级到v1
-					c.JSON(http.StatusOK, gin.H{
-						"code":    200,
-						"message": "Message marked as read (v1 fallback)",
-					})
-				}
-			})
-
-			chat.POST("/sessions", func(c *gin.Context) {
-				version := c.GetHeader("API-Version")
-				if version == "v2" {
-					handler := handlers.NewChatHandler()
-					handler.CreateChatSession(c)
-				} else {
-					// 降级到v1
-					c.JSON(http.StatusOK, gin.H{
-						"code":    200,
-						"message": "Session created (v1 fallback)",
-						"data": gin.H{
-							"session_id": "session_new",
-							"title":      "新会话",
-						},
-					})
-				}
-			})
+			chatHandler := handlers.NewChatHandler()
+			chatAPI.GET("/sessions", chatHandler.GetChatSessions)
+			chatAPI.POST("/sessions", chatHandler.CreateChatSession)
+			chatAPI.GET("/sessions/:sessionId/messages", chatHandler.GetChatMessages)
+			chatAPI.POST("/sessions/:sessionId/messages", chatHandler.SendMessage)
+			chatAPI.PUT("/sessions/:sessionId/messages/:messageId/read", chatHandler.MarkMessageRead)
+			chatAPI.POST("/sessions/:sessionId/attachments", chatHandler.UploadAttachment)
+			chatAPI.GET("/sessions/:sessionId/attachments/:attachmentId", chatHandler.DownloadAttachment)
+			chatAPI.GET("/quick-replies", chatHandler.GetQuickReplies)
+			chatAPI.POST("/quick-replies", chatHandler.SaveQuickReply)
+			chatAPI.PUT("/quick-replies/:id", chatHandler.SaveQuickReply)
+			chatAPI.DELETE("/quick-replies/:id", chatHandler.DeleteQuickReply)
+			chatAPI.GET("/ws", chatHandler.ServeWebSocket)
 		}
 
 		// 积分系统相关API - 需要认证
@@ -1157,13 +1121,21 @@ func setupRouter() *gin.Engine {
 			})
 
 			// 聊天相关API
+			// 常用语：求职者的平台通用模板
 			protected.GET("/chat/usual", func(c *gin.Context) {
+				replies, err := chat.NewStore(db).QuickReplies(c.Request.Context(), 0, chat.RoleCandidate)
+				if err != nil {
+					logger.Errorf("Failed to load quick replies: %v", err)
+					c.JSON(http.StatusInternalServerError, gin.H{
+						"code":    500,
+						"message": "获取常用语失败",
+					})
+					return
+				}
 				c.JSON(http.StatusOK, gin.H{
 					"code":    200,
 					"message": "success",
-					"data": []gin.H{
-						{"id": 1, "content": "您好，有什么可以帮助您的吗？", "time": "2024-08-30T16:30:00Z"},
-					},
+					"data":    replies,
 				})
 			})
 
@@ -1579,6 +1551,10 @@ func authMiddleware() gin.HandlerFunc {
 				token = token[7:]
 			}
 		}
+		// 浏览器无法为WebSocket握手设置请求头，允许通过查询参数传递token
+		if token == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			token = c.Query("token")
+		}
 
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
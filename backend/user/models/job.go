@@ -2,6 +2,10 @@ package models
 
 import (
 	"time"
+
+	"resume-centre/common/skills"
+
+	"gorm.io/gorm"
 )
 
 // Job 职位模型
@@ -76,6 +80,16 @@ type Category struct {
 	DeletedAt   *time.Time `json:"deleted_at" gorm:"index"`
 }
 
+// BeforeSave 写入前把技能要求标准化为技能词典中的展示名，保留权重和必备标记
+func (j *Job) BeforeSave(_ *gorm.DB) error {
+	normalized, err := skills.Default().NormalizeJSON(j.Skills)
+	if err != nil {
+		return err
+	}
+	j.Skills = normalized
+	return nil
+}
+
 // TableName 指定表名
 func (Job) TableName() string {
 	return "jobs"